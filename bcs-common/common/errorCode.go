@@ -173,6 +173,8 @@ const (
 	BcsErrMesosSchedResourceExistStr = "resource already exist"
 	BcsErrMesosSchedNotFound         = AdditionErrorCode + 202
 	BcsErrMesosSchedNotFoundStr      = "404 not found"
+	BcsErrMesosSchedQuotaExceeded    = AdditionErrorCode + 203
+	BcsErrMesosSchedQuotaExceededStr = "resource quota exceeded"
 
 	/*Common error code 1401 230~1401 259
 	bcs mesos driver module errno name is as a beginning to BcsErrMesosDriver*/
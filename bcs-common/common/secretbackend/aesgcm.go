@@ -0,0 +1,294 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package secretbackend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+)
+
+//KeySize size of master keys and data keys, AES-256
+const KeySize = 32
+
+//keyringFile format of keyring file:
+//{"primary": "k2", "keys": {"k1": "base64 key", "k2": "base64 key"}}
+type keyringFile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+//Keyring master keys of aesgcm backend, the file is reloaded when modified,
+//so keys can be rotated without restarting scheduler
+type Keyring struct {
+	sync.Mutex
+	file    string
+	modTime time.Time
+	primary string
+	keys    map[string][]byte
+}
+
+//NewKeyring create keyring of file
+func NewKeyring(file string) *Keyring {
+	return &Keyring{file: file}
+}
+
+func (k *Keyring) load() error {
+	info, err := os.Stat(k.file)
+	if err != nil {
+		return fmt.Errorf("stat keyring failed: %s", err.Error())
+	}
+	if k.keys != nil && info.ModTime().Equal(k.modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(k.file)
+	if err != nil {
+		return fmt.Errorf("read keyring failed: %s", err.Error())
+	}
+	ring := &keyringFile{}
+	if err := json.Unmarshal(data, ring); err != nil {
+		return fmt.Errorf("decode keyring failed: %s", err.Error())
+	}
+	keys := make(map[string][]byte)
+	for id, str := range ring.Keys {
+		key, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return fmt.Errorf("decode key %s failed: %s", id, err.Error())
+		}
+		if len(key) != KeySize {
+			return fmt.Errorf("key %s size %d is not %d", id, len(key), KeySize)
+		}
+		keys[id] = key
+	}
+	if _, ok := keys[ring.Primary]; !ok {
+		return fmt.Errorf("primary key %s not found in keyring", ring.Primary)
+	}
+	k.keys = keys
+	k.primary = ring.Primary
+	k.modTime = info.ModTime()
+	return nil
+}
+
+//Primary returns id and content of primary key
+func (k *Keyring) Primary() (string, []byte, error) {
+	k.Lock()
+	defer k.Unlock()
+	if err := k.load(); err != nil {
+		return "", nil, err
+	}
+	return k.primary, k.keys[k.primary], nil
+}
+
+//Key returns content of key id
+func (k *Keyring) Key(id string) ([]byte, error) {
+	k.Lock()
+	defer k.Unlock()
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("master key %s not found in keyring", id)
+	}
+	return key, nil
+}
+
+//GenerateKey adds a random key id into keyring file and makes it primary,
+//the keyring file is created if it does not exist
+func (k *Keyring) GenerateKey(id string) error {
+	k.Lock()
+	defer k.Unlock()
+	ring := &keyringFile{Keys: make(map[string]string)}
+	data, err := ioutil.ReadFile(k.file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read keyring failed: %s", err.Error())
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, ring); err != nil {
+			return fmt.Errorf("decode keyring failed: %s", err.Error())
+		}
+		if ring.Keys == nil {
+			ring.Keys = make(map[string]string)
+		}
+	}
+	if _, ok := ring.Keys[id]; ok {
+		return fmt.Errorf("key %s already exists in keyring", id)
+	}
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	ring.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	ring.Primary = id
+	data, err = json.MarshalIndent(ring, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := k.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write keyring failed: %s", err.Error())
+	}
+	if err := os.Rename(tmp, k.file); err != nil {
+		return fmt.Errorf("write keyring failed: %s", err.Error())
+	}
+	//force reload, mtime may not change within the same second
+	k.keys = nil
+	return nil
+}
+
+//AESGCMBackend keeps content encrypted in store with envelope encryption:
+//content is encrypted by a random data key, and the data key is encrypted by
+//the master key KeyID of keyring. Rotating master key only rewraps the data keys.
+type AESGCMBackend struct {
+	keyring *Keyring
+}
+
+//NewAESGCMBackend create aesgcm backend
+func NewAESGCMBackend(keyring *Keyring) *AESGCMBackend {
+	return &AESGCMBackend{keyring: keyring}
+}
+
+func seal(key, plaintext, additional []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, additional)), nil
+}
+
+func open(key []byte, ciphertext string, additional []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additional)
+}
+
+func (a *AESGCMBackend) dataKey(item *commtypes.SecretDataItem) ([]byte, error) {
+	master, err := a.keyring.Key(item.Backend.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := open(master, item.Backend.DataKey, []byte(item.Backend.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key with master key %s failed: %s", item.Backend.KeyID, err.Error())
+	}
+	return dek, nil
+}
+
+//Resolve decrypts content
+func (a *AESGCMBackend) Resolve(item *commtypes.SecretDataItem) (string, error) {
+	if item.Backend.DataKey == "" {
+		return "", fmt.Errorf("secret item is not encrypted")
+	}
+	dek, err := a.dataKey(item)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dek, item.Content, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt content failed: %s", err.Error())
+	}
+	return string(plaintext), nil
+}
+
+//Seal encrypts plaintext content with a new data key wrapped by primary master key,
+//items already encrypted are kept. Content can not be decrypted by the data key of item
+//is plaintext put back with the backend block by client, it is sealed with a new data key
+func (a *AESGCMBackend) Seal(item *commtypes.SecretDataItem) error {
+	if item.Backend.DataKey != "" {
+		dek, err := a.dataKey(item)
+		if err != nil {
+			return err
+		}
+		if _, err := open(dek, item.Content, nil); err == nil {
+			return nil
+		}
+	}
+	keyID, master, err := a.keyring.Primary()
+	if err != nil {
+		return err
+	}
+	dek := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return err
+	}
+	content, err := seal(dek, []byte(item.Content), nil)
+	if err != nil {
+		return err
+	}
+	wrapped, err := seal(master, dek, []byte(keyID))
+	if err != nil {
+		return err
+	}
+	item.Content = content
+	item.Backend.KeyID = keyID
+	item.Backend.DataKey = wrapped
+	return nil
+}
+
+//Rewrap encrypts the data key with primary master key if it is encrypted by an old one,
+//returns whether item is changed
+func (a *AESGCMBackend) Rewrap(item *commtypes.SecretDataItem) (bool, error) {
+	if item.Backend.DataKey == "" {
+		return true, a.Seal(item)
+	}
+	keyID, master, err := a.keyring.Primary()
+	if err != nil {
+		return false, err
+	}
+	if item.Backend.KeyID == keyID {
+		return false, nil
+	}
+	dek, err := a.dataKey(item)
+	if err != nil {
+		return false, err
+	}
+	wrapped, err := seal(master, dek, []byte(keyID))
+	if err != nil {
+		return false, err
+	}
+	item.Backend.KeyID = keyID
+	item.Backend.DataKey = wrapped
+	return true, nil
+}
@@ -0,0 +1,208 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package secretbackend resolves the content of BcsSecret items kept outside of scheduler store.
+//
+// Items without backend keep the base64 content in store as before. Items of vault and file backend
+// only keep a reference, the content is read when taskgroup launched. Items of aesgcm backend keep
+// the content encrypted by a data key, and the data key encrypted by a rotatable master key.
+package secretbackend
+
+import (
+	"fmt"
+	"sync"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+)
+
+//Backend keeps the content of secret items
+type Backend interface {
+	//Resolve returns the content of item in base64, the same as SecretDataItem.Content without backend
+	Resolve(item *commtypes.SecretDataItem) (string, error)
+	//Seal checks item before it is saved into store, plaintext content must not be kept in item
+	Seal(item *commtypes.SecretDataItem) error
+}
+
+//Config of secret backends
+type Config struct {
+	//VaultAddr address of vault, like https://127.0.0.1:8200
+	VaultAddr string
+	//VaultToken token to read vault kv
+	VaultToken string
+	//FileDir directory of secret files, for file backend
+	FileDir string
+	//KeyringFile master keys of aesgcm backend
+	KeyringFile string
+	//DefaultBackend the backend of items without backend, only aesgcm supported, empty means plaintext
+	DefaultBackend commtypes.SecretBackendType
+}
+
+//Manager dispatches secret items to their backends
+type Manager struct {
+	sync.RWMutex
+	backends       map[commtypes.SecretBackendType]Backend
+	defaultBackend commtypes.SecretBackendType
+}
+
+//NewManager create manager with the backends enabled in config
+func NewManager(conf *Config) (*Manager, error) {
+	m := &Manager{
+		backends: make(map[commtypes.SecretBackendType]Backend),
+	}
+	if conf.VaultAddr != "" {
+		m.Register(commtypes.SecretBackendVault, NewVaultBackend(conf.VaultAddr, conf.VaultToken))
+	}
+	if conf.FileDir != "" {
+		m.Register(commtypes.SecretBackendFile, NewFileBackend(conf.FileDir))
+	}
+	if conf.KeyringFile != "" {
+		m.Register(commtypes.SecretBackendAESGCM, NewAESGCMBackend(NewKeyring(conf.KeyringFile)))
+	}
+	if conf.DefaultBackend != "" {
+		if conf.DefaultBackend != commtypes.SecretBackendAESGCM {
+			return nil, fmt.Errorf("default secret backend %s is not supported", conf.DefaultBackend)
+		}
+		if _, ok := m.backends[conf.DefaultBackend]; !ok {
+			return nil, fmt.Errorf("default secret backend %s is not configured", conf.DefaultBackend)
+		}
+		m.defaultBackend = conf.DefaultBackend
+	}
+	return m, nil
+}
+
+//Register add backend of type, the old one is replaced
+func (m *Manager) Register(backendType commtypes.SecretBackendType, backend Backend) {
+	m.Lock()
+	defer m.Unlock()
+	m.backends[backendType] = backend
+}
+
+func (m *Manager) backend(backendType commtypes.SecretBackendType) (Backend, error) {
+	m.RLock()
+	defer m.RUnlock()
+	backend, ok := m.backends[backendType]
+	if !ok {
+		return nil, fmt.Errorf("secret backend %s is not configured", backendType)
+	}
+	return backend, nil
+}
+
+//Resolve returns the base64 content of item
+func (m *Manager) Resolve(item *commtypes.SecretDataItem) (string, error) {
+	if item.Backend == nil {
+		return item.Content, nil
+	}
+	backend, err := m.backend(item.Backend.Type)
+	if err != nil {
+		return "", err
+	}
+	return backend.Resolve(item)
+}
+
+//Seal prepares all items of secret before it is saved into store,
+//items without backend are moved into the default backend if it is set
+func (m *Manager) Seal(secret *commtypes.BcsSecret) error {
+	for key, item := range secret.Data {
+		if item.Backend == nil && m.defaultBackend != "" {
+			item.Backend = &commtypes.SecretBackend{Type: m.defaultBackend}
+		}
+		if item.Backend == nil {
+			continue
+		}
+		backend, err := m.backend(item.Backend.Type)
+		if err != nil {
+			return fmt.Errorf("secret item %s: %s", key, err.Error())
+		}
+		if err := backend.Seal(&item); err != nil {
+			return fmt.Errorf("secret item %s: %s", key, err.Error())
+		}
+		secret.Data[key] = item
+	}
+	return nil
+}
+
+//Reencrypt moves plaintext items into the default backend, and rewraps the data keys
+//of aesgcm items with the primary master key, returns whether secret is changed
+func (m *Manager) Reencrypt(secret *commtypes.BcsSecret) (bool, error) {
+	changed := false
+	for key, item := range secret.Data {
+		if item.Backend == nil {
+			if m.defaultBackend == "" {
+				continue
+			}
+			item.Backend = &commtypes.SecretBackend{Type: m.defaultBackend}
+		}
+		if item.Backend.Type != commtypes.SecretBackendAESGCM {
+			continue
+		}
+		backend, err := m.backend(item.Backend.Type)
+		if err != nil {
+			return changed, fmt.Errorf("secret item %s: %s", key, err.Error())
+		}
+		aesgcm, ok := backend.(*AESGCMBackend)
+		if !ok {
+			continue
+		}
+		rewrapped, err := aesgcm.Rewrap(&item)
+		if err != nil {
+			return changed, fmt.Errorf("secret item %s: %s", key, err.Error())
+		}
+		if rewrapped {
+			secret.Data[key] = item
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+//Equal compares the content of items, the content of aesgcm items is decrypted,
+//items referencing vault or file are equal if references are the same
+func (m *Manager) Equal(a, b *commtypes.SecretDataItem) bool {
+	if a.Backend != nil && b.Backend != nil &&
+		a.Backend.Type == commtypes.SecretBackendAESGCM && b.Backend.Type == commtypes.SecretBackendAESGCM {
+		contentA, errA := m.Resolve(a)
+		contentB, errB := m.Resolve(b)
+		return errA == nil && errB == nil && contentA == contentB
+	}
+	if (a.Backend == nil) != (b.Backend == nil) {
+		return false
+	}
+	if a.Backend != nil && *a.Backend != *b.Backend {
+		return false
+	}
+	return a.Content == b.Content
+}
+
+var (
+	defaultManager     = &Manager{backends: make(map[commtypes.SecretBackendType]Backend)}
+	defaultManagerLock sync.RWMutex
+)
+
+//Init create the default manager used by Default
+func Init(conf *Config) error {
+	m, err := NewManager(conf)
+	if err != nil {
+		return err
+	}
+	defaultManagerLock.Lock()
+	defaultManager = m
+	defaultManagerLock.Unlock()
+	return nil
+}
+
+//Default returns the default manager, no backend is configured before Init
+func Default() *Manager {
+	defaultManagerLock.RLock()
+	defer defaultManagerLock.RUnlock()
+	return defaultManager
+}
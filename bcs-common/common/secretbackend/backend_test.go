@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package secretbackend
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestAESGCMSealAndRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretbackend")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	keyring := NewKeyring(filepath.Join(dir, "keyring.json"))
+	assert.Nil(t, keyring.GenerateKey("k1"))
+
+	m, err := NewManager(&Config{KeyringFile: keyring.file, DefaultBackend: commtypes.SecretBackendAESGCM})
+	assert.Nil(t, err)
+	m.Register(commtypes.SecretBackendAESGCM, NewAESGCMBackend(keyring))
+
+	secret := &commtypes.BcsSecret{
+		Data: map[string]commtypes.SecretDataItem{
+			"password": {Content: b64("123456")},
+		},
+	}
+	assert.Nil(t, m.Seal(secret))
+	item := secret.Data["password"]
+	assert.NotNil(t, item.Backend)
+	assert.Equal(t, "k1", item.Backend.KeyID)
+	assert.NotEqual(t, b64("123456"), item.Content)
+	content, err := m.Resolve(&item)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("123456"), content)
+
+	//sealed item is kept when saved again
+	assert.Nil(t, m.Seal(secret))
+	assert.Equal(t, item.Content, secret.Data["password"].Content)
+
+	//plaintext put back with the backend block is sealed again
+	backend := *item.Backend
+	plain := &commtypes.BcsSecret{
+		Data: map[string]commtypes.SecretDataItem{
+			"password": {Content: b64("654321"), Backend: &backend},
+		},
+	}
+	assert.Nil(t, m.Seal(plain))
+	resealed := plain.Data["password"]
+	assert.NotEqual(t, b64("654321"), resealed.Content)
+	assert.NotEqual(t, item.Backend.DataKey, resealed.Backend.DataKey)
+	content, err = m.Resolve(&resealed)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("654321"), content)
+
+	changed, err := m.Reencrypt(secret)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	assert.Nil(t, keyring.GenerateKey("k2"))
+	changed, err = m.Reencrypt(secret)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	rotated := secret.Data["password"]
+	assert.Equal(t, "k2", rotated.Backend.KeyID)
+	assert.Equal(t, item.Content, rotated.Content)
+	content, err = m.Resolve(&rotated)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("123456"), content)
+	assert.True(t, m.Equal(&item, &rotated))
+
+	//tampered data key can not be decrypted
+	rotated.Backend.KeyID = "k1"
+	_, err = m.Resolve(&rotated)
+	assert.NotNil(t, err)
+}
+
+func TestFileBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretbackend")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "db"), []byte("secret"), 0600))
+
+	m, err := NewManager(&Config{FileDir: dir})
+	assert.Nil(t, err)
+	item := &commtypes.SecretDataItem{Backend: &commtypes.SecretBackend{Type: commtypes.SecretBackendFile, Path: "db"}}
+	content, err := m.Resolve(item)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("secret"), content)
+
+	item.Backend.Path = "../db"
+	_, err = m.Resolve(item)
+	assert.NotNil(t, err)
+
+	item.Backend.Path = "db"
+	item.Content = b64("secret")
+	assert.NotNil(t, m.Seal(&commtypes.BcsSecret{Data: map[string]commtypes.SecretDataItem{"db": *item}}))
+}
+
+func TestVaultBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/app":
+			fmt.Fprint(w, `{"data":{"password":"v1pass"}}`)
+		case "/v1/secret/data/app":
+			fmt.Fprint(w, `{"data":{"data":{"password":"v2pass"},"metadata":{"version":1}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	m, err := NewManager(&Config{VaultAddr: server.URL, VaultToken: "token"})
+	assert.Nil(t, err)
+	item := &commtypes.SecretDataItem{
+		Backend: &commtypes.SecretBackend{Type: commtypes.SecretBackendVault, Path: "secret/app", Key: "password"},
+	}
+	content, err := m.Resolve(item)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("v1pass"), content)
+
+	item.Backend.Path = "secret/data/app"
+	content, err = m.Resolve(item)
+	assert.Nil(t, err)
+	assert.Equal(t, b64("v2pass"), content)
+
+	item.Backend.Key = "nokey"
+	_, err = m.Resolve(item)
+	assert.NotNil(t, err)
+
+	item.Backend.Path = "secret/none"
+	_, err = m.Resolve(item)
+	assert.NotNil(t, err)
+
+	_, err = NewVaultBackend(server.URL, "wrong").Resolve(item)
+	assert.NotNil(t, err)
+}
+
+func TestManagerWithoutBackend(t *testing.T) {
+	m, err := NewManager(&Config{})
+	assert.Nil(t, err)
+	secret := &commtypes.BcsSecret{
+		Data: map[string]commtypes.SecretDataItem{
+			"plain": {Content: b64("plain")},
+			"vault": {Backend: &commtypes.SecretBackend{Type: commtypes.SecretBackendVault, Path: "p", Key: "k"}},
+		},
+	}
+	assert.NotNil(t, m.Seal(secret))
+	delete(secret.Data, "vault")
+	assert.Nil(t, m.Seal(secret))
+	assert.Nil(t, secret.Data["plain"].Backend)
+
+	_, err = NewManager(&Config{DefaultBackend: commtypes.SecretBackendAESGCM})
+	assert.NotNil(t, err)
+}
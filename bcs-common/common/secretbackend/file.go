@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package secretbackend
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+)
+
+//FileBackend reads secret content from files under directory, like files mounted by other secret agents
+type FileBackend struct {
+	dir string
+}
+
+//NewFileBackend create file backend
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{dir: dir}
+}
+
+func (f *FileBackend) path(item *commtypes.SecretDataItem) (string, error) {
+	if item.Backend.Path == "" {
+		return "", fmt.Errorf("path is required by file backend")
+	}
+	clean := filepath.Clean("/" + item.Backend.Path)
+	if strings.Contains(item.Backend.Path, "..") {
+		return "", fmt.Errorf("path %s is out of secret directory", item.Backend.Path)
+	}
+	return filepath.Join(f.dir, clean), nil
+}
+
+//Resolve reads the file
+func (f *FileBackend) Resolve(item *commtypes.SecretDataItem) (string, error) {
+	path, err := f.path(item)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file failed: %s", err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+//Seal only checks the reference, content is not allowed
+func (f *FileBackend) Seal(item *commtypes.SecretDataItem) error {
+	if _, err := f.path(item); err != nil {
+		return err
+	}
+	if item.Content != "" {
+		return fmt.Errorf("content is not allowed with file backend")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package secretbackend
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+)
+
+//VaultBackend reads secret content from hashicorp vault kv, both kv v1 and kv v2 are supported
+type VaultBackend struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+//NewVaultBackend create vault backend
+func NewVaultBackend(addr, token string) *VaultBackend {
+	return &VaultBackend{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+//Resolve reads field Key of kv Path
+func (v *VaultBackend) Resolve(item *commtypes.SecretDataItem) (string, error) {
+	url := v.addr + "/v1/" + strings.TrimLeft(item.Backend.Path, "/")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("read vault %s failed: %s", item.Backend.Path, err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read vault %s failed: %s", item.Backend.Path, err.Error())
+	}
+	vaultResp := &vaultResponse{}
+	if err := json.Unmarshal(body, vaultResp); err != nil {
+		return "", fmt.Errorf("decode vault %s response failed: %s", item.Backend.Path, err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("read vault %s failed: status %d %s",
+			item.Backend.Path, resp.StatusCode, strings.Join(vaultResp.Errors, ";"))
+	}
+	data := vaultResp.Data
+	//kv v2 wraps the data with metadata
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, meta := data["metadata"]; meta {
+			data = inner
+		}
+	}
+	value, ok := data[item.Backend.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in vault %s", item.Backend.Key, item.Backend.Path)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s in vault %s is not string", item.Backend.Key, item.Backend.Path)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(str)), nil
+}
+
+//Seal only checks the reference, content is not allowed
+func (v *VaultBackend) Seal(item *commtypes.SecretDataItem) error {
+	if item.Backend.Path == "" || item.Backend.Key == "" {
+		return fmt.Errorf("path and key are required by vault backend")
+	}
+	if item.Content != "" {
+		return fmt.Errorf("content is not allowed with vault backend")
+	}
+	return nil
+}
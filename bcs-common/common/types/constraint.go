@@ -68,4 +68,32 @@ type ConstraintDataItem struct {
 type Constraint struct {
 	IntersectionItem []*ConstraintDataItem `json:"intersectionItem,omitempty"`
 	NodeSelector     map[string]string     `json:"nodeSelector,omitempty"`
+	//place taskgroups near the taskgroups of other applications
+	AppAffinity *AppAffinity `json:"appAffinity,omitempty"`
+	//keep taskgroups away from the taskgroups of other applications
+	AppAntiAffinity *AppAffinity `json:"appAntiAffinity,omitempty"`
+}
+
+//AppAffinityTerm selects the taskgroups of applications, and the agent attribute they are compared by
+type AppAffinityTerm struct {
+	//namespace of the applications, default the namespace of the application itself
+	Namespace string `json:"namespace,omitempty"`
+	//names of the applications
+	Applications []string `json:"applications"`
+	//agent attribute as the topology domain, such as zone, default hostname
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+//WeightedAppAffinityTerm is a preferred AppAffinityTerm, weight is from 1 to 100
+type WeightedAppAffinityTerm struct {
+	Weight int             `json:"weight"`
+	Term   AppAffinityTerm `json:"term"`
+}
+
+//AppAffinity is the affinity or anti-affinity between applications
+type AppAffinity struct {
+	//the offer must fit all the required terms
+	Required []*AppAffinityTerm `json:"required,omitempty"`
+	//the offer fits more preferred terms gets higher score
+	Preferred []*WeightedAppAffinityTerm `json:"preferred,omitempty"`
 }
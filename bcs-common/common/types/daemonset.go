@@ -0,0 +1,36 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package types
+
+//BcsDaemonset runs one taskgroup on every enabled agent matching the constraints
+type BcsDaemonset struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	Spec BcsDaemonsetSpec `json:"spec"`
+
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+	KillPolicy    KillPolicy    `json:"killPolicy,omitempty"`
+	Constraints   *Constraint   `json:"constraint,omitempty"`
+	//Priority of the taskgroups, same as ReplicaController
+	Priority int32 `json:"priority,omitempty"`
+	//ConfigReload policy when referenced configmaps or secrets updated
+	ConfigReload *ConfigReloadPolicy `json:"configReload,omitempty"`
+}
+
+//BcsDaemonsetSpec the pod template of daemonset, there is no instance field,
+//the count of taskgroups is decided by the agents in cluster
+type BcsDaemonsetSpec struct {
+	Template *PodTemplateSpec `json:"template"`
+}
@@ -23,6 +23,12 @@ type BcsDeployment struct {
 	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
 	KillPolicy    KillPolicy    `json:"killPolicy,omitempty"`
 	Constraints   *Constraint   `json:"constraint,omitempty"`
+	//Priority of the taskgroups, same as ReplicaController
+	Priority int32 `json:"priority,omitempty"`
+	//ScorePolicy overrides the offer scoring policy of the cluster
+	ScorePolicy *ScorePolicy `json:"scorePolicy,omitempty"`
+	//ConfigReload policy when referenced configmaps or secrets updated
+	ConfigReload *ConfigReloadPolicy `json:"configReload,omitempty"`
 }
 
 type BcsDeploymentSpec struct {
@@ -35,11 +41,17 @@ type BcsDeploymentSpec struct {
 	// pause allows you to pause the rolling update process when you are in it.
 	// default value is false.
 	PauseDeployment bool `json:"pauseDeployment"`
+
+	// the number of old revisions to retain for rollback.
+	// by default, a value of 10 is used.
+	RevisionHistoryLimit *int `json:"revisionHistoryLimit,omitempty"`
 }
 
 type UpgradeStrategy struct {
 	Type          UpgradeStrategyType `json:"type"`
 	RollingUpdate *RollingUpdate      `json:"rollingupdate"`
+	Canary        *CanaryUpdate       `json:"canary,omitempty"`
+	BlueGreen     *BlueGreenUpdate    `json:"bluegreen,omitempty"`
 }
 
 type UpgradeStrategyType string
@@ -49,6 +61,13 @@ const (
 	RecreateUpgradeStrategyType      UpgradeStrategyType = "Recreate"
 	RollingUpdateUpgradeStrategyType UpgradeStrategyType = "RollingUpdate"
 
+	// Canary means that the new pods are launched step by step according to the weights,
+	// and the update pauses for analysis after every step.
+	CanaryUpgradeStrategyType UpgradeStrategyType = "Canary"
+	// BlueGreen means that all the new pods are launched firstly, then the selector of the
+	// active services is switched to the new pods, and the old pods are deleted at last.
+	BlueGreenUpgradeStrategyType UpgradeStrategyType = "BlueGreen"
+
 	// ForceUpdate means that all the old pods will be deleted. and then recreate
 	// pods with new deployment.
 	// ForceUpdate is "valid" only when you call the k8s update restful api.
@@ -81,4 +100,43 @@ type RollingUpdate struct {
 	// rolling update order, create first or delete first.
 	// by default, a value of CreateFirst is used.
 	RollingOrder RollingOrderType `json:"rollingOrder"`
+
+	// the seconds for the new pods of every rolling step to become running and healthy,
+	// or the update is considered failed. by default, a value of 0 means no deadline.
+	ProgressDeadlineSeconds int64 `json:"progressDeadlineSeconds"`
+
+	// rollback to the previous version automatically when the progress deadline is exceeded,
+	// otherwise the update is suspended. by default is false
+	AutoRollback bool `json:"autoRollback"`
+}
+
+type CanaryStep struct {
+	// the percent of pods running the new version after this step, 1-100.
+	Weight int `json:"weight"`
+
+	// the seconds to pause for analysis after this step.
+	// 0 means that the update pauses until it is resumed manually.
+	Pause int64 `json:"pause"`
+}
+
+type CanaryUpdate struct {
+	// the steps of canary update, the weights must be increasing and the last one must be 100.
+	Steps []CanaryStep `json:"steps"`
+
+	// rollback to the old version automatically when the new pods fail or are unhealthy
+	// during the update. by default is false
+	AutoRollback bool `json:"autoRollback"`
+}
+
+type BlueGreenUpdate struct {
+	// the services whose selector will be switched to the new pods.
+	ActiveServices []string `json:"activeServices"`
+
+	// pause before switching the services until it is resumed manually.
+	// by default is false
+	ManualPromotion bool `json:"manualPromotion"`
+
+	// the seconds to keep the old pods after switching the services, for fast rollback.
+	// by default, a value of 0 is used.
+	ScaleDownDelaySeconds int64 `json:"scaleDownDelaySeconds"`
 }
@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package types
+
+//BcsJob runs taskgroups to completion, the taskgroups are not restarted when they exit successfully
+type BcsJob struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	Spec BcsJobSpec `json:"spec"`
+
+	KillPolicy  KillPolicy  `json:"killPolicy,omitempty"`
+	Constraints *Constraint `json:"constraint,omitempty"`
+	//Priority of the taskgroups, same as ReplicaController
+	Priority int32 `json:"priority,omitempty"`
+	//ScorePolicy overrides the offer scoring policy of the cluster
+	ScorePolicy *ScorePolicy `json:"scorePolicy,omitempty"`
+}
+
+//BcsJobSpec the spec of job
+type BcsJobSpec struct {
+	//the count of taskgroups which should finish successfully, default 1
+	Completions int `json:"completions,omitempty"`
+	//the max count of taskgroups running at the same time, default 1
+	Parallelism int `json:"parallelism,omitempty"`
+	//the count of failed taskgroups before marking the job failed, default 6
+	BackoffLimit *int `json:"backoffLimit,omitempty"`
+	//seconds since the job started, the job will be failed and its taskgroups killed after it
+	//0 means no deadline
+	ActiveDeadlineSeconds int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	Template *PodTemplateSpec `json:"template"`
+}
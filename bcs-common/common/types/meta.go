@@ -32,6 +32,9 @@ const (
 	BcsDataType_CRR              BcsDataType = "crr"
 	BcsDataType_WebConsole       BcsDataType = "webconsole"
 	BcsDataType_Admissionwebhook BcsDataType = "admissionwebhook"
+	BcsDataType_ResourceQuota    BcsDataType = "resourcequota"
+	BcsDataType_DAEMONSET        BcsDataType = "daemonset"
+	BcsDataType_JOB              BcsDataType = "job"
 )
 
 //TypeMeta for bcs data type
@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package types
+
+//ResourceQuotaList the amount of resources in a namespace, zero means unlimited
+type ResourceQuotaList struct {
+	Cpu       float64 `json:"cpu"`
+	Mem       float64 `json:"mem"`
+	Disk      float64 `json:"disk"`
+	Instances int     `json:"instances"`
+}
+
+//ResourceQuotaSpec hard limits of the namespace
+type ResourceQuotaSpec struct {
+	Hard ResourceQuotaList `json:"hard"`
+}
+
+//ResourceQuotaStatus current usage of the namespace
+type ResourceQuotaStatus struct {
+	Used ResourceQuotaList `json:"used"`
+}
+
+//BcsResourceQuota definition, limit the total resources of all the applications in a namespace
+type BcsResourceQuota struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       ResourceQuotaSpec   `json:"spec"`
+	Status     ResourceQuotaStatus `json:"status,omitempty"`
+}
@@ -111,6 +111,31 @@ type Volume struct {
 	MountPath string `json:"mountPath,omitempty"`
 	SubPath   string `json:"subPath,omitempty"`
 	ReadOnly  bool   `json:"readOnly,omitempty"`
+
+	//volumes managed by executor, HostPath is ignored when one of them is set
+	EmptyDir *EmptyDirVolume `json:"emptyDir,omitempty"`
+	Local    *LocalVolume    `json:"local,omitempty"`
+}
+
+const (
+	//VolumeMediumDefault emptyDir on disk of agent
+	VolumeMediumDefault = ""
+	//VolumeMediumMemory emptyDir on tmpfs
+	VolumeMediumMemory = "Memory"
+)
+
+//EmptyDirVolume scratch directory shared by containers in taskgroup,
+//it is created when taskgroup starts and removed when taskgroup is down
+type EmptyDirVolume struct {
+	Medium    string `json:"medium,omitempty"`    //"" for disk, Memory for tmpfs
+	SizeLimit int64  `json:"sizeLimit,omitempty"` //size limit in MB, 0 means no limit
+}
+
+//LocalVolume named volume on agent managed by executor, data is kept after
+//taskgroup is down and shared by all taskgroups using the same name on agent
+type LocalVolume struct {
+	Name  string `json:"name"`            //volume name, unique on agent
+	Quota int64  `json:"quota,omitempty"` //quota in MB, 0 means no limit
 }
 
 type VolumeUnit struct {
@@ -170,6 +195,42 @@ type Container struct {
 	Volumes         []VolumeUnit         `json:"volumes,omitempty"`
 	ConfigMaps      []ConfigMap          `json:"configmaps,omitempty"`
 	Secrets         []Secret             `json:"secrets,omitempty"`
+	//LivenessProbe container is restarted when it fails, it takes the place of executor checks in HealthChecks
+	LivenessProbe *HealthCheck `json:"livenessProbe,omitempty"`
+	//ReadinessProbe taskgroup is removed from service endpoints and loadbalance backends while it fails
+	ReadinessProbe *HealthCheck `json:"readinessProbe,omitempty"`
+	//StartupProbe liveness and readiness probes are not started until it succeeds
+	StartupProbe *HealthCheck `json:"startupProbe,omitempty"`
+	//Lifecycle hooks run by executor when container starts or stops
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+}
+
+//Lifecycle actions run by executor after container started and before container stopped
+type Lifecycle struct {
+	//PostStart runs after container started, taskgroup fails when it fails
+	PostStart *LifecycleHandler `json:"postStart,omitempty"`
+	//PreStop runs before container stopped, within the grace period of kill policy
+	PreStop *LifecycleHandler `json:"preStop,omitempty"`
+}
+
+//LifecycleHandler exec command in container or send http get request to container, only one should be set
+type LifecycleHandler struct {
+	Exec           *ExecAction    `json:"exec,omitempty"`
+	HttpGet        *HttpGetAction `json:"httpGet,omitempty"`
+	TimeoutSeconds int            `json:"timeoutSeconds,omitempty"`
+}
+
+//ExecAction command executed in container, exit code 0 is success
+type ExecAction struct {
+	Command []string `json:"command,omitempty"`
+}
+
+//HttpGetAction http get request to container ip address, status code 200-399 is success
+type HttpGetAction struct {
+	Port    int32             `json:"port"`
+	Scheme  string            `json:"scheme,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 // a single process that is expected to be run on the host
@@ -213,6 +274,9 @@ type PodSpec struct {
 	NetworkMode  string            `json:"networkMode,omitempty"`
 	NetworkType  string            `json:"networktype,omitempty"`
 	NetLimit     *NetLimit         `json:"netLimit,omitempty"`
+
+	//InitContainers run to completion one by one before containers start
+	InitContainers []Container `json:"initContainers,omitempty"`
 }
 
 //PodTemplateSpec specification for pod
@@ -238,6 +302,14 @@ type ReplicaController struct {
 	RestartPolicy         RestartPolicy         `json:"restartPolicy,omitempty"`
 	KillPolicy            KillPolicy            `json:"killPolicy,omitempty"`
 	Constraints           *Constraint           `json:"constraint,omitempty"`
+	//Priority of the taskgroups, the bigger the higher, default 0.
+	//taskgroups with higher priority get offers first, and may preempt lower ones
+	Priority int32 `json:"priority,omitempty"`
+	//ScorePolicy overrides the offer scoring policy of the cluster
+	ScorePolicy *ScorePolicy `json:"scorePolicy,omitempty"`
+	//ConfigReload decides what to do with running taskgroups when
+	//configmaps or secrets they reference are updated, default None
+	ConfigReload *ConfigReloadPolicy `json:"configReload,omitempty"`
 }
 
 type HealthCheck struct {
@@ -305,6 +377,29 @@ type RestartPolicy struct {
 	HostRetainTime int64             `json:"hostRetainTime,omitempty"` //only for mesos
 }
 
+//ConfigReloadPolicyType type for config reload strategy
+type ConfigReloadPolicyType string
+
+const (
+	//ConfigReloadPolicy_NONE running taskgroups keep the old config until they are restarted
+	ConfigReloadPolicy_NONE ConfigReloadPolicyType = "None"
+	//ConfigReloadPolicy_FILE rewrite the changed config files in running containers
+	ConfigReloadPolicy_FILE ConfigReloadPolicyType = "File"
+	//ConfigReloadPolicy_FILESIGNAL rewrite the changed files, then send signal or run command
+	ConfigReloadPolicy_FILESIGNAL ConfigReloadPolicyType = "FileSignal"
+	//ConfigReloadPolicy_RESTART reschedule running taskgroups one by one
+	ConfigReloadPolicy_RESTART ConfigReloadPolicyType = "Restart"
+)
+
+//ConfigReloadPolicy for configmap & secret updating
+type ConfigReloadPolicy struct {
+	Policy ConfigReloadPolicyType `json:"policy"` //value: None | File | FileSignal | Restart
+	//Signal sent to container main process after files updated, only for FileSignal
+	Signal int `json:"signal,omitempty"`
+	//Command run in container after files updated, only for FileSignal
+	Command []string `json:"command,omitempty"`
+}
+
 //KillPolicy for container
 type KillPolicy struct {
 	GracePeriod int64 `json:"gracePeriod"` //seconds
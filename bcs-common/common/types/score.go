@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package types
+
+//scorer plugins of the offer scoring pipeline in mesos scheduler
+const (
+	//prefer the offer with the most free cpu and mem left after placing the taskgroup
+	Scorer_LeastAllocated = "LeastAllocated"
+	//prefer the offer with the least free cpu and mem left, pack taskgroups on fewer agents
+	Scorer_MostAllocated = "MostAllocated"
+	//prefer the offer whose attribute value (args, default hostname) has the fewest taskgroups of the application
+	Scorer_Spread = "Spread"
+	//prefer the offer whose agent already runs taskgroups with the same images
+	Scorer_ImageLocality = "ImageLocality"
+	//prefer the offer whose attribute matches args, format name=value1|value2
+	Scorer_Affinity = "Affinity"
+	//prefer the offer which fits more preferred app affinity and anti-affinity terms in constraint,
+	//it is added with weight 1 if the application has preferred terms and the policy does not include it
+	Scorer_AppAffinity = "AppAffinity"
+)
+
+//ScorePlugin is one scorer of the scoring pipeline
+type ScorePlugin struct {
+	Name string `json:"name"`
+	//weight of the scorer in the final score, default 1
+	Weight int `json:"weight,omitempty"`
+	//arguments of the scorer, see the Scorer_* consts
+	Args string `json:"args,omitempty"`
+}
+
+//ScorePolicy decides how the scheduler ranks the offers which fit the resources and constraints,
+//the offer with the highest weighted score is used first
+type ScorePolicy struct {
+	Plugins []*ScorePlugin `json:"plugins,omitempty"`
+}
@@ -17,6 +17,33 @@ package types
 type SecretDataItem struct {
 	//Path    string `json:"path,omitempty"` //mesos only
 	Content string `json:"content"`
+	//Backend keeps the content outside of store, Content is empty or ciphertext then, mesos only
+	Backend *SecretBackend `json:"backend,omitempty"`
+}
+
+//SecretBackendType where the content of secret item is kept
+type SecretBackendType string
+
+const (
+	//SecretBackendVault content is read from hashicorp vault kv
+	SecretBackendVault SecretBackendType = "vault"
+	//SecretBackendFile content is read from file in the secret directory of scheduler
+	SecretBackendFile SecretBackendType = "file"
+	//SecretBackendAESGCM content is encrypted by data key, data key is encrypted by master key
+	SecretBackendAESGCM SecretBackendType = "aesgcm"
+)
+
+//SecretBackend reference of secret content in backend, resolved when taskgroup launched
+type SecretBackend struct {
+	Type SecretBackendType `json:"type"`
+	//Path of vault kv like secret/data/app, or file path relative to secret directory
+	Path string `json:"path,omitempty"`
+	//Key of vault kv data
+	Key string `json:"key,omitempty"`
+	//KeyID of master key encrypting DataKey, aesgcm only
+	KeyID string `json:"keyId,omitempty"`
+	//DataKey encrypted data key in base64, aesgcm only
+	DataKey string `json:"dataKey,omitempty"`
 }
 
 //BcsSecretType type for secret
@@ -70,6 +70,7 @@ type ExtraKind string
 
 const (
 	ApplicationExtraKind ExtraKind = "application"
+	DeploymentExtraKind  ExtraKind = "deployment"
 )
 
 type EventEnv string
@@ -82,7 +83,8 @@ const (
 type EventKind string
 
 const (
-	TaskEventKind EventKind = "task"
+	TaskEventKind       EventKind = "task"
+	DeploymentEventKind EventKind = "deployment"
 )
 
 type EventLevel string
@@ -17,10 +17,13 @@ import (
 	exec "bk-bcs/bcs-mesos/bcs-container-executor/executor"
 	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 //Run is entry point for container executor
@@ -37,6 +40,9 @@ func Run(cmd *CommandFlags) error {
 		return fmt.Errorf("Create ExeuctorDriver failed")
 	}
 	e.SetDriver(driver)
+	if cmd.MetricsPort > 0 {
+		go serveMetrics(cmd.MetricsPort)
+	}
 	_, err := driver.Start()
 	if err != nil {
 		return nil
@@ -48,6 +54,17 @@ func Run(cmd *CommandFlags) error {
 	return waitErr
 }
 
+//serveMetrics export prometheus metrics, including container stats, on local port
+func serveMetrics(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	logs.Infof("executor serve metrics on %s/metrics\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logs.Errorf("executor serve metrics on %s failed, %s\n", addr, err.Error())
+	}
+}
+
 func handleSysSignal(signalChan <-chan os.Signal, executor exec.Executor, driver exec.ExecutorDriver) {
 	select {
 	case <-signalChan:
@@ -14,6 +14,8 @@
 package app
 
 import (
+	comtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-container-executor/collector"
 	"bk-bcs/bcs-mesos/bcs-container-executor/container"
 	"bk-bcs/bcs-mesos/bcs-container-executor/container/cni"
 	"bk-bcs/bcs-mesos/bcs-container-executor/container/cnm"
@@ -38,6 +40,7 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
 	//"github.com/pborman/uuid"
 	"bk-bcs/bcs-common/common/blog"
 	"golang.org/x/net/context"
@@ -59,9 +62,18 @@ const (
 //NewBcsExecutor create Executor instance
 func NewBcsExecutor(flag *CommandFlags) exec.Executor {
 	//create container runtime client
-	docker := container.NewDockerContainer(flag.DockerSocket, flag.User, flag.Passwd)
-	if docker == nil {
-		logs.Errorln("BcsExecutor create docker client failed")
+	var runtime container.Container
+	switch flag.Runtime {
+	case RuntimeCRI:
+		runtime = container.NewCRIContainer(flag.CRIEndpoint, flag.CRIImageTool, flag.User, flag.Passwd)
+	case RuntimeDocker, "":
+		runtime = container.NewDockerContainer(flag.DockerSocket, flag.User, flag.Passwd)
+	default:
+		logs.Errorf("BcsExecutor get unknown container runtime %s\n", flag.Runtime)
+		return nil
+	}
+	if runtime == nil {
+		logs.Errorf("BcsExecutor create %s client failed\n", flag.Runtime)
 		return nil
 	}
 	//create stop channel
@@ -72,7 +84,7 @@ func NewBcsExecutor(flag *CommandFlags) exec.Executor {
 		exeCxt:    eCxt,
 		exeCancel: eCancel,
 		podStatus: container.PodStatus_UNKNOWN,
-		container: docker,
+		container: runtime,
 		launched:  false,
 		tasks: &BcsTaskInfo{
 			ContainerRef:  make(map[string]string),
@@ -82,6 +94,12 @@ func NewBcsExecutor(flag *CommandFlags) exec.Executor {
 		},
 		messages: make(map[int64]*bcstype.BcsMessage),
 	}
+	//collect container logs to rotated files for log query
+	bcsExecutor.logCollector = container.NewLogCollector(runtime, flag.LogDir,
+		int64(flag.LogMaxSize)*1024*1024, flag.LogMaxFiles, time.Duration(flag.LogMaxAge)*time.Hour)
+	//sample container resource stats for status report & metrics
+	bcsExecutor.collector = collector.NewCollector(time.Duration(flag.StatsInterval)*time.Second, bcsExecutor.statsTargets)
+	prometheus.MustRegister(bcsExecutor.collector)
 	//create network manager for executor
 	createNetManager(bcsExecutor, bcsExecutor.flag)
 	if bcsExecutor.netManager == nil {
@@ -150,6 +168,10 @@ type BcsExecutor struct {
 	exeLock    sync.RWMutex        //lock for tasks & monitors
 	tasks      *BcsTaskInfo        //taskinfo cache, key is TaskName
 	messages   map[int64]*bcstype.BcsMessage
+	//collector for container stdout & stderr logs
+	logCollector *container.LogCollector
+	//collector for container resource stats
+	collector *collector.Collector
 }
 
 //Stop send stop signal
@@ -206,6 +228,7 @@ func (executor *BcsExecutor) LaunchTaskGroup(driver exec.ExecutorDriver, taskGro
 	executor.status = ExecutorStatus_LAUNCHING
 	//construct BcsContainerTask to create Pod
 	var containerTasks []*container.BcsContainerTask
+	var initTasks []*container.BcsContainerTask
 	for _, taskInfo := range taskGroup.GetTasks() {
 		by, _ := json.Marshal(taskInfo)
 		logs.Infof("Launch Task %s with data %s.\n", taskInfo.GetName(), string(by))
@@ -308,20 +331,31 @@ func (executor *BcsExecutor) LaunchTaskGroup(driver exec.ExecutorDriver, taskGro
 		}
 		//setting volumes
 		executor.volumeSetting(containerTask, taskInfo)
+		for _, volume := range dataClass.Volumes {
+			containerTask.Volums = append(containerTask.Volums, executor.managedVolumeSetting(volume))
+		}
 		//setting health check
-		if err := executor.healthcheckSetting(containerTask, taskInfo); err != nil {
+		if err := executor.healthcheckSetting(containerTask, taskInfo, dataClass); err != nil {
 			logs.Errorf("Create healcheck for image: %s failed, %s\n", containerTask.Image, err.Error())
 			executor.updateTaskGroup(driver, taskGroup, mesos.TaskState_TASK_FAILED, "create healcheck failed, "+err.Error())
 			executor.status = ExecutorStatus_SHUTDOWN
 			driver.Stop()
 			return
 		}
+		//setting lifecycle hooks & init containers, init containers only come with first task
+		containerTask.Lifecycle = dataClass.Lifecycle
+		for _, initContainer := range dataClass.InitContainers {
+			initTasks = append(initTasks, executor.initContainerSetting(containerTask, initContainer))
+		}
 		//adding
 		containerTasks = append(containerTasks, containerTask)
 	}
+	//init containers run before app containers in pod, keep their order
+	containerTasks = append(containerTasks, initTasks...)
 	//tasks are ready, create Pod now
 	podEvent := &container.PodEventHandler{
-		PreStart: executor.preContainerStartEventCallback,
+		PreStart:  executor.preContainerStartEventCallback,
+		PostStart: executor.postContainerStartEventCallback,
 	}
 
 	createPod(executor, executor.flag, containerTasks, podEvent)
@@ -382,9 +416,34 @@ func (executor *BcsExecutor) LaunchTaskGroup(driver exec.ExecutorDriver, taskGro
 	}
 	//watching all containers
 	go executor.monitorPod()
+	//sampling stats of all containers
+	go executor.collector.Run(executor.exeCxt)
 	return
 }
 
+//statsTargets list running containers for stats collector
+func (executor *BcsExecutor) statsTargets() []*collector.Target {
+	executor.exeLock.RLock()
+	defer executor.exeLock.RUnlock()
+	if executor.podInst == nil {
+		return nil
+	}
+	var targets []*collector.Target
+	for _, info := range executor.podInst.GetContainers() {
+		taskInfo := executor.tasks.GetTaskByContainerID(info.Name)
+		if taskInfo == nil {
+			continue
+		}
+		targets = append(targets, &collector.Target{
+			Name:      info.Name,
+			TaskGroup: executor.driver.ExecutorID(),
+			TaskID:    taskInfo.GetTaskId().GetValue(),
+			Pid:       info.Pid,
+		})
+	}
+	return targets
+}
+
 func (executor *BcsExecutor) watchStartingTask() {
 	for {
 		//time.Sleep(time.Minute)
@@ -477,9 +536,14 @@ func (executor *BcsExecutor) FrameworkMessage(driver exec.ExecutorDriver, msg st
 		err = executor.frameworkMessageUpdateResources(bcsMessage.UpdateTaskResources)
 	case bcstype.Msg_COMMIT_TASK:
 		err = executor.frameworkMessageCommitTask(bcsMessage.CommitTask)
+	case bcstype.Msg_RELOAD_CONFIG:
+		err = executor.frameworkMessageReloadConfig(bcsMessage.TaskID.GetValue(), bcsMessage.ReloadConfig)
 	case bcstype.Msg_Req_COMMAND_TASK:
 		go executor.frameworkMessageCommandTask(bcsMessage.RequestCommandTask)
 		return
+	case bcstype.Msg_Req_LOGS_TASK:
+		go executor.frameworkMessageLogsTask(bcsMessage.RequestLogsTask)
+		return
 
 	default:
 		logs.Errorf("Get unknown message type %d in frameworkMessage", *bcsMessage.Type)
@@ -532,6 +596,40 @@ func (executor *BcsExecutor) Error(driver exec.ExecutorDriver, err string) {
 	logs.Infoln("Got error message:", err)
 }
 
+//updateProbeStatus update healthy, started and ready status of container from its checkers,
+//return true if any status changed
+func updateProbeStatus(task *container.BcsContainerTask) bool {
+	var changed bool
+	//report startup checker before container started
+	checker := task.HealthCheck
+	if !task.IsStarted() {
+		checker = task.StartupCheck
+	}
+	if checker != nil {
+		if task.RuntimeConf.Healthy != checker.IsHealthy() || task.RuntimeConf.IsChecked != checker.IsTicks() ||
+			task.RuntimeConf.ConsecutiveFailureTimes != checker.ConsecutiveFailure() ||
+			task.RuntimeConf.HealthCheckMessage != checker.Message() {
+			changed = true
+			task.RuntimeConf.Healthy = checker.IsHealthy()
+			task.RuntimeConf.IsChecked = checker.IsTicks()
+			task.RuntimeConf.ConsecutiveFailureTimes = checker.ConsecutiveFailure()
+			task.RuntimeConf.HealthCheckMessage = checker.Message()
+		}
+	}
+	//ready after readiness checker succeeds, and not ready when it becomes unhealthy
+	ready := task.IsStarted()
+	if task.ReadinessCheck != nil {
+		ready = ready && task.ReadinessCheck.IsTicks() && task.ReadinessCheck.IsHealthy() &&
+			(task.RuntimeConf.Ready || task.ReadinessCheck.ConsecutiveFailure() == 0)
+	}
+	if task.RuntimeConf.Started != task.IsStarted() || task.RuntimeConf.Ready != ready {
+		changed = true
+		task.RuntimeConf.Started = task.IsStarted()
+		task.RuntimeConf.Ready = ready
+	}
+	return changed
+}
+
 //monitorContainer monitor Container by Name
 //if container is lost, or stopCh is closed, monitor exit.
 func (executor *BcsExecutor) monitorPod() {
@@ -592,14 +690,8 @@ func (executor *BcsExecutor) monitorPod() {
 
 				var healthyChanged bool
 				for _, task := range executor.podInst.GetContainerTasks() {
-					if task.HealthCheck != nil {
-						if task.RuntimeConf.Healthy != task.HealthCheck.IsHealthy() || task.RuntimeConf.IsChecked != task.HealthCheck.IsTicks() ||
-							task.RuntimeConf.ConsecutiveFailureTimes != task.HealthCheck.ConsecutiveFailure() {
-							healthyChanged = true
-							task.RuntimeConf.Healthy = task.HealthCheck.IsHealthy()
-							task.RuntimeConf.IsChecked = task.HealthCheck.IsTicks()
-							task.RuntimeConf.ConsecutiveFailureTimes = task.HealthCheck.ConsecutiveFailure()
-						}
+					if updateProbeStatus(task) {
+						healthyChanged = true
 					}
 
 					if reporting%30 == 0 || changed || healthyChanged || message != nil {
@@ -634,6 +726,7 @@ func (executor *BcsExecutor) monitorPod() {
 								TaskID:     taskInfo.GetTaskId(),
 								Type:       bcstype.Msg_TASK_STATUS_UPDATE.Enum(),
 								TaskStatus: infoby,
+								TaskStats:  executor.collector.GetStats(info.Name),
 							}
 							by, _ := json.Marshal(bcsMsg)
 							_, err := executor.driver.SendFrameworkMessage(string(by))
@@ -766,7 +859,7 @@ func (executor *BcsExecutor) handleDataClass(taskInfo *mesos.TaskInfo) (*bcstype
 	dataBytes, err := base64.StdEncoding.DecodeString(string(base64Bytes))
 	if err != nil {
 		logs.Errorf("decode TaskInfo.Data failed: %s\n", err.Error())
-		return nil, fmt.Errorf("base64 decode taskinfo.data failed: " + err.Error())
+		return nil, fmt.Errorf("base64 decode taskinfo.data failed: %s", err.Error())
 	}
 
 	logs.Infof("handle dataclass %s", string(dataBytes))
@@ -775,7 +868,7 @@ func (executor *BcsExecutor) handleDataClass(taskInfo *mesos.TaskInfo) (*bcstype
 	//parse json to message list
 	if jsonErr := json.Unmarshal(dataBytes, &data); jsonErr != nil {
 		logs.Errorf("decode TaskInfo.Data json failed: %s\n", jsonErr.Error())
-		return nil, fmt.Errorf("json decode taskinfo.data failed: " + jsonErr.Error())
+		return nil, fmt.Errorf("json decode taskinfo.data failed: %s", jsonErr.Error())
 	}
 	return &data, nil
 }
@@ -992,7 +1085,7 @@ func (executor *BcsExecutor) commandEnvSetting(containerTask *container.BcsConta
 	}
 }
 
-func (executor *BcsExecutor) healthcheckSetting(containerTask *container.BcsContainerTask, taskInfo *mesos.TaskInfo) error {
+func (executor *BcsExecutor) healthcheckSetting(containerTask *container.BcsContainerTask, taskInfo *mesos.TaskInfo, dataClass *bcstype.DataClass) error {
 	health := taskInfo.GetHealthCheck()
 	if health != nil {
 		tm := &healthcheck.TimeMechanism{
@@ -1010,6 +1103,8 @@ func (executor *BcsExecutor) healthcheckSetting(containerTask *container.BcsCont
 		case mesos.HealthCheck_TCP:
 			tcpChecker := health.GetTcp()
 			checker, checkErr = healthcheck.NewTCPChecker(containerTask.Name, int(tcpChecker.GetPort()), tm, nil)
+		case mesos.HealthCheck_COMMAND:
+			checker, checkErr = healthcheck.NewCommandChecker(containerTask.Name, health.GetCommand().GetValue(), executor.container, tm, nil)
 		default:
 			checkErr = fmt.Errorf("Get Unsupported health check type %d", health.GetType())
 		}
@@ -1018,9 +1113,101 @@ func (executor *BcsExecutor) healthcheckSetting(containerTask *container.BcsCont
 		}
 		containerTask.HealthCheck = checker
 	}
+	//readiness and startup probes are delivered in DataClass
+	if dataClass == nil {
+		return nil
+	}
+	if dataClass.ReadinessProbe != nil {
+		checker, err := executor.probeSetting(containerTask, dataClass.ReadinessProbe)
+		if err != nil {
+			return fmt.Errorf("readiness probe: %s", err.Error())
+		}
+		containerTask.ReadinessCheck = checker
+	}
+	if dataClass.StartupProbe != nil {
+		checker, err := executor.probeSetting(containerTask, dataClass.StartupProbe)
+		if err != nil {
+			return fmt.Errorf("startup probe: %s", err.Error())
+		}
+		containerTask.StartupCheck = checker
+	}
 	return nil
 }
 
+//probeSetting create checker for probe, port of probe is resolved by scheduler
+func (executor *BcsExecutor) probeSetting(containerTask *container.BcsContainerTask, probe *comtypes.HealthCheck) (healthcheck.Checker, error) {
+	tm := &healthcheck.TimeMechanism{
+		IntervalSeconds:     probe.IntervalSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		ConsecutiveFailures: int(probe.ConsecutiveFailures),
+		GracePeriodSeconds:  probe.GracePeriodSeconds,
+	}
+	switch probe.Type {
+	case comtypes.BcsHealthCheckType_HTTP:
+		if probe.Http == nil {
+			return nil, fmt.Errorf("http probe data is nil")
+		}
+		return healthcheck.NewHTTPChecker(containerTask.Name, probe.Http.Scheme, int(probe.Http.Port), probe.Http.Path, tm, nil)
+	case comtypes.BcsHealthCheckType_TCP:
+		if probe.Tcp == nil {
+			return nil, fmt.Errorf("tcp probe data is nil")
+		}
+		return healthcheck.NewTCPChecker(containerTask.Name, int(probe.Tcp.Port), tm, nil)
+	case comtypes.BcsHealthCheckType_COMMAND:
+		if probe.Command == nil {
+			return nil, fmt.Errorf("command probe data is nil")
+		}
+		return healthcheck.NewCommandChecker(containerTask.Name, probe.Command.Value, executor.container, tm, nil)
+	default:
+		return nil, fmt.Errorf("Get Unsupported probe type %s", probe.Type)
+	}
+}
+
+//initContainerSetting create init container task, network & kill policy come from app container
+func (executor *BcsExecutor) initContainerSetting(containerTask *container.BcsContainerTask, initContainer *bcstype.InitContainer) *container.BcsContainerTask {
+	initTask := new(container.BcsContainerTask)
+	initTask.InitContainer = true
+	initTask.Name = containerTask.Name + "-init-" + initContainer.Name
+	initTask.TaskId = containerTask.TaskId
+	initTask.HostName = containerTask.HostName
+	initTask.NetworkName = containerTask.NetworkName
+	initTask.KillPolicy = containerTask.KillPolicy
+	initTask.NetLimit = containerTask.NetLimit
+	initTask.AutoRemove = true
+	initTask.Resource = initContainer.Resources
+	if initTask.Resource == nil {
+		initTask.Resource = containerTask.Resource
+	}
+	initTask.LimitResource = initContainer.LimitResoures
+	if initContainer.Docker != nil {
+		initTask.Image = initContainer.Docker.Image
+		initTask.ForcePullImage = initContainer.Docker.ForcePullImage
+		initTask.Privileged = initContainer.Docker.Privileged
+		initTask.Command = initContainer.Docker.Command
+		initTask.Args = initContainer.Docker.Arguments
+		for key, value := range initContainer.Docker.Env {
+			initTask.Env = append(initTask.Env, container.BcsKV{Key: key, Value: value})
+		}
+	}
+	for _, volume := range initContainer.Volumes {
+		if volume.IsManaged() {
+			initTask.Volums = append(initTask.Volums, executor.managedVolumeSetting(volume))
+			continue
+		}
+		bv := container.BcsVolume{
+			ReadOnly:      volume.Mode != "RW",
+			HostPath:      volume.HostPath,
+			ContainerPath: volume.ContainerPath,
+		}
+		if strings.Contains(bv.HostPath, "$BCS_POD_ID") {
+			bv.HostPath = strings.Replace(bv.HostPath, "$BCS_POD_ID", executor.driver.ExecutorID(), -1)
+		}
+		initTask.Volums = append(initTask.Volums, bv)
+	}
+	logs.Infof("Task %s create init container %s with image %s\n", containerTask.TaskId, initTask.Name, initTask.Image)
+	return initTask
+}
+
 func (executor *BcsExecutor) volumeSetting(containerTask *container.BcsContainerTask, taskInfo *mesos.TaskInfo) {
 	mesosContainer := taskInfo.GetContainer()
 	if mesosContainer.GetVolumes() != nil && len(mesosContainer.GetVolumes()) > 0 {
@@ -1053,6 +1240,24 @@ func (executor *BcsExecutor) volumeSetting(containerTask *container.BcsContainer
 	}
 }
 
+//managedVolumeSetting setting host path of emptyDir & local volume, emptyDir is
+//under executor sandbox, and local volume is under volume directory of agent
+func (executor *BcsExecutor) managedVolumeSetting(volume *bcstype.Volume) container.BcsVolume {
+	bv := container.BcsVolume{
+		ReadOnly:      volume.Mode != "RW",
+		ContainerPath: volume.ContainerPath,
+		EmptyDir:      volume.EmptyDir,
+		Local:         volume.Local,
+	}
+	if volume.Local != nil {
+		bv.HostPath = filepath.Join(executor.flag.VolumeDir, volume.Local.Name)
+	} else {
+		bv.HostPath = filepath.Join(os.Getenv("MESOS_SANDBOX"), "volumes", volume.Name)
+	}
+	logs.Infof("Setting volume %s on host path %s\n", volume.ContainerPath, bv.HostPath)
+	return bv
+}
+
 func (executor *BcsExecutor) portMappingSetting(containerTask *container.BcsContainerTask, taskInfo *mesos.TaskInfo) {
 	docker := taskInfo.GetContainer().GetDocker()
 	portMaps := docker.GetPortMappings()
@@ -1109,6 +1314,18 @@ func (executor *BcsExecutor) preContainerStartEventCallback(containerTask *conta
 	return nil
 }
 
+//postContainerStartEventCallback start collecting logs after container started
+func (executor *BcsExecutor) postContainerStartEventCallback(containerTask *container.BcsContainerTask) error {
+	if containerTask.InitContainer || containerTask.RuntimeConf == nil {
+		return nil
+	}
+	if err := executor.logCollector.Collect(executor.exeCxt, containerTask.RuntimeConf); err != nil {
+		logs.Errorf("BcsExecutor collect logs of container %s failed, %s\n", containerTask.RuntimeConf.Name, err.Error())
+		return err
+	}
+	return nil
+}
+
 //copyFileToContainer copy Msg_LocalFile to contianer by ContianerID
 func (executor *BcsExecutor) copyFileToContainer(containerID string, fileInfo *bcstype.Msg_LocalFile) error {
 	if containerID == "" {
@@ -0,0 +1,132 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package app
+
+import (
+	"testing"
+
+	"bk-bcs/bcs-mesos/bcs-container-executor/container"
+	"bk-bcs/bcs-mesos/bcs-container-executor/healthcheck"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//statusChecker returns the check results set by test, it's always running
+type statusChecker struct {
+	healthcheck.Checker
+	healthy  bool
+	ticks    bool
+	failures int
+	message  string
+}
+
+func (c *statusChecker) IsStarting() bool {
+	return true
+}
+
+func (c *statusChecker) Stop() {}
+
+func (c *statusChecker) IsHealthy() bool {
+	return c.healthy
+}
+
+func (c *statusChecker) IsTicks() bool {
+	return c.ticks
+}
+
+func (c *statusChecker) ConsecutiveFailure() int {
+	return c.failures
+}
+
+func (c *statusChecker) Message() string {
+	return c.message
+}
+
+//check set the result of one check
+func (c *statusChecker) check(healthy bool, message string) {
+	c.ticks = true
+	c.healthy = healthy
+	c.message = message
+	if healthy {
+		c.failures = 0
+	} else {
+		c.failures++
+	}
+}
+
+func TestUpdateProbeStatusReadiness(t *testing.T) {
+	readiness := &statusChecker{}
+	task := &container.BcsContainerTask{ReadinessCheck: readiness, RuntimeConf: &container.BcsContainerInfo{}}
+
+	//started without startup probe, not ready before checked
+	assert.True(t, updateProbeStatus(task))
+	assert.True(t, task.RuntimeConf.Started)
+	assert.False(t, task.RuntimeConf.Ready)
+	assert.False(t, updateProbeStatus(task))
+
+	readiness.check(false, "connection refused")
+	assert.False(t, updateProbeStatus(task))
+	assert.False(t, task.RuntimeConf.Ready)
+
+	readiness.check(true, "")
+	assert.True(t, updateProbeStatus(task))
+	assert.True(t, task.RuntimeConf.Ready)
+
+	//not ready when readiness checker becomes unhealthy
+	readiness.check(false, "connection refused")
+	assert.True(t, updateProbeStatus(task))
+	assert.False(t, task.RuntimeConf.Ready)
+
+	//checker keeps healthy until consecutive failures reached, ready only after a success
+	readiness.healthy = true
+	assert.False(t, updateProbeStatus(task))
+	assert.False(t, task.RuntimeConf.Ready)
+	readiness.check(true, "")
+	assert.True(t, updateProbeStatus(task))
+	assert.True(t, task.RuntimeConf.Ready)
+
+	//failure under the threshold keeps ready
+	readiness.check(false, "timeout")
+	readiness.healthy = true
+	assert.False(t, updateProbeStatus(task))
+	assert.True(t, task.RuntimeConf.Ready)
+}
+
+func TestUpdateProbeStatusStartup(t *testing.T) {
+	startup := &statusChecker{}
+	liveness := &statusChecker{healthy: true}
+	task := &container.BcsContainerTask{StartupCheck: startup, HealthCheck: liveness, RuntimeConf: &container.BcsContainerInfo{}}
+
+	//startup checker is reported before started
+	startup.check(false, "connection refused")
+	assert.True(t, updateProbeStatus(task))
+	assert.False(t, task.RuntimeConf.Started)
+	assert.False(t, task.RuntimeConf.Ready)
+	assert.False(t, task.RuntimeConf.Healthy)
+	assert.True(t, task.RuntimeConf.IsChecked)
+	assert.Equal(t, 1, task.RuntimeConf.ConsecutiveFailureTimes)
+	assert.Equal(t, "connection refused", task.RuntimeConf.HealthCheckMessage)
+
+	//liveness checker is reported after startup probe succeeds
+	startup.check(true, "")
+	task.StartProbes("127.0.0.1")
+	liveness.check(true, "ok")
+	assert.True(t, updateProbeStatus(task))
+	assert.True(t, task.RuntimeConf.Started)
+	assert.True(t, task.RuntimeConf.Ready)
+	assert.True(t, task.RuntimeConf.Healthy)
+	assert.Equal(t, 0, task.RuntimeConf.ConsecutiveFailureTimes)
+	assert.Equal(t, "ok", task.RuntimeConf.HealthCheckMessage)
+	assert.False(t, updateProbeStatus(task))
+}
@@ -126,7 +126,7 @@ func (executor *BcsExecutor) frameworkMessageRemoteFile(taskID string, remote *b
 func (executor *BcsExecutor) frameworkMessageSignalExecute(taskID string, singalInfo *bcstype.Msg_Signal) error {
 	executor.exeLock.Lock()
 	defer executor.exeLock.Unlock()
-	logs.Infof(fmt.Sprintf("Executor get framework signal %d for %v", singalInfo.Signal, singalInfo.ProcessName))
+	logs.Infof("Executor get framework signal %d for %v", singalInfo.Signal, singalInfo.ProcessName)
 	//get containerID list from local cache
 	var containerList []string
 	var err error
@@ -161,6 +161,57 @@ func (executor *BcsExecutor) frameworkMessageSignalExecute(taskID string, singal
 	return err
 }
 
+//frameworkMessageReloadConfig rewrite the changed configmap/secret files in container of task,
+//then notify the process in container with signal or command
+func (executor *BcsExecutor) frameworkMessageReloadConfig(taskID string, msg *bcstype.Msg_ReloadConfig) error {
+	files := msg.Files
+	for _, remote := range msg.Remotes {
+		local, err := executor.downloadRemoteFile(remote)
+		if err != nil {
+			return fmt.Errorf("download %s for reload failed: %s", *remote.From, err.Error())
+		}
+		files = append(files, local)
+	}
+
+	containerID, containerName, err := executor.reloadConfigFiles(taskID, files, msg.Signal)
+	if err != nil {
+		return err
+	}
+
+	//the reload command may run for long, so it is run without exeLock
+	if len(msg.Command) != 0 {
+		if err := executor.container.RunCommand(containerID, msg.Command); err != nil {
+			return fmt.Errorf("run command %v in container %s failed: %s", msg.Command, containerName, err.Error())
+		}
+		logs.Infof("run command %v in container %s after reload", msg.Command, containerName)
+	}
+	return nil
+}
+
+//reloadConfigFiles copy the files into container of task and send the signal, returns the id and name of container
+func (executor *BcsExecutor) reloadConfigFiles(taskID string, files []*bcstype.Msg_LocalFile, signal int) (string, string, error) {
+	executor.exeLock.Lock()
+	defer executor.exeLock.Unlock()
+	containerInfo := executor.tasks.GetContainerByTaskID(taskID)
+	if containerInfo == nil {
+		return "", "", fmt.Errorf("task %s container not found", taskID)
+	}
+	for _, file := range files {
+		if err := executor.copyFileToContainer(containerInfo.ID, file); err != nil {
+			return "", "", fmt.Errorf("reload file %s failed: %s", *file.To, err.Error())
+		}
+		logs.Infof("reload file %s in container %s success", *file.To, containerInfo.Name)
+	}
+
+	if signal > 0 {
+		if err := executor.container.KillContainer(containerInfo.Name, signal); err != nil {
+			return "", "", fmt.Errorf("send signal %d to container %s failed: %s", signal, containerInfo.Name, err.Error())
+		}
+		logs.Infof("send signal %d to container %s after reload", signal, containerInfo.Name)
+	}
+	return containerInfo.ID, containerInfo.Name, nil
+}
+
 func (executor *BcsExecutor) frameworkMessageUpdateResources(msg *bcstype.Msg_UpdateTaskResources) error {
 	executor.exeLock.Lock()
 	defer executor.exeLock.Unlock()
@@ -252,6 +303,44 @@ func (executor *BcsExecutor) frameworkMessageCommandTask(msg *bcstype.RequestCom
 	}
 }
 
+func (executor *BcsExecutor) frameworkMessageLogsTask(msg *bcstype.RequestLogsTask) {
+	resp := &bcstype.ResponseLogsTask{
+		ID:     msg.ID,
+		TaskId: msg.TaskId,
+		Status: bcstype.Msg_Status_Success,
+	}
+	limit := msg.Limit
+	if limit <= 0 || limit > bcstype.LogsMaxLimit {
+		limit = bcstype.LogsDefaultLimit
+	}
+	container := executor.tasks.GetContainerByTaskID(msg.TaskId)
+	if container == nil {
+		logs.Errorf("task %s container not found", msg.TaskId)
+		resp.Status = bcstype.Msg_Status_Failed
+		resp.Message = fmt.Sprintf("task %s container not found", msg.TaskId)
+	} else {
+		resp.ContainerId = container.ID
+		data, offset, err := executor.logCollector.Read(container.Name, msg.Offset, msg.Tail, limit)
+		if err != nil {
+			logs.Errorf("read logs of task %s error %s", msg.TaskId, err.Error())
+			resp.Status = bcstype.Msg_Status_Failed
+			resp.Message = err.Error()
+		}
+		resp.Logs = data
+		resp.Offset = offset
+		resp.NextOffset = offset + int64(len(data))
+	}
+
+	bcsMsg := &bcstype.BcsMessage{
+		Type:             bcstype.Msg_Res_LOGS_TASK.Enum(),
+		ResponseLogsTask: resp,
+	}
+	by, _ := json.Marshal(bcsMsg)
+	if _, err := executor.driver.SendFrameworkMessage(string(by)); err != nil {
+		logs.Errorf("send framework message error %s", err.Error())
+	}
+}
+
 /*
  * dataClass message handler
  * dataClassRemote
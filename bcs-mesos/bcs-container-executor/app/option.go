@@ -16,7 +16,10 @@ package app
 import (
 	"bk-bcs/bcs-common/common/encrypt"
 	"bk-bcs/bcs-common/common/util"
+	"bk-bcs/bcs-mesos/bcs-container-executor/collector"
+	"bk-bcs/bcs-mesos/bcs-container-executor/container"
 	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -24,6 +27,12 @@ import (
 const (
 	//defualt cni directory
 	DefaultCNIDirectory = "/data/bcs/bcs-cni"
+	//DefaultLogDirectory default directory for container logs under executor sandbox
+	DefaultLogDirectory = "container-logs"
+	//RuntimeDocker container runtime docker
+	RuntimeDocker = "docker"
+	//RuntimeCRI container runtime with CRI, like containerd
+	RuntimeCRI = "cri"
 )
 
 //CommandFlags hold all command line flags from mesos-slave
@@ -31,10 +40,20 @@ type CommandFlags struct {
 	User            string //user for authentication
 	Passwd          string //password for authentication
 	DockerSocket    string //docker socket path
+	Runtime         string //container runtime: docker or cri
+	CRIEndpoint     string //cri runtime socket path, like containerd
+	CRIImageTool    string //tool compatible with nerdctl for committing images in cri runtime
 	MappedDirectory string //The sandbox directory path that is mapped in the docker container.
 	NetworkMode     string //mode for cni/cnm
 	CNIPluginDir    string //cni plugin directory, $CNIPluginDir/bin for binary, $CNIPluginDir/conf for configuration
 	NetworkImage    string //cni network images
+	LogDir          string //directory for container logs, relative to executor sandbox
+	LogMaxSize      int    //max size(MB) of one container log file
+	LogMaxFiles     int    //max rotated log files for one container
+	LogMaxAge       int    //max age(hours) of container log file
+	StatsInterval   int    //interval(seconds) for sampling container stats
+	MetricsPort     int    //port for exporting container stats metrics, 0 for disabled
+	VolumeDir       string //directory for local named volumes on agent
 }
 
 //NewCommandFlags return new DockerFalgs with default value
@@ -43,9 +62,19 @@ func NewCommandFlags() *CommandFlags {
 		User:            "",
 		Passwd:          "",
 		DockerSocket:    "unix:///var/run/docker.sock",
+		Runtime:         RuntimeDocker,
+		CRIEndpoint:     container.DefaultCRIEndpoint,
+		CRIImageTool:    container.DefaultCRIImageTool,
 		MappedDirectory: "/etc/mnt/bcs",
 		NetworkMode:     "",
 		CNIPluginDir:    DefaultCNIDirectory,
+		LogDir:          DefaultLogDirectory,
+		LogMaxSize:      container.DefaultLogMaxSize / 1024 / 1024,
+		LogMaxFiles:     container.DefaultLogMaxFiles,
+		LogMaxAge:       int(container.DefaultLogMaxAge / time.Hour),
+		StatsInterval:   int(collector.DefaultInterval / time.Second),
+		MetricsPort:     0,
+		VolumeDir:       container.DefaultLocalVolumeDirectory,
 	}
 }
 
@@ -56,10 +85,20 @@ func ParseCmdFlags() *CommandFlags {
 	flag.StringVar(&cmdFlag.User, "user", cmdFlag.User, "user for executor")
 	flag.StringVar(&cmdFlag.Passwd, "uuid", cmdFlag.Passwd, "uuid for executor")
 	flag.StringVar(&cmdFlag.DockerSocket, "docker-socket", cmdFlag.DockerSocket, "container name for running docker container")
+	flag.StringVar(&cmdFlag.Runtime, "container-runtime", cmdFlag.Runtime, "container runtime: docker or cri. default docker")
+	flag.StringVar(&cmdFlag.CRIEndpoint, "cri-endpoint", cmdFlag.CRIEndpoint, "cri runtime unix socket, only used when container-runtime is cri")
+	flag.StringVar(&cmdFlag.CRIImageTool, "cri-image-tool", cmdFlag.CRIImageTool, "tool compatible with nerdctl for committing images, only used when container-runtime is cri")
 	flag.StringVar(&cmdFlag.MappedDirectory, "mapped-directory", cmdFlag.MappedDirectory, "The sandbox directory path that is mapped in the docker container.")
 	flag.StringVar(&cmdFlag.CNIPluginDir, "cni-plugin", cmdFlag.CNIPluginDir, "cni interface plugin directory, $cni_plugin/bin for binary, $cni_plugin/conf for configuration")
 	flag.StringVar(&cmdFlag.NetworkMode, "network-mode", cmdFlag.NetworkMode, "container network mode: cni or cnm. default empty")
 	flag.StringVar(&cmdFlag.NetworkImage, "network-image", cmdFlag.NetworkImage, "container network image")
+	flag.StringVar(&cmdFlag.LogDir, "container-log-dir", cmdFlag.LogDir, "directory for container stdout & stderr logs, relative path is under executor sandbox")
+	flag.IntVar(&cmdFlag.LogMaxSize, "log-max-size", cmdFlag.LogMaxSize, "max size(MB) of one container log file, file is rotated when reaching it")
+	flag.IntVar(&cmdFlag.LogMaxFiles, "log-max-files", cmdFlag.LogMaxFiles, "max log files kept for one container, including the writing one")
+	flag.IntVar(&cmdFlag.LogMaxAge, "log-max-age", cmdFlag.LogMaxAge, "max age(hours) of container log file, older file is rotated and removed, 0 means no limit")
+	flag.IntVar(&cmdFlag.StatsInterval, "stats-interval", cmdFlag.StatsInterval, "interval(seconds) for sampling container cpu, memory, network & blkio stats")
+	flag.IntVar(&cmdFlag.MetricsPort, "metrics-port", cmdFlag.MetricsPort, "port for exporting container stats prometheus metrics on /metrics, 0 means disabled")
+	flag.StringVar(&cmdFlag.VolumeDir, "volume-dir", cmdFlag.VolumeDir, "directory for local named volumes on agent, volumes are kept after taskgroup is down")
 	util.InitFlags()
 	//parse base64 uuid to password, skip if uuid empty
 	if len(cmdFlag.Passwd) != 0 {
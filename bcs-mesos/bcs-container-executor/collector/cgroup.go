@@ -0,0 +1,257 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package collector
+
+import (
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//cgroupPaths cgroup path of process, key is controller, "" for cgroup v2
+type cgroupPaths map[string]string
+
+//readCgroupPaths parse /proc/$pid/cgroup, line like 4:cpu,cpuacct:/docker/$id
+func readCgroupPaths(procRoot string, pid int) (cgroupPaths, error) {
+	file, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	paths := make(cgroupPaths)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+	return paths, scanner.Err()
+}
+
+//v1Dir directory of cgroup v1 controller, empty if controller not found
+func (paths cgroupPaths) v1Dir(root, controller string) string {
+	path, ok := paths[controller]
+	if !ok {
+		return ""
+	}
+	return filepath.Join(root, controller, path)
+}
+
+//v2Dir directory of cgroup v2, empty if it is not cgroup v2
+func (paths cgroupPaths) v2Dir(root string) string {
+	path, ok := paths[""]
+	if !ok || len(paths) != 1 {
+		return ""
+	}
+	return filepath.Join(root, path)
+}
+
+//readStats read stats of process pid from cgroup & proc filesystem
+func readStats(procRoot, cgroupRoot string, pid int) (*schedTypes.ContainerStats, error) {
+	paths, err := readCgroupPaths(procRoot, pid)
+	if err != nil {
+		return nil, err
+	}
+	stats := &schedTypes.ContainerStats{Timestamp: time.Now()}
+	if dir := paths.v2Dir(cgroupRoot); dir != "" {
+		err = readCgroupV2(dir, stats)
+	} else {
+		err = readCgroupV1(paths, cgroupRoot, stats)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := readNetDev(filepath.Join(procRoot, strconv.Itoa(pid), "net", "dev"), stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func readCgroupV1(paths cgroupPaths, root string, stats *schedTypes.ContainerStats) error {
+	var err error
+	if dir := paths.v1Dir(root, "cpuacct"); dir != "" {
+		if stats.CPUUsage, err = readUint(filepath.Join(dir, "cpuacct.usage")); err != nil {
+			return err
+		}
+	}
+	if dir := paths.v1Dir(root, "memory"); dir != "" {
+		if stats.MemoryUsage, err = readUint(filepath.Join(dir, "memory.usage_in_bytes")); err != nil {
+			return err
+		}
+		if stats.MemoryLimit, err = readUint(filepath.Join(dir, "memory.limit_in_bytes")); err != nil {
+			return err
+		}
+		//no limit in cgroup v1 is a huge number of page align
+		if stats.MemoryLimit >= 1<<62 {
+			stats.MemoryLimit = 0
+		}
+		memStat, err := readKeyValues(filepath.Join(dir, "memory.stat"))
+		if err != nil {
+			return err
+		}
+		stats.MemoryWorkingSet = workingSet(stats.MemoryUsage, memStat["total_inactive_file"])
+	}
+	if dir := paths.v1Dir(root, "blkio"); dir != "" {
+		//line like: 8:0 Read 4096
+		lines, err := readLines(filepath.Join(dir, "blkio.throttle.io_service_bytes"))
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			value, _ := strconv.ParseUint(fields[2], 10, 64)
+			switch fields[1] {
+			case "Read":
+				stats.BlkioReadBytes += value
+			case "Write":
+				stats.BlkioWriteBytes += value
+			}
+		}
+	}
+	return nil
+}
+
+func readCgroupV2(dir string, stats *schedTypes.ContainerStats) error {
+	cpuStat, err := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return err
+	}
+	stats.CPUUsage = cpuStat["usage_usec"] * 1000
+	if stats.MemoryUsage, err = readUint(filepath.Join(dir, "memory.current")); err != nil {
+		return err
+	}
+	//memory.max is "max" when there is no limit
+	stats.MemoryLimit, _ = readUint(filepath.Join(dir, "memory.max"))
+	memStat, err := readKeyValues(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return err
+	}
+	stats.MemoryWorkingSet = workingSet(stats.MemoryUsage, memStat["inactive_file"])
+	//line like: 8:0 rbytes=4096 wbytes=0 rios=1 wios=0
+	lines, err := readLines(filepath.Join(dir, "io.stat"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range lines {
+		for _, field := range strings.Fields(line)[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			value, _ := strconv.ParseUint(kv[1], 10, 64)
+			switch kv[0] {
+			case "rbytes":
+				stats.BlkioReadBytes += value
+			case "wbytes":
+				stats.BlkioWriteBytes += value
+			}
+		}
+	}
+	return nil
+}
+
+//readNetDev sum all interfaces except lo in /proc/$pid/net/dev
+func readNetDev(path string, stats *schedTypes.ContainerStats) error {
+	lines, err := readLines(path)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "lo" {
+			continue
+		}
+		//receive: bytes packets errs drop fifo frame compressed multicast, then transmit
+		fields := strings.Fields(parts[1])
+		if len(fields) < 10 {
+			continue
+		}
+		values := make([]uint64, 10)
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		stats.NetRxBytes += values[0]
+		stats.NetRxPackets += values[1]
+		stats.NetTxBytes += values[8]
+		stats.NetTxPackets += values[9]
+	}
+	return nil
+}
+
+func workingSet(usage, inactiveFile uint64) uint64 {
+	if usage < inactiveFile {
+		return 0
+	}
+	return usage - inactiveFile
+}
+
+func readUint(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s failed, %s", path, err.Error())
+	}
+	return value, nil
+}
+
+func readLines(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+//readKeyValues read file with lines like "key value"
+func readKeyValues(path string) (map[string]uint64, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		values[fields[0]], _ = strconv.ParseUint(fields[1], 10, 64)
+	}
+	return values, nil
+}
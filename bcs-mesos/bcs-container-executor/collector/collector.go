@@ -11,4 +11,118 @@
  *
  */
 
+//Package collector samples resource stats of containers in pod from
+//cgroup & proc filesystem, without cAdvisor deployed on agent.
 package collector
+
+import (
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	//DefaultInterval default interval for sampling stats
+	DefaultInterval = 10 * time.Second
+	//DefaultProcRoot default mount point of proc filesystem
+	DefaultProcRoot = "/proc"
+	//DefaultCgroupRoot default mount point of cgroup filesystem
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+)
+
+//Target container for sampling stats
+type Target struct {
+	Name      string //container name
+	TaskGroup string //taskgroup id
+	TaskID    string //mesos task id
+	Pid       int    //pid of container init process
+}
+
+//Lister list all containers need sampling
+type Lister func() []*Target
+
+//Collector sample stats of containers periodically
+type Collector struct {
+	interval   time.Duration
+	lister     Lister
+	procRoot   string
+	cgroupRoot string
+	lock       sync.RWMutex
+	targets    map[string]*Target                    //targets of last sampling, key is container name
+	stats      map[string]*schedTypes.ContainerStats //latest stats, key is container name
+}
+
+//NewCollector create collector sampling containers from lister
+func NewCollector(interval time.Duration, lister Lister) *Collector {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Collector{
+		interval:   interval,
+		lister:     lister,
+		procRoot:   DefaultProcRoot,
+		cgroupRoot: DefaultCgroupRoot,
+		targets:    make(map[string]*Target),
+		stats:      make(map[string]*schedTypes.ContainerStats),
+	}
+}
+
+//Run sampling until cxt is done
+func (c *Collector) Run(cxt context.Context) {
+	tick := time.NewTicker(c.interval)
+	defer tick.Stop()
+	c.Sample()
+	for {
+		select {
+		case <-cxt.Done():
+			logs.Infoln("stats collector quit.")
+			return
+		case <-tick.C:
+			c.Sample()
+		}
+	}
+}
+
+//Sample sample stats of all containers once
+func (c *Collector) Sample() {
+	targets := make(map[string]*Target)
+	stats := make(map[string]*schedTypes.ContainerStats)
+	for _, target := range c.lister() {
+		if target.Pid <= 0 {
+			continue
+		}
+		current, err := readStats(c.procRoot, c.cgroupRoot, target.Pid)
+		if err != nil {
+			logs.Errorf("collector sample stats of container %s pid %d failed, %s\n", target.Name, target.Pid, err.Error())
+			continue
+		}
+		c.lock.RLock()
+		last, ok := c.stats[target.Name]
+		c.lock.RUnlock()
+		if ok && current.Timestamp.After(last.Timestamp) && current.CPUUsage >= last.CPUUsage {
+			elapsed := current.Timestamp.Sub(last.Timestamp)
+			current.CPUPercent = float64(current.CPUUsage-last.CPUUsage) / float64(elapsed.Nanoseconds()) * 100
+		}
+		targets[target.Name] = target
+		stats[target.Name] = current
+	}
+	c.lock.Lock()
+	c.targets = targets
+	c.stats = stats
+	c.lock.Unlock()
+}
+
+//GetStats get latest stats of container, nil if container is not sampled
+func (c *Collector) GetStats(name string) *schedTypes.ContainerStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	stats, ok := c.stats[name]
+	if !ok {
+		return nil
+	}
+	copied := *stats
+	return &copied
+}
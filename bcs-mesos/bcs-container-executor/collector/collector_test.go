@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+const netDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:     100       2    0    0    0     0          0         0      100       2    0    0    0     0       0          0
+  eth0:    1000      10    0    0    0     0          0         0     2000      20    0    0    0     0       0          0
+`
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func newTestCollector(t *testing.T, files map[string]string) (*Collector, func()) {
+	root, err := ioutil.TempDir("", "collector")
+	assert.Nil(t, err)
+	writeFiles(t, root, files)
+	c := NewCollector(0, func() []*Target {
+		return []*Target{{Name: "web", TaskGroup: "0.app.ns.10001.1", TaskID: "task-1", Pid: 100}}
+	})
+	c.procRoot = filepath.Join(root, "proc")
+	c.cgroupRoot = filepath.Join(root, "cgroup")
+	return c, func() { os.RemoveAll(root) }
+}
+
+func TestSampleCgroupV1(t *testing.T) {
+	c, clean := newTestCollector(t, map[string]string{
+		"proc/100/cgroup":                                         "12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n3:blkio:/docker/abc\n",
+		"proc/100/net/dev":                                        netDev,
+		"cgroup/cpuacct/docker/abc/cpuacct.usage":                 "2000000000\n",
+		"cgroup/memory/docker/abc/memory.usage_in_bytes":          "4096\n",
+		"cgroup/memory/docker/abc/memory.limit_in_bytes":          "9223372036854771712\n",
+		"cgroup/memory/docker/abc/memory.stat":                    "cache 2048\ntotal_inactive_file 1024\n",
+		"cgroup/blkio/docker/abc/blkio.throttle.io_service_bytes": "8:0 Read 512\n8:0 Write 256\n8:0 Total 768\nTotal 768\n",
+	})
+	defer clean()
+
+	c.Sample()
+	stats := c.GetStats("web")
+	if assert.NotNil(t, stats) {
+		assert.Equal(t, uint64(2000000000), stats.CPUUsage)
+		assert.Equal(t, uint64(4096), stats.MemoryUsage)
+		assert.Equal(t, uint64(3072), stats.MemoryWorkingSet)
+		assert.Equal(t, uint64(0), stats.MemoryLimit)
+		assert.Equal(t, uint64(512), stats.BlkioReadBytes)
+		assert.Equal(t, uint64(256), stats.BlkioWriteBytes)
+		assert.Equal(t, uint64(1000), stats.NetRxBytes)
+		assert.Equal(t, uint64(10), stats.NetRxPackets)
+		assert.Equal(t, uint64(2000), stats.NetTxBytes)
+		assert.Equal(t, uint64(20), stats.NetTxPackets)
+	}
+	assert.Nil(t, c.GetStats("unknown"))
+}
+
+func TestSampleCgroupV2(t *testing.T) {
+	c, clean := newTestCollector(t, map[string]string{
+		"proc/100/cgroup":  "0::/system.slice/docker-abc.scope\n",
+		"proc/100/net/dev": netDev,
+		"cgroup/system.slice/docker-abc.scope/cpu.stat":       "usage_usec 1500\nuser_usec 1000\n",
+		"cgroup/system.slice/docker-abc.scope/memory.current": "8192\n",
+		"cgroup/system.slice/docker-abc.scope/memory.max":     "max\n",
+		"cgroup/system.slice/docker-abc.scope/memory.stat":    "anon 4096\ninactive_file 2048\n",
+		"cgroup/system.slice/docker-abc.scope/io.stat":        "8:0 rbytes=100 wbytes=200 rios=1 wios=2\n8:16 rbytes=1 wbytes=2 rios=1 wios=1\n",
+	})
+	defer clean()
+
+	c.Sample()
+	stats := c.GetStats("web")
+	if assert.NotNil(t, stats) {
+		assert.Equal(t, uint64(1500000), stats.CPUUsage)
+		assert.Equal(t, uint64(8192), stats.MemoryUsage)
+		assert.Equal(t, uint64(6144), stats.MemoryWorkingSet)
+		assert.Equal(t, uint64(0), stats.MemoryLimit)
+		assert.Equal(t, uint64(101), stats.BlkioReadBytes)
+		assert.Equal(t, uint64(202), stats.BlkioWriteBytes)
+	}
+}
+
+func TestSampleCPUPercent(t *testing.T) {
+	c, clean := newTestCollector(t, map[string]string{
+		"proc/100/cgroup":           "0::/pod\n",
+		"proc/100/net/dev":          netDev,
+		"cgroup/pod/cpu.stat":       "usage_usec 1000\n",
+		"cgroup/pod/memory.current": "0\n",
+		"cgroup/pod/memory.stat":    "",
+	})
+	defer clean()
+
+	c.Sample()
+	first := c.GetStats("web")
+	assert.Equal(t, float64(0), first.CPUPercent)
+	//pretend last sampling is one second ago, and container consumes half core since then
+	first.Timestamp = first.Timestamp.Add(-time.Second)
+	first.CPUUsage = 0
+	c.stats["web"] = first
+	writeFiles(t, c.cgroupRoot, map[string]string{"pod/cpu.stat": "usage_usec 500000\n"})
+
+	c.Sample()
+	second := c.GetStats("web")
+	assert.InDelta(t, 50, second.CPUPercent, 1)
+}
+
+func TestCollectMetrics(t *testing.T) {
+	c, clean := newTestCollector(t, map[string]string{
+		"proc/100/cgroup":           "0::/pod\n",
+		"proc/100/net/dev":          netDev,
+		"cgroup/pod/cpu.stat":       "usage_usec 1000\n",
+		"cgroup/pod/memory.current": "4096\n",
+		"cgroup/pod/memory.max":     "8192\n",
+		"cgroup/pod/memory.stat":    "",
+	})
+	defer clean()
+	c.Sample()
+
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, registry.Register(c))
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.Equal(t, len(metrics), len(families))
+	for _, family := range families {
+		if !strings.HasSuffix(family.GetName(), "memory_limit_bytes") {
+			continue
+		}
+		assert.Equal(t, "bkbcs_container_memory_limit_bytes", family.GetName())
+		assert.Equal(t, float64(8192), family.GetMetric()[0].GetGauge().GetValue())
+		assert.Equal(t, 3, len(family.GetMetric()[0].GetLabel()))
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package collector
+
+import (
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "bkbcs"
+	metricsSubsystem = "container"
+)
+
+var metricsLabels = []string{"taskgroup", "task", "container"}
+
+//metric description & value getter of container stats
+type metric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(stats *schedTypes.ContainerStats) float64
+}
+
+func newMetric(name, help string, valueType prometheus.ValueType, value func(stats *schedTypes.ContainerStats) float64) *metric {
+	return &metric{
+		desc:      prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name), help, metricsLabels, nil),
+		valueType: valueType,
+		value:     value,
+	}
+}
+
+var metrics = []*metric{
+	newMetric("cpu_usage_seconds_total", "Cumulative cpu time consumed by container in seconds.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.CPUUsage) / 1e9 }),
+	newMetric("cpu_usage_percent", "Cpu usage percent of container in last sampling interval, 100 for one core.", prometheus.GaugeValue,
+		func(s *schedTypes.ContainerStats) float64 { return s.CPUPercent }),
+	newMetric("memory_usage_bytes", "Current memory usage of container in bytes, including cache.", prometheus.GaugeValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.MemoryUsage) }),
+	newMetric("memory_working_set_bytes", "Current working set of container in bytes.", prometheus.GaugeValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.MemoryWorkingSet) }),
+	newMetric("memory_limit_bytes", "Memory limit of container in bytes, 0 for no limit.", prometheus.GaugeValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.MemoryLimit) }),
+	newMetric("network_receive_bytes_total", "Cumulative count of bytes received by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.NetRxBytes) }),
+	newMetric("network_receive_packets_total", "Cumulative count of packets received by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.NetRxPackets) }),
+	newMetric("network_transmit_bytes_total", "Cumulative count of bytes transmitted by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.NetTxBytes) }),
+	newMetric("network_transmit_packets_total", "Cumulative count of packets transmitted by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.NetTxPackets) }),
+	newMetric("blkio_read_bytes_total", "Cumulative count of bytes read from block devices by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.BlkioReadBytes) }),
+	newMetric("blkio_write_bytes_total", "Cumulative count of bytes written to block devices by container.", prometheus.CounterValue,
+		func(s *schedTypes.ContainerStats) float64 { return float64(s.BlkioWriteBytes) }),
+}
+
+//Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range metrics {
+		ch <- m.desc
+	}
+}
+
+//Collect implements prometheus.Collector, export latest sampled stats
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for name, stats := range c.stats {
+		target := c.targets[name]
+		for _, m := range metrics {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, m.value(stats), target.TaskGroup, target.TaskID, target.Name)
+		}
+	}
+}
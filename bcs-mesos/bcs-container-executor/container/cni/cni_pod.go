@@ -73,6 +73,21 @@ func NewPod(operator container.Container, tasks []*container.BcsContainerTask,
 		logs.Errorf("Create CNIPod error, Container tasks are 0")
 		return nil
 	}
+	//init containers keep their order and run before all app containers
+	var initTasks []*container.BcsContainerTask
+	var appTasks []*container.BcsContainerTask
+	for _, task := range tasks {
+		if task.InitContainer {
+			initTasks = append(initTasks, task)
+		} else {
+			appTasks = append(appTasks, task)
+		}
+	}
+	if len(appTasks) == 0 {
+		logs.Errorf("Create CNIPod error, app Container tasks are 0")
+		return nil
+	}
+	tasks = appTasks
 	taskMap := make(map[string]*container.BcsContainerTask)
 	for _, task := range tasks {
 		taskMap[task.Name] = task
@@ -90,6 +105,7 @@ func NewPod(operator container.Container, tasks []*container.BcsContainerTask,
 		networkName:      tasks[0].NetworkName,
 		conClient:        operator,
 		conTasks:         taskMap,
+		initTasks:        initTasks,
 		networkTaskId:    tasks[0].TaskId,
 		runningContainer: make(map[string]*container.BcsContainerInfo),
 	}
@@ -129,6 +145,7 @@ type CNIPod struct {
 	netTask          *container.BcsContainerTask            //network container task, using in Init stage
 	conClient        container.Container                    //container operator interface
 	conTasks         map[string]*container.BcsContainerTask //task for running containers, key is taskID
+	initTasks        []*container.BcsContainerTask          //init containers, run to completion in order before app containers
 	runningContainer map[string]*container.BcsContainerInfo //running container ID list for monitor
 	//container network flow limit args
 	NetLimit      *comtypes.NetLimit
@@ -318,6 +335,8 @@ func (p *CNIPod) Init() error {
 
 //Finit cni pod finit, close network infrastructure
 func (p *CNIPod) Finit() error {
+	//emptyDir volumes are removed after all containers stopped
+	defer container.CleanVolumes(p.allTasks())
 	if p.netTask != nil && p.netTask.RuntimeConf != nil && p.netTask.RuntimeConf.ID != "" {
 		//kill network infrastructure
 		if err := p.conClient.StopContainer(p.netTask.RuntimeConf.ID, 1); err != nil {
@@ -337,6 +356,18 @@ func (p *CNIPod) Finit() error {
 func (p *CNIPod) Start() error {
 	p.status = container.PodStatus_STARTING
 	p.message = "Pod is starting"
+	//init containers share network infrastructure, run one by one
+	for _, task := range p.initTasks {
+		task.NetworkName = "container:" + p.GetContainerID()
+		task.Env = append(task.Env, container.BcsKV{Key: "BCS_CONTAINER_IP", Value: p.cniIPAddr})
+		container.EnvOperCopy(task)
+		if err := container.RunInitContainer(p.podCxt, p.conClient, task); err != nil {
+			logs.Errorf("CNIPod running init container failed, err: %s\n", err.Error())
+			p.status = container.PodStatus_FAILED
+			p.message = err.Error()
+			return err
+		}
+	}
 	for name, task := range p.conTasks {
 		//create container attach to network infrastructure
 		task.NetworkName = "container:" + p.GetContainerID()
@@ -426,6 +457,11 @@ func (p *CNIPod) Start() error {
 		p.runningContainer[task.RuntimeConf.Name] = task.RuntimeConf
 		//starting health
 		logs.Infof("Pod add container %s in running container.\n", task.RuntimeConf.Name)
+		if err := container.RunPostStartHook(p.conClient, task, p.cniIPAddr); err != nil {
+			task.RuntimeConf.Message = err.Error()
+			p.startFailedStop(err)
+			return err
+		}
 	}
 	//all container starting, start containerWatch
 	watchCxt, _ := context.WithCancel(p.podCxt)
@@ -441,16 +477,24 @@ func (p *CNIPod) Stop(graceExit int) {
 	defer p.lock.Unlock()
 	p.podCancel()
 	logs.Infof("CNIPod prepare to stop %d running containers\n", len(p.runningContainer))
+	//hooks run without lock, running containers may be changed by containers watch
+	var names []string
 	for name := range p.runningContainer {
+		names = append(names, name)
+	}
+	for _, name := range names {
 		//preStop event
 		task := p.conTasks[name]
 		if p.events != nil && p.events.PreStop != nil {
 			p.events.PreStop(task)
 		}
-		if task.HealthCheck != nil {
-			task.HealthCheck.Stop()
-		}
-		if err := p.conClient.StopContainer(name, task.KillPolicy); err != nil {
+		task.StopProbes()
+		//preStop hook runs within grace period of kill policy, release lock
+		//so that container infos can be read while hook is running
+		p.lock.Unlock()
+		grace := container.RunPreStopHook(p.conClient, task, p.cniIPAddr)
+		p.lock.Lock()
+		if err := p.conClient.StopContainer(name, grace); err != nil {
 			logs.Errorf("CNIPod stop container %s failed: %s\n", name, err.Error())
 			//todo(developerJim): if container daemon connection broken, maybe try again later
 			continue
@@ -545,9 +589,7 @@ func (p *CNIPod) runningFailedStop(err error) {
 			logs.Errorf("CNIPod lost previous running container %s info\n", name)
 			continue
 		}
-		if task.HealthCheck != nil {
-			task.HealthCheck.Stop()
-		}
+		task.StopProbes()
 		p.conClient.StopContainer(name, task.KillPolicy)
 		if task.AutoRemove {
 			p.conClient.RemoveContainer(name, true)
@@ -582,6 +624,8 @@ func (p *CNIPod) containersWatch(cxt context.Context) {
 	}
 
 	tick := time.NewTicker(defaultPodWatchInterval * time.Second)
+	volumeTick := time.NewTicker(container.DefaultVolumeCheckInterval)
+	defer volumeTick.Stop()
 	for {
 		select {
 		case <-cxt.Done():
@@ -592,10 +636,38 @@ func (p *CNIPod) containersWatch(cxt context.Context) {
 				logs.Infof("Pod watch for container exit: %s", err.Error())
 				return
 			}
+		case <-volumeTick.C:
+			if err := p.volumeCheck(); err != nil {
+				logs.Infof("CNIPod watch for volume exit: %s", err.Error())
+				return
+			}
 		} //end select
 	}
 }
 
+//volumeCheck stop all containers when emptyDir or local volume exceeds its size limit
+func (p *CNIPod) volumeCheck() error {
+	err := container.CheckVolumes(p.allTasks())
+	if err == nil {
+		return nil
+	}
+	logs.Errorf("CNIPod %s\n", err.Error())
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.exitCode = ContainerStatusAbnormal
+	p.runningFailedStop(fmt.Errorf("Pod failed because %s", err.Error()))
+	return err
+}
+
+//allTasks init containers and app containers of pod
+func (p *CNIPod) allTasks() []*container.BcsContainerTask {
+	tasks := append([]*container.BcsContainerTask{}, p.initTasks...)
+	for _, task := range p.conTasks {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
 func (p *CNIPod) containerCheck() error {
 	running := 0
 	healthyCount := 0
@@ -625,12 +697,6 @@ func (p *CNIPod) containerCheck() error {
 			case container.ContainerStatus_RUNNING, container.ContainerStatus_PAUSED:
 				//update status
 				task.RuntimeConf.Message = "container is running, healthy status unkown"
-				if task.HealthCheck != nil && !task.HealthCheck.IsStarting() {
-					//health check starting when Status become RUNNING
-					logs.Infof("container [%s] is running, healthy status unkown, starting HealthyChecker with ip: %s\n", task.RuntimeConf.Name, p.cniIPAddr)
-					task.HealthCheck.SetHost(p.cniIPAddr)
-					go task.HealthCheck.Start()
-				}
 				running++
 				if running == len(p.runningContainer) && p.status != container.PodStatus_RUNNING {
 					p.status = container.PodStatus_RUNNING
@@ -640,9 +706,7 @@ func (p *CNIPod) containerCheck() error {
 			case container.ContainerStatus_EXITED, container.ContainerStatus_DEAD:
 				//one container down, update dead container and then KILL all left
 				logs.Infof("CNIPod Get container %s #%s#, ready clean all containers\n", task.RuntimeConf.Name, task.RuntimeConf.Status)
-				if task.HealthCheck != nil {
-					task.HealthCheck.Stop()
-				}
+				task.StopProbes()
 				delete(p.runningContainer, name)
 
 				p.exitCode = task.RuntimeConf.ExitCode
@@ -655,7 +719,7 @@ func (p *CNIPod) containerCheck() error {
 
 				//stop running container
 				p.runningFailedStop(fmt.Errorf("Pod failed because %s", task.RuntimeConf.Message))
-				return fmt.Errorf(task.RuntimeConf.Message)
+				return fmt.Errorf("%s", task.RuntimeConf.Message)
 
 				/*case container.ContainerStatus_PAUSED:
 				logs.Infof("CNIPod Get container %s #%s#, ready clean all containers\n", task.RuntimeConf.Name, task.RuntimeConf.Status)
@@ -672,6 +736,10 @@ func (p *CNIPod) containerCheck() error {
 				return fmt.Errorf("container is down")*/
 			} //end of switch
 		} //end if
+		if task.RuntimeConf.Status == container.ContainerStatus_RUNNING || task.RuntimeConf.Status == container.ContainerStatus_PAUSED {
+			//health check starting when Status become RUNNING, liveness and readiness checkers wait for startup checker
+			task.StartProbes(p.cniIPAddr)
+		}
 		if task.HealthCheck != nil && task.HealthCheck.IsStarting() {
 			if task.HealthCheck.IsHealthy() {
 				task.RuntimeConf.Healthy = true
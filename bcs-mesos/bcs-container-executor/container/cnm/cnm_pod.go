@@ -48,6 +48,21 @@ func NewPod(operator container.Container, tasks []*container.BcsContainerTask, h
 		logs.Errorf("Create DockerPod error, Container tasks are 0")
 		return nil
 	}
+	//init containers keep their order and run before all app containers
+	var initTasks []*container.BcsContainerTask
+	var appTasks []*container.BcsContainerTask
+	for _, task := range tasks {
+		if task.InitContainer {
+			initTasks = append(initTasks, task)
+		} else {
+			appTasks = append(appTasks, task)
+		}
+	}
+	if len(appTasks) == 0 {
+		logs.Errorf("Create DockerPod error, app Container tasks are 0")
+		return nil
+	}
+	tasks = appTasks
 	taskMap := make(map[string]*container.BcsContainerTask)
 	if len(tasks) > 1 {
 		for _, task := range tasks[1:] {
@@ -62,6 +77,7 @@ func NewPod(operator container.Container, tasks []*container.BcsContainerTask, h
 		podCancel:        rCancel,
 		events:           handler,
 		netTask:          tasks[0],
+		initTasks:        initTasks,
 		conClient:        operator,
 		conTasks:         taskMap,
 		runningContainer: make(map[string]*container.BcsContainerInfo),
@@ -84,6 +100,7 @@ type DockerPod struct {
 	lock             sync.Mutex                             //lock for monitor & query
 	events           *container.PodEventHandler             //pod event changed callback collection
 	netTask          *container.BcsContainerTask            //network container task, using in Init stage
+	initTasks        []*container.BcsContainerTask          //init containers, run to completion in order before network container
 	conClient        container.Container                    //container operator interface
 	conTasks         map[string]*container.BcsContainerTask //task for running containers, key is taskID
 	runningContainer map[string]*container.BcsContainerInfo //running container Name list for monitor
@@ -193,6 +210,18 @@ func (p *DockerPod) Init() error {
 	// container, network container applies all PortMappings with docker
 	p.copyPortMappings()
 
+	//step: running init containers one by one
+	for _, task := range p.initTasks {
+		task.Env = append(task.Env, envHost)
+		container.EnvOperCopy(task)
+		if err := container.RunInitContainer(p.podCxt, p.conClient, task); err != nil {
+			logs.Errorf("DockerPod init failed in running init container, err: %s\n", err.Error())
+			p.status = container.PodStatus_FAILED
+			p.message = err.Error()
+			return err
+		}
+	}
+
 	//step: creating network container
 	var createErr error
 	if p.netTask.RuntimeConf, createErr = p.conClient.CreateContainer(p.netTask.Name, p.netTask); createErr != nil {
@@ -249,12 +278,17 @@ func (p *DockerPod) Init() error {
 	p.netTask.RuntimeConf.NetworkMode = info.NetworkMode
 	p.runningContainer[p.netTask.RuntimeConf.Name] = p.netTask.RuntimeConf
 	logs.Infof("DockerPod treat container [%s] net container, ip: %s\n", p.netTask.RuntimeConf.Name, p.cnmIPAddr)
+	if err := container.RunPostStartHook(p.conClient, p.netTask, p.cnmIPAddr); err != nil {
+		p.netTask.RuntimeConf.Message = err.Error()
+		p.startFailedStop(err)
+		return err
+	}
 	return nil
 }
 
-//Finit dockerpod finit, nothing to be release.
-//keep it empty
+//Finit dockerpod finit, only emptyDir volumes need to be released.
 func (p *DockerPod) Finit() error {
+	container.CleanVolumes(p.allTasks())
 	return nil
 }
 
@@ -323,6 +357,11 @@ func (p *DockerPod) Start() error {
 		}
 		p.runningContainer[task.RuntimeConf.Name] = task.RuntimeConf
 		logs.Infof("Pod add container %s in running container.\n", task.RuntimeConf.Name)
+		if err := container.RunPostStartHook(p.conClient, task, p.cnmIPAddr); err != nil {
+			task.RuntimeConf.Message = err.Error()
+			p.startFailedStop(err)
+			return err
+		}
 	}
 	p.conTasks[p.netTask.Name] = p.netTask
 	//all container starting, start containerWatch
@@ -339,17 +378,25 @@ func (p *DockerPod) Stop(graceExit int) {
 	defer p.lock.Unlock()
 	p.podCancel()
 	logs.Infof("DockerPod prepare to stop %d running containers\n", len(p.runningContainer))
+	//hooks run without lock, running containers may be changed by containers watch
+	var names []string
 	for name := range p.runningContainer {
+		names = append(names, name)
+	}
+	for _, name := range names {
 		//preStop event
 		task := p.conTasks[name]
 		if p.events != nil && p.events.PreStop != nil {
 			p.events.PreStop(task)
 		}
-		if task.HealthCheck != nil {
-			task.HealthCheck.Stop()
-		}
-
-		if err := p.conClient.StopContainer(name, task.KillPolicy); err != nil {
+		task.StopProbes()
+		//preStop hook runs within grace period of kill policy, release lock
+		//so that container infos can be read while hook is running
+		p.lock.Unlock()
+		grace := container.RunPreStopHook(p.conClient, task, p.cnmIPAddr)
+		p.lock.Lock()
+
+		if err := p.conClient.StopContainer(name, grace); err != nil {
 			logs.Errorf("DockerPod stop container %s failed: %s\n", name, err.Error())
 			//todo(developerJim): if container daemon connection broken, maybe try again later
 			continue
@@ -456,9 +503,7 @@ func (p *DockerPod) runningFailedStop(err error) {
 			logs.Errorf("DockerPod lost previous running container %s info\n", name)
 			continue
 		}
-		if task.HealthCheck != nil {
-			task.HealthCheck.Stop()
-		}
+		task.StopProbes()
 		p.conClient.StopContainer(name, task.KillPolicy)
 		if task.AutoRemove {
 			p.conClient.RemoveContainer(name, true)
@@ -493,6 +538,8 @@ func (p *DockerPod) containersWatch(cxt context.Context) {
 	}
 
 	tick := time.NewTicker(defaultPodWatchInterval * time.Second)
+	volumeTick := time.NewTicker(container.DefaultVolumeCheckInterval)
+	defer volumeTick.Stop()
 	//total := defaultErrTolerate * len(p.runningContainer)
 	for {
 		select {
@@ -503,10 +550,39 @@ func (p *DockerPod) containersWatch(cxt context.Context) {
 			if err := p.containerCheck(); err != nil {
 				return
 			}
+		case <-volumeTick.C:
+			if err := p.volumeCheck(); err != nil {
+				logs.Infof("DockerPod watch for volume exit: %s", err.Error())
+				return
+			}
 		} //end select
 	}
 }
 
+//volumeCheck stop all containers when emptyDir or local volume exceeds its size limit
+func (p *DockerPod) volumeCheck() error {
+	err := container.CheckVolumes(p.allTasks())
+	if err == nil {
+		return nil
+	}
+	logs.Errorf("DockerPod %s\n", err.Error())
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.exitCode = ContainerStatusAbnormal
+	p.runningFailedStop(fmt.Errorf("Pod failed because %s", err.Error()))
+	return err
+}
+
+//allTasks init containers and app containers of pod
+func (p *DockerPod) allTasks() []*container.BcsContainerTask {
+	tasks := append([]*container.BcsContainerTask{}, p.initTasks...)
+	tasks = append(tasks, p.netTask)
+	for _, task := range p.conTasks {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
 func (p *DockerPod) containerCheck() error {
 	running := 0
 	healthyCount := 0
@@ -542,12 +618,6 @@ func (p *DockerPod) containerCheck() error {
 			case container.ContainerStatus_RUNNING, container.ContainerStatus_PAUSED:
 				//update status
 				task.RuntimeConf.Message = "container is running, healthy status unkown"
-				if task.HealthCheck != nil && !task.HealthCheck.IsStarting() {
-					//health check starting when Status become RUNNING
-					logs.Infof("container [%s] is running, healthy status unkown, starting HealthyChecker, ip: %s\n", task.RuntimeConf.Name, p.cnmIPAddr)
-					task.HealthCheck.SetHost(p.cnmIPAddr)
-					go task.HealthCheck.Start()
-				}
 				running++
 				if running == len(p.runningContainer) && p.status != container.PodStatus_RUNNING {
 					logs.Infoln("DockerPod status is first changing to RUNNING")
@@ -558,9 +628,7 @@ func (p *DockerPod) containerCheck() error {
 			case container.ContainerStatus_EXITED, container.ContainerStatus_DEAD:
 				//one container down, update dead container and then KILL all left
 				logs.Infof("DockerPod Get container %s #%s#, ready clean all containers\n", task.RuntimeConf.Name, task.RuntimeConf.Status)
-				if task.HealthCheck != nil {
-					task.HealthCheck.Stop()
-				}
+				task.StopProbes()
 				delete(p.runningContainer, name)
 
 				p.exitCode = task.RuntimeConf.ExitCode
@@ -573,7 +641,7 @@ func (p *DockerPod) containerCheck() error {
 
 				//stop running container
 				p.runningFailedStop(fmt.Errorf("Pod failed because %s", task.RuntimeConf.Message))
-				return fmt.Errorf(task.RuntimeConf.Message)
+				return fmt.Errorf("%s", task.RuntimeConf.Message)
 
 				/*case container.ContainerStatus_PAUSED:
 				logs.Infof("DockerPod Get container %s #%s#, ready clean all containers\n", task.RuntimeConf.Name, task.RuntimeConf.Status)
@@ -590,6 +658,10 @@ func (p *DockerPod) containerCheck() error {
 				return fmt.Errorf("container is paused")*/
 			} //end of switch
 		} //end if
+		if task.RuntimeConf.Status == container.ContainerStatus_RUNNING || task.RuntimeConf.Status == container.ContainerStatus_PAUSED {
+			//health check starting when Status become RUNNING, liveness and readiness checkers wait for startup checker
+			task.StartProbes(p.cnmIPAddr)
+		}
 		if task.HealthCheck != nil && task.HealthCheck.IsStarting() {
 			if task.HealthCheck.IsHealthy() {
 				task.RuntimeConf.Healthy = true
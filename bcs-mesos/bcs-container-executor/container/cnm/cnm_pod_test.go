@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cnm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	comtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-container-executor/container"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+//stopContainer records stop operations, exec blocks until release is closed
+type stopContainer struct {
+	container.Container
+	sync.Mutex
+	calls    []string
+	execExit int
+	running  chan struct{}
+	release  chan struct{}
+}
+
+func (c *stopContainer) record(format string, args ...interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+func (c *stopContainer) StopContainer(name string, timeout int) error {
+	c.record("stop %s %d", name, timeout)
+	return nil
+}
+
+func (c *stopContainer) RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	c.record("exec %s %s", ops.ContainerId, strings.Join(ops.Cmd, " "))
+	if c.running != nil {
+		close(c.running)
+		<-c.release
+	}
+	return &schedTypes.ResponseCommandTask{
+		Status:      comtypes.TaskCommandStatusFinish,
+		CommInspect: &comtypes.CommandInspectInfo{ExitCode: c.execExit},
+	}, nil
+}
+
+func newStopPod(operator container.Container) *DockerPod {
+	task := &container.BcsContainerTask{
+		Name:       "app",
+		KillPolicy: 10,
+		Lifecycle: &comtypes.Lifecycle{
+			PreStop: &comtypes.LifecycleHandler{Exec: &comtypes.ExecAction{Command: []string{"nginx", "-s", "quit"}}},
+		},
+		RuntimeConf: &container.BcsContainerInfo{Name: "app", Status: container.ContainerStatus_RUNNING},
+	}
+	cxt, cancel := context.WithCancel(context.Background())
+	return &DockerPod{
+		podCxt:           cxt,
+		podCancel:        cancel,
+		netTask:          task,
+		conClient:        operator,
+		conTasks:         map[string]*container.BcsContainerTask{"app": task},
+		runningContainer: map[string]*container.BcsContainerInfo{"app": task.RuntimeConf},
+	}
+}
+
+func TestDockerPodStopPreStopHook(t *testing.T) {
+	operator := &stopContainer{running: make(chan struct{}), release: make(chan struct{})}
+	pod := newStopPod(operator)
+	var events []string
+	pod.events = &container.PodEventHandler{
+		PreStop: func(task *container.BcsContainerTask) error {
+			events = append(events, "preStop "+task.Name)
+			return nil
+		},
+		PostStop: func(task *container.BcsContainerTask) error {
+			events = append(events, "postStop "+task.Name)
+			return nil
+		},
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		pod.Stop(10)
+		close(stopped)
+	}()
+	<-operator.running
+	//pod lock is released while hook is running
+	infos := make(chan []*container.BcsContainerInfo)
+	go func() {
+		infos <- pod.GetContainers()
+	}()
+	select {
+	case got := <-infos:
+		assert.Equal(t, 1, len(got))
+	case <-time.After(time.Second):
+		t.Fatal("GetContainers is blocked by preStop hook")
+	}
+	close(operator.release)
+	<-stopped
+
+	//hook runs before container stopped, and grace period is left for stopping
+	assert.Equal(t, []string{"exec app nginx -s quit", "stop app 10"}, operator.calls)
+	assert.Equal(t, []string{"preStop app", "postStop app"}, events)
+	assert.Equal(t, container.ContainerStatus_EXITED, pod.netTask.RuntimeConf.Status)
+	assert.Equal(t, container.PodStatus_KILLED, pod.GetPodStatus())
+}
+
+func TestDockerPodStopPreStopHookFailed(t *testing.T) {
+	operator := &stopContainer{execExit: 1}
+	pod := newStopPod(operator)
+	pod.Stop(10)
+	//failed hook does not block stopping container
+	assert.Equal(t, []string{"exec app nginx -s quit", "stop app 10"}, operator.calls)
+	assert.Equal(t, container.PodStatus_KILLED, pod.GetPodStatus())
+}
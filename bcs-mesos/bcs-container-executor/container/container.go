@@ -15,8 +15,11 @@ package container
 
 import (
 	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"io"
 	"strings"
 	"time"
+
+	"golang.org/x/net/context"
 )
 
 const (
@@ -47,6 +50,9 @@ type BcsContainerInfo struct {
 	Healthy                 bool                   `json:"Healthy,omitempty"`     //Container healthy
 	IsChecked               bool                   `json:",omitempty"`            //is health check
 	ConsecutiveFailureTimes int                    `json:",omitempty"`            //consecutive failure times
+	HealthCheckMessage      string                 `json:",omitempty"`            //message of last health check
+	Ready                   bool                   `json:",omitempty"`            //readiness probe succeeds
+	Started                 bool                   `json:",omitempty"`            //startup probe succeeds
 	ExitCode                int                    `json:"ExitCode,omitempty"`    //container exit code
 	Hostname                string                 `json:"Hostname,omitempty"`    //container host name
 	NetworkMode             string                 `json:"NetworkMode,omitempty"` //Network mode for container
@@ -73,6 +79,7 @@ func (info *BcsContainerInfo) Update(other *BcsContainerInfo) {
 	info.Hostname = other.Hostname
 	info.IsChecked = other.IsChecked
 	info.ConsecutiveFailureTimes = other.ConsecutiveFailureTimes
+	info.HealthCheckMessage = other.HealthCheckMessage
 	if strings.Contains(other.NetworkMode, "container:") {
 		info.NetworkMode = "user"
 	} else {
@@ -132,4 +139,10 @@ type Container interface {
 
 	//exec command
 	RunCommandV2(ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error)
+	//exec command, command is killed when cxt is done
+	RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error)
+
+	//ContainerLogs follow stdout & stderr of container from the beginning,
+	//it blocks until container exits or cxt is done
+	ContainerLogs(cxt context.Context, containerID string, stdout, stderr io.Writer) error
 }
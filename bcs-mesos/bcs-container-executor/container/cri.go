@@ -0,0 +1,868 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"bk-bcs/bcs-mesos/bcs-container-executor/util"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	runtimeapi "bk-bcs/bcs-mesos/bcs-container-executor/container/cri/runtime/v1alpha2"
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+const (
+	//DefaultCRIEndpoint default cri endpoint of containerd
+	DefaultCRIEndpoint = "unix:///run/containerd/containerd.sock"
+	//DefaultCRIImageTool default tool for committing and pushing images, which cri has no api for
+	DefaultCRIImageTool = "nerdctl"
+	//CRINameLabel label for container name in cri runtime
+	CRINameLabel = "io.tencent.bcs.container.name"
+	//CRIRequestIPAnnotation annotation of sandbox for ip address request, cni plugin of runtime can use it
+	CRIRequestIPAnnotation = "io.tencent.bcs.netsvc.requestip"
+
+	criConnectTimeout = 10 * time.Second
+	criRequestTimeout = 2 * time.Minute
+	criPullTimeout    = 10 * time.Minute
+	criSandboxNS      = "bcs"
+	//criUploadChunk raw bytes of each exec when uploading file, keep command line small
+	criUploadChunk = 48 * 1024
+	//criLogDirectory directory under executor sandbox for container logs written by runtime
+	criLogDirectory = "cri-logs"
+	//criContainerdNamespace containerd namespace of containers created by cri plugin
+	criContainerdNamespace = "k8s.io"
+	//criLogPollInterval interval for checking new logs when tailing log file
+	criLogPollInterval = 500 * time.Millisecond
+)
+
+//criSandbox pod sandbox in cri runtime, network container creates it,
+//and other containers join it with network container:$name
+type criSandbox struct {
+	id      string
+	config  *runtimeapi.PodSandboxConfig
+	network string //network mode of sandbox
+	refs    int    //containers in sandbox, sandbox is removed when it becomes 0
+}
+
+//criFile file waiting for uploading after container started
+type criFile struct {
+	path    string
+	mode    os.FileMode
+	content []byte
+}
+
+//criInstance container created by CRIContainer
+type criInstance struct {
+	id       string
+	name     string
+	hostname string
+	sandbox  *criSandbox
+	resource *schedTypes.Resource
+	uploads  []*criFile
+	logPath  string //log file written by runtime
+}
+
+//CRIContainer implement container interface with
+//kubernetes CRI(containerd, cri-o) grpc api
+type CRIContainer struct {
+	user          string //login image hub user name
+	passwd        string //login image hub user password
+	endpoint      string //cri runtime endpoint, only unix domain socket
+	imageTool     string //command compatible with nerdctl for committing images, empty for not supported
+	logDir        string //root directory of sandbox log directory
+	conn          *grpc.ClientConn
+	runtimeClient runtimeapi.RuntimeServiceClient
+	imageClient   runtimeapi.ImageServiceClient
+	lock          sync.Mutex
+	containers    map[string]*criInstance //all created containers, key is container name
+}
+
+//NewCRIContainer create CRIContainer manager tool
+//endpoint is unix domain socket, like unix:///run/containerd/containerd.sock
+//imageTool is the command compatible with nerdctl for committing images, like nerdctl
+func NewCRIContainer(endpoint, imageTool, user, passwd string) Container {
+	addr := strings.TrimPrefix(endpoint, "unix://")
+	conn, err := grpc.Dial(addr,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithTimeout(criConnectTimeout),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Create CRIContainer Manager with %s failed: %s\n", endpoint, err.Error())
+		return nil
+	}
+	workDir, _ := os.Getwd()
+	cri := &CRIContainer{
+		user:          user,
+		passwd:        passwd,
+		endpoint:      endpoint,
+		imageTool:     imageTool,
+		logDir:        filepath.Join(workDir, criLogDirectory),
+		conn:          conn,
+		runtimeClient: runtimeapi.NewRuntimeServiceClient(conn),
+		imageClient:   runtimeapi.NewImageServiceClient(conn),
+		containers:    make(map[string]*criInstance),
+	}
+	//check cri version for remote runtime
+	cxt, cancel := context.WithTimeout(context.Background(), criConnectTimeout)
+	defer cancel()
+	version, err := cri.runtimeClient.Version(cxt, &runtimeapi.VersionRequest{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "CRIContainer get runtime version from %s failed: %s\n", endpoint, err.Error())
+		conn.Close()
+		return nil
+	}
+	logs.Infof("CRIContainer connect to runtime %s %s, api version %s\n", version.GetRuntimeName(), version.GetRuntimeVersion(), version.GetRuntimeApiVersion())
+	return cri
+}
+
+//getInstance get container by name or id, nil if container is not created by CRIContainer
+func (cri *CRIContainer) getInstance(nameOrID string) *criInstance {
+	cri.lock.Lock()
+	defer cri.lock.Unlock()
+	if inst, ok := cri.containers[nameOrID]; ok {
+		return inst
+	}
+	for _, inst := range cri.containers {
+		if inst.id == nameOrID {
+			return inst
+		}
+	}
+	return nil
+}
+
+//containerID get container id by name or id
+func (cri *CRIContainer) containerID(nameOrID string) string {
+	if inst := cri.getInstance(nameOrID); inst != nil {
+		return inst.id
+	}
+	return nameOrID
+}
+
+//RunCommand running command in Container
+func (cri *CRIContainer) RunCommand(containerID string, command []string) error {
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	response, err := cri.runtimeClient.ExecSync(cxt, &runtimeapi.ExecSyncRequest{
+		ContainerId: cri.containerID(containerID),
+		Cmd:         command,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "CRI exec in container %s failed: %s\n", containerID, err.Error())
+		return err
+	}
+	if response.GetExitCode() != 0 {
+		return fmt.Errorf("command exit code %d, %s", response.GetExitCode(), string(response.GetStderr()))
+	}
+	return nil
+}
+
+//RunCommandV2 exec command in container and wait for result,
+//user and privileged are not supported by cri exec
+func (cri *CRIContainer) RunCommandV2(ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	return cri.RunCommandContext(context.Background(), ops)
+}
+
+//RunCommandContext exec command in container and wait for result, deadline of cxt
+//is passed to runtime as exec timeout, runtime kills exec process when it expires
+func (cri *CRIContainer) RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	by, _ := json.Marshal(ops)
+	logs.Infof("cri run command %s", string(by))
+	resp := &schedTypes.ResponseCommandTask{
+		ID:          ops.ID,
+		TaskId:      ops.TaskId,
+		ContainerId: ops.ContainerId,
+	}
+	cmd := ops.Cmd
+	if len(ops.Env) > 0 {
+		cmd = append(append([]string{"env"}, ops.Env...), ops.Cmd...)
+	}
+	request := &runtimeapi.ExecSyncRequest{
+		ContainerId: cri.containerID(ops.ContainerId),
+		Cmd:         cmd,
+	}
+	if deadline, ok := cxt.Deadline(); ok {
+		//round up, zero timeout means no timeout for runtime
+		request.Timeout = int64(math.Ceil(time.Until(deadline).Seconds()))
+		if request.Timeout <= 0 {
+			request.Timeout = 1
+		}
+	}
+	cxt, cancel := context.WithTimeout(cxt, criRequestTimeout)
+	defer cancel()
+	response, err := cri.runtimeClient.ExecSync(cxt, request)
+	if err != nil {
+		logs.Errorf("cri exec error %s", err.Error())
+		resp.Status = commtypes.TaskCommandStatusFailed
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	logs.Infof("cri container %s run command success", ops.ContainerId)
+
+	out := response.GetStdout()
+	if len(out) > 256 {
+		out = out[:256]
+	}
+	errB := response.GetStderr()
+	if len(errB) > 256 {
+		errB = errB[:256]
+	}
+	resp.Status = commtypes.TaskCommandStatusFinish
+	resp.CommInspect = &commtypes.CommandInspectInfo{
+		ExitCode: int(response.GetExitCode()),
+		Stdout:   string(out),
+		Stderr:   string(errB),
+	}
+	return resp, nil
+}
+
+//UploadToContainer upload file from host to Container, file is placed in directory of dest
+//with source file name like docker. cri has no copy api, file is written by exec
+//after container started, so sh & base64 are required in container image
+func (cri *CRIContainer) UploadToContainer(containerID string, source, dest string) error {
+	fileInfo, err := os.Stat(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "State %s failed.\n", source)
+		return err
+	}
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Read source %s failed!\n", source)
+		return err
+	}
+	destPath, absErr := filepath.Abs(filepath.Dir(dest))
+	if absErr != nil {
+		fmt.Fprintf(os.Stderr, "Get dest %s abs Err: %s\n", dest, absErr.Error())
+		return absErr
+	}
+	file := &criFile{
+		path:    filepath.Join(destPath, filepath.Base(source)),
+		mode:    fileInfo.Mode().Perm(),
+		content: content,
+	}
+	info, err := cri.InspectContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if info.Status == ContainerStatus_RUNNING {
+		return cri.writeFile(info.ID, file)
+	}
+	//container is not running, upload when it starts
+	inst := cri.getInstance(containerID)
+	if inst == nil {
+		return fmt.Errorf("container %s is not running", containerID)
+	}
+	cri.lock.Lock()
+	inst.uploads = append(inst.uploads, file)
+	cri.lock.Unlock()
+	logs.Infof("container %s is %s, file %s will be uploaded after started\n", containerID, info.Status, file.path)
+	return nil
+}
+
+//writeFile write file in running container with exec
+func (cri *CRIContainer) writeFile(containerID string, file *criFile) error {
+	redirect := ">"
+	for start := 0; start == 0 || start < len(file.content); start += criUploadChunk {
+		end := start + criUploadChunk
+		if end > len(file.content) {
+			end = len(file.content)
+		}
+		chunk := base64.StdEncoding.EncodeToString(file.content[start:end])
+		script := fmt.Sprintf("mkdir -p \"$(dirname \"$1\")\" && printf '%%s' \"$2\" | base64 -d %s \"$1\"", redirect)
+		if err := cri.RunCommand(containerID, []string{"sh", "-c", script, "sh", file.path, chunk}); err != nil {
+			return fmt.Errorf("write file %s failed, %s", file.path, err.Error())
+		}
+		redirect = ">>"
+	}
+	mode := fmt.Sprintf("%o", file.mode)
+	return cri.RunCommand(containerID, []string{"chmod", mode, file.path})
+}
+
+//ListContainer list all running containner info
+func (cri *CRIContainer) ListContainer() {
+
+}
+
+//CreateContainer create container with cri, network container creates pod sandbox,
+//other containers with network container:$name join the sandbox of network container
+func (cri *CRIContainer) CreateContainer(containerName string, containerTask *BcsContainerTask) (*BcsContainerInfo, error) {
+	//check images
+	imageList, _ := cri.ListImage(containerTask.Image)
+	//ready to pull if user setting pullImageForce
+	if len(imageList) == 0 || containerTask.ForcePullImage {
+		if pullErr := cri.PullImage(containerTask.Image); pullErr != nil {
+			return nil, pullErr
+		}
+	}
+	fmt.Fprintf(os.Stdout, "CreateContainer %s, hostname: %s\n", containerName, containerTask.HostName)
+
+	if err := setupVolumes(containerTask); err != nil {
+		fmt.Fprintf(os.Stderr, "Create Container %s volumes failed: %s\n", containerName, err.Error())
+		return nil, err
+	}
+	sandbox, err := cri.prepareSandbox(containerName, containerTask)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Create Container %s sandbox failed: %s\n", containerName, err.Error())
+		return nil, err
+	}
+	config := cri.containerConfig(containerName, containerTask, sandbox)
+	by, _ := json.Marshal(config)
+	logs.Infof("create container %s in sandbox %s data %s", containerName, sandbox.id, string(by))
+
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	response, err := cri.runtimeClient.CreateContainer(cxt, &runtimeapi.CreateContainerRequest{
+		PodSandboxId:  sandbox.id,
+		Config:        config,
+		SandboxConfig: sandbox.config,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Create Container %s failed: %s\n", containerName, err.Error())
+		cri.releaseSandbox(sandbox)
+		return nil, err
+	}
+	cri.lock.Lock()
+	cri.containers[containerName] = &criInstance{
+		id:       response.GetContainerId(),
+		name:     containerName,
+		hostname: containerTask.HostName,
+		sandbox:  sandbox,
+		resource: containerTask.Resource,
+		logPath:  filepath.Join(sandbox.config.GetLogDirectory(), config.GetLogPath()),
+	}
+	cri.lock.Unlock()
+	fmt.Fprintf(os.Stdout, "Success to create container(ID:%s)\n", response.GetContainerId())
+	return &BcsContainerInfo{
+		ID:   response.GetContainerId(),
+		Name: containerName,
+	}, nil
+}
+
+//prepareSandbox get sandbox for container, create one if container does not join other container
+func (cri *CRIContainer) prepareSandbox(containerName string, containerTask *BcsContainerTask) (*criSandbox, error) {
+	if strings.HasPrefix(containerTask.NetworkName, "container:") {
+		target := cri.getInstance(strings.TrimPrefix(containerTask.NetworkName, "container:"))
+		if target == nil {
+			return nil, fmt.Errorf("network %s is not created by cri runtime", containerTask.NetworkName)
+		}
+		cri.lock.Lock()
+		target.sandbox.refs++
+		cri.lock.Unlock()
+		return target.sandbox, nil
+	}
+
+	namespace := &runtimeapi.NamespaceOption{}
+	if containerTask.NetworkName == "host" {
+		namespace.Network = runtimeapi.NamespaceMode_NODE
+	}
+	config := &runtimeapi.PodSandboxConfig{
+		Metadata: &runtimeapi.PodSandboxMetadata{
+			Name:      containerName,
+			Uid:       containerName,
+			Namespace: criSandboxNS,
+		},
+		Hostname:     containerTask.HostName,
+		LogDirectory: filepath.Join(cri.logDir, containerName),
+		Labels:       map[string]string{CRINameLabel: containerName},
+		Annotations:  make(map[string]string),
+		Linux: &runtimeapi.LinuxPodSandboxConfig{
+			SecurityContext: &runtimeapi.LinuxSandboxSecurityContext{
+				NamespaceOptions: namespace,
+				Privileged:       containerTask.Privileged,
+			},
+		},
+	}
+	if containerTask.NetworkIPAddr != "" {
+		config.Annotations[CRIRequestIPAnnotation] = containerTask.NetworkIPAddr
+	}
+	for key, value := range containerTask.PortBindings {
+		hostPort, _ := strconv.Atoi(value.HostPort)
+		containerPort, _ := strconv.Atoi(value.ContainerPort)
+		protocol := runtimeapi.Protocol_TCP
+		if strings.HasSuffix(key, "/udp") {
+			protocol = runtimeapi.Protocol_UDP
+		}
+		config.PortMappings = append(config.PortMappings, &runtimeapi.PortMapping{
+			Protocol:      protocol,
+			ContainerPort: int32(containerPort),
+			HostPort:      int32(hostPort),
+			HostIp:        value.HostIP,
+		})
+	}
+
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	response, err := cri.runtimeClient.RunPodSandbox(cxt, &runtimeapi.RunPodSandboxRequest{Config: config})
+	if err != nil {
+		return nil, err
+	}
+	logs.Infof("CRIContainer run sandbox %s for container %s, network %s\n", response.GetPodSandboxId(), containerName, containerTask.NetworkName)
+	return &criSandbox{
+		id:      response.GetPodSandboxId(),
+		config:  config,
+		network: containerTask.NetworkName,
+		refs:    1,
+	}, nil
+}
+
+//releaseSandbox stop & remove sandbox when no container in it
+func (cri *CRIContainer) releaseSandbox(sandbox *criSandbox) {
+	cri.lock.Lock()
+	sandbox.refs--
+	refs := sandbox.refs
+	cri.lock.Unlock()
+	if refs > 0 {
+		return
+	}
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	if _, err := cri.runtimeClient.StopPodSandbox(cxt, &runtimeapi.StopPodSandboxRequest{PodSandboxId: sandbox.id}); err != nil {
+		logs.Errorf("CRIContainer stop sandbox %s failed, %s\n", sandbox.id, err.Error())
+	}
+	if _, err := cri.runtimeClient.RemovePodSandbox(cxt, &runtimeapi.RemovePodSandboxRequest{PodSandboxId: sandbox.id}); err != nil {
+		logs.Errorf("CRIContainer remove sandbox %s failed, %s\n", sandbox.id, err.Error())
+		return
+	}
+	logs.Infof("CRIContainer remove sandbox %s success\n", sandbox.id)
+}
+
+//containerConfig convert BcsContainerTask to cri container config
+func (cri *CRIContainer) containerConfig(containerName string, containerTask *BcsContainerTask, sandbox *criSandbox) *runtimeapi.ContainerConfig {
+	config := &runtimeapi.ContainerConfig{
+		Metadata: &runtimeapi.ContainerMetadata{Name: containerName},
+		Image:    &runtimeapi.ImageSpec{Image: containerTask.Image},
+		Labels:   map[string]string{CRINameLabel: containerName},
+		LogPath:  containerName + ".log",
+		Linux: &runtimeapi.LinuxContainerConfig{
+			Resources: criResources(containerTask.Resource, containerTask.LimitResource),
+			SecurityContext: &runtimeapi.LinuxContainerSecurityContext{
+				Capabilities:     &runtimeapi.Capability{AddCapabilities: []string{"SYS_PTRACE"}},
+				Privileged:       containerTask.Privileged,
+				NamespaceOptions: &runtimeapi.NamespaceOption{},
+			},
+		},
+	}
+	if sandbox.network == "host" {
+		config.Linux.SecurityContext.NamespaceOptions.Network = runtimeapi.NamespaceMode_NODE
+	}
+	if containerTask.Ipc == "host" {
+		config.Linux.SecurityContext.NamespaceOptions.Ipc = runtimeapi.NamespaceMode_NODE
+	}
+	//command in BcsContainerTask overwrites image cmd like docker, entrypoint is kept
+	if containerTask.Command != "" {
+		config.Args = append(config.Args, containerTask.Command)
+	}
+	config.Args = append(config.Args, containerTask.Args...)
+	for _, env := range containerTask.Env {
+		config.Envs = append(config.Envs, &runtimeapi.KeyValue{Key: env.Key, Value: env.Value})
+	}
+	for _, volumn := range containerTask.Volums {
+		config.Mounts = append(config.Mounts, &runtimeapi.Mount{
+			HostPath:      volumn.HostPath,
+			ContainerPath: volumn.ContainerPath,
+			Readonly:      volumn.ReadOnly,
+		})
+	}
+	for _, kv := range containerTask.Labels {
+		config.Labels[kv.Key] = kv.Value
+	}
+	return config
+}
+
+//criResources convert request & limit resource to cri linux resources, same as docker
+func criResources(request, limit *schedTypes.Resource) *runtimeapi.LinuxContainerResources {
+	resources := &runtimeapi.LinuxContainerResources{CpuShares: 1024}
+	if request == nil {
+		return resources
+	}
+	if request.Cpus > 0 {
+		resources.CpuShares = int64(request.Cpus * 1024)
+	}
+	if request.Mem >= 4 {
+		resources.MemoryLimitInBytes = int64(request.Mem * 1024 * 1024)
+	}
+	if float64(runtime.NumCPU()) > request.Cpus && request.CPUSet > 0 {
+		//only setting cpu info when cpu request can be met in slave host
+		cpuList, numaList := util.GetBindingCPUs(int(math.Ceil(request.Cpus)), int64(request.Mem))
+		if len(cpuList) > 0 {
+			resources.CpusetCpus = util.ListJoin(cpuList)
+			resources.CpusetMems = util.ListJoin(numaList)
+		}
+	}
+	if limit != nil && limit.Cpus > 0 {
+		resources.CpuPeriod = DefaultDockerCpuPeriod
+		resources.CpuQuota = int64(limit.Cpus * DefaultDockerCpuPeriod)
+	}
+	if limit != nil && limit.Mem >= 4 {
+		resources.MemoryLimitInBytes = int64(limit.Mem * 1024 * 1024)
+	}
+	return resources
+}
+
+//StartContainer starting container with container id return by CreateContainer,
+//files uploaded before starting are written after container started
+func (cri *CRIContainer) StartContainer(containerID string) error {
+	fmt.Fprintf(os.Stdout, "begin to start container(ID:%s)\n", containerID)
+	id := cri.containerID(containerID)
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	if _, err := cri.runtimeClient.StartContainer(cxt, &runtimeapi.StartContainerRequest{ContainerId: id}); err != nil {
+		fmt.Fprintf(os.Stderr, "Start Container(ID:%s) failed: %s\n", containerID, err.Error())
+		return err
+	}
+	if inst := cri.getInstance(containerID); inst != nil {
+		cri.lock.Lock()
+		uploads := inst.uploads
+		inst.uploads = nil
+		cri.lock.Unlock()
+		for _, file := range uploads {
+			if err := cri.writeFile(id, file); err != nil {
+				fmt.Fprintf(os.Stderr, "Upload file to Container(ID:%s) failed: %s\n", containerID, err.Error())
+				return err
+			}
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Success to start container(ID:%s)\n", containerID)
+	return nil
+}
+
+//StopContainer with container name. container will be killed when timeout
+func (cri *CRIContainer) StopContainer(containerName string, timeout int) error {
+	fmt.Fprintf(os.Stdout, "start stop container %s\n", containerName)
+	cxt, cancel := context.WithTimeout(context.Background(), time.Duration(timeout+StopContainerGraceTime)*time.Second)
+	defer cancel()
+	_, err := cri.runtimeClient.StopContainer(cxt, &runtimeapi.StopContainerRequest{
+		ContainerId: cri.containerID(containerName),
+		Timeout:     int64(timeout),
+	})
+	fmt.Fprintf(os.Stdout, "stop container %s done\n", containerName)
+	return err
+}
+
+//RemoveContainer remove container by name, sandbox is removed with the last container in it
+func (cri *CRIContainer) RemoveContainer(containerName string, force bool) error {
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	if _, err := cri.runtimeClient.RemoveContainer(cxt, &runtimeapi.RemoveContainerRequest{ContainerId: cri.containerID(containerName)}); err != nil {
+		fmt.Fprintf(os.Stderr, "Remove Container %s failed, err %s\n", containerName, err.Error())
+		return err
+	}
+	if inst := cri.getInstance(containerName); inst != nil {
+		cri.lock.Lock()
+		delete(cri.containers, inst.name)
+		cri.lock.Unlock()
+		cri.releaseSandbox(inst.sandbox)
+	}
+	fmt.Fprintf(os.Stdout, "Success to remove container %s\n", containerName)
+	return nil
+}
+
+//KillContainer send signal to container by name, cri has no signal api,
+//SIGKILL stops the container, other signals are sent to main process by exec kill
+func (cri *CRIContainer) KillContainer(containerName string, signal int) error {
+	if signal == int(syscall.SIGKILL) {
+		return cri.StopContainer(containerName, 0)
+	}
+	return cri.RunCommand(containerName, []string{"kill", "-" + strconv.Itoa(signal), "1"})
+}
+
+//InspectContainer inspect container by name
+func (cri *CRIContainer) InspectContainer(containerName string) (*BcsContainerInfo, error) {
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	response, err := cri.runtimeClient.ContainerStatus(cxt, &runtimeapi.ContainerStatusRequest{
+		ContainerId: cri.containerID(containerName),
+		Verbose:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	status := response.GetStatus()
+	if status == nil {
+		return nil, fmt.Errorf("container %s status is empty", containerName)
+	}
+	bcsContainer := &BcsContainerInfo{
+		ID:       status.GetId(),
+		Name:     status.GetMetadata().GetName(),
+		Status:   criContainerStatus(status.GetState()),
+		ExitCode: int(status.GetExitCode()),
+		Pid:      criContainerPid(response.GetInfo()),
+	}
+	if status.GetStartedAt() > 0 {
+		bcsContainer.StartAt = time.Unix(0, status.GetStartedAt())
+	}
+	if status.GetFinishedAt() > 0 {
+		bcsContainer.FinishAt = time.Unix(0, status.GetFinishedAt())
+	}
+	if status.GetMessage() != "" {
+		bcsContainer.Message = status.GetMessage()
+	}
+	inst := cri.getInstance(containerName)
+	if inst == nil {
+		return bcsContainer, nil
+	}
+	bcsContainer.Hostname = inst.hostname
+	bcsContainer.NetworkMode = inst.sandbox.network
+	if inst.resource != nil {
+		bcsContainer.Resource = &schedTypes.Resource{
+			Cpus: inst.resource.Cpus,
+			Mem:  inst.resource.Mem,
+		}
+	}
+	if inst.sandbox.network != "host" {
+		sandboxStatus, err := cri.runtimeClient.PodSandboxStatus(cxt, &runtimeapi.PodSandboxStatusRequest{PodSandboxId: inst.sandbox.id})
+		if err != nil {
+			logs.Errorf("CRIContainer get sandbox %s status failed, %s\n", inst.sandbox.id, err.Error())
+			return bcsContainer, nil
+		}
+		bcsContainer.IPAddress = sandboxStatus.GetStatus().GetNetwork().GetIp()
+	}
+	return bcsContainer, nil
+}
+
+//ContainerLogs tail log file written by runtime until container exits or cxt is done
+func (cri *CRIContainer) ContainerLogs(cxt context.Context, containerID string, stdout, stderr io.Writer) error {
+	inst := cri.getInstance(containerID)
+	if inst == nil || inst.logPath == "" {
+		return fmt.Errorf("container %s is not created by cri runtime", containerID)
+	}
+	exited := false
+	var file *os.File
+	var reader *bufio.Reader
+	var pending []byte
+	for {
+		if file == nil {
+			f, err := os.Open(inst.logPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err == nil {
+				file = f
+				defer file.Close()
+				reader = bufio.NewReader(file)
+			}
+		}
+		if reader != nil {
+			line, err := reader.ReadBytes('\n')
+			pending = append(pending, line...)
+			if err == nil {
+				writeCRILog(pending, stdout, stderr)
+				pending = pending[:0]
+				continue
+			}
+			if err != io.EOF {
+				return err
+			}
+		}
+		//no more logs, read again after container exits to get the last logs
+		if exited {
+			return nil
+		}
+		if info, err := cri.InspectContainer(inst.id); err != nil ||
+			info.Status == ContainerStatus_EXITED || info.Status == ContainerStatus_DEAD {
+			exited = true
+			continue
+		}
+		select {
+		case <-cxt.Done():
+			return cxt.Err()
+		case <-time.After(criLogPollInterval):
+		}
+	}
+}
+
+//writeCRILog parse cri log line "timestamp stream P|F content" and write content to stream,
+//P means partial line without newline
+func writeCRILog(line []byte, stdout, stderr io.Writer) {
+	fields := bytes.SplitN(line, []byte{' '}, 4)
+	if len(fields) != 4 {
+		stdout.Write(line)
+		return
+	}
+	content := fields[3]
+	if string(fields[2]) == "P" {
+		content = bytes.TrimSuffix(content, []byte{'\n'})
+	}
+	if string(fields[1]) == "stderr" {
+		stderr.Write(content)
+		return
+	}
+	stdout.Write(content)
+}
+
+//criContainerStatus convert cri container state to docker status string.
+//unknown state is not handled by pod, waiting for next inspection
+func criContainerStatus(state runtimeapi.ContainerState) string {
+	switch state {
+	case runtimeapi.ContainerState_CONTAINER_CREATED:
+		return ContainerStatus_CREATED
+	case runtimeapi.ContainerState_CONTAINER_RUNNING:
+		return ContainerStatus_RUNNING
+	case runtimeapi.ContainerState_CONTAINER_EXITED:
+		return ContainerStatus_EXITED
+	default:
+		return "unknown"
+	}
+}
+
+//criContainerPid get pid from verbose info of container status, containerd reports it in info["info"]
+func criContainerPid(info map[string]string) int {
+	data, ok := info["info"]
+	if !ok {
+		return 0
+	}
+	var verbose struct {
+		Pid int `json:"pid"`
+	}
+	if err := json.Unmarshal([]byte(data), &verbose); err != nil {
+		logs.Errorf("CRIContainer decode container verbose info failed, %s\n", err.Error())
+		return 0
+	}
+	return verbose.Pid
+}
+
+//PullImage pull image from hub
+func (cri *CRIContainer) PullImage(image string) error {
+	request := &runtimeapi.PullImageRequest{
+		Image: &runtimeapi.ImageSpec{Image: image},
+	}
+	if cri.user != "" {
+		request.Auth = &runtimeapi.AuthConfig{
+			Username: cri.user,
+			Password: cri.passwd,
+		}
+	}
+	cxt, cancel := context.WithTimeout(context.Background(), criPullTimeout)
+	defer cancel()
+	_, err := cri.imageClient.PullImage(cxt, request)
+	return err
+}
+
+//ListImage list all image from local
+func (cri *CRIContainer) ListImage(filter string) ([]*BcsImage, error) {
+	request := &runtimeapi.ListImagesRequest{}
+	if filter != "" {
+		request.Filter = &runtimeapi.ImageFilter{Image: &runtimeapi.ImageSpec{Image: filter}}
+	}
+	var bcsImages []*BcsImage
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	response, err := cri.imageClient.ListImages(cxt, request)
+	if err != nil {
+		return bcsImages, err
+	}
+	for _, image := range response.GetImages() {
+		bcs := &BcsImage{
+			ID:         image.GetId(),
+			Repository: image.GetRepoTags(),
+			Size:       int64(image.GetSize_()),
+		}
+		bcsImages = append(bcsImages, bcs)
+	}
+	return bcsImages, nil
+}
+
+//UpdateResources update container resources limit
+func (cri *CRIContainer) UpdateResources(id string, resource *schedTypes.Resource) error {
+	if resource.Cpus < 0 || resource.Mem < 4 {
+		return fmt.Errorf("container resource cpu %f memory %f is invalid", resource.Cpus, resource.Mem)
+	}
+
+	logs.Infof("update container %s resources cpu %f mem %f", id, resource.Cpus, resource.Mem)
+
+	cxt, cancel := context.WithTimeout(context.Background(), criRequestTimeout)
+	defer cancel()
+	_, err := cri.runtimeClient.UpdateContainerResources(cxt, &runtimeapi.UpdateContainerResourcesRequest{
+		ContainerId: cri.containerID(id),
+		Linux: &runtimeapi.LinuxContainerResources{
+			CpuShares:          int64(resource.Cpus * 1024),
+			MemoryLimitInBytes: int64(resource.Mem * 1024 * 1024),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if inst := cri.getInstance(id); inst != nil {
+		cri.lock.Lock()
+		inst.resource = resource
+		cri.lock.Unlock()
+	}
+	return nil
+}
+
+//CommitImage commit container to image and push it with login user. cri has no api for it,
+//so the image tool runs in the containerd namespace of cri plugin
+func (cri *CRIContainer) CommitImage(id, image string) error {
+	if cri.imageTool == "" {
+		return fmt.Errorf("commit container %s to image %s failed: no cri image tool", id, image)
+	}
+	id = cri.containerID(id)
+	if cri.user != "" {
+		if err := cri.runImageTool(strings.NewReader(cri.passwd), "login", "--username", cri.user,
+			"--password-stdin", criImageRegistry(image)); err != nil {
+			return err
+		}
+	}
+	if err := cri.runImageTool(nil, "commit", id, image); err != nil {
+		return err
+	}
+	return cri.runImageTool(nil, "push", image)
+}
+
+//runImageTool run the sub command of image tool
+func (cri *CRIContainer) runImageTool(stdin io.Reader, args ...string) error {
+	cxt, cancel := context.WithTimeout(context.Background(), criPullTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(cxt, cri.imageTool, append([]string{"--namespace", criContainerdNamespace}, args...)...)
+	cmd.Stdin = stdin
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %s, %s", cri.imageTool, args[0], err.Error(), strings.TrimSpace(string(out)))
+	}
+	logs.Infof("CRIContainer %s %v success\n", cri.imageTool, args)
+	return nil
+}
+
+//criImageRegistry get registry of image, docker.io for the image without registry
+func criImageRegistry(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return "docker.io"
+}
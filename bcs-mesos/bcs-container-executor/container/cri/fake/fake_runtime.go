@@ -0,0 +1,394 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+//Package fake implements an in-memory CRI runtime & image service for testing
+package fake
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	runtimeapi "bk-bcs/bcs-mesos/bcs-container-executor/container/cri/runtime/v1alpha2"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Sandbox pod sandbox in fake runtime
+type Sandbox struct {
+	ID     string
+	Config *runtimeapi.PodSandboxConfig
+	State  runtimeapi.PodSandboxState
+	IP     string
+}
+
+//Container container in fake runtime
+type Container struct {
+	ID         string
+	SandboxID  string
+	Config     *runtimeapi.ContainerConfig
+	State      runtimeapi.ContainerState
+	Resources  *runtimeapi.LinuxContainerResources
+	Pid        int
+	ExitCode   int32
+	StartedAt  int64
+	FinishedAt int64
+	//Execs all commands executed in container
+	Execs [][]string
+}
+
+//ExecHandler handle ExecSync in fake runtime
+type ExecHandler func(container *Container, cmd []string) *runtimeapi.ExecSyncResponse
+
+//Runtime fake CRI runtime, all state is kept in memory,
+//the apis not used by executor return codes.Unimplemented
+type Runtime struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+	runtimeapi.UnimplementedImageServiceServer
+	sync.Mutex
+	Sandboxes  map[string]*Sandbox
+	Containers map[string]*Container
+	Images     map[string]*runtimeapi.Image
+	//Pulls auth config of every pull request
+	Pulls []*runtimeapi.AuthConfig
+	//OnExec handle ExecSync commands, default success with empty output
+	OnExec ExecHandler
+	//SandboxIP ip address for sandbox not in host network
+	SandboxIP string
+
+	server *grpc.Server
+	seq    int
+}
+
+//NewRuntime create empty fake runtime
+func NewRuntime() *Runtime {
+	return &Runtime{
+		Sandboxes:  make(map[string]*Sandbox),
+		Containers: make(map[string]*Container),
+		Images:     make(map[string]*runtimeapi.Image),
+		SandboxIP:  "127.0.0.2",
+	}
+}
+
+//Start serve runtime & image service on unix socket
+func (r *Runtime) Start(socket string) error {
+	os.Remove(socket)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	r.server = grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(r.server, r)
+	runtimeapi.RegisterImageServiceServer(r.server, r)
+	go r.server.Serve(listener)
+	return nil
+}
+
+//Stop stop grpc server
+func (r *Runtime) Stop() {
+	if r.server != nil {
+		r.server.Stop()
+	}
+}
+
+//ExitContainer make running container exited with exit code
+func (r *Runtime) ExitContainer(id string, exitCode int32) {
+	r.Lock()
+	defer r.Unlock()
+	if c, ok := r.Containers[id]; ok {
+		c.State = runtimeapi.ContainerState_CONTAINER_EXITED
+		c.ExitCode = exitCode
+		c.FinishedAt = time.Now().UnixNano()
+	}
+}
+
+func (r *Runtime) nextID(prefix string) string {
+	r.seq++
+	return fmt.Sprintf("%s-%d", prefix, r.seq)
+}
+
+//Version return fake version
+func (r *Runtime) Version(ctx context.Context, req *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       "fake",
+		RuntimeVersion:    "0.1.0",
+		RuntimeApiVersion: "v1alpha2",
+	}, nil
+}
+
+//RunPodSandbox create ready sandbox
+func (r *Runtime) RunPodSandbox(ctx context.Context, req *runtimeapi.RunPodSandboxRequest) (*runtimeapi.RunPodSandboxResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	sandbox := &Sandbox{
+		ID:     r.nextID("sandbox"),
+		Config: req.GetConfig(),
+		State:  runtimeapi.PodSandboxState_SANDBOX_READY,
+	}
+	if req.GetConfig().GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() != runtimeapi.NamespaceMode_NODE {
+		sandbox.IP = r.SandboxIP
+	}
+	r.Sandboxes[sandbox.ID] = sandbox
+	return &runtimeapi.RunPodSandboxResponse{PodSandboxId: sandbox.ID}, nil
+}
+
+//StopPodSandbox stop sandbox and all containers in it
+func (r *Runtime) StopPodSandbox(ctx context.Context, req *runtimeapi.StopPodSandboxRequest) (*runtimeapi.StopPodSandboxResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	sandbox, ok := r.Sandboxes[req.GetPodSandboxId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "sandbox %s not found", req.GetPodSandboxId())
+	}
+	sandbox.State = runtimeapi.PodSandboxState_SANDBOX_NOTREADY
+	for _, c := range r.Containers {
+		if c.SandboxID == sandbox.ID && c.State == runtimeapi.ContainerState_CONTAINER_RUNNING {
+			c.State = runtimeapi.ContainerState_CONTAINER_EXITED
+			c.FinishedAt = time.Now().UnixNano()
+		}
+	}
+	return &runtimeapi.StopPodSandboxResponse{}, nil
+}
+
+//RemovePodSandbox remove sandbox and all containers in it
+func (r *Runtime) RemovePodSandbox(ctx context.Context, req *runtimeapi.RemovePodSandboxRequest) (*runtimeapi.RemovePodSandboxResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.Sandboxes[req.GetPodSandboxId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "sandbox %s not found", req.GetPodSandboxId())
+	}
+	delete(r.Sandboxes, req.GetPodSandboxId())
+	for id, c := range r.Containers {
+		if c.SandboxID == req.GetPodSandboxId() {
+			delete(r.Containers, id)
+		}
+	}
+	return &runtimeapi.RemovePodSandboxResponse{}, nil
+}
+
+//PodSandboxStatus return sandbox status
+func (r *Runtime) PodSandboxStatus(ctx context.Context, req *runtimeapi.PodSandboxStatusRequest) (*runtimeapi.PodSandboxStatusResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	sandbox, ok := r.Sandboxes[req.GetPodSandboxId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "sandbox %s not found", req.GetPodSandboxId())
+	}
+	return &runtimeapi.PodSandboxStatusResponse{
+		Status: &runtimeapi.PodSandboxStatus{
+			Id:       sandbox.ID,
+			Metadata: sandbox.Config.GetMetadata(),
+			State:    sandbox.State,
+			Network:  &runtimeapi.PodSandboxNetworkStatus{Ip: sandbox.IP},
+			Labels:   sandbox.Config.GetLabels(),
+		},
+	}, nil
+}
+
+//CreateContainer create container in ready sandbox
+func (r *Runtime) CreateContainer(ctx context.Context, req *runtimeapi.CreateContainerRequest) (*runtimeapi.CreateContainerResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	sandbox, ok := r.Sandboxes[req.GetPodSandboxId()]
+	if !ok || sandbox.State != runtimeapi.PodSandboxState_SANDBOX_READY {
+		return nil, status.Errorf(codes.FailedPrecondition, "sandbox %s is not ready", req.GetPodSandboxId())
+	}
+	if _, ok := r.Images[req.GetConfig().GetImage().GetImage()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "image %s not found", req.GetConfig().GetImage().GetImage())
+	}
+	c := &Container{
+		ID:        r.nextID("container"),
+		SandboxID: sandbox.ID,
+		Config:    req.GetConfig(),
+		State:     runtimeapi.ContainerState_CONTAINER_CREATED,
+		Resources: req.GetConfig().GetLinux().GetResources(),
+	}
+	r.Containers[c.ID] = c
+	return &runtimeapi.CreateContainerResponse{ContainerId: c.ID}, nil
+}
+
+//StartContainer start created container
+func (r *Runtime) StartContainer(ctx context.Context, req *runtimeapi.StartContainerRequest) (*runtimeapi.StartContainerResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.Containers[req.GetContainerId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	if c.State != runtimeapi.ContainerState_CONTAINER_CREATED {
+		return nil, status.Errorf(codes.FailedPrecondition, "container %s is not created", c.ID)
+	}
+	c.State = runtimeapi.ContainerState_CONTAINER_RUNNING
+	c.StartedAt = time.Now().UnixNano()
+	c.Pid = 1000 + r.seq
+	return &runtimeapi.StartContainerResponse{}, nil
+}
+
+//StopContainer stop container, exit code is 137 when timeout is 0
+func (r *Runtime) StopContainer(ctx context.Context, req *runtimeapi.StopContainerRequest) (*runtimeapi.StopContainerResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.Containers[req.GetContainerId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	if c.State == runtimeapi.ContainerState_CONTAINER_RUNNING {
+		c.State = runtimeapi.ContainerState_CONTAINER_EXITED
+		c.FinishedAt = time.Now().UnixNano()
+		if req.GetTimeout() == 0 {
+			c.ExitCode = 137
+		}
+	}
+	return &runtimeapi.StopContainerResponse{}, nil
+}
+
+//RemoveContainer remove container
+func (r *Runtime) RemoveContainer(ctx context.Context, req *runtimeapi.RemoveContainerRequest) (*runtimeapi.RemoveContainerResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.Containers[req.GetContainerId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	delete(r.Containers, req.GetContainerId())
+	return &runtimeapi.RemoveContainerResponse{}, nil
+}
+
+//ListContainers list containers with filter
+func (r *Runtime) ListContainers(ctx context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	filter := req.GetFilter()
+	response := &runtimeapi.ListContainersResponse{}
+	for _, c := range r.Containers {
+		if filter.GetId() != "" && filter.GetId() != c.ID {
+			continue
+		}
+		if filter.GetPodSandboxId() != "" && filter.GetPodSandboxId() != c.SandboxID {
+			continue
+		}
+		if filter.GetState() != nil && filter.GetState().GetState() != c.State {
+			continue
+		}
+		matched := true
+		for key, value := range filter.GetLabelSelector() {
+			if c.Config.GetLabels()[key] != value {
+				matched = false
+			}
+		}
+		if !matched {
+			continue
+		}
+		response.Containers = append(response.Containers, &runtimeapi.Container{
+			Id:           c.ID,
+			PodSandboxId: c.SandboxID,
+			Metadata:     c.Config.GetMetadata(),
+			Image:        c.Config.GetImage(),
+			State:        c.State,
+			Labels:       c.Config.GetLabels(),
+		})
+	}
+	return response, nil
+}
+
+//ContainerStatus return container status, pid is reported in verbose info like containerd
+func (r *Runtime) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.Containers[req.GetContainerId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	response := &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{
+			Id:         c.ID,
+			Metadata:   c.Config.GetMetadata(),
+			State:      c.State,
+			StartedAt:  c.StartedAt,
+			FinishedAt: c.FinishedAt,
+			ExitCode:   c.ExitCode,
+			Image:      c.Config.GetImage(),
+			Labels:     c.Config.GetLabels(),
+			Mounts:     c.Config.GetMounts(),
+		},
+	}
+	if req.GetVerbose() {
+		response.Info = map[string]string{"info": fmt.Sprintf(`{"pid":%d}`, c.Pid)}
+	}
+	return response, nil
+}
+
+//UpdateContainerResources update linux resources of container
+func (r *Runtime) UpdateContainerResources(ctx context.Context, req *runtimeapi.UpdateContainerResourcesRequest) (*runtimeapi.UpdateContainerResourcesResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.Containers[req.GetContainerId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	c.Resources = req.GetLinux()
+	return &runtimeapi.UpdateContainerResourcesResponse{}, nil
+}
+
+//ExecSync run command in running container with ExecHandler
+func (r *Runtime) ExecSync(ctx context.Context, req *runtimeapi.ExecSyncRequest) (*runtimeapi.ExecSyncResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	c, ok := r.Containers[req.GetContainerId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.GetContainerId())
+	}
+	if c.State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+		return nil, status.Errorf(codes.FailedPrecondition, "container %s is not running", c.ID)
+	}
+	c.Execs = append(c.Execs, req.GetCmd())
+	if r.OnExec != nil {
+		return r.OnExec(c, req.GetCmd()), nil
+	}
+	return &runtimeapi.ExecSyncResponse{}, nil
+}
+
+//ListImages list images, filter matches image name
+func (r *Runtime) ListImages(ctx context.Context, req *runtimeapi.ListImagesRequest) (*runtimeapi.ListImagesResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	response := &runtimeapi.ListImagesResponse{}
+	filter := req.GetFilter().GetImage().GetImage()
+	for name, image := range r.Images {
+		if filter != "" && !strings.HasPrefix(name, filter) {
+			continue
+		}
+		response.Images = append(response.Images, image)
+	}
+	return response, nil
+}
+
+//PullImage add image to runtime
+func (r *Runtime) PullImage(ctx context.Context, req *runtimeapi.PullImageRequest) (*runtimeapi.PullImageResponse, error) {
+	r.Lock()
+	defer r.Unlock()
+	name := req.GetImage().GetImage()
+	r.Pulls = append(r.Pulls, req.GetAuth())
+	r.Images[name] = &runtimeapi.Image{
+		Id:       r.nextID("sha256"),
+		RepoTags: []string{name},
+		Size_:    1024,
+	}
+	return &runtimeapi.PullImageResponse{ImageRef: r.Images[name].Id}, nil
+}
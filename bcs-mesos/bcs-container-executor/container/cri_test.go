@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bk-bcs/bcs-mesos/bcs-container-executor/container/cri/fake"
+	runtimeapi "bk-bcs/bcs-mesos/bcs-container-executor/container/cri/runtime/v1alpha2"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeCRI(t *testing.T) (*fake.Runtime, *CRIContainer, func()) {
+	dir, err := ioutil.TempDir("", "cri")
+	assert.Nil(t, err)
+	runtime := fake.NewRuntime()
+	assert.Nil(t, runtime.Start(filepath.Join(dir, "cri.sock")))
+	cri := NewCRIContainer("unix://"+filepath.Join(dir, "cri.sock"), "", "user", "passwd")
+	assert.NotNil(t, cri)
+	return runtime, cri.(*CRIContainer), func() {
+		runtime.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCRIContainerLifecycle(t *testing.T) {
+	runtime, cri, cleanup := newFakeCRI(t)
+	defer cleanup()
+
+	netTask := &BcsContainerTask{
+		Name:        "net",
+		Image:       "nginx:latest",
+		HostName:    "host-net",
+		Command:     "/bin/start",
+		Args:        []string{"-c", "conf"},
+		Env:         []BcsKV{{Key: "KEY", Value: "VALUE"}},
+		Volums:      []BcsVolume{{HostPath: "/data", ContainerPath: "/data", ReadOnly: true}},
+		NetworkName: "bridge",
+		PortBindings: map[string]BcsPort{
+			"80/tcp": {ContainerPort: "80", HostPort: "31000"},
+		},
+		Resource: &schedTypes.Resource{Cpus: 0.5, Mem: 64},
+	}
+	netInfo, err := cri.CreateContainer(netTask.Name, netTask)
+	assert.Nil(t, err)
+	assert.Equal(t, "net", netInfo.Name)
+	//image is pulled with auth
+	assert.Len(t, runtime.Pulls, 1)
+	assert.Equal(t, "user", runtime.Pulls[0].GetUsername())
+
+	assert.Len(t, runtime.Sandboxes, 1)
+	netContainer := runtime.Containers[netInfo.ID]
+	assert.NotNil(t, netContainer)
+	sandbox := runtime.Sandboxes[netContainer.SandboxID]
+	assert.Equal(t, int32(31000), sandbox.Config.GetPortMappings()[0].GetHostPort())
+	assert.Equal(t, "host-net", sandbox.Config.GetHostname())
+	assert.Equal(t, []string{"/bin/start", "-c", "conf"}, netContainer.Config.GetArgs())
+	assert.Equal(t, "KEY", netContainer.Config.GetEnvs()[0].GetKey())
+	assert.True(t, netContainer.Config.GetMounts()[0].GetReadonly())
+	assert.Equal(t, int64(512), netContainer.Resources.GetCpuShares())
+	assert.Equal(t, int64(64*1024*1024), netContainer.Resources.GetMemoryLimitInBytes())
+
+	//container joins sandbox of network container
+	appTask := &BcsContainerTask{
+		Name:        "app",
+		Image:       "nginx:latest",
+		NetworkName: "container:" + netInfo.ID,
+		Resource:    &schedTypes.Resource{Cpus: 1, Mem: 128},
+	}
+	appInfo, err := cri.CreateContainer(appTask.Name, appTask)
+	assert.Nil(t, err)
+	assert.Len(t, runtime.Sandboxes, 1)
+	assert.Equal(t, netContainer.SandboxID, runtime.Containers[appInfo.ID].SandboxID)
+	//image exists, no more pull
+	assert.Len(t, runtime.Pulls, 1)
+
+	//file is uploaded after container started
+	source, err := ioutil.TempFile("", "upload")
+	assert.Nil(t, err)
+	defer os.Remove(source.Name())
+	source.WriteString("hello")
+	source.Close()
+	assert.Nil(t, cri.UploadToContainer("app", source.Name(), "/etc/app/app.conf"))
+	assert.Len(t, runtime.Containers[appInfo.ID].Execs, 0)
+
+	assert.Nil(t, cri.StartContainer("net"))
+	assert.Nil(t, cri.StartContainer(appInfo.ID))
+	execs := runtime.Containers[appInfo.ID].Execs
+	assert.Len(t, execs, 2)
+	assert.Equal(t, "sh", execs[0][0])
+	assert.Equal(t, filepath.Join("/etc/app", filepath.Base(source.Name())), execs[0][4])
+	assert.Equal(t, "aGVsbG8=", execs[0][5])
+	assert.Equal(t, "chmod", execs[1][0])
+
+	info, err := cri.InspectContainer("app")
+	assert.Nil(t, err)
+	assert.Equal(t, ContainerStatus_RUNNING, info.Status)
+	assert.Equal(t, "app", info.Name)
+	assert.Equal(t, "127.0.0.2", info.IPAddress)
+	assert.Equal(t, "bridge", info.NetworkMode)
+	assert.NotEqual(t, 0, info.Pid)
+	assert.Equal(t, float64(128), info.Resource.Mem)
+
+	assert.Nil(t, cri.UpdateResources(appInfo.ID, &schedTypes.Resource{Cpus: 2, Mem: 256}))
+	assert.Equal(t, int64(2048), runtime.Containers[appInfo.ID].Resources.GetCpuShares())
+	assert.NotNil(t, cri.UpdateResources(appInfo.ID, &schedTypes.Resource{Cpus: 2, Mem: 1}))
+
+	assert.NotNil(t, cri.CommitImage(appInfo.ID, "nginx:commit"))
+
+	assert.Nil(t, cri.KillContainer("app", 9))
+	info, err = cri.InspectContainer("app")
+	assert.Nil(t, err)
+	assert.Equal(t, ContainerStatus_EXITED, info.Status)
+	assert.Equal(t, 137, info.ExitCode)
+
+	assert.Nil(t, cri.StopContainer("net", 1))
+	//sandbox is removed with the last container
+	assert.Nil(t, cri.RemoveContainer("net", true))
+	assert.Len(t, runtime.Sandboxes, 1)
+	assert.Nil(t, cri.RemoveContainer("app", true))
+	assert.Len(t, runtime.Sandboxes, 0)
+	assert.Len(t, cri.containers, 0)
+}
+
+func TestCRIContainerHostNetwork(t *testing.T) {
+	runtime, cri, cleanup := newFakeCRI(t)
+	defer cleanup()
+
+	task := &BcsContainerTask{
+		Name:        "host",
+		Image:       "nginx:latest",
+		NetworkName: "host",
+		Resource:    &schedTypes.Resource{Cpus: 1, Mem: 128},
+	}
+	created, err := cri.CreateContainer(task.Name, task)
+	assert.Nil(t, err)
+	c := runtime.Containers[created.ID]
+	assert.Equal(t, runtimeapi.NamespaceMode_NODE, c.Config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork())
+	assert.Nil(t, cri.StartContainer("host"))
+	info, err := cri.InspectContainer("host")
+	assert.Nil(t, err)
+	assert.Equal(t, "", info.IPAddress)
+
+	//join unknown container
+	task.Name = "other"
+	task.NetworkName = "container:unknown"
+	_, err = cri.CreateContainer(task.Name, task)
+	assert.NotNil(t, err)
+}
+
+func TestCRIContainerRunCommandV2(t *testing.T) {
+	runtime, cri, cleanup := newFakeCRI(t)
+	defer cleanup()
+	runtime.OnExec = func(c *fake.Container, cmd []string) *runtimeapi.ExecSyncResponse {
+		return &runtimeapi.ExecSyncResponse{Stdout: []byte(cmd[len(cmd)-1]), ExitCode: 3}
+	}
+
+	task := &BcsContainerTask{
+		Name:        "app",
+		Image:       "nginx:latest",
+		NetworkName: "bridge",
+		Resource:    &schedTypes.Resource{Cpus: 1, Mem: 128},
+	}
+	_, err := cri.CreateContainer(task.Name, task)
+	assert.Nil(t, err)
+
+	request := &schedTypes.RequestCommandTask{
+		ID:          "command",
+		ContainerId: "app",
+		Cmd:         []string{"echo", "hello"},
+		Env:         []string{"KEY=VALUE"},
+	}
+	//container is not running
+	resp, err := cri.RunCommandV2(request)
+	assert.Nil(t, err)
+	assert.Nil(t, resp.CommInspect)
+	assert.NotEmpty(t, resp.Message)
+
+	assert.Nil(t, cri.StartContainer("app"))
+	resp, err = cri.RunCommandV2(request)
+	assert.Nil(t, err)
+	assert.NotNil(t, resp.CommInspect)
+	assert.Equal(t, 3, resp.CommInspect.ExitCode)
+	assert.Equal(t, "hello", resp.CommInspect.Stdout)
+	execs := runtime.Containers[cri.containerID("app")].Execs
+	assert.Equal(t, []string{"env", "KEY=VALUE", "echo", "hello"}, execs[0])
+
+	assert.NotNil(t, cri.RunCommand("app", []string{"false"}))
+}
+
+func TestCRIContainerCommitImage(t *testing.T) {
+	runtime, cri, cleanup := newFakeCRI(t)
+	defer cleanup()
+
+	info, err := cri.CreateContainer("app", &BcsContainerTask{Name: "app", Image: "nginx:latest", NetworkName: "host"})
+	assert.Nil(t, err)
+	assert.NotNil(t, runtime.Containers[info.ID])
+
+	//no image tool
+	assert.NotNil(t, cri.CommitImage("app", "hub.example.com/app:v2"))
+
+	//image tool records its arguments and stdin
+	dir, err := ioutil.TempDir("", "cri-tool")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	record := filepath.Join(dir, "record")
+	cri.imageTool = filepath.Join(dir, "nerdctl")
+	script := "#!/bin/sh\necho \"$@\" >> " + record + "\n" +
+		"if [ \"$3\" = login ]; then cat >> " + record + "; echo >> " + record + "; fi\n" +
+		"if [ \"$3\" = push ]; then echo denied; exit 1; fi\n"
+	assert.Nil(t, ioutil.WriteFile(cri.imageTool, []byte(script), 0755))
+
+	err = cri.CommitImage("app", "hub.example.com/app:v2")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "denied")
+	data, err := ioutil.ReadFile(record)
+	assert.Nil(t, err)
+	assert.Equal(t, "--namespace k8s.io login --username user --password-stdin hub.example.com\npasswd\n"+
+		"--namespace k8s.io commit "+info.ID+" hub.example.com/app:v2\n"+
+		"--namespace k8s.io push hub.example.com/app:v2\n", string(data))
+
+	assert.Equal(t, "docker.io", criImageRegistry("library/nginx:latest"))
+	assert.Equal(t, "localhost", criImageRegistry("localhost/nginx"))
+	assert.Equal(t, "hub:5000", criImageRegistry("hub:5000/nginx"))
+}
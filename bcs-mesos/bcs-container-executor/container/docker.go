@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 
 	commtypes "bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
@@ -33,6 +34,7 @@ import (
 	"time"
 
 	dockerclient "github.com/fsouza/go-dockerclient"
+	"golang.org/x/net/context"
 )
 
 // DockerInterface is an abstract interface for testability.  It abstracts the interface of docker.Client.
@@ -119,6 +121,11 @@ func (docker *DockerContainer) RunCommand(containerID string, command []string)
 }
 
 func (docker *DockerContainer) RunCommandV2(ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	return docker.RunCommandContext(context.Background(), ops)
+}
+
+//RunCommandContext exec command in container and wait for result, exec process is killed when cxt is done
+func (docker *DockerContainer) RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
 	if ops.User == "" {
 		ops.User = "root"
 	}
@@ -156,7 +163,30 @@ func (docker *DockerContainer) RunCommandV2(ops *schedTypes.RequestCommandTask)
 		OutputStream: &outBuf,
 		ErrorStream:  &errBuf,
 	}
-	err = docker.client.StartExec(exeInst.ID, startOpt)
+	waiter, err := docker.client.StartExecNonBlocking(exeInst.ID, startOpt)
+	if err != nil {
+		logs.Errorf("docker start exec error %s", err.Error())
+		resp.Status = commtypes.TaskCommandStatusFailed
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- waiter.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-cxt.Done():
+		//hijacked connection ignores context, kill exec process and close connection
+		if killErr := docker.killExec(exeInst.ID); killErr != nil {
+			logs.Errorf("docker kill exec %s error %s", exeInst.ID, killErr.Error())
+		}
+		waiter.Close()
+		logs.Errorf("docker container %s run command %s", ops.ContainerId, cxt.Err().Error())
+		resp.Status = commtypes.TaskCommandStatusFailed
+		resp.Message = "command killed: " + cxt.Err().Error()
+		return resp, nil
+	}
 	if err != nil {
 		logs.Errorf("docker start exec error %s", err.Error())
 		resp.Status = commtypes.TaskCommandStatusFailed
@@ -194,6 +224,34 @@ func (docker *DockerContainer) RunCommandV2(ops *schedTypes.RequestCommandTask)
 	return resp, nil
 }
 
+//killExec kill exec process by host pid, go-dockerclient ExecInspect drops Pid
+//and docker has no api to kill exec
+func (docker *DockerContainer) killExec(execID string) error {
+	execURL := docker.endpoint
+	if strings.HasPrefix(execURL, "unix://") {
+		//unix domain sock is dialed by transport of client
+		execURL = "http://unix.sock"
+	} else {
+		execURL = strings.Replace(execURL, "tcp://", "http://", 1)
+	}
+	httpResp, err := docker.client.HTTPClient.Get(strings.TrimRight(execURL, "/") + "/exec/" + execID + "/json")
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	ins := &struct {
+		Pid     int
+		Running bool
+	}{}
+	if err := json.NewDecoder(httpResp.Body).Decode(ins); err != nil {
+		return err
+	}
+	if !ins.Running || ins.Pid <= 0 {
+		return nil
+	}
+	return syscall.Kill(ins.Pid, syscall.SIGKILL)
+}
+
 //UploadToContainer upload file from host to Container
 func (docker *DockerContainer) UploadToContainer(containerID string, source, dest string) error {
 	//create tarfile by source
@@ -303,6 +361,10 @@ func (docker *DockerContainer) CreateContainer(containerName string, containerTa
 		}
 	}
 	fmt.Fprintf(os.Stdout, "CreateContainer %s, hostname: %s\n", containerName, containerTask.HostName)
+	if err := setupVolumes(containerTask); err != nil {
+		fmt.Fprintf(os.Stderr, "Create Container %s volumes failed: %s\n", containerName, err.Error())
+		return nil, err
+	}
 	//from BcsContainerTask.Volumes to dockerclient.Config
 	for _, volumn := range containerTask.Volums {
 		mount := dockerclient.Mount{
@@ -479,7 +541,7 @@ func (docker *DockerContainer) RemoveContainer(containerName string, force bool)
 func (docker *DockerContainer) KillContainer(containerName string, signal int) error {
 	option := dockerclient.KillContainerOptions{
 		ID:     containerName,
-		Signal: dockerclient.SIGKILL,
+		Signal: dockerclient.Signal(signal),
 	}
 	return docker.client.KillContainer(option)
 }
@@ -602,3 +664,18 @@ func (docker *DockerContainer) CommitImage(id, image string) error {
 
 	return docker.client.PushImage(opts, auth)
 }
+
+//ContainerLogs follow stdout & stderr of container until container exits or cxt is done
+func (docker *DockerContainer) ContainerLogs(cxt context.Context, containerID string, stdout, stderr io.Writer) error {
+	opts := dockerclient.LogsOptions{
+		Context:      cxt,
+		Container:    containerID,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Follow:       true,
+		Stdout:       true,
+		Stderr:       true,
+		Tail:         "all",
+	}
+	return docker.client.Logs(opts)
+}
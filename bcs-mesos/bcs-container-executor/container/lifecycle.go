@@ -0,0 +1,185 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	comtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	//DefaultHookTimeout timeout for hook when TimeoutSeconds is not setting
+	DefaultHookTimeout = 30 * time.Second
+	//initContainerCheckInterval interval for inspecting init container status
+	initContainerCheckInterval = time.Second
+)
+
+//RunInitContainer create and start init container, wait for it to exit and remove it.
+//error is returned when exit code is not 0 or pod is stopped
+func RunInitContainer(cxt context.Context, operator Container, task *BcsContainerTask) error {
+	logs.Infof("init container %s with image %s starting\n", task.Name, task.Image)
+	task.RuntimeConf = &BcsContainerInfo{
+		Name: task.Name,
+	}
+	createInst, err := operator.CreateContainer(task.Name, task)
+	if err != nil {
+		return fmt.Errorf("init container %s create failed, %s", task.Name, err.Error())
+	}
+	task.RuntimeConf.ID = createInst.ID
+	defer operator.RemoveContainer(task.Name, true)
+
+	if err := operator.StartContainer(task.Name); err != nil {
+		return fmt.Errorf("init container %s start failed, %s", task.Name, err.Error())
+	}
+
+	tick := time.NewTicker(initContainerCheckInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-cxt.Done():
+			operator.StopContainer(task.Name, task.KillPolicy)
+			return fmt.Errorf("init container %s is stopped because pod is stopping", task.Name)
+		case <-tick.C:
+		}
+		info, err := operator.InspectContainer(task.Name)
+		if err != nil {
+			logs.Errorf("init container %s inspect failed, %s, wait next tick\n", task.Name, err.Error())
+			continue
+		}
+		task.RuntimeConf.Update(info)
+		if info.Status != ContainerStatus_EXITED && info.Status != ContainerStatus_DEAD {
+			continue
+		}
+		if info.ExitCode != 0 {
+			return fmt.Errorf("init container %s failed, exit code %d", task.Name, info.ExitCode)
+		}
+		logs.Infof("init container %s finished\n", task.Name)
+		return nil
+	}
+}
+
+//RunPostStartHook run postStart hook of container after it started
+func RunPostStartHook(operator Container, task *BcsContainerTask, host string) error {
+	if task.Lifecycle == nil || task.Lifecycle.PostStart == nil {
+		return nil
+	}
+	timeout := DefaultHookTimeout
+	if task.Lifecycle.PostStart.TimeoutSeconds > 0 {
+		timeout = time.Duration(task.Lifecycle.PostStart.TimeoutSeconds) * time.Second
+	}
+	if err := RunLifecycleHandler(operator, task, host, task.Lifecycle.PostStart, timeout); err != nil {
+		logs.Errorf("container %s postStart hook failed, %s\n", task.Name, err.Error())
+		return fmt.Errorf("container %s postStart hook failed, %s", task.Name, err.Error())
+	}
+	logs.Infof("container %s postStart hook success\n", task.Name)
+	return nil
+}
+
+//RunPreStopHook run preStop hook of container within the grace period of kill policy,
+//return grace period seconds left for stopping container
+func RunPreStopHook(operator Container, task *BcsContainerTask, host string) int {
+	if task.Lifecycle == nil || task.Lifecycle.PreStop == nil {
+		return task.KillPolicy
+	}
+	grace := time.Duration(task.KillPolicy) * time.Second
+	timeout := grace
+	if task.Lifecycle.PreStop.TimeoutSeconds > 0 && time.Duration(task.Lifecycle.PreStop.TimeoutSeconds)*time.Second < grace {
+		timeout = time.Duration(task.Lifecycle.PreStop.TimeoutSeconds) * time.Second
+	}
+	start := time.Now()
+	if err := RunLifecycleHandler(operator, task, host, task.Lifecycle.PreStop, timeout); err != nil {
+		logs.Errorf("container %s preStop hook failed, %s\n", task.Name, err.Error())
+	} else {
+		logs.Infof("container %s preStop hook success\n", task.Name)
+	}
+	//whole seconds elapsed are deducted, a quick hook does not cost grace period
+	left := task.KillPolicy - int(time.Since(start)/time.Second)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+//RunLifecycleHandler run exec or http hook of container, error is returned when hook fails or timeout
+func RunLifecycleHandler(operator Container, task *BcsContainerTask, host string, handler *comtypes.LifecycleHandler, timeout time.Duration) error {
+	switch {
+	case handler.Exec != nil:
+		return runExecHandler(operator, task, handler.Exec, timeout)
+	case handler.HttpGet != nil:
+		return runHTTPHandler(host, handler.HttpGet, timeout)
+	default:
+		return fmt.Errorf("no action in hook")
+	}
+}
+
+//runExecHandler run command in container, command is killed when it is not finished in timeout
+func runExecHandler(operator Container, task *BcsContainerTask, action *comtypes.ExecAction, timeout time.Duration) error {
+	request := &schedTypes.RequestCommandTask{
+		ID:          fmt.Sprintf("hook-%d", time.Now().UnixNano()),
+		TaskId:      task.TaskId,
+		ContainerId: task.Name,
+		Cmd:         action.Command,
+	}
+	cxt, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := operator.RunCommandContext(cxt, request)
+	if cxt.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("exec timeout after %s", timeout.String())
+	}
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return fmt.Errorf("exec failed, no response")
+	}
+	if resp.Status != comtypes.TaskCommandStatusFinish || resp.CommInspect == nil {
+		return fmt.Errorf("exec failed, %s", resp.Message)
+	}
+	if resp.CommInspect.ExitCode != 0 {
+		return fmt.Errorf("exec exit code %d, %s", resp.CommInspect.ExitCode, resp.CommInspect.Stderr)
+	}
+	return nil
+}
+
+func runHTTPHandler(host string, action *comtypes.HttpGetAction, timeout time.Duration) error {
+	scheme := action.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	url := scheme + "://" + host + ":" + strconv.Itoa(int(action.Port)) + action.Path
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range action.Headers {
+		request.Header.Set(key, value)
+	}
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("http get %s response status %d", url, response.StatusCode)
+	}
+	return nil
+}
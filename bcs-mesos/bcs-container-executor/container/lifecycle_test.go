@@ -0,0 +1,213 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	comtypes "bk-bcs/bcs-common/common/types"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+//hookContainer records container operations in order,
+//container exits with exitCode after inspected once
+type hookContainer struct {
+	Container
+	sync.Mutex
+	calls     []string
+	exitCode  int
+	createErr error
+	execExit  int
+	execBlock bool
+	killed    []error
+}
+
+func (c *hookContainer) record(format string, args ...interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+func (c *hookContainer) Calls() []string {
+	c.Lock()
+	defer c.Unlock()
+	return append([]string{}, c.calls...)
+}
+
+func (c *hookContainer) CreateContainer(name string, task *BcsContainerTask) (*BcsContainerInfo, error) {
+	c.record("create %s", name)
+	if c.createErr != nil {
+		return nil, c.createErr
+	}
+	return &BcsContainerInfo{ID: name, Name: name}, nil
+}
+
+func (c *hookContainer) StartContainer(id string) error {
+	c.record("start %s", id)
+	return nil
+}
+
+func (c *hookContainer) StopContainer(name string, timeout int) error {
+	c.record("stop %s %d", name, timeout)
+	return nil
+}
+
+func (c *hookContainer) RemoveContainer(name string, force bool) error {
+	c.record("remove %s", name)
+	return nil
+}
+
+func (c *hookContainer) InspectContainer(name string) (*BcsContainerInfo, error) {
+	return &BcsContainerInfo{Name: name, Status: ContainerStatus_EXITED, ExitCode: c.exitCode}, nil
+}
+
+func (c *hookContainer) RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	c.record("exec %s %s", ops.ContainerId, strings.Join(ops.Cmd, " "))
+	resp := &schedTypes.ResponseCommandTask{ID: ops.ID, ContainerId: ops.ContainerId}
+	if c.execBlock {
+		<-cxt.Done()
+		c.Lock()
+		c.killed = append(c.killed, cxt.Err())
+		c.Unlock()
+		resp.Status = comtypes.TaskCommandStatusFailed
+		resp.Message = cxt.Err().Error()
+		return resp, nil
+	}
+	resp.Status = comtypes.TaskCommandStatusFinish
+	resp.CommInspect = &comtypes.CommandInspectInfo{ExitCode: c.execExit, Stderr: "stderr"}
+	return resp, nil
+}
+
+func execHook(timeout int, command ...string) *comtypes.LifecycleHandler {
+	return &comtypes.LifecycleHandler{
+		Exec:           &comtypes.ExecAction{Command: command},
+		TimeoutSeconds: timeout,
+	}
+}
+
+func TestRunInitContainer(t *testing.T) {
+	operator := &hookContainer{}
+	task := &BcsContainerTask{Name: "init", Image: "busybox"}
+	assert.Nil(t, RunInitContainer(context.Background(), operator, task))
+	assert.Equal(t, []string{"create init", "start init", "remove init"}, operator.Calls())
+	assert.Equal(t, ContainerStatus_EXITED, task.RuntimeConf.Status)
+
+	//exit code is not 0
+	operator = &hookContainer{exitCode: 2}
+	err := RunInitContainer(context.Background(), operator, task)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exit code 2")
+	assert.Equal(t, []string{"create init", "start init", "remove init"}, operator.Calls())
+
+	//create failed, nothing started
+	operator = &hookContainer{createErr: fmt.Errorf("no image")}
+	assert.NotNil(t, RunInitContainer(context.Background(), operator, task))
+	assert.Equal(t, []string{"create init"}, operator.Calls())
+
+	//pod is stopping, init container is stopped
+	operator = &hookContainer{}
+	task.KillPolicy = 5
+	cxt, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, RunInitContainer(cxt, operator, task))
+	assert.Equal(t, []string{"create init", "start init", "stop init 5", "remove init"}, operator.Calls())
+}
+
+func TestRunPostStartHook(t *testing.T) {
+	//no hook
+	operator := &hookContainer{}
+	task := &BcsContainerTask{Name: "app"}
+	assert.Nil(t, RunPostStartHook(operator, task, "127.0.0.1"))
+	assert.Empty(t, operator.Calls())
+
+	task.Lifecycle = &comtypes.Lifecycle{PostStart: execHook(0, "touch", "/tmp/started")}
+	assert.Nil(t, RunPostStartHook(operator, task, "127.0.0.1"))
+	assert.Equal(t, []string{"exec app touch /tmp/started"}, operator.Calls())
+
+	//exit code is not 0
+	operator = &hookContainer{execExit: 1}
+	err := RunPostStartHook(operator, task, "127.0.0.1")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exec exit code 1, stderr")
+
+	//hook timeout, command is killed
+	operator = &hookContainer{execBlock: true}
+	task.Lifecycle.PostStart.TimeoutSeconds = 1
+	err = RunPostStartHook(operator, task, "127.0.0.1")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exec timeout after 1s")
+	assert.Equal(t, []error{context.DeadlineExceeded}, operator.killed)
+}
+
+func TestRunPostStartHookHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/started" || r.Header.Get("X-Hook") != "postStart" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host, portStr, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portStr)
+
+	task := &BcsContainerTask{Name: "app", Lifecycle: &comtypes.Lifecycle{
+		PostStart: &comtypes.LifecycleHandler{HttpGet: &comtypes.HttpGetAction{
+			Port:    int32(port),
+			Path:    "/started",
+			Headers: map[string]string{"X-Hook": "postStart"},
+		}},
+	}}
+	assert.Nil(t, RunPostStartHook(&hookContainer{}, task, host))
+
+	task.Lifecycle.PostStart.HttpGet.Path = "/unknown"
+	assert.NotNil(t, RunPostStartHook(&hookContainer{}, task, host))
+
+	//no action
+	task.Lifecycle.PostStart = &comtypes.LifecycleHandler{}
+	assert.NotNil(t, RunPostStartHook(&hookContainer{}, task, host))
+}
+
+func TestRunPreStopHook(t *testing.T) {
+	//no hook, all grace period is left
+	operator := &hookContainer{}
+	task := &BcsContainerTask{Name: "app", KillPolicy: 10}
+	assert.Equal(t, 10, RunPreStopHook(operator, task, "127.0.0.1"))
+
+	task.Lifecycle = &comtypes.Lifecycle{PreStop: execHook(0, "nginx", "-s", "quit")}
+	assert.Equal(t, 10, RunPreStopHook(operator, task, "127.0.0.1"))
+	assert.Equal(t, []string{"exec app nginx -s quit"}, operator.Calls())
+
+	//failed hook does not block stopping
+	operator = &hookContainer{execExit: 1}
+	assert.Equal(t, 10, RunPreStopHook(operator, task, "127.0.0.1"))
+
+	//hook timeout is limited by grace period, nothing left for stopping
+	operator = &hookContainer{execBlock: true}
+	task.KillPolicy = 1
+	task.Lifecycle.PreStop.TimeoutSeconds = 30
+	start := time.Now()
+	assert.Equal(t, 0, RunPreStopHook(operator, task, "127.0.0.1"))
+	assert.True(t, time.Since(start) < 2*time.Second)
+	assert.Equal(t, []error{context.DeadlineExceeded}, operator.killed)
+}
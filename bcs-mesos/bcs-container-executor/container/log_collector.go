@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	//DefaultLogMaxSize default max size of one container log file
+	DefaultLogMaxSize = 100 * 1024 * 1024
+	//DefaultLogMaxFiles default max log files for one container
+	DefaultLogMaxFiles = 5
+	//DefaultLogMaxAge default max age of container log file
+	DefaultLogMaxAge = 72 * time.Hour
+)
+
+//LogCollector collect stdout & stderr of containers to rotated files,
+//file of container is $dir/$name.log
+type LogCollector struct {
+	operator Container
+	dir      string
+	maxSize  int64
+	maxFiles int
+	maxAge   time.Duration
+	lock     sync.RWMutex
+	files    map[string]*logs.RotateFile //key is container name
+}
+
+//NewLogCollector create collector for containers
+func NewLogCollector(operator Container, dir string, maxSize int64, maxFiles int, maxAge time.Duration) *LogCollector {
+	return &LogCollector{
+		operator: operator,
+		dir:      dir,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		maxAge:   maxAge,
+		files:    make(map[string]*logs.RotateFile),
+	}
+}
+
+//Collect start collecting logs of started container until container exits or cxt is done.
+//logs are kept for reading after collection ends
+func (collector *LogCollector) Collect(cxt context.Context, info *BcsContainerInfo) error {
+	file, err := logs.NewRotateFile(filepath.Join(collector.dir, info.Name+".log"), collector.maxSize, collector.maxFiles, collector.maxAge)
+	if err != nil {
+		return err
+	}
+	collector.lock.Lock()
+	if old, ok := collector.files[info.Name]; ok {
+		old.Close()
+	}
+	collector.files[info.Name] = file
+	collector.lock.Unlock()
+
+	go func() {
+		logs.Infof("LogCollector start collecting logs of container %s\n", info.Name)
+		if err := collector.operator.ContainerLogs(cxt, info.ID, file, file); err != nil {
+			logs.Errorf("LogCollector collect logs of container %s failed, %s\n", info.Name, err.Error())
+		}
+		file.Close()
+		logs.Infof("LogCollector stop collecting logs of container %s\n", info.Name)
+	}()
+	return nil
+}
+
+//Read read logs of container from offset, if tail > 0, read the last tail lines.
+//return logs and the offset of logs
+func (collector *LogCollector) Read(name string, offset int64, tail, limit int) ([]byte, int64, error) {
+	collector.lock.RLock()
+	file, ok := collector.files[name]
+	collector.lock.RUnlock()
+	if !ok {
+		return nil, 0, fmt.Errorf("logs of container %s not collected", name)
+	}
+	if tail > 0 {
+		return file.Tail(tail, limit)
+	}
+	return file.ReadAt(offset, limit)
+}
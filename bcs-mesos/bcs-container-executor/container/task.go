@@ -41,6 +41,9 @@ type BcsVolume struct {
 	ReadOnly      bool
 	HostPath      string
 	ContainerPath string
+	//emptyDir & local volume managed by executor, HostPath is decided by executor
+	EmptyDir *comtypes.EmptyDirVolume
+	Local    *comtypes.LocalVolume
 }
 
 //BcsContainerTask task info for running container
@@ -69,13 +72,19 @@ type BcsContainerTask struct {
 	BcsMessages     []*bcstypes.BcsMessage //bcs define message
 	RuntimeConf     *BcsContainerInfo      //container runtime info
 	HealthCheck     healthcheck.Checker    //for health check
+	ReadinessCheck  healthcheck.Checker    //readiness probe, pod is removed from service when failed
+	StartupCheck    healthcheck.Checker    //startup probe, other checkers start after it succeeds
 	KillPolicy      int                    //kill policy timeout, unit is seconds
+	InitContainer   bool                   //init container, run to completion before app containers start
+	Lifecycle       *comtypes.Lifecycle    //postStart & preStop hooks
 	//container network flow limit args
 	NetLimit *comtypes.NetLimit
 	TaskId   string
 
 	Ipc string //IPC namespace to use
 
+	started bool //startup probe succeeds
+
 	/*Healthy                 bool `json:"Healthy,omitempty"` //Container healthy
 	IsChecked               bool `json:",omitempty"`        //is health check
 	ConsecutiveFailureTimes int  `json:",omitempty"`        //consecutive failure times*/
@@ -98,3 +107,44 @@ func EnvOperCopy(task *BcsContainerTask) {
 		}
 	}
 }
+
+//IsStarted container started when startup probe is not setting or it succeeds
+func (task *BcsContainerTask) IsStarted() bool {
+	return task.StartupCheck == nil || task.started
+}
+
+//StartProbes start startup checker, liveness and readiness checkers start after startup checker succeeds.
+//it's called in every pod watch tick when container is running
+func (task *BcsContainerTask) StartProbes(host string) {
+	if task.StartupCheck != nil && !task.started {
+		if !task.StartupCheck.IsStarting() {
+			logs.Infof("container [%s] is running, starting StartupChecker, ip: %s\n", task.Name, host)
+			task.StartupCheck.SetHost(host)
+			go task.StartupCheck.Start()
+			return
+		}
+		//waiting for first success
+		if !task.StartupCheck.IsTicks() || task.StartupCheck.ConsecutiveFailure() != 0 {
+			return
+		}
+		logs.Infof("container [%s] startup probe succeeds, stop StartupChecker\n", task.Name)
+		task.StartupCheck.Stop()
+		task.started = true
+	}
+	for _, checker := range []healthcheck.Checker{task.HealthCheck, task.ReadinessCheck} {
+		if checker != nil && !checker.IsStarting() {
+			logs.Infof("container [%s] is running, starting %s, ip: %s\n", task.Name, checker.Name(), host)
+			checker.SetHost(host)
+			go checker.Start()
+		}
+	}
+}
+
+//StopProbes stop all checkers of container
+func (task *BcsContainerTask) StopProbes() {
+	for _, checker := range []healthcheck.Checker{task.StartupCheck, task.HealthCheck, task.ReadinessCheck} {
+		if checker != nil {
+			checker.Stop()
+		}
+	}
+}
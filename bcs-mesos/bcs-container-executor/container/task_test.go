@@ -0,0 +1,196 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"bk-bcs/bcs-mesos/bcs-container-executor/healthcheck"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//probeChecker is a checker whose check results are set by test,
+//Start is called in goroutine by StartProbes, so it notifies started
+type probeChecker struct {
+	healthcheck.Checker
+	name string
+
+	lock     sync.Mutex
+	host     string
+	starting bool
+	started  chan struct{}
+	stopped  int
+	healthy  bool
+	ticks    bool
+	failures int
+}
+
+func newProbeChecker(name string) *probeChecker {
+	return &probeChecker{name: name, started: make(chan struct{}, 1)}
+}
+
+func (c *probeChecker) Name() string {
+	return c.name
+}
+
+func (c *probeChecker) SetHost(host string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.host = host
+}
+
+func (c *probeChecker) Start() {
+	c.lock.Lock()
+	c.starting = true
+	c.lock.Unlock()
+	c.started <- struct{}{}
+}
+
+func (c *probeChecker) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.starting = false
+	c.stopped++
+}
+
+func (c *probeChecker) IsStarting() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.starting
+}
+
+func (c *probeChecker) IsHealthy() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.healthy
+}
+
+func (c *probeChecker) IsTicks() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ticks
+}
+
+func (c *probeChecker) ConsecutiveFailure() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.failures
+}
+
+func (c *probeChecker) Message() string {
+	return ""
+}
+
+//check set the result of one check
+func (c *probeChecker) check(healthy bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ticks = true
+	c.healthy = healthy
+	if healthy {
+		c.failures = 0
+	} else {
+		c.failures++
+	}
+}
+
+func (c *probeChecker) waitStarted(t *testing.T) {
+	select {
+	case <-c.started:
+	case <-time.After(time.Second):
+		t.Fatalf("checker %s not started", c.name)
+	}
+	assert.Equal(t, "127.0.0.1", c.host)
+}
+
+func (c *probeChecker) isStarted() bool {
+	select {
+	case <-c.started:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestStartProbesWithoutStartup(t *testing.T) {
+	liveness := newProbeChecker("liveness")
+	readiness := newProbeChecker("readiness")
+	task := &BcsContainerTask{Name: "c0", HealthCheck: liveness, ReadinessCheck: readiness}
+	assert.True(t, task.IsStarted())
+
+	task.StartProbes("127.0.0.1")
+	liveness.waitStarted(t)
+	readiness.waitStarted(t)
+
+	//started checkers are not started again in next tick
+	task.StartProbes("127.0.0.1")
+	assert.False(t, liveness.isStarted())
+	assert.False(t, readiness.isStarted())
+
+	task.StopProbes()
+	assert.Equal(t, 1, liveness.stopped)
+	assert.Equal(t, 1, readiness.stopped)
+}
+
+func TestStartProbesWaitStartup(t *testing.T) {
+	startup := newProbeChecker("startup")
+	liveness := newProbeChecker("liveness")
+	readiness := newProbeChecker("readiness")
+	task := &BcsContainerTask{Name: "c0", StartupCheck: startup, HealthCheck: liveness, ReadinessCheck: readiness}
+	assert.False(t, task.IsStarted())
+
+	//only startup checker starts first
+	task.StartProbes("127.0.0.1")
+	startup.waitStarted(t)
+	assert.False(t, liveness.isStarted())
+	assert.False(t, readiness.isStarted())
+
+	//not checked yet
+	task.StartProbes("127.0.0.1")
+	assert.False(t, task.IsStarted())
+
+	//still failing
+	startup.check(false)
+	task.StartProbes("127.0.0.1")
+	assert.False(t, task.IsStarted())
+	assert.False(t, liveness.isStarted())
+	assert.Equal(t, 0, startup.stopped)
+
+	//startup checker stops after first success, then other checkers start
+	startup.check(true)
+	task.StartProbes("127.0.0.1")
+	assert.True(t, task.IsStarted())
+	assert.Equal(t, 1, startup.stopped)
+	liveness.waitStarted(t)
+	readiness.waitStarted(t)
+
+	//startup checker is not started again once succeeded
+	task.StartProbes("127.0.0.1")
+	assert.False(t, startup.isStarted())
+	assert.True(t, task.IsStarted())
+}
+
+func TestStopProbes(t *testing.T) {
+	startup := newProbeChecker("startup")
+	task := &BcsContainerTask{Name: "c0", StartupCheck: startup}
+	task.StopProbes()
+	assert.Equal(t, 1, startup.stopped)
+
+	//no checker setting
+	task = &BcsContainerTask{Name: "c1"}
+	task.StopProbes()
+}
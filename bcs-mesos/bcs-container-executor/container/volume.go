@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	comtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	//DefaultVolumeCheckInterval interval for checking usage of emptyDir & local volumes
+	DefaultVolumeCheckInterval = 30 * time.Second
+	//DefaultLocalVolumeDirectory directory for local named volumes on agent
+	DefaultLocalVolumeDirectory = "/data/bcs/volumes"
+)
+
+//IsManaged volume is emptyDir or local volume managed by executor
+func (volume *BcsVolume) IsManaged() bool {
+	return volume.EmptyDir != nil || volume.Local != nil
+}
+
+//sizeLimit size limit in bytes checked by executor, 0 means no limit.
+//limit of tmpfs is ensured by kernel
+func (volume *BcsVolume) sizeLimit() int64 {
+	if volume.EmptyDir != nil && volume.EmptyDir.Medium != comtypes.VolumeMediumMemory {
+		return volume.EmptyDir.SizeLimit * 1024 * 1024
+	}
+	if volume.Local != nil {
+		return volume.Local.Quota * 1024 * 1024
+	}
+	return 0
+}
+
+//SetupVolume create host directory for emptyDir & local volume, and mount tmpfs
+//for emptyDir in memory. containers share the volume, so it can be setup repeatedly
+func SetupVolume(volume *BcsVolume) error {
+	if !volume.IsManaged() {
+		return nil
+	}
+	if err := os.MkdirAll(volume.HostPath, 0755); err != nil {
+		return fmt.Errorf("create volume directory %s failed, %s", volume.HostPath, err.Error())
+	}
+	if volume.EmptyDir == nil || volume.EmptyDir.Medium != comtypes.VolumeMediumMemory {
+		return nil
+	}
+	mounted, err := isMountPoint(volume.HostPath)
+	if err != nil {
+		return err
+	}
+	if mounted {
+		return nil
+	}
+	var options string
+	if volume.EmptyDir.SizeLimit > 0 {
+		options = fmt.Sprintf("size=%dm", volume.EmptyDir.SizeLimit)
+	}
+	if err := syscall.Mount("tmpfs", volume.HostPath, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+		return fmt.Errorf("mount tmpfs on %s failed, %s", volume.HostPath, err.Error())
+	}
+	logs.Infof("mount tmpfs on %s with options %s\n", volume.HostPath, options)
+	return nil
+}
+
+//setupVolumes setup all emptyDir & local volumes of container before creating it
+func setupVolumes(containerTask *BcsContainerTask) error {
+	for i := range containerTask.Volums {
+		if err := SetupVolume(&containerTask.Volums[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//CleanVolumes unmount & remove emptyDir volumes of all tasks in pod, local volumes are kept
+func CleanVolumes(tasks []*BcsContainerTask) {
+	for _, volume := range managedVolumes(tasks) {
+		if volume.EmptyDir == nil {
+			continue
+		}
+		if mounted, _ := isMountPoint(volume.HostPath); mounted {
+			if err := syscall.Unmount(volume.HostPath, syscall.MNT_DETACH); err != nil {
+				logs.Errorf("umount emptyDir %s failed, %s\n", volume.HostPath, err.Error())
+				continue
+			}
+		}
+		if err := os.RemoveAll(volume.HostPath); err != nil {
+			logs.Errorf("remove emptyDir %s failed, %s\n", volume.HostPath, err.Error())
+			continue
+		}
+		logs.Infof("emptyDir %s is removed\n", volume.HostPath)
+	}
+}
+
+//CheckVolumes check disk usage of emptyDir & local volumes, error is returned
+//when usage of any volume exceeds its size limit
+func CheckVolumes(tasks []*BcsContainerTask) error {
+	for _, volume := range managedVolumes(tasks) {
+		limit := volume.sizeLimit()
+		if limit <= 0 {
+			continue
+		}
+		usage, err := dirUsage(volume.HostPath)
+		if err != nil {
+			logs.Errorf("get usage of volume %s failed, %s\n", volume.HostPath, err.Error())
+			continue
+		}
+		if usage > limit {
+			return fmt.Errorf("usage %d bytes of volume %s exceeds limit %d bytes", usage, volume.HostPath, limit)
+		}
+	}
+	return nil
+}
+
+//managedVolumes emptyDir & local volumes of tasks, volume shared by containers is returned once
+func managedVolumes(tasks []*BcsContainerTask) []*BcsVolume {
+	var volumes []*BcsVolume
+	paths := make(map[string]bool)
+	for _, task := range tasks {
+		if task == nil {
+			continue
+		}
+		for i := range task.Volums {
+			volume := &task.Volums[i]
+			if !volume.IsManaged() || volume.HostPath == "" || paths[volume.HostPath] {
+				continue
+			}
+			paths[volume.HostPath] = true
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+//dirUsage total size of files in directory
+func dirUsage(dir string) (int64, error) {
+	var usage int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			//file removed during walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			usage += info.Size()
+		}
+		return nil
+	})
+	return usage, err
+}
+
+//isMountPoint path is mount point when its device is different from its parent
+func isMountPoint(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	parent, err := os.Stat(filepath.Dir(filepath.Clean(path)))
+	if err != nil {
+		return false, err
+	}
+	return info.Sys().(*syscall.Stat_t).Dev != parent.Sys().(*syscall.Stat_t).Dev, nil
+}
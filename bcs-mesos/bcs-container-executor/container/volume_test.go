@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	comtypes "bk-bcs/bcs-common/common/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumesLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "volume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	emptyDir := BcsVolume{
+		HostPath:      filepath.Join(dir, "sandbox", "volumes", "cache"),
+		ContainerPath: "/cache",
+		EmptyDir:      &comtypes.EmptyDirVolume{SizeLimit: 1},
+	}
+	local := BcsVolume{
+		HostPath:      filepath.Join(dir, "local", "data"),
+		ContainerPath: "/data",
+		Local:         &comtypes.LocalVolume{Name: "data"},
+	}
+	hostPath := BcsVolume{HostPath: filepath.Join(dir, "host"), ContainerPath: "/host"}
+	tasks := []*BcsContainerTask{
+		{Name: "app", Volums: []BcsVolume{emptyDir, local, hostPath}},
+		{Name: "sidecar", Volums: []BcsVolume{emptyDir}},
+	}
+	//shared emptyDir is returned once, host path volume is not managed
+	assert.Len(t, managedVolumes(tasks), 2)
+
+	assert.Nil(t, setupVolumes(tasks[0]))
+	assert.Nil(t, setupVolumes(tasks[1]))
+	assert.True(t, isDir(emptyDir.HostPath))
+	assert.True(t, isDir(local.HostPath))
+	_, err = os.Stat(hostPath.HostPath)
+	assert.True(t, os.IsNotExist(err))
+
+	//emptyDir size limit is 1MB
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(emptyDir.HostPath, "small"), make([]byte, 1024), 0644))
+	assert.Nil(t, CheckVolumes(tasks))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(emptyDir.HostPath, "large"), make([]byte, 1024*1024), 0644))
+	assert.NotNil(t, CheckVolumes(tasks))
+
+	//emptyDir is removed, local volume is kept
+	CleanVolumes(tasks)
+	_, err = os.Stat(emptyDir.HostPath)
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, isDir(local.HostPath))
+}
+
+func TestCRIContainerEmptyDir(t *testing.T) {
+	runtime, cri, cleanup := newFakeCRI(t)
+	defer cleanup()
+	dir, err := ioutil.TempDir("", "volume")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	task := &BcsContainerTask{
+		Name:        "app",
+		Image:       "nginx:latest",
+		NetworkName: "bridge",
+		Volums: []BcsVolume{{
+			HostPath:      filepath.Join(dir, "cache"),
+			ContainerPath: "/cache",
+			EmptyDir:      &comtypes.EmptyDirVolume{},
+		}},
+	}
+	info, err := cri.CreateContainer(task.Name, task)
+	assert.Nil(t, err)
+	assert.True(t, isDir(filepath.Join(dir, "cache")))
+	assert.Equal(t, filepath.Join(dir, "cache"), runtime.Containers[info.ID].Config.GetMounts()[0].GetHostPath())
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -12,3 +12,194 @@
  */
 
 package healthcheck
+
+import (
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-container-executor/logs"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+//CommandOutputLimit max length of command output kept in check message
+const CommandOutputLimit = 256
+
+//CommandRunner run command in container, container.Container implements it
+type CommandRunner interface {
+	RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error)
+}
+
+//NewCommandChecker create command checker, command is executed by /bin/sh -c in container
+func NewCommandChecker(container, command string, runner CommandRunner, mechanism *TimeMechanism, notify FailureNotify) (Checker, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("command checker command is empty")
+	}
+	if runner == nil {
+		return nil, fmt.Errorf("command checker runner is nil")
+	}
+	if mechanism.IntervalSeconds <= mechanism.TimeoutSeconds {
+		return nil, fmt.Errorf("Interval Seconds must larger than Timeout Seconds")
+	}
+	cxt, cancel := context.WithCancel(context.Background())
+	checker := &CommandChecker{
+		CheckerStat: CheckerStat{
+			Failures:            0,
+			Ticks:               0,
+			ConsecutiveFailures: 0,
+			Healthy:             true,
+		},
+		container: container,
+		command:   command,
+		runner:    runner,
+		isPause:   false,
+		cxt:       cxt,
+		canceler:  cancel,
+		mechanism: mechanism,
+		notify:    notify,
+	}
+	return checker, nil
+}
+
+//CommandChecker check health by command exit code, 0 is healthy
+type CommandChecker struct {
+	CheckerStat
+	container string             //name for container
+	command   string             //command to check
+	runner    CommandRunner      //run command in container
+	isPause   bool               //status for pause
+	cxt       context.Context    //context to control exit
+	canceler  context.CancelFunc //canceler
+	mechanism *TimeMechanism     //time config for checker
+	notify    FailureNotify      //callback when unhealthy
+}
+
+//IsStarting checker is running
+func (check *CommandChecker) IsStarting() bool {
+	return check.Started
+}
+
+//SetHost command runs in container, host is no use
+func (check *CommandChecker) SetHost(host string) {
+}
+
+//Start start checker, must running in indivisual goroutine
+func (check *CommandChecker) Start() {
+	check.Started = true
+	check.StartPoint = time.Now()
+	time.Sleep(time.Duration(int64(check.mechanism.GracePeriodSeconds)) * time.Second)
+	tick := time.NewTicker(time.Duration(int64(check.mechanism.IntervalSeconds)) * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-check.cxt.Done():
+			logs.Infof("CommandChecker ###%s### ask to exit", check.container)
+			return
+		case check.LastCheck = <-tick.C:
+			if check.isPause {
+				continue
+			}
+			check.Ticks++
+			healthy := check.ReCheck()
+			if !healthy {
+				check.LastFailure = check.LastCheck
+				check.Failures++
+				check.ConsecutiveFailures++
+				//become unhealthy only when consecutive failures reach the setting
+				if check.Healthy && check.ConsecutiveFailures >= check.mechanism.ConsecutiveFailures {
+					check.Healthy = false
+					logs.Infof("CommandChecker %s become **Unhealthy**: %s", check.container, check.Message())
+					if check.notify != nil {
+						check.notify(check)
+					}
+				}
+			} else {
+				check.Healthy = true
+				check.ConsecutiveFailures = 0
+			}
+		}
+	}
+}
+
+//Stop stop checker
+func (check *CommandChecker) Stop() {
+	check.canceler()
+}
+
+//ReCheck ask checker to check, the command is killed and regarded as failed
+//when it is not finished in TimeoutSeconds
+func (check *CommandChecker) ReCheck() bool {
+	request := &schedTypes.RequestCommandTask{
+		ID:          fmt.Sprintf("healthcheck-%d", time.Now().UnixNano()),
+		ContainerId: check.container,
+		Cmd:         []string{"/bin/sh", "-c", check.command},
+	}
+
+	var cxt context.Context
+	var cancel context.CancelFunc
+	if check.mechanism.TimeoutSeconds > 0 {
+		cxt, cancel = context.WithTimeout(check.cxt, time.Duration(int64(check.mechanism.TimeoutSeconds))*time.Second)
+	} else {
+		cxt, cancel = context.WithCancel(check.cxt)
+	}
+	defer cancel()
+	resp, err := check.runner.RunCommandContext(cxt, request)
+	if cxt.Err() == context.DeadlineExceeded {
+		check.setMessage(fmt.Sprintf("command timeout after %d seconds", check.mechanism.TimeoutSeconds))
+		return false
+	}
+	healthy, message := commandResult(resp, err)
+	check.setMessage(message)
+	return healthy
+}
+
+//commandResult returns whether the command succeeded, and the exit code with truncated output as message
+func commandResult(resp *schedTypes.ResponseCommandTask, err error) (bool, string) {
+	if err != nil {
+		return false, truncate("run command failed: " + err.Error())
+	}
+	if resp == nil {
+		return false, "run command failed: no response"
+	}
+	if resp.Status != commtypes.TaskCommandStatusFinish || resp.CommInspect == nil {
+		return false, truncate("run command failed: " + resp.Message)
+	}
+
+	output := strings.TrimSpace(resp.CommInspect.Stdout + resp.CommInspect.Stderr)
+	message := fmt.Sprintf("exit code %d", resp.CommInspect.ExitCode)
+	if output != "" {
+		message = message + ": " + truncate(output)
+	}
+	return resp.CommInspect.ExitCode == 0, message
+}
+
+func truncate(s string) string {
+	if len(s) > CommandOutputLimit {
+		return s[:CommandOutputLimit] + "..."
+	}
+	return s
+}
+
+//Pause pause check
+func (check *CommandChecker) Pause() error {
+	check.isPause = true
+	return nil
+}
+
+//Resume arouse checker
+func (check *CommandChecker) Resume() error {
+	check.isPause = false
+	return nil
+}
+
+//Name get check name
+func (check *CommandChecker) Name() string {
+	return "cmd://" + check.container + "/" + check.command
+}
+
+//Relation checker relative to container
+func (check *CommandChecker) Relation() string {
+	return check.container
+}
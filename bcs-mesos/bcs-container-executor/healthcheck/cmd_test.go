@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package healthcheck
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	schedTypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+//fakeRunner returns results in order, the last one is repeated.
+//a nil result blocks until context is done like a hanging command
+type fakeRunner struct {
+	sync.Mutex
+	results  []*schedTypes.ResponseCommandTask
+	calls    int
+	requests []*schedTypes.RequestCommandTask
+	killed   []error
+}
+
+func (r *fakeRunner) RunCommandContext(cxt context.Context, ops *schedTypes.RequestCommandTask) (*schedTypes.ResponseCommandTask, error) {
+	r.Lock()
+	r.requests = append(r.requests, ops)
+	index := r.calls
+	if index >= len(r.results) {
+		index = len(r.results) - 1
+	}
+	r.calls++
+	result := r.results[index]
+	r.Unlock()
+	if result == nil {
+		<-cxt.Done()
+		r.Lock()
+		r.killed = append(r.killed, cxt.Err())
+		r.Unlock()
+		return &schedTypes.ResponseCommandTask{Status: commtypes.TaskCommandStatusFailed, Message: cxt.Err().Error()}, nil
+	}
+	return result, nil
+}
+
+func exitResult(code int, stdout string) *schedTypes.ResponseCommandTask {
+	return &schedTypes.ResponseCommandTask{
+		Status: commtypes.TaskCommandStatusFinish,
+		CommInspect: &commtypes.CommandInspectInfo{
+			ExitCode: code,
+			Stdout:   stdout,
+		},
+	}
+}
+
+func newTestCommandChecker(t *testing.T, runner CommandRunner, mechanism *TimeMechanism, notify FailureNotify) *CommandChecker {
+	checker, err := NewCommandChecker("app", "cat /tmp/healthy", runner, mechanism, notify)
+	assert.Nil(t, err)
+	return checker.(*CommandChecker)
+}
+
+func TestNewCommandChecker(t *testing.T) {
+	mechanism := &TimeMechanism{IntervalSeconds: 2, TimeoutSeconds: 1}
+	_, err := NewCommandChecker("app", "", &fakeRunner{}, mechanism, nil)
+	assert.NotNil(t, err)
+	_, err = NewCommandChecker("app", "true", nil, mechanism, nil)
+	assert.NotNil(t, err)
+	_, err = NewCommandChecker("app", "true", &fakeRunner{}, &TimeMechanism{IntervalSeconds: 1, TimeoutSeconds: 1}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCommandCheckerExitCode(t *testing.T) {
+	runner := &fakeRunner{results: []*schedTypes.ResponseCommandTask{
+		exitResult(0, "ok\n"),
+		exitResult(2, ""),
+		{Status: commtypes.TaskCommandStatusFailed, Message: "no such container"},
+	}}
+	checker := newTestCommandChecker(t, runner, &TimeMechanism{IntervalSeconds: 2, TimeoutSeconds: 1}, nil)
+
+	assert.True(t, checker.ReCheck())
+	assert.Equal(t, "exit code 0: ok", checker.Message())
+	assert.Equal(t, "app", runner.requests[0].ContainerId)
+	assert.Equal(t, []string{"/bin/sh", "-c", "cat /tmp/healthy"}, runner.requests[0].Cmd)
+
+	assert.False(t, checker.ReCheck())
+	assert.Equal(t, "exit code 2", checker.Message())
+
+	assert.False(t, checker.ReCheck())
+	assert.Equal(t, "run command failed: no such container", checker.Message())
+}
+
+func TestCommandCheckerTruncateOutput(t *testing.T) {
+	output := strings.Repeat("a", CommandOutputLimit+10)
+	runner := &fakeRunner{results: []*schedTypes.ResponseCommandTask{exitResult(1, output)}}
+	checker := newTestCommandChecker(t, runner, &TimeMechanism{IntervalSeconds: 2, TimeoutSeconds: 1}, nil)
+
+	assert.False(t, checker.ReCheck())
+	assert.Equal(t, "exit code 1: "+output[:CommandOutputLimit]+"...", checker.Message())
+}
+
+func TestCommandCheckerTimeout(t *testing.T) {
+	runner := &fakeRunner{results: []*schedTypes.ResponseCommandTask{nil}}
+	checker := newTestCommandChecker(t, runner, &TimeMechanism{IntervalSeconds: 2, TimeoutSeconds: 1}, nil)
+
+	start := time.Now()
+	assert.False(t, checker.ReCheck())
+	assert.True(t, time.Since(start) < 2*time.Second)
+	assert.Equal(t, "command timeout after 1 seconds", checker.Message())
+	//running command is asked to be killed when timeout
+	assert.Equal(t, []error{context.DeadlineExceeded}, runner.killed)
+}
+
+func TestCommandCheckerConsecutiveFailures(t *testing.T) {
+	//the last command hangs until checker stops, so stats are stable when checked
+	runner := &fakeRunner{results: []*schedTypes.ResponseCommandTask{
+		exitResult(1, ""),
+		exitResult(0, ""),
+		exitResult(1, "first"),
+		exitResult(1, "second"),
+		exitResult(0, ""),
+		nil,
+	}}
+	type stat struct {
+		message  string
+		healthy  bool
+		failures int
+	}
+	notified := make(chan stat, 10)
+	notify := func(c Checker) {
+		notified <- stat{message: c.Message(), healthy: c.IsHealthy(), failures: c.Failure()}
+	}
+	checker := newTestCommandChecker(t, runner, &TimeMechanism{IntervalSeconds: 1, ConsecutiveFailures: 2}, notify)
+	go checker.Start()
+	defer checker.Stop()
+
+	select {
+	case s := <-notified:
+		//single failure is reset by success, only two failures in a row make checker unhealthy
+		assert.Equal(t, "exit code 1: second", s.message)
+		assert.False(t, s.healthy)
+		assert.Equal(t, 3, s.failures)
+	case <-time.After(10 * time.Second):
+		t.Fatal("checker is not notified when consecutive failures reach the setting")
+	}
+
+	calls := 0
+	for i := 0; i < 30 && calls < len(runner.results); i++ {
+		time.Sleep(100 * time.Millisecond)
+		runner.Lock()
+		calls = runner.calls
+		runner.Unlock()
+	}
+	assert.Equal(t, len(runner.results), calls)
+	//success makes checker healthy again
+	assert.True(t, checker.IsHealthy())
+	assert.Equal(t, 0, checker.ConsecutiveFailure())
+	assert.Equal(t, 6, checker.TotalTicks())
+	assert.Equal(t, 0, len(notified))
+}
@@ -13,7 +13,10 @@
 
 package healthcheck
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 //TimeMechanism item for checker
 type TimeMechanism struct {
@@ -29,14 +32,16 @@ type FailureNotify func(Checker)
 
 //CheckerStat checker statistic
 type CheckerStat struct {
-	Started             bool      //status for started
-	StartPoint          time.Time //starting time
-	LastFailure         time.Time //time record
-	LastCheck           time.Time //time record
-	Failures            int       //amount of failure
-	Ticks               int       //total checks
-	ConsecutiveFailures int       //failure
-	Healthy             bool      //healthy status
+	Started             bool         //status for started
+	StartPoint          time.Time    //starting time
+	LastFailure         time.Time    //time record
+	LastCheck           time.Time    //time record
+	Failures            int          //amount of failure
+	Ticks               int          //total checks
+	ConsecutiveFailures int          //failure
+	Healthy             bool         //healthy status
+	lastMessage         string       //message of last check
+	messageLock         sync.RWMutex //lock for lastMessage, it's read by executor when checker is running
 }
 
 //TotalTicks total check ticks
@@ -77,6 +82,19 @@ func (check *CheckerStat) IsHealthy() bool {
 	return check.Healthy
 }
 
+//Message message of last check, such as exit code and output of command
+func (check *CheckerStat) Message() string {
+	check.messageLock.RLock()
+	defer check.messageLock.RUnlock()
+	return check.lastMessage
+}
+
+func (check *CheckerStat) setMessage(message string) {
+	check.messageLock.Lock()
+	defer check.messageLock.Unlock()
+	check.lastMessage = message
+}
+
 //Checker health check interface
 type Checker interface {
 	IsStarting() bool           //checker is running
@@ -95,4 +113,5 @@ type Checker interface {
 	Resume() error              //arouse checker
 	Name() string               //checker name
 	Relation() string           //checker relative to container
+	Message() string            //message of last check
 }
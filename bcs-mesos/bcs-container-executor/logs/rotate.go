@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//segment one log file of RotateFile, start is offset of
+//the first byte in file in the whole logs stream
+type segment struct {
+	path    string
+	start   int64
+	size    int64
+	created time.Time
+}
+
+//RotateFile io.Writer for container logs, logs are written to file $name,
+//when file size reaches MaxSize or it is older than MaxAge, file is rotated
+//to $name.1, $name.2 ..., and at most MaxFiles files are kept.
+//All logs are seen as a stream, offset of stream is used for reading.
+type RotateFile struct {
+	MaxSize  int64         //max bytes of one file
+	MaxFiles int           //max files including the writing one
+	MaxAge   time.Duration //max age of file, 0 means no limit
+	name     string
+	lock     sync.RWMutex
+	file     *os.File
+	segments []*segment //oldest first, the last one is the writing file
+}
+
+//NewRotateFile create RotateFile for path, directory is created if not exist
+func NewRotateFile(path string, maxSize int64, maxFiles int, maxAge time.Duration) (*RotateFile, error) {
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &RotateFile{
+		MaxSize:  maxSize,
+		MaxFiles: maxFiles,
+		MaxAge:   maxAge,
+		name:     path,
+		file:     file,
+		segments: []*segment{{path: path, created: time.Now()}},
+	}, nil
+}
+
+//Write write logs to current file, rotate it if needed
+func (r *RotateFile) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.file == nil {
+		return 0, fmt.Errorf("rotate file %s is closed", r.name)
+	}
+	current := r.segments[len(r.segments)-1]
+	if current.size > 0 && (current.size+int64(len(p)) > r.MaxSize ||
+		(r.MaxAge > 0 && time.Since(current.created) > r.MaxAge)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+		current = r.segments[len(r.segments)-1]
+	}
+	n, err := r.file.Write(p)
+	current.size += int64(n)
+	return n, err
+}
+
+//rotate rename files to $name.N and create new file, lock must be held
+func (r *RotateFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	//drop files out of MaxFiles & MaxAge
+	keep := r.segments[:0]
+	for i, seg := range r.segments {
+		expired := r.MaxAge > 0 && time.Since(seg.created) > r.MaxAge && i != len(r.segments)-1
+		if len(r.segments)-i >= r.MaxFiles || expired {
+			os.Remove(seg.path)
+			continue
+		}
+		keep = append(keep, seg)
+	}
+	r.segments = keep
+	//rename from the oldest one, $name.N is older than $name.N-1
+	for i := 0; i < len(r.segments); i++ {
+		seg := r.segments[i]
+		path := r.name + "." + strconv.Itoa(len(r.segments)-i)
+		if err := os.Rename(seg.path, path); err != nil {
+			return err
+		}
+		seg.path = path
+	}
+	var next int64
+	if len(r.segments) != 0 {
+		last := r.segments[len(r.segments)-1]
+		next = last.start + last.size
+	}
+	file, err := os.OpenFile(r.name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.segments = append(r.segments, &segment{path: r.name, start: next, created: time.Now()})
+	return nil
+}
+
+//Size end offset of logs stream
+func (r *RotateFile) Size() int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	last := r.segments[len(r.segments)-1]
+	return last.start + last.size
+}
+
+//ReadAt read at most limit bytes from offset of logs stream, if logs at offset
+//are already removed, read from the oldest one. return the real offset of data
+func (r *RotateFile) ReadAt(offset int64, limit int) ([]byte, int64, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if offset < r.segments[0].start {
+		offset = r.segments[0].start
+	}
+	buf := bytes.NewBuffer(nil)
+	for _, seg := range r.segments {
+		end := seg.start + seg.size
+		if offset+int64(buf.Len()) >= end {
+			continue
+		}
+		if buf.Len() >= limit {
+			break
+		}
+		file, err := os.Open(seg.path)
+		if err != nil {
+			return nil, offset, err
+		}
+		from := offset + int64(buf.Len()) - seg.start
+		size := end - seg.start - from
+		if size > int64(limit-buf.Len()) {
+			size = int64(limit - buf.Len())
+		}
+		_, err = io.Copy(buf, io.NewSectionReader(file, from, size))
+		file.Close()
+		if err != nil {
+			return nil, offset, err
+		}
+	}
+	return buf.Bytes(), offset, nil
+}
+
+//Tail read the last lines of logs stream, at most limit bytes.
+//return the offset of data
+func (r *RotateFile) Tail(lines int, limit int) ([]byte, int64, error) {
+	end := r.Size()
+	start := end - int64(limit)
+	if start < 0 {
+		start = 0
+	}
+	data, offset, err := r.ReadAt(start, limit)
+	if err != nil {
+		return nil, offset, err
+	}
+	//skip the last newline, then search lines from the end
+	pos := len(data)
+	if pos > 0 && data[pos-1] == '\n' {
+		pos--
+	}
+	for i := 0; i < lines; i++ {
+		idx := bytes.LastIndexByte(data[:pos], '\n')
+		if idx < 0 {
+			pos = -1
+			break
+		}
+		pos = idx
+	}
+	if pos < 0 {
+		return data, offset, nil
+	}
+	return data[pos+1:], offset + int64(pos+1), nil
+}
+
+//Close close writing file, rotated files are kept for reading
+func (r *RotateFile) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
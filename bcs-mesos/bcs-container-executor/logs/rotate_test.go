@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file, err := NewRotateFile(filepath.Join(dir, "app.log"), 10, 3, 0)
+	assert.Nil(t, err)
+	for _, line := range []string{"line1\n", "line2\n", "line3\n", "line4\n", "line5\n"} {
+		_, err = file.Write([]byte(line))
+		assert.Nil(t, err)
+	}
+	//every file holds one line, line1 & line2 are rotated away
+	assert.Equal(t, int64(30), file.Size())
+	files, _ := filepath.Glob(filepath.Join(dir, "app.log*"))
+	assert.Equal(t, 3, len(files))
+
+	data, offset, err := file.ReadAt(0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), offset)
+	assert.Equal(t, "line3\nline4\nline5\n", string(data))
+
+	data, offset, err = file.ReadAt(20, 8)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), offset)
+	assert.Equal(t, "ne4\nline", string(data))
+
+	data, offset, err = file.Tail(2, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(18), offset)
+	assert.Equal(t, "line4\nline5\n", string(data))
+
+	data, offset, err = file.ReadAt(30, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), offset)
+	assert.Equal(t, 0, len(data))
+
+	//logs can be read after closed
+	assert.Nil(t, file.Close())
+	data, _, err = file.Tail(1, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, "line5\n", string(data))
+}
@@ -108,6 +108,9 @@ func (s *Scheduler) newVersionWithParam(param *bcstype.ReplicaController) (*type
 	}
 	version.Instances = int32(param.ReplicaControllerSpec.Instance)
 	version.Constraints = param.Constraints
+	version.Priority = param.Priority
+	version.ScorePolicy = param.ScorePolicy
+	version.ConfigReload = param.ConfigReload
 
 	for k, v := range param.Labels {
 		version.Labels[k] = v
@@ -211,6 +214,8 @@ func (s *Scheduler) setVersionWithPodSpec(version *types.Version, spec *bcstype.
 		container.NetLimit = spec.PodSpec.NetLimit
 		container.DataClass.NetLimit = container.NetLimit
 
+		container.Name = c.Name
+
 		//docker
 		container.Docker = new(types.Docker)
 		container.Docker.Image = c.Image
@@ -261,6 +266,9 @@ func (s *Scheduler) setVersionWithPodSpec(version *types.Version, spec *bcstype.
 			vol := new(types.Volume)
 			vol.ContainerPath = volUnit.Volume.MountPath
 			vol.HostPath = volUnit.Volume.HostPath
+			vol.Name = volUnit.Name
+			vol.EmptyDir = volUnit.Volume.EmptyDir
+			vol.Local = volUnit.Volume.Local
 			vol.Mode = "RW"
 			if volUnit.Volume.ReadOnly {
 				vol.Mode = "R"
@@ -277,25 +285,103 @@ func (s *Scheduler) setVersionWithPodSpec(version *types.Version, spec *bcstype.
 
 		container.HealthChecks = c.HealthChecks
 		for _, oneCheck := range container.HealthChecks {
-			if oneCheck.DelaySeconds <= 0 {
-				oneCheck.DelaySeconds = 10
-			}
-			if oneCheck.IntervalSeconds <= 0 {
-				oneCheck.IntervalSeconds = 60
-			}
-			if oneCheck.TimeoutSeconds <= 0 {
-				oneCheck.TimeoutSeconds = 20
-			}
-			if oneCheck.ConsecutiveFailures < 0 {
-				oneCheck.ConsecutiveFailures = 0
-			}
-			if oneCheck.GracePeriodSeconds <= 0 {
-				oneCheck.GracePeriodSeconds = 300
+			setHealthCheckDefault(oneCheck)
+		}
+
+		//probes
+		container.LivenessProbe = c.LivenessProbe
+		container.ReadinessProbe = c.ReadinessProbe
+		container.StartupProbe = c.StartupProbe
+		for _, probe := range []*bcstype.HealthCheck{container.LivenessProbe, container.ReadinessProbe, container.StartupProbe} {
+			if probe != nil {
+				setHealthCheckDefault(probe)
 			}
 		}
 
+		//hooks
+		container.Lifecycle = c.Lifecycle
+
 		version.Container = append(version.Container, container)
 	} //end for i
 
+	//init containers
+	for i := range spec.PodSpec.InitContainers {
+		version.InitContainers = append(version.InitContainers, newInitContainer(&spec.PodSpec.InitContainers[i], spec))
+	}
+
 	return version, nil
 }
+
+//newInitContainer convert init container in pod spec, init container shares network with taskgroup
+func newInitContainer(c *bcstype.Container, spec *bcstype.PodTemplateSpec) *types.InitContainer {
+	if c.Resources.Requests.Cpu == "" && c.Resources.Limits.Cpu != "" {
+		c.Resources.Requests.Cpu = c.Resources.Limits.Cpu
+		c.Resources.Requests.Mem = c.Resources.Limits.Mem
+		c.Resources.Requests.Storage = c.Resources.Limits.Storage
+	}
+
+	initContainer := new(types.InitContainer)
+	initContainer.Name = c.Name
+	initContainer.Resources = new(types.Resource)
+	initContainer.Resources.Cpus, _ = strconv.ParseFloat(c.Resources.Requests.Cpu, 64)
+	initContainer.Resources.Mem, _ = strconv.ParseFloat(c.Resources.Requests.Mem, 64)
+	initContainer.Resources.Disk, _ = strconv.ParseFloat(c.Resources.Requests.Storage, 64)
+	initContainer.LimitResoures = new(types.Resource)
+	initContainer.LimitResoures.Cpus, _ = strconv.ParseFloat(c.Resources.Limits.Cpu, 64)
+	initContainer.LimitResoures.Mem, _ = strconv.ParseFloat(c.Resources.Limits.Mem, 64)
+	initContainer.LimitResoures.Disk, _ = strconv.ParseFloat(c.Resources.Limits.Storage, 64)
+
+	initContainer.Docker = new(types.Docker)
+	initContainer.Docker.Image = c.Image
+	initContainer.Docker.ImagePullUser = c.ImagePullUser
+	initContainer.Docker.ImagePullPasswd = c.ImagePullPasswd
+	initContainer.Docker.ForcePullImage = c.ImagePullPolicy == bcstype.ImagePullPolicy_ALWAYS
+	initContainer.Docker.Privileged = c.Privileged
+	initContainer.Docker.Network = spec.PodSpec.NetworkMode
+	initContainer.Docker.NetworkType = spec.PodSpec.NetworkType
+	initContainer.Docker.Command = c.Command
+	initContainer.Docker.Arguments = c.Args
+	initContainer.Docker.Parameters = []*types.Parameter{}
+	for _, ps := range c.Parameters {
+		initContainer.Docker.Parameters = append(initContainer.Docker.Parameters, &types.Parameter{Key: ps.Key, Value: ps.Value})
+	}
+	initContainer.Docker.Env = make(map[string]string)
+	for _, env := range c.Env {
+		initContainer.Docker.Env[env.Name] = env.Value
+	}
+
+	for _, volUnit := range c.Volumes {
+		vol := new(types.Volume)
+		vol.ContainerPath = volUnit.Volume.MountPath
+		vol.HostPath = volUnit.Volume.HostPath
+		vol.Name = volUnit.Name
+		vol.EmptyDir = volUnit.Volume.EmptyDir
+		vol.Local = volUnit.Volume.Local
+		vol.Mode = "RW"
+		if volUnit.Volume.ReadOnly {
+			vol.Mode = "R"
+		}
+		initContainer.Volumes = append(initContainer.Volumes, vol)
+	}
+
+	return initContainer
+}
+
+//setHealthCheckDefault setting default time mechanism for health check
+func setHealthCheckDefault(check *bcstype.HealthCheck) {
+	if check.DelaySeconds <= 0 {
+		check.DelaySeconds = 10
+	}
+	if check.IntervalSeconds <= 0 {
+		check.IntervalSeconds = 60
+	}
+	if check.TimeoutSeconds <= 0 {
+		check.TimeoutSeconds = 20
+	}
+	if check.ConsecutiveFailures < 0 {
+		check.ConsecutiveFailures = 0
+	}
+	if check.GracePeriodSeconds <= 0 {
+		check.GracePeriodSeconds = 300
+	}
+}
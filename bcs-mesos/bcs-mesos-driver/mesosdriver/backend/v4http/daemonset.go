@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package v4http
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"bk-bcs/bcs-common/common"
+	"bk-bcs/bcs-common/common/blog"
+	bhttp "bk-bcs/bcs-common/common/http"
+	bcstype "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+)
+
+func (s *Scheduler) CreateDaemonset(body []byte) (string, error) {
+	blog.Info("create daemonset. param(%s)", string(body))
+	var param bcstype.BcsDaemonset
+
+	//encoding param by json
+	if err := json.Unmarshal(body, &param); err != nil {
+		blog.Error("parse parameters failed. param(%s), err(%s)", string(body), err.Error())
+		err = bhttp.InternalError(common.BcsErrCommJsonDecode, common.BcsErrCommJsonDecodeStr)
+		return err.Error(), err
+	}
+
+	// bcs-mesos-scheduler daemonsetDef
+	daemonsetDef, err := s.newDaemonsetDefWithParam(&param)
+	if err != nil {
+		return err.Error(), err
+	}
+	daemonsetDef.RawJson = &param
+
+	// post daemonsetdef to bcs-mesos-scheduler,
+	data, err := json.Marshal(daemonsetDef)
+	if err != nil {
+		blog.Error("marshal parameter daemonsetDef by json failed. err:%s", err.Error())
+		err = bhttp.InternalError(common.BcsErrCommJsonEncode, common.BcsErrCommJsonEncodeStr+"encode daemonsetDef by json")
+		return err.Error(), err
+	}
+
+	if s.GetHost() == "" {
+		blog.Error("no scheduler is connected by driver")
+		err := bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+"scheduler not exist")
+		return err.Error(), err
+	}
+
+	url := fmt.Sprintf("%s/v1/daemonset/%s/%s", s.GetHost(), param.NameSpace, param.Name)
+	blog.Info("post a request to url(%s), request:%s", url, string(data))
+
+	reply, err := s.client.POST(url, nil, data)
+	if err != nil {
+		blog.Error("post request to url(%s) failed! err(%s)", url, err.Error())
+		err = bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+err.Error())
+		return err.Error(), err
+	}
+
+	return string(reply), nil
+}
+
+func (s *Scheduler) deleteDaemonset(ns, name string, enforce string) (string, error) {
+	blog.Info("delete daemonset namespace %s name %s", ns, name)
+
+	if s.GetHost() == "" {
+		blog.Error("no scheduler is connected by driver")
+		err := bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+"scheduler not exist")
+		return err.Error(), err
+	}
+
+	url := fmt.Sprintf("%s/v1/daemonset/%s/%s?enforce=%s", s.GetHost(), ns, name, enforce)
+	blog.Info("post a request to url(%s), request: null", url)
+
+	reply, err := s.client.DELETE(url, nil, nil)
+	if err != nil {
+		blog.Error("post request to url(%s) failed! err(%s)", url, err.Error())
+		err = bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+err.Error())
+		return err.Error(), err
+	}
+
+	return string(reply), nil
+}
+
+func (s *Scheduler) newDaemonsetDefWithParam(param *bcstype.BcsDaemonset) (*types.DaemonsetDef, error) {
+
+	if param.Spec.Template == nil {
+		blog.Error("daemonset(%s.%s) template is nil", param.NameSpace, param.Name)
+		replyErr := bhttp.InternalError(common.BcsErrMesosDriverParameterErr,
+			common.BcsErrMesosDriverParameterErrStr+"daemonset template is nil")
+		return nil, replyErr
+	}
+
+	daemonsetDef := &types.DaemonsetDef{
+		ObjectMeta: param.ObjectMeta,
+	}
+
+	version := &types.Version{
+		ID:          param.Name,
+		Instances:   0,
+		RunAs:       param.NameSpace,
+		Container:   []*types.Container{},
+		Process:     []*bcstype.Process{},
+		Labels:      make(map[string]string),
+		Constraints: param.Constraints,
+		Uris:        []string{},
+		Ip:          []string{},
+		Mode:        "",
+		Priority:    param.Priority,
+	}
+	version.ObjectMeta = param.ObjectMeta
+	version.KillPolicy = &param.KillPolicy
+	version.ConfigReload = param.ConfigReload
+
+	version.RestartPolicy = &param.RestartPolicy
+	if version.RestartPolicy.Policy == "" {
+		version.RestartPolicy.Policy = bcstype.RestartPolicy_ALWAYS
+	}
+	if version.RestartPolicy.Policy != bcstype.RestartPolicy_ONFAILURE &&
+		version.RestartPolicy.Policy != bcstype.RestartPolicy_ALWAYS &&
+		version.RestartPolicy.Policy != bcstype.RestartPolicy_NEVER {
+		blog.Error("error restart policy: %s", version.RestartPolicy.Policy)
+		replyErr := bhttp.InternalError(common.BcsErrMesosDriverParameterErr,
+			common.BcsErrMesosDriverParameterErrStr+"restart policy error")
+		return nil, replyErr
+	}
+
+	for k, v := range param.Labels {
+		version.Labels[k] = v
+	}
+
+	version, err := s.setVersionWithPodSpec(version, param.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	daemonsetDef.Version = version
+
+	return daemonsetDef, nil
+}
@@ -233,6 +233,73 @@ func (s *Scheduler) scaleDeployment(ns, name string, instances int) (string, err
 	return string(reply), nil
 }
 
+func (s *Scheduler) listDeploymentRevisions(ns, name string) (string, error) {
+	return s.getDeploymentRevisionData(fmt.Sprintf("%s/v1/deployment/%s/%s/revisions", s.GetHost(), ns, name))
+}
+
+func (s *Scheduler) getDeploymentRevision(ns, name, revision string) (string, error) {
+	return s.getDeploymentRevisionData(fmt.Sprintf("%s/v1/deployment/%s/%s/revisions/%s", s.GetHost(), ns, name, revision))
+}
+
+func (s *Scheduler) diffDeploymentRevisions(ns, name, from, to string) (string, error) {
+	return s.getDeploymentRevisionData(fmt.Sprintf("%s/v1/deployment/%s/%s/revisiondiff/%s/%s", s.GetHost(), ns, name, from, to))
+}
+
+func (s *Scheduler) getDeploymentRevisionData(url string) (string, error) {
+	if s.GetHost() == "" {
+		blog.Error("no scheduler is connected by driver")
+		err := bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+"scheduler not exist")
+		return err.Error(), err
+	}
+
+	blog.V(3).Infof("get a request to url(%s)", url)
+
+	reply, err := s.client.GET(url, nil, nil)
+	if err != nil {
+		blog.Error("get request to url(%s) failed! err(%s)", url, err.Error())
+		err = bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+err.Error())
+		return err.Error(), err
+	}
+
+	return string(reply), nil
+}
+
+func (s *Scheduler) rollbackDeployment(ns, name string, body []byte) (string, error) {
+	blog.Info("rollback deployment namespace %s name %s. param(%s)", ns, name, string(body))
+
+	var param types.DeploymentRollbackDef
+	if err := json.Unmarshal(body, &param); err != nil {
+		blog.Error("parse parameters failed. param(%s), err(%s)", string(body), err.Error())
+		err = bhttp.InternalError(common.BcsErrCommJsonDecode, common.BcsErrCommJsonDecodeStr)
+		return err.Error(), err
+	}
+
+	if s.GetHost() == "" {
+		blog.Error("no scheduler is connected by driver")
+		err := bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+"scheduler not exist")
+		return err.Error(), err
+	}
+
+	data, err := json.Marshal(param)
+	if err != nil {
+		blog.Error("marshal parameter DeploymentRollbackDef by json failed. err:%s", err.Error())
+		err = bhttp.InternalError(common.BcsErrCommJsonEncode, common.BcsErrCommJsonEncodeStr+"encode DeploymentRollbackDef by json")
+		return err.Error(), err
+	}
+
+	url := fmt.Sprintf("%s/v1/deployment/%s/%s/rollback", s.GetHost(), ns, name)
+	blog.Info("post a request to url(%s), request:%s", url, string(data))
+
+	reply, err := s.client.POST(url, nil, data)
+	if err != nil {
+		blog.Error("post request to url(%s) failed! err(%s)", url, err.Error())
+		err = bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+err.Error())
+		return err.Error(), err
+	}
+
+	return string(reply), nil
+}
+
 func (s *Scheduler) newDeploymentDefWithParam(param *bcstype.BcsDeployment) (*types.DeploymentDef, error) {
 
 	deploymentDef := &types.DeploymentDef{
@@ -289,6 +356,9 @@ func (s *Scheduler) newDeploymentDefWithParam(param *bcstype.BcsDeployment) (*ty
 	version.RunAs = param.NameSpace
 	version.Instances = int32(param.Spec.Instance)
 	version.Constraints = param.Constraints
+	version.Priority = param.Priority
+	version.ScorePolicy = param.ScorePolicy
+	version.ConfigReload = param.ConfigReload
 
 	for k, v := range param.Labels {
 		version.Labels[k] = v
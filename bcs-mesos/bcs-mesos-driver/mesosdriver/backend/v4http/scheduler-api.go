@@ -110,6 +110,7 @@ func (s *Scheduler) initActions() {
 		httpserver.NewAction("PUT", "/namespaces/{ns}/applications/{appid}/taskgroups/{taskgroupId}/rescheduler", nil, s.reschedulerTaskgroupHandler),
 		httpserver.NewAction("POST", "/namespaces/{ns}/applications/{appid}/taskgroups/{taskGroupID}/restart", nil, s.restartTaskGroupHandler),
 		httpserver.NewAction("POST", "/namespaces/{ns}/applications/{appid}/taskgroups/{taskGroupID}/reload", nil, s.reloadTaskGroupHandler),
+		httpserver.NewAction("GET", "/namespaces/{ns}/applications/{appid}/taskgroups/{taskGroupID}/logs", nil, s.fetchTaskGroupLogsHandler),
 		/*================= taskgroup ====================*/
 
 		/*================= version ====================*/
@@ -149,9 +150,23 @@ func (s *Scheduler) initActions() {
 		httpserver.NewAction("PUT", "/namespaces/{ns}/deployments/{name}/pauseupdate", nil, s.pauseupdateDeploymentHandler),
 		httpserver.NewAction("PUT", "/namespaces/{ns}/deployments/{name}/resumeupdate", nil, s.resumeupdateDeploymentHandler),
 		httpserver.NewAction("PUT", "/namespaces/{ns}/deployments/{name}/scale/{instances}", nil, s.scaleDeploymentHandler),
+		httpserver.NewAction("GET", "/namespaces/{ns}/deployments/{name}/revisions", nil, s.listDeploymentRevisionsHandler),
+		httpserver.NewAction("GET", "/namespaces/{ns}/deployments/{name}/revisions/{revision}", nil, s.getDeploymentRevisionHandler),
+		httpserver.NewAction("GET", "/namespaces/{ns}/deployments/{name}/revisiondiff/{from}/{to}", nil, s.diffDeploymentRevisionsHandler),
+		httpserver.NewAction("PUT", "/namespaces/{ns}/deployments/{name}/rollback", nil, s.rollbackDeploymentHandler),
 
 		/*================= deployment ====================*/
 
+		/*================= daemonset ====================*/
+		httpserver.NewAction("POST", "/namespaces/{ns}/daemonsets", nil, s.createDaemonsetHandler),
+		httpserver.NewAction("DELETE", "/namespaces/{ns}/daemonsets/{name}", nil, s.deleteDaemonsetHandler),
+		/*================= daemonset ====================*/
+
+		/*================= job ====================*/
+		httpserver.NewAction("POST", "/namespaces/{ns}/jobs", nil, s.createJobHandler),
+		httpserver.NewAction("DELETE", "/namespaces/{ns}/jobs/{name}", nil, s.deleteJobHandler),
+		/*================= job ====================*/
+
 		/*================= agentsetting ====================*/
 		//	httpserver.NewAction("POST","/agentsetting/{IP}/disable",nil,s.disableAgentHandler),
 		//	httpserver.NewAction("POST","/agentsetting/{IP}/enable",nil,s.enableAgentHandler),
@@ -587,7 +602,9 @@ func (s *Scheduler) GetClusterCurrentOffersHandler(req *restful.Request, resp *r
 		return
 	}
 
-	url := s.GetHost() + "/v1/cluster/current/offers"
+	//offers are scored for the application if namespace and name are set
+	url := s.GetHost() + "/v1/cluster/current/offers?namespace=" + req.QueryParameter("namespace") +
+		"&name=" + req.QueryParameter("name")
 	blog.V(3).Infof("post a request to url(%s)", url)
 
 	reply, err := s.client.GET(url, nil, nil)
@@ -1244,6 +1261,86 @@ func (s *Scheduler) deleteDeploymentHandler(req *restful.Request, resp *restful.
 	resp.Write([]byte(reply))
 }
 
+func (s *Scheduler) createDaemonsetHandler(req *restful.Request, resp *restful.Response) {
+	body, err := s.getRequestInfo(req)
+	if err != nil {
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	err = util.CheckKind(types.BcsDataType_DAEMONSET, body)
+	if err != nil {
+		blog.Error("fail to create daemonset(%s). err(%s)", string(body), err.Error())
+		err = bhttp.InternalError(common.BcsErrCommRequestDataErr, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	reply, err := s.CreateDaemonset(body)
+	if err != nil {
+		blog.Error("fail to create daemonset. reply(%s), err(%s)", reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) deleteDaemonsetHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+
+	enforce := req.QueryParameter("enforce")
+	reply, err := s.deleteDaemonset(ns, name, enforce)
+	if err != nil {
+		blog.Error("fail to delete daemonset namespace %s name %s. reply(%s), err(%s)", ns, name, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) createJobHandler(req *restful.Request, resp *restful.Response) {
+	body, err := s.getRequestInfo(req)
+	if err != nil {
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	err = util.CheckKind(types.BcsDataType_JOB, body)
+	if err != nil {
+		blog.Error("fail to create job(%s). err(%s)", string(body), err.Error())
+		err = bhttp.InternalError(common.BcsErrCommRequestDataErr, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	reply, err := s.CreateJob(body)
+	if err != nil {
+		blog.Error("fail to create job. reply(%s), err(%s)", reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) deleteJobHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+
+	enforce := req.QueryParameter("enforce")
+	reply, err := s.deleteJob(ns, name, enforce)
+	if err != nil {
+		blog.Error("fail to delete job namespace %s name %s. reply(%s), err(%s)", ns, name, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
 func (s *Scheduler) cancelupdateDeploymentHandler(req *restful.Request, resp *restful.Response) {
 	ns := req.PathParameter("ns")
 	name := req.PathParameter("name")
@@ -1286,6 +1383,71 @@ func (s *Scheduler) resumeupdateDeploymentHandler(req *restful.Request, resp *re
 	resp.Write([]byte(reply))
 }
 
+func (s *Scheduler) listDeploymentRevisionsHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+
+	reply, err := s.listDeploymentRevisions(ns, name)
+	if err != nil {
+		blog.Error("fail to list deployment namespace %s name %s revisions. reply(%s), err(%s)", ns, name, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) getDeploymentRevisionHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+	revision := req.PathParameter("revision")
+
+	reply, err := s.getDeploymentRevision(ns, name, revision)
+	if err != nil {
+		blog.Error("fail to get deployment namespace %s name %s revision %s. reply(%s), err(%s)", ns, name, revision, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) diffDeploymentRevisionsHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+	from := req.PathParameter("from")
+	to := req.PathParameter("to")
+
+	reply, err := s.diffDeploymentRevisions(ns, name, from, to)
+	if err != nil {
+		blog.Error("fail to diff deployment namespace %s name %s revisions %s %s. reply(%s), err(%s)", ns, name, from, to, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
+func (s *Scheduler) rollbackDeploymentHandler(req *restful.Request, resp *restful.Response) {
+	ns := req.PathParameter("ns")
+	name := req.PathParameter("name")
+
+	body, err := s.getRequestInfo(req)
+	if err != nil {
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	reply, err := s.rollbackDeployment(ns, name, body)
+	if err != nil {
+		blog.Error("fail to rollback deployment namespace %s name %s. reply(%s), err(%s)", ns, name, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
 func (s *Scheduler) scaleDeploymentHandler(req *restful.Request, resp *restful.Response) {
 	ns := req.PathParameter("ns")
 	name := req.PathParameter("name")
@@ -1348,6 +1510,19 @@ func (s *Scheduler) reloadTaskGroupHandler(req *restful.Request, resp *restful.R
 	resp.Write([]byte(reply))
 }
 
+func (s *Scheduler) fetchTaskGroupLogsHandler(req *restful.Request, resp *restful.Response) {
+	taskGroupID := req.PathParameter("taskGroupID")
+
+	reply, err := s.FetchTaskGroupLogs(taskGroupID, req.Request.URL.RawQuery)
+	if err != nil {
+		blog.Error("fail to fetch taskGroup (%s) logs reply(%s), err(%s)", taskGroupID, reply, err.Error())
+		resp.Write([]byte(err.Error()))
+		return
+	}
+
+	resp.Write([]byte(reply))
+}
+
 func (s *Scheduler) registerCustomResourceHander(req *restful.Request, resp *restful.Response) {
 	body, err := s.getRequestInfo(req)
 	if err != nil {
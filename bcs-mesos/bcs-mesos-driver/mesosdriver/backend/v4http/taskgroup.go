@@ -88,3 +88,29 @@ func (s *Scheduler) ReloadTaskGroup(taskGroupID string) (string, error) {
 
 	return string(reply), nil
 }
+
+func (s *Scheduler) FetchTaskGroupLogs(taskGroupID, query string) (string, error) {
+	blog.V(3).Infof("fetch taskGroup(%s) logs", taskGroupID)
+
+	if s.GetHost() == "" {
+		blog.Error("no scheduler is connected by driver")
+		err := bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+"scheduler not exist")
+		return err.Error(), err
+	}
+
+	url := fmt.Sprintf("%s/v1/taskgroup/%s/logs", s.GetHost(), taskGroupID)
+	if query != "" {
+		url = url + "?" + query
+	}
+
+	blog.V(3).Infof("get a request to url(%s)", url)
+
+	reply, err := s.client.GET(url, nil, nil)
+	if err != nil {
+		blog.Error("get request to url(%s) failed! err(%s)", url, err.Error())
+		err = bhttp.InternalError(common.BcsErrCommHttpDo, common.BcsErrCommHttpDoStr+err.Error())
+		return err.Error(), err
+	}
+
+	return string(reply), nil
+}
@@ -92,6 +92,13 @@ func Run(cfg *types.CmdConfig) error {
 	}
 	blog.Info("datawatcher run for cluster %s", cfg.ClusterID)
 
+	switch cfg.StoreDriver {
+	case "", types.StoreDriverZk, types.StoreDriverEtcd:
+	default:
+		blog.Error("datawatcher store driver %s is not supported", cfg.StoreDriver)
+		return fmt.Errorf("store driver %s is not supported", cfg.StoreDriver)
+	}
+
 	//create root context for exit
 	rootCxt, rootCancel := context.WithCancel(context.Background())
 	interupt := make(chan os.Signal, 10)
@@ -0,0 +1,300 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mesos
+
+import (
+	"crypto/tls"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/samuel/go-zookeeper/zk"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	// etcdRequestTimeout is the timeout for every etcd request
+	etcdRequestTimeout = 10 * time.Second
+	// etcdListPageSize is the max keys fetched by one request when listing children
+	etcdListPageSize = 500
+)
+
+//EtcdClient implements ZkClient by etcd v3, it is used when scheduler store driver is etcd.
+//Scheduler keeps the zookeeper path layout in etcd, every zk node is an etcd key,
+//so the children of a node are the keys with prefix "path/".
+type EtcdClient struct {
+	hosts []string
+	tls   *tls.Config
+	cli   *clientv3.Client
+}
+
+//NewEtcdClient create etcd client of scheduler store
+func NewEtcdClient(hosts []string, tlsConfig *tls.Config) *EtcdClient {
+	return &EtcdClient{
+		hosts: hosts,
+		tls:   tlsConfig,
+	}
+}
+
+//ConnectEx connect etcd cluster, sessionTimeOut is used as dial timeout
+func (e *EtcdClient) ConnectEx(sessionTimeOut time.Duration) error {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   e.hosts,
+		DialTimeout: sessionTimeOut,
+		TLS:         e.tls,
+	})
+	if err != nil {
+		return err
+	}
+	e.cli = cli
+	return nil
+}
+
+//etcdKey clean the zk style path, so "/a//b/" and "/a/b" are the same key
+func etcdKey(p string) string {
+	return path.Clean("/" + p)
+}
+
+//etcdChildrenPrefix return the prefix of all the children keys of path
+func etcdChildrenPrefix(p string) string {
+	key := etcdKey(p)
+	if key == "/" {
+		return key
+	}
+	return key + "/"
+}
+
+//etcdStat convert the etcd key to zk stat, revisions are used as zxids
+func etcdStat(kv *mvccpb.KeyValue) *zk.Stat {
+	return &zk.Stat{
+		Czxid:      kv.CreateRevision,
+		Mzxid:      kv.ModRevision,
+		Version:    int32(kv.Version),
+		DataLength: int32(len(kv.Value)),
+	}
+}
+
+//get return the key of path and the revision of etcd, zk.ErrNoNode if the key does not exist
+func (e *EtcdClient) get(p string) (*mvccpb.KeyValue, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	resp, err := e.cli.Get(ctx, etcdKey(p))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, resp.Header.Revision, zk.ErrNoNode
+	}
+	return resp.Kvs[0], resp.Header.Revision, nil
+}
+
+//children return the names of the direct children of path and the revision of etcd.
+//Keys are fetched page by page, the descendants of a child are skipped when the page
+//ends in them, so listing a node does not load its whole subtree.
+func (e *EtcdClient) children(p string) ([]string, int64, error) {
+	prefix := etcdChildrenPrefix(p)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+
+	var revision int64
+	var childs []string
+	exist := make(map[string]struct{})
+	for start := prefix; ; {
+		ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithLimit(etcdListPageSize)}
+		//pages are read in the revision of the first one
+		if revision != 0 {
+			opts = append(opts, clientv3.WithRev(revision))
+		}
+		resp, err := e.cli.Get(ctx, start, opts...)
+		cancel()
+		if err != nil {
+			return nil, 0, err
+		}
+		if revision == 0 {
+			revision = resp.Header.Revision
+		}
+
+		for _, kv := range resp.Kvs {
+			child := strings.SplitN(strings.TrimPrefix(string(kv.Key), prefix), "/", 2)[0]
+			if _, ok := exist[child]; ok || child == "" {
+				continue
+			}
+			exist[child] = struct{}{}
+			childs = append(childs, child)
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+
+		last := string(resp.Kvs[len(resp.Kvs)-1].Key)
+		child := strings.SplitN(strings.TrimPrefix(last, prefix), "/", 2)[0]
+		if strings.HasPrefix(last, prefix+child+"/") {
+			//'0' is next to '/', the rest keys of child subtree are skipped
+			start = prefix + child + "0"
+		} else {
+			start = last + "\x00"
+		}
+	}
+
+	sort.Strings(childs)
+	return childs, revision, nil
+}
+
+//GetEx get the data of path, zk.ErrNoNode is returned if it does not exist
+func (e *EtcdClient) GetEx(p string) ([]byte, *zk.Stat, error) {
+	kv, _, err := e.get(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kv.Value, etcdStat(kv), nil
+}
+
+//GetW get the data of path and watch its change, the channel receives one event
+//like zookeeper watch, EventNodeDataChanged for update and EventNodeDeleted for delete
+func (e *EtcdClient) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	kv, revision, err := e.get(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ch := make(chan zk.Event, 1)
+	go e.watchOnce(p, ch, revision+1, false, func(ev *clientv3.Event) zk.EventType {
+		if ev.Type == mvccpb.DELETE {
+			return zk.EventNodeDeleted
+		}
+		return zk.EventNodeDataChanged
+	})
+	return kv.Value, etcdStat(kv), ch, nil
+}
+
+//GetChildrenEx get the children of path, zk.ErrNoNode is returned if the path and
+//its children do not exist
+func (e *EtcdClient) GetChildrenEx(p string) ([]string, *zk.Stat, error) {
+	childs, revision, err := e.childrenOrNoNode(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return childs, &zk.Stat{Pzxid: revision, NumChildren: int32(len(childs))}, nil
+}
+
+//ChildrenW get the children of path and watch the children change, the channel
+//receives one EventNodeChildrenChanged event when any child is created or deleted
+func (e *EtcdClient) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	childs, revision, err := e.childrenOrNoNode(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	prefix := etcdChildrenPrefix(p)
+	ch := make(chan zk.Event, 1)
+	go e.watchOnce(p, ch, revision+1, true, func(ev *clientv3.Event) zk.EventType {
+		//data change of children and the change of their descendants are ignored
+		if strings.Contains(strings.TrimPrefix(string(ev.Kv.Key), prefix), "/") {
+			return zk.EventNotWatching
+		}
+		if ev.Type == mvccpb.DELETE || ev.IsCreate() {
+			return zk.EventNodeChildrenChanged
+		}
+		return zk.EventNotWatching
+	})
+	return childs, &zk.Stat{Pzxid: revision, NumChildren: int32(len(childs))}, ch, nil
+}
+
+//childrenOrNoNode return the children of path, parent nodes written by old scheduler may
+//only exist implicitly, so zk.ErrNoNode is returned only if the path has no children and no key
+func (e *EtcdClient) childrenOrNoNode(p string) ([]string, int64, error) {
+	childs, revision, err := e.children(p)
+	if err != nil || len(childs) > 0 {
+		return childs, revision, err
+	}
+	if _, _, err = e.get(p); err != nil {
+		return nil, 0, err
+	}
+	return childs, revision, nil
+}
+
+//watchOnce watch the key of path, or its children prefix when children is true, from revision,
+//and send the first event converted by convert to ch. Events converted to EventNotWatching are skipped.
+//If the watch fails, EventNotWatching with the error is sent, so watchers read the path again.
+func (e *EtcdClient) watchOnce(p string, ch chan<- zk.Event, revision int64, children bool,
+	convert func(ev *clientv3.Event) zk.EventType) {
+	ctx, cancel := context.WithCancel(e.cli.Ctx())
+	defer cancel()
+
+	key := etcdKey(p)
+	opts := []clientv3.OpOption{clientv3.WithRev(revision)}
+	if children {
+		key = etcdChildrenPrefix(p)
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	for resp := range e.cli.Watch(clientv3.WithRequireLeader(ctx), key, opts...) {
+		if err := resp.Err(); err != nil {
+			blog.Warnf("watch etcd key %s failed: %s", key, err.Error())
+			ch <- zk.Event{Type: zk.EventNotWatching, State: zk.StateDisconnected, Path: p, Err: err}
+			return
+		}
+		for _, ev := range resp.Events {
+			if tp := convert(ev); tp != zk.EventNotWatching {
+				ch <- zk.Event{Type: tp, State: zk.StateHasSession, Path: p}
+				return
+			}
+		}
+	}
+
+	//watch channel is closed when client is closed
+	ch <- zk.Event{Type: zk.EventNotWatching, State: zk.StateDisconnected, Path: p, Err: zk.ErrClosing}
+}
+
+//ExistEx check whether the key of path exists
+func (e *EtcdClient) ExistEx(p string) (bool, *zk.Stat, error) {
+	kv, _, err := e.get(p)
+	if err == zk.ErrNoNode {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, etcdStat(kv), nil
+}
+
+//State convert the grpc connection state to zk state, so the connection monitor works for etcd
+func (e *EtcdClient) State() zk.State {
+	if e.cli == nil {
+		return zk.StateDisconnected
+	}
+
+	switch e.cli.ActiveConnection().GetState() {
+	case connectivity.Ready, connectivity.Idle:
+		return zk.StateHasSession
+	case connectivity.Connecting:
+		return zk.StateConnecting
+	default:
+		return zk.StateDisconnected
+	}
+}
+
+//Close close the etcd client, all the watches are stopped
+func (e *EtcdClient) Close() {
+	if e.cli != nil {
+		e.cli.Close()
+	}
+}
@@ -314,6 +314,10 @@ func (watch *ExportServiceWatch) getTaskGroupServiceLabel(service *commtypes.Bcs
 	}
 
 	key := service.ObjectMeta.NameSpace + "." + service.ObjectMeta.Name
+	//selector pinned to an application by blue-green deployment
+	if appID, ok := service.Spec.Selector[schedtypes.DEPLOYMENT_BLUEGREEN_APP_LABEL]; ok && appID != tskgroup.AppID {
+		return ""
+	}
 	for ks, vs := range service.Spec.Selector {
 		//blog.V(3).Infof("check service %s selector label:%s -> %s", key, ks, vs)
 		task := tskgroup.Taskgroup[0]
@@ -339,6 +343,10 @@ func (watch *ExportServiceWatch) getApplicationServiceLabel(service *commtypes.B
 	}
 
 	key := service.ObjectMeta.NameSpace + "." + service.ObjectMeta.Name
+	//selector pinned to an application by blue-green deployment
+	if appID, ok := service.Spec.Selector[schedtypes.DEPLOYMENT_BLUEGREEN_APP_LABEL]; ok && appID != app.ID {
+		return ""
+	}
 	for ks, vs := range service.Spec.Selector {
 
 		for kt, vt := range app.ObjectMeta.Labels {
@@ -401,6 +409,10 @@ func (watch *ExportServiceWatch) addTaskGroup(tskgroup *schedtypes.TaskGroup) {
 		blog.V(3).Infof("ExportServiceWatch receive taskgroup add event, TaskGroup %s status %s, do nothing ", tskgroup.ID, tskgroup.Status)
 		return
 	}
+	if !tskgroup.IsReady() {
+		blog.V(3).Infof("ExportServiceWatch receive taskgroup add event, TaskGroup %s is not ready, do nothing ", tskgroup.ID)
+		return
+	}
 
 	keyList := watch.esInfoCache.ListKeys()
 	for _, key := range keyList {
@@ -568,7 +580,8 @@ func (watch *ExportServiceWatch) updateTaskGroup(tskgroup *schedtypes.TaskGroup)
 						}
 
 						var changed bool
-						if tskgroup.Status == schedtypes.TASKGROUP_STATUS_RUNNING || tskgroup.Status == schedtypes.TASKGROUP_STATUS_LOST {
+						if (tskgroup.Status == schedtypes.TASKGROUP_STATUS_RUNNING || tskgroup.Status == schedtypes.TASKGROUP_STATUS_LOST) &&
+							tskgroup.IsReady() {
 							changed = watch.addEpBackend(&oneEsPort, backend)
 						} else {
 							changed = watch.deleteEpBackend(&oneEsPort, backend)
@@ -846,6 +859,10 @@ func (watch *ExportServiceWatch) SyncEpTaskgroupBackend(esInfo *ExportServiceInf
 		blog.V(3).Infof("ExportServiceWatch receive taskgroup add event, TaskGroup %s status %s, do nothing ", taskgroup.ID, taskgroup.Status)
 		return nil
 	}
+	if !taskgroup.IsReady() {
+		blog.V(3).Infof("ExportServiceWatch receive taskgroup add event, TaskGroup %s is not ready, do nothing ", taskgroup.ID)
+		return nil
+	}
 
 	// check matching of selector and task label
 	label := watch.getTaskGroupServiceLabel(esInfo.bcsService, taskgroup)
@@ -15,12 +15,14 @@ package mesos
 
 import (
 	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-common/common/ssl"
 	commtypes "bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-common/common/zkclient"
 	"bk-bcs/bcs-mesos/bcs-mesos-watch/cluster"
 	"bk-bcs/bcs-mesos/bcs-mesos-watch/storage"
 	"bk-bcs/bcs-mesos/bcs-mesos-watch/types"
 	schedtypes "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"crypto/tls"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -80,6 +82,7 @@ func NewMesosCluster(cfg *types.CmdConfig, st storage.Storage) cluster.Cluster {
 		zkLinks:        linkItems[0],
 		watchPath:      "/" + strings.Join(linkItems[1:], "/"),
 		clusterID:      cfg.ClusterID,
+		cfg:            cfg,
 		storage:        st,
 		reportCallback: make(map[string]cluster.ReportFunc),
 		existCallback:  make(map[string]cluster.DataExister),
@@ -97,7 +100,8 @@ type MesosCluster struct {
 	zkLinks        string                         //zk connection link, like 127.0.0.1:2181,127.0.0.2:2181
 	watchPath      string                         //zk watching path, like /blueking
 	clusterID      string                         //watch cluster id
-	client         ZkClient                       //client for zookeeper
+	cfg            *types.CmdConfig               //command config, for store driver of scheduler
+	client         ZkClient                       //client for zookeeper, or etcd with zookeeper interface
 	retry          bool                           //flag for reconnect zookeeper
 	connCxt        context.Context                //context for client disconnected
 	cancel         context.CancelFunc             //cancel func when client disconnected
@@ -112,14 +116,20 @@ type MesosCluster struct {
 	secret         *SecretWatch
 	service        *ServiceWatch
 	deployment     *DeploymentWatch
+	job            *JobWatch
 	endpoint       *EndpointWatch
 }
 
-//createZkConn create zookeeper connection with cluster
+//createZkConn create zookeeper connection with cluster,
+//etcd client is created instead if scheduler stores data in etcd
 func (ms *MesosCluster) createZkConn() error {
 
-	blog.Info("mesos cluster create ZK connection ...")
 	servers := strings.Split(ms.zkLinks, ",")
+	if ms.cfg.StoreDriver == types.StoreDriverEtcd {
+		return ms.createEtcdConn(servers)
+	}
+
+	blog.Info("mesos cluster create ZK connection ...")
 	ms.client = zkclient.NewZkClient(servers)
 	conErr := ms.client.ConnectEx(time.Second * 5)
 	if conErr != nil {
@@ -130,6 +140,29 @@ func (ms *MesosCluster) createZkConn() error {
 	return nil
 }
 
+//createEtcdConn create etcd connection with cluster
+func (ms *MesosCluster) createEtcdConn(servers []string) error {
+
+	blog.Info("mesos cluster create etcd connection ...")
+	var tlsConfig *tls.Config
+	if ms.cfg.EtcdCAFile != "" && ms.cfg.EtcdCertFile != "" && ms.cfg.EtcdKeyFile != "" {
+		var err error
+		tlsConfig, err = ssl.ClientTslConfVerity(ms.cfg.EtcdCAFile, ms.cfg.EtcdCertFile, ms.cfg.EtcdKeyFile, "")
+		if err != nil {
+			blog.Error("cluster load etcd tls config failed: %s", err.Error())
+			return err
+		}
+	}
+
+	ms.client = NewEtcdClient(servers, tlsConfig)
+	if err := ms.client.ConnectEx(time.Second * 5); err != nil {
+		blog.Error("cluster connect etcd failed: %s", err.Error())
+		return err
+	}
+	blog.Info("mesos cluster link etcd %s success! base watch path: %s ", ms.zkLinks, ms.watchPath)
+	return nil
+}
+
 // GenerateRandnum just for test
 func GenerateRandnum() int {
 	rand.Seed(time.Now().Unix())
@@ -184,6 +217,7 @@ func (ms *MesosCluster) registerReportHandler() error {
 	ms.reportCallback["Secret"] = ms.reportSecret
 
 	ms.reportCallback["Deployment"] = ms.reportDeployment
+	ms.reportCallback["Job"] = ms.reportJob
 
 	ms.reportCallback["Endpoint"] = ms.reportEndpoint
 
@@ -228,6 +262,10 @@ func (ms *MesosCluster) createDatTypeWatch() error {
 	ms.deployment = NewDeploymentWatch(deploymentCxt, ms.client, ms, ms.watchPath)
 	go ms.deployment.Work()
 
+	jobCxt, _ := context.WithCancel(ms.connCxt)
+	ms.job = NewJobWatch(jobCxt, ms.client, ms, ms.watchPath)
+	go ms.job.Work()
+
 	endpointCxt, _ := context.WithCancel(ms.connCxt)
 	ms.endpoint = NewEndpointWatch(endpointCxt, ms.client, ms, ms.watchPath)
 	go ms.endpoint.Work()
@@ -340,6 +378,18 @@ func (ms *MesosCluster) reportDeployment(data *types.BcsSyncData) error {
 	return nil
 }
 
+func (ms *MesosCluster) reportJob(data *types.BcsSyncData) error {
+	dataType := data.Item.(*schedtypes.Job)
+	blog.V(3).Infof("mesos cluster report job(%s.%s) for action(%s)",
+		dataType.ObjectMeta.NameSpace, dataType.ObjectMeta.Name, data.Action)
+	if err := ms.storage.Sync(data); err != nil {
+		blog.Error("job(%s.%s) sync(%s) dispatch failed: %+v",
+			dataType.ObjectMeta.NameSpace, dataType.ObjectMeta.Name, data.Action, err)
+		return err
+	}
+	return nil
+}
+
 func (ms *MesosCluster) reportSecret(data *types.BcsSyncData) error {
 	dataType := data.Item.(*commtypes.BcsSecret)
 	blog.V(3).Infof("mesos cluster report secret(%s.%s) for action(%s)",
@@ -30,6 +30,7 @@ const (
 	dataTypeDeploy = "Deployment"
 	dataTypeSvr    = "Service"
 	dataTypeExpSVR = "ExportService"
+	dataTypeJob    = "Job"
 )
 
 var (
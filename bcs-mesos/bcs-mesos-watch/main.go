@@ -49,6 +49,11 @@ func setCfg(op *MesosWatchOptions) {
 
 	cfg.ClusterID = op.Cluster
 
+	cfg.StoreDriver = op.StoreDriver
+	cfg.EtcdCAFile = op.EtcdCAFile
+	cfg.EtcdCertFile = op.EtcdCertFile
+	cfg.EtcdKeyFile = op.EtcdKeyFile
+
 	if cfg.ServerCertFile != "" && cfg.ServerKeyFile != "" {
 		cfg.ServerSchem = "https"
 	} else {
@@ -97,4 +102,8 @@ type MesosWatchOptions struct {
 	ExportserviceThreadNum uint   `json:"exportservice_threads" value:"100" usage:"exportservice thread num"`
 	Cluster                string `json:"cluster" value:"" usage:"the cluster ID under bcs"`
 	IsExternal             bool   `json:"is_external" value:"false" usage:"the cluster whether external deployment"`
+	StoreDriver            string `json:"store_driver" value:"zookeeper" usage:"the db driver of scheduler store, zookeeper or etcd, clusterinfo is etcd address for etcd"`
+	EtcdCAFile             string `json:"etcd_ca_file" value:"" usage:"the ca file of etcd"`
+	EtcdCertFile           string `json:"etcd_cert_file" value:"" usage:"the client cert file of etcd"`
+	EtcdKeyFile            string `json:"etcd_key_file" value:"" usage:"the client key file of etcd"`
 }
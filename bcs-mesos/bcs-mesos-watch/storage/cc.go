@@ -196,6 +196,15 @@ func (cc *CCStorage) init() error {
 		},
 	}
 
+	cc.handlers["Job"] = &ChannelProxy{
+		dataQueue: make(chan *types.BcsSyncData, 1024),
+		actionHandler: &JobHandler{
+			oper:      cc,
+			dataType:  "job",
+			ClusterID: cc.ClusterID,
+		},
+	}
+
 	cc.handlers["Endpoint"] = &ChannelProxy{
 		dataQueue: make(chan *types.BcsSyncData, 1024),
 		actionHandler: &EndpointHandler{
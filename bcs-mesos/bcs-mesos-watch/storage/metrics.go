@@ -42,6 +42,7 @@ var (
 	dataTypeSvr       = "Service"
 	dataTypeExpSVR    = "ExportService"
 	dataTypeEp        = "Endpoint"
+	dataTypeJob       = "Job"
 
 	actionDelete = "DELETE"
 	actionPut    = "PUT"
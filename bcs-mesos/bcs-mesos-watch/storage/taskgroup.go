@@ -190,6 +190,8 @@ func (handler *TaskGroupHandler) FormatConv(taskgroup *schedtypes.TaskGroup) (*c
 				HostPath:  volume.HostPath,
 				MountPath: volume.ContainerPath,
 				ReadOnly:  true,
+				EmptyDir:  volume.EmptyDir,
+				Local:     volume.Local,
 			}
 
 			if volume.Mode == "RW" {
@@ -52,6 +52,12 @@ type CmdConfig struct {
 	ServerKeyFile  string
 	ServerPassWord string
 	ServerSchem    string
+
+	//the db driver of scheduler store, zookeeper or etcd
+	StoreDriver  string
+	EtcdCAFile   string
+	EtcdCertFile string
+	EtcdKeyFile  string
 }
 
 const (
@@ -61,4 +67,9 @@ const (
 	TaskgroupChannelPrefix = "TaskGroup_"
 	//ExportserviceChannelPrefix prefix for event post channel
 	ExportserviceChannelPrefix = "Exportservice_"
+
+	//StoreDriverZk scheduler stores data in zookeeper
+	StoreDriverZk = "zookeeper"
+	//StoreDriverEtcd scheduler stores data in etcd
+	StoreDriverEtcd = "etcd"
 )
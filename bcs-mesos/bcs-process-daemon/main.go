@@ -38,6 +38,7 @@ type Option struct {
 	DataDir      string `json:"data-dir" value:"" usage:"the process daemon data dir"`
 	UnixSocket   string `json:"unix-socket" value:"" usage:"the unix socket path"`
 	WorkspaceDir string `json:"workspace-dir" value:"" usage:"the process packages dir"`
+	CgroupRoot   string `json:"cgroup-root" value:"/sys/fs/cgroup" usage:"the cgroup mount point, process resource is not limited if empty"`
 }
 
 // Init process init
@@ -61,6 +62,7 @@ func Init() {
 	config := &config.Config{
 		DataDir:      op.DataDir,
 		WorkspaceDir: op.WorkspaceDir,
+		CgroupRoot:   op.CgroupRoot,
 	}
 	manager := manager.NewManager(config)
 	err = manager.Init()
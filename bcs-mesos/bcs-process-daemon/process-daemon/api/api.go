@@ -68,7 +68,7 @@ func createResponeData(err error, code int, msg string, data interface{}) []byte
 	if err != nil {
 		rpyErr = bhttp.InternalError(code, msg)
 	} else {
-		rpyErr = fmt.Errorf(bhttp.GetRespone(common.BcsSuccess, common.BcsSuccessStr, data))
+		rpyErr = fmt.Errorf("%s", bhttp.GetRespone(common.BcsSuccess, common.BcsSuccessStr, data))
 	}
 
 	blog.V(3).Infof("createRespone: %s", rpyErr.Error())
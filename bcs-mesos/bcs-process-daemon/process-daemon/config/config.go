@@ -16,4 +16,6 @@ package config
 type Config struct {
 	DataDir      string
 	WorkspaceDir string
+	//cgroup mount point, process resource is not limited if empty
+	CgroupRoot string
 }
@@ -0,0 +1,306 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package manager
+
+import (
+	"bk-bcs/bcs-mesos/bcs-process-executor/process-executor/types"
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//DefaultCgroupRoot mount point of cgroup filesystem
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+	//CgroupParent parent cgroup of all processes created by daemon
+	CgroupParent = "bcs-process"
+
+	cgroupCPUPeriod = 100000 //microseconds
+
+	//cgroupGateScript waits on fd 3 until daemon moves it into cgroup, then execs start command.
+	//read is shell builtin, so nothing is forked outside cgroup
+	cgroupGateScript = `read _ <&3 || exit 125; exec 3<&-; exec "$@"`
+)
+
+//cgroupV1Controllers controllers used for process in cgroup v1
+var cgroupV1Controllers = []string{"cpu", "cpuacct", "memory"}
+
+//processCgroup cgroup of one process, supports both cgroup v1 and v2,
+//the cgroup is bcs-process/$processId under every controller
+type processCgroup struct {
+	root string
+	name string
+	v2   bool
+}
+
+func newProcessCgroup(root, processID string) *processCgroup {
+	_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+	return &processCgroup{
+		root: root,
+		name: processID,
+		v2:   err == nil,
+	}
+}
+
+//dirs cgroup directories of process
+func (cg *processCgroup) dirs() []string {
+	if cg.v2 {
+		return []string{filepath.Join(cg.root, CgroupParent, cg.name)}
+	}
+	var dirs []string
+	for _, controller := range cgroupV1Controllers {
+		dirs = append(dirs, cg.dir(controller))
+	}
+	return dirs
+}
+
+//dir cgroup directory of controller, controller is ignored in cgroup v2
+func (cg *processCgroup) dir(controller string) string {
+	if cg.v2 {
+		return filepath.Join(cg.root, CgroupParent, cg.name)
+	}
+	return filepath.Join(cg.root, controller, CgroupParent, cg.name)
+}
+
+//Create create cgroup and set cpu quota & memory limit, zero resource means no limit
+func (cg *processCgroup) Create(cpus float64, memMB float64) error {
+	if cg.v2 {
+		//controllers must be enabled in all ancestors
+		for _, dir := range []string{cg.root, filepath.Join(cg.root, CgroupParent)} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err := writeCgroupFile(dir, "cgroup.subtree_control", "+cpu +memory"); err != nil {
+				return err
+			}
+		}
+	}
+	for _, dir := range cg.dirs() {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	quota := int64(cpus * cgroupCPUPeriod)
+	memLimit := int64(memMB * 1024 * 1024)
+	if cg.v2 {
+		cpuMax := "max " + strconv.Itoa(cgroupCPUPeriod)
+		if quota > 0 {
+			cpuMax = fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)
+		}
+		if err := writeCgroupFile(cg.dir(""), "cpu.max", cpuMax); err != nil {
+			return err
+		}
+		memMax := "max"
+		if memLimit > 0 {
+			memMax = strconv.FormatInt(memLimit, 10)
+		}
+		return writeCgroupFile(cg.dir(""), "memory.max", memMax)
+	}
+
+	if quota <= 0 {
+		quota = -1
+	}
+	if err := writeCgroupFile(cg.dir("cpu"), "cpu.cfs_period_us", strconv.Itoa(cgroupCPUPeriod)); err != nil {
+		return err
+	}
+	if err := writeCgroupFile(cg.dir("cpu"), "cpu.cfs_quota_us", strconv.FormatInt(quota, 10)); err != nil {
+		return err
+	}
+	if memLimit <= 0 {
+		memLimit = -1
+	}
+	return writeCgroupFile(cg.dir("memory"), "memory.limit_in_bytes", strconv.FormatInt(memLimit, 10))
+}
+
+//AddProcess move process pid into cgroup, the children forked after that are in cgroup too
+func (cg *processCgroup) AddProcess(pid int) error {
+	for _, dir := range cg.dirs() {
+		if err := writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Start start cmd in cgroup. cmd runs in a shell gate at first, the gate is opened
+//after its pid is moved into cgroup, so start command never runs outside cgroup.
+//process user in cmd is kept, pid is moved by daemon who has the permission
+func (cg *processCgroup) Start(cmd *exec.Cmd) error {
+	gateR, gateW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer gateW.Close()
+	var args []string
+	if len(cmd.Args) > 1 {
+		args = cmd.Args[1:]
+	}
+	cmd.Args = append([]string{"/bin/sh", "-c", cgroupGateScript, "bcs-cgroup-gate", cmd.Path}, args...)
+	cmd.Path = "/bin/sh"
+	cmd.ExtraFiles = []*os.File{gateR}
+	err = cmd.Start()
+	gateR.Close()
+	if err != nil {
+		return err
+	}
+	if err = cg.AddProcess(cmd.Process.Pid); err != nil {
+		//gate closed without opening, shell exits without exec start command
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("add start command to cgroup error %s", err.Error())
+	}
+	if _, err = gateW.Write([]byte("\n")); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("open cgroup gate error %s", err.Error())
+	}
+	return nil
+}
+
+//HasProcess check whether process pid is in cgroup
+func (cg *processCgroup) HasProcess(pid int) bool {
+	lines, err := readCgroupLines(filepath.Join(cg.dirs()[0], "cgroup.procs"))
+	if err != nil {
+		return false
+	}
+	for _, line := range lines {
+		if line == strconv.Itoa(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+//Usage read resource usage of cgroup, CPUPercent is calculated with last usage
+func (cg *processCgroup) Usage(last *types.ProcessResourceUsage) (*types.ProcessResourceUsage, error) {
+	usage := &types.ProcessResourceUsage{Timestamp: time.Now().UnixNano()}
+	var err error
+	if cg.v2 {
+		dir := cg.dir("")
+		cpuStat, err := readCgroupKeyValues(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			return nil, err
+		}
+		usage.CPUUsage = cpuStat["usage_usec"] * 1000
+		if usage.MemUsage, err = readCgroupUint(filepath.Join(dir, "memory.current")); err != nil {
+			return nil, err
+		}
+		//memory.max is "max" when there is no limit
+		usage.MemLimit, _ = readCgroupUint(filepath.Join(dir, "memory.max"))
+		events, err := readCgroupKeyValues(filepath.Join(dir, "memory.events"))
+		if err != nil {
+			return nil, err
+		}
+		usage.OOMKills = events["oom_kill"]
+	} else {
+		if usage.CPUUsage, err = readCgroupUint(filepath.Join(cg.dir("cpuacct"), "cpuacct.usage")); err != nil {
+			return nil, err
+		}
+		dir := cg.dir("memory")
+		if usage.MemUsage, err = readCgroupUint(filepath.Join(dir, "memory.usage_in_bytes")); err != nil {
+			return nil, err
+		}
+		if usage.MemLimit, err = readCgroupUint(filepath.Join(dir, "memory.limit_in_bytes")); err != nil {
+			return nil, err
+		}
+		//no limit in cgroup v1 is a huge number of page align
+		if usage.MemLimit >= 1<<62 {
+			usage.MemLimit = 0
+		}
+		//oom_kill is in memory.oom_control since linux 4.13
+		oomControl, err := readCgroupKeyValues(filepath.Join(dir, "memory.oom_control"))
+		if err != nil {
+			return nil, err
+		}
+		usage.OOMKills = oomControl["oom_kill"]
+	}
+
+	if last != nil && usage.Timestamp > last.Timestamp && usage.CPUUsage >= last.CPUUsage {
+		usage.CPUPercent = float64(usage.CPUUsage-last.CPUUsage) / float64(usage.Timestamp-last.Timestamp) * 100
+	}
+	return usage, nil
+}
+
+//Destroy remove cgroup, the processes still in cgroup are moved to root cgroup before removing,
+//parent cgroup can not hold processes in cgroup v2 because controllers are enabled in it
+func (cg *processCgroup) Destroy() error {
+	for _, dir := range cg.dirs() {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		rootDir := filepath.Dir(filepath.Dir(dir))
+		pids, _ := readCgroupLines(filepath.Join(dir, "cgroup.procs"))
+		for _, pid := range pids {
+			if err := writeCgroupFile(rootDir, "cgroup.procs", pid); err != nil {
+				return fmt.Errorf("move pid %s out of cgroup %s error %s", pid, dir, err.Error())
+			}
+		}
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCgroupFile(dir, file, value string) error {
+	return ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+}
+
+func readCgroupUint(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func readCgroupLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+//readCgroupKeyValues parse file with lines like "key value"
+func readCgroupKeyValues(path string) (map[string]uint64, error) {
+	lines, err := readCgroupLines(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		values[fields[0]], _ = strconv.ParseUint(fields[1], 10, 64)
+	}
+	return values, nil
+}
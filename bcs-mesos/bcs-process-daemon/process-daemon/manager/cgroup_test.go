@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"bk-bcs/bcs-mesos/bcs-process-executor/process-executor/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestProcessCgroupV1(t *testing.T) {
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+
+	cg := newProcessCgroup(root, "proc-1")
+	assert.False(t, cg.v2)
+	assert.Nil(t, cg.Create(1.5, 512))
+	assert.Equal(t, "150000", readTestFile(t, filepath.Join(root, "cpu", CgroupParent, "proc-1", "cpu.cfs_quota_us")))
+	assert.Equal(t, "536870912", readTestFile(t, filepath.Join(root, "memory", CgroupParent, "proc-1", "memory.limit_in_bytes")))
+
+	assert.Nil(t, cg.AddProcess(100))
+	assert.True(t, cg.HasProcess(100))
+	assert.False(t, cg.HasProcess(101))
+
+	ioutil.WriteFile(filepath.Join(cg.dir("cpuacct"), "cpuacct.usage"), []byte("1000000000\n"), 0644)
+	ioutil.WriteFile(filepath.Join(cg.dir("memory"), "memory.usage_in_bytes"), []byte("1048576\n"), 0644)
+	ioutil.WriteFile(filepath.Join(cg.dir("memory"), "memory.oom_control"),
+		[]byte("oom_kill_disable 0\nunder_oom 0\noom_kill 2\n"), 0644)
+	last := &types.ProcessResourceUsage{}
+	usage, err := cg.Usage(last)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000000000), usage.CPUUsage)
+	assert.Equal(t, uint64(1048576), usage.MemUsage)
+	assert.Equal(t, uint64(536870912), usage.MemLimit)
+	assert.Equal(t, uint64(2), usage.OOMKills)
+
+	//cgroup.procs & other files in tmpfs can not be removed like cgroupfs, clean them first
+	for _, dir := range cg.dirs() {
+		files, _ := ioutil.ReadDir(dir)
+		for _, file := range files {
+			os.Remove(filepath.Join(dir, file.Name()))
+		}
+	}
+	assert.Nil(t, cg.Destroy())
+	_, err = os.Stat(cg.dir("memory"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessCgroupV2(t *testing.T) {
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+	ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0644)
+
+	cg := newProcessCgroup(root, "proc-2")
+	assert.True(t, cg.v2)
+	assert.Nil(t, cg.Create(0.5, 0))
+	assert.Equal(t, "+cpu +memory", readTestFile(t, filepath.Join(root, CgroupParent, "cgroup.subtree_control")))
+	assert.Equal(t, "50000 100000", readTestFile(t, filepath.Join(cg.dir(""), "cpu.max")))
+	assert.Equal(t, "max", readTestFile(t, filepath.Join(cg.dir(""), "memory.max")))
+
+	dir := cg.dir("")
+	ioutil.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 2000000\nuser_usec 1000000\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "memory.current"), []byte("4096\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644)
+	usage, err := cg.Usage(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2000000000), usage.CPUUsage)
+	assert.Equal(t, uint64(4096), usage.MemUsage)
+	assert.Equal(t, uint64(0), usage.MemLimit)
+	assert.Equal(t, uint64(1), usage.OOMKills)
+	assert.Equal(t, float64(0), usage.CPUPercent)
+
+	//one core used in last second
+	last := *usage
+	last.CPUUsage -= 1000000000
+	last.Timestamp -= 1000000000
+	usage, err = cg.Usage(&last)
+	assert.Nil(t, err)
+	assert.True(t, usage.CPUPercent >= 99 && usage.CPUPercent <= 100, "cpu percent %f", usage.CPUPercent)
+}
+
+func TestProcessCgroupStart(t *testing.T) {
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+	cg := newProcessCgroup(root, "proc-3")
+	assert.Nil(t, cg.Create(1, 128))
+
+	//start command runs after its pid is in cgroup, and gate fd is closed
+	output := filepath.Join(root, "output")
+	script := `[ -e /proc/$$/fd/3 ] && exit 3; cat ` + filepath.Join(cg.dir("cpu"), "cgroup.procs") + ` > $1`
+	cmd := exec.Cmd{Path: "/bin/sh", Args: []string{"sh", "-c", script, "sh", output}}
+	assert.Nil(t, cg.Start(&cmd))
+	assert.Nil(t, cmd.Wait())
+	pid := strconv.Itoa(cmd.Process.Pid)
+	assert.Equal(t, pid, readTestFile(t, output))
+	for _, dir := range cg.dirs() {
+		assert.Equal(t, pid, readTestFile(t, filepath.Join(dir, "cgroup.procs")))
+	}
+
+	//start command is never executed when pid can not be moved into cgroup
+	os.RemoveAll(cg.dir("memory"))
+	os.Remove(output)
+	cmd = exec.Cmd{Path: "/bin/sh", Args: []string{"sh", "-c", script, "sh", output}}
+	assert.NotNil(t, cg.Start(&cmd))
+	_, err := os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessCgroupDestroyFailed(t *testing.T) {
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+	ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0644)
+	cg := newProcessCgroup(root, "proc-4")
+	assert.Nil(t, cg.Create(1, 128))
+	assert.Nil(t, cg.AddProcess(100))
+
+	//pid can not be moved to root cgroup, cgroup is kept
+	os.Mkdir(filepath.Join(root, "cgroup.procs"), 0755)
+	assert.NotNil(t, cg.Destroy())
+	_, err := os.Stat(cg.dir(""))
+	assert.Nil(t, err)
+
+	os.Remove(filepath.Join(root, "cgroup.procs"))
+	files, _ := ioutil.ReadDir(cg.dir(""))
+	for _, file := range files {
+		os.Remove(filepath.Join(cg.dir(""), file.Name()))
+	}
+	assert.Nil(t, cg.Destroy())
+	_, err = os.Stat(cg.dir(""))
+	assert.True(t, os.IsNotExist(err))
+}
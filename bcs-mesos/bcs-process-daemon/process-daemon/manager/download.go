@@ -55,7 +55,7 @@ func (m *manager) requestManager(method, uri string, data []byte, header map[str
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(resp.Reply))
+		return nil, fmt.Errorf("%s", string(resp.Reply))
 	}
 
 	return resp.Reply, err
@@ -344,6 +344,14 @@ func (m *manager) DeleteProcess(processId string) error {
 		}
 	}
 
+	//process is kept when cgroup is not destroyed, so deleting can be retried
+	if cg := m.processCgroup(processInfo); cg != nil {
+		if err := cg.Destroy(); err != nil {
+			blog.Errorf("process %s destroy cgroup error %s", processInfo.Id, err.Error())
+			return fmt.Errorf("process %s destroy cgroup error %s", processInfo.Id, err.Error())
+		}
+	}
+
 	delete(m.processInfos, processInfo.Id)
 	blog.Infof("delete process %s success", processInfo.Id)
 
@@ -435,9 +443,33 @@ func (m *manager) startProcess(processInfo *types.ProcessInfo) {
 		}
 	}
 
+	//create cgroup before start, the start command is started in it,
+	//so the process forked by start command is limited by cgroup
+	cg := m.processCgroup(processInfo)
+	if cg != nil {
+		err = cg.Create(processInfo.Resource.Cpus, processInfo.Resource.Mem)
+		if err != nil {
+			blog.Errorf("process %s create cgroup error %s", processInfo.Id, err.Error())
+			processInfo.StatusInfo.Status = types.ProcessStatusStopped
+			processInfo.StatusInfo.Message = fmt.Sprintf("create cgroup error %s", err.Error())
+			err = m.store.StoreProcessInfo(processInfo)
+			if err != nil {
+				blog.Errorf("store processInfo %s error %s", processInfo.Id, err.Error())
+			}
+			return
+		}
+	}
+
 	buf := bytes.NewBuffer(make([]byte, 1024))
 	cmd.Stderr = buf
-	err = cmd.Run()
+	if cg != nil {
+		err = cg.Start(&cmd)
+	} else {
+		err = cmd.Start()
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
 	//_,err := os.StartProcess(processInfo.StartCmd,processInfo.Argv,attr)
 	if err != nil {
 		blog.Errorf("start process %s startcmd %s stderr %s error %s", processInfo.Id, processInfo.StartCmd, buf.String(), err.Error())
@@ -523,6 +555,7 @@ func (m *manager) checkProcess(processInfo *types.ProcessInfo) {
 		processInfo.StatusInfo.Message = fmt.Sprintf("process %s is running", processInfo.Id)
 		processInfo.StatusInfo.Pid = pid
 	}
+	m.checkProcessCgroup(processInfo)
 
 	if oldStatus != processInfo.StatusInfo.Status {
 		blog.Infof("process %s status from %s change to %s message %s", processInfo.Id, oldStatus,
@@ -535,6 +568,61 @@ func (m *manager) checkProcess(processInfo *types.ProcessInfo) {
 	}
 }
 
+//processCgroup cgroup of process, nil if process resource is not limited
+func (m *manager) processCgroup(processInfo *types.ProcessInfo) *processCgroup {
+	if m.conf.CgroupRoot == "" || processInfo.Resource == nil {
+		return nil
+	}
+	if processInfo.Resource.Cpus <= 0 && processInfo.Resource.Mem <= 0 {
+		return nil
+	}
+	return newProcessCgroup(m.conf.CgroupRoot, processInfo.Id)
+}
+
+//checkProcessCgroup make sure the running process in cgroup, update resource usage,
+//and report oom kills in status message
+func (m *manager) checkProcessCgroup(processInfo *types.ProcessInfo) {
+	cg := m.processCgroup(processInfo)
+	if cg == nil {
+		return
+	}
+
+	status := processInfo.StatusInfo
+	//process started before cgroup enabled is moved into cgroup
+	if status.Status == types.ProcessStatusRunning && !cg.HasProcess(status.Pid) {
+		blog.Warnf("process %s pid %d is not in cgroup, add it", processInfo.Id, status.Pid)
+		if err := cg.AddProcess(status.Pid); err != nil {
+			blog.Errorf("process %s add pid %d to cgroup error %s", processInfo.Id, status.Pid, err.Error())
+		}
+	}
+
+	usage, err := cg.Usage(status.Usage)
+	if err != nil {
+		blog.Errorf("process %s read cgroup usage error %s", processInfo.Id, err.Error())
+		return
+	}
+	var lastOOMKills uint64
+	if status.Usage != nil {
+		lastOOMKills = status.Usage.OOMKills
+	}
+	if usage.OOMKills > lastOOMKills {
+		blog.Warnf("process %s oom kills in cgroup from %d to %d", processInfo.Id, lastOOMKills, usage.OOMKills)
+	}
+	status.Usage = usage
+
+	if status.Status == types.ProcessStatusStopped {
+		//process stopped just now, it is killed by oom if oom kills increased
+		if usage.OOMKills > lastOOMKills {
+			status.Message = fmt.Sprintf("process %s is OOM killed, memory limit %dMB", processInfo.Id, usage.MemLimit/1024/1024)
+		}
+		return
+	}
+	if usage.OOMKills > 0 {
+		status.Message = fmt.Sprintf("process %s is running, %d processes OOM killed in cgroup, memory limit %dMB",
+			processInfo.Id, usage.OOMKills, usage.MemLimit/1024/1024)
+	}
+}
+
 func (m *manager) processIsOk(processInfo *types.ProcessInfo) (*os.Process, int, error) {
 	by, err := ioutil.ReadFile(processInfo.PidFile)
 	if err != nil {
@@ -101,6 +101,19 @@ type ProcessStatusInfo struct {
 	Pid           int
 	RegisterTime  int64
 	LastStartTime int64
+
+	//resource usage of the process cgroup, nil if process has no cgroup
+	Usage *ProcessResourceUsage `json:",omitempty"`
+}
+
+//ProcessResourceUsage resource usage of process cgroup, sampled when process checked
+type ProcessResourceUsage struct {
+	CPUUsage   uint64  //cumulative cpu time, nanoseconds
+	CPUPercent float64 //cpu usage since last sample, 100 means one core
+	MemUsage   uint64  //bytes
+	MemLimit   uint64  //bytes, 0 means no limit
+	OOMKills   uint64  //count of processes killed by oom in cgroup
+	Timestamp  int64   //unix nano of sample
 }
 
 type ProcessStatusType string
@@ -29,6 +29,7 @@ import (
 
 	"bk-bcs/bcs-common/common/blog"
 	"bk-bcs/bcs-common/common/http/httpserver"
+	"bk-bcs/bcs-common/common/secretbackend"
 )
 
 type Manager struct {
@@ -43,14 +44,26 @@ func New(config util.SchedConfig) (*Manager, error) {
 		config: config,
 	}
 
-	dbzk := store.NewDbZk(strings.Split(config.ZkHost, ","))
-	dbzk.Connect()
+	db, err := store.NewDbDriver(&store.DbDriverConfig{
+		Driver:       config.StoreDriver,
+		ZkHost:       config.ZkHost,
+		EtcdHost:     config.EtcdHost,
+		EtcdCAFile:   config.EtcdCAFile,
+		EtcdCertFile: config.EtcdCertFile,
+		EtcdKeyFile:  config.EtcdKeyFile,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	zkStore := store.NewManagerStore(dbzk)
+	if err := secretbackend.Init(&config.SecretBackend); err != nil {
+		blog.Error("init secret backends error: %s", err.Error())
+		return nil, err
+	}
 
 	manager.schedContext = &schedcontext.SchedContext{
 		Config: config,
-		Store:  zkStore,
+		Store:  store.NewManagerStore(db),
 	}
 
 	listener := &manager.config.HttpListener
@@ -22,6 +22,7 @@ import (
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/emicklei/go-restful"
 	"github.com/samuel/go-zookeeper/zk"
 	"strconv"
@@ -508,7 +509,13 @@ func (r *Router) getCurrentOffers(req *restful.Request, resp *restful.Response)
 
 	blog.V(3).Info("request get current offers request")
 
-	res := r.backend.GetCurrentOffers()
+	res, err := r.backend.GetCurrentOffers(req.QueryParameter("namespace"), req.QueryParameter("name"))
+	if err != nil {
+		blog.Error("fail to get current offers, err:%s", err.Error())
+		data := createResponeData(err, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
 	data := createResponeData(nil, "", res)
 	resp.Write([]byte(data))
 	blog.Info("request get current offers request finish")
@@ -601,6 +608,7 @@ func (r *Router) updateConfigMap(req *restful.Request, resp *restful.Response) {
 		resp.Write([]byte(data))
 		return
 	}
+	r.backend.ReloadConfigMap(currData, &configmap)
 
 	data := createResponeData(nil, "success", nil)
 	resp.Write([]byte(data))
@@ -705,6 +713,7 @@ func (r *Router) updateSecret(req *restful.Request, resp *restful.Response) {
 		resp.Write([]byte(data))
 		return
 	}
+	r.backend.ReloadSecret(currData, &secret)
 
 	data := createResponeData(nil, "success", nil)
 	resp.Write([]byte(data))
@@ -920,6 +929,13 @@ func (r *Router) buildApplication(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if err := r.backend.CheckResourceQuota(&version, uint64(version.Instances)); err != nil {
+		blog.Warn("request build fail: app(%s.%s) %s", version.RunAs, version.ID, err.Error())
+		data := createResponeDataV2(comm.BcsErrMesosSchedQuotaExceeded, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
 	application := types.Application{
 		Kind:             version.Kind,
 		ID:               version.ID,
@@ -1368,6 +1384,13 @@ func (r *Router) updateApplication(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if err := r.backend.CheckResourceQuota(&version, uint64(version.Instances)); err != nil {
+		blog.Warn("request update application(%s.%s) fail: %s", runAs, appId, err.Error())
+		data := createResponeDataV2(comm.BcsErrMesosSchedQuotaExceeded, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
 	if err := r.backend.SaveVersion(runAs, appId, &version); err != nil {
 		blog.Error("request update application(%s.%s) fail to save version. err:%s", runAs, appId, err.Error())
 		data := createResponeData(err, err.Error(), nil)
@@ -2124,6 +2147,61 @@ func (r *Router) reloadTaskGroup(req *restful.Request, resp *restful.Response) {
 	return
 }
 
+func (r *Router) fetchTaskGroupLogs(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	blog.V(3).Infof("receive fetch taskGroup logs request")
+
+	taskGroupID := req.PathParameter("taskGroupID")
+	containerName := req.QueryParameter("container")
+
+	request := &types.RequestLogsTask{
+		Limit: types.LogsDefaultLimit,
+	}
+	var err error
+	if offset := req.QueryParameter("offset"); offset != "" {
+		if request.Offset, err = strconv.ParseInt(offset, 10, 64); err != nil || request.Offset < 0 {
+			err = fmt.Errorf("invalid offset %s", offset)
+		}
+	}
+	if tail := req.QueryParameter("tail"); err == nil && tail != "" {
+		if request.Tail, err = strconv.Atoi(tail); err != nil || request.Tail < 0 {
+			err = fmt.Errorf("invalid tail %s", tail)
+		}
+	}
+	if limit := req.QueryParameter("limit"); err == nil && limit != "" {
+		if request.Limit, err = strconv.Atoi(limit); err != nil || request.Limit <= 0 {
+			err = fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+	if err != nil {
+		blog.Error("request fetch taskGroup(%s) logs err(%s)", taskGroupID, err.Error())
+		data := createResponeData(err, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+	if request.Limit > types.LogsMaxLimit {
+		request.Limit = types.LogsMaxLimit
+	}
+
+	blog.V(3).Infof("request fetch taskGroup(%s) container(%s) logs, offset(%d) tail(%d) limit(%d)",
+		taskGroupID, containerName, request.Offset, request.Tail, request.Limit)
+
+	logs, err := r.backend.FetchTaskGroupLogs(taskGroupID, containerName, request)
+	if err != nil {
+		blog.Error("request fetch taskGroup(%s) logs err(%s)", taskGroupID, err.Error())
+		data := createResponeData(err, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", logs)
+	resp.Write([]byte(data))
+	return
+}
+
 func (r *Router) createAdmissionwebhook(req *restful.Request, resp *restful.Response) {
 	if r.backend.GetRole() != "master" {
 		blog.Warn("scheduler is not master, can not process cmd")
@@ -2279,3 +2357,560 @@ func (r *Router) fetchAdmissionwebhook(req *restful.Request, resp *restful.Respo
 	blog.Info("request list all admissions end")
 	return
 }
+
+func (r *Router) createResourceQuota(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	var quota commtypes.BcsResourceQuota
+	decoder := json.NewDecoder(req.Request.Body)
+	if err := decoder.Decode(&quota); err != nil {
+		blog.Error("fail to decode resourcequota json, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrCommJsonDecode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	blog.Info("request create resourcequota(%s.%s):%+v", quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name, quota)
+
+	if err := checkResourceQuota(&quota); err != nil {
+		data := createResponeDataV2(comm.BcsErrCommRequestDataErr, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	currData, _ := r.backend.FetchResourceQuota(quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name)
+	if currData != nil {
+		err := errors.New("resourcequota already exist")
+		data := createResponeDataV2(comm.BcsErrMesosSchedResourceExist, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	if err := r.backend.SaveResourceQuota(&quota); err != nil {
+		blog.Error("fail to save resourcequota, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request create resourcequota(%s.%s) end", quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name)
+	return
+}
+
+func (r *Router) updateResourceQuota(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	var quota commtypes.BcsResourceQuota
+	decoder := json.NewDecoder(req.Request.Body)
+	if err := decoder.Decode(&quota); err != nil {
+		blog.Error("fail to decode resourcequota json, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrCommJsonDecode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	blog.Info("request update resourcequota(%s.%s): %+v", quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name, quota)
+
+	if err := checkResourceQuota(&quota); err != nil {
+		data := createResponeDataV2(comm.BcsErrCommRequestDataErr, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	currData, _ := r.backend.FetchResourceQuota(quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name)
+	if currData == nil {
+		err := errors.New("resourcequota not exist")
+		data := createResponeDataV2(comm.BcsErrMesosSchedNotFound, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	//only update resourcequota spec
+	quota.ObjectMeta = currData.ObjectMeta
+	quota.TypeMeta = currData.TypeMeta
+
+	if err := r.backend.SaveResourceQuota(&quota); err != nil {
+		blog.Error("fail to save resourcequota, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+	blog.Info("request update resourcequota(%s.%s) end", quota.ObjectMeta.NameSpace, quota.ObjectMeta.Name)
+	return
+}
+
+func (r *Router) deleteResourceQuota(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request delete resourcequota(%s.%s)", ns, name)
+
+	var data string
+	if err := r.backend.DeleteResourceQuota(ns, name); err != nil {
+		blog.Error("fail to delete resourcequota, err:%s", err.Error())
+		if strings.Contains(err.Error(), "node does not exist") {
+			data = createResponeDataV2(common.BcsErrMesosSchedNotFound, err.Error(), nil)
+		} else {
+			data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		}
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request delete resourcequota(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) fetchResourceQuota(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request fetch resourcequota(%s.%s)", ns, name)
+
+	var data string
+	quota, err := r.backend.FetchResourceQuota(ns, name)
+	if err != nil {
+		blog.Error("request fetch resourcequota(%s.%s) err(%s)", ns, name, err.Error())
+		if err == zk.ErrNoNode {
+			data = createResponeDataV2(comm.BcsErrMesosSchedNotFound, err.Error(), nil)
+		} else {
+			data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		}
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", quota)
+	resp.Write([]byte(data))
+
+	blog.Info("request fetch resourcequota(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) listResourceQuotas(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	blog.V(3).Infof("request list resourcequotas(%s)", ns)
+
+	var data string
+	quotas, err := r.backend.ListResourceQuotas(ns)
+	if err != nil {
+		blog.Error("request list resourcequotas(%s) err(%s)", ns, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", quotas)
+	resp.Write([]byte(data))
+
+	blog.Info("request list resourcequotas(%s) end", ns)
+	return
+}
+
+//checkResourceQuota check the required fields and the hard limits of resourcequota
+func checkResourceQuota(quota *commtypes.BcsResourceQuota) error {
+	if quota.ObjectMeta.NameSpace == "" || quota.ObjectMeta.Name == "" {
+		return errors.New("resourcequota namespace and name can not be empty")
+	}
+
+	hard := quota.Spec.Hard
+	if hard.Cpu < 0 || hard.Mem < 0 || hard.Disk < 0 || hard.Instances < 0 {
+		return errors.New("resourcequota hard limits can not be negative")
+	}
+
+	return nil
+}
+
+func (r *Router) createDaemonset(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	blog.V(3).Infof("recv create daemonset request")
+
+	var daemonsetDef types.DaemonsetDef
+	decoder := json.NewDecoder(req.Request.Body)
+	if err := decoder.Decode(&daemonsetDef); err != nil {
+		blog.Error("fail to Decode json to create daemonset, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrCommJsonDecode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	blog.Info("request create daemonset(%s.%s)",
+		daemonsetDef.ObjectMeta.NameSpace, daemonsetDef.ObjectMeta.Name)
+
+	if errcode, err := r.backend.CreateDaemonset(&daemonsetDef); err != nil {
+		blog.Error("fail to create daemonset(%s.%s), err:%s",
+			daemonsetDef.ObjectMeta.NameSpace, daemonsetDef.ObjectMeta.Name, err.Error())
+		data := createResponeDataV2(errcode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request create daemonset(%s.%s) end",
+		daemonsetDef.ObjectMeta.NameSpace, daemonsetDef.ObjectMeta.Name)
+	return
+}
+
+func (r *Router) fetchDaemonset(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request fetch daemonset(%s.%s)", ns, name)
+
+	var data string
+	daemonset, err := r.backend.GetDaemonset(ns, name)
+	if err != nil {
+		blog.Error("request fetch daemonset(%s.%s) err(%s)", ns, name, err.Error())
+		if err == zk.ErrNoNode {
+			data = createResponeDataV2(comm.BcsErrMesosSchedNotFound, err.Error(), nil)
+		} else {
+			data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		}
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", daemonset)
+	resp.Write([]byte(data))
+
+	blog.Info("request fetch daemonset(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) deleteDaemonset(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+
+	enforce := false
+	enforcePara := req.QueryParameter("enforce")
+	if enforcePara == "1" {
+		enforce = true
+	}
+
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.Infof("request delete daemonset(%s.%s)", ns, name)
+
+	var data string
+	if errCode, err := r.backend.DeleteDaemonset(ns, name, enforce); err != nil {
+		blog.Error("fail to delete daemonset(%s.%s), err:%s", ns, name, err.Error())
+		data = createResponeDataV2(errCode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request delete daemonset(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) listDaemonsets(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	blog.V(3).Infof("request list daemonsets(%s)", ns)
+
+	var data string
+	daemonsets, err := r.backend.ListDaemonsets(ns)
+	if err != nil {
+		blog.Error("request list daemonsets(%s) err(%s)", ns, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", daemonsets)
+	resp.Write([]byte(data))
+
+	blog.Info("request list daemonsets(%s) end", ns)
+	return
+}
+
+func (r *Router) createJob(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	blog.V(3).Infof("recv create job request")
+
+	var jobDef types.JobDef
+	decoder := json.NewDecoder(req.Request.Body)
+	if err := decoder.Decode(&jobDef); err != nil {
+		blog.Error("fail to Decode json to create job, err:%s", err.Error())
+		data := createResponeDataV2(comm.BcsErrCommJsonDecode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	blog.Info("request create job(%s.%s)",
+		jobDef.ObjectMeta.NameSpace, jobDef.ObjectMeta.Name)
+
+	if errcode, err := r.backend.CreateJob(&jobDef); err != nil {
+		blog.Error("fail to create job(%s.%s), err:%s",
+			jobDef.ObjectMeta.NameSpace, jobDef.ObjectMeta.Name, err.Error())
+		data := createResponeDataV2(errcode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request create job(%s.%s) end",
+		jobDef.ObjectMeta.NameSpace, jobDef.ObjectMeta.Name)
+	return
+}
+
+func (r *Router) fetchJob(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request fetch job(%s.%s)", ns, name)
+
+	var data string
+	job, err := r.backend.GetJob(ns, name)
+	if err != nil {
+		blog.Error("request fetch job(%s.%s) err(%s)", ns, name, err.Error())
+		if err == zk.ErrNoNode {
+			data = createResponeDataV2(comm.BcsErrMesosSchedNotFound, err.Error(), nil)
+		} else {
+			data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		}
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", job)
+	resp.Write([]byte(data))
+
+	blog.Info("request fetch job(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) deleteJob(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+
+	enforce := false
+	enforcePara := req.QueryParameter("enforce")
+	if enforcePara == "1" {
+		enforce = true
+	}
+
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.Infof("request delete job(%s.%s)", ns, name)
+
+	var data string
+	if errCode, err := r.backend.DeleteJob(ns, name, enforce); err != nil {
+		blog.Error("fail to delete job(%s.%s), err:%s", ns, name, err.Error())
+		data = createResponeDataV2(errCode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request delete job(%s.%s) end", ns, name)
+	return
+}
+
+func (r *Router) listJobs(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	blog.V(3).Infof("request list jobs(%s)", ns)
+
+	var data string
+	jobs, err := r.backend.ListJobs(ns)
+	if err != nil {
+		blog.Error("request list jobs(%s) err(%s)", ns, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", jobs)
+	resp.Write([]byte(data))
+
+	blog.Info("request list jobs(%s) end", ns)
+	return
+}
+
+func (r *Router) listDeploymentRevisions(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request list deployment(%s.%s) revisions", ns, name)
+
+	var data string
+	revisions, err := r.backend.ListDeploymentRevisions(ns, name)
+	if err != nil {
+		blog.Error("request list deployment(%s.%s) revisions err(%s)", ns, name, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", revisions)
+	resp.Write([]byte(data))
+
+	blog.Info("request list deployment(%s.%s) revisions end", ns, name)
+	return
+}
+
+func (r *Router) getDeploymentRevision(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request get deployment(%s.%s) revision(%s)", ns, name, req.PathParameter("revision"))
+
+	var data string
+	revision, err := strconv.ParseInt(req.PathParameter("revision"), 10, 64)
+	if err != nil {
+		blog.Error("request get deployment(%s.%s) revision err(%s)", ns, name, err.Error())
+		data = createResponeDataV2(comm.BcsErrCommRequestDataErr, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	obj, err := r.backend.GetDeploymentRevision(ns, name, revision)
+	if err != nil {
+		blog.Error("request get deployment(%s.%s) revision(%d) err(%s)", ns, name, revision, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", obj)
+	resp.Write([]byte(data))
+
+	blog.Info("request get deployment(%s.%s) revision(%d) end", ns, name, revision)
+	return
+}
+
+func (r *Router) diffDeploymentRevisions(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("request diff deployment(%s.%s) revisions(%s, %s)",
+		ns, name, req.PathParameter("from"), req.PathParameter("to"))
+
+	var data string
+	from, err := strconv.ParseInt(req.PathParameter("from"), 10, 64)
+	if err != nil {
+		blog.Error("request diff deployment(%s.%s) revisions err(%s)", ns, name, err.Error())
+		data = createResponeDataV2(comm.BcsErrCommRequestDataErr, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+	to, err := strconv.ParseInt(req.PathParameter("to"), 10, 64)
+	if err != nil {
+		blog.Error("request diff deployment(%s.%s) revisions err(%s)", ns, name, err.Error())
+		data = createResponeDataV2(comm.BcsErrCommRequestDataErr, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	diffs, err := r.backend.DiffDeploymentRevisions(ns, name, from, to)
+	if err != nil {
+		blog.Error("request diff deployment(%s.%s) revisions(%d, %d) err(%s)", ns, name, from, to, err.Error())
+		data = createResponeDataV2(comm.BcsErrMesosSchedCommon, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data = createResponeData(nil, "success", diffs)
+	resp.Write([]byte(data))
+
+	blog.Info("request diff deployment(%s.%s) revisions(%d, %d) end", ns, name, from, to)
+	return
+}
+
+func (r *Router) rollbackDeployment(req *restful.Request, resp *restful.Response) {
+	if r.backend.GetRole() != "master" {
+		blog.Warn("scheduler is not master, can not process cmd")
+		return
+	}
+	ns := req.PathParameter("namespace")
+	name := req.PathParameter("name")
+	blog.V(3).Infof("recv rollback deployment(%s.%s) request", ns, name)
+
+	var rollback types.DeploymentRollbackDef
+	decoder := json.NewDecoder(req.Request.Body)
+	if err := decoder.Decode(&rollback); err != nil {
+		blog.Error("fail to Decode json to rollback deployment(%s.%s), err:%s", ns, name, err.Error())
+		data := createResponeDataV2(comm.BcsErrCommJsonDecode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	blog.Info("request rollback deployment(%s.%s) to revision(%d)", ns, name, rollback.Revision)
+
+	if errCode, err := r.backend.RollbackDeployment(ns, name, &rollback); err != nil {
+		blog.Error("fail to rollback deployment(%s.%s), err:%s", ns, name, err.Error())
+		data := createResponeDataV2(errCode, err.Error(), nil)
+		resp.Write([]byte(data))
+		return
+	}
+
+	data := createResponeData(nil, "success", nil)
+	resp.Write([]byte(data))
+
+	blog.Info("request rollback deployment(%s.%s) end", ns, name)
+	return
+}
@@ -67,6 +67,7 @@ func (r *Router) initRoutes() {
 	r.actions = append(r.actions, httpserver.NewAction("PUT", "/taskgroup/{taskgroupId}/rescheduler", nil, r.reschedulerTaskgroup))
 	r.actions = append(r.actions, httpserver.NewAction("POST", "/taskgroup/{taskGroupID}/restart", nil, r.restartTaskGroup))
 	r.actions = append(r.actions, httpserver.NewAction("POST", "/taskgroup/{taskGroupID}/reload", nil, r.reloadTaskGroup))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/taskgroup/{taskGroupID}/logs", nil, r.fetchTaskGroupLogs))
 	/*-------------- taskgroup ---------------*/
 
 	/*-------------- task ---------------*/
@@ -115,6 +116,10 @@ func (r *Router) initRoutes() {
 	r.actions = append(r.actions, httpserver.NewAction("POST", "/deployment/{namespace}/{name}/resumeupdate", nil, r.resumeUpdateDeployment))
 	r.actions = append(r.actions, httpserver.NewAction("DELETE", "/deployment/{namespace}/{name}", nil, r.deleteDeployment))
 	r.actions = append(r.actions, httpserver.NewAction("PUT", "/deployment/{namespace}/{name}/scale/{instances}", nil, r.scaleDeployment_r))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/deployment/{namespace}/{name}/revisions", nil, r.listDeploymentRevisions))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/deployment/{namespace}/{name}/revisions/{revision}", nil, r.getDeploymentRevision))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/deployment/{namespace}/{name}/revisiondiff/{from}/{to}", nil, r.diffDeploymentRevisions))
+	r.actions = append(r.actions, httpserver.NewAction("POST", "/deployment/{namespace}/{name}/rollback", nil, r.rollbackDeployment))
 	/*-------------- deployment ---------------*/
 
 	/*-------------- healthcheck ---------------*/
@@ -162,6 +167,28 @@ func (r *Router) initRoutes() {
 	r.actions = append(r.actions, httpserver.NewAction("DELETE", "/command/deployment/{ns}/{name}", nil, r.deleteDeploymentCommand))
 	/*--------------command ----------------------*/
 
+	/*--------------resourcequota ----------------------*/
+	r.actions = append(r.actions, httpserver.NewAction("POST", "/resourcequota", nil, r.createResourceQuota))
+	r.actions = append(r.actions, httpserver.NewAction("PUT", "/resourcequota", nil, r.updateResourceQuota))
+	r.actions = append(r.actions, httpserver.NewAction("DELETE", "/resourcequota/{namespace}/{name}", nil, r.deleteResourceQuota))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/resourcequota/{namespace}/{name}", nil, r.fetchResourceQuota))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/resourcequotas/{namespace}", nil, r.listResourceQuotas))
+	/*--------------resourcequota ----------------------*/
+
+	/*-------------- daemonset ---------------*/
+	r.actions = append(r.actions, httpserver.NewAction("POST", "/daemonset/{namespace}/{name}", nil, r.createDaemonset))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/daemonset/{namespace}/{name}", nil, r.fetchDaemonset))
+	r.actions = append(r.actions, httpserver.NewAction("DELETE", "/daemonset/{namespace}/{name}", nil, r.deleteDaemonset))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/daemonsets/{namespace}", nil, r.listDaemonsets))
+	/*-------------- daemonset ---------------*/
+
+	/*-------------- job ---------------*/
+	r.actions = append(r.actions, httpserver.NewAction("POST", "/job/{namespace}/{name}", nil, r.createJob))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/job/{namespace}/{name}", nil, r.fetchJob))
+	r.actions = append(r.actions, httpserver.NewAction("DELETE", "/job/{namespace}/{name}", nil, r.deleteJob))
+	r.actions = append(r.actions, httpserver.NewAction("GET", "/jobs/{namespace}", nil, r.listJobs))
+	/*-------------- job ---------------*/
+
 	/*--------------admissionwebhook ----------------------*/
 	r.actions = append(r.actions, httpserver.NewAction("POST", "/admissionwebhook", nil, r.createAdmissionwebhook))
 	r.actions = append(r.actions, httpserver.NewAction("PUT", "/admissionwebhook", nil, r.updateAdmissionwebhook))
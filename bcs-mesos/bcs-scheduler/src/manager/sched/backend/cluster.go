@@ -15,7 +15,8 @@ package backend
 
 import (
 	commtypes "bk-bcs/bcs-common/common/types"
-	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
 )
 
 func (b *backend) GetClusterResources() (*commtypes.BcsClusterResource, error) {
@@ -36,6 +37,18 @@ func (b *backend) GetClusterEndpoints() *commtypes.ClusterEndpoints {
 	return endpoints
 }
 
-func (b *backend) GetCurrentOffers() []*mesos.Offer {
-	return b.sched.GetCurrentOffers()
+//GetCurrentOffers returns the offers with scores, if appID is set, the offers are scored for the application
+func (b *backend) GetCurrentOffers(runAs, appID string) ([]*types.ScoredOffer, error) {
+	if appID == "" {
+		return b.sched.GetCurrentOffers(nil), nil
+	}
+
+	version, err := b.store.GetVersion(runAs, appID)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, fmt.Errorf("version of application(%s.%s) not found", runAs, appID)
+	}
+	return b.sched.GetCurrentOffers(version), nil
 }
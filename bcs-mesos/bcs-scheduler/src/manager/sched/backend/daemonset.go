@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package backend
+
+import (
+	comm "bk-bcs/bcs-common/common"
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"errors"
+	"fmt"
+	"github.com/samuel/go-zookeeper/zk"
+	"time"
+)
+
+func (b *backend) CreateDaemonset(daemonsetDef *types.DaemonsetDef) (int, error) {
+	ns := daemonsetDef.ObjectMeta.NameSpace
+	name := daemonsetDef.ObjectMeta.Name
+	blog.Info("request create daemonset(%s.%s) begin", ns, name)
+
+	version := daemonsetDef.Version
+	if version == nil || version.RunAs != ns || version.ID != name {
+		blog.Error("request create daemonset(%s.%s): version empty or namespace error", ns, name)
+		return comm.BcsErrCommRequestDataErr, errors.New("version empty or namespace error")
+	}
+
+	if err := b.CheckVersion(version); err != nil {
+		blog.Error("request create daemonset(%s.%s) version error: %s", ns, name, err.Error())
+		return comm.BcsErrCommRequestDataErr, err
+	}
+	if err := version.CheckAndDefaultResource(); err != nil {
+		blog.Error("request create daemonset(%s.%s) version error: %s", ns, name, err.Error())
+		return comm.BcsErrCommRequestDataErr, err
+	}
+	if version.CheckConstraints() == false {
+		blog.Error("request create daemonset(%s.%s) constraints error", ns, name)
+		return comm.BcsErrCommRequestDataErr, errors.New("version constraints error")
+	}
+
+	b.store.LockApplication(ns + "." + name)
+	defer b.store.UnLockApplication(ns + "." + name)
+
+	currDaemonset, err := b.store.FetchDaemonset(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("request create daemonset(%s.%s), fetch daemonset err:%s", ns, name, err.Error())
+		return comm.BcsErrMesosSchedCommon, err
+	}
+	if currDaemonset != nil {
+		blog.Warn("request create error: daemonset(%s.%s) already exist", ns, name)
+		return comm.BcsErrMesosSchedResourceExist, fmt.Errorf("daemonset(%s.%s) already exist", ns, name)
+	}
+
+	app, err := b.store.FetchApplication(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("request create daemonset(%s.%s), fetch application err:%s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if app != nil {
+		blog.Warn("request create daemonset(%s.%s): application already exist", ns, name)
+		return comm.BcsErrMesosSchedResourceExist, fmt.Errorf("application(%s.%s) already exist", ns, name)
+	}
+
+	//the instances of daemonset is decided by the agents, the controller will launch taskgroups
+	version.Instances = 0
+	application := types.Application{
+		Kind:             commtypes.BcsDataType_DAEMONSET,
+		ID:               version.ID,
+		Name:             version.ID,
+		DefineInstances:  0,
+		Instances:        0,
+		RunningInstances: 0,
+		RunAs:            version.RunAs,
+		ClusterId:        b.ClusterId(),
+		Status:           types.APP_STATUS_STAGING,
+		Message:          "daemonset waiting for agents",
+		Created:          time.Now().Unix(),
+		UpdateTime:       time.Now().Unix(),
+		ObjectMeta:       version.ObjectMeta,
+	}
+	if err := b.SaveApplication(&application); err != nil {
+		blog.Error("request create daemonset(%s.%s): save application err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+	if err := b.SaveVersion(version.RunAs, version.ID, version); err != nil {
+		blog.Error("request create daemonset(%s.%s): save version err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	daemonset := types.Daemonset{
+		ObjectMeta: daemonsetDef.ObjectMeta,
+		Status:     types.DAEMONSET_STATUS_RUNNING,
+		Message:    "daemonset waiting for agents",
+		Pods:       make(map[string]string),
+		RawJson:    daemonsetDef.RawJson,
+	}
+	if err := b.store.SaveDaemonset(&daemonset); err != nil {
+		blog.Error("request create daemonset(%s.%s): save daemonset err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	blog.Info("request create daemonset(%s.%s) end", ns, name)
+	return comm.BcsSuccess, nil
+}
+
+func (b *backend) GetDaemonset(ns string, name string) (*types.Daemonset, error) {
+	return b.store.FetchDaemonset(ns, name)
+}
+
+func (b *backend) ListDaemonsets(ns string) ([]*types.Daemonset, error) {
+	return b.store.ListDaemonsets(ns)
+}
+
+func (b *backend) DeleteDaemonset(ns string, name string, enforce bool) (int, error) {
+	blog.Info("request delete daemonset(%s.%s) begin", ns, name)
+
+	daemonset, err := b.store.FetchDaemonset(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("delete daemonset(%s.%s) fetch daemonset err: %s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if daemonset == nil {
+		blog.Warn("delete daemonset(%s.%s), daemonset not exist", ns, name)
+		return comm.BcsErrMesosSchedNotFound, errors.New("daemonset not exist")
+	}
+
+	err = b.sched.InnerDeleteApplication(ns, name, enforce)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("delete daemonset(%s.%s), delete application err:%s", ns, name, err.Error())
+		return comm.BcsErrMesosSchedCommon, err
+	}
+
+	b.store.LockApplication(ns + "." + name)
+	defer b.store.UnLockApplication(ns + "." + name)
+
+	daemonset, err = b.store.FetchDaemonset(ns, name)
+	if err != nil {
+		blog.Error("delete daemonset(%s.%s) fetch daemonset err: %s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	daemonset.Status = types.DAEMONSET_STATUS_DELETING
+	daemonset.Message = "waiting application to be deleted"
+	if err := b.store.SaveDaemonset(daemonset); err != nil {
+		blog.Error("delete daemonset(%s.%s), save daemonset err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	blog.Info("request delete daemonset(%s.%s) end", ns, name)
+	return comm.BcsSuccess, nil
+}
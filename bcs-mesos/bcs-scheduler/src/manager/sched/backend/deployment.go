@@ -34,6 +34,13 @@ func (b *backend) CreateDeployment(deploymentDef *types.DeploymentDef) (int, err
 	name := deploymentDef.ObjectMeta.Name
 	blog.Info("request create deployment(%s.%s) begin", ns, name)
 
+	//the version will be changed when creating application, keep it for revision
+	var revisionVersion *types.Version
+	if deploymentDef.Version != nil {
+		version := *deploymentDef.Version
+		revisionVersion = &version
+	}
+
 	b.store.LockDeployment(name)
 	defer b.store.UnLockDeployment(name)
 
@@ -176,6 +183,20 @@ func (b *backend) CreateDeployment(deploymentDef *types.DeploymentDef) (int, err
 		return comm.BcsErrCommRequestDataErr, errors.New("too many application exist matching deployment")
 	}
 
+	if revisionVersion != nil {
+		revision, err := b.recordDeploymentRevision(deploymentDef.ObjectMeta, revisionVersion,
+			deploymentDef.Strategy, deploymentDef.RawJson, "create deployment")
+		if err != nil {
+			blog.Error("request create deployment(%s.%s), record revision err:%s", ns, name, err.Error())
+		} else {
+			deployment.Revision = revision
+			if err := b.store.SaveDeployment(&deployment); err != nil {
+				blog.Error("request create deployment: save(%s.%s), err:%s", ns, name, err.Error())
+				return comm.BcsErrCommCreateZkNodeFail, err
+			}
+		}
+	}
+
 	blog.Info("request create deployment(%s.%s) end", ns, name)
 	return comm.BcsSuccess, nil
 }
@@ -260,20 +281,9 @@ func (b *backend) UpdateDeployment(deployment *types.DeploymentDef) (int, error)
 	name := deployment.ObjectMeta.Name
 	blog.Info("request update deployment(%s.%s) begin", ns, name)
 
-	if deployment.Strategy.RollingUpdate == nil {
-		blog.Error("update deployment(%s.%s): rollingupdate strategy not set", ns, name)
-		return comm.BcsErrCommRequestDataErr, errors.New("update strategy not set")
-	}
-	if deployment.Strategy.RollingUpdate.RollingOrder != commtypes.CreateFirstOrder && deployment.Strategy.RollingUpdate.RollingOrder != commtypes.DeleteFirstOrder {
-		blog.Error("update deployment(%s.%s): RollingOrder(%s) err",
-			ns, name, deployment.Strategy.RollingUpdate.RollingOrder)
-		return comm.BcsErrCommRequestDataErr, errors.New("update strategy rolling order error")
-	}
-	if deployment.Strategy.RollingUpdate.MaxUnavailable <= 0 {
-		deployment.Strategy.RollingUpdate.MaxUnavailable = 1
-	}
-	if deployment.Strategy.RollingUpdate.MaxSurge <= 0 {
-		deployment.Strategy.RollingUpdate.MaxSurge = 1
+	if err := b.checkDeploymentStrategy(ns, &deployment.Strategy); err != nil {
+		blog.Error("update deployment(%s.%s): strategy error: %s", ns, name, err.Error())
+		return comm.BcsErrCommRequestDataErr, err
 	}
 
 	b.store.LockDeployment(name)
@@ -340,6 +350,7 @@ func (b *backend) UpdateDeployment(deployment *types.DeploymentDef) (int, error)
 
 	//create extension application but not launch
 	version := deployment.Version
+	revisionVersion := *version
 	version.ID = version.ID + "-v" + strconv.Itoa(int(time.Now().Unix()))
 	blog.Info("update deployment(%s.%s): create application(%s.%s)",
 		ns, name, version.RunAs, version.ID)
@@ -409,6 +420,14 @@ func (b *backend) UpdateDeployment(deployment *types.DeploymentDef) (int, error)
 	}
 
 	blog.Info("request update deployment(%s.%s), create and bind application(%s)", ns, name, application.ID)
+	revision, err := b.recordDeploymentRevision(deployment.ObjectMeta, &revisionVersion,
+		deployment.Strategy, deployment.RawJson, "update deployment")
+	if err != nil {
+		blog.Error("update deployment(%s.%s), record revision err: %s", ns, name, err.Error())
+	} else {
+		currDeployment.RevisionBackup = currDeployment.Revision
+		currDeployment.Revision = revision
+	}
 	currDeployment.ApplicationExt = new(types.DeploymentReferApplication)
 	currDeployment.ApplicationExt.ApplicationName = application.ID
 	currDeployment.Strategy = deployment.Strategy
@@ -416,6 +435,9 @@ func (b *backend) UpdateDeployment(deployment *types.DeploymentDef) (int, error)
 	currDeployment.LastRollingTime = 0
 	currDeployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
 	currDeployment.IsInRolling = false
+	currDeployment.CurrCanaryStep = 0
+	currDeployment.CanaryStepFinished = 0
+	currDeployment.BlueGreenSwitched = 0
 
 	// add  20181122
 	currDeployment.RawJsonBackup = currDeployment.RawJson
@@ -482,6 +504,17 @@ func (b *backend) CancelUpdateDeployment(ns string, name string) error {
 		return err
 	}
 
+	if deployment.Strategy.Type == commtypes.BlueGreenUpgradeStrategyType && deployment.Strategy.BlueGreen != nil {
+		//unpin services before the new version is deleted
+		blog.Info("cancelupdate deployment(%s.%s): switch back bluegreen services %v",
+			ns, name, deployment.Strategy.BlueGreen.ActiveServices)
+		err = b.sched.SwitchServicesSelector(ns, deployment.Strategy.BlueGreen.ActiveServices, "")
+		if err != nil {
+			blog.Error("request cancelupdate deployment(%s.%s): switch back services err: %s", ns, name, err.Error())
+			return err
+		}
+	}
+
 	times := 0
 	for {
 		if deployment.IsInRolling && deployment.CurrRollingOp == types.DEPLOYMENT_OPERATION_DELETE {
@@ -548,6 +581,12 @@ func (b *backend) CancelUpdateDeployment(ns string, name string) error {
 	deployment.LastRollingTime = 0
 	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
 	deployment.IsInRolling = false
+	deployment.CurrCanaryStep = 0
+	deployment.CanaryStepFinished = 0
+	deployment.BlueGreenSwitched = 0
+	if deployment.RevisionBackup > 0 {
+		deployment.Revision = deployment.RevisionBackup
+	}
 	if err := b.store.SaveDeployment(deployment); err != nil {
 		blog.Info("cancelupdate deployment(%s.%s), save deployment err:%s", ns, name, err.Error())
 		return err
@@ -658,6 +697,9 @@ func (b *backend) CheckDeleteDeployment(ns string, name string) {
 		if err := b.store.DeleteDeployment(ns, name); err != nil {
 			blog.Warn("delete deployment(%s.%s) from db err:%s", ns, name, err.Error())
 		}
+		if err := b.store.DeleteDeploymentRevisions(ns, name); err != nil && err != zk.ErrNoNode {
+			blog.Warn("delete deployment(%s.%s) revisions from db err:%s", ns, name, err.Error())
+		}
 		return
 
 	}
@@ -763,3 +805,58 @@ func (b *backend) ScaleDeployment(runAs, name string, instances uint64) error {
 
 	return b.ScaleApplication(runAs, deployment.Application.ApplicationName, instances, "", false)
 }
+
+// checkDeploymentStrategy check the update strategy of deployment and set the default values
+func (b *backend) checkDeploymentStrategy(ns string, strategy *commtypes.UpgradeStrategy) error {
+	switch strategy.Type {
+	case commtypes.CanaryUpgradeStrategyType:
+		if strategy.Canary == nil || len(strategy.Canary.Steps) == 0 {
+			return errors.New("canary strategy steps not set")
+		}
+		lastWeight := 0
+		for i, step := range strategy.Canary.Steps {
+			if step.Weight <= lastWeight || step.Weight > 100 {
+				return fmt.Errorf("canary step %d weight(%d) error, must be increasing in (0, 100]", i, step.Weight)
+			}
+			if step.Pause < 0 {
+				return fmt.Errorf("canary step %d pause(%d) error", i, step.Pause)
+			}
+			lastWeight = step.Weight
+		}
+		if lastWeight != 100 {
+			return errors.New("canary strategy weight of last step must be 100")
+		}
+	case commtypes.BlueGreenUpgradeStrategyType:
+		if strategy.BlueGreen == nil || len(strategy.BlueGreen.ActiveServices) == 0 {
+			return errors.New("bluegreen strategy activeServices not set")
+		}
+		if strategy.BlueGreen.ScaleDownDelaySeconds < 0 {
+			return errors.New("bluegreen strategy scaleDownDelaySeconds error")
+		}
+		for _, name := range strategy.BlueGreen.ActiveServices {
+			if _, err := b.store.FetchService(ns, name); err != nil {
+				return fmt.Errorf("bluegreen strategy active service(%s.%s) error: %s", ns, name, err.Error())
+			}
+		}
+	default:
+		if strategy.RollingUpdate == nil {
+			return errors.New("update strategy not set")
+		}
+		if strategy.RollingUpdate.RollingOrder != commtypes.CreateFirstOrder && strategy.RollingUpdate.RollingOrder != commtypes.DeleteFirstOrder {
+			return errors.New("update strategy rolling order error")
+		}
+		if strategy.RollingUpdate.MaxUnavailable <= 0 {
+			strategy.RollingUpdate.MaxUnavailable = 1
+		}
+		if strategy.RollingUpdate.MaxSurge <= 0 {
+			strategy.RollingUpdate.MaxSurge = 1
+		}
+		if strategy.RollingUpdate.ProgressDeadlineSeconds < 0 {
+			return errors.New("update strategy progressDeadlineSeconds error")
+		}
+		if strategy.RollingUpdate.AutoRollback && strategy.RollingUpdate.ProgressDeadlineSeconds == 0 {
+			return errors.New("update strategy autoRollback requires progressDeadlineSeconds")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package backend
+
+import (
+	comm "bk-bcs/bcs-common/common"
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samuel/go-zookeeper/zk"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//revisionHistoryLimit return the number of old revisions to retain for the deployment
+func revisionHistoryLimit(rawJson *commtypes.BcsDeployment) int {
+	if rawJson == nil || rawJson.Spec.RevisionHistoryLimit == nil || *rawJson.Spec.RevisionHistoryLimit < 0 {
+		return types.DEPLOYMENT_REVISION_HISTORY_LIMIT_DEFAULT
+	}
+	return *rawJson.Spec.RevisionHistoryLimit
+}
+
+//recordDeploymentRevision save the spec as a new revision of deployment, and prune the old revisions
+//out of the history limit. the operator and change cause are taken from the annotations
+func (b *backend) recordDeploymentRevision(meta commtypes.ObjectMeta, version *types.Version,
+	strategy commtypes.UpgradeStrategy, rawJson *commtypes.BcsDeployment, changeCause string) (int64, error) {
+	ns := meta.NameSpace
+	name := meta.Name
+
+	revisions, err := b.store.ListDeploymentRevisions(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		return 0, err
+	}
+
+	revision := &types.DeploymentRevision{
+		ObjectMeta: commtypes.ObjectMeta{
+			NameSpace: ns,
+			Name:      name,
+		},
+		Revision:    1,
+		Version:     version,
+		Strategy:    strategy,
+		RawJson:     rawJson,
+		CreateTime:  time.Now().Unix(),
+		Operator:    meta.Annotations[types.DEPLOYMENT_ANNOTATION_OPERATOR],
+		ChangeCause: changeCause,
+	}
+	if cause, ok := meta.Annotations[types.DEPLOYMENT_ANNOTATION_CHANGE_CAUSE]; ok && cause != "" {
+		revision.ChangeCause = cause
+	}
+	if len(revisions) > 0 {
+		revision.Revision = revisions[len(revisions)-1].Revision + 1
+	}
+	if err := b.store.SaveDeploymentRevision(revision); err != nil {
+		return 0, err
+	}
+	blog.Info("deployment(%s.%s) record revision %d: %s", ns, name, revision.Revision, revision.ChangeCause)
+
+	//revisions are in ascending order, keep the current one and the newest limit old ones
+	prune := len(revisions) - revisionHistoryLimit(rawJson)
+	for i := 0; i < prune; i++ {
+		if err := b.store.DeleteDeploymentRevision(ns, name, revisions[i].Revision); err != nil {
+			blog.Warn("deployment(%s.%s) prune revision %d err:%s", ns, name, revisions[i].Revision, err.Error())
+		}
+	}
+
+	return revision.Revision, nil
+}
+
+func (b *backend) ListDeploymentRevisions(ns, name string) ([]*types.DeploymentRevision, error) {
+	if _, err := b.store.FetchDeployment(ns, name); err != nil {
+		blog.Error("list deployment(%s.%s) revisions, fetch deployment err:%s", ns, name, err.Error())
+		return nil, err
+	}
+
+	revisions, err := b.store.ListDeploymentRevisions(ns, name)
+	if err == zk.ErrNoNode {
+		return nil, nil
+	}
+	return revisions, err
+}
+
+func (b *backend) GetDeploymentRevision(ns, name string, revision int64) (*types.DeploymentRevision, error) {
+	return b.store.FetchDeploymentRevision(ns, name, revision)
+}
+
+//DiffDeploymentRevisions return the changed fields of version and strategy from one revision to another
+func (b *backend) DiffDeploymentRevisions(ns, name string, from, to int64) ([]*types.DeploymentRevisionDiff, error) {
+	fromRevision, err := b.store.FetchDeploymentRevision(ns, name, from)
+	if err != nil {
+		blog.Error("diff deployment(%s.%s) revisions, fetch revision %d err:%s", ns, name, from, err.Error())
+		return nil, fmt.Errorf("fetch revision %d err: %s", from, err.Error())
+	}
+	toRevision, err := b.store.FetchDeploymentRevision(ns, name, to)
+	if err != nil {
+		blog.Error("diff deployment(%s.%s) revisions, fetch revision %d err:%s", ns, name, to, err.Error())
+		return nil, fmt.Errorf("fetch revision %d err: %s", to, err.Error())
+	}
+
+	return diffDeploymentRevisions(fromRevision, toRevision)
+}
+
+func diffDeploymentRevisions(from, to *types.DeploymentRevision) ([]*types.DeploymentRevisionDiff, error) {
+	fromFields := make(map[string]interface{})
+	if err := flattenJsonFields(map[string]interface{}{"version": from.Version, "strategy": from.Strategy}, fromFields); err != nil {
+		return nil, err
+	}
+	toFields := make(map[string]interface{})
+	if err := flattenJsonFields(map[string]interface{}{"version": to.Version, "strategy": to.Strategy}, toFields); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path, value := range fromFields {
+		if toValue, ok := toFields[path]; !ok || !reflect.DeepEqual(value, toValue) {
+			paths = append(paths, path)
+		}
+	}
+	for path := range toFields {
+		if _, ok := fromFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	diffs := make([]*types.DeploymentRevisionDiff, 0, len(paths))
+	for _, path := range paths {
+		diffs = append(diffs, &types.DeploymentRevisionDiff{
+			Path: path,
+			From: fromFields[path],
+			To:   toFields[path],
+		})
+	}
+	return diffs, nil
+}
+
+//flattenJsonFields flatten the json of object into leaf fields, keyed by the path like a.b[0].c
+func flattenJsonFields(obj interface{}, fields map[string]interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	flattenJsonValue("", value, fields)
+	return nil
+}
+
+func flattenJsonValue(path string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if path == "" {
+				flattenJsonValue(key, item, fields)
+			} else {
+				flattenJsonValue(path+"."+key, item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenJsonValue(path+"["+strconv.Itoa(i)+"]", item, fields)
+		}
+	case nil:
+		//null and missing fields are the same
+	default:
+		fields[path] = v
+	}
+}
+
+//RollbackDeployment update the deployment to the application spec of a revision by current strategy,
+//revision 0 means the revision before current one
+func (b *backend) RollbackDeployment(ns, name string, rollback *types.DeploymentRollbackDef) (int, error) {
+	blog.Info("request rollback deployment(%s.%s) to revision %d", ns, name, rollback.Revision)
+
+	deployment, err := b.store.FetchDeployment(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("rollback deployment(%s.%s) fetch deployment err: %s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if deployment == nil {
+		blog.Warn("rollback deployment(%s.%s): data not exist", ns, name)
+		return comm.BcsErrMesosSchedNotFound, errors.New("deployment not exist")
+	}
+
+	target := rollback.Revision
+	if target == 0 {
+		revisions, err := b.store.ListDeploymentRevisions(ns, name)
+		if err != nil && err != zk.ErrNoNode {
+			blog.Error("rollback deployment(%s.%s) list revisions err: %s", ns, name, err.Error())
+			return comm.BcsErrCommListZkNodeFail, err
+		}
+		for _, revision := range revisions {
+			if revision.Revision < deployment.Revision {
+				target = revision.Revision
+			}
+		}
+		if target == 0 {
+			blog.Warn("rollback deployment(%s.%s): no previous revision of %d", ns, name, deployment.Revision)
+			return comm.BcsErrMesosSchedNotFound, errors.New("no previous revision to rollback")
+		}
+	}
+	if target == deployment.Revision {
+		return comm.BcsErrCommRequestDataErr, fmt.Errorf("revision %d is the current revision", target)
+	}
+
+	revision, err := b.store.FetchDeploymentRevision(ns, name, target)
+	if err == zk.ErrNoNode {
+		blog.Warn("rollback deployment(%s.%s): revision %d not exist", ns, name, target)
+		return comm.BcsErrMesosSchedNotFound, fmt.Errorf("revision %d not exist", target)
+	}
+	if err != nil {
+		blog.Error("rollback deployment(%s.%s) fetch revision %d err: %s", ns, name, target, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if revision.Version == nil {
+		return comm.BcsErrCommRequestDataErr, fmt.Errorf("revision %d has no application version", target)
+	}
+
+	meta := deployment.ObjectMeta
+	meta.Annotations = make(map[string]string)
+	for k, v := range deployment.ObjectMeta.Annotations {
+		meta.Annotations[k] = v
+	}
+	meta.Annotations[types.DEPLOYMENT_ANNOTATION_OPERATOR] = rollback.Operator
+	meta.Annotations[types.DEPLOYMENT_ANNOTATION_CHANGE_CAUSE] = fmt.Sprintf("rollback to revision %d", target)
+	version := *revision.Version
+
+	deploymentDef := &types.DeploymentDef{
+		ObjectMeta: meta,
+		Selector:   deployment.Selector,
+		Version:    &version,
+		Strategy:   deployment.Strategy,
+		RawJson:    revision.RawJson,
+	}
+	return b.UpdateDeployment(deploymentDef)
+}
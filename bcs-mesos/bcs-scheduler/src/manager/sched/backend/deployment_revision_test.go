@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package backend
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDeploymentRevisions(t *testing.T) {
+	from := &types.DeploymentRevision{
+		Revision: 1,
+		Version: &types.Version{
+			ID:        "app",
+			Instances: 2,
+			Labels:    map[string]string{"app": "test"},
+		},
+	}
+	to := &types.DeploymentRevision{
+		Revision: 2,
+		Version: &types.Version{
+			ID:        "app",
+			Instances: 3,
+			Labels:    map[string]string{"app": "test", "tier": "web"},
+		},
+		Strategy: commtypes.UpgradeStrategy{Type: commtypes.RollingUpdateUpgradeStrategyType},
+	}
+
+	diffs, err := diffDeploymentRevisions(from, to)
+	assert.Nil(t, err)
+	paths := make(map[string]*types.DeploymentRevisionDiff)
+	for _, diff := range diffs {
+		paths[diff.Path] = diff
+	}
+
+	assert.Equal(t, float64(2), paths["version.Instances"].From)
+	assert.Equal(t, float64(3), paths["version.Instances"].To)
+	assert.Nil(t, paths["version.Labels.tier"].From)
+	assert.Equal(t, "web", paths["version.Labels.tier"].To)
+	assert.Equal(t, "", paths["strategy.type"].From)
+	assert.NotContains(t, paths, "version.ID")
+	assert.NotContains(t, paths, "version.Labels.app")
+
+	diffs, err = diffDeploymentRevisions(to, to)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diffs))
+}
+
+func TestRevisionHistoryLimit(t *testing.T) {
+	assert.Equal(t, types.DEPLOYMENT_REVISION_HISTORY_LIMIT_DEFAULT, revisionHistoryLimit(nil))
+
+	limit := 3
+	rawJson := &commtypes.BcsDeployment{}
+	rawJson.Spec.RevisionHistoryLimit = &limit
+	assert.Equal(t, 3, revisionHistoryLimit(rawJson))
+}
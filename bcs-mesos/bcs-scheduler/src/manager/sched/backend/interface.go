@@ -27,7 +27,6 @@ package backend
 
 import (
 	commtypes "bk-bcs/bcs-common/common/types"
-	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
 )
 
@@ -129,6 +128,9 @@ type Backend interface {
 	//fetch a specific configmap, ns is namespace, name is configmap's name
 	FetchConfigMap(ns, name string) (*commtypes.BcsConfigMap, error)
 
+	//reload the changed items of configmap in running taskgroups, old is the configmap before updated
+	ReloadConfigMap(old, cur *commtypes.BcsConfigMap)
+
 	//delete configmap, ns is namespace, name is configmap's name
 	DeleteConfigMap(ns string, name string) error
 
@@ -138,6 +140,9 @@ type Backend interface {
 	//fetch secret, ns is namespace, name is secret's name
 	FetchSecret(ns, name string) (*commtypes.BcsSecret, error)
 
+	//reload the changed items of secret in running taskgroups, old is the secret before updated
+	ReloadSecret(old, cur *commtypes.BcsSecret)
+
 	//delete secret, ns is namespace, name is secret's name
 	DeleteSecret(ns string, name string) error
 
@@ -195,6 +200,52 @@ type Backend interface {
 	//the number must be >= 1.
 	ScaleDeployment(string, string, uint64) error
 
+	//list the revisions of deployment in ascending order
+	//first para is namespace, second one is deployment's name
+	ListDeploymentRevisions(string, string) ([]*types.DeploymentRevision, error)
+
+	//get a revision of deployment
+	//first para is namespace, second one is deployment's name, third one is the revision
+	GetDeploymentRevision(string, string, int64) (*types.DeploymentRevision, error)
+
+	//diff the version and strategy of two revisions of deployment
+	//first para is namespace, second one is deployment's name, the others are revisions from and to
+	DiffDeploymentRevisions(string, string, int64, int64) ([]*types.DeploymentRevisionDiff, error)
+
+	//rollback deployment to a revision by rolling update
+	//first para is namespace, second one is deployment's name
+	RollbackDeployment(string, string, *types.DeploymentRollbackDef) (int, error)
+
+	//create daemonset, which runs one taskgroup on every enabled agent
+	//the application with the same namespace and name is created for the taskgroups
+	CreateDaemonset(*types.DaemonsetDef) (int, error)
+
+	//get daemonset, first para is namespace, second one is daemonset's name
+	GetDaemonset(string, string) (*types.Daemonset, error)
+
+	//list daemonsets under namespace
+	ListDaemonsets(string) ([]*types.Daemonset, error)
+
+	//delete daemonset, include the associated application
+	//first para is namespace, second one is daemonset's name
+	//third one is whether force to delete the daemonset
+	DeleteDaemonset(string, string, bool) (int, error)
+
+	//create job, which runs taskgroups until the completions are succeeded
+	//the application with the same namespace and name is created for the taskgroups
+	CreateJob(*types.JobDef) (int, error)
+
+	//get job, first para is namespace, second one is job's name
+	GetJob(string, string) (*types.Job, error)
+
+	//list jobs under namespace
+	ListJobs(string) ([]*types.Job, error)
+
+	//delete job, include the associated application
+	//first para is namespace, second one is job's name
+	//third one is whether force to delete the job
+	DeleteJob(string, string, bool) (int, error)
+
 	//healthy report
 	HealthyReport(*commtypes.HealthCheckResult)
 
@@ -275,14 +326,17 @@ type Backend interface {
 	//commit task(taskgroup->image) to url
 	CommitImage(string, string, string) (*types.BcsMessage, error)
 
-	//get current offers
-	GetCurrentOffers() []*mesos.Offer
+	//get current offers with scores, scored for the application if appID is set
+	GetCurrentOffers(runAs, appID string) ([]*types.ScoredOffer, error)
 	// send restart taskGroup command, only for process.
 	RestartTaskGroup(taskGroupID string) (*types.BcsMessage, error)
 
 	// send reload taskGroup command, only for process.
 	ReloadTaskGroup(taskGroupID string) (*types.BcsMessage, error)
 
+	// fetch logs of container in taskGroup, collected by executor.
+	FetchTaskGroupLogs(taskGroupID, containerName string, request *types.RequestLogsTask) (*types.ResponseLogsTask, error)
+
 	// get command
 	GetCommand(ID string) (*commtypes.BcsCommandInfo, error)
 	// delete command
@@ -297,4 +351,20 @@ type Backend interface {
 	DeleteAdmissionWebhook(ns, name string) error
 	FetchAllAdmissionWebhooks() ([]*commtypes.AdmissionWebhookConfiguration, error)
 	/*=========AdmissionWebhook==========*/
+
+	//save resource quota of namespace
+	SaveResourceQuota(quota *commtypes.BcsResourceQuota) error
+
+	//fetch resource quota with current usage, ns is namespace, name is quota's name
+	FetchResourceQuota(ns, name string) (*commtypes.BcsResourceQuota, error)
+
+	//delete resource quota, ns is namespace, name is quota's name
+	DeleteResourceQuota(ns, name string) error
+
+	//list resource quotas with current usage of namespace
+	ListResourceQuotas(ns string) ([]*commtypes.BcsResourceQuota, error)
+
+	//check whether the application of version with instances exceed the resource quota of its namespace
+	//if not exceeded, return nil
+	CheckResourceQuota(version *types.Version, instances uint64) error
 }
@@ -0,0 +1,176 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package backend
+
+import (
+	comm "bk-bcs/bcs-common/common"
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"errors"
+	"fmt"
+	"github.com/samuel/go-zookeeper/zk"
+	"time"
+)
+
+func (b *backend) CreateJob(jobDef *types.JobDef) (int, error) {
+	ns := jobDef.ObjectMeta.NameSpace
+	name := jobDef.ObjectMeta.Name
+	blog.Info("request create job(%s.%s) begin", ns, name)
+
+	version := jobDef.Version
+	if version == nil || version.RunAs != ns || version.ID != name {
+		blog.Error("request create job(%s.%s): version empty or namespace error", ns, name)
+		return comm.BcsErrCommRequestDataErr, errors.New("version empty or namespace error")
+	}
+	if jobDef.Completions <= 0 || jobDef.Parallelism <= 0 || jobDef.BackoffLimit < 0 || jobDef.ActiveDeadlineSeconds < 0 {
+		blog.Error("request create job(%s.%s): completions(%d) parallelism(%d) backoffLimit(%d) activeDeadlineSeconds(%d) error",
+			ns, name, jobDef.Completions, jobDef.Parallelism, jobDef.BackoffLimit, jobDef.ActiveDeadlineSeconds)
+		return comm.BcsErrCommRequestDataErr, errors.New("completions and parallelism must be > 0, backoffLimit and activeDeadlineSeconds must be >= 0")
+	}
+
+	if err := b.CheckVersion(version); err != nil {
+		blog.Error("request create job(%s.%s) version error: %s", ns, name, err.Error())
+		return comm.BcsErrCommRequestDataErr, err
+	}
+	if err := version.CheckAndDefaultResource(); err != nil {
+		blog.Error("request create job(%s.%s) version error: %s", ns, name, err.Error())
+		return comm.BcsErrCommRequestDataErr, err
+	}
+	if version.CheckConstraints() == false {
+		blog.Error("request create job(%s.%s) constraints error", ns, name)
+		return comm.BcsErrCommRequestDataErr, errors.New("version constraints error")
+	}
+
+	b.store.LockApplication(ns + "." + name)
+	defer b.store.UnLockApplication(ns + "." + name)
+
+	currJob, err := b.store.FetchJob(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("request create job(%s.%s), fetch job err:%s", ns, name, err.Error())
+		return comm.BcsErrMesosSchedCommon, err
+	}
+	if currJob != nil {
+		blog.Warn("request create error: job(%s.%s) already exist", ns, name)
+		return comm.BcsErrMesosSchedResourceExist, fmt.Errorf("job(%s.%s) already exist", ns, name)
+	}
+
+	app, err := b.store.FetchApplication(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("request create job(%s.%s), fetch application err:%s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if app != nil {
+		blog.Warn("request create job(%s.%s): application already exist", ns, name)
+		return comm.BcsErrMesosSchedResourceExist, fmt.Errorf("application(%s.%s) already exist", ns, name)
+	}
+
+	//all the completions are counted in the resource quota of namespace like application instances
+	if err := b.CheckResourceQuota(version, uint64(jobDef.Completions)); err != nil {
+		blog.Warn("request create job(%s.%s) rejected: %s", ns, name, err.Error())
+		return comm.BcsErrMesosSchedQuotaExceeded, err
+	}
+
+	//the taskgroups of job are launched by the controller, and never restarted by scheduler
+	version.Instances = 0
+	version.RestartPolicy = &commtypes.RestartPolicy{Policy: commtypes.RestartPolicy_NEVER}
+	application := types.Application{
+		Kind:             commtypes.BcsDataType_JOB,
+		ID:               version.ID,
+		Name:             version.ID,
+		DefineInstances:  uint64(jobDef.Completions),
+		Instances:        0,
+		RunningInstances: 0,
+		RunAs:            version.RunAs,
+		ClusterId:        b.ClusterId(),
+		Status:           types.APP_STATUS_STAGING,
+		Message:          "job waiting to launch taskgroups",
+		Created:          time.Now().Unix(),
+		UpdateTime:       time.Now().Unix(),
+		ObjectMeta:       version.ObjectMeta,
+	}
+	if err := b.SaveApplication(&application); err != nil {
+		blog.Error("request create job(%s.%s): save application err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+	if err := b.SaveVersion(version.RunAs, version.ID, version); err != nil {
+		blog.Error("request create job(%s.%s): save version err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	job := types.Job{
+		ObjectMeta:            jobDef.ObjectMeta,
+		Completions:           jobDef.Completions,
+		Parallelism:           jobDef.Parallelism,
+		BackoffLimit:          jobDef.BackoffLimit,
+		ActiveDeadlineSeconds: jobDef.ActiveDeadlineSeconds,
+		Status:                types.JOB_STATUS_RUNNING,
+		Message:               "job waiting to launch taskgroups",
+		StartTime:             time.Now().Unix(),
+		RawJson:               jobDef.RawJson,
+	}
+	if err := b.store.SaveJob(&job); err != nil {
+		blog.Error("request create job(%s.%s): save job err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	blog.Info("request create job(%s.%s) end", ns, name)
+	return comm.BcsSuccess, nil
+}
+
+func (b *backend) GetJob(ns string, name string) (*types.Job, error) {
+	return b.store.FetchJob(ns, name)
+}
+
+func (b *backend) ListJobs(ns string) ([]*types.Job, error) {
+	return b.store.ListJobs(ns)
+}
+
+func (b *backend) DeleteJob(ns string, name string, enforce bool) (int, error) {
+	blog.Info("request delete job(%s.%s) begin", ns, name)
+
+	job, err := b.store.FetchJob(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("delete job(%s.%s) fetch job err: %s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	if job == nil {
+		blog.Warn("delete job(%s.%s), job not exist", ns, name)
+		return comm.BcsErrMesosSchedNotFound, errors.New("job not exist")
+	}
+
+	err = b.sched.InnerDeleteApplication(ns, name, enforce)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Error("delete job(%s.%s), delete application err:%s", ns, name, err.Error())
+		return comm.BcsErrMesosSchedCommon, err
+	}
+
+	b.store.LockApplication(ns + "." + name)
+	defer b.store.UnLockApplication(ns + "." + name)
+
+	job, err = b.store.FetchJob(ns, name)
+	if err != nil {
+		blog.Error("delete job(%s.%s) fetch job err: %s", ns, name, err.Error())
+		return comm.BcsErrCommGetZkNodeFail, err
+	}
+	job.Status = types.JOB_STATUS_DELETING
+	job.Message = "waiting application to be deleted"
+	if err := b.store.SaveJob(job); err != nil {
+		blog.Error("delete job(%s.%s), save job err:%s", ns, name, err.Error())
+		return comm.BcsErrCommCreateZkNodeFail, err
+	}
+
+	blog.Info("request delete job(%s.%s) end", ns, name)
+	return comm.BcsSuccess, nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package backend
+
+import (
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+)
+
+func (b *backend) SaveResourceQuota(quota *commtypes.BcsResourceQuota) error {
+	//status is calculated when fetching, never save it
+	quota.Status = commtypes.ResourceQuotaStatus{}
+	return b.store.SaveResourceQuota(quota)
+}
+
+func (b *backend) FetchResourceQuota(ns, name string) (*commtypes.BcsResourceQuota, error) {
+	quota, err := b.store.FetchResourceQuota(ns, name)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := b.sched.GetResourceQuotaUsage(ns, "")
+	if err != nil {
+		return nil, err
+	}
+	quota.Status.Used = *used
+
+	return quota, nil
+}
+
+func (b *backend) DeleteResourceQuota(ns, name string) error {
+	return b.store.DeleteResourceQuota(ns, name)
+}
+
+func (b *backend) ListResourceQuotas(ns string) ([]*commtypes.BcsResourceQuota, error) {
+	quotas, err := b.store.ListResourceQuotas(ns)
+	if err != nil || len(quotas) == 0 {
+		return quotas, err
+	}
+
+	used, err := b.sched.GetResourceQuotaUsage(ns, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, quota := range quotas {
+		quota.Status.Used = *used
+	}
+
+	return quotas, nil
+}
+
+func (b *backend) CheckResourceQuota(version *types.Version, instances uint64) error {
+	return b.sched.CheckResourceQuota(version.RunAs, version.ID, version.AllResource(), instances)
+}
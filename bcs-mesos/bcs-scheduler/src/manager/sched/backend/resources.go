@@ -14,6 +14,7 @@
 package backend
 
 import (
+	"bk-bcs/bcs-common/common/secretbackend"
 	commtypes "bk-bcs/bcs-common/common/types"
 )
 
@@ -26,12 +27,19 @@ func (b *backend) FetchConfigMap(ns, name string) (*commtypes.BcsConfigMap, erro
 	return b.store.FetchConfigMap(ns, name)
 }
 
+func (b *backend) ReloadConfigMap(old, cur *commtypes.BcsConfigMap) {
+	go b.sched.ReloadConfigMap(old, cur)
+}
+
 func (b *backend) DeleteConfigMap(ns string, name string) error {
 	return b.store.DeleteConfigMap(ns, name)
 }
 
 func (b *backend) SaveSecret(secret *commtypes.BcsSecret) error {
-
+	// keep only references or ciphertext of secret items in store
+	if err := secretbackend.Default().Seal(secret); err != nil {
+		return err
+	}
 	return b.store.SaveSecret(secret)
 }
 
@@ -39,6 +47,10 @@ func (b *backend) FetchSecret(ns, name string) (*commtypes.BcsSecret, error) {
 	return b.store.FetchSecret(ns, name)
 }
 
+func (b *backend) ReloadSecret(old, cur *commtypes.BcsSecret) {
+	go b.sched.ReloadSecret(old, cur)
+}
+
 func (b *backend) DeleteSecret(ns string, name string) error {
 	return b.store.DeleteSecret(ns, name)
 }
@@ -77,6 +77,13 @@ func (b *backend) ScaleApplication(runAs, appID string, instances uint64, kind c
 	}
 
 	blog.Info("get newest version(%s) for application(%s.%s) to do scale", newestVersion, runAs, appID)
+	if instances > app.Instances {
+		if err := b.sched.CheckResourceQuota(runAs, appID, version.AllResource(), instances); err != nil {
+			blog.Warn("scale application(%s.%s) to instances(%d) fail: %s", runAs, appID, instances, err.Error())
+			return err
+		}
+	}
+
 	version.Instances = int32(instances)
 	err = b.store.SaveVersion(version)
 	if err != nil {
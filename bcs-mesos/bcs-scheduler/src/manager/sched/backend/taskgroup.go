@@ -20,7 +20,9 @@ import (
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	//"sort"
 )
 
@@ -214,3 +216,43 @@ func (b *backend) ReloadTaskGroup(taskGroupID string) (*types.BcsMessage, error)
 
 	return b.sched.SendBcsMessage(taskGroup, bcsMsg)
 }
+
+// FetchTaskGroupLogs fetch logs of container in taskGroup from executor.
+// containerName can be empty when there is only one container in taskGroup.
+func (b *backend) FetchTaskGroupLogs(taskGroupID, containerName string, request *types.RequestLogsTask) (*types.ResponseLogsTask, error) {
+	blog.V(3).Infof("to fetch logs of taskgroup(%s) container(%s)", taskGroupID, containerName)
+
+	taskGroup, err := b.store.FetchTaskGroup(taskGroupID)
+	if err != nil {
+		blog.Errorf("fetch logs of taskgroup(%s), fetch taskGroup error: %s", taskGroupID, err.Error())
+		return nil, err
+	}
+
+	if taskGroup.Kind == commonTypes.BcsDataType_PROCESS {
+		blog.Errorf("fetch logs of taskgroup(%s), type is process, logs only for container", taskGroupID)
+		return nil, errors.New("taskgroup type is not container")
+	}
+
+	var task *types.Task
+	if containerName == "" {
+		if len(taskGroup.Taskgroup) != 1 {
+			return nil, fmt.Errorf("taskgroup has %d containers, container name must be specified", len(taskGroup.Taskgroup))
+		}
+		task = taskGroup.Taskgroup[0]
+	} else {
+		var names []string
+		for _, t := range taskGroup.Taskgroup {
+			if t.ContainerName == containerName || t.ID == containerName {
+				task = t
+				break
+			}
+			names = append(names, t.ContainerName)
+		}
+		if task == nil {
+			return nil, fmt.Errorf("container %s not found in taskgroup, containers: %s", containerName, strings.Join(names, ","))
+		}
+	}
+
+	request.TaskId = task.ID
+	return b.sched.FetchTaskLogs(taskGroup, request)
+}
@@ -681,6 +681,11 @@ func (p *offerPool) printOffer(offer *mesos.Offer) {
 }
 
 func (p *offerPool) addOfferAttributes(offer *mesos.Offer, agentSetting *commtype.BcsClusterAgentSetting) error {
+	return AddOfferAttributes(offer, agentSetting)
+}
+
+// AddOfferAttributes add the attributes of agentsetting into offer
+func AddOfferAttributes(offer *mesos.Offer, agentSetting *commtype.BcsClusterAgentSetting) error {
 
 	if agentSetting == nil {
 		return nil
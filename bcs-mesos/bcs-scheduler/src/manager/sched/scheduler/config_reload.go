@@ -0,0 +1,437 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// reload of configmaps & secrets in running taskgroups
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-common/common/secretbackend"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"net/http"
+	"time"
+)
+
+// Max seconds to wait for the taskgroups of application running again after one of them restarted
+const CONFIG_RELOAD_RESTART_TIMEOUT = 600
+
+// Interval seconds to check taskgroups status when restarting for config reload
+const CONFIG_RELOAD_CHECK_INTERVAL = 5
+
+// The killed taskgroup is rescheduled after 5 seconds, the same as restarting taskgroup by api,
+// so the ports and volumes are released before relaunching
+const CONFIG_RELOAD_RESCHEDULE_DELAYTIME = 5
+
+// ReloadConfigMap reload the changed items of configmap in the running taskgroups referencing it,
+// what to do is decided by the ConfigReload policy of application version
+func (s *Scheduler) ReloadConfigMap(old, cur *commtypes.BcsConfigMap) {
+	keys := changedConfigMapKeys(old, cur)
+	if len(keys) == 0 {
+		blog.Info("configmap(%s.%s) content not changed, no need to reload", cur.NameSpace, cur.Name)
+		return
+	}
+	s.reloadConfig(cur.NameSpace, "configmap", cur.Name, func(version *types.Version) ([]*types.Msg_ReloadConfig, bool) {
+		return configMapReloads(version, cur, keys)
+	})
+}
+
+// ReloadSecret reload the changed items of secret in the running taskgroups referencing it
+func (s *Scheduler) ReloadSecret(old, cur *commtypes.BcsSecret) {
+	keys := changedSecretKeys(old, cur)
+	if len(keys) == 0 {
+		blog.Info("secret(%s.%s) content not changed, no need to reload", cur.NameSpace, cur.Name)
+		return
+	}
+	s.reloadConfig(cur.NameSpace, "secret", cur.Name, func(version *types.Version) ([]*types.Msg_ReloadConfig, bool) {
+		return secretReloads(version, cur, keys)
+	})
+}
+
+// reloadConfig apply the reloads of configmap or secret to every application in namespace,
+// reloads returns the files to reload for each container of version, and whether env items changed
+func (s *Scheduler) reloadConfig(ns, kind, name string,
+	reloads func(version *types.Version) ([]*types.Msg_ReloadConfig, bool)) {
+
+	apps, err := s.store.ListApplications(ns)
+	if err != nil {
+		blog.Error("reload %s(%s.%s), list applications err:%s", kind, ns, name, err.Error())
+		return
+	}
+	for _, app := range apps {
+		if app.Kind == commtypes.BcsDataType_PROCESS {
+			continue
+		}
+		version, _ := s.store.GetVersion(ns, app.ID)
+		if version == nil {
+			blog.Warn("reload %s(%s.%s), no version for application(%s.%s)", kind, ns, name, ns, app.ID)
+			continue
+		}
+		msgs, envChanged := reloads(version)
+		if !hasConfigReload(msgs) && !envChanged {
+			continue
+		}
+
+		policy := commtypes.ConfigReloadPolicy_NONE
+		if version.ConfigReload != nil && version.ConfigReload.Policy != "" {
+			policy = version.ConfigReload.Policy
+		}
+		blog.Info("reload %s(%s.%s) for application(%s.%s) with policy %s", kind, ns, name, ns, app.ID, policy)
+		switch policy {
+		case commtypes.ConfigReloadPolicy_FILE, commtypes.ConfigReloadPolicy_FILESIGNAL:
+			if envChanged {
+				blog.Warn("reload %s(%s.%s), env items changed, application(%s.%s) need restart to take effect",
+					kind, ns, name, ns, app.ID)
+			}
+			if !hasConfigReload(msgs) {
+				continue
+			}
+			if policy == commtypes.ConfigReloadPolicy_FILESIGNAL {
+				for _, msg := range msgs {
+					if msg != nil {
+						msg.Signal = version.ConfigReload.Signal
+						msg.Command = version.ConfigReload.Command
+					}
+				}
+			}
+			s.sendConfigReloads(ns, app.ID, msgs)
+		case commtypes.ConfigReloadPolicy_RESTART:
+			go s.restartTaskGroupsForConfig(ns, app.ID)
+		default:
+			blog.Info("reload %s(%s.%s), application(%s.%s) takes effect after taskgroups restarted",
+				kind, ns, name, ns, app.ID)
+		}
+	}
+}
+
+// sendConfigReloads send reload message to every task of running taskgroups,
+// msgs is indexed by the containers of version, same as the tasks of taskgroup
+func (s *Scheduler) sendConfigReloads(runAs, appID string, msgs []*types.Msg_ReloadConfig) {
+	s.store.LockApplication(runAs + "." + appID)
+	defer s.store.UnLockApplication(runAs + "." + appID)
+
+	taskGroups, err := s.store.ListTaskGroups(runAs, appID)
+	if err != nil {
+		blog.Error("reload config, list taskgroups of application(%s.%s) err:%s", runAs, appID, err.Error())
+		return
+	}
+	for _, taskGroup := range taskGroups {
+		if taskGroup.Status != types.TASKGROUP_STATUS_RUNNING {
+			blog.Info("reload config, taskgroup(%s) status %s, skip it", taskGroup.ID, taskGroup.Status)
+			continue
+		}
+		if len(taskGroup.Taskgroup) != len(msgs) {
+			blog.Warn("reload config, taskgroup(%s) has %d tasks but version has %d containers, skip it",
+				taskGroup.ID, len(taskGroup.Taskgroup), len(msgs))
+			continue
+		}
+		for i, task := range taskGroup.Taskgroup {
+			if msgs[i] == nil {
+				continue
+			}
+			bcsMsg := &types.BcsMessage{
+				Type:         types.Msg_RELOAD_CONFIG.Enum(),
+				TaskID:       &mesos.TaskID{Value: proto.String(task.ID)},
+				ReloadConfig: msgs[i],
+			}
+			if _, err := s.SendBcsMessage(taskGroup, bcsMsg); err != nil {
+				blog.Error("reload config, send message to task(%s) err:%s", task.ID, err.Error())
+				continue
+			}
+			blog.Info("reload config, send %d files %d remotes to task(%s)",
+				len(msgs[i].Files), len(msgs[i].Remotes), task.ID)
+		}
+	}
+}
+
+// restartTaskGroupsForConfig reschedule the running taskgroups of application one by one,
+// the next one is restarted only after all taskgroups are running again
+func (s *Scheduler) restartTaskGroupsForConfig(runAs, appID string) {
+	taskGroups, err := s.listTaskGroupsForConfig(runAs, appID)
+	if err != nil {
+		blog.Error("reload config, list taskgroups of application(%s.%s) err:%s", runAs, appID, err.Error())
+		return
+	}
+	for _, taskGroup := range taskGroups {
+		if taskGroup.Status != types.TASKGROUP_STATUS_RUNNING {
+			continue
+		}
+		trans, err := s.rescheduleTaskGroupForConfig(taskGroup.ID)
+		if err != nil {
+			blog.Error("reload config, restart taskgroup(%s) err:%s, stop restarting application(%s.%s)",
+				taskGroup.ID, err.Error(), runAs, appID)
+			return
+		}
+		s.RunRescheduleTaskgroup(trans)
+		if trans.Status != types.OPERATION_STATUS_FINISH {
+			blog.Error("reload config, reschedule taskgroup(%s) status %s, stop restarting application(%s.%s)",
+				taskGroup.ID, trans.Status, runAs, appID)
+			return
+		}
+		if !s.waitTaskGroupsRunning(runAs, appID) {
+			blog.Error("reload config, taskgroups of application(%s.%s) not running in %d seconds, stop restarting",
+				runAs, appID, CONFIG_RELOAD_RESTART_TIMEOUT)
+			return
+		}
+	}
+	blog.Info("reload config, application(%s.%s) restart finish", runAs, appID)
+}
+
+// rescheduleTaskGroupForConfig kill the taskgroup and create the reschedule transaction for it
+func (s *Scheduler) rescheduleTaskGroupForConfig(taskGroupID string) (*Transaction, error) {
+	runAs, appID := store.GetRunAsAndAppIDbyTaskGroupID(taskGroupID)
+	s.store.LockApplication(runAs + "." + appID)
+	defer s.store.UnLockApplication(runAs + "." + appID)
+
+	app, err := s.store.FetchApplication(runAs, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app.Status == types.APP_STATUS_OPERATING || app.Status == types.APP_STATUS_ROLLINGUPDATE {
+		return nil, fmt.Errorf("application is in status %s", app.Status)
+	}
+	version, _ := s.store.GetVersion(runAs, appID)
+	if version == nil {
+		return nil, fmt.Errorf("application version not exist")
+	}
+	taskGroup, err := s.store.FetchTaskGroup(taskGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if taskGroup.Status != types.TASKGROUP_STATUS_RUNNING {
+		return nil, fmt.Errorf("taskgroup is in status %s", taskGroup.Status)
+	}
+
+	blog.Info("reload config, kill taskgroup(%s) to restart it", taskGroup.ID)
+	resp, err := s.KillTaskGroup(taskGroup)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("kill taskgroup return code %d", resp.StatusCode)
+	}
+
+	rescheduleTrans := CreateTransaction()
+	rescheduleTrans.RunAs = runAs
+	rescheduleTrans.AppID = appID
+	rescheduleTrans.OpType = types.OPERATION_RESCHEDULE
+	rescheduleTrans.Status = types.OPERATION_STATUS_INIT
+	rescheduleTrans.DelayTime = CONFIG_RELOAD_RESCHEDULE_DELAYTIME
+
+	var rescheduleOpdata TransRescheduleOpData
+	rescheduleOpdata.TaskGroupID = taskGroup.ID
+	rescheduleOpdata.Force = true
+	rescheduleOpdata.IsInner = false
+	if taskGroup.RestartPolicy != nil && taskGroup.RestartPolicy.HostRetainTime > 0 {
+		rescheduleOpdata.HostRetainTime = taskGroup.RestartPolicy.HostRetainTime
+		rescheduleOpdata.HostRetain = taskGroup.HostName
+	}
+	// taskgroup of daemonset can only run on its own host
+	if app.Kind == commtypes.BcsDataType_DAEMONSET {
+		rescheduleTrans.LifePeriod = TRANSACTION_DAEMONSET_LAUNCH_LIFEPERIOD
+		rescheduleOpdata.HostRetainTime = TRANSACTION_DAEMONSET_LAUNCH_LIFEPERIOD
+		rescheduleOpdata.HostRetain = taskGroup.HostName
+	}
+	rescheduleOpdata.NeedResource = version.AllResource()
+	rescheduleOpdata.Version = version
+	rescheduleTrans.OpData = &rescheduleOpdata
+
+	return rescheduleTrans, nil
+}
+
+// waitTaskGroupsRunning wait until all taskgroups of application are running
+func (s *Scheduler) waitTaskGroupsRunning(runAs, appID string) bool {
+	deadline := time.Now().Add(CONFIG_RELOAD_RESTART_TIMEOUT * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(CONFIG_RELOAD_CHECK_INTERVAL * time.Second)
+		taskGroups, err := s.listTaskGroupsForConfig(runAs, appID)
+		if err != nil {
+			blog.Warn("reload config, list taskgroups of application(%s.%s) err:%s", runAs, appID, err.Error())
+			continue
+		}
+		running := true
+		for _, taskGroup := range taskGroups {
+			if taskGroup.Status != types.TASKGROUP_STATUS_RUNNING {
+				running = false
+				break
+			}
+		}
+		if running {
+			return true
+		}
+	}
+	return false
+}
+
+// listTaskGroupsForConfig list the taskgroups of application under the application lock,
+// the lock is not held while restarting, which reschedules the taskgroup with the lock itself
+func (s *Scheduler) listTaskGroupsForConfig(runAs, appID string) ([]*types.TaskGroup, error) {
+	s.store.LockApplication(runAs + "." + appID)
+	defer s.store.UnLockApplication(runAs + "." + appID)
+
+	return s.store.ListTaskGroups(runAs, appID)
+}
+
+func hasConfigReload(msgs []*types.Msg_ReloadConfig) bool {
+	for _, msg := range msgs {
+		if msg != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// changedConfigMapKeys the keys added or modified in cur
+func changedConfigMapKeys(old, cur *commtypes.BcsConfigMap) map[string]bool {
+	keys := make(map[string]bool)
+	for key, item := range cur.Data {
+		if old == nil {
+			keys[key] = true
+			continue
+		}
+		if oldItem, ok := old.Data[key]; !ok || oldItem != item {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// changedSecretKeys the keys added or modified in cur
+func changedSecretKeys(old, cur *commtypes.BcsSecret) map[string]bool {
+	keys := make(map[string]bool)
+	for key, item := range cur.Data {
+		if old == nil {
+			keys[key] = true
+			continue
+		}
+		if oldItem, ok := old.Data[key]; !ok || !secretbackend.Default().Equal(&oldItem, &item) {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// configMapReloads build the reload message for each container referencing the changed keys of configmap,
+// the files are the same as createTaskConfigMaps does when taskgroup launched
+func configMapReloads(version *types.Version, cm *commtypes.BcsConfigMap, keys map[string]bool) ([]*types.Msg_ReloadConfig, bool) {
+	msgs := make([]*types.Msg_ReloadConfig, len(version.Container))
+	envChanged := false
+	for i, container := range version.Container {
+		for _, configMap := range container.ConfigMaps {
+			if configMap.Name != cm.Name {
+				continue
+			}
+			for _, confItem := range configMap.Items {
+				if !keys[confItem.DataKey] {
+					continue
+				}
+				if confItem.Type == commtypes.DataUsageType_ENV {
+					envChanged = true
+					continue
+				}
+				if confItem.Type != commtypes.DataUsageType_FILE {
+					continue
+				}
+				right := "rw"
+				if confItem.ReadOnly {
+					right = "r"
+				}
+				if msgs[i] == nil {
+					msgs[i] = new(types.Msg_ReloadConfig)
+				}
+				bcsConfigItem := cm.Data[confItem.DataKey]
+				if bcsConfigItem.Type == commtypes.BcsConfigMapSourceType_FILE {
+					msgs[i].Files = append(msgs[i].Files, &types.Msg_LocalFile{
+						To:     proto.String(confItem.KeyOrPath),
+						Right:  proto.String(right),
+						User:   proto.String(confItem.User),
+						Base64: proto.String(bcsConfigItem.Content),
+					})
+				} else {
+					msgs[i].Remotes = append(msgs[i].Remotes, &types.Msg_Remote{
+						To:           proto.String(confItem.KeyOrPath),
+						Right:        proto.String(right),
+						User:         proto.String(confItem.User),
+						From:         proto.String(bcsConfigItem.Content),
+						Type:         proto.String(string(bcsConfigItem.Type)),
+						RemoteUser:   proto.String(bcsConfigItem.RemoteUser),
+						RemotePasswd: proto.String(bcsConfigItem.RemotePasswd),
+					})
+				}
+			}
+		}
+	}
+	return msgs, envChanged
+}
+
+// secretReloads build the reload message for each container referencing the changed keys of secret,
+// secret files are written by root and readonly, same as the executor does when taskgroup launched
+func secretReloads(version *types.Version, bcsSecret *commtypes.BcsSecret, keys map[string]bool) ([]*types.Msg_ReloadConfig, bool) {
+	msgs := make([]*types.Msg_ReloadConfig, len(version.Container))
+	envChanged := false
+	for i, container := range version.Container {
+		for _, secret := range container.Secrets {
+			if secret.SecretName != bcsSecret.Name {
+				continue
+			}
+			for _, secretItem := range secret.Items {
+				if !keys[secretItem.DataKey] {
+					continue
+				}
+				if secretItem.Type == commtypes.DataUsageType_ENV {
+					envChanged = true
+					continue
+				}
+				if secretItem.Type != commtypes.DataUsageType_FILE {
+					continue
+				}
+				bcsSecretItem := bcsSecret.Data[secretItem.DataKey]
+				content, err := secretbackend.Default().Resolve(&bcsSecretItem)
+				if err != nil {
+					blog.Error("resolve secret(%s.%s) item(key:%s) for reload err: %s",
+						bcsSecret.NameSpace, bcsSecret.Name, secretItem.DataKey, err.Error())
+					continue
+				}
+				if msgs[i] == nil {
+					msgs[i] = new(types.Msg_ReloadConfig)
+				}
+				msgs[i].Files = append(msgs[i].Files, &types.Msg_LocalFile{
+					To:     proto.String(secretItem.KeyOrPath),
+					Right:  proto.String("r"),
+					User:   proto.String("root"),
+					Base64: proto.String(content),
+				})
+			}
+		}
+	}
+	return msgs, envChanged
+}
+
+// stripReloadConfigContent copy the reload message without the content of files
+func stripReloadConfigContent(bcsMsg *types.BcsMessage) *types.BcsMessage {
+	msg := *bcsMsg
+	reload := *bcsMsg.ReloadConfig
+	reload.Files = make([]*types.Msg_LocalFile, 0, len(bcsMsg.ReloadConfig.Files))
+	for _, file := range bcsMsg.ReloadConfig.Files {
+		stripped := *file
+		stripped.Base64 = nil
+		reload.Files = append(reload.Files, &stripped)
+	}
+	msg.ReloadConfig = &reload
+	return &msg
+}
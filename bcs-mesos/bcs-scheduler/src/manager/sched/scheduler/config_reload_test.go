@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedConfigMapKeys(t *testing.T) {
+	old := &commtypes.BcsConfigMap{Data: map[string]commtypes.BcsConfigMapItem{
+		"a": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "YQ=="},
+		"b": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "Yg=="},
+		"c": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "Yw=="},
+	}}
+	cur := &commtypes.BcsConfigMap{Data: map[string]commtypes.BcsConfigMapItem{
+		"a": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "YQ=="},
+		"b": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "YmI="},
+		"d": {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "ZA=="},
+	}}
+	assert.Equal(t, map[string]bool{"b": true, "d": true}, changedConfigMapKeys(old, cur))
+	assert.Equal(t, 0, len(changedConfigMapKeys(cur, cur)))
+	assert.Equal(t, 3, len(changedConfigMapKeys(nil, cur)))
+}
+
+func TestChangedSecretKeys(t *testing.T) {
+	old := &commtypes.BcsSecret{Data: map[string]commtypes.SecretDataItem{"user": {Content: "YQ=="}, "passwd": {Content: "Yg=="}}}
+	cur := &commtypes.BcsSecret{Data: map[string]commtypes.SecretDataItem{"user": {Content: "YQ=="}, "passwd": {Content: "Yw=="}}}
+	assert.Equal(t, map[string]bool{"passwd": true}, changedSecretKeys(old, cur))
+
+	//references of backend are compared by value
+	old.Data["vault"] = commtypes.SecretDataItem{Backend: &commtypes.SecretBackend{Type: commtypes.SecretBackendVault, Path: "secret/app", Key: "a"}}
+	cur.Data["vault"] = commtypes.SecretDataItem{Backend: &commtypes.SecretBackend{Type: commtypes.SecretBackendVault, Path: "secret/app", Key: "a"}}
+	assert.Equal(t, map[string]bool{"passwd": true}, changedSecretKeys(old, cur))
+	cur.Data["vault"].Backend.Key = "b"
+	assert.Equal(t, map[string]bool{"passwd": true, "vault": true}, changedSecretKeys(old, cur))
+}
+
+func TestStripReloadConfigContent(t *testing.T) {
+	msg := &types.BcsMessage{ReloadConfig: &types.Msg_ReloadConfig{
+		Files: []*types.Msg_LocalFile{{To: proto.String("/etc/passwd"), Base64: proto.String("cGFzc3dk")}},
+	}}
+	stripped := stripReloadConfigContent(msg)
+	assert.Nil(t, stripped.ReloadConfig.Files[0].Base64)
+	assert.Equal(t, "/etc/passwd", *stripped.ReloadConfig.Files[0].To)
+	assert.Equal(t, "cGFzc3dk", *msg.ReloadConfig.Files[0].Base64)
+}
+
+func TestConfigMapReloads(t *testing.T) {
+	cm := &commtypes.BcsConfigMap{Data: map[string]commtypes.BcsConfigMapItem{
+		"conf":   {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "Y29uZg=="},
+		"remote": {Type: commtypes.BcsConfigMapSourceType_HTTP, Content: "http://repo/conf"},
+		"env":    {Type: commtypes.BcsConfigMapSourceType_FILE, Content: "value"},
+	}}
+	cm.Name = "app-conf"
+	version := &types.Version{Container: []*types.Container{
+		{ConfigMaps: []commtypes.ConfigMap{{Name: "app-conf", Items: []commtypes.KeyToPath{
+			{Type: commtypes.DataUsageType_FILE, DataKey: "conf", KeyOrPath: "/etc/app.conf", ReadOnly: true, User: "app"},
+			{Type: commtypes.DataUsageType_FILE, DataKey: "remote", KeyOrPath: "/etc/remote.conf"},
+		}}}},
+		{ConfigMaps: []commtypes.ConfigMap{{Name: "other-conf", Items: []commtypes.KeyToPath{
+			{Type: commtypes.DataUsageType_FILE, DataKey: "conf", KeyOrPath: "/etc/app.conf"},
+		}}}},
+		{ConfigMaps: []commtypes.ConfigMap{{Name: "app-conf", Items: []commtypes.KeyToPath{
+			{Type: commtypes.DataUsageType_ENV, DataKey: "env", KeyOrPath: "APP_ENV"},
+		}}}},
+	}}
+
+	msgs, envChanged := configMapReloads(version, cm, map[string]bool{"conf": true, "remote": true})
+	assert.False(t, envChanged)
+	assert.Equal(t, 3, len(msgs))
+	assert.Equal(t, 1, len(msgs[0].Files))
+	assert.Equal(t, "/etc/app.conf", *msgs[0].Files[0].To)
+	assert.Equal(t, "r", *msgs[0].Files[0].Right)
+	assert.Equal(t, "app", *msgs[0].Files[0].User)
+	assert.Equal(t, "Y29uZg==", *msgs[0].Files[0].Base64)
+	assert.Equal(t, 1, len(msgs[0].Remotes))
+	assert.Equal(t, "http://repo/conf", *msgs[0].Remotes[0].From)
+	assert.Nil(t, msgs[1])
+	assert.Nil(t, msgs[2])
+	assert.True(t, hasConfigReload(msgs))
+
+	msgs, envChanged = configMapReloads(version, cm, map[string]bool{"env": true})
+	assert.True(t, envChanged)
+	assert.False(t, hasConfigReload(msgs))
+}
+
+func TestSecretReloads(t *testing.T) {
+	secret := &commtypes.BcsSecret{Data: map[string]commtypes.SecretDataItem{"passwd": {Content: "cGFzc3dk"}}}
+	secret.Name = "app-secret"
+	version := &types.Version{Container: []*types.Container{
+		{Secrets: []commtypes.Secret{{SecretName: "app-secret", Items: []commtypes.SecretItem{
+			{Type: commtypes.DataUsageType_FILE, DataKey: "passwd", KeyOrPath: "/etc/passwd.txt"},
+			{Type: commtypes.DataUsageType_ENV, DataKey: "passwd", KeyOrPath: "PASSWD"},
+		}}}},
+	}}
+
+	msgs, envChanged := secretReloads(version, secret, map[string]bool{"passwd": true})
+	assert.True(t, envChanged)
+	assert.Equal(t, 1, len(msgs[0].Files))
+	assert.Equal(t, "/etc/passwd.txt", *msgs[0].Files[0].To)
+	assert.Equal(t, "root", *msgs[0].Files[0].User)
+	assert.Equal(t, "r", *msgs[0].Files[0].Right)
+	assert.Equal(t, "cGFzc3dk", *msgs[0].Files[0].Base64)
+}
@@ -0,0 +1,472 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// daemonset controller, keep one taskgroup on every enabled agent
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/strategy"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/task"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/samuel/go-zookeeper/zk"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Interval seconds to check daemonsets with the agents in cluster
+const DAEMONSET_CHECK_INTERVAL = 10
+
+func (s *Scheduler) startCheckDaemonsets() {
+	time.Sleep(60 * time.Second)
+	blog.Info("check daemonsets begin")
+
+	for {
+		if s.Role != "master" {
+			blog.Warn("check daemonsets exit, because scheduler is not master now")
+			return
+		}
+		s.checkDaemonsets()
+		time.Sleep(DAEMONSET_CHECK_INTERVAL * time.Second)
+	}
+}
+
+func (s *Scheduler) checkDaemonsets() {
+	daemonsets, err := s.store.ListAllDaemonsets()
+	if err != nil {
+		blog.Error("check daemonsets: list daemonsets err:%s", err.Error())
+		return
+	}
+	if len(daemonsets) == 0 {
+		return
+	}
+
+	agents, err := s.getDaemonsetAgents()
+	if err != nil {
+		blog.Error("check daemonsets: get agents err:%s", err.Error())
+		return
+	}
+
+	for _, daemonset := range daemonsets {
+		s.daemonsetCheckTick(daemonset.ObjectMeta.NameSpace, daemonset.ObjectMeta.Name, agents)
+	}
+}
+
+// getDaemonsetAgents return the agents which can run daemonset taskgroups by hostname, the agents disabled
+// by agentsettings are excluded. Each agent is described as an offer with the attributes of agent and
+// agentsetting, so it can be checked by the constraints of daemonset like the offers
+func (s *Scheduler) getDaemonsetAgents() (map[string]*mesos.Offer, error) {
+	agents, err := s.store.ListAllAgents()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]*mesos.Offer)
+	for _, agent := range agents {
+		if agent.AgentInfo == nil {
+			continue
+		}
+		info := agent.GetAgentInfo()
+		if info.HostName == "" {
+			continue
+		}
+		setting, err := s.store.FetchAgentSetting(info.IP)
+		if err != nil {
+			blog.Warn("check daemonsets: fetch agentsetting(%s) err:%s, pass it", info.IP, err.Error())
+			continue
+		}
+		if setting != nil && setting.Disabled {
+			blog.V(3).Infof("check daemonsets: agent(%s:%s) is disabled", info.HostName, info.IP)
+			continue
+		}
+		agentOffer := &mesos.Offer{
+			Hostname:   proto.String(info.HostName),
+			Attributes: append([]*mesos.Attribute{}, agent.AgentInfo.GetAgentInfo().GetAttributes()...),
+		}
+		offer.AddOfferAttributes(agentOffer, setting)
+		hosts[info.HostName] = agentOffer
+	}
+
+	return hosts, nil
+}
+
+// daemonsetHosts return the hostnames of the agents fit the constraints of daemonset
+func daemonsetHosts(version *types.Version, agents map[string]*mesos.Offer) map[string]bool {
+	hosts := make(map[string]bool)
+	for host, agentOffer := range agents {
+		if strategy.AttributeConstraintsFit(version, agentOffer) {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// diffDaemonsetHosts compare the taskgroups of daemonset with the hosts,
+// return the hosts without taskgroup and the taskgroups which should be removed.
+// The taskgroups on hosts not in the list, or more than one on the same host, should be removed.
+func diffDaemonsetHosts(hosts map[string]bool, taskGroups []*types.TaskGroup) ([]string, []*types.TaskGroup) {
+	covered := make(map[string]bool)
+	var removes []*types.TaskGroup
+	for _, taskGroup := range taskGroups {
+		if taskGroup.HostName == "" {
+			continue
+		}
+		if !hosts[taskGroup.HostName] || covered[taskGroup.HostName] {
+			removes = append(removes, taskGroup)
+			continue
+		}
+		covered[taskGroup.HostName] = true
+	}
+
+	var launches []string
+	for host := range hosts {
+		if !covered[host] {
+			launches = append(launches, host)
+		}
+	}
+	sort.Strings(launches)
+
+	return launches, removes
+}
+
+// daemonsetFreeInstance return the smallest instance index not used by the taskgroups,
+// so the index of a removed taskgroup can be used by the new one
+func daemonsetFreeInstance(taskGroups []*types.TaskGroup) uint64 {
+	used := make(map[uint64]bool)
+	for _, taskGroup := range taskGroups {
+		used[taskGroup.InstanceID] = true
+	}
+
+	var index uint64
+	for used[index] {
+		index++
+	}
+	return index
+}
+
+func (s *Scheduler) daemonsetCheckTick(ns string, name string, agents map[string]*mesos.Offer) {
+	blog.V(3).Infof("check daemonset(%s.%s)", ns, name)
+
+	s.store.LockApplication(ns + "." + name)
+	defer s.store.UnLockApplication(ns + "." + name)
+
+	daemonset, err := s.store.FetchDaemonset(ns, name)
+	if err != nil {
+		blog.Warn("check daemonset(%s.%s), fetch daemonset err:%s", ns, name, err.Error())
+		return
+	}
+
+	app, err := s.store.FetchApplication(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Warn("check daemonset(%s.%s), fetch application err:%s", ns, name, err.Error())
+		return
+	}
+
+	if daemonset.Status == types.DAEMONSET_STATUS_DELETING {
+		if app != nil {
+			blog.Info("check daemonset(%s.%s), waiting application to be deleted", ns, name)
+			return
+		}
+		blog.Info("check daemonset(%s.%s), application deleted, delete daemonset", ns, name)
+		if err = s.store.DeleteDaemonset(ns, name); err != nil {
+			blog.Error("check daemonset(%s.%s), delete daemonset err:%s", ns, name, err.Error())
+		}
+		return
+	}
+
+	if app == nil {
+		blog.Warn("check daemonset(%s.%s), application not exist", ns, name)
+		return
+	}
+	if app.Status == types.APP_STATUS_OPERATING {
+		blog.V(3).Infof("check daemonset(%s.%s), application is operating now", ns, name)
+		return
+	}
+
+	version, _ := s.store.GetVersion(ns, name)
+	if version == nil {
+		blog.Error("check daemonset(%s.%s), no version for application", ns, name)
+		return
+	}
+
+	taskGroups, err := s.store.ListTaskGroups(ns, name)
+	if err != nil {
+		blog.Error("check daemonset(%s.%s), list taskgroups err:%s", ns, name, err.Error())
+		return
+	}
+
+	hosts := daemonsetHosts(version, agents)
+	launches, removes := diffDaemonsetHosts(hosts, taskGroups)
+
+	removed := make(map[string]bool)
+	for _, taskGroup := range removes {
+		if taskGroup.Status != types.TASKGROUP_STATUS_LOST && !task.IsTaskGroupEnd(taskGroup) {
+			if task.CanTaskGroupShutdown(taskGroup) {
+				blog.Info("check daemonset(%s.%s), host(%s) is not available, kill taskgroup(%s)",
+					ns, name, taskGroup.HostName, taskGroup.ID)
+				s.KillTaskGroup(taskGroup)
+			}
+			continue
+		}
+		blog.Info("check daemonset(%s.%s), host(%s) is not available, delete taskgroup(%s)",
+			ns, name, taskGroup.HostName, taskGroup.ID)
+		if app.Instances > 0 {
+			app.Instances--
+		}
+		if app.DefineInstances > 0 {
+			app.DefineInstances--
+		}
+		if err = s.DeleteTaskGroup(app, taskGroup, "daemonset host not available"); err != nil {
+			blog.Error("check daemonset(%s.%s), delete taskgroup(%s) err:%s", ns, name, taskGroup.ID, err.Error())
+		}
+		removed[taskGroup.ID] = true
+	}
+	if len(removed) > 0 {
+		if err = s.store.SaveApplication(app); err != nil {
+			blog.Error("check daemonset(%s.%s), save application err:%s", ns, name, err.Error())
+		}
+	}
+
+	launching := 0
+	for _, host := range launches {
+		if !s.launchDaemonsetTaskGroup(version, host) {
+			launching++
+		}
+	}
+
+	daemonset.Pods = make(map[string]string)
+	for _, taskGroup := range taskGroups {
+		if removed[taskGroup.ID] || taskGroup.HostName == "" {
+			continue
+		}
+		daemonset.Pods[taskGroup.HostName] = taskGroup.ID
+	}
+	daemonset.Message = fmt.Sprintf("%d hosts available, %d taskgroups, %d hosts launching, %d hosts waiting to launch",
+		len(hosts), len(daemonset.Pods), launching, len(launches)-launching)
+	daemonset.CheckTime = time.Now().Unix()
+	if err = s.store.SaveDaemonset(daemonset); err != nil {
+		blog.Error("check daemonset(%s.%s), save daemonset err:%s", ns, name, err.Error())
+	}
+}
+
+// launchDaemonsetTaskGroup create a transaction to launch taskgroup on the host,
+// do nothing and return false if there is already one launching on the host
+func (s *Scheduler) launchDaemonsetTaskGroup(version *types.Version, host string) bool {
+	key := version.RunAs + "." + version.ID + "." + host
+
+	s.daemonsetLaunchLock.Lock()
+	defer s.daemonsetLaunchLock.Unlock()
+
+	if transID, ok := s.daemonsetLaunching[key]; ok {
+		blog.V(3).Infof("daemonset(%s.%s) is launching on host(%s) by transaction %s",
+			version.RunAs, version.ID, host, transID)
+		return false
+	}
+
+	launchTrans := CreateTransaction()
+	launchTrans.RunAs = version.RunAs
+	launchTrans.AppID = version.ID
+	launchTrans.OpType = types.OPERATION_DAEMONSET
+	launchTrans.Status = types.OPERATION_STATUS_INIT
+	launchTrans.LifePeriod = TRANSACTION_DAEMONSET_LAUNCH_LIFEPERIOD
+
+	launchOpdata := &TransDaemonsetOpData{
+		Version:      version,
+		NeedResource: version.AllResource(),
+		HostName:     host,
+	}
+	launchTrans.OpData = launchOpdata
+
+	s.daemonsetLaunching[key] = launchTrans.ID
+	blog.Info("daemonset(%s.%s) create transaction %s to launch taskgroup on host(%s)",
+		version.RunAs, version.ID, launchTrans.ID, host)
+	go s.RunLaunchDaemonset(launchTrans)
+	return true
+}
+
+// The goroutine function for launching a daemonset taskgroup on the specified host
+func (s *Scheduler) RunLaunchDaemonset(transaction *Transaction) {
+
+	runAs := transaction.RunAs
+	appID := transaction.AppID
+	opData := transaction.OpData.(*TransDaemonsetOpData)
+
+	blog.Infof("transaction %s launch daemonset(%s.%s) on host(%s) run begin", transaction.ID, runAs, appID, opData.HostName)
+
+	started := time.Now()
+
+	for {
+		daemonset, _ := s.store.FetchDaemonset(runAs, appID)
+		if daemonset == nil || daemonset.Status == types.DAEMONSET_STATUS_DELETING {
+			blog.Info("transaction %s daemonset(%s.%s) not exist or in deleting, finish", transaction.ID, runAs, appID)
+			transaction.Status = types.OPERATION_STATUS_FINISH
+			goto run_end
+		}
+
+		version := opData.Version
+		s.addPendingTransaction(transaction, version, opData.NeedResource, "")
+
+		offerOut := s.GetFirstOffer()
+		for offerOut != nil {
+			offerIdx := offerOut.Id
+			offer := offerOut.Offer
+
+			curOffer := offerOut
+			offerOut = s.GetNextOffer(offerOut)
+			if offer.GetHostname() != opData.HostName {
+				continue
+			}
+			blog.V(3).Infof("transaction %s get offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
+
+			isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "") &&
+				!s.IsOfferReserved(transaction, version.Priority, curOffer)
+			if isFit == true {
+				if s.UseOffer(curOffer) == true {
+					blog.Info("transaction %s launch daemonset(%s.%s) use offer(%d) %s||%s",
+						transaction.ID, runAs, appID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
+					s.doLaunchDaemonsetTrans(transaction, curOffer, started)
+					if transaction.Status == types.OPERATION_STATUS_FINISH || transaction.Status == types.OPERATION_STATUS_FAIL {
+						goto run_end
+					}
+				} else {
+					blog.Info("transaction %s use offer(%d) %s||%s fail", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
+				}
+			}
+		}
+
+		//check timeout
+		if (transaction.CreateTime + transaction.LifePeriod) < time.Now().Unix() {
+			blog.Warn("transaction %s launch daemonset(%s.%s) on host(%s) timeout", transaction.ID, runAs, appID, opData.HostName)
+			transaction.Status = types.OPERATION_STATUS_TIMEOUT
+			goto run_end
+		}
+
+		time.Sleep(3 * time.Second)
+	}
+
+run_end:
+	s.deletePendingTransaction(transaction)
+	s.FinishTransaction(transaction)
+
+	s.daemonsetLaunchLock.Lock()
+	delete(s.daemonsetLaunching, runAs+"."+appID+"."+opData.HostName)
+	s.daemonsetLaunchLock.Unlock()
+
+	blog.Infof("transaction %s launch daemonset(%s.%s) on host(%s) run end, result(%s)",
+		transaction.ID, runAs, appID, opData.HostName, transaction.Status)
+}
+
+func (s *Scheduler) doLaunchDaemonsetTrans(trans *Transaction, outOffer *offer.Offer, started time.Time) {
+
+	offer := outOffer.Offer
+	opData := trans.OpData.(*TransDaemonsetOpData)
+	version := opData.Version
+	runAs := trans.RunAs
+	appID := trans.AppID
+
+	s.store.LockApplication(runAs + "." + appID)
+	defer s.store.UnLockApplication(runAs + "." + appID)
+
+	app, _ := s.store.FetchApplication(runAs, appID)
+	if app == nil {
+		blog.Error("transaction %s fail: fetch application(%s.%s) return nil", trans.ID, runAs, appID)
+		trans.Status = types.OPERATION_STATUS_FAIL
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+	if app.Status == types.APP_STATUS_OPERATING {
+		blog.Warn("transaction %s: application(%s.%s) status is %s", trans.ID, runAs, appID, app.Status)
+		trans.Status = types.OPERATION_STATUS_FINISH
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	taskGroups, err := s.store.ListTaskGroups(runAs, appID)
+	if err != nil {
+		blog.Error("transaction %s: list taskgroups(%s.%s) err:%s", trans.ID, runAs, appID, err.Error())
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+	for _, taskGroup := range taskGroups {
+		if taskGroup.HostName == opData.HostName {
+			blog.Info("transaction %s: taskgroup(%s) already on host(%s)", trans.ID, taskGroup.ID, opData.HostName)
+			trans.Status = types.OPERATION_STATUS_FINISH
+			s.DeclineResource(offer.Id.Value)
+			return
+		}
+	}
+
+	instances := uint64(len(taskGroups)) + 1
+	if err = s.CheckResourceQuota(runAs, appID, version.AllResource(), instances); err != nil {
+		//no offer can fit the quota, finish now rather than retry until timeout
+		blog.Warn("transaction %s %s(%s.%s) rejected: %s", trans.ID, trans.OpType, runAs, appID, err.Error())
+		trans.Status = types.OPERATION_STATUS_FAIL
+		trans.Message = "application " + trans.OpType + " rejected: " + err.Error()
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	index := daemonsetFreeInstance(taskGroups)
+	ID := fmt.Sprintf("%d.%s.%s.%s.%d", index, appID, runAs, app.ClusterId, time.Now().UnixNano())
+	taskGroup, err := s.BuildTaskGroup(version, app, ID, "launch daemonset")
+	if err != nil {
+		blog.Error("transaction %s: build taskgroup err: %s", trans.ID, err.Error())
+		trans.Status = types.OPERATION_STATUS_FAIL
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	resources := task.BuildResources(version.AllResource())
+	taskGroupInfo := task.CreateTaskGroupInfo(offer, version, resources, taskGroup)
+	if taskGroupInfo == nil {
+		blog.Warn("transaction %s: build taskgroupinfo fail", trans.ID)
+		s.DeleteTaskGroup(app, taskGroup, "create taskgroupinfo fail")
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	if err := s.store.SaveTaskGroup(taskGroup); err != nil {
+		blog.Error("transaction %s: save taskgroup error %s", trans.ID, err.Error())
+		s.DeleteTaskGroup(app, taskGroup, "save taskgroup fail")
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	resp, err := s.LaunchTaskGroup(offer, taskGroupInfo, version)
+	if err != nil || (resp != nil && resp.StatusCode != http.StatusAccepted) {
+		blog.Error("transaction %s: launch taskgroup(%s) on host(%s) fail", trans.ID, taskGroup.ID, opData.HostName)
+		s.DeleteTaskGroup(app, taskGroup, "launch taskgroup fail")
+		s.store.SaveApplication(app)
+		trans.Status = types.OPERATION_STATUS_FAIL
+		s.DeclineResource(offer.Id.Value)
+		return
+	}
+
+	reportScheduleTaskgroupMetrics(app.RunAs, app.Name, taskGroup.Name, LaunchTaskgroupType, started)
+
+	app.Instances = instances
+	app.DefineInstances = instances
+	app.UpdateTime = time.Now().Unix()
+	if err = s.store.SaveApplication(app); err != nil {
+		blog.Error("transaction %s: save application(%s.%s) err:%s", trans.ID, runAs, appID, err.Error())
+	}
+
+	blog.Info("transaction %s launch taskgroup(%s) on host(%s) finish", trans.ID, taskGroup.ID, opData.HostName)
+	trans.Status = types.OPERATION_STATUS_FINISH
+}
@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	mesos_master "bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos/master"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDaemonsetTaskGroup(id string, instance uint64, host string) *types.TaskGroup {
+	return &types.TaskGroup{
+		ID:         id,
+		InstanceID: instance,
+		HostName:   host,
+	}
+}
+
+func TestDiffDaemonsetHosts(t *testing.T) {
+	hosts := map[string]bool{"host-a": true, "host-b": true, "host-c": true}
+	taskGroups := []*types.TaskGroup{
+		newDaemonsetTaskGroup("tg-0", 0, "host-a"),
+		newDaemonsetTaskGroup("tg-1", 1, "host-d"),
+		newDaemonsetTaskGroup("tg-2", 2, "host-a"),
+		newDaemonsetTaskGroup("tg-3", 3, ""),
+	}
+
+	launches, removes := diffDaemonsetHosts(hosts, taskGroups)
+	assert.Equal(t, []string{"host-b", "host-c"}, launches)
+	if assert.Equal(t, 2, len(removes)) {
+		//taskgroup on removed host
+		assert.Equal(t, "tg-1", removes[0].ID)
+		//duplicated taskgroup on the same host
+		assert.Equal(t, "tg-2", removes[1].ID)
+	}
+
+	//no agents, all taskgroups are removed
+	launches, removes = diffDaemonsetHosts(map[string]bool{}, taskGroups[:2])
+	assert.Nil(t, launches)
+	assert.Equal(t, 2, len(removes))
+}
+
+func TestDaemonsetFreeInstance(t *testing.T) {
+	assert.Equal(t, uint64(0), daemonsetFreeInstance(nil))
+
+	taskGroups := []*types.TaskGroup{
+		newDaemonsetTaskGroup("tg-0", 0, "host-a"),
+		newDaemonsetTaskGroup("tg-2", 2, "host-b"),
+	}
+	assert.Equal(t, uint64(1), daemonsetFreeInstance(taskGroups))
+
+	taskGroups = append(taskGroups, newDaemonsetTaskGroup("tg-1", 1, "host-c"))
+	assert.Equal(t, uint64(3), daemonsetFreeInstance(taskGroups))
+}
+
+type appStore struct {
+	store.Store
+	app *types.Application
+}
+
+func (as *appStore) LockApplication(appID string) {}
+
+func (as *appStore) UnLockApplication(appID string) {}
+
+func (as *appStore) FetchApplication(ns, name string) (*types.Application, error) {
+	return as.app, nil
+}
+
+func (as *appStore) SaveApplication(app *types.Application) error {
+	as.app = app
+	return nil
+}
+
+func TestFinishDaemonsetTransaction(t *testing.T) {
+	as := &appStore{app: &types.Application{ID: "ds", RunAs: "ns"}}
+	s := &Scheduler{store: as}
+
+	trans := &Transaction{ID: "trans-0", RunAs: "ns", AppID: "ds", OpType: types.OPERATION_DAEMONSET,
+		Status: types.OPERATION_STATUS_FAIL}
+	s.FinishTransaction(trans)
+	assert.Equal(t, "application "+types.OPERATION_DAEMONSET+" "+types.OPERATION_STATUS_FAIL, as.app.Message)
+
+	//the quota rejection is kept in the application message
+	trans.Message = "application " + types.OPERATION_DAEMONSET + " rejected: quota exceeded"
+	s.FinishTransaction(trans)
+	assert.Equal(t, trans.Message, as.app.Message)
+}
+
+type agentStore struct {
+	store.Store
+	agents   []*types.Agent
+	settings map[string]*commtypes.BcsClusterAgentSetting
+}
+
+func (as *agentStore) ListAllAgents() ([]*types.Agent, error) {
+	return as.agents, nil
+}
+
+func (as *agentStore) FetchAgentSetting(ip string) (*commtypes.BcsClusterAgentSetting, error) {
+	return as.settings[ip], nil
+}
+
+func newDaemonsetAgent(host, ip, zone string) *types.Agent {
+	return &types.Agent{
+		Key: ip,
+		AgentInfo: &mesos_master.Response_GetAgents_Agent{
+			Pid: proto.String("slave(1)@" + ip + ":5051"),
+			AgentInfo: &mesos.AgentInfo{
+				Hostname: proto.String(host),
+				Attributes: []*mesos.Attribute{{
+					Name: proto.String("zone"),
+					Type: mesos.Value_TEXT.Enum(),
+					Text: &mesos.Value_Text{Value: proto.String(zone)},
+				}},
+			},
+		},
+	}
+}
+
+func TestDaemonsetHosts(t *testing.T) {
+	as := &agentStore{
+		agents: []*types.Agent{
+			newDaemonsetAgent("host-a", "10.0.0.1", "a"),
+			newDaemonsetAgent("host-b", "10.0.0.2", "b"),
+			newDaemonsetAgent("host-c", "10.0.0.3", "a"),
+			newDaemonsetAgent("host-d", "10.0.0.4", "a"),
+		},
+		settings: map[string]*commtypes.BcsClusterAgentSetting{
+			//attribute from agentsetting
+			"10.0.0.3": {AttrStrings: map[string]commtypes.MesosValue_Text{"gpu": {Value: "true"}}},
+			"10.0.0.4": {Disabled: true},
+		},
+	}
+	s := &Scheduler{store: as}
+
+	agents, err := s.getDaemonsetAgents()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(agents))
+
+	version := &types.Version{RunAs: "ns", ID: "ds"}
+	assert.Equal(t, map[string]bool{"host-a": true, "host-b": true, "host-c": true}, daemonsetHosts(version, agents))
+
+	version.Constraints = &commtypes.Constraint{IntersectionItem: []*commtypes.ConstraintDataItem{
+		{UnionData: []*commtypes.ConstraintData{{Name: "zone", Operate: commtypes.Constraint_Type_CLUSTER,
+			Type: commtypes.ConstValueType_Text, Text: &commtypes.ConstraintValue_Text{Value: "a"}}}},
+	}}
+	assert.Equal(t, map[string]bool{"host-a": true, "host-c": true}, daemonsetHosts(version, agents))
+
+	version.Constraints.IntersectionItem = append(version.Constraints.IntersectionItem, &commtypes.ConstraintDataItem{
+		UnionData: []*commtypes.ConstraintData{{Name: "gpu", Operate: commtypes.Constraint_Type_CLUSTER,
+			Type: commtypes.ConstValueType_Text, Text: &commtypes.ConstraintValue_Text{Value: "true"}}},
+	})
+	assert.Equal(t, map[string]bool{"host-c": true}, daemonsetHosts(version, agents))
+}
+
+func TestLaunchDaemonsetTaskGroupInFlight(t *testing.T) {
+	s := &Scheduler{daemonsetLaunching: map[string]string{"ns.ds.host-a": "trans-0"}}
+	version := &types.Version{RunAs: "ns", ID: "ds"}
+
+	//no transaction is created for the host already launching
+	assert.False(t, s.launchDaemonsetTaskGroup(version, "host-a"))
+	assert.Equal(t, map[string]string{"ns.ds.host-a": "trans-0"}, s.daemonsetLaunching)
+}
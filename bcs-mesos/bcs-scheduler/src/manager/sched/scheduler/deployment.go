@@ -14,6 +14,7 @@
 package scheduler
 
 import (
+	alarm "bk-bcs/bcs-common/common/bcs-health/api"
 	"bk-bcs/bcs-common/common/blog"
 	commtypes "bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
@@ -115,6 +116,17 @@ func (s *Scheduler) deploymentCheckTick(ns string, name string, recover bool) bo
 	deployment.CheckTime = time.Now().Unix()
 	s.store.SaveDeployment(deployment)
 
+	switch deployment.Strategy.Type {
+	case commtypes.CanaryUpgradeStrategyType:
+		if deployment.Strategy.Canary != nil {
+			return s.deploymentCheckCanary(deployment)
+		}
+	case commtypes.BlueGreenUpgradeStrategyType:
+		if deployment.Strategy.BlueGreen != nil {
+			return s.deploymentCheckBlueGreen(deployment)
+		}
+	}
+
 	if deployment.IsInRolling == false {
 		now := time.Now().Unix()
 		if deployment.LastRollingTime > now {
@@ -438,6 +450,9 @@ func (s *Scheduler) finishRollingUpdate(deployment *types.Deployment) bool {
 	deployment.LastRollingTime = 0
 	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
 	deployment.IsInRolling = false
+	deployment.CurrCanaryStep = 0
+	deployment.CanaryStepFinished = 0
+	deployment.BlueGreenSwitched = 0
 	deployment.Message = ""
 	if err := s.store.SaveDeployment(deployment); err != nil {
 		blog.Error("deployment(%s.%s) rolling update finish, save to db err:%s", ns, name, err.Error())
@@ -449,22 +464,20 @@ func (s *Scheduler) finishRollingUpdate(deployment *types.Deployment) bool {
 
 func (s *Scheduler) deploymentCheckRolling(deployment *types.Deployment) bool {
 
-	ns := deployment.ObjectMeta.NameSpace
-	name := deployment.ObjectMeta.Name
-
-	now := time.Now().Unix()
-	if deployment.LastRollingTime+TRANSACTION_DEPLOYMENT_ROLLING_LIFEPERIOD+60 < now {
-		blog.Warnf("====deployment(%s.%s) rolling update: %s timeout, suspend", ns, name, deployment.CurrRollingOp)
-		if deployment.CurrRollingOp == types.DEPLOYMENT_OPERATION_START {
-			deployment.Message = "create taskgroup timeout, rollingupdate suspend"
-		} else {
-			deployment.Message = "delete taskgroup timeout, rollingupdate suspend"
+	if rollingProgressDeadlineExceeded(deployment, time.Now().Unix()) {
+		appExt, err := s.store.FetchApplication(deployment.ObjectMeta.NameSpace, deployment.ApplicationExt.ApplicationName)
+		if err != nil {
+			//check the deadline again in next tick
+			blog.Warn("deployment(%s.%s) rolling update, fetch application(%s) for progress deadline err:%s",
+				deployment.ObjectMeta.NameSpace, deployment.ObjectMeta.Name, deployment.ApplicationExt.ApplicationName, err.Error())
+			return false
+		}
+		if !s.isRollingStartFinished(appExt, deployment.ApplicationExt.CurrentRollingInstances, deployment.ApplicationExt.CurrentTargetInstances) {
+			return s.deploymentProgressFailed(deployment)
 		}
+	}
 
-		deployment.IsInRolling = false
-		deployment.Status = types.DEPLOYMENT_STATUS_ROLLINGUPDATE_SUSPEND
-		deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
-		s.store.SaveDeployment(deployment)
+	if s.checkDeploymentRollingTimeout(deployment) {
 		return false
 	}
 
@@ -475,6 +488,44 @@ func (s *Scheduler) deploymentCheckRolling(deployment *types.Deployment) bool {
 	return s.checkDeleteFirstRolling(deployment)
 }
 
+// rollingProgressDeadlineExceeded return true if the new taskgroups of current rolling
+// are not finished in the progress deadline of the strategy
+func rollingProgressDeadlineExceeded(deployment *types.Deployment, now int64) bool {
+	rolling := deployment.Strategy.RollingUpdate
+	if rolling == nil || rolling.ProgressDeadlineSeconds <= 0 {
+		return false
+	}
+	if !deployment.IsInRolling || deployment.CurrRollingOp != types.DEPLOYMENT_OPERATION_START {
+		return false
+	}
+	return now-deployment.LastRollingTime > rolling.ProgressDeadlineSeconds
+}
+
+// deploymentProgressFailed roll back the deployment if autoRollback is set, otherwise suspend the update.
+// return true if the deployment is rolled back
+func (s *Scheduler) deploymentProgressFailed(deployment *types.Deployment) bool {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+	reason := fmt.Sprintf("new taskgroups of application(%s) not running and healthy in %d seconds",
+		deployment.ApplicationExt.ApplicationName, deployment.Strategy.RollingUpdate.ProgressDeadlineSeconds)
+
+	if deployment.Strategy.RollingUpdate.AutoRollback {
+		s.deploymentRollback(deployment, reason)
+		return true
+	}
+
+	blog.Warnf("====deployment(%s.%s) rolling update: %s, suspend", ns, name, reason)
+	deployment.IsInRolling = false
+	deployment.Status = types.DEPLOYMENT_STATUS_ROLLINGUPDATE_SUSPEND
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
+	deployment.Message = "progress deadline exceeded, rollingupdate suspend: " + reason
+	s.store.SaveDeployment(deployment)
+
+	s.SendHealthMsg(alarm.WarnKind, ns, fmt.Sprintf("deployment(%s.%s) rolling update suspend: %s", ns, name, reason), "", nil)
+	s.produceDeploymentEvent(deployment, types.DEPLOYMENT_EVENT_PROGRESS_DEADLINE_EXCEEDED, commtypes.Event_Level_Warning)
+	return false
+}
+
 func (s *Scheduler) isRollingStartFinished(app *types.Application, rollingNum int, targetNum int) bool {
 
 	if app.Instances < uint64(targetNum) {
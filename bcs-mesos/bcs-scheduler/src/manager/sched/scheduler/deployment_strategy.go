@@ -0,0 +1,441 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// canary and blue-green update for deployment, built on the applications of rolling update:
+// the deployment's Application is the old version and ApplicationExt is the new one
+
+package scheduler
+
+import (
+	alarm "bk-bcs/bcs-common/common/bcs-health/api"
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"github.com/samuel/go-zookeeper/zk"
+	"time"
+)
+
+// canaryInstances return the target instances of the new and old applications after a canary step,
+// the new application is rounded up and the old one is rounded down
+func canaryInstances(newDefine, oldDefine uint64, weight int) (uint64, uint64) {
+	if weight > 100 {
+		weight = 100
+	}
+	if weight < 0 {
+		weight = 0
+	}
+	newTarget := (newDefine*uint64(weight) + 99) / 100
+	oldTarget := oldDefine * uint64(100-weight) / 100
+	return newTarget, oldTarget
+}
+
+// unhealthyTaskGroupsReason return the reason if any of the taskgroups is failed or unhealthy,
+// return empty string if all are healthy
+func unhealthyTaskGroupsReason(taskGroups []*types.TaskGroup) string {
+	for _, taskGroup := range taskGroups {
+		switch taskGroup.Status {
+		case types.TASKGROUP_STATUS_FAIL, types.TASKGROUP_STATUS_ERROR, types.TASKGROUP_STATUS_LOST:
+			return fmt.Sprintf("taskgroup(%s) status %s", taskGroup.ID, taskGroup.Status)
+		}
+		if taskGroup.ReschededTimes > 0 {
+			return fmt.Sprintf("taskgroup(%s) rescheduled %d times", taskGroup.ID, taskGroup.ReschededTimes)
+		}
+		for _, task := range taskGroup.Taskgroup {
+			if task.IsChecked && !task.Healthy {
+				return fmt.Sprintf("task(%s) healthcheck not ok", task.ID)
+			}
+		}
+	}
+	return ""
+}
+
+// fetchDeploymentApplications return the old and new applications of the deployment in updating
+func (s *Scheduler) fetchDeploymentApplications(deployment *types.Deployment) (*types.Application, *types.Application, error) {
+	ns := deployment.ObjectMeta.NameSpace
+	if deployment.Application == nil || deployment.ApplicationExt == nil {
+		return nil, nil, zk.ErrNoNode
+	}
+	app, err := s.store.FetchApplication(ns, deployment.Application.ApplicationName)
+	if err != nil {
+		return nil, nil, err
+	}
+	appExt, err := s.store.FetchApplication(ns, deployment.ApplicationExt.ApplicationName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, appExt, nil
+}
+
+// checkDeploymentRollingTimeout suspend the update if the current scaling is timeout
+func (s *Scheduler) checkDeploymentRollingTimeout(deployment *types.Deployment) bool {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+
+	if deployment.LastRollingTime+TRANSACTION_DEPLOYMENT_ROLLING_LIFEPERIOD+60 >= time.Now().Unix() {
+		return false
+	}
+
+	blog.Warnf("====deployment(%s.%s) rolling update: %s timeout, suspend", ns, name, deployment.CurrRollingOp)
+	if deployment.CurrRollingOp == types.DEPLOYMENT_OPERATION_START {
+		deployment.Message = "create taskgroup timeout, rollingupdate suspend"
+	} else {
+		deployment.Message = "delete taskgroup timeout, rollingupdate suspend"
+	}
+	deployment.IsInRolling = false
+	deployment.Status = types.DEPLOYMENT_STATUS_ROLLINGUPDATE_SUSPEND
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
+	s.store.SaveDeployment(deployment)
+	return true
+}
+
+// deploymentCheckCanary maintain the canary update of deployment, every step scales the new application
+// up to the weight then the old application down, and pauses for analysis after the step.
+// return true if the update is finished or rolled back
+func (s *Scheduler) deploymentCheckCanary(deployment *types.Deployment) bool {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+	canary := deployment.Strategy.Canary
+
+	app, appExt, err := s.fetchDeploymentApplications(deployment)
+	if err == zk.ErrNoNode {
+		blog.Warn("deployment(%s.%s) canary update, application not exist, finish", ns, name)
+		return true
+	}
+	if err != nil {
+		blog.Warn("deployment(%s.%s) canary update, fetch application err:%s", ns, name, err.Error())
+		return false
+	}
+
+	if canary.AutoRollback {
+		taskGroups, err := s.store.ListTaskGroups(ns, appExt.ID)
+		if err != nil {
+			blog.Warn("deployment(%s.%s) canary update, list taskgroups(%s) err:%s", ns, name, appExt.ID, err.Error())
+			return false
+		}
+		if reason := unhealthyTaskGroupsReason(taskGroups); reason != "" {
+			s.deploymentRollback(deployment, fmt.Sprintf("canary step %d: %s", deployment.CurrCanaryStep+1, reason))
+			return true
+		}
+	}
+
+	if deployment.IsInRolling {
+		return s.checkCanaryRolling(deployment, app, appExt)
+	}
+
+	now := time.Now().Unix()
+	if deployment.CanaryStepFinished > 0 {
+		step := canary.Steps[deployment.CurrCanaryStep]
+		if deployment.Status == types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED {
+			return false
+		}
+		if step.Pause > 0 && now-deployment.CanaryStepFinished < step.Pause {
+			return false
+		}
+		blog.Info("deployment(%s.%s) canary step %d analysis finish", ns, name, deployment.CurrCanaryStep+1)
+		deployment.CurrCanaryStep++
+		deployment.CanaryStepFinished = 0
+	} else if deployment.Status == types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED {
+		return false
+	}
+
+	step := canary.Steps[deployment.CurrCanaryStep]
+	newTarget, oldTarget := canaryInstances(appExt.DefineInstances, app.DefineInstances, step.Weight)
+
+	deployment.Application.CurrentTargetInstances = int(oldTarget)
+	deployment.Application.CurrentRollingInstances = 0
+	if app.Instances > oldTarget {
+		deployment.Application.CurrentRollingInstances = int(app.Instances - oldTarget)
+	}
+	deployment.ApplicationExt.CurrentTargetInstances = int(newTarget)
+	deployment.ApplicationExt.CurrentRollingInstances = 0
+	if newTarget > appExt.Instances {
+		deployment.ApplicationExt.CurrentRollingInstances = int(newTarget - appExt.Instances)
+	}
+
+	blog.Info("====deployment(%s.%s) canary step %d/%d begin, weight %d: application(%s: %d->%d), applicationExt(%s: %d->%d)",
+		ns, name, deployment.CurrCanaryStep+1, len(canary.Steps), step.Weight,
+		app.ID, app.Instances, oldTarget, appExt.ID, appExt.Instances, newTarget)
+
+	deployment.IsInRolling = true
+	deployment.LastRollingTime = now
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_START
+	deployment.Message = fmt.Sprintf("canary step %d/%d, weight %d%%", deployment.CurrCanaryStep+1, len(canary.Steps), step.Weight)
+	s.innerScaleApplication(appExt.RunAs, appExt.ID, newTarget)
+	s.store.SaveDeployment(deployment)
+
+	return false
+}
+
+func (s *Scheduler) checkCanaryRolling(deployment *types.Deployment, app, appExt *types.Application) bool {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+	canary := deployment.Strategy.Canary
+
+	if s.checkDeploymentRollingTimeout(deployment) {
+		return false
+	}
+
+	oldTarget := uint64(deployment.Application.CurrentTargetInstances)
+	if deployment.CurrRollingOp == types.DEPLOYMENT_OPERATION_START {
+		if !s.isRollingStartFinished(appExt, deployment.ApplicationExt.CurrentRollingInstances, deployment.ApplicationExt.CurrentTargetInstances) {
+			return false
+		}
+		if app.Instances > oldTarget {
+			deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_DELETE
+			s.innerScaleApplication(app.RunAs, app.ID, oldTarget)
+			s.store.SaveDeployment(deployment)
+			return false
+		}
+	} else if app.Instances > oldTarget {
+		return false
+	}
+
+	blog.Info("====deployment(%s.%s) canary step %d/%d finish scaling: application(%s: %d), applicationExt(%s: %d)",
+		ns, name, deployment.CurrCanaryStep+1, len(canary.Steps), app.ID, app.Instances, appExt.ID, appExt.Instances)
+
+	deployment.IsInRolling = false
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
+	deployment.LastRollingTime = time.Now().Unix()
+
+	if deployment.CurrCanaryStep >= len(canary.Steps)-1 {
+		return s.finishRollingUpdate(deployment)
+	}
+
+	step := canary.Steps[deployment.CurrCanaryStep]
+	deployment.CanaryStepFinished = time.Now().Unix()
+	if step.Pause <= 0 {
+		deployment.Status = types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED
+		deployment.Message = fmt.Sprintf("canary step %d/%d finished, paused for analysis until resumed",
+			deployment.CurrCanaryStep+1, len(canary.Steps))
+	} else {
+		deployment.Message = fmt.Sprintf("canary step %d/%d finished, analysis for %d seconds",
+			deployment.CurrCanaryStep+1, len(canary.Steps), step.Pause)
+	}
+	s.store.SaveDeployment(deployment)
+
+	return false
+}
+
+// deploymentCheckBlueGreen maintain the blue-green update of deployment, the new application is scaled
+// to all instances firstly, then the active services are switched to it, and the old application
+// is deleted after the scale down delay. return true if the update is finished
+func (s *Scheduler) deploymentCheckBlueGreen(deployment *types.Deployment) bool {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+	blueGreen := deployment.Strategy.BlueGreen
+	now := time.Now().Unix()
+
+	if deployment.BlueGreenSwitched > 0 {
+		if now-deployment.BlueGreenSwitched < blueGreen.ScaleDownDelaySeconds {
+			return false
+		}
+		blog.Info("deployment(%s.%s) blue-green update, scale down delay finish", ns, name)
+		return s.finishBlueGreenUpdate(deployment)
+	}
+
+	app, appExt, err := s.fetchDeploymentApplications(deployment)
+	if err == zk.ErrNoNode {
+		blog.Warn("deployment(%s.%s) blue-green update, application not exist, finish", ns, name)
+		return true
+	}
+	if err != nil {
+		blog.Warn("deployment(%s.%s) blue-green update, fetch application err:%s", ns, name, err.Error())
+		return false
+	}
+
+	define := int(appExt.DefineInstances)
+	if deployment.CurrRollingOp == types.DEPLOYMENT_OPERATION_NIL {
+		if deployment.Status == types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED {
+			return false
+		}
+		blog.Info("====deployment(%s.%s) blue-green update begin: application(%s: %d), applicationExt(%s: %d->%d)",
+			ns, name, app.ID, app.Instances, appExt.ID, appExt.Instances, define)
+		//pin services to the old version, so the taskgroups of new version stay out of the endpoints until switched
+		if err := s.SwitchServicesSelector(ns, blueGreen.ActiveServices, app.ID); err != nil {
+			blog.Error("deployment(%s.%s) blue-green update, pin services err:%s", ns, name, err.Error())
+			deployment.Message = "pin services failed: " + err.Error()
+			s.store.SaveDeployment(deployment)
+			return false
+		}
+		deployment.ApplicationExt.CurrentTargetInstances = define
+		deployment.ApplicationExt.CurrentRollingInstances = define
+		deployment.IsInRolling = true
+		deployment.LastRollingTime = now
+		deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_START
+		deployment.Message = "launching all taskgroups of new version"
+		s.innerScaleApplication(appExt.RunAs, appExt.ID, appExt.DefineInstances)
+		s.store.SaveDeployment(deployment)
+		return false
+	}
+
+	if deployment.IsInRolling && s.checkDeploymentRollingTimeout(deployment) {
+		return false
+	}
+	if !s.isRollingStartFinished(appExt, define, define) {
+		//taskgroups not ready or the rolling is restarted by recover
+		if !deployment.IsInRolling {
+			deployment.IsInRolling = true
+			deployment.LastRollingTime = now
+			s.store.SaveDeployment(deployment)
+		}
+		return false
+	}
+
+	if deployment.IsInRolling {
+		blog.Info("====deployment(%s.%s) blue-green update: applicationExt(%s) all %d taskgroups running",
+			ns, name, appExt.ID, appExt.Instances)
+		deployment.IsInRolling = false
+		deployment.LastRollingTime = now
+		if blueGreen.ManualPromotion {
+			deployment.Status = types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED
+			deployment.Message = "all taskgroups of new version are running, waiting for promotion"
+			s.store.SaveDeployment(deployment)
+			return false
+		}
+	}
+	if deployment.Status == types.DEPLOYMENT_STATUS_ROLLINGUPDATE_PAUSED {
+		return false
+	}
+
+	if err := s.SwitchServicesSelector(ns, blueGreen.ActiveServices, appExt.ID); err != nil {
+		blog.Error("deployment(%s.%s) blue-green update, switch services err:%s", ns, name, err.Error())
+		deployment.Message = "switch services failed: " + err.Error()
+		s.store.SaveDeployment(deployment)
+		return false
+	}
+
+	blog.Info("====deployment(%s.%s) blue-green update: services %v switched to application(%s)",
+		ns, name, blueGreen.ActiveServices, appExt.ID)
+	deployment.BlueGreenSwitched = now
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_DELETE
+	deployment.Message = fmt.Sprintf("services switched to %s, old version is deleted after %d seconds",
+		appExt.ID, blueGreen.ScaleDownDelaySeconds)
+	s.store.SaveDeployment(deployment)
+
+	if blueGreen.ScaleDownDelaySeconds <= 0 {
+		return s.finishBlueGreenUpdate(deployment)
+	}
+	return false
+}
+
+// finishBlueGreenUpdate finish the update after the old version is deleted, and remove the
+// blue-green label from the selector of services, so they select by the user's labels again
+func (s *Scheduler) finishBlueGreenUpdate(deployment *types.Deployment) bool {
+	if !s.finishRollingUpdate(deployment) {
+		return false
+	}
+	ns := deployment.ObjectMeta.NameSpace
+	err := s.SwitchServicesSelector(ns, deployment.Strategy.BlueGreen.ActiveServices, "")
+	if err != nil {
+		blog.Error("deployment(%s.%s) blue-green update finish, restore services err:%s",
+			ns, deployment.ObjectMeta.Name, err.Error())
+	}
+	return true
+}
+
+// SwitchServicesSelector set the blue-green label of the selector of services to the application,
+// so the services only select the taskgroups of the application. Other keys of the selector
+// are kept, and the label is removed if appID is empty
+func (s *Scheduler) SwitchServicesSelector(ns string, services []string, appID string) error {
+	for _, name := range services {
+		service, err := s.store.FetchService(ns, name)
+		if err != nil {
+			return fmt.Errorf("fetch service(%s.%s) err:%s", ns, name, err.Error())
+		}
+		selector := make(map[string]string)
+		for k, v := range service.Spec.Selector {
+			selector[k] = v
+		}
+		if appID == "" {
+			delete(selector, types.DEPLOYMENT_BLUEGREEN_APP_LABEL)
+		} else {
+			selector[types.DEPLOYMENT_BLUEGREEN_APP_LABEL] = appID
+		}
+		service.Spec.Selector = selector
+		if err := s.store.SaveService(service); err != nil {
+			return fmt.Errorf("save service(%s.%s) err:%s", ns, name, err.Error())
+		}
+		if s.ServiceMgr != nil {
+			s.ServiceMgr.ServiceUpdate(service)
+		}
+	}
+	return nil
+}
+
+// deploymentRollback stop the update of deployment, delete the new application and
+// recover the old application to its defined instances
+func (s *Scheduler) deploymentRollback(deployment *types.Deployment, reason string) {
+	ns := deployment.ObjectMeta.NameSpace
+	name := deployment.ObjectMeta.Name
+	blog.Warn("====deployment(%s.%s) update rollback: %s", ns, name, reason)
+
+	if deployment.Strategy.Type == commtypes.BlueGreenUpgradeStrategyType && deployment.Strategy.BlueGreen != nil {
+		err := s.SwitchServicesSelector(ns, deployment.Strategy.BlueGreen.ActiveServices, "")
+		if err != nil {
+			blog.Error("deployment(%s.%s) rollback, unpin services err:%s", ns, name, err.Error())
+		}
+	}
+
+	if deployment.ApplicationExt != nil {
+		err := s.InnerDeleteApplication(ns, deployment.ApplicationExt.ApplicationName, false)
+		if err != nil {
+			blog.Error("deployment(%s.%s) rollback, delete application(%s) err:%s",
+				ns, name, deployment.ApplicationExt.ApplicationName, err.Error())
+		}
+	}
+
+	if deployment.Application != nil {
+		appName := deployment.Application.ApplicationName
+		s.store.LockApplication(ns + "." + appName)
+		app, err := s.store.FetchApplication(ns, appName)
+		if err == nil && app != nil {
+			app.LastStatus = app.Status
+			app.Status = types.APP_STATUS_RUNNING
+			app.SubStatus = types.APP_SUBSTATUS_UNKNOWN
+			app.Message = "application recovered by deployment rollback"
+			if err = s.store.SaveApplication(app); err != nil {
+				blog.Error("deployment(%s.%s) rollback, save application(%s) err:%s", ns, name, appName, err.Error())
+			}
+		}
+		s.store.UnLockApplication(ns + "." + appName)
+
+		if app != nil && app.Instances < app.DefineInstances {
+			s.innerScaleApplication(ns, appName, app.DefineInstances)
+		}
+		deployment.Application.CurrentTargetInstances = 0
+		deployment.Application.CurrentRollingInstances = 0
+	}
+
+	if deployment.RawJsonBackup != nil {
+		deployment.RawJson = deployment.RawJsonBackup
+	}
+	if deployment.RevisionBackup > 0 {
+		deployment.Revision = deployment.RevisionBackup
+	}
+	deployment.Status = types.DEPLOYMENT_STATUS_RUNNING
+	deployment.ApplicationExt = nil
+	deployment.LastRollingTime = 0
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_NIL
+	deployment.IsInRolling = false
+	deployment.CurrCanaryStep = 0
+	deployment.CanaryStepFinished = 0
+	deployment.BlueGreenSwitched = 0
+	deployment.Message = "rollback: " + reason
+	if err := s.store.SaveDeployment(deployment); err != nil {
+		blog.Error("deployment(%s.%s) rollback, save deployment err:%s", ns, name, err.Error())
+	}
+
+	s.SendHealthMsg(alarm.WarnKind, ns, fmt.Sprintf("deployment(%s.%s) update rollback: %s", ns, name, reason), "", nil)
+	s.produceDeploymentEvent(deployment, types.DEPLOYMENT_EVENT_ROLLBACK, commtypes.Event_Level_Warning)
+}
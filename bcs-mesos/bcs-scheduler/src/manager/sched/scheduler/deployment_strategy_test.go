@@ -0,0 +1,235 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryInstances(t *testing.T) {
+	newTarget, oldTarget := canaryInstances(10, 10, 20)
+	assert.Equal(t, uint64(2), newTarget)
+	assert.Equal(t, uint64(8), oldTarget)
+
+	//new instances rounded up and old instances rounded down
+	newTarget, oldTarget = canaryInstances(3, 3, 10)
+	assert.Equal(t, uint64(1), newTarget)
+	assert.Equal(t, uint64(2), oldTarget)
+
+	newTarget, oldTarget = canaryInstances(5, 4, 100)
+	assert.Equal(t, uint64(5), newTarget)
+	assert.Equal(t, uint64(0), oldTarget)
+}
+
+func TestUnhealthyTaskGroupsReason(t *testing.T) {
+	healthy := []*types.TaskGroup{
+		{ID: "tg-0", Status: types.TASKGROUP_STATUS_RUNNING},
+		{ID: "tg-1", Status: types.TASKGROUP_STATUS_STAGING},
+		{ID: "tg-2", Status: types.TASKGROUP_STATUS_RUNNING, Taskgroup: []*types.Task{
+			{ID: "task-0", IsChecked: true, Healthy: true},
+			{ID: "task-1", IsChecked: false},
+		}},
+	}
+	assert.Equal(t, "", unhealthyTaskGroupsReason(healthy))
+
+	failed := append(healthy, &types.TaskGroup{ID: "tg-3", Status: types.TASKGROUP_STATUS_FAIL})
+	assert.NotEqual(t, "", unhealthyTaskGroupsReason(failed))
+
+	rescheduled := append(healthy, &types.TaskGroup{ID: "tg-3", Status: types.TASKGROUP_STATUS_RUNNING, ReschededTimes: 1})
+	assert.NotEqual(t, "", unhealthyTaskGroupsReason(rescheduled))
+
+	unhealthy := append(healthy, &types.TaskGroup{ID: "tg-3", Status: types.TASKGROUP_STATUS_RUNNING, Taskgroup: []*types.Task{
+		{ID: "task-2", IsChecked: true, Healthy: false},
+	}})
+	assert.NotEqual(t, "", unhealthyTaskGroupsReason(unhealthy))
+}
+
+func TestRollingProgressDeadlineExceeded(t *testing.T) {
+	deployment := &types.Deployment{
+		Strategy: commtypes.UpgradeStrategy{
+			RollingUpdate: &commtypes.RollingUpdate{ProgressDeadlineSeconds: 60},
+		},
+		LastRollingTime: 1000,
+		IsInRolling:     true,
+		CurrRollingOp:   types.DEPLOYMENT_OPERATION_START,
+	}
+	assert.False(t, rollingProgressDeadlineExceeded(deployment, 1060))
+	assert.True(t, rollingProgressDeadlineExceeded(deployment, 1061))
+
+	//only the creating of new taskgroups has deadline
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_DELETE
+	assert.False(t, rollingProgressDeadlineExceeded(deployment, 1061))
+
+	deployment.CurrRollingOp = types.DEPLOYMENT_OPERATION_START
+	deployment.Strategy.RollingUpdate.ProgressDeadlineSeconds = 0
+	assert.False(t, rollingProgressDeadlineExceeded(deployment, 5000))
+}
+
+type serviceStore struct {
+	store.Store
+	services map[string]*commtypes.BcsService
+}
+
+func (ss *serviceStore) FetchService(ns, name string) (*commtypes.BcsService, error) {
+	service, ok := ss.services[ns+"."+name]
+	if !ok {
+		return nil, errors.New("service not found")
+	}
+	return service, nil
+}
+
+func (ss *serviceStore) SaveService(service *commtypes.BcsService) error {
+	ss.services[service.NameSpace+"."+service.Name] = service
+	return nil
+}
+
+func TestSwitchServicesSelector(t *testing.T) {
+	service := &commtypes.BcsService{}
+	service.NameSpace = "ns"
+	service.Name = "svc"
+	service.Spec.Selector = map[string]string{"app": "web", "version": "v1"}
+	ss := &serviceStore{services: map[string]*commtypes.BcsService{"ns.svc": service}}
+	s := &Scheduler{store: ss}
+
+	assert.Nil(t, s.SwitchServicesSelector("ns", []string{"svc"}, "web-v2"))
+	assert.Equal(t, map[string]string{"app": "web", "version": "v1", types.DEPLOYMENT_BLUEGREEN_APP_LABEL: "web-v2"},
+		ss.services["ns.svc"].Spec.Selector)
+
+	//switch back only removes the blue-green label
+	assert.Nil(t, s.SwitchServicesSelector("ns", []string{"svc"}, ""))
+	assert.Equal(t, map[string]string{"app": "web", "version": "v1"}, ss.services["ns.svc"].Spec.Selector)
+
+	assert.NotNil(t, s.SwitchServicesSelector("ns", []string{"svc", "missing"}, "web-v2"))
+}
+
+func TestMatchServiceSelector(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+	assert.Equal(t, "web", matchServiceSelector(selector, map[string]string{"app": "web", "version": "v1"}, "web-v1"))
+	assert.Equal(t, "", matchServiceSelector(selector, map[string]string{"app": "db"}, "db-v1"))
+	assert.Equal(t, "", matchServiceSelector(selector, nil, "web-v1"))
+
+	//pinned selector only matches the labels of the pinned application
+	selector[types.DEPLOYMENT_BLUEGREEN_APP_LABEL] = "web-v2"
+	labels := map[string]string{"app": "web"}
+	assert.Equal(t, "", matchServiceSelector(selector, labels, "web-v1"))
+	assert.Equal(t, "web", matchServiceSelector(selector, labels, "web-v2"))
+	assert.Equal(t, "", matchServiceSelector(selector, map[string]string{"app": "db"}, "web-v2"))
+}
+
+type blueGreenStore struct {
+	serviceStore
+	apps map[string]*types.Application
+}
+
+func (bs *blueGreenStore) FetchApplication(ns, name string) (*types.Application, error) {
+	app, ok := bs.apps[name]
+	if !ok {
+		return nil, errors.New("application not found")
+	}
+	return app, nil
+}
+
+func (bs *blueGreenStore) LockApplication(appID string) {}
+
+func (bs *blueGreenStore) UnLockApplication(appID string) {}
+
+func (bs *blueGreenStore) SaveDeployment(deployment *types.Deployment) error {
+	return nil
+}
+
+func TestBlueGreenServicesPinned(t *testing.T) {
+	service := &commtypes.BcsService{}
+	service.NameSpace = "ns"
+	service.Name = "svc"
+	service.Spec.Selector = map[string]string{"app": "web"}
+	bs := &blueGreenStore{
+		serviceStore: serviceStore{services: map[string]*commtypes.BcsService{"ns.svc": service}},
+		apps: map[string]*types.Application{
+			"web-v1": {ID: "web-v1", Instances: 2, DefineInstances: 2},
+			"web-v2": {ID: "web-v2", Instances: 2, DefineInstances: 2},
+		},
+	}
+	s := &Scheduler{store: bs}
+	deployment := &types.Deployment{
+		Strategy: commtypes.UpgradeStrategy{
+			Type:      commtypes.BlueGreenUpgradeStrategyType,
+			BlueGreen: &commtypes.BlueGreenUpdate{ActiveServices: []string{"svc"}},
+		},
+		Application:    &types.DeploymentReferApplication{ApplicationName: "web-v1"},
+		ApplicationExt: &types.DeploymentReferApplication{ApplicationName: "web-v2"},
+		CurrRollingOp:  types.DEPLOYMENT_OPERATION_NIL,
+		Status:         types.DEPLOYMENT_STATUS_ROLLINGUPDATE,
+	}
+	deployment.ObjectMeta.NameSpace = "ns"
+	deployment.ObjectMeta.Name = "web"
+
+	labels := map[string]string{"app": "web"}
+	blue := &types.TaskGroup{ID: "0.web-v1.ns.10001.1", AppID: "web-v1", Taskgroup: []*types.Task{{Labels: labels}}}
+	green := &types.TaskGroup{ID: "0.web-v2.ns.10001.2", AppID: "web-v2", Taskgroup: []*types.Task{{Labels: labels}}}
+	blue.ObjectMeta.NameSpace = "ns"
+	green.ObjectMeta.NameSpace = "ns"
+	mgr := &ServiceMgr{}
+
+	//services are pinned to the old version before the new version is launched
+	assert.False(t, s.deploymentCheckBlueGreen(deployment))
+	assert.Equal(t, types.DEPLOYMENT_OPERATION_START, deployment.CurrRollingOp)
+	pinned := bs.services["ns.svc"]
+	assert.Equal(t, "web-v1", pinned.Spec.Selector[types.DEPLOYMENT_BLUEGREEN_APP_LABEL])
+	assert.NotEqual(t, "", mgr.getTaskGroupServiceLabel(pinned, blue))
+	assert.Equal(t, "", mgr.getTaskGroupServiceLabel(pinned, green))
+
+	//promotion flips the endpoints to the new version
+	assert.Nil(t, s.SwitchServicesSelector("ns", deployment.Strategy.BlueGreen.ActiveServices, "web-v2"))
+	switched := bs.services["ns.svc"]
+	assert.Equal(t, "", mgr.getTaskGroupServiceLabel(switched, blue))
+	assert.NotEqual(t, "", mgr.getTaskGroupServiceLabel(switched, green))
+}
+
+type errAppStore struct {
+	store.Store
+	fetched int
+}
+
+func (es *errAppStore) FetchApplication(ns, name string) (*types.Application, error) {
+	es.fetched++
+	return nil, errors.New("zk unavailable")
+}
+
+func TestRollingDeadlineFetchApplicationErr(t *testing.T) {
+	deployment := &types.Deployment{
+		Strategy: commtypes.UpgradeStrategy{
+			RollingUpdate: &commtypes.RollingUpdate{ProgressDeadlineSeconds: 60, AutoRollback: true},
+		},
+		ApplicationExt:  &types.DeploymentReferApplication{ApplicationName: "web-v2"},
+		LastRollingTime: 1000,
+		IsInRolling:     true,
+		CurrRollingOp:   types.DEPLOYMENT_OPERATION_START,
+		Status:          types.DEPLOYMENT_STATUS_ROLLINGUPDATE,
+	}
+	es := &errAppStore{}
+	s := &Scheduler{store: es}
+
+	//nothing is rolled back or saved, the deadline is checked again in next tick
+	assert.False(t, s.deploymentCheckRolling(deployment))
+	assert.Equal(t, 1, es.fetched)
+	assert.Equal(t, types.DEPLOYMENT_STATUS_ROLLINGUPDATE, deployment.Status)
+	assert.True(t, deployment.IsInRolling)
+}
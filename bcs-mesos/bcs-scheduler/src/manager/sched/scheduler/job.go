@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// job controller, launch taskgroups until the completions of job are succeeded
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/task"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"github.com/samuel/go-zookeeper/zk"
+	"time"
+)
+
+// Interval seconds to check the taskgroups of jobs
+const JOB_CHECK_INTERVAL = 5
+
+func (s *Scheduler) startCheckJobs() {
+	time.Sleep(60 * time.Second)
+	blog.Info("check jobs begin")
+
+	for {
+		if s.Role != "master" {
+			blog.Warn("check jobs exit, because scheduler is not master now")
+			return
+		}
+		s.checkJobs()
+		time.Sleep(JOB_CHECK_INTERVAL * time.Second)
+	}
+}
+
+func (s *Scheduler) checkJobs() {
+	jobs, err := s.store.ListAllJobs()
+	if err != nil {
+		blog.Error("check jobs: list jobs err:%s", err.Error())
+		return
+	}
+
+	for _, job := range jobs {
+		s.jobCheckTick(job.ObjectMeta.NameSpace, job.ObjectMeta.Name)
+	}
+}
+
+// countJobTaskGroups return the count of active, succeeded and failed taskgroups.
+// The taskgroups of job are not rescheduled, so every taskgroup is counted once.
+func countJobTaskGroups(taskGroups []*types.TaskGroup) (active, succeeded, failed int) {
+	for _, taskGroup := range taskGroups {
+		switch taskGroup.Status {
+		case types.TASKGROUP_STATUS_FINISH:
+			succeeded++
+		case types.TASKGROUP_STATUS_FAIL, types.TASKGROUP_STATUS_ERROR,
+			types.TASKGROUP_STATUS_KILLED, types.TASKGROUP_STATUS_LOST:
+			failed++
+		default:
+			active++
+		}
+	}
+	return active, succeeded, failed
+}
+
+// isJobFinished return whether the job is complete or failed
+func isJobFinished(job *types.Job) bool {
+	return job.Status == types.JOB_STATUS_COMPLETE || job.Status == types.JOB_STATUS_FAILED
+}
+
+// syncJobStatus update the counts and status of running job,
+// return the count of taskgroups which should be launched now
+func syncJobStatus(job *types.Job, active, succeeded, failed int, now int64) int {
+	job.Active = active
+	job.Succeeded = succeeded
+	job.Failed = failed
+
+	if succeeded >= job.Completions {
+		job.Status = types.JOB_STATUS_COMPLETE
+		job.Message = fmt.Sprintf("job completed, %d taskgroups succeeded", succeeded)
+		job.CompletionTime = now
+		return 0
+	}
+	if failed > job.BackoffLimit {
+		job.Status = types.JOB_STATUS_FAILED
+		job.Message = fmt.Sprintf("BackoffLimitExceeded: %d taskgroups failed, backoff limit %d", failed, job.BackoffLimit)
+		job.CompletionTime = now
+		return 0
+	}
+	if job.ActiveDeadlineSeconds > 0 && now-job.StartTime >= job.ActiveDeadlineSeconds {
+		job.Status = types.JOB_STATUS_FAILED
+		job.Message = fmt.Sprintf("DeadlineExceeded: job was active longer than %d seconds", job.ActiveDeadlineSeconds)
+		job.CompletionTime = now
+		return 0
+	}
+
+	job.Status = types.JOB_STATUS_RUNNING
+	job.Message = fmt.Sprintf("%d active, %d succeeded, %d failed", active, succeeded, failed)
+
+	launch := job.Parallelism - active
+	if remain := job.Completions - succeeded - active; remain < launch {
+		launch = remain
+	}
+	if launch < 0 {
+		launch = 0
+	}
+	return launch
+}
+
+func (s *Scheduler) jobCheckTick(ns string, name string) {
+	blog.V(3).Infof("check job(%s.%s)", ns, name)
+
+	s.store.LockApplication(ns + "." + name)
+	defer s.store.UnLockApplication(ns + "." + name)
+
+	job, err := s.store.FetchJob(ns, name)
+	if err != nil {
+		blog.Warn("check job(%s.%s), fetch job err:%s", ns, name, err.Error())
+		return
+	}
+
+	app, err := s.store.FetchApplication(ns, name)
+	if err != nil && err != zk.ErrNoNode {
+		blog.Warn("check job(%s.%s), fetch application err:%s", ns, name, err.Error())
+		return
+	}
+
+	if job.Status == types.JOB_STATUS_DELETING {
+		if app != nil {
+			blog.Info("check job(%s.%s), waiting application to be deleted", ns, name)
+			return
+		}
+		blog.Info("check job(%s.%s), application deleted, delete job", ns, name)
+		if err = s.store.DeleteJob(ns, name); err != nil {
+			blog.Error("check job(%s.%s), delete job err:%s", ns, name, err.Error())
+		}
+		return
+	}
+
+	if app == nil {
+		blog.Warn("check job(%s.%s), application not exist", ns, name)
+		return
+	}
+	if app.Status == types.APP_STATUS_OPERATING {
+		blog.V(3).Infof("check job(%s.%s), application is operating now", ns, name)
+		return
+	}
+
+	taskGroups, err := s.store.ListTaskGroups(ns, name)
+	if err != nil {
+		blog.Error("check job(%s.%s), list taskgroups err:%s", ns, name, err.Error())
+		return
+	}
+
+	//the counts of finished job are not changed any more, only kill the taskgroups left
+	if isJobFinished(job) {
+		s.killJobTaskGroups(job, taskGroups)
+		return
+	}
+
+	active, succeeded, failed := countJobTaskGroups(taskGroups)
+	launch := syncJobStatus(job, active, succeeded, failed, time.Now().Unix())
+	job.CheckTime = time.Now().Unix()
+	if err = s.store.SaveJob(job); err != nil {
+		blog.Error("check job(%s.%s), save job err:%s", ns, name, err.Error())
+		return
+	}
+
+	if isJobFinished(job) {
+		blog.Info("check job(%s.%s) finished, status(%s): %s", ns, name, job.Status, job.Message)
+		s.killJobTaskGroups(job, taskGroups)
+		return
+	}
+
+	if launch > 0 {
+		if err = s.launchJobTaskGroups(app, uint64(launch)); err != nil {
+			job.Message = "launch taskgroups rejected: " + err.Error()
+			if err = s.store.SaveJob(job); err != nil {
+				blog.Error("check job(%s.%s), save job err:%s", ns, name, err.Error())
+			}
+		}
+	}
+}
+
+// killJobTaskGroups kill the taskgroups still running after the job finished
+func (s *Scheduler) killJobTaskGroups(job *types.Job, taskGroups []*types.TaskGroup) {
+	for _, taskGroup := range taskGroups {
+		if task.IsTaskGroupEnd(taskGroup) || !task.CanTaskGroupShutdown(taskGroup) {
+			continue
+		}
+		blog.Info("job(%s.%s) is %s, kill taskgroup(%s)",
+			job.ObjectMeta.NameSpace, job.ObjectMeta.Name, job.Status, taskGroup.ID)
+		s.KillTaskGroup(taskGroup)
+	}
+}
+
+// launchJobTaskGroups create an inner scale transaction to launch more taskgroups for job,
+// do nothing if there is already one launching for the job.
+// Return error if the job can't launch, like the resource quota of namespace is exceeded
+func (s *Scheduler) launchJobTaskGroups(app *types.Application, launch uint64) error {
+	key := app.RunAs + "." + app.ID
+
+	s.jobLaunchLock.Lock()
+	defer s.jobLaunchLock.Unlock()
+
+	if transID, ok := s.jobLaunching[key]; ok {
+		blog.V(3).Infof("job(%s) is launching taskgroups by transaction %s", key, transID)
+		return nil
+	}
+
+	version, _ := s.store.GetVersion(app.RunAs, app.ID)
+	if version == nil {
+		blog.Error("job(%s) has no version to launch taskgroups", key)
+		return fmt.Errorf("job(%s) has no version", key)
+	}
+
+	//the completions of job are the define instances of its application
+	if err := s.CheckResourceQuota(app.RunAs, app.ID, version.AllResource(), app.DefineInstances); err != nil {
+		blog.Warn("job(%s) launch %d taskgroups rejected: %s", key, launch, err.Error())
+		return err
+	}
+
+	scaleTrans := CreateTransaction()
+	scaleTrans.RunAs = app.RunAs
+	scaleTrans.AppID = app.ID
+	scaleTrans.OpType = types.OPERATION_INNERSCALE
+	scaleTrans.Status = types.OPERATION_STATUS_INIT
+	scaleTrans.LifePeriod = TRANSACTION_DEPLOYMENT_ROLLING_LIFEPERIOD
+
+	scaleOpdata := &TransAPIScaleOpdata{
+		Version:      version,
+		NeedResource: version.AllResource(),
+		Instances:    app.Instances + launch,
+	}
+	scaleTrans.OpData = scaleOpdata
+
+	s.jobLaunching[key] = scaleTrans.ID
+	blog.Info("job(%s) create transaction %s to launch %d taskgroups", key, scaleTrans.ID, launch)
+	go func() {
+		s.RunInnerScaleApplication(scaleTrans)
+
+		s.jobLaunchLock.Lock()
+		delete(s.jobLaunching, key)
+		s.jobLaunchLock.Unlock()
+	}()
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountJobTaskGroups(t *testing.T) {
+	taskGroups := []*types.TaskGroup{
+		{ID: "tg-0", Status: types.TASKGROUP_STATUS_FINISH},
+		{ID: "tg-1", Status: types.TASKGROUP_STATUS_RUNNING},
+		{ID: "tg-2", Status: types.TASKGROUP_STATUS_FAIL},
+		{ID: "tg-3", Status: types.TASKGROUP_STATUS_STAGING},
+		{ID: "tg-4", Status: types.TASKGROUP_STATUS_LOST},
+		{ID: "tg-5", Status: types.TASKGROUP_STATUS_FINISH},
+	}
+
+	active, succeeded, failed := countJobTaskGroups(taskGroups)
+	assert.Equal(t, 2, active)
+	assert.Equal(t, 2, succeeded)
+	assert.Equal(t, 2, failed)
+}
+
+func TestSyncJobStatus(t *testing.T) {
+	newJob := func() *types.Job {
+		return &types.Job{
+			Completions:           5,
+			Parallelism:           2,
+			BackoffLimit:          1,
+			ActiveDeadlineSeconds: 100,
+			StartTime:             1000,
+		}
+	}
+
+	//launch up to parallelism
+	job := newJob()
+	assert.Equal(t, 2, syncJobStatus(job, 0, 0, 0, 1010))
+	assert.Equal(t, types.JOB_STATUS_RUNNING, job.Status)
+
+	//one running, one more can be launched
+	job = newJob()
+	assert.Equal(t, 1, syncJobStatus(job, 1, 2, 1, 1010))
+
+	//do not launch more than the remaining completions
+	job = newJob()
+	assert.Equal(t, 0, syncJobStatus(job, 1, 4, 0, 1010))
+	assert.Equal(t, types.JOB_STATUS_RUNNING, job.Status)
+
+	//all completions succeeded
+	job = newJob()
+	assert.Equal(t, 0, syncJobStatus(job, 0, 5, 1, 1010))
+	assert.Equal(t, types.JOB_STATUS_COMPLETE, job.Status)
+	assert.Equal(t, int64(1010), job.CompletionTime)
+	assert.True(t, isJobFinished(job))
+
+	//failed more than backoff limit
+	job = newJob()
+	assert.Equal(t, 0, syncJobStatus(job, 1, 1, 2, 1010))
+	assert.Equal(t, types.JOB_STATUS_FAILED, job.Status)
+
+	//active deadline exceeded
+	job = newJob()
+	assert.Equal(t, 0, syncJobStatus(job, 1, 1, 0, 1100))
+	assert.Equal(t, types.JOB_STATUS_FAILED, job.Status)
+
+	//no deadline
+	job = newJob()
+	job.ActiveDeadlineSeconds = 0
+	assert.Equal(t, 1, syncJobStatus(job, 1, 1, 0, 100000))
+}
+
+type jobQuotaStore struct {
+	store.Store
+	apps    []*types.Application
+	version *types.Version
+	quotas  []*commtypes.BcsResourceQuota
+}
+
+func (js *jobQuotaStore) GetVersion(runAs, appID string) (*types.Version, error) {
+	return js.version, nil
+}
+
+func (js *jobQuotaStore) ListApplications(runAs string) ([]*types.Application, error) {
+	return js.apps, nil
+}
+
+func (js *jobQuotaStore) ListResourceQuotas(runAs string) ([]*commtypes.BcsResourceQuota, error) {
+	return js.quotas, nil
+}
+
+func TestLaunchJobTaskGroupsRejectedByQuota(t *testing.T) {
+	job := &types.Application{ID: "job", RunAs: "ns", Kind: commtypes.BcsDataType_JOB, DefineInstances: 6}
+	js := &jobQuotaStore{
+		apps: []*types.Application{job},
+		version: &types.Version{
+			ID:        "job",
+			RunAs:     "ns",
+			Container: []*types.Container{{DataClass: &types.DataClass{Resources: &types.Resource{Cpus: 1, Mem: 128}}}},
+		},
+		quotas: []*commtypes.BcsResourceQuota{{
+			ObjectMeta: commtypes.ObjectMeta{NameSpace: "ns", Name: "quota"},
+			Spec:       commtypes.ResourceQuotaSpec{Hard: commtypes.ResourceQuotaList{Cpu: 5}},
+		}},
+	}
+	s := &Scheduler{store: js, jobLaunching: make(map[string]string)}
+
+	//all the completions are checked though only 2 taskgroups are launched now
+	err := s.launchJobTaskGroups(job, 2)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "cpu requested 6, used 0, hard 5"), err.Error())
+	}
+	assert.Empty(t, s.jobLaunching)
+}
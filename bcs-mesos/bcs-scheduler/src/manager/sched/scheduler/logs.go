@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultLogsTimeout is the time waiting for executor to response logs request
+	DefaultLogsTimeout = 15 * time.Second
+)
+
+// FetchTaskLogs send logs request to executor of taskgroup, and wait for the response.
+// logs request is not saved in store, it is too frequent when following logs
+func (s *Scheduler) FetchTaskLogs(taskGroup *types.TaskGroup, request *types.RequestLogsTask) (*types.ResponseLogsTask, error) {
+	if taskGroup.Status != types.TASKGROUP_STATUS_RUNNING {
+		return nil, fmt.Errorf("taskgroup %s must be running", taskGroup.ID)
+	}
+
+	request.ID = request.TaskId + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	bcsMsg := &types.BcsMessage{
+		Id:              time.Now().Unix(),
+		Type:            types.Msg_Req_LOGS_TASK.Enum(),
+		TaskGroupId:     taskGroup.ID,
+		RequestLogsTask: request,
+		Status:          types.Msg_Status_Staging,
+		CreateTime:      time.Now().Unix(),
+	}
+	msg, err := json.Marshal(bcsMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *types.ResponseLogsTask, 1)
+	s.logsWaitersLock.Lock()
+	s.logsWaiters[request.ID] = ch
+	s.logsWaitersLock.Unlock()
+	defer func() {
+		s.logsWaitersLock.Lock()
+		delete(s.logsWaiters, request.ID)
+		s.logsWaitersLock.Unlock()
+	}()
+
+	resp, err := s.SendMessage(taskGroup, msg)
+	if err != nil {
+		return nil, fmt.Errorf("send logs request to executor %s fail for %s", taskGroup.ID, err.Error())
+	}
+	if resp != nil && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("send logs request to executor %s fail for status code %d received", taskGroup.ID, resp.StatusCode)
+	}
+
+	select {
+	case response := <-ch:
+		if response.Status == types.Msg_Status_Failed {
+			return nil, fmt.Errorf("executor %s fetch logs of task %s failed: %s", taskGroup.ID, request.TaskId, response.Message)
+		}
+		return response, nil
+	case <-time.After(DefaultLogsTimeout):
+		return nil, fmt.Errorf("wait for logs of task %s from executor %s timeout", request.TaskId, taskGroup.ID)
+	}
+}
+
+// ProcessLogsMessage pass logs response from executor to the waiting request
+func (s *Scheduler) ProcessLogsMessage(bcsMsg *types.BcsMessage) {
+	if bcsMsg.ResponseLogsTask == nil {
+		blog.Error("process logs message, but data empty")
+		return
+	}
+
+	response := bcsMsg.ResponseLogsTask
+	s.logsWaitersLock.Lock()
+	ch, ok := s.logsWaiters[response.ID]
+	s.logsWaitersLock.Unlock()
+	if !ok {
+		blog.Warn("process logs message: request(%s) of task(%s) is not waiting, maybe timeout", response.ID, response.TaskId)
+		return
+	}
+
+	blog.V(3).Infof("process logs message: request(%s), task(%s), offset(%d), %d bytes",
+		response.ID, response.TaskId, response.Offset, len(response.Logs))
+	select {
+	case ch <- response:
+	default:
+		blog.Warn("process logs message: request(%s) already has response", response.ID)
+	}
+}
@@ -77,6 +77,10 @@ func (s *Scheduler) SendBcsMessage(taskGroup *types.TaskGroup, bcsMsg *types.Bcs
 	//taskGroup.BcsMessages[bcsMsg.Id] = bcsMsg
 
 	taskGroup.BcsEventMsg = bcsMsg
+	if bcsMsg.ReloadConfig != nil {
+		// the reloaded files may be secrets resolved from backends, not stored with taskgroup
+		taskGroup.BcsEventMsg = stripReloadConfigContent(bcsMsg)
+	}
 
 	//save taskGroup into zk, in this function, task will alse be saved
 	if err = s.store.SaveTaskGroup(taskGroup); err != nil {
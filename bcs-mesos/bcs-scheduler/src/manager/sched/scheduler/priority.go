@@ -0,0 +1,276 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// priority of transactions and preemption of lower priority taskgroups
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// A transaction pending for more than 30 seconds can preempt lower priority taskgroups
+const PREEMPTION_PENDING_TIME = 30
+
+// Min interval between two preemptions of one transaction, 120 seconds
+// The killed taskgroups need some time to release their resources to mesos
+const PREEMPTION_INTERVAL = 120
+
+// The preempted taskgroups will be rescheduled after 60 seconds
+const PREEMPTION_RESCHEDULE_DELAYTIME = 60
+
+// pendingTransaction is a transaction waiting for offers
+type pendingTransaction struct {
+	trans   *Transaction
+	version *types.Version
+	// resource for one taskgroup
+	need *types.Resource
+	// taskgroup to be updated or rescheduled, used for constraints
+	taskGroupID string
+	// the time when the transaction begin to wait for offers
+	since int64
+	// the last time the transaction preempted taskgroups
+	lastPreempted int64
+}
+
+// pendingDemand is the copy of what a pending transaction is waiting for, taken under pendingTransLock
+// so that it can be used after the lock is released
+type pendingDemand struct {
+	transID     string
+	version     *types.Version
+	need        *types.Resource
+	taskGroupID string
+}
+
+func (p *pendingTransaction) demand() pendingDemand {
+	return pendingDemand{
+		transID:     p.trans.ID,
+		version:     p.version,
+		need:        p.need,
+		taskGroupID: p.taskGroupID,
+	}
+}
+
+// addPendingTransaction register the transaction which is waiting for offers,
+// the offers fit for it will be reserved from the transactions with lower priority
+func (s *Scheduler) addPendingTransaction(trans *Transaction, version *types.Version, need *types.Resource, taskGroupID string) {
+	s.pendingTransLock.Lock()
+	defer s.pendingTransLock.Unlock()
+
+	if p, ok := s.pendingTrans[trans.ID]; ok {
+		p.version = version
+		p.need = need
+		p.taskGroupID = taskGroupID
+		return
+	}
+
+	s.pendingTrans[trans.ID] = &pendingTransaction{
+		trans:       trans,
+		version:     version,
+		need:        need,
+		taskGroupID: taskGroupID,
+		since:       time.Now().Unix(),
+	}
+}
+
+// deletePendingTransaction remove the transaction when it is end
+func (s *Scheduler) deletePendingTransaction(trans *Transaction) {
+	s.pendingTransLock.Lock()
+	delete(s.pendingTrans, trans.ID)
+	s.pendingTransLock.Unlock()
+}
+
+// IsOfferReserved return true if the offer is fit for a pending transaction with higher priority than priority,
+// the caller should skip the offer so that the transaction with higher priority can use it first
+func (s *Scheduler) IsOfferReserved(trans *Transaction, priority int32, o *offer.Offer) bool {
+	var highers []pendingDemand
+	s.pendingTransLock.RLock()
+	for _, p := range s.pendingTrans {
+		if p.trans.ID != trans.ID && p.version.Priority > priority {
+			highers = append(highers, p.demand())
+		}
+	}
+	s.pendingTransLock.RUnlock()
+
+	for _, d := range highers {
+		if s.IsOfferResourceFitLaunch(d.need, o) && s.IsConstraintsFit(d.version, o.Offer, d.taskGroupID) {
+			blog.V(3).Infof("transaction %s(priority %d) skip offer %s, reserved for transaction %s(priority %d)",
+				trans.ID, priority, o.Offer.GetHostname(), d.transID, d.version.Priority)
+			return true
+		}
+	}
+
+	return false
+}
+
+// preemptForTransaction try to kill lower priority taskgroups on one host to make room for the pending transaction,
+// the killed taskgroups will be rescheduled later.
+// It does nothing if preemption is disabled, or the transaction has priority 0, or it is not pending long enough.
+func (s *Scheduler) preemptForTransaction(trans *Transaction) {
+	if !s.config.EnablePreemption {
+		return
+	}
+
+	now := time.Now().Unix()
+	s.pendingTransLock.Lock()
+	p, ok := s.pendingTrans[trans.ID]
+	if !ok || p.version.Priority <= 0 || now-p.since < PREEMPTION_PENDING_TIME || now-p.lastPreempted < PREEMPTION_INTERVAL {
+		s.pendingTransLock.Unlock()
+		return
+	}
+	p.lastPreempted = now
+	d := p.demand()
+	s.pendingTransLock.Unlock()
+
+	hostname, victims := s.selectPreemptionVictims(d)
+	if len(victims) == 0 {
+		blog.Infof("transaction %s(priority %d) have no taskgroups to preempt", trans.ID, d.version.Priority)
+		return
+	}
+
+	blog.Infof("transaction %s(priority %d) preempt %d taskgroups on host %s",
+		trans.ID, d.version.Priority, len(victims), hostname)
+	for _, taskGroup := range victims {
+		s.preemptTaskGroup(taskGroup, trans)
+	}
+}
+
+// selectPreemptionVictims select the host which need the least taskgroups to be killed,
+// only the hosts which have offers fit the constraints of the transaction are considered
+func (s *Scheduler) selectPreemptionVictims(d pendingDemand) (string, []*types.TaskGroup) {
+	apps, err := s.store.ListAllApplications()
+	if err != nil {
+		blog.Errorf("select preemption victims, list all applications err: %s", err.Error())
+		return "", nil
+	}
+
+	candidates := make(map[string][]*types.TaskGroup)
+	for _, app := range apps {
+		taskGroups, err := s.store.ListTaskGroups(app.RunAs, app.ID)
+		if err != nil {
+			blog.Errorf("select preemption victims, list taskgroups(%s.%s) err: %s", app.RunAs, app.ID, err.Error())
+			continue
+		}
+		for _, taskGroup := range taskGroups {
+			if taskGroup.Priority >= d.version.Priority || taskGroup.Status != types.TASKGROUP_STATUS_RUNNING ||
+				taskGroup.LaunchResource == nil {
+				continue
+			}
+			candidates[taskGroup.HostName] = append(candidates[taskGroup.HostName], taskGroup)
+		}
+	}
+
+	var hostname string
+	var selected []*types.TaskGroup
+	for _, o := range s.GetAllOffers() {
+		host := o.Offer.GetHostname()
+		if len(candidates[host]) == 0 {
+			continue
+		}
+		if !s.IsConstraintsFit(d.version, o.Offer, d.taskGroupID) {
+			continue
+		}
+
+		cpus, mem, disk := s.OfferedResources(o.Offer)
+		free := &types.Resource{
+			Cpus: cpus - o.DeltaCPU,
+			Mem:  mem - o.DeltaMem,
+			Disk: disk - o.DeltaDisk,
+		}
+		victims := pickPreemptionVictims(free, candidates[host], d.need)
+		if victims == nil {
+			continue
+		}
+		if selected == nil || len(victims) < len(selected) {
+			hostname = host
+			selected = victims
+		}
+	}
+
+	return hostname, selected
+}
+
+// pickPreemptionVictims pick taskgroups from the lowest priority, until the free resource plus
+// the resources of picked taskgroups is enough for need, return nil if all the taskgroups are not enough
+func pickPreemptionVictims(free *types.Resource, taskGroups []*types.TaskGroup, need *types.Resource) []*types.TaskGroup {
+	sorted := make([]*types.TaskGroup, len(taskGroups))
+	copy(sorted, taskGroups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	cpus, mem, disk := free.Cpus, free.Mem, free.Disk
+	var victims []*types.TaskGroup
+	for _, taskGroup := range sorted {
+		if need.Cpus <= cpus && need.Mem <= mem && need.Disk <= disk {
+			break
+		}
+		victims = append(victims, taskGroup)
+		cpus += taskGroup.LaunchResource.Cpus
+		mem += taskGroup.LaunchResource.Mem
+		disk += taskGroup.LaunchResource.Disk
+	}
+
+	if need.Cpus > cpus || need.Mem > mem || need.Disk > disk {
+		return nil
+	}
+	return victims
+}
+
+// preemptTaskGroup kill the taskgroup and reschedule it after PREEMPTION_RESCHEDULE_DELAYTIME,
+// the reschedule transaction has the priority of the taskgroup, so it will not take the offer back
+func (s *Scheduler) preemptTaskGroup(taskGroup *types.TaskGroup, by *Transaction) {
+	runAs, appID := store.GetRunAsAndAppIDbyTaskGroupID(taskGroup.ID)
+
+	version, _ := s.store.GetVersion(runAs, appID)
+	if version == nil {
+		blog.Error("preempt taskgroup(%s) fail, no version for application(%s.%s)", taskGroup.ID, runAs, appID)
+		return
+	}
+
+	blog.Info("taskgroup(%s) priority(%d) is preempted by transaction %s(%s.%s)",
+		taskGroup.ID, taskGroup.Priority, by.ID, by.RunAs, by.AppID)
+	resp, err := s.KillTaskGroup(taskGroup)
+	if err != nil {
+		blog.Warn("preempt taskgroup(%s), kill failed: %s", taskGroup.ID, err.Error())
+		return
+	}
+	if resp != nil && resp.StatusCode != http.StatusAccepted {
+		blog.Warn("preempt taskgroup(%s), kill return code %d", taskGroup.ID, resp.StatusCode)
+		return
+	}
+
+	rescheduleTrans := CreateTransaction()
+	rescheduleTrans.DelayTime = PREEMPTION_RESCHEDULE_DELAYTIME
+	rescheduleTrans.RunAs = runAs
+	rescheduleTrans.AppID = appID
+	rescheduleTrans.OpType = types.OPERATION_RESCHEDULE
+	rescheduleTrans.Status = types.OPERATION_STATUS_INIT
+	rescheduleTrans.LifePeriod = TRANSACTION_INNER_RESCHEDULE_LIFEPERIOD
+
+	var rescheduleOpdata TransRescheduleOpData
+	rescheduleOpdata.TaskGroupID = taskGroup.ID
+	rescheduleOpdata.Force = true
+	rescheduleOpdata.IsInner = true
+	rescheduleOpdata.NeedResource = version.AllResource()
+	rescheduleOpdata.Version = version
+	rescheduleTrans.OpData = &rescheduleOpdata
+
+	go s.RunRescheduleTaskgroup(rescheduleTrans)
+}
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPriorityTaskGroup(id string, priority int32, cpus, mem float64) *types.TaskGroup {
+	return &types.TaskGroup{
+		ID:             id,
+		Priority:       priority,
+		LaunchResource: &types.Resource{Cpus: cpus, Mem: mem},
+	}
+}
+
+func TestPickPreemptionVictims(t *testing.T) {
+	taskGroups := []*types.TaskGroup{
+		newPriorityTaskGroup("tg-2", 2, 1, 512),
+		newPriorityTaskGroup("tg-0", 0, 1, 512),
+		newPriorityTaskGroup("tg-1", 1, 2, 1024),
+	}
+
+	//free resource is enough, no victims
+	victims := pickPreemptionVictims(&types.Resource{Cpus: 2, Mem: 1024}, taskGroups, &types.Resource{Cpus: 1, Mem: 512})
+	assert.Equal(t, 0, len(victims))
+
+	//the lowest priority taskgroups are picked first
+	victims = pickPreemptionVictims(&types.Resource{Cpus: 0.5, Mem: 256}, taskGroups, &types.Resource{Cpus: 3, Mem: 1024})
+	if assert.Equal(t, 2, len(victims)) {
+		assert.Equal(t, "tg-0", victims[0].ID)
+		assert.Equal(t, "tg-1", victims[1].ID)
+	}
+	//the input is not reordered
+	assert.Equal(t, "tg-2", taskGroups[0].ID)
+
+	//all the taskgroups are not enough
+	victims = pickPreemptionVictims(&types.Resource{}, taskGroups, &types.Resource{Cpus: 8, Mem: 512})
+	assert.Nil(t, victims)
+}
@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// resource quota of namespace, checked when launch, scale and update application
+
+package scheduler
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"fmt"
+	"time"
+)
+
+// GetResourceQuotaUsage return the resources used by all the applications in namespace runAs,
+// the application exceptAppID is not included, so the caller can add its new request to the usage.
+// An application uses version resources * DefineInstances of its newest version.
+func (s *Scheduler) GetResourceQuotaUsage(runAs, exceptAppID string) (*commtypes.ResourceQuotaList, error) {
+	used := &commtypes.ResourceQuotaList{}
+
+	apps, err := s.store.ListApplications(runAs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range apps {
+		if app.ID == exceptAppID {
+			continue
+		}
+
+		version, err := s.store.GetVersion(runAs, app.ID)
+		if err != nil {
+			return nil, err
+		}
+		if version == nil {
+			blog.Warn("resource quota usage: application(%s.%s) have no version", runAs, app.ID)
+			continue
+		}
+
+		addResourceQuotaUsage(used, version.AllResource(), app.DefineInstances)
+	}
+
+	return used, nil
+}
+
+// CheckResourceQuota check whether application appID in namespace runAs can run instances of need resources,
+// return error with the requested, used and hard resources if any resource quota of the namespace is exceeded
+func (s *Scheduler) CheckResourceQuota(runAs, appID string, need *types.Resource, instances uint64) error {
+	quotas, err := s.store.ListResourceQuotas(runAs)
+	if err != nil {
+		blog.Error("check resource quota for application(%s.%s), list resourcequota err:%s", runAs, appID, err.Error())
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	used, err := s.GetResourceQuotaUsage(runAs, appID)
+	if err != nil {
+		blog.Error("check resource quota for application(%s.%s), get usage err:%s", runAs, appID, err.Error())
+		return err
+	}
+
+	request := &commtypes.ResourceQuotaList{}
+	addResourceQuotaUsage(request, need, instances)
+
+	for _, quota := range quotas {
+		if err := checkResourceQuotaExceeded(quota, request, used); err != nil {
+			blog.Warn("application(%s.%s) is rejected by resourcequota: %s", runAs, appID, err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rejectTransactionByQuota check the resource quota before the transaction do anything,
+// if the quota is exceeded, the transaction is failed and the reason is set into the application message.
+// return true if the transaction is rejected.
+func (s *Scheduler) rejectTransactionByQuota(trans *Transaction, need *types.Resource, instances uint64) bool {
+	err := s.CheckResourceQuota(trans.RunAs, trans.AppID, need, instances)
+	if err == nil {
+		return false
+	}
+
+	blog.Warn("transaction %s %s(%s.%s) rejected: %s", trans.ID, trans.OpType, trans.RunAs, trans.AppID, err.Error())
+	trans.Status = types.OPERATION_STATUS_FAIL
+
+	s.store.LockApplication(trans.RunAs + "." + trans.AppID)
+	defer s.store.UnLockApplication(trans.RunAs + "." + trans.AppID)
+
+	app, fetchErr := s.store.FetchApplication(trans.RunAs, trans.AppID)
+	if fetchErr != nil || app == nil {
+		blog.Error("transaction %s fetch application(%s.%s) to set quota message fail", trans.ID, trans.RunAs, trans.AppID)
+		return true
+	}
+
+	// set status here, so FinishTransaction will not overwrite the message
+	app.LastStatus = app.Status
+	if app.Instances < app.DefineInstances {
+		app.Status = types.APP_STATUS_ABNORMAL
+	} else {
+		app.Status = types.APP_STATUS_RUNNING
+	}
+	app.SubStatus = types.APP_SUBSTATUS_UNKNOWN
+	app.UpdateTime = time.Now().Unix()
+	app.Message = "application " + trans.OpType + " rejected: " + err.Error()
+	if err := s.store.SaveApplication(app); err != nil {
+		blog.Error("transaction %s save application(%s.%s) err:%s", trans.ID, trans.RunAs, trans.AppID, err.Error())
+	}
+
+	return true
+}
+
+func addResourceQuotaUsage(used *commtypes.ResourceQuotaList, resource *types.Resource, instances uint64) {
+	used.Cpu += resource.Cpus * float64(instances)
+	used.Mem += resource.Mem * float64(instances)
+	used.Disk += resource.Disk * float64(instances)
+	used.Instances += int(instances)
+}
+
+// checkResourceQuotaExceeded return error if request + used exceed the hard limits of quota, zero limit means unlimited
+func checkResourceQuotaExceeded(quota *commtypes.BcsResourceQuota, request, used *commtypes.ResourceQuotaList) error {
+	hard := quota.Spec.Hard
+
+	exceeded := func(resource string, request, used, hard float64) error {
+		if hard <= 0 || request+used <= hard {
+			return nil
+		}
+		return fmt.Errorf("exceeded resourcequota %s.%s: %s requested %g, used %g, hard %g",
+			quota.NameSpace, quota.Name, resource, request, used, hard)
+	}
+
+	if err := exceeded("cpu", request.Cpu, used.Cpu, hard.Cpu); err != nil {
+		return err
+	}
+	if err := exceeded("mem", request.Mem, used.Mem, hard.Mem); err != nil {
+		return err
+	}
+	if err := exceeded("disk", request.Disk, used.Disk, hard.Disk); err != nil {
+		return err
+	}
+	return exceeded("instances", float64(request.Instances), float64(used.Instances), float64(hard.Instances))
+}
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResourceQuotaExceeded(t *testing.T) {
+	quota := &commtypes.BcsResourceQuota{
+		ObjectMeta: commtypes.ObjectMeta{NameSpace: "ns", Name: "quota"},
+		Spec: commtypes.ResourceQuotaSpec{
+			Hard: commtypes.ResourceQuotaList{Cpu: 10, Mem: 1024, Instances: 5},
+		},
+	}
+
+	request := &commtypes.ResourceQuotaList{}
+	addResourceQuotaUsage(request, &types.Resource{Cpus: 1, Mem: 128, Disk: 100}, 2)
+	assert.Equal(t, commtypes.ResourceQuotaList{Cpu: 2, Mem: 256, Disk: 200, Instances: 2}, *request)
+
+	//disk is unlimited
+	used := &commtypes.ResourceQuotaList{Cpu: 8, Mem: 512, Disk: 10000, Instances: 3}
+	assert.Nil(t, checkResourceQuotaExceeded(quota, request, used))
+
+	used.Cpu = 8.5
+	err := checkResourceQuotaExceeded(quota, request, used)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "ns.quota: cpu requested 2, used 8.5, hard 10"), err.Error())
+	}
+
+	used.Cpu = 0
+	used.Instances = 4
+	err = checkResourceQuotaExceeded(quota, request, used)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "instances requested 2, used 4, hard 5"), err.Error())
+	}
+}
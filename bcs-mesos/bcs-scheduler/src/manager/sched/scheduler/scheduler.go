@@ -22,6 +22,7 @@ import (
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/misc"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/operator"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/score"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/task"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
@@ -116,6 +117,29 @@ type Scheduler struct {
 	offerPool offer.OfferPool
 
 	pluginManager *pluginManager.PluginManager
+
+	// transactions waiting for offers, key is transaction ID
+	pendingTransLock sync.RWMutex
+	pendingTrans     map[string]*pendingTransaction
+
+	// daemonset launch transactions in progress, key is namespace.name.hostname
+	daemonsetLaunchLock sync.Mutex
+	daemonsetLaunching  map[string]string
+
+	// job launch transactions in progress, key is namespace.name
+	jobLaunchLock sync.Mutex
+	jobLaunching  map[string]string
+
+	// offer scoring pipeline of the cluster
+	scorePipeline *score.Pipeline
+	// images on each host listed for scoring, shared by the transactions in one scheduling round
+	hostImagesLock   sync.Mutex
+	hostImages       map[string][]string
+	hostImagesListed time.Time
+
+	// log requests waiting for executor response, key is request ID
+	logsWaitersLock sync.Mutex
+	logsWaiters     map[string]chan *types.ResponseLogsTask
 }
 
 // NewScheduler returns a pointer to new Scheduler
@@ -125,11 +149,23 @@ func NewScheduler(config util.Scheduler, store store.Store) *Scheduler {
 		store:        store,
 		eventManager: newBcsEventManager(config),
 		lostSlave:    make(map[string]int64),
+		pendingTrans: make(map[string]*pendingTransaction),
+
+		daemonsetLaunching: make(map[string]string),
+		jobLaunching:       make(map[string]string),
+		logsWaiters:        make(map[string]chan *types.ResponseLogsTask),
 	}
 
 	para := &offer.OfferPara{Sched: s}
 	s.offerPool = offer.NewOfferPool(para)
 
+	policy, err := score.ParsePolicy(config.ScorePolicy)
+	if err != nil {
+		blog.Errorf("parse score policy(%s) err: %s, use the default policy", config.ScorePolicy, err.Error())
+		policy = score.DefaultPolicy()
+	}
+	s.scorePipeline, _ = score.NewPipeline(policy)
+
 	//if config.ClientCertDir != "" {
 	s.clientCert = &commtype.CertConfig{
 		CertFile:   config.ClientCertFile,
@@ -139,7 +175,8 @@ func NewScheduler(config util.Scheduler, store store.Store) *Scheduler {
 	}
 	//}
 	//init executor info
-	task.InitExecutorInfo(s.config.ContainerExecutor, s.config.ProcessExecutor, s.config.CniDir, s.config.NetImage)
+	task.InitExecutorInfo(s.config.ContainerExecutor, s.config.ProcessExecutor, s.config.CniDir, s.config.NetImage,
+		s.config.ContainerRuntime, s.config.CRIEndpoint, s.config.CRIImageTool)
 
 	s.eventManager.Run()
 
@@ -151,8 +188,6 @@ func NewScheduler(config util.Scheduler, store store.Store) *Scheduler {
 	s.client = client.New("foobar", "make test pass")
 	s.operatorClient = client.New("foobar", "make test pass")
 
-	var err error
-
 	if s.config.Plugins != "" {
 		blog.Infof("start init plugin manager")
 		plugins := strings.Split(s.config.Plugins, ",")
@@ -241,10 +276,7 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
-	s.ServiceMgr = NewServiceMgr(s.config.ZK, "/blueking", s)
-	if s.ServiceMgr == nil {
-		return fmt.Errorf("new serviceMgr(%s:/blueking) error", s.config.ZK)
-	}
+	s.ServiceMgr = NewServiceMgr(s)
 	go s.ServiceMgr.Worker()
 
 	//blog.Info("to create transaction manager")
@@ -823,6 +855,10 @@ func (s *Scheduler) checkRoleChange(currRole string) error {
 
 	go s.startCheckDeployments()
 
+	go s.startCheckDaemonsets()
+
+	go s.startCheckJobs()
+
 	if s.ServiceMgr != nil {
 		var msgOpen ServiceMgrMsg
 		msgOpen.MsgType = "open"
@@ -1016,6 +1052,8 @@ func (s *Scheduler) handleEvents(resp *http.Response) {
 			switch *bcsMsg.Type {
 			case types.Msg_Res_COMMAND_TASK:
 				go s.ProcessCommandMessage(bcsMsg)
+			case types.Msg_Res_LOGS_TASK:
+				go s.ProcessLogsMessage(bcsMsg)
 			case types.Msg_TASK_STATUS_UPDATE:
 				go s.UpdateTaskStatus(message.GetAgentId().GetValue(), message.GetExecutorId().GetValue(), bcsMsg)
 			default:
@@ -1131,6 +1169,28 @@ func (s *Scheduler) newTaskEvent(task *types.Task) *commtype.BcsStorageEventIf {
 	return event
 }
 
+func (s *Scheduler) produceDeploymentEvent(deployment *types.Deployment, eventType string, level commtype.EventLevel) error {
+	now := time.Now().Unix()
+	event := &commtype.BcsStorageEventIf{
+		ID:        fmt.Sprintf("%s.%s.%d", deployment.ObjectMeta.NameSpace, deployment.ObjectMeta.Name, now),
+		Env:       commtype.Event_Env_Mesos,
+		Kind:      commtype.DeploymentEventKind,
+		Level:     level,
+		Component: commtype.Event_Component_Scheduler,
+		Type:      eventType,
+		EventTime: now,
+		Describe:  deployment.Message,
+		ClusterId: s.BcsClusterId,
+		ExtraInfo: commtype.EventExtraInfo{
+			Namespace: deployment.ObjectMeta.NameSpace,
+			Name:      deployment.ObjectMeta.Name,
+			Kind:      commtype.DeploymentExtraKind,
+		},
+	}
+
+	return s.eventManager.syncEvent(event)
+}
+
 // DeclineResource is used to send DECLINE request to mesos to release offer. This
 // is very important, otherwise resource will be taked until framework exited.
 func (s *Scheduler) DeclineResource(offerId *string) (*http.Response, error) {
@@ -1479,17 +1539,6 @@ func (s *Scheduler) GetMesosResourceIn(mesosClient *client.Client) (*commtype.Bc
 	return clusterRes, nil
 }
 
-func (s *Scheduler) GetCurrentOffers() []*mesos.Offer {
-	offers := s.offerPool.GetAllOffers()
-
-	inOffers := make([]*mesos.Offer, 0)
-	for _, o := range offers {
-		inOffers = append(inOffers, o.Offer)
-	}
-
-	return inOffers
-}
-
 func mesosAttribute2commonAttribute(oldAttributeList []*mesos.Attribute) []*commtype.BcsAgentAttribute {
 	if oldAttributeList == nil {
 		return nil
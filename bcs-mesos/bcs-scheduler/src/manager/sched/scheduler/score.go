@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/score"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+)
+
+// hostImagesCacheInterval is the interval of one scheduling round, the transactions retry offers
+// every 3 seconds, so the images on hosts listed in the round are reused by all the transactions
+const hostImagesCacheInterval = 3 * time.Second
+
+// getScorePipeline returns the scoring pipeline of the version, the score policy of version overrides the one of cluster
+func (s *Scheduler) getScorePipeline(version *types.Version) *score.Pipeline {
+	if version == nil || version.ScorePolicy == nil {
+		return s.scorePipeline
+	}
+
+	pipeline, err := score.NewPipeline(version.ScorePolicy)
+	if err != nil {
+		blog.Warnf("version(%s.%s) score policy err: %s, use the policy of cluster", version.RunAs, version.ID, err.Error())
+		return s.scorePipeline
+	}
+	return pipeline
+}
+
+func (s *Scheduler) newScoreContext(version *types.Version, need *types.Resource) *score.Context {
+	return &score.Context{
+		Version:    version,
+		Need:       need,
+		HostImages: s.listHostImages,
+		TaskGroups: func(runAs, appID string) []*types.TaskGroup {
+			s.store.LockApplication(runAs + "." + appID)
+			taskGroups, err := s.store.ListTaskGroups(runAs, appID)
+			s.store.UnLockApplication(runAs + "." + appID)
+			if err != nil {
+				blog.Errorf("score offers, list taskgroups(%s.%s) err: %s", runAs, appID, err.Error())
+			}
+			return taskGroups
+		},
+	}
+}
+
+// listHostImages returns the images of the taskgroups on each host, the result is cached for one scheduling round
+func (s *Scheduler) listHostImages() map[string][]string {
+	s.hostImagesLock.Lock()
+	defer s.hostImagesLock.Unlock()
+
+	if s.hostImages != nil && time.Since(s.hostImagesListed) < hostImagesCacheInterval {
+		return s.hostImages
+	}
+	hostImages := s.doListHostImages()
+	if hostImages != nil {
+		s.hostImages = hostImages
+		s.hostImagesListed = time.Now()
+	}
+	return hostImages
+}
+
+func (s *Scheduler) doListHostImages() map[string][]string {
+	apps, err := s.store.ListAllApplications()
+	if err != nil {
+		blog.Errorf("score offers, list all applications err: %s", err.Error())
+		return nil
+	}
+
+	hostImages := make(map[string][]string)
+	for _, app := range apps {
+		s.store.LockApplication(app.RunAs + "." + app.ID)
+		taskGroups, err := s.store.ListTaskGroups(app.RunAs, app.ID)
+		s.store.UnLockApplication(app.RunAs + "." + app.ID)
+		if err != nil {
+			blog.Errorf("score offers, list taskgroups(%s.%s) err: %s", app.RunAs, app.ID, err.Error())
+			continue
+		}
+		for _, taskGroup := range taskGroups {
+			if taskGroup.HostName == "" {
+				continue
+			}
+			for _, task := range taskGroup.Taskgroup {
+				if task.Image != "" {
+					hostImages[taskGroup.HostName] = append(hostImages[taskGroup.HostName], task.Image)
+				}
+			}
+		}
+	}
+
+	return hostImages
+}
+
+// scoreOffers scores all the offers in pool for the version
+func (s *Scheduler) scoreOffers(version *types.Version, need *types.Resource) []*score.OfferScore {
+	return s.getScorePipeline(version).ScoreOffers(s.newScoreContext(version, need), s.GetAllOffers())
+}
+
+// GetScoredOffers returns all the offers in pool sorted by score from the highest to the lowest,
+// the caller uses the first offer which fits the resources and constraints, so the best fit offer is used
+func (s *Scheduler) GetScoredOffers(version *types.Version, need *types.Resource) []*offer.Offer {
+	scores := s.scoreOffers(version, need)
+	offers := make([]*offer.Offer, 0, len(scores))
+	for _, o := range scores {
+		offers = append(offers, o.Offer)
+	}
+	return offers
+}
+
+// GetCurrentOffers returns the offers in pool with their scores for debugging,
+// if version is nil, the offers are scored by the policy of cluster with no resource needed
+func (s *Scheduler) GetCurrentOffers(version *types.Version) []*types.ScoredOffer {
+	var need *types.Resource
+	if version != nil {
+		need = version.AllResource()
+	}
+
+	scores := s.scoreOffers(version, need)
+	offers := make([]*types.ScoredOffer, 0, len(scores))
+	for _, o := range scores {
+		offers = append(offers, &types.ScoredOffer{
+			Offer:  o.Offer.Offer,
+			Score:  o.Score,
+			Scores: o.Scores,
+		})
+	}
+	return offers
+}
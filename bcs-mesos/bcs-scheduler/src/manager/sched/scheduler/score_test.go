@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scoreStore struct {
+	store.Store
+	apps       []*types.Application
+	taskGroups map[string][]*types.TaskGroup
+	listed     int
+	locked     map[string]bool
+	lockedList int
+}
+
+func (ss *scoreStore) LockApplication(appID string) {
+	ss.locked[appID] = true
+}
+
+func (ss *scoreStore) UnLockApplication(appID string) {
+	delete(ss.locked, appID)
+}
+
+func (ss *scoreStore) ListAllApplications() ([]*types.Application, error) {
+	ss.listed++
+	return ss.apps, nil
+}
+
+func (ss *scoreStore) ListTaskGroups(runAs, appID string) ([]*types.TaskGroup, error) {
+	if ss.locked[runAs+"."+appID] {
+		ss.lockedList++
+	}
+	return ss.taskGroups[runAs+"."+appID], nil
+}
+
+func newScoreStore() *scoreStore {
+	return &scoreStore{
+		apps: []*types.Application{{RunAs: "ns", ID: "web"}},
+		taskGroups: map[string][]*types.TaskGroup{
+			"ns.web": {
+				{ID: "tg-0", HostName: "host-0", Taskgroup: []*types.Task{{Image: "nginx:1.0"}}},
+				{ID: "tg-1", Taskgroup: []*types.Task{{Image: "nginx:1.0"}}},
+			},
+		},
+		locked: make(map[string]bool),
+	}
+}
+
+func TestListHostImagesCached(t *testing.T) {
+	ss := newScoreStore()
+	s := &Scheduler{store: ss}
+
+	assert.Equal(t, map[string][]string{"host-0": {"nginx:1.0"}}, s.listHostImages())
+	assert.Equal(t, 1, ss.listed)
+	assert.Equal(t, 1, ss.lockedList)
+	assert.Empty(t, ss.locked)
+
+	//reused in the same scheduling round
+	s.listHostImages()
+	assert.Equal(t, 1, ss.listed)
+
+	s.hostImagesListed = time.Now().Add(-hostImagesCacheInterval)
+	s.listHostImages()
+	assert.Equal(t, 2, ss.listed)
+}
+
+func TestScoreContextTaskGroupsLocked(t *testing.T) {
+	ss := newScoreStore()
+	s := &Scheduler{store: ss}
+
+	ctx := s.newScoreContext(&types.Version{RunAs: "ns", ID: "web"}, nil)
+	assert.Len(t, ctx.TaskGroups("ns", "web"), 2)
+	assert.Equal(t, 1, ss.lockedList)
+	assert.Empty(t, ss.locked)
+}
@@ -14,18 +14,14 @@
 package scheduler
 
 import (
-	alarm "bk-bcs/bcs-common/common/bcs-health/api"
 	"bk-bcs/bcs-common/common/blog"
 	commtypes "bk-bcs/bcs-common/common/types"
-	"bk-bcs/bcs-common/common/zkclient"
 	"bk-bcs/bcs-common/pkg/cache"
 	"bk-bcs/bcs-mesos/bcs-container-executor/container"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
 	"encoding/json"
 	"fmt"
-	"github.com/samuel/go-zookeeper/zk"
 	"reflect"
-	"strings"
 	"time"
 )
 
@@ -55,18 +51,6 @@ type exportServiceInfo struct {
 	syncTime   int64
 }
 
-type zkClient interface {
-	ConnectEx(sessionTimeOut time.Duration) error
-	GetEx(path string) ([]byte, *zk.Stat, error)
-	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
-	GetChildrenEx(path string) ([]string, *zk.Stat, error)
-	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
-	ExistEx(path string) (bool, *zk.Stat, error)
-	//ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
-	State() zk.State
-	Close()
-}
-
 // Control message for service manager
 type ServiceMgrMsg struct {
 	// open:  work
@@ -79,30 +63,22 @@ type ServiceMgrMsg struct {
 type ServiceMgr struct {
 	esInfoCache cache.Store
 	queue       chan *ServiceSyncData
-	zklink      string
-	client      zkClient
-	watchPath   string
 	sched       *Scheduler
 	msgQueue    chan *ServiceMgrMsg
 	isWork      bool
 }
 
-// Create service manager
-func NewServiceMgr(zkLink string, path string, scheduler *Scheduler) *ServiceMgr {
+// Create service manager, services and taskgroups are read from the store of scheduler,
+// so they are the same whichever db driver the store uses
+func NewServiceMgr(scheduler *Scheduler) *ServiceMgr {
 	mgr := &ServiceMgr{
 		esInfoCache: cache.NewCache(esInfoKeyFunc),
 		queue:       make(chan *ServiceSyncData, 4096),
-		zklink:      zkLink,
-		watchPath:   path,
 		sched:       scheduler,
 		msgQueue:    make(chan *ServiceMgrMsg, 128),
 		isWork:      false,
 	}
 
-	err := mgr.createZkConn()
-	if err != nil {
-		return nil
-	}
 	return mgr
 }
 
@@ -119,53 +95,6 @@ func (mgr *ServiceMgr) SendMsg(msg *ServiceMgrMsg) error {
 	return nil
 }
 
-func (mgr *ServiceMgr) createZkConn() error {
-
-	blog.Info("servicemgr create ZK connection(%s) ...", mgr.zklink)
-	servers := strings.Split(mgr.zklink, ",")
-	//var conErr error
-	//mgr.client, _, conErr = zk.Connect(servers, time.Second*5)
-	mgr.client = zkclient.NewZkClient(servers)
-	conErr := mgr.client.ConnectEx(time.Second * 5)
-	if conErr != nil {
-		blog.Error("bcs-scheduler connect zookeeper failed: %s", conErr.Error())
-		return conErr
-	}
-	blog.Info("connect zookeeper %s success! base mgr path: %s ", mgr.zklink, mgr.watchPath)
-	return nil
-}
-
-func (mgr *ServiceMgr) zkConnMonitor() {
-	state := mgr.client.State()
-	if state == zk.StateConnected {
-		blog.V(3).Infof("############ZK Connection status is connected#####")
-		return
-	}
-	if state == zk.StateHasSession {
-		blog.V(3).Infof("############ZK Connection status is hassession#####")
-		return
-	}
-	blog.Warn("zookeeper connection under %s", state.String())
-	mgr.client.Close()
-
-	tryTimes := 0
-	for {
-		tryTimes++
-		err := mgr.createZkConn()
-		if err != nil {
-			mgr.client.Close()
-			time.Sleep(3 * time.Second)
-		} else {
-			break
-		}
-
-		if tryTimes >= 20 {
-			mgr.sched.SendHealthMsg(alarm.WarnKind, "", err.Error(), "", nil)
-			tryTimes = 0
-		}
-	}
-}
-
 // Send taskgroup update event to servie manager
 func (mgr *ServiceMgr) TaskgroupUpdate(taskgroup *types.TaskGroup) {
 	data := &ServiceSyncData{
@@ -260,7 +189,6 @@ func (mgr *ServiceMgr) Worker() {
 				return
 			}
 		case <-tick.C:
-			mgr.zkConnMonitor()
 			blog.V(3).Infof("ServiceMgr is running, managed service num: %d", mgr.esInfoCache.Num())
 			if mgr.isWork == false {
 				continue
@@ -332,76 +260,53 @@ func (mgr *ServiceMgr) doCheck() {
 
 func (mgr *ServiceMgr) processAllServices() error {
 	currTime := time.Now().Unix()
-	basePath := mgr.watchPath + "/service"
-	blog.Info("sync all services under(%s), currTime(%d)", basePath, currTime)
+	blog.Info("sync all services, currTime(%d)", currTime)
 
-	nmList, _, err := mgr.client.GetChildrenEx(basePath)
+	services, err := mgr.sched.store.ListAllServices()
 	if err != nil {
-		blog.Error("get path(%s) children err: %s", basePath, err.Error())
+		blog.Error("list all services err: %s", err.Error())
 		return err
 	}
-	if len(nmList) == 0 {
-		blog.Warn("get empty namespace list under path(%s)", basePath)
+	if len(services) == 0 {
+		blog.Warn("get empty service list")
 		return nil
 	}
 
-	// sync all services from zk and update cache, create add and update events
-	numZk := 0
+	// sync all services from store and update cache, create add and update events
+	numStore := 0
 	numDel := 0
-	for _, nmNode := range nmList {
-		blog.V(3).Infof("get namespace node(%s) under path(%s)", nmNode, basePath)
-		nmPath := basePath + "/" + nmNode
-		nodeList, _, err := mgr.client.GetChildrenEx(nmPath)
+	for _, data := range services {
+		numStore++
+		key := data.ObjectMeta.NameSpace + "." + data.ObjectMeta.Name
+		cacheData, exist, err := mgr.esInfoCache.GetByKey(key)
 		if err != nil {
-			blog.Error("get children nodes under %s err: %s", nmPath, err.Error())
+			blog.Error("get service %s from cache return err:%s", key, err.Error())
 			continue
 		}
-		for _, oneNode := range nodeList {
-			numZk++
-			blog.V(3).Infof("get node(%s) under path(%s)", oneNode, nmPath)
-			nodePath := nmPath + "/" + oneNode
-			byteData, _, err := mgr.client.GetEx(nodePath)
-			if err != nil {
-				blog.Error("Get %s data err: %s", nodePath, err.Error())
-				continue
-			}
-			data := new(commtypes.BcsService)
-			if jsonErr := json.Unmarshal(byteData, data); jsonErr != nil {
-				blog.Error("Parse %s json data(%s) Err: %s", nodePath, string(byteData), jsonErr.Error())
+		if exist == true {
+			cacheDataInfo, ok := cacheData.(*exportServiceInfo)
+			if !ok {
+				blog.Error("convert cachedata to exportServiceInfo fail, key(%s)", key)
 				continue
 			}
-
-			key := data.ObjectMeta.NameSpace + "." + data.ObjectMeta.Name
-			cacheData, exist, err := mgr.esInfoCache.GetByKey(key)
-			if err != nil {
-				blog.Error("get service %s from cache return err:%s", key, err.Error())
-				continue
-			}
-			if exist == true {
-				cacheDataInfo, ok := cacheData.(*exportServiceInfo)
-				if !ok {
-					blog.Error("convert cachedata to exportServiceInfo fail, key(%s)", key)
-					continue
-				}
-				if !reflect.DeepEqual(cacheDataInfo.bcsService, data) {
-					blog.Warnf("service %s is changed, do update and init its endpoint", key)
-					mgr.updateService(data, currTime)
-				} else {
-					blog.V(3).Infof("service %s is not changed, update sync time(%d)", key, currTime)
-					cacheDataInfo.syncTime = currTime
-				}
+			if !reflect.DeepEqual(cacheDataInfo.bcsService, data) {
+				blog.Warnf("service %s is changed, do update and init its endpoint", key)
+				mgr.updateService(data, currTime)
 			} else {
-				blog.Info("service %s is not in cache, to do add, time(%d)", key, currTime)
-				mgr.addService(data, currTime)
-				//bcsEndpoint, _ := mgr.sched.store.FetchEndpoint(data.ObjectMeta.NameSpace, data.ObjectMeta.Name)
-				//if bcsEndpoint != nil {
-				//	blog.Info("service %s is already has endpoint data in ZK, add to cache", key)
-				//	mgr.addService(data, bcsEndpoint, currTime)
-				//} else {
-				//	blog.Info("service %s is has not endpoint data in ZK, create for it", key)
-				//	mgr.addService(data, nil, currTime)
-				//}
+				blog.V(3).Infof("service %s is not changed, update sync time(%d)", key, currTime)
+				cacheDataInfo.syncTime = currTime
 			}
+		} else {
+			blog.Info("service %s is not in cache, to do add, time(%d)", key, currTime)
+			mgr.addService(data, currTime)
+			//bcsEndpoint, _ := mgr.sched.store.FetchEndpoint(data.ObjectMeta.NameSpace, data.ObjectMeta.Name)
+			//if bcsEndpoint != nil {
+			//	blog.Info("service %s is already has endpoint data in ZK, add to cache", key)
+			//	mgr.addService(data, bcsEndpoint, currTime)
+			//} else {
+			//	blog.Info("service %s is has not endpoint data in ZK, create for it", key)
+			//	mgr.addService(data, nil, currTime)
+			//}
 		}
 	}
 
@@ -431,7 +336,7 @@ func (mgr *ServiceMgr) processAllServices() error {
 		}
 	}
 
-	blog.Info("sync %d services from zk, delete %d cache services", numZk, numDel)
+	blog.Info("sync %d services from store, delete %d cache services", numStore, numDel)
 	return nil
 }
 
@@ -554,58 +459,32 @@ func (mgr *ServiceMgr) syncEndpointInfo(esInfo *exportServiceInfo) {
 
 	key := esInfo.bcsService.ObjectMeta.NameSpace + "." + esInfo.bcsService.ObjectMeta.Name
 
-	basePath := fmt.Sprintf("%s/application/%s", mgr.watchPath, esInfo.bcsService.ObjectMeta.NameSpace)
-	blog.V(3).Infof("sync all taskgroups under(%s) for service(%s)", basePath, key)
+	ns := esInfo.bcsService.ObjectMeta.NameSpace
+	blog.V(3).Infof("sync all taskgroups in namespace(%s) for service(%s)", ns, key)
 
-	appList, _, err := mgr.client.GetChildrenEx(basePath)
+	apps, err := mgr.sched.store.ListApplications(ns)
 	if err != nil {
-		blog.Error("get path(%s) children err: %s", basePath, err.Error())
+		blog.Error("list applications in namespace(%s) err: %s", ns, err.Error())
 		return
 	}
 
 	esInfo.endpoint.Endpoints = nil
 
-	for _, app := range appList {
-		appPath := fmt.Sprintf("%s/%s", basePath, app)
-
-		by, _, err := mgr.client.GetEx(appPath)
-		if err != nil {
-			blog.Warnf("get application(%s) from zk error: %s", appPath, err.Error())
-			continue
-		}
-		var application *types.Application
-		err = json.Unmarshal(by, &application)
-		if err != nil {
-			blog.Warnf("json.Unmarshal application(%s) error: %s", appPath, err.Error())
-			continue
-		}
+	for _, application := range apps {
 		label := mgr.getApplicationServiceLabel(esInfo.bcsService, application)
 		if label == "" {
-			blog.V(3).Infof("application(%s) not match service: %s", appPath, key)
+			blog.V(3).Infof("application(%s.%s) not match service: %s", ns, application.ID, key)
 			continue
 		}
 
-		blog.Infof("sync all taskgroups under(%s) for service(%s)", appPath, key)
-		tgList, _, err := mgr.client.GetChildrenEx(appPath)
+		blog.Infof("sync all taskgroups of application(%s.%s) for service(%s)", ns, application.ID, key)
+		tgList, err := mgr.sched.store.ListTaskGroups(ns, application.ID)
 		if err != nil {
-			blog.Error("get path(%s) children err: %s", appPath, err.Error())
+			blog.Error("list taskgroups of application(%s.%s) err: %s", ns, application.ID, err.Error())
 			continue
 		}
 
-		for _, tg := range tgList {
-			tgPath := fmt.Sprintf("%s/%s", appPath, tg)
-			by, _, err := mgr.client.GetEx(tgPath)
-			if err != nil {
-				blog.Errorf("get zk path %s error %s", tgPath, err.Error())
-				continue
-			}
-
-			tskgroup := new(types.TaskGroup)
-			err = json.Unmarshal(by, tskgroup)
-			if err != nil {
-				blog.Errorf("json.Unmarshal zk path %s data failed, error %s", tgPath, err.Error())
-				continue
-			}
+		for _, tskgroup := range tgList {
 			if tskgroup.Taskgroup == nil || len(tskgroup.Taskgroup) == 0 {
 				blog.Error("taskgroup(%s) has no Task Info", tskgroup.ID)
 				continue
@@ -614,6 +493,10 @@ func (mgr *ServiceMgr) syncEndpointInfo(esInfo *exportServiceInfo) {
 				blog.V(3).Infof("taskgroup(%s) status %s, do nothing ", tskgroup.ID, tskgroup.Status)
 				continue
 			}
+			if !tskgroup.IsReady() {
+				blog.V(3).Infof("taskgroup(%s) is not ready, do nothing ", tskgroup.ID)
+				continue
+			}
 
 			//label := mgr.getTaskGroupServiceLabel(esInfo.bcsService, tskgroup)
 			//if label == "" {
@@ -645,20 +528,15 @@ func (mgr *ServiceMgr) getTaskGroupServiceLabel(service *commtypes.BcsService, t
 	}
 
 	key := service.ObjectMeta.NameSpace + "." + service.ObjectMeta.Name
-	for ks, vs := range service.Spec.Selector {
-		task := tskgroup.Taskgroup[0]
-		if task.Labels == nil {
-			return ""
-		}
-		for kt, vt := range task.Labels {
-			//blog.V(3).Infof("check task(%s) label(%s:%s) with selector label(%s:%s)", task.Name, kt, vt, ks, vs)
-			if ks == kt && vs == vt {
-				blog.V(3).Infof("task label match service: task(%s) label(%s:%s) service(%s)", task.Name, kt, vt, key)
-				return vt
-			}
-		}
+	task := tskgroup.Taskgroup[0]
+	if task.Labels == nil {
+		return ""
 	}
-	return ""
+	label := matchServiceSelector(service.Spec.Selector, task.Labels, tskgroup.AppID)
+	if label != "" {
+		blog.V(3).Infof("task label match service: task(%s) label(%s) service(%s)", task.Name, label, key)
+	}
+	return label
 }
 
 func (mgr *ServiceMgr) getApplicationServiceLabel(service *commtypes.BcsService, app *types.Application) string {
@@ -669,14 +547,27 @@ func (mgr *ServiceMgr) getApplicationServiceLabel(service *commtypes.BcsService,
 	}
 
 	key := service.ObjectMeta.NameSpace + "." + service.ObjectMeta.Name
-	for ks, vs := range service.Spec.Selector {
+	label := matchServiceSelector(service.Spec.Selector, app.ObjectMeta.Labels, app.ID)
+	if label != "" {
+		blog.V(3).Infof("application label match service: application(%s.%s) label(%s) service(%s)",
+			app.RunAs, app.ID, label, key)
+	}
+	return label
+}
 
-		for kt, vt := range app.ObjectMeta.Labels {
-			if ks == kt && vs == vt {
-				blog.V(3).Infof("application label match service: application(%s.%s) label(%s:%s) service(%s)",
-					app.RunAs, app.ID, kt, vt, key)
-				return vt
-			}
+// matchServiceSelector return the value of the first label matched by the selector, or "" if not matched.
+// any key of the selector is enough to match, except the blue-green label, which is set by
+// the deployment and pins the selector to the application(appID) owning the labels
+func matchServiceSelector(selector, labels map[string]string, appID string) string {
+	if pinned, ok := selector[types.DEPLOYMENT_BLUEGREEN_APP_LABEL]; ok && pinned != appID {
+		return ""
+	}
+	for ks, vs := range selector {
+		if ks == types.DEPLOYMENT_BLUEGREEN_APP_LABEL {
+			continue
+		}
+		if vt, ok := labels[ks]; ok && vs == vt {
+			return vt
 		}
 	}
 	return ""
@@ -800,6 +691,10 @@ func (mgr *ServiceMgr) addTaskGroup(tskgroup *types.TaskGroup) {
 		blog.V(3).Infof("ServiceMgr receive taskgroup add event, TaskGroup %s status %s, do nothing ", tskgroup.ID, tskgroup.Status)
 		return
 	}
+	if !tskgroup.IsReady() {
+		blog.V(3).Infof("ServiceMgr receive taskgroup add event, TaskGroup %s is not ready, do nothing ", tskgroup.ID)
+		return
+	}
 
 	keyList := mgr.esInfoCache.ListKeys()
 	for _, key := range keyList {
@@ -883,7 +778,7 @@ func (mgr *ServiceMgr) updateTaskGroup(tskgroup *types.TaskGroup) {
 		}
 
 		var changed bool
-		if tskgroup.Status == types.TASKGROUP_STATUS_RUNNING || tskgroup.Status == types.TASKGROUP_STATUS_LOST {
+		if (tskgroup.Status == types.TASKGROUP_STATUS_RUNNING || tskgroup.Status == types.TASKGROUP_STATUS_LOST) && tskgroup.IsReady() {
 			changed = mgr.addEndPoint(esInfo.endpoint, podEndpoint)
 		} else {
 			changed = mgr.deleteEndPoint(esInfo.endpoint, podEndpoint)
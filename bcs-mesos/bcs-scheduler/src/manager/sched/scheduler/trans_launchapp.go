@@ -37,6 +37,13 @@ func (s *Scheduler) RunLaunchApplication(transaction *Transaction) {
 
 	startedTaskgroup := time.Now()
 	startedApp := time.Now()
+
+	//check resource quota of namespace before launching any taskgroup
+	launchData := transaction.OpData.(*TransAPILaunchOpdata)
+	if s.rejectTransactionByQuota(transaction, launchData.Version.AllResource(), uint64(launchData.Version.Instances)) {
+		goto run_end
+	}
+
 	//var offerIdx int64 = 0
 	for {
 		blog.Infof("transaction %s launch(%s.%s) run check", transaction.ID, runAs, appID)
@@ -52,17 +59,16 @@ func (s *Scheduler) RunLaunchApplication(transaction *Transaction) {
 		//check doing
 		opData := transaction.OpData.(*TransAPILaunchOpdata)
 		version := opData.Version
+		s.addPendingTransaction(transaction, version, opData.NeedResource, "")
 
-		offerOut := s.GetFirstOffer()
-		for offerOut != nil {
-			offerIdx := offerOut.Id
-			offer := offerOut.Offer
+		for _, curOffer := range s.GetScoredOffers(version, opData.NeedResource) {
+			offerIdx := curOffer.Id
+			offer := curOffer.Offer
 			blog.V(3).Infof("transaction %s get offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 
-			curOffer := offerOut
-			offerOut = s.GetNextOffer(offerOut)
 			//isFit := s.IsResourceFit(opData.NeedResource, offer) && s.IsConstraintsFit(version, offer, "")
-			isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "")
+			isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "") &&
+				!s.IsOfferReserved(transaction, version.Priority, curOffer)
 			if isFit == true {
 				blog.V(3).Infof("transaction %s fit offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 				if s.UseOffer(curOffer) == true {
@@ -83,6 +89,9 @@ func (s *Scheduler) RunLaunchApplication(transaction *Transaction) {
 			}
 		}
 
+		//no fit offer, try to preempt lower priority taskgroups
+		s.preemptForTransaction(transaction)
+
 		//check timeout
 		if (transaction.CreateTime + transaction.LifePeriod) < time.Now().Unix() {
 			blog.Warn("transaction %s launch(%s.%s) timeout", transaction.ID, runAs, appID)
@@ -94,6 +103,7 @@ func (s *Scheduler) RunLaunchApplication(transaction *Transaction) {
 	}
 
 run_end:
+	s.deletePendingTransaction(transaction)
 	s.FinishTransaction(transaction)
 	reportOperateAppMetrics(transaction.RunAs, transaction.AppID, LaunchApplicationType, startedApp)
 	blog.Infof("transaction %s launch(%s.%s) run end, result(%s)", transaction.ID, runAs, appID, transaction.Status)
@@ -82,16 +82,15 @@ func (s *Scheduler) RunRescheduleTaskgroup(transaction *Transaction) {
 			hostRetain = true
 		}
 
-		offerOut := s.GetFirstOffer()
-		for offerOut != nil {
-			offerIdx := offerOut.Id
-			offer := offerOut.Offer
+		s.addPendingTransaction(transaction, version, opData.NeedResource, taskGroupID)
+		for _, curOffer := range s.GetScoredOffers(version, opData.NeedResource) {
+			offerIdx := curOffer.Id
+			offer := curOffer.Offer
 			blog.V(3).Infof("transaction %s get offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 
-			curOffer := offerOut
-			offerOut = s.GetNextOffer(offerOut)
 			if hostRetain == false || offer.GetHostname() == opData.HostRetain {
-				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, taskGroupID)
+				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, taskGroupID) &&
+					!s.IsOfferReserved(transaction, version.Priority, curOffer)
 				if isFit == true {
 					blog.V(3).Infof("transaction %s fit offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 					if s.UseOffer(curOffer) == true {
@@ -131,6 +130,7 @@ func (s *Scheduler) RunRescheduleTaskgroup(transaction *Transaction) {
 		time.Sleep(3 * time.Second)
 	}
 
+	s.deletePendingTransaction(transaction)
 	s.FinishTransaction(transaction)
 	blog.Infof("transaction %s reschedule(%s) run end, result(%s)", transaction.ID, taskGroupID, transaction.Status)
 
@@ -37,6 +37,13 @@ func (s *Scheduler) RunScaleApplication(transaction *Transaction) {
 
 	startedTaskgroup := time.Now()
 	startedApp := time.Now()
+
+	//check resource quota of namespace, only scale up need more resources
+	scaleData := transaction.OpData.(*TransAPIScaleOpdata)
+	if !scaleData.IsDown && s.rejectTransactionByQuota(transaction, scaleData.Version.AllResource(), scaleData.Instances) {
+		goto run_end
+	}
+
 	for {
 		blog.Infof("transaction %s scale(%s.%s) run check", transaction.ID, runAs, appID)
 
@@ -58,14 +65,13 @@ func (s *Scheduler) RunScaleApplication(transaction *Transaction) {
 				goto run_end
 			}
 		} else {
-			offerOut := s.GetFirstOffer()
-			for offerOut != nil {
-				offer := offerOut.Offer
+			s.addPendingTransaction(transaction, version, opData.NeedResource, "")
+			for _, curOffer := range s.GetScoredOffers(version, opData.NeedResource) {
+				offer := curOffer.Offer
 
-				curOffer := offerOut
-				offerOut = s.GetNextOffer(offerOut)
 				blog.V(3).Infof("transaction %s get offer %s||%s ", transaction.ID, offer.GetHostname(), *(offer.Id.Value))
-				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "")
+				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "") &&
+					!s.IsOfferReserved(transaction, version.Priority, curOffer)
 				if isFit == true {
 					blog.V(3).Infof("transaction %s fit offer %s||%s ", transaction.ID, offer.GetHostname(), *(offer.Id.Value))
 					if s.UseOffer(curOffer) == true {
@@ -86,6 +92,9 @@ func (s *Scheduler) RunScaleApplication(transaction *Transaction) {
 				}
 
 			}
+
+			//no fit offer, try to preempt lower priority taskgroups
+			s.preemptForTransaction(transaction)
 		}
 
 		//check timeout
@@ -99,6 +108,7 @@ func (s *Scheduler) RunScaleApplication(transaction *Transaction) {
 	}
 
 run_end:
+	s.deletePendingTransaction(transaction)
 	s.FinishTransaction(transaction)
 	reportOperateAppMetrics(transaction.RunAs, transaction.AppID, ScaleApplicationType, startedApp)
 	blog.Infof("transaction %s scale(%s.%s) run end, result(%s)", transaction.ID, runAs, appID, transaction.Status)
@@ -138,15 +148,14 @@ func (s *Scheduler) RunInnerScaleApplication(transaction *Transaction) {
 				goto run_end
 			}
 		} else {
-			offerOut := s.GetFirstOffer()
-			for offerOut != nil {
-				offerIdx := offerOut.Id
-				offer := offerOut.Offer
+			s.addPendingTransaction(transaction, version, opData.NeedResource, "")
+			for _, curOffer := range s.GetScoredOffers(version, opData.NeedResource) {
+				offerIdx := curOffer.Id
+				offer := curOffer.Offer
 
-				curOffer := offerOut
-				offerOut = s.GetNextOffer(offerOut)
 				blog.V(3).Infof("transaction %s get offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
-				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "")
+				isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, "") &&
+					!s.IsOfferReserved(transaction, version.Priority, curOffer)
 				if isFit == true {
 					blog.V(3).Infof("transaction %s fit offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 					if s.UseOffer(curOffer) == true {
@@ -179,6 +188,7 @@ func (s *Scheduler) RunInnerScaleApplication(transaction *Transaction) {
 	}
 
 run_end:
+	s.deletePendingTransaction(transaction)
 	s.FinishTransaction(transaction)
 	blog.Infof("transaction %s innerscale(%s.%s) run end, result(%s)", transaction.ID, runAs, appID, transaction.Status)
 }
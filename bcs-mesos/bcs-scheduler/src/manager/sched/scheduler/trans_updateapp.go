@@ -37,6 +37,13 @@ func (s *Scheduler) RunUpdateApplication(transaction *Transaction) {
 	//var offerIdx int64 = 0
 	startedTaskgroup := time.Now()
 	startedApp := time.Now()
+
+	//check resource quota of namespace with the new version
+	updateData := transaction.OpData.(*TransAPIUpdateOpdata)
+	if s.rejectTransactionByQuota(transaction, updateData.Version.AllResource(), uint64(updateData.Version.Instances)) {
+		goto run_end
+	}
+
 	for {
 		blog.Infof("transaction %s update(%s.%s) run check", transaction.ID, runAs, appID)
 
@@ -51,18 +58,17 @@ func (s *Scheduler) RunUpdateApplication(transaction *Transaction) {
 		//check doing
 		opData := transaction.OpData.(*TransAPIUpdateOpdata)
 		version := opData.Version
-		offerOut := s.GetFirstOffer()
 
 		taskGroupID := opData.Taskgroups[opData.LaunchedNum].ID
-		for offerOut != nil {
-			offerIdx := offerOut.Id
-			offer := offerOut.Offer
+		s.addPendingTransaction(transaction, version, opData.NeedResource, taskGroupID)
+		for _, curOffer := range s.GetScoredOffers(version, opData.NeedResource) {
+			offerIdx := curOffer.Id
+			offer := curOffer.Offer
 
-			curOffer := offerOut
-			offerOut = s.GetNextOffer(offerOut)
 			blog.V(3).Infof("transaction %s get offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 
-			isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, taskGroupID)
+			isFit := s.IsOfferResourceFitLaunch(opData.NeedResource, curOffer) && s.IsConstraintsFit(version, offer, taskGroupID) &&
+				!s.IsOfferReserved(transaction, version.Priority, curOffer)
 			if isFit == true {
 				blog.V(3).Infof("transaction %s fit offer(%d) %s||%s ", transaction.ID, offerIdx, offer.GetHostname(), *(offer.Id.Value))
 				if s.UseOffer(curOffer) == true {
@@ -93,6 +99,7 @@ func (s *Scheduler) RunUpdateApplication(transaction *Transaction) {
 	}
 
 run_end:
+	s.deletePendingTransaction(transaction)
 	s.FinishTransaction(transaction)
 	reportOperateAppMetrics(transaction.RunAs, transaction.AppID, UpdateApplicationType, startedApp)
 	blog.Infof("transaction %s update(%s.%s) run end, result(%s)", transaction.ID, runAs, appID, transaction.Status)
@@ -32,6 +32,13 @@ func (s *Scheduler) RunUpdateApplicationResource(transaction *Transaction) {
 	appID := transaction.AppID
 
 	blog.Infof("transaction %s update resource for application(%s.%s) run begin", transaction.ID, runAs, appID)
+
+	//check resource quota of namespace with the new resources
+	updateData := transaction.OpData.(*TransAPIUpdateOpdata)
+	if s.rejectTransactionByQuota(transaction, updateData.Version.AllResource(), uint64(updateData.Version.Instances)) {
+		goto run_end
+	}
+
 	for {
 		blog.Infof("transaction %s update resource for application(%s.%s) run check", transaction.ID, runAs, appID)
 
@@ -39,6 +39,10 @@ const TRANSACTION_DEPLOYMENT_INNERDELETE_LIFEPERIOD = 300
 // If a transaction dosen't finish in its lifePeriod, it will be timeout
 const TRANSACTION_INNER_RESCHEDULE_LIFEPERIOD = 86400
 
+// Max lifePeriod for launching a daemonset taskgroup on one host, 86400 seconds
+// The transaction waits for the offer of the host, it is finished early when the daemonset is deleted
+const TRANSACTION_DAEMONSET_LAUNCH_LIFEPERIOD = 86400
+
 // If taskgroup running than 1800 seconds, the restart times will be reset to 0
 const TRANSACTION_RESCHEDULE_RESET_INTERVAL = 1800
 
@@ -54,6 +58,8 @@ type Transaction struct {
 	OpType string
 	// operation status: INIT, FINISH, FAIL, ERROR ...
 	Status string
+	// the reason of failure, set into the application message when finished
+	Message string
 	// operation data
 	OpData interface{}
 	// the seconds before transaction timeout
@@ -128,6 +134,16 @@ type TransRescheduleOpData struct {
 	HostRetain string
 }
 
+// Launch daemonset taskgroup transaction data
+type TransDaemonsetOpData struct {
+	// version definition for daemonset application
+	Version *types.Version
+	// resource for one taskgroup
+	NeedResource *types.Resource
+	// the host to launch taskgroup on
+	HostName string
+}
+
 // Create a transaction, ID, createTime will be initialized
 func CreateTransaction() *Transaction {
 	transaction := new(Transaction)
@@ -165,9 +181,12 @@ func (s *Scheduler) FinishTransaction(transaction *Transaction) {
 		s.SendHealthMsg(alarm.WarnKind, app.RunAs, "transaction("+transaction.ID+") timeout: "+transaction.OpType+" "+runAs+"."+appID, "", nil)
 	}
 
-	if transaction.OpType == types.OPERATION_INNERSCALE {
+	if transaction.OpType == types.OPERATION_INNERSCALE || transaction.OpType == types.OPERATION_DAEMONSET {
 		if transaction.Status != types.OPERATION_STATUS_FINISH {
 			app.Message = "application " + transaction.OpType + " " + transaction.Status
+			if transaction.Message != "" {
+				app.Message = transaction.Message
+			}
 			s.store.SaveApplication(app)
 		}
 		return
@@ -138,6 +138,9 @@ func (s *Scheduler) StatusReport(status *mesos.TaskStatus) {
 			bcsMsg = containerInfo.BcsMessage
 			task.IsChecked = containerInfo.IsChecked
 			task.ConsecutiveFailureTimes = uint32(containerInfo.ConsecutiveFailureTimes)
+			setCommandCheckMessage(task, containerInfo.HealthCheckMessage)
+			task.Ready = containerInfo.Ready
+			task.Started = containerInfo.Started
 		}
 	}
 	if oldData != "" && task.StatusData == "" {
@@ -238,11 +241,15 @@ func (s *Scheduler) checkTaskHealth(task *types.Task, taskGroupID string, health
 				}
 			}
 		}
-		//check health check ConsecutiveFailureTimes
-		if task.LocalMaxConsecutiveFailures > 0 {
-			if !task.Healthy && task.IsChecked && task.ConsecutiveFailureTimes > task.LocalMaxConsecutiveFailures {
+		//check health check ConsecutiveFailureTimes, executor reports startup probe failures before task started
+		maxConsecutiveFailures := task.LocalMaxConsecutiveFailures
+		if !task.IsStarted() {
+			maxConsecutiveFailures = task.StartupProbe.ConsecutiveFailures
+		}
+		if maxConsecutiveFailures > 0 {
+			if !task.Healthy && task.IsChecked && task.ConsecutiveFailureTimes > maxConsecutiveFailures {
 				blog.Infof("status report: task(%s) in running but not ConsecutiveFailureTimes(%d>%d), set to Failed",
-					task.ID, task.ConsecutiveFailureTimes, task.LocalMaxConsecutiveFailures)
+					task.ID, task.ConsecutiveFailureTimes, maxConsecutiveFailures)
 				healthyChg = true
 				task.Status = types.TASK_STATUS_FAIL
 				task.Message = "health check consecutive failure times over, kill by scheduler"
@@ -257,6 +264,18 @@ func (s *Scheduler) checkTaskHealth(task *types.Task, taskGroupID string, health
 	return healthyChg
 }
 
+// setCommandCheckMessage record exit code and output of command health check reported by executor
+func setCommandCheckMessage(task *types.Task, message string) {
+	if message == "" {
+		return
+	}
+	for _, healthStatus := range task.HealthCheckStatus {
+		if healthStatus.Type == bcstype.BcsHealthCheckType_COMMAND {
+			healthStatus.Message = message
+		}
+	}
+}
+
 func (s *Scheduler) checkApplicationChange(runAs, appId, taskGroupStatus string, taskGroup *types.TaskGroup, now int64) {
 
 	applicationUpdated := false
@@ -701,6 +720,13 @@ func (s *Scheduler) taskGroupStatusUpdated(taskGroup *types.TaskGroup, originSta
 			rescheduleOpdata.HostRetainTime = 0
 		}
 
+		// taskgroup of daemonset can only run on its own host
+		if app, _ := s.store.FetchApplication(runAs, appID); app != nil && app.Kind == bcstype.BcsDataType_DAEMONSET {
+			blog.Info("taskgroup(%s) belongs to daemonset, will rescheduled retain host(%s)", taskGroup.ID, taskGroup.HostName)
+			rescheduleOpdata.HostRetainTime = TRANSACTION_DAEMONSET_LAUNCH_LIFEPERIOD
+			rescheduleOpdata.HostRetain = taskGroup.HostName
+		}
+
 		// computer resource needed
 		//versions, err := s.store.ListVersions(runAs, appID)
 		//if err != nil {
@@ -788,6 +814,10 @@ func (s *Scheduler) UpdateTaskStatus(agentID, executorID string, bcsMsg *types.B
 
 	task.Status = reportStatus
 	task.StatusData = string(bcsMsg.TaskStatus)
+	// stats change every report, it is saved with other changes or every MAX_DATA_UPDATE_INTERVAL
+	if bcsMsg.TaskStats != nil {
+		task.Stats = bcsMsg.TaskStats
+	}
 
 	var msg *types.BcsMessage
 	if task.StatusData != oldData {
@@ -800,6 +830,9 @@ func (s *Scheduler) UpdateTaskStatus(agentID, executorID string, bcsMsg *types.B
 			msg = containerInfo.BcsMessage
 			task.IsChecked = containerInfo.IsChecked
 			task.ConsecutiveFailureTimes = uint32(containerInfo.ConsecutiveFailureTimes)
+			setCommandCheckMessage(task, containerInfo.HealthCheckMessage)
+			task.Ready = containerInfo.Ready
+			task.Started = containerInfo.Started
 		}
 	}
 	if oldData != "" && task.StatusData == "" {
@@ -0,0 +1,31 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+Package score provides the offer scoring pipeline of scheduler.
+
+The offers which fit the resources and constraints are ranked by the weighted
+score of the plugins in the policy, the offer with the highest score is used first.
+
+Currently, scorer plugins include:
+LeastAllocated
+MostAllocated
+Spread
+ImageLocality
+Affinity
+AppAffinity
+
+The policy is configured per cluster by score_policy, and can be overridden by
+the scorePolicy of application, deployment or job.
+*/
+package score
@@ -0,0 +1,277 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package score
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	offerP "bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxScore is the highest score a scorer plugin gives an offer
+const MaxScore = 100
+
+// Context is the taskgroup to be placed, it is shared by the scorers during one scoring
+type Context struct {
+	Version *types.Version
+	// resource needed by the taskgroup
+	Need *types.Resource
+	// TaskGroups lists the taskgroups of an application, used by Spread and AppAffinity
+	TaskGroups func(runAs, appID string) []*types.TaskGroup
+	// HostImages lists the images of the taskgroups on each host, used by ImageLocality
+	HostImages func() map[string][]string
+
+	maxFreeCPU float64
+	maxFreeMem float64
+
+	taskGroups       map[string][]*types.TaskGroup
+	hostImages       map[string][]string
+	hostImagesListed bool
+}
+
+func (ctx *Context) listTaskGroups(runAs, appID string) []*types.TaskGroup {
+	key := runAs + "." + appID
+	if taskGroups, ok := ctx.taskGroups[key]; ok {
+		return taskGroups
+	}
+	if ctx.TaskGroups == nil {
+		return nil
+	}
+	if ctx.taskGroups == nil {
+		ctx.taskGroups = make(map[string][]*types.TaskGroup)
+	}
+	ctx.taskGroups[key] = ctx.TaskGroups(runAs, appID)
+	return ctx.taskGroups[key]
+}
+
+func (ctx *Context) listHostImages() map[string][]string {
+	if !ctx.hostImagesListed && ctx.HostImages != nil {
+		ctx.hostImages = ctx.HostImages()
+	}
+	ctx.hostImagesListed = true
+	return ctx.hostImages
+}
+
+// prepare computes the max free resources of the offers, which the resource scorers normalize by
+func (ctx *Context) prepare(offers []*offerP.Offer) {
+	ctx.maxFreeCPU = 0
+	ctx.maxFreeMem = 0
+	for _, o := range offers {
+		cpu, mem := ctx.freeResource(o)
+		if cpu > ctx.maxFreeCPU {
+			ctx.maxFreeCPU = cpu
+		}
+		if mem > ctx.maxFreeMem {
+			ctx.maxFreeMem = mem
+		}
+	}
+}
+
+// freeResource returns the cpu and mem left on the offer after placing the taskgroup
+func (ctx *Context) freeResource(o *offerP.Offer) (float64, float64) {
+	var cpu, mem float64
+	for _, res := range o.Offer.GetResources() {
+		if res.GetName() == "cpus" {
+			cpu += res.GetScalar().GetValue()
+		}
+		if res.GetName() == "mem" {
+			mem += res.GetScalar().GetValue()
+		}
+	}
+	cpu -= o.DeltaCPU
+	mem -= o.DeltaMem
+	if ctx.Need != nil {
+		cpu -= ctx.Need.Cpus
+		mem -= ctx.Need.Mem
+	}
+	if cpu < 0 {
+		cpu = 0
+	}
+	if mem < 0 {
+		mem = 0
+	}
+	return cpu, mem
+}
+
+// Scorer gives an offer a score from 0 to MaxScore, the higher the better
+type Scorer interface {
+	Score(ctx *Context, o *offerP.Offer) float64
+}
+
+// ScorerFactory builds a scorer with the args of the plugin
+type ScorerFactory func(args string) (Scorer, error)
+
+var factories = map[string]ScorerFactory{
+	commtypes.Scorer_LeastAllocated: newLeastAllocated,
+	commtypes.Scorer_MostAllocated:  newMostAllocated,
+	commtypes.Scorer_Spread:         newSpread,
+	commtypes.Scorer_ImageLocality:  newImageLocality,
+	commtypes.Scorer_Affinity:       newAffinity,
+	commtypes.Scorer_AppAffinity:    newAppAffinity,
+}
+
+// RegisterScorer adds a scorer plugin, it must be called before the scheduler starts
+func RegisterScorer(name string, factory ScorerFactory) {
+	factories[name] = factory
+}
+
+// DefaultPolicy ranks the offers by free resources, as the offer pool did before scoring
+func DefaultPolicy() *commtypes.ScorePolicy {
+	return &commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{
+			{Name: commtypes.Scorer_LeastAllocated, Weight: 1},
+		},
+	}
+}
+
+// ParsePolicy parses the score policy of cluster, the format is name[:weight[:args]] split by comma,
+// for example "LeastAllocated:1,Spread:2:zone". Empty string means the default policy.
+func ParsePolicy(s string) (*commtypes.ScorePolicy, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultPolicy(), nil
+	}
+
+	policy := &commtypes.ScorePolicy{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		fields := strings.SplitN(item, ":", 3)
+		plugin := &commtypes.ScorePlugin{Name: fields[0], Weight: 1}
+		if len(fields) > 1 {
+			weight, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("score plugin %s weight %s is not integer", fields[0], fields[1])
+			}
+			plugin.Weight = weight
+		}
+		if len(fields) > 2 {
+			plugin.Args = fields[2]
+		}
+		policy.Plugins = append(policy.Plugins, plugin)
+	}
+
+	if _, err := NewPipeline(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+type pipelinePlugin struct {
+	name   string
+	weight int
+	scorer Scorer
+}
+
+// Pipeline is the scorers built from a score policy
+type Pipeline struct {
+	plugins []*pipelinePlugin
+}
+
+// NewPipeline builds the scorers of the policy, weight 0 means 1
+func NewPipeline(policy *commtypes.ScorePolicy) (*Pipeline, error) {
+	if policy == nil || len(policy.Plugins) == 0 {
+		return nil, errors.New("score policy has no plugins")
+	}
+
+	p := &Pipeline{}
+	for _, plugin := range policy.Plugins {
+		if plugin == nil {
+			continue
+		}
+		factory, ok := factories[plugin.Name]
+		if !ok {
+			return nil, fmt.Errorf("score plugin %s not supported", plugin.Name)
+		}
+		if plugin.Weight < 0 {
+			return nil, fmt.Errorf("score plugin %s weight %d is negative", plugin.Name, plugin.Weight)
+		}
+		scorer, err := factory(plugin.Args)
+		if err != nil {
+			return nil, fmt.Errorf("score plugin %s args %s invalid: %s", plugin.Name, plugin.Args, err.Error())
+		}
+		weight := plugin.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		name := plugin.Name
+		if plugin.Args != "" {
+			name = plugin.Name + ":" + plugin.Args
+		}
+		p.plugins = append(p.plugins, &pipelinePlugin{name: name, weight: weight, scorer: scorer})
+	}
+	if len(p.plugins) == 0 {
+		return nil, errors.New("score policy has no plugins")
+	}
+
+	return p, nil
+}
+
+func (p *Pipeline) hasPlugin(name string) bool {
+	for _, plugin := range p.plugins {
+		if plugin.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OfferScore is the weighted score of an offer, and the score of each plugin
+type OfferScore struct {
+	Offer  *offerP.Offer
+	Score  float64
+	Scores map[string]float64
+}
+
+// ScoreOffers scores the offers and sorts them from the highest score to the lowest,
+// the offers with the same score keep their order
+func (p *Pipeline) ScoreOffers(ctx *Context, offers []*offerP.Offer) []*OfferScore {
+	ctx.prepare(offers)
+
+	// preferred app affinity is always scored, add AppAffinity in policy to change its weight
+	plugins := append([]*pipelinePlugin{}, p.plugins...)
+	if hasPreferredAppAffinity(ctx.Version) && !p.hasPlugin(commtypes.Scorer_AppAffinity) {
+		plugins = append(plugins, &pipelinePlugin{name: commtypes.Scorer_AppAffinity, weight: 1, scorer: &appAffinity{}})
+	}
+
+	totalWeight := 0
+	for _, plugin := range plugins {
+		totalWeight += plugin.weight
+	}
+
+	scores := make([]*OfferScore, 0, len(offers))
+	for _, o := range offers {
+		s := &OfferScore{Offer: o, Scores: make(map[string]float64)}
+		for _, plugin := range plugins {
+			pluginScore := plugin.scorer.Score(ctx, o)
+			s.Scores[plugin.name] = pluginScore
+			s.Score += pluginScore * float64(plugin.weight)
+		}
+		s.Score = s.Score / float64(totalWeight)
+		blog.V(3).Infof("offer %s score %f: %v", o.Offer.GetHostname(), s.Score, s.Scores)
+		scores = append(scores, s)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
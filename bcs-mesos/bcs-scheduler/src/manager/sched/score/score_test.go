@@ -0,0 +1,222 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package score
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	offerP "bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOffer(hostname string, cpu, mem float64, attrs map[string]string) *offerP.Offer {
+	o := &mesos.Offer{
+		Hostname: proto.String(hostname),
+		Resources: []*mesos.Resource{
+			{Name: proto.String("cpus"), Type: mesos.Value_SCALAR.Enum(), Scalar: &mesos.Value_Scalar{Value: proto.Float64(cpu)}},
+			{Name: proto.String("mem"), Type: mesos.Value_SCALAR.Enum(), Scalar: &mesos.Value_Scalar{Value: proto.Float64(mem)}},
+		},
+	}
+	for k, v := range attrs {
+		o.Attributes = append(o.Attributes, &mesos.Attribute{
+			Name: proto.String(k),
+			Type: mesos.Value_TEXT.Enum(),
+			Text: &mesos.Value_Text{Value: proto.String(v)},
+		})
+	}
+	return &offerP.Offer{Offer: o}
+}
+
+func hostnames(scores []*OfferScore) []string {
+	var hosts []string
+	for _, s := range scores {
+		hosts = append(hosts, s.Offer.Offer.GetHostname())
+	}
+	return hosts
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultPolicy(), policy)
+
+	policy, err = ParsePolicy("LeastAllocated:2, Spread:1:zone ,Affinity:3:zone=a|b")
+	assert.Nil(t, err)
+	assert.Equal(t, []*commtypes.ScorePlugin{
+		{Name: commtypes.Scorer_LeastAllocated, Weight: 2},
+		{Name: commtypes.Scorer_Spread, Weight: 1, Args: "zone"},
+		{Name: commtypes.Scorer_Affinity, Weight: 3, Args: "zone=a|b"},
+	}, policy.Plugins)
+
+	_, err = ParsePolicy("LeastAllocated:x")
+	assert.NotNil(t, err)
+	_, err = ParsePolicy("Unknown:1")
+	assert.NotNil(t, err)
+	_, err = ParsePolicy("Affinity:1:zone")
+	assert.NotNil(t, err)
+	_, err = ParsePolicy("MostAllocated:-1")
+	assert.NotNil(t, err)
+}
+
+func TestScoreOffersByAllocation(t *testing.T) {
+	offers := []*offerP.Offer{
+		newTestOffer("small", 2, 2048, nil),
+		newTestOffer("large", 8, 8192, nil),
+		newTestOffer("medium", 4, 4096, nil),
+	}
+	ctx := &Context{Need: &types.Resource{Cpus: 1, Mem: 1024}}
+
+	least, err := NewPipeline(DefaultPolicy())
+	assert.Nil(t, err)
+	scores := least.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"large", "medium", "small"}, hostnames(scores))
+	assert.Equal(t, float64(MaxScore), scores[0].Score)
+
+	most, err := NewPipeline(&commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{{Name: commtypes.Scorer_MostAllocated}},
+	})
+	assert.Nil(t, err)
+	scores = most.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"small", "medium", "large"}, hostnames(scores))
+	assert.Equal(t, float64(0), scores[2].Score)
+}
+
+func TestScoreOffersBySpread(t *testing.T) {
+	offers := []*offerP.Offer{
+		newTestOffer("host1", 4, 4096, map[string]string{"zone": "a"}),
+		newTestOffer("host2", 4, 4096, map[string]string{"zone": "b"}),
+		newTestOffer("host3", 4, 4096, nil),
+	}
+	zoneA := &mesos.Attribute{
+		Name: proto.String("zone"),
+		Type: mesos.Value_TEXT.Enum(),
+		Text: &mesos.Value_Text{Value: proto.String("a")},
+	}
+	ctx := &Context{
+		Version: &types.Version{RunAs: "ns", ID: "app"},
+		Need:    &types.Resource{Cpus: 1, Mem: 1024},
+		TaskGroups: func(runAs, appID string) []*types.TaskGroup {
+			return []*types.TaskGroup{
+				{HostName: "host1", Status: types.TASKGROUP_STATUS_RUNNING, Attributes: []*mesos.Attribute{zoneA}},
+				{HostName: "host1", Status: types.TASKGROUP_STATUS_FAIL, Attributes: []*mesos.Attribute{zoneA}},
+			}
+		},
+	}
+
+	pipeline, err := NewPipeline(&commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{{Name: commtypes.Scorer_Spread, Args: "zone"}},
+	})
+	assert.Nil(t, err)
+	scores := pipeline.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"host2", "host1", "host3"}, hostnames(scores))
+	assert.Equal(t, float64(MaxScore)/2, scores[1].Score)
+}
+
+func TestScoreOffersByImageLocalityAndAffinity(t *testing.T) {
+	offers := []*offerP.Offer{
+		newTestOffer("host1", 8, 8192, map[string]string{"zone": "a"}),
+		newTestOffer("host2", 4, 4096, map[string]string{"zone": "b"}),
+		newTestOffer("host3", 4, 4096, map[string]string{"zone": "c"}),
+	}
+	ctx := &Context{
+		Version: &types.Version{
+			Container: []*types.Container{
+				{Docker: &types.Docker{Image: "nginx:1.0"}},
+				{Docker: &types.Docker{Image: "sidecar:1.0"}},
+			},
+		},
+		Need: &types.Resource{Cpus: 1, Mem: 1024},
+		HostImages: func() map[string][]string {
+			return map[string][]string{
+				"host2": {"nginx:1.0"},
+				"host3": {"nginx:1.0", "sidecar:1.0", "other:1.0"},
+			}
+		},
+	}
+
+	pipeline, err := NewPipeline(&commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{{Name: commtypes.Scorer_ImageLocality}},
+	})
+	assert.Nil(t, err)
+	scores := pipeline.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"host3", "host2", "host1"}, hostnames(scores))
+
+	pipeline, err = NewPipeline(&commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{
+			{Name: commtypes.Scorer_ImageLocality, Weight: 1},
+			{Name: commtypes.Scorer_Affinity, Weight: 3, Args: "zone=b"},
+		},
+	})
+	assert.Nil(t, err)
+	scores = pipeline.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"host2", "host3", "host1"}, hostnames(scores))
+	assert.Equal(t, float64(MaxScore), scores[0].Scores["Affinity:zone=b"])
+}
+
+func TestScoreOffersByAppAffinity(t *testing.T) {
+	offers := []*offerP.Offer{
+		newTestOffer("host1", 8, 8192, map[string]string{"zone": "a"}),
+		newTestOffer("host2", 4, 4096, map[string]string{"zone": "b"}),
+		newTestOffer("host3", 4, 4096, map[string]string{"zone": "b"}),
+	}
+	ctx := &Context{
+		Version: &types.Version{
+			RunAs: "ns",
+			ID:    "web",
+			Constraints: &commtypes.Constraint{
+				AppAffinity: &commtypes.AppAffinity{
+					Preferred: []*commtypes.WeightedAppAffinityTerm{
+						{Weight: 80, Term: commtypes.AppAffinityTerm{Applications: []string{"cache"}, TopologyKey: "zone"}},
+					},
+				},
+				AppAntiAffinity: &commtypes.AppAffinity{
+					Preferred: []*commtypes.WeightedAppAffinityTerm{
+						{Weight: 20, Term: commtypes.AppAffinityTerm{Applications: []string{"batch"}}},
+					},
+				},
+			},
+		},
+		Need: &types.Resource{Cpus: 1, Mem: 1024},
+		TaskGroups: func(runAs, appID string) []*types.TaskGroup {
+			switch appID {
+			case "cache":
+				zoneB := &mesos.Attribute{
+					Name: proto.String("zone"),
+					Type: mesos.Value_TEXT.Enum(),
+					Text: &mesos.Value_Text{Value: proto.String("b")},
+				}
+				return []*types.TaskGroup{{HostName: "host2", Status: types.TASKGROUP_STATUS_RUNNING, Attributes: []*mesos.Attribute{zoneB}}}
+			case "batch":
+				return []*types.TaskGroup{{HostName: "host2", Status: types.TASKGROUP_STATUS_RUNNING}}
+			}
+			return nil
+		},
+	}
+
+	//AppAffinity is added to the policy, as the version has preferred terms
+	pipeline, err := NewPipeline(&commtypes.ScorePolicy{
+		Plugins: []*commtypes.ScorePlugin{{Name: commtypes.Scorer_ImageLocality}},
+	})
+	assert.Nil(t, err)
+	scores := pipeline.ScoreOffers(ctx, offers)
+	assert.Equal(t, []string{"host3", "host2", "host1"}, hostnames(scores))
+	assert.Equal(t, float64(MaxScore), scores[0].Scores[commtypes.Scorer_AppAffinity])
+	assert.Equal(t, float64(80), scores[1].Scores[commtypes.Scorer_AppAffinity])
+	assert.Equal(t, float64(20), scores[2].Scores[commtypes.Scorer_AppAffinity])
+}
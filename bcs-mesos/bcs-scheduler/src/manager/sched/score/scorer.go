@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package score
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	offerP "bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/strategy"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"errors"
+	"strings"
+)
+
+// leastAllocated prefers the offer with the most cpu and mem left after placing the taskgroup,
+// the free resources are normalized by the max free resources of all the offers
+type leastAllocated struct{}
+
+func newLeastAllocated(args string) (Scorer, error) {
+	return &leastAllocated{}, nil
+}
+
+func (l *leastAllocated) Score(ctx *Context, o *offerP.Offer) float64 {
+	cpu, mem := ctx.freeResource(o)
+	score := 0.0
+	if ctx.maxFreeCPU > 0 {
+		score += cpu / ctx.maxFreeCPU * MaxScore
+	}
+	if ctx.maxFreeMem > 0 {
+		score += mem / ctx.maxFreeMem * MaxScore
+	}
+	return score / 2
+}
+
+// mostAllocated prefers the offer with the least cpu and mem left, it packs the taskgroups on fewer agents
+type mostAllocated struct {
+	least leastAllocated
+}
+
+func newMostAllocated(args string) (Scorer, error) {
+	return &mostAllocated{}, nil
+}
+
+func (m *mostAllocated) Score(ctx *Context, o *offerP.Offer) float64 {
+	return MaxScore - m.least.Score(ctx, o)
+}
+
+// spread prefers the offer whose attribute value has the fewest running taskgroups of the application
+type spread struct {
+	attribute string
+}
+
+func newSpread(args string) (Scorer, error) {
+	if args == "" {
+		args = "hostname"
+	}
+	return &spread{attribute: args}, nil
+}
+
+func (s *spread) Score(ctx *Context, o *offerP.Offer) float64 {
+	value := strategy.OfferAttributeValue(o.Offer, s.attribute)
+	if value == "" {
+		return 0
+	}
+
+	if ctx.Version == nil {
+		return MaxScore
+	}
+
+	count := 0
+	for _, taskGroup := range ctx.listTaskGroups(ctx.Version.RunAs, ctx.Version.ID) {
+		if taskGroup.Status == types.TASKGROUP_STATUS_FINISH || taskGroup.Status == types.TASKGROUP_STATUS_FAIL {
+			continue
+		}
+		if strategy.TaskGroupAttributeValue(taskGroup, s.attribute) == value {
+			count++
+		}
+	}
+
+	return MaxScore / float64(count+1)
+}
+
+// imageLocality prefers the offer whose agent already has the images of the taskgroup
+type imageLocality struct{}
+
+func newImageLocality(args string) (Scorer, error) {
+	return &imageLocality{}, nil
+}
+
+func (i *imageLocality) Score(ctx *Context, o *offerP.Offer) float64 {
+	if ctx.Version == nil {
+		return 0
+	}
+	images := make(map[string]bool)
+	for _, container := range ctx.Version.Container {
+		if container != nil && container.Docker != nil && container.Docker.Image != "" {
+			images[container.Docker.Image] = true
+		}
+	}
+	if len(images) == 0 {
+		return 0
+	}
+
+	found := make(map[string]bool)
+	for _, image := range ctx.listHostImages()[o.Offer.GetHostname()] {
+		if images[image] {
+			found[image] = true
+		}
+	}
+
+	return float64(len(found)) / float64(len(images)) * MaxScore
+}
+
+// affinity prefers the offer whose attribute matches one of the values, args is name=value1|value2
+type affinity struct {
+	attribute string
+	values    []string
+}
+
+func newAffinity(args string) (Scorer, error) {
+	kv := strings.SplitN(args, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return nil, errors.New("args should be name=value1|value2")
+	}
+	return &affinity{attribute: kv[0], values: strings.Split(kv[1], "|")}, nil
+}
+
+func (a *affinity) Score(ctx *Context, o *offerP.Offer) float64 {
+	value := strategy.OfferAttributeValue(o.Offer, a.attribute)
+	for _, v := range a.values {
+		if value != "" && v == value {
+			return MaxScore
+		}
+	}
+	return 0
+}
+
+// appAffinity scores the preferred app affinity and anti-affinity terms of the version,
+// the score is the weight of the fit terms divided by the weight of all terms
+type appAffinity struct{}
+
+func newAppAffinity(args string) (Scorer, error) {
+	return &appAffinity{}, nil
+}
+
+func hasPreferredAppAffinity(version *types.Version) bool {
+	if version == nil || version.Constraints == nil {
+		return false
+	}
+	constraints := version.Constraints
+	return (constraints.AppAffinity != nil && len(constraints.AppAffinity.Preferred) > 0) ||
+		(constraints.AppAntiAffinity != nil && len(constraints.AppAntiAffinity.Preferred) > 0)
+}
+
+func (a *appAffinity) Score(ctx *Context, o *offerP.Offer) float64 {
+	if !hasPreferredAppAffinity(ctx.Version) {
+		return 0
+	}
+
+	lister := func(runAs, appID string) ([]*types.TaskGroup, error) {
+		return ctx.listTaskGroups(runAs, appID), nil
+	}
+	total := 0
+	fit := 0
+	score := func(terms []*commtypes.WeightedAppAffinityTerm, anti bool) {
+		for _, term := range terms {
+			if term == nil {
+				continue
+			}
+			total += term.Weight
+			match, _, err := strategy.AppAffinityTermMatch(&term.Term, ctx.Version, o.Offer, "", lister)
+			if err != nil {
+				blog.Errorf("score app affinity of offer %s err: %s", o.Offer.GetHostname(), err.Error())
+				continue
+			}
+			if match != anti {
+				fit += term.Weight
+			}
+		}
+	}
+	if ctx.Version.Constraints.AppAffinity != nil {
+		score(ctx.Version.Constraints.AppAffinity.Preferred, false)
+	}
+	if ctx.Version.Constraints.AppAntiAffinity != nil {
+		score(ctx.Version.Constraints.AppAntiAffinity.Preferred, true)
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(fit) / float64(total) * MaxScore
+}
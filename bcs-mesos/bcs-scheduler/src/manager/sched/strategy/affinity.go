@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package strategy
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	offerP "bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"strconv"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// AppTaskGroupsLister lists the taskgroups of an application
+type AppTaskGroupsLister func(runAs, appID string) ([]*types.TaskGroup, error)
+
+// OfferAttributeValue returns the text or scalar value of the offer attribute, hostname is a built-in attribute
+func OfferAttributeValue(offer *mesos.Offer, name string) string {
+	if name == "" || name == "hostname" {
+		return offer.GetHostname()
+	}
+	attribute, _ := offerP.GetOfferAttribute(offer, name)
+	return attributeValue(attribute)
+}
+
+// TaskGroupAttributeValue returns the value of the attribute of the agent which the taskgroup is running on
+func TaskGroupAttributeValue(taskGroup *types.TaskGroup, name string) string {
+	if name == "" || name == "hostname" {
+		return taskGroup.HostName
+	}
+	for _, attribute := range taskGroup.Attributes {
+		if attribute.GetName() == name {
+			return attributeValue(attribute)
+		}
+	}
+	return ""
+}
+
+func attributeValue(attribute *mesos.Attribute) string {
+	if attribute == nil {
+		return ""
+	}
+	switch attribute.GetType() {
+	case mesos.Value_TEXT:
+		return attribute.GetText().GetValue()
+	case mesos.Value_SCALAR:
+		return strconv.FormatFloat(attribute.GetScalar().GetValue(), 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// AppAffinityTermMatch checks whether the topology domain of the offer has active taskgroups of the term applications,
+// the taskgroup taskgroupID is skipped, as it is the one to be rescheduled.
+// If the term selects the application itself and no taskgroups exist, required affinity is fit, so the first
+// taskgroup can be placed, selfFit returns it. An application not existing has no taskgroups.
+func AppAffinityTermMatch(term *commtypes.AppAffinityTerm, version *types.Version, offer *mesos.Offer,
+	taskgroupID string, lister AppTaskGroupsLister) (match bool, selfFit bool, err error) {
+
+	namespace := term.Namespace
+	if namespace == "" {
+		namespace = version.RunAs
+	}
+	value := OfferAttributeValue(offer, term.TopologyKey)
+
+	selfFit = true
+	for _, appID := range term.Applications {
+		taskGroups, err := lister(namespace, appID)
+		if err == zk.ErrNoNode {
+			continue
+		}
+		if err != nil {
+			return false, false, err
+		}
+		for _, taskGroup := range taskGroups {
+			if (taskgroupID != "" && taskGroup.ID == taskgroupID) ||
+				taskGroup.Status == types.TASKGROUP_STATUS_FINISH || taskGroup.Status == types.TASKGROUP_STATUS_FAIL {
+				continue
+			}
+			selfFit = false
+			if value != "" && TaskGroupAttributeValue(taskGroup, term.TopologyKey) == value {
+				return true, false, nil
+			}
+		}
+	}
+
+	if selfFit {
+		selfFit = false
+		for _, appID := range term.Applications {
+			if namespace == version.RunAs && appID == version.ID {
+				selfFit = true
+			}
+		}
+	}
+
+	return false, selfFit, nil
+}
+
+// checkAppAffinity checks the required app affinity and anti-affinity terms of the version
+func checkAppAffinity(version *types.Version, offer *mesos.Offer, store store.Store, taskgroupID string) (bool, error) {
+	constraints := version.Constraints
+	if constraints == nil {
+		return true, nil
+	}
+
+	lister := func(runAs, appID string) ([]*types.TaskGroup, error) {
+		store.LockApplication(runAs + "." + appID)
+		defer store.UnLockApplication(runAs + "." + appID)
+		return store.ListTaskGroups(runAs, appID)
+	}
+
+	if constraints.AppAffinity != nil {
+		for _, term := range constraints.AppAffinity.Required {
+			if term == nil {
+				continue
+			}
+			match, selfFit, err := AppAffinityTermMatch(term, version, offer, taskgroupID, lister)
+			if err != nil {
+				blog.Errorf("app affinity: list taskgroups of %s.%v err: %s", term.Namespace, term.Applications, err.Error())
+				return false, err
+			}
+			if !match && !selfFit {
+				blog.V(3).Infof("app affinity: offer from %s has no taskgroups of %s.%v by %s, not fit",
+					offer.GetHostname(), term.Namespace, term.Applications, term.TopologyKey)
+				return false, nil
+			}
+		}
+	}
+
+	if constraints.AppAntiAffinity != nil {
+		for _, term := range constraints.AppAntiAffinity.Required {
+			if term == nil {
+				continue
+			}
+			match, _, err := AppAffinityTermMatch(term, version, offer, taskgroupID, lister)
+			if err != nil {
+				blog.Errorf("app anti-affinity: list taskgroups of %s.%v err: %s", term.Namespace, term.Applications, err.Error())
+				return false, err
+			}
+			if match {
+				blog.V(3).Infof("app anti-affinity: offer from %s has taskgroups of %s.%v by %s, not fit",
+					offer.GetHostname(), term.Namespace, term.Applications, term.TopologyKey)
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
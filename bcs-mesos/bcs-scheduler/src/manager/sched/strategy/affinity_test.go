@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package strategy
+
+import (
+	"errors"
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func newZoneAttribute(zone string) *mesos.Attribute {
+	return &mesos.Attribute{
+		Name: proto.String("zone"),
+		Type: mesos.Value_TEXT.Enum(),
+		Text: &mesos.Value_Text{Value: proto.String(zone)},
+	}
+}
+
+func TestAppAffinityTermMatch(t *testing.T) {
+	version := &types.Version{RunAs: "ns", ID: "web"}
+	offer := &mesos.Offer{
+		Hostname:   proto.String("host1"),
+		Attributes: []*mesos.Attribute{newZoneAttribute("a")},
+	}
+	taskGroups := map[string][]*types.TaskGroup{
+		"ns.cache": {
+			{ID: "cache-0", HostName: "host2", Status: types.TASKGROUP_STATUS_RUNNING, Attributes: []*mesos.Attribute{newZoneAttribute("a")}},
+			{ID: "cache-1", HostName: "host1", Status: types.TASKGROUP_STATUS_FAIL, Attributes: []*mesos.Attribute{newZoneAttribute("a")}},
+		},
+		"other.db": {
+			{ID: "db-0", HostName: "host1", Status: types.TASKGROUP_STATUS_RUNNING},
+		},
+		"ns.web": {
+			{ID: "web-0", HostName: "host1", Status: types.TASKGROUP_STATUS_LOST},
+		},
+	}
+	lister := func(runAs, appID string) ([]*types.TaskGroup, error) {
+		return taskGroups[runAs+"."+appID], nil
+	}
+
+	//same zone, but the running taskgroup is on another host
+	match, selfFit, err := AppAffinityTermMatch(&commtypes.AppAffinityTerm{Applications: []string{"cache"}, TopologyKey: "zone"},
+		version, offer, "", lister)
+	assert.Nil(t, err)
+	assert.True(t, match)
+	assert.False(t, selfFit)
+	match, _, _ = AppAffinityTermMatch(&commtypes.AppAffinityTerm{Applications: []string{"cache"}}, version, offer, "", lister)
+	assert.False(t, match)
+
+	//namespace of term
+	match, _, _ = AppAffinityTermMatch(&commtypes.AppAffinityTerm{Namespace: "other", Applications: []string{"db"}},
+		version, offer, "", lister)
+	assert.True(t, match)
+
+	//the taskgroup to be rescheduled is skipped, the first taskgroup of itself is fit for affinity
+	match, selfFit, _ = AppAffinityTermMatch(&commtypes.AppAffinityTerm{Applications: []string{"web"}}, version, offer, "web-0", lister)
+	assert.False(t, match)
+	assert.True(t, selfFit)
+	match, selfFit, _ = AppAffinityTermMatch(&commtypes.AppAffinityTerm{Applications: []string{"web"}}, version, offer, "", lister)
+	assert.True(t, match)
+	assert.False(t, selfFit)
+
+	_, _, err = AppAffinityTermMatch(&commtypes.AppAffinityTerm{Applications: []string{"web"}}, version, offer, "",
+		func(runAs, appID string) ([]*types.TaskGroup, error) { return nil, errors.New("store error") })
+	assert.NotNil(t, err)
+}
+
+//taskGroupStore lists the taskgroups in map, the applications not in map do not exist
+type taskGroupStore struct {
+	store.Store
+	taskGroups map[string][]*types.TaskGroup
+}
+
+func (s *taskGroupStore) LockApplication(appID string)   {}
+func (s *taskGroupStore) UnLockApplication(appID string) {}
+
+func (s *taskGroupStore) ListTaskGroups(runAs, appID string) ([]*types.TaskGroup, error) {
+	taskGroups, ok := s.taskGroups[runAs+"."+appID]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	return taskGroups, nil
+}
+
+func TestCheckAppAffinityNotExistApp(t *testing.T) {
+	offer := &mesos.Offer{Hostname: proto.String("host1")}
+	db := &taskGroupStore{taskGroups: map[string][]*types.TaskGroup{
+		"ns.cache": {{ID: "cache-0", HostName: "host1", Status: types.TASKGROUP_STATUS_RUNNING}},
+	}}
+	term := func(apps ...string) *commtypes.AppAffinity {
+		return &commtypes.AppAffinity{Required: []*commtypes.AppAffinityTerm{{Applications: apps}}}
+	}
+
+	//no taskgroups to be close to
+	version := &types.Version{RunAs: "ns", ID: "web", Constraints: &commtypes.Constraint{AppAffinity: term("db")}}
+	fit, err := checkAppAffinity(version, offer, db, "")
+	assert.Nil(t, err)
+	assert.False(t, fit)
+
+	//the first taskgroup of itself is fit
+	version.Constraints.AppAffinity = term("web")
+	fit, err = checkAppAffinity(version, offer, db, "")
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	//the other existing application is still checked
+	version.Constraints.AppAffinity = term("db", "cache")
+	fit, err = checkAppAffinity(version, offer, db, "")
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	//no taskgroups to be away from
+	version.Constraints = &commtypes.Constraint{AppAntiAffinity: term("db")}
+	fit, err = checkAppAffinity(version, offer, db, "")
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	version.Constraints.AppAntiAffinity = term("db", "cache")
+	fit, err = checkAppAffinity(version, offer, db, "")
+	assert.Nil(t, err)
+	assert.False(t, fit)
+}
@@ -25,5 +25,9 @@ GROUPBY
 CLUSTER
 GREATER
 EXCLUDE
+
+and the required affinity and anti-affinity between applications:
+AppAffinity
+AppAntiAffinity
 */
 package strategy
@@ -58,7 +58,13 @@ func ConstraintsFit(version *types.Version, offer *mesos.Offer, store store.Stor
 		}
 	}
 
-	isFit, err := checkRequestIP(version, offer, store, taskgroupID)
+	isFit, err := checkAppAffinity(version, offer, store, taskgroupID)
+	if err != nil || isFit == false {
+		blog.V(3).Infof("app affinity constraint not fit, taskgroupID(%s)", taskgroupID)
+		return false, err
+	}
+
+	isFit, err = checkRequestIP(version, offer, store, taskgroupID)
 	if err != nil {
 		blog.V(3).Infof("requestip constraint check error(%s)", err.Error())
 		return isFit, err
@@ -73,6 +79,44 @@ func ConstraintsFit(version *types.Version, offer *mesos.Offer, store store.Stor
 	return true, nil
 }
 
+// AttributeConstraintsFit check whether the attributes of an agent match with the constraints of application.
+// The constraints depending on where the taskgroups are placed (UNIQUE, GROUPBY, MAXPER, EXCLUDE) are
+// regarded as fit, they are checked with the offers when launching
+func AttributeConstraintsFit(version *types.Version, offer *mesos.Offer) bool {
+	if version.Constraints == nil {
+		return true
+	}
+
+	for _, oneConstraint := range version.Constraints.IntersectionItem {
+		if oneConstraint == nil {
+			continue
+		}
+		isFit := false
+		for _, constraintData := range oneConstraint.UnionData {
+			if constraintData == nil {
+				continue
+			}
+			switch constraintData.Operate {
+			case commtypes.Constraint_Type_UNIQUE, commtypes.Constraint_Type_GROUP_BY,
+				commtypes.Constraint_Type_MAX_PER, commtypes.Constraint_Type_EXCLUDE:
+				isFit = true
+			default:
+				isFit, _ = contraintDataFit(constraintData, offer, version, nil)
+			}
+			if isFit {
+				break
+			}
+		}
+		if !isFit {
+			blog.V(3).Infof("agent %s attributes not fit constraints of version(%s.%s)",
+				offer.GetHostname(), version.RunAs, version.ID)
+			return false
+		}
+	}
+
+	return true
+}
+
 func constraintDataItemFit(constraintItem *commtypes.ConstraintDataItem, offer *mesos.Offer, version *types.Version, store store.Store) (bool, error) {
 
 	i := 0
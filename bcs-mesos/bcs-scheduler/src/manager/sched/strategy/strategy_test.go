@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package strategy
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeConstraintsFit(t *testing.T) {
+	offer := &mesos.Offer{
+		Hostname:   proto.String("host1"),
+		Attributes: []*mesos.Attribute{newZoneAttribute("a")},
+	}
+	version := &types.Version{RunAs: "ns", ID: "ds"}
+	assert.True(t, AttributeConstraintsFit(version, offer))
+
+	zoneLike := func(zone string) *commtypes.ConstraintData {
+		return &commtypes.ConstraintData{Name: "zone", Operate: commtypes.Constraint_Type_LIKE,
+			Type: commtypes.ConstValueType_Text, Text: &commtypes.ConstraintValue_Text{Value: zone}}
+	}
+	version.Constraints = &commtypes.Constraint{IntersectionItem: []*commtypes.ConstraintDataItem{
+		{UnionData: []*commtypes.ConstraintData{zoneLike("a")}},
+	}}
+	assert.True(t, AttributeConstraintsFit(version, offer))
+
+	version.Constraints.IntersectionItem[0].UnionData[0] = zoneLike("b")
+	assert.False(t, AttributeConstraintsFit(version, offer))
+
+	//attribute not exist on agent
+	version.Constraints.IntersectionItem[0].UnionData[0] = &commtypes.ConstraintData{Name: "rack",
+		Operate: commtypes.Constraint_Type_CLUSTER, Type: commtypes.ConstValueType_Text,
+		Text: &commtypes.ConstraintValue_Text{Value: "r1"}}
+	assert.False(t, AttributeConstraintsFit(version, offer))
+
+	//constraints depending on taskgroups placement are not checked, store is never used
+	version.Constraints.IntersectionItem = []*commtypes.ConstraintDataItem{
+		{UnionData: []*commtypes.ConstraintData{{Name: "hostname", Operate: commtypes.Constraint_Type_UNIQUE}}},
+		{UnionData: []*commtypes.ConstraintData{{Name: "zone", Operate: commtypes.Constraint_Type_MAX_PER}}},
+		{UnionData: []*commtypes.ConstraintData{zoneLike("b"), {Name: "zone", Operate: commtypes.Constraint_Type_GROUP_BY}}},
+	}
+	assert.True(t, AttributeConstraintsFit(version, offer))
+}
@@ -16,6 +16,7 @@ package task
 import (
 	"bk-bcs/bcs-common/common/blog"
 	"bk-bcs/bcs-common/common/encrypt"
+	"bk-bcs/bcs-common/common/secretbackend"
 	"bk-bcs/bcs-common/common/static"
 	commtypes "bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
@@ -31,11 +32,14 @@ var BcsContainerExecutorPath string
 var BcsProcessExecutorPath string
 var BcsCniDir string
 var NetImage string
+var ContainerRuntime string
+var CRIEndpoint string
+var CRIImageTool string
 var Passwd = static.BcsDefaultPasswd
 var User = static.BcsDefaultUser
 
 //init mesos executor info
-func InitExecutorInfo(CExec, PExec, CniDir, netImage string) {
+func InitExecutorInfo(CExec, PExec, CniDir, netImage, runtime, criEndpoint, criImageTool string) {
 	if CExec != "" {
 		BcsContainerExecutorPath = CExec
 	} else {
@@ -55,6 +59,11 @@ func InitExecutorInfo(CExec, PExec, CniDir, netImage string) {
 	if netImage != "" {
 		NetImage = netImage
 	}
+
+	//container runtime of executor, empty for docker
+	ContainerRuntime = runtime
+	CRIEndpoint = criEndpoint
+	CRIImageTool = criImageTool
 }
 
 //CreateBcsExecutorInfo special the bcs executor
@@ -126,7 +135,12 @@ func CreateBcsExecutorInfo(offer *mesos.Offer /*cmdOrUri string,*/, taskGroupID
 					return nil
 				}
 
-				userBase := strings.TrimSpace(bcsSecretItem.Content)
+				userContent, err := secretbackend.Default().Resolve(&bcsSecretItem)
+				if err != nil {
+					blog.Error("resolve bcssecret(%s.%s) item(key:%s) err: %s", secretNs, secretName, secretKey, err.Error())
+					return nil
+				}
+				userBase := strings.TrimSpace(userContent)
 				if userBase != "" {
 					userScrt, err := base64.StdEncoding.DecodeString(userBase)
 					if err != nil {
@@ -188,7 +202,12 @@ func CreateBcsExecutorInfo(offer *mesos.Offer /*cmdOrUri string,*/, taskGroupID
 					return nil
 				}
 
-				passwdBase := strings.TrimSpace(bcsSecretItem.Content)
+				passwdContent, err := secretbackend.Default().Resolve(&bcsSecretItem)
+				if err != nil {
+					blog.Error("resolve bcssecret(%s.%s) item(key:%s) err: %s", secretNs, secretName, secretKey, err.Error())
+					return nil
+				}
+				passwdBase := strings.TrimSpace(passwdContent)
 				if passwdBase != "" {
 					passwdScrt, err := base64.StdEncoding.DecodeString(passwdBase)
 					if err != nil {
@@ -224,8 +243,19 @@ func CreateBcsExecutorInfo(offer *mesos.Offer /*cmdOrUri string,*/, taskGroupID
 			netImage = fmt.Sprintf(" --network-image %s", NetImage)
 		}
 
+		var containerRuntime string
+		if ContainerRuntime != "" {
+			containerRuntime = fmt.Sprintf(" --container-runtime %s", ContainerRuntime)
+			if CRIEndpoint != "" {
+				containerRuntime += fmt.Sprintf(" --cri-endpoint %s", CRIEndpoint)
+			}
+			if CRIImageTool != "" {
+				containerRuntime += fmt.Sprintf(" --cri-image-tool %s", CRIImageTool)
+			}
+		}
+
 		uuid, _ := encrypt.DesEncryptToBase([]byte(passwd))
-		execCommand = fmt.Sprintf("./%s --user %s --uuid %s %s %s %s", base, user, string(uuid), networkType, cniDir, netImage)
+		execCommand = fmt.Sprintf("./%s --user %s --uuid %s %s %s %s %s", base, user, string(uuid), networkType, cniDir, netImage, containerRuntime)
 	}
 
 	//blog.Infof("exec:%s", execCommand)
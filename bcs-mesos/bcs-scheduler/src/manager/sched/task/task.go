@@ -16,6 +16,7 @@ package task
 import (
 	"bk-bcs/bcs-common/common/blog"
 	"bk-bcs/bcs-common/common/codec"
+	"bk-bcs/bcs-common/common/secretbackend"
 	bcstype "bk-bcs/bcs-common/common/types"
 	commtypes "bk-bcs/bcs-common/common/types"
 	offerP "bk-bcs/bcs-mesos/bcs-scheduler/src/manager/sched/offer"
@@ -189,6 +190,7 @@ func CreateTaskGroup(version *types.Version, ID string, appInstances uint64, app
 			task.AppId = version.ID
 			task.RunAs = version.RunAs
 			task.Name = fmt.Sprintf("%d-%s", idTime, task.ID)
+			task.ContainerName = container.Name
 			task.Hostame = container.Docker.Hostname
 			task.Image = container.Docker.Image
 			task.ImagePullUser = container.Docker.ImagePullUser
@@ -253,7 +255,13 @@ func CreateTaskGroup(version *types.Version, ID string, appInstances uint64, app
 			if version.KillPolicy != nil {
 				task.KillPolicy = version.KillPolicy
 			}
-			createTaskHealthChecks(&task, container.HealthChecks)
+			createTaskHealthChecks(&task, livenessHealthChecks(container))
+			createTaskProbes(&task, container)
+			task.Lifecycle = container.Lifecycle
+			//init containers are delivered with the first task
+			if index == 0 {
+				task.InitContainers = version.InitContainers
+			}
 
 			taskgroup.Taskgroup = append(taskgroup.Taskgroup, &task)
 		}
@@ -276,6 +284,7 @@ func CreateTaskGroup(version *types.Version, ID string, appInstances uint64, app
 	taskgroup.RunAs = version.RunAs
 	taskgroup.LaunchResource = version.AllResource()
 	taskgroup.CurrResource = version.AllResource()
+	taskgroup.Priority = version.Priority
 	taskgroup.Status = types.TASKGROUP_STATUS_STAGING
 	taskgroup.UpdateTime = time.Now().Unix()
 	taskgroup.LastUpdateTime = time.Now().Unix()
@@ -407,6 +416,22 @@ func createTaskSecrets(task *types.Task, secrets []commtypes.Secret, store store
 			}
 			msg.Secret.Name = proto.String(secretItem.KeyOrPath)
 			msg.Secret.Value = proto.String(bcsSecretItem.Content)
+			if bcsSecretItem.Backend != nil {
+				// resolve now to fail the task early, only the reference is kept in task
+				content, err := secretbackend.Default().Resolve(&bcsSecretItem)
+				if err != nil {
+					blog.Error("resolve bcssecret(%s, %s) item(key:%s) err: %s",
+						secretNs, secretName, secretItem.DataKey, err.Error())
+					return fmt.Errorf("resolve bcssecret(Namespace:%s Name:%s) item(key:%s) err: %s",
+						secretNs, secretName, secretItem.DataKey, err.Error())
+				}
+				backend := *bcsSecretItem.Backend
+				msg.Secret.Backend = &backend
+				msg.Secret.Resolved = proto.String(content)
+				blog.Info("add task secret message(name:%s, backend:%s)", secretItem.KeyOrPath, backend.Type)
+				task.DataClass.Msgs = append(task.DataClass.Msgs, msg)
+				continue
+			}
 			blog.Info("add task secret message:%+v", msg)
 			task.DataClass.Msgs = append(task.DataClass.Msgs, msg)
 		}
@@ -482,6 +507,40 @@ func createTaskHealthChecks(task *types.Task, healthChecks []*commtypes.HealthCh
 	}
 }
 
+// livenessHealthChecks put liveness probe in front of health checks, so it takes the place of executor check
+func livenessHealthChecks(container *types.Container) []*commtypes.HealthCheck {
+	if container.LivenessProbe == nil {
+		return container.HealthChecks
+	}
+	healthChecks := []*commtypes.HealthCheck{container.LivenessProbe}
+	return append(healthChecks, container.HealthChecks...)
+}
+
+// createTaskProbes set readiness and startup probes for task, only executor checks are supported
+func createTaskProbes(task *types.Task, container *types.Container) {
+	task.Ready = false
+	task.Started = false
+	if isExecutorProbe(task, container.ReadinessProbe) {
+		task.ReadinessProbe = container.ReadinessProbe
+	}
+	if isExecutorProbe(task, container.StartupProbe) {
+		task.StartupProbe = container.StartupProbe
+	}
+}
+
+func isExecutorProbe(task *types.Task, probe *commtypes.HealthCheck) bool {
+	if probe == nil {
+		return false
+	}
+	switch probe.Type {
+	case bcstype.BcsHealthCheckType_COMMAND, bcstype.BcsHealthCheckType_TCP, bcstype.BcsHealthCheckType_HTTP:
+		return true
+	default:
+		blog.Info("task(%s) probe(%s) not supported", task.ID, probe.Type)
+		return false
+	}
+}
+
 //add branch for process task, to do 20180802
 // #lizard forgives createContainerTaskInfo
 func createContainerTaskInfo(offer *mesos.Offer, resources []*mesos.Resource, task *types.Task, portUsed int) (*mesos.TaskInfo, int) {
@@ -544,6 +603,10 @@ func createContainerTaskInfo(offer *mesos.Offer, resources []*mesos.Resource, ta
 	}
 
 	for _, volume := range task.Volumes {
+		// emptyDir & local volumes are delivered in DataClass
+		if volume.IsManaged() {
+			continue
+		}
 		mode := mesos.Volume_RO
 		if volume.Mode == "RW" {
 			mode = mesos.Volume_RW
@@ -834,7 +897,12 @@ func createContainerTaskInfo(offer *mesos.Offer, resources []*mesos.Resource, ta
 		Variables: varEnvs,
 	}
 
-	msgData, err := json.Marshal(task.DataClass)
+	dataClass, err := createTaskInfoDataClass(task)
+	if err != nil {
+		blog.Error("task(%s) create dataclass err: %s", task.ID, err.Error())
+		return nil, 0
+	}
+	msgData, err := json.Marshal(dataClass)
 	blog.V(3).Infof("task %s dataclass %s", task.ID, string(msgData))
 
 	if err == nil {
@@ -957,7 +1025,12 @@ func createProcessTaskInfo(offer *mesos.Offer, resources []*mesos.Resource, task
 		Variables: varEnvs,
 	}
 
-	msgData, err := json.Marshal(task.DataClass)
+	dataClass, err := createTaskInfoSecrets(task, task.DataClass)
+	if err != nil {
+		blog.Error("task(%s) create dataclass err: %s", task.ID, err.Error())
+		return nil, 0
+	}
+	msgData, err := json.Marshal(dataClass)
 	blog.V(3).Infof("task %s dataclass %s", task.ID, string(msgData))
 
 	if err == nil {
@@ -971,6 +1044,120 @@ func createProcessTaskInfo(offer *mesos.Offer, resources []*mesos.Resource, task
 	return &taskInfo, portNum
 }
 
+// createTaskInfoDataClass copy task DataClass with probes, hooks, init containers and
+// volumes managed by executor, ports of probes are resolved by port name
+func createTaskInfoDataClass(task *types.Task) (*types.DataClass, error) {
+	var volumes []*types.Volume
+	for _, volume := range task.Volumes {
+		if volume.IsManaged() {
+			volumes = append(volumes, volume)
+		}
+	}
+	if task.DataClass == nil || (task.ReadinessProbe == nil && task.StartupProbe == nil &&
+		task.Lifecycle == nil && len(task.InitContainers) == 0 && len(volumes) == 0) {
+		return createTaskInfoSecrets(task, task.DataClass)
+	}
+	secretDataClass, err := createTaskInfoSecrets(task, task.DataClass)
+	if err != nil {
+		return nil, err
+	}
+	dataClass := *secretDataClass
+	dataClass.ReadinessProbe = createTaskInfoProbe(task, task.ReadinessProbe)
+	dataClass.StartupProbe = createTaskInfoProbe(task, task.StartupProbe)
+	dataClass.Lifecycle = task.Lifecycle
+	dataClass.InitContainers = task.InitContainers
+	dataClass.Volumes = volumes
+	return &dataClass, nil
+}
+
+// createTaskInfoSecrets copy DataClass with the content of secrets kept in backends,
+// the content is only sent to executor and never stored with task
+func createTaskInfoSecrets(task *types.Task, dataClass *types.DataClass) (*types.DataClass, error) {
+	if dataClass == nil {
+		return nil, nil
+	}
+	resolved := false
+	msgs := make([]*types.BcsMessage, 0, len(dataClass.Msgs))
+	for _, msg := range dataClass.Msgs {
+		if msg.Secret == nil || msg.Secret.Backend == nil {
+			msgs = append(msgs, msg)
+			continue
+		}
+		content := msg.Secret.Resolved
+		if content == nil {
+			item := &commtypes.SecretDataItem{Backend: msg.Secret.Backend}
+			if msg.Secret.Value != nil {
+				item.Content = *msg.Secret.Value
+			}
+			value, err := secretbackend.Default().Resolve(item)
+			if err != nil {
+				blog.Error("task(%s) resolve secret from backend %s err: %s",
+					task.ID, msg.Secret.Backend.Type, err.Error())
+				return nil, fmt.Errorf("resolve secret %s from backend %s: %s",
+					*msg.Secret.Name, msg.Secret.Backend.Type, err.Error())
+			}
+			content = proto.String(value)
+		}
+		secretMsg := *msg
+		secretMsg.Secret = &types.Msg_Secret{
+			Name:  msg.Secret.Name,
+			Value: content,
+			Type:  msg.Secret.Type,
+		}
+		msgs = append(msgs, &secretMsg)
+		resolved = true
+	}
+	if !resolved {
+		return dataClass, nil
+	}
+	taskDataClass := *dataClass
+	taskDataClass.Msgs = msgs
+	return &taskDataClass, nil
+}
+
+func createTaskInfoProbe(task *types.Task, probe *commtypes.HealthCheck) *commtypes.HealthCheck {
+	if probe == nil {
+		return nil
+	}
+	taskProbe := *probe
+	switch probe.Type {
+	case bcstype.BcsHealthCheckType_TCP:
+		if probe.Tcp == nil {
+			blog.Error("task(%s) probe(%s) data is nil", task.ID, probe.Type)
+			return nil
+		}
+		tcp := *probe.Tcp
+		if tcp.Port <= 0 {
+			tcp.Port, _ = getTaskHealthCheckPort(task, tcp.PortName)
+			if tcp.Port <= 0 {
+				blog.Error("task(%s) probe(%s) no port", task.ID, probe.Type)
+				return nil
+			}
+		}
+		taskProbe.Tcp = &tcp
+	case bcstype.BcsHealthCheckType_HTTP:
+		if probe.Http == nil {
+			blog.Error("task(%s) probe(%s) data is nil", task.ID, probe.Type)
+			return nil
+		}
+		http := *probe.Http
+		if http.Port <= 0 {
+			http.Port, _ = getTaskHealthCheckPort(task, http.PortName)
+			if http.Port <= 0 {
+				blog.Error("task(%s) probe(%s) no port", task.ID, probe.Type)
+				return nil
+			}
+		}
+		taskProbe.Http = &http
+	case bcstype.BcsHealthCheckType_COMMAND:
+		if probe.Command == nil || probe.Command.Value == "" {
+			blog.Error("task(%s) probe(%s) command is empty", task.ID, probe.Type)
+			return nil
+		}
+	}
+	return &taskProbe
+}
+
 func createTaskInfoHealth(task *types.Task, taskInfo *mesos.TaskInfo) {
 
 	for _, healthCheck := range task.HealthChecks {
@@ -1428,6 +1615,21 @@ func CheckVersion(version *types.Version, store store.Store) error {
 		}
 	}
 
+	if err := checkVersionLifecycle(version); err != nil {
+		blog.Warn("version(%s.%s) check lifecycle err: %s", version.RunAs, version.ID, err.Error())
+		return err
+	}
+
+	if err := checkVersionVolumes(version); err != nil {
+		blog.Warn("version(%s.%s) check volumes err: %s", version.RunAs, version.ID, err.Error())
+		return err
+	}
+
+	if err := checkVersionConfigReload(version); err != nil {
+		blog.Warn("version(%s.%s) check configReload err: %s", version.RunAs, version.ID, err.Error())
+		return err
+	}
+
 	//check requestIP labels "io.tencent.bcs.netsvc.requestip.*"
 	requestIpLabelNum := 0
 	for k := range version.Labels {
@@ -1446,6 +1648,118 @@ func CheckVersion(version *types.Version, store store.Store) error {
 	return nil
 }
 
+// checkVersionLifecycle check init containers and hooks of containers
+func checkVersionLifecycle(version *types.Version) error {
+	names := make(map[string]bool)
+	for _, initContainer := range version.InitContainers {
+		if initContainer.Name == "" || initContainer.Docker == nil || initContainer.Docker.Image == "" {
+			return fmt.Errorf("init container name and image can not be empty")
+		}
+		if names[initContainer.Name] {
+			return fmt.Errorf("init container name(%s) is duplicated", initContainer.Name)
+		}
+		names[initContainer.Name] = true
+	}
+
+	for _, container := range version.Container {
+		if container.Lifecycle == nil {
+			continue
+		}
+		if err := checkLifecycleHandler(container.Lifecycle.PostStart); err != nil {
+			return fmt.Errorf("postStart: %s", err.Error())
+		}
+		if err := checkLifecycleHandler(container.Lifecycle.PreStop); err != nil {
+			return fmt.Errorf("preStop: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// checkVersionVolumes check emptyDir & local volumes of containers and init containers,
+// emptyDir with the same name is shared in taskgroup, so its definition must be the same
+func checkVersionVolumes(version *types.Version) error {
+	var volumes []*types.Volume
+	for _, container := range version.Container {
+		volumes = append(volumes, container.Volumes...)
+	}
+	for _, initContainer := range version.InitContainers {
+		volumes = append(volumes, initContainer.Volumes...)
+	}
+	emptyDirs := make(map[string]*commtypes.EmptyDirVolume)
+	for _, volume := range volumes {
+		if volume.EmptyDir != nil && volume.Local != nil {
+			return fmt.Errorf("volume(%s) can not be both emptyDir and local", volume.Name)
+		}
+		if volume.EmptyDir != nil {
+			if !isValidVolumeName(volume.Name) {
+				return fmt.Errorf("emptyDir volume name(%s) is invalid", volume.Name)
+			}
+			if volume.EmptyDir.Medium != commtypes.VolumeMediumDefault && volume.EmptyDir.Medium != commtypes.VolumeMediumMemory {
+				return fmt.Errorf("emptyDir volume(%s) medium %s is not supported", volume.Name, volume.EmptyDir.Medium)
+			}
+			if volume.EmptyDir.SizeLimit < 0 {
+				return fmt.Errorf("emptyDir volume(%s) sizeLimit can not be negative", volume.Name)
+			}
+			if exist, ok := emptyDirs[volume.Name]; ok && *exist != *volume.EmptyDir {
+				return fmt.Errorf("emptyDir volume(%s) is defined differently in containers", volume.Name)
+			}
+			emptyDirs[volume.Name] = volume.EmptyDir
+		}
+		if volume.Local != nil {
+			if !isValidVolumeName(volume.Local.Name) {
+				return fmt.Errorf("local volume name(%s) is invalid", volume.Local.Name)
+			}
+			if volume.Local.Quota < 0 {
+				return fmt.Errorf("local volume(%s) quota can not be negative", volume.Local.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkVersionConfigReload check the policy of reloading configmaps & secrets,
+// FileSignal needs one of signal and command to notify the process in container
+func checkVersionConfigReload(version *types.Version) error {
+	if version.ConfigReload == nil {
+		return nil
+	}
+	reload := version.ConfigReload
+	switch reload.Policy {
+	case "", commtypes.ConfigReloadPolicy_NONE, commtypes.ConfigReloadPolicy_FILE, commtypes.ConfigReloadPolicy_RESTART:
+	case commtypes.ConfigReloadPolicy_FILESIGNAL:
+		if reload.Signal <= 0 && len(reload.Command) == 0 {
+			return fmt.Errorf("one of signal and command must be set for policy %s", reload.Policy)
+		}
+	default:
+		return fmt.Errorf("policy %s is not supported", reload.Policy)
+	}
+	if reload.Signal < 0 || reload.Signal > 64 {
+		return fmt.Errorf("signal %d is invalid", reload.Signal)
+	}
+	return nil
+}
+
+// isValidVolumeName volume name is used as directory name on agent
+func isValidVolumeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+func checkLifecycleHandler(handler *commtypes.LifecycleHandler) error {
+	if handler == nil {
+		return nil
+	}
+	if (handler.Exec == nil) == (handler.HttpGet == nil) {
+		return fmt.Errorf("one of exec and httpGet must be set")
+	}
+	if handler.Exec != nil && len(handler.Exec.Command) == 0 {
+		return fmt.Errorf("exec command can not be empty")
+	}
+	if handler.HttpGet != nil && handler.HttpGet.Port <= 0 {
+		return fmt.Errorf("httpGet port must be larger than 0")
+	}
+	return nil
+}
+
 // GetVersionRequestIpCount Get reserve IP number in version definition
 func GetVersionRequestIpCount(version *types.Version) int {
 	requestIpLabelNum := 0
@@ -12,3 +12,233 @@
  */
 
 package task
+
+import (
+	"testing"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessHealthChecks(t *testing.T) {
+	tcpCheck := &commtypes.HealthCheck{Type: commtypes.BcsHealthCheckType_TCP}
+	liveness := &commtypes.HealthCheck{Type: commtypes.BcsHealthCheckType_COMMAND}
+
+	container := &types.Container{HealthChecks: []*commtypes.HealthCheck{tcpCheck}}
+	assert.Equal(t, []*commtypes.HealthCheck{tcpCheck}, livenessHealthChecks(container))
+
+	//liveness probe takes the place of executor check
+	container.LivenessProbe = liveness
+	task := &types.Task{}
+	createTaskHealthChecks(task, livenessHealthChecks(container))
+	assert.Equal(t, []*commtypes.HealthCheck{liveness}, task.HealthChecks)
+	assert.Len(t, task.HealthCheckStatus, 1)
+	assert.Equal(t, commtypes.BcsHealthCheckType_COMMAND, task.HealthCheckStatus[0].Type)
+}
+
+func TestCreateTaskProbes(t *testing.T) {
+	container := &types.Container{
+		ReadinessProbe: &commtypes.HealthCheck{Type: commtypes.BcsHealthCheckType_HTTP},
+		StartupProbe:   &commtypes.HealthCheck{Type: commtypes.BcsHealthCheckType_REMOTETCP},
+	}
+	task := &types.Task{}
+	createTaskProbes(task, container)
+	assert.Equal(t, container.ReadinessProbe, task.ReadinessProbe)
+	assert.Nil(t, task.StartupProbe)
+	assert.False(t, task.IsReady())
+	assert.True(t, task.IsStarted())
+
+	task.Ready = true
+	assert.True(t, task.IsReady())
+}
+
+func TestCreateTaskInfoDataClass(t *testing.T) {
+	task := &types.Task{
+		ID:        "task",
+		Network:   "BRIDGE",
+		DataClass: &types.DataClass{},
+		PortMappings: []*types.PortMapping{
+			{Name: "http", ContainerPort: 8080, HostPort: 31000},
+		},
+		ReadinessProbe: &commtypes.HealthCheck{
+			Type: commtypes.BcsHealthCheckType_HTTP,
+			Http: &commtypes.HttpHealthCheck{PortName: "http", Path: "/ready"},
+		},
+		StartupProbe: &commtypes.HealthCheck{
+			Type: commtypes.BcsHealthCheckType_TCP,
+			Tcp:  &commtypes.TcpHealthCheck{PortName: "unknown"},
+		},
+	}
+
+	dataClass, err := createTaskInfoDataClass(task)
+	assert.Nil(t, err)
+	assert.NotNil(t, dataClass.ReadinessProbe)
+	assert.Equal(t, int32(8080), dataClass.ReadinessProbe.Http.Port)
+	assert.Equal(t, "/ready", dataClass.ReadinessProbe.Http.Path)
+	//port name not found
+	assert.Nil(t, dataClass.StartupProbe)
+	//probe of task is not changed
+	assert.Equal(t, int32(0), task.ReadinessProbe.Http.Port)
+	assert.Nil(t, task.DataClass.ReadinessProbe)
+
+	task.ReadinessProbe = nil
+	task.StartupProbe = nil
+	dataClass, err = createTaskInfoDataClass(task)
+	assert.Nil(t, err)
+	assert.Equal(t, task.DataClass, dataClass)
+}
+
+func TestTaskGroupIsReady(t *testing.T) {
+	probe := &commtypes.HealthCheck{Type: commtypes.BcsHealthCheckType_TCP}
+	taskGroup := &types.TaskGroup{
+		Taskgroup: []*types.Task{
+			{ID: "no-probe"},
+			{ID: "probe", ReadinessProbe: probe},
+		},
+	}
+	assert.False(t, taskGroup.IsReady())
+
+	taskGroup.Taskgroup[1].Ready = true
+	assert.True(t, taskGroup.IsReady())
+}
+
+func TestCheckVersionLifecycle(t *testing.T) {
+	version := &types.Version{
+		InitContainers: []*types.InitContainer{
+			{Name: "init", Docker: &types.Docker{Image: "busybox"}},
+		},
+		Container: []*types.Container{
+			{
+				Lifecycle: &commtypes.Lifecycle{
+					PostStart: &commtypes.LifecycleHandler{Exec: &commtypes.ExecAction{Command: []string{"ls"}}},
+					PreStop:   &commtypes.LifecycleHandler{HttpGet: &commtypes.HttpGetAction{Port: 8080, Path: "/stop"}},
+				},
+			},
+		},
+	}
+	assert.Nil(t, checkVersionLifecycle(version))
+
+	version.InitContainers = append(version.InitContainers, &types.InitContainer{Name: "init", Docker: &types.Docker{Image: "busybox"}})
+	assert.NotNil(t, checkVersionLifecycle(version))
+	version.InitContainers = version.InitContainers[:1]
+
+	version.Container[0].Lifecycle.PreStop.HttpGet.Port = 0
+	assert.NotNil(t, checkVersionLifecycle(version))
+	version.Container[0].Lifecycle.PreStop.HttpGet.Port = 8080
+
+	version.Container[0].Lifecycle.PostStart.HttpGet = &commtypes.HttpGetAction{Port: 8080}
+	assert.NotNil(t, checkVersionLifecycle(version))
+}
+
+func TestCreateTaskInfoDataClassVolumes(t *testing.T) {
+	task := &types.Task{
+		ID:        "task",
+		DataClass: &types.DataClass{},
+		Volumes: []*types.Volume{
+			{ContainerPath: "/data", HostPath: "/data/host", Mode: "RW"},
+			{ContainerPath: "/cache", Name: "cache", EmptyDir: &commtypes.EmptyDirVolume{Medium: commtypes.VolumeMediumMemory}},
+		},
+	}
+	dataClass, err := createTaskInfoDataClass(task)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dataClass.Volumes))
+	assert.Equal(t, "cache", dataClass.Volumes[0].Name)
+	assert.Nil(t, task.DataClass.Volumes)
+
+	task.Volumes = task.Volumes[:1]
+	dataClass, err = createTaskInfoDataClass(task)
+	assert.Nil(t, err)
+	assert.Equal(t, task.DataClass, dataClass)
+}
+
+func TestCheckVersionVolumes(t *testing.T) {
+	version := &types.Version{
+		Container: []*types.Container{
+			{Volumes: []*types.Volume{
+				{ContainerPath: "/cache", Name: "cache", EmptyDir: &commtypes.EmptyDirVolume{SizeLimit: 100}},
+				{ContainerPath: "/data", Local: &commtypes.LocalVolume{Name: "data", Quota: 1024}},
+			}},
+			{Volumes: []*types.Volume{
+				{ContainerPath: "/cache", Name: "cache", EmptyDir: &commtypes.EmptyDirVolume{SizeLimit: 100}},
+			}},
+		},
+	}
+	assert.Nil(t, checkVersionVolumes(version))
+
+	//shared emptyDir defined differently
+	version.Container[1].Volumes[0].EmptyDir = &commtypes.EmptyDirVolume{Medium: commtypes.VolumeMediumMemory}
+	assert.NotNil(t, checkVersionVolumes(version))
+	version.Container[1].Volumes[0].EmptyDir = &commtypes.EmptyDirVolume{SizeLimit: 100}
+
+	version.Container[0].Volumes[0].EmptyDir.Medium = "HugePages"
+	assert.NotNil(t, checkVersionVolumes(version))
+	version.Container[0].Volumes[0].EmptyDir.Medium = ""
+
+	version.Container[0].Volumes[1].Local.Name = "../data"
+	assert.NotNil(t, checkVersionVolumes(version))
+	version.Container[0].Volumes[1].Local.Name = "data"
+
+	version.InitContainers = []*types.InitContainer{
+		{Name: "init", Volumes: []*types.Volume{{ContainerPath: "/cache", EmptyDir: &commtypes.EmptyDirVolume{}}}},
+	}
+	assert.NotNil(t, checkVersionVolumes(version))
+}
+
+func TestCheckVersionConfigReload(t *testing.T) {
+	version := &types.Version{}
+	assert.Nil(t, checkVersionConfigReload(version))
+
+	version.ConfigReload = &commtypes.ConfigReloadPolicy{Policy: commtypes.ConfigReloadPolicy_FILE}
+	assert.Nil(t, checkVersionConfigReload(version))
+
+	//FileSignal without signal and command
+	version.ConfigReload.Policy = commtypes.ConfigReloadPolicy_FILESIGNAL
+	assert.NotNil(t, checkVersionConfigReload(version))
+	version.ConfigReload.Signal = 1
+	assert.Nil(t, checkVersionConfigReload(version))
+	version.ConfigReload.Signal = 0
+	version.ConfigReload.Command = []string{"nginx", "-s", "reload"}
+	assert.Nil(t, checkVersionConfigReload(version))
+
+	version.ConfigReload.Signal = 100
+	assert.NotNil(t, checkVersionConfigReload(version))
+	version.ConfigReload.Signal = 0
+
+	version.ConfigReload.Policy = "Rolling"
+	assert.NotNil(t, checkVersionConfigReload(version))
+}
+
+func TestCreateTaskInfoSecrets(t *testing.T) {
+	plain := &types.BcsMessage{Type: types.Msg_SECRET.Enum(), Secret: &types.Msg_Secret{
+		Name: proto.String("USER"), Value: proto.String("dXNlcg=="), Type: types.Secret_Env.Enum()}}
+	task := &types.Task{ID: "task", DataClass: &types.DataClass{Msgs: []*types.BcsMessage{plain}}}
+	dataClass, err := createTaskInfoSecrets(task, task.DataClass)
+	assert.Nil(t, err)
+	assert.Equal(t, task.DataClass, dataClass)
+
+	vault := &types.BcsMessage{Type: types.Msg_SECRET.Enum(), Secret: &types.Msg_Secret{
+		Name: proto.String("PASSWD"), Value: proto.String(""), Type: types.Secret_Env.Enum(),
+		Backend:  &commtypes.SecretBackend{Type: commtypes.SecretBackendVault, Path: "secret/app", Key: "passwd"},
+		Resolved: proto.String("cGFzc3dk"),
+	}}
+	task.DataClass.Msgs = append(task.DataClass.Msgs, vault)
+	dataClass, err = createTaskInfoSecrets(task, task.DataClass)
+	assert.Nil(t, err)
+	assert.Equal(t, plain, dataClass.Msgs[0])
+	assert.Equal(t, "cGFzc3dk", *dataClass.Msgs[1].Secret.Value)
+	assert.Nil(t, dataClass.Msgs[1].Secret.Backend)
+	//resolved content is not kept in task
+	assert.Equal(t, "", *task.DataClass.Msgs[1].Secret.Value)
+
+	//taskinfo is not built with blank secret when backend fails
+	task.DataClass.Msgs[1].Secret.Resolved = nil
+	dataClass, err = createTaskInfoSecrets(task, task.DataClass)
+	assert.NotNil(t, err)
+	assert.Nil(t, dataClass)
+	dataClass, err = createTaskInfoDataClass(task)
+	assert.NotNil(t, err)
+	assert.Nil(t, dataClass)
+}
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"encoding/json"
+)
+
+func getDaemonsetRootPath() string {
+	return "/" + bcsRootNode + "/" + daemonsetNode
+}
+
+func (store *managerStore) SaveDaemonset(daemonset *types.Daemonset) error {
+
+	data, err := json.Marshal(daemonset)
+	if err != nil {
+		return err
+	}
+
+	path := getDaemonsetRootPath() + "/" + daemonset.ObjectMeta.NameSpace + "/" + daemonset.ObjectMeta.Name
+
+	return store.Db.Insert(path, string(data))
+}
+
+func (store *managerStore) FetchDaemonset(ns, name string) (*types.Daemonset, error) {
+
+	path := getDaemonsetRootPath() + "/" + ns + "/" + name
+
+	data, err := store.Db.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonset := &types.Daemonset{}
+	if err := json.Unmarshal(data, daemonset); err != nil {
+		blog.Error("fail to unmarshal daemonset(%s). err:%s", string(data), err.Error())
+		return nil, err
+	}
+
+	return daemonset, nil
+}
+
+func (store *managerStore) DeleteDaemonset(ns, name string) error {
+
+	path := getDaemonsetRootPath() + "/" + ns + "/" + name
+	if err := store.Db.Delete(path); err != nil {
+		blog.Error("fail to delete daemonset(%s) err:%s", path, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (store *managerStore) ListDaemonsets(runAs string) ([]*types.Daemonset, error) {
+
+	path := getDaemonsetRootPath() + "/" + runAs
+
+	names, err := store.Db.List(path)
+	if err != nil {
+		blog.Error("fail to list daemonset names, err:%s", err.Error())
+		return nil, err
+	}
+
+	var objs []*types.Daemonset
+	for _, name := range names {
+		obj, err := store.FetchDaemonset(runAs, name)
+		if err != nil {
+			blog.Error("fail to fetch daemonset(%s.%s)", runAs, name)
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+func (store *managerStore) ListAllDaemonsets() ([]*types.Daemonset, error) {
+	nss, err := store.ListObjectNamespaces(daemonsetNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*types.Daemonset
+	for _, ns := range nss {
+		obj, err := store.ListDaemonsets(ns)
+		if err != nil {
+			blog.Error("fail to fetch daemonset by ns(%s)", ns)
+			continue
+		}
+
+		objs = append(objs, obj...)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-common/common/ssl"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/util"
+)
+
+//DbDriverConfig is the config of the db driver of scheduler store,
+//the keys are the same as bcs-scheduler config
+type DbDriverConfig struct {
+	//Driver is util.StoreDriverZk or util.StoreDriverEtcd, zookeeper if empty
+	Driver string
+	//ZkHost zookeeper address, split by comma
+	ZkHost string
+	//EtcdHost etcd address, split by comma
+	EtcdHost string
+	//tls files of etcd, tls is disabled if any of them is empty
+	EtcdCAFile   string
+	EtcdCertFile string
+	EtcdKeyFile  string
+}
+
+//NewDbDriver create the db driver of scheduler store by config.Driver and connect it
+func NewDbDriver(config *DbDriverConfig) (Dbdrvier, error) {
+	switch config.Driver {
+	case "", util.StoreDriverZk:
+		dbzk := NewDbZk(strings.Split(config.ZkHost, ","))
+		if err := dbzk.Connect(); err != nil {
+			blog.Error("connect zookeeper(%s) error: %s", config.ZkHost, err.Error())
+			return nil, fmt.Errorf("connect zookeeper %s error: %s", config.ZkHost, err.Error())
+		}
+		return dbzk, nil
+
+	case util.StoreDriverEtcd:
+		var tlsConfig *tls.Config
+		if config.EtcdCAFile != "" && config.EtcdCertFile != "" && config.EtcdKeyFile != "" {
+			var err error
+			tlsConfig, err = ssl.ClientTslConfVerity(config.EtcdCAFile, config.EtcdCertFile, config.EtcdKeyFile, "")
+			if err != nil {
+				blog.Error("load etcd tls config error: %s", err.Error())
+				return nil, fmt.Errorf("load etcd tls config error: %s", err.Error())
+			}
+		}
+
+		dbetcd := NewDbEtcd(strings.Split(config.EtcdHost, ","), tlsConfig)
+		if err := dbetcd.Connect(); err != nil {
+			blog.Error("connect etcd(%s) error: %s", config.EtcdHost, err.Error())
+			return nil, fmt.Errorf("connect etcd %s error: %s", config.EtcdHost, err.Error())
+		}
+		return dbetcd, nil
+	}
+
+	return nil, fmt.Errorf("store driver %s is not supported", config.Driver)
+}
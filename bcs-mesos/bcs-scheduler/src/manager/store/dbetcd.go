@@ -0,0 +1,282 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto/tls"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	// etcdDialTimeout is the timeout for connecting etcd cluster
+	etcdDialTimeout = 5 * time.Second
+	// etcdRequestTimeout is the timeout for every etcd request
+	etcdRequestTimeout = 10 * time.Second
+	// etcdListPageSize is the max keys fetched by one request when listing children
+	etcdListPageSize = 500
+)
+
+//dbEtcd is a struct of the etcd v3 client.
+//It keeps the same path layout as zookeeper, every zk node is stored as an etcd key,
+//so the children of a node are all the keys with prefix "path/".
+type dbEtcd struct {
+	EtcdHost []string
+	TLS      *tls.Config
+	EtcdCli  *clientv3.Client
+}
+
+//NewDbEtcd create a dbEtcd object
+func NewDbEtcd(host []string, tlsConfig *tls.Config) Dbdrvier {
+	etcd := dbEtcd{
+		EtcdHost: host[:],
+		TLS:      tlsConfig,
+	}
+
+	return &etcd
+}
+
+func (e *dbEtcd) Connect() error {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:        e.EtcdHost,
+		DialTimeout:      etcdDialTimeout,
+		AutoSyncInterval: time.Minute * 5,
+		TLS:              e.TLS,
+	})
+	if err != nil {
+		blog.Errorf("connect etcd %v failed: %s", e.EtcdHost, err.Error())
+		return err
+	}
+
+	e.EtcdCli = cli
+	return nil
+}
+
+func (e *dbEtcd) Close() {
+	if e.EtcdCli != nil {
+		e.EtcdCli.Close()
+	}
+}
+
+//etcdKey clean the zk style path, so "/a//b/" and "/a/b" are the same key
+func etcdKey(p string) string {
+	return path.Clean("/" + p)
+}
+
+//etcdChildrenPrefix return the prefix of all the children keys of path
+func etcdChildrenPrefix(p string) string {
+	key := etcdKey(p)
+	if key == "/" {
+		return key
+	}
+	return key + "/"
+}
+
+//etcdChildren return the names of the direct children under prefix, like zk GetChildren
+func etcdChildren(prefix string, keys []string) []string {
+	var childs []string
+	exist := make(map[string]struct{})
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		child := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)[0]
+		if child == "" {
+			continue
+		}
+		if _, ok := exist[child]; ok {
+			continue
+		}
+		exist[child] = struct{}{}
+		childs = append(childs, child)
+	}
+
+	sort.Strings(childs)
+	return childs
+}
+
+//etcdAncestors return the keys of all the ancestors of key from the top one, root is excluded
+func etcdAncestors(key string) []string {
+	var ancestors []string
+	for parent := path.Dir(key); parent != "/"; parent = path.Dir(parent) {
+		ancestors = append([]string{parent}, ancestors...)
+	}
+	return ancestors
+}
+
+//put save the value of path. Same as zookeeper CreateDeepNode, the missing parent nodes
+//are created with empty value, so every node of the path exists as a key in etcd.
+func (e *dbEtcd) put(p string, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	key := etcdKey(p)
+	parent := path.Dir(key)
+	if parent == "/" {
+		_, err := e.EtcdCli.Put(ctx, key, value)
+		return err
+	}
+
+	//the parent exists mostly, then the key is put in one request
+	resp, err := e.EtcdCli.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(parent), ">", 0)).
+		Then(clientv3.OpPut(key, value)).
+		Commit()
+	if err != nil || resp.Succeeded {
+		return err
+	}
+
+	//only the missing ancestors are created, the value of existing ones are kept
+	for _, ancestor := range etcdAncestors(key) {
+		_, err = e.EtcdCli.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(ancestor), "=", 0)).
+			Then(clientv3.OpPut(ancestor, "")).
+			Commit()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = e.EtcdCli.Put(ctx, key, value)
+	return err
+}
+
+func (e *dbEtcd) Insert(p string, value string) error {
+	var failed bool
+	started := time.Now()
+
+	err := e.put(p, value)
+	if err != nil {
+		failed = true
+	}
+
+	reportStorageOperatorMetrics(StoreOperatorCreate, started, failed)
+	return err
+}
+
+//Fetch return zk.ErrNoNode if the key does not exist,
+//callers of the store check this error to know the object is not found
+func (e *dbEtcd) Fetch(p string) ([]byte, error) {
+	var failed bool
+	started := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	var data []byte
+	resp, err := e.EtcdCli.Get(ctx, etcdKey(p))
+	if err != nil {
+		failed = true
+	} else if len(resp.Kvs) == 0 {
+		err = zk.ErrNoNode
+	} else {
+		data = resp.Kvs[0].Value
+	}
+
+	reportStorageOperatorMetrics(StoreOperatorFetch, started, failed)
+	return data, err
+}
+
+func (e *dbEtcd) Update(p string, value string) error {
+	var failed bool
+	started := time.Now()
+
+	err := e.put(p, value)
+	if err != nil {
+		failed = true
+	}
+
+	reportStorageOperatorMetrics(StoreOperatorUpdate, started, failed)
+	return err
+}
+
+//Delete remove the key of path. Same as zookeeper, the node with children can't be deleted,
+//and zk.ErrNoNode is returned if the key does not exist.
+//The children check and the deletion are done in one transaction.
+func (e *dbEtcd) Delete(p string) error {
+	var failed bool
+	started := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	//the create revision of keys not exist is 0, so the comparison succeeds only without children
+	resp, err := e.EtcdCli.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(etcdChildrenPrefix(p)).WithPrefix(), "=", 0)).
+		Then(clientv3.OpDelete(etcdKey(p))).
+		Commit()
+	if err == nil {
+		if !resp.Succeeded {
+			err = zk.ErrNotEmpty
+		} else if resp.Responses[0].GetResponseDeleteRange().Deleted == 0 {
+			err = zk.ErrNoNode
+		}
+	}
+	if err != nil {
+		failed = true
+	}
+
+	reportStorageOperatorMetrics(StoreOperatorDelete, started, failed)
+	return err
+}
+
+//List return the names of the direct children of path like zk GetChildren.
+//Keys are fetched page by page, the descendants of a child are skipped when the page
+//ends in them, so listing a node does not load its whole subtree.
+func (e *dbEtcd) List(p string) ([]string, error) {
+	started := time.Now()
+
+	prefix := etcdChildrenPrefix(p)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	var keys []string
+	for start := prefix; ; {
+		resp, err := e.listPage(start, end)
+		if err != nil {
+			reportStorageOperatorMetrics(StoreOperatorFetch, started, true)
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+
+		last := string(resp.Kvs[len(resp.Kvs)-1].Key)
+		child := strings.SplitN(strings.TrimPrefix(last, prefix), "/", 2)[0]
+		if strings.HasPrefix(last, prefix+child+"/") {
+			//'0' is next to '/', the rest keys of child subtree are skipped
+			start = prefix + child + "0"
+		} else {
+			start = last + "\x00"
+		}
+	}
+
+	reportStorageOperatorMetrics(StoreOperatorFetch, started, false)
+	return etcdChildren(prefix, keys), nil
+}
+
+func (e *dbEtcd) listPage(start, end string) (*clientv3.GetResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	return e.EtcdCli.Get(ctx, start, clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithLimit(etcdListPageSize))
+}
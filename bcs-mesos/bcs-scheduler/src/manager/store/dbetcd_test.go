@@ -0,0 +1,436 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/coreos/etcd/clientv3"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//memDb is a Dbdrvier in memory with zookeeper semantics, parent nodes are created on insert
+type memDb struct {
+	nodes map[string]string
+}
+
+func newMemDb() *memDb {
+	return &memDb{nodes: make(map[string]string)}
+}
+
+func (m *memDb) Connect() error {
+	return nil
+}
+
+func (m *memDb) Insert(p string, value string) error {
+	key := etcdKey(p)
+	for parent := key; parent != "/"; parent = parent[:strings.LastIndex(parent, "/")] {
+		if parent == "" {
+			break
+		}
+		if _, ok := m.nodes[parent]; !ok {
+			m.nodes[parent] = ""
+		}
+	}
+	m.nodes[key] = value
+	return nil
+}
+
+func (m *memDb) Fetch(p string) ([]byte, error) {
+	value, ok := m.nodes[etcdKey(p)]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	return []byte(value), nil
+}
+
+func (m *memDb) Update(p string, value string) error {
+	return m.Insert(p, value)
+}
+
+func (m *memDb) Delete(p string) error {
+	key := etcdKey(p)
+	if _, ok := m.nodes[key]; !ok {
+		return zk.ErrNoNode
+	}
+	for child := range m.nodes {
+		if strings.HasPrefix(child, etcdChildrenPrefix(key)) {
+			return zk.ErrNotEmpty
+		}
+	}
+	delete(m.nodes, key)
+	return nil
+}
+
+func (m *memDb) List(p string) ([]string, error) {
+	var keys []string
+	for key := range m.nodes {
+		keys = append(keys, key)
+	}
+	return etcdChildren(etcdChildrenPrefix(p), keys), nil
+}
+
+func TestEtcdKey(t *testing.T) {
+	assert.Equal(t, "/blueking/deployment/ns/name", etcdKey("/blueking/deployment//ns/name/"))
+	assert.Equal(t, "/blueking/agent/", etcdChildrenPrefix("/blueking/agent"))
+	assert.Equal(t, "/", etcdChildrenPrefix("/"))
+}
+
+func TestEtcdChildren(t *testing.T) {
+	keys := []string{
+		"/blueking/application/ns1/app1",
+		"/blueking/application/ns1/app1/0.app1.ns1.10001.123",
+		"/blueking/application/ns1/app2",
+		"/blueking/application/ns2/app3",
+		"/blueking/version/ns1/app1/1",
+	}
+
+	assert.Equal(t, []string{"ns1", "ns2"}, etcdChildren("/blueking/application/", keys))
+	assert.Equal(t, []string{"app1", "app2"}, etcdChildren("/blueking/application/ns1/", keys))
+	assert.Nil(t, etcdChildren("/blueking/application/ns3/", keys))
+}
+
+func TestMigrateStore(t *testing.T) {
+	src := newMemDb()
+	src.Insert("/blueking/application/ns1/app1", "app1")
+	src.Insert("/blueking/application/ns1/app1/0.app1.ns1.10001.123", "taskgroup")
+	src.Insert("/blueking/configmap/ns1/cm1", "cm1")
+	src.Insert("/other/node", "other")
+
+	dst := newMemDb()
+	count, err := MigrateStore(src, dst, GetStoreRootPath())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	data, err := dst.Fetch("/blueking/application/ns1/app1/0.app1.ns1.10001.123")
+	assert.Nil(t, err)
+	assert.Equal(t, "taskgroup", string(data))
+
+	_, err = dst.Fetch("/other/node")
+	assert.Equal(t, zk.ErrNoNode, err)
+
+	store := NewManagerStore(dst)
+	nss, err := store.ListObjectNamespaces(configMapNode)
+	assert.Nil(t, err)
+	sort.Strings(nss)
+	assert.Equal(t, []string{"ns1"}, nss)
+}
+
+//etcdKVServer is an in-process etcd KV service for the real etcd client,
+//it supports the range options used by dbEtcd
+type etcdKVServer struct {
+	sync.Mutex
+	kvs      map[string][]byte
+	revision int64
+	returned int //keys returned by range
+}
+
+func startEtcdKVServer(t *testing.T) (*etcdKVServer, string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	kv := &etcdKVServer{kvs: make(map[string][]byte)}
+	server := grpc.NewServer()
+	pb.RegisterKVServer(server, kv)
+	go server.Serve(lis)
+	return kv, lis.Addr().String(), server.Stop
+}
+
+func inEtcdRange(key string, start, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return key == string(start)
+	case string(end) == "\x00":
+		return key >= string(start)
+	default:
+		return key >= string(start) && key < string(end)
+	}
+}
+
+func (s *etcdKVServer) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{Revision: s.revision}
+}
+
+func (s *etcdKVServer) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+	var keys []string
+	for key := range s.kvs {
+		if inEtcdRange(key, req.Key, req.RangeEnd) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	resp := &pb.RangeResponse{Header: s.header(), Count: int64(len(keys))}
+	if req.CountOnly {
+		return resp, nil
+	}
+	if req.Limit > 0 && int64(len(keys)) > req.Limit {
+		keys = keys[:req.Limit]
+		resp.More = true
+	}
+	for _, key := range keys {
+		kv := &mvccpb.KeyValue{Key: []byte(key)}
+		if !req.KeysOnly {
+			kv.Value = s.kvs[key]
+		}
+		resp.Kvs = append(resp.Kvs, kv)
+	}
+	s.returned += len(keys)
+	return resp, nil
+}
+
+func (s *etcdKVServer) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.revision++
+	s.kvs[string(req.Key)] = req.Value
+	return &pb.PutResponse{Header: s.header()}, nil
+}
+
+func (s *etcdKVServer) DeleteRange(ctx context.Context, req *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+	resp := &pb.DeleteRangeResponse{}
+	for key := range s.kvs {
+		if inEtcdRange(key, req.Key, req.RangeEnd) {
+			delete(s.kvs, key)
+			resp.Deleted++
+		}
+	}
+	if resp.Deleted > 0 {
+		s.revision++
+	}
+	resp.Header = s.header()
+	return resp, nil
+}
+
+//Txn supports comparing the create revision of keys in range, and putting or deleting in the ops,
+//the create revision of key is regarded as 1 here
+func (s *etcdKVServer) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	s.Lock()
+	resp := &pb.TxnResponse{Succeeded: true}
+	for _, cmp := range req.Compare {
+		if cmp.Target != pb.Compare_CREATE || (cmp.Result != pb.Compare_EQUAL && cmp.Result != pb.Compare_GREATER) {
+			s.Unlock()
+			return nil, status.Error(codes.Unimplemented, "only create revision equal or greater comparison is supported")
+		}
+		var revision int64
+		for key := range s.kvs {
+			if inEtcdRange(key, cmp.Key, cmp.RangeEnd) {
+				revision = 1
+			}
+		}
+		if (cmp.Result == pb.Compare_EQUAL && revision != cmp.GetCreateRevision()) ||
+			(cmp.Result == pb.Compare_GREATER && revision <= cmp.GetCreateRevision()) {
+			resp.Succeeded = false
+		}
+	}
+	s.Unlock()
+
+	ops := req.Success
+	if !resp.Succeeded {
+		ops = req.Failure
+	}
+	for _, op := range ops {
+		if put := op.GetRequestPut(); put != nil {
+			putResp, _ := s.Put(ctx, put)
+			resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: putResp}})
+			continue
+		}
+		del := op.GetRequestDeleteRange()
+		if del == nil {
+			return nil, status.Error(codes.Unimplemented, "only put and delete are supported in txn")
+		}
+		delResp, _ := s.DeleteRange(ctx, del)
+		resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: delResp}})
+	}
+	s.Lock()
+	resp.Header = s.header()
+	s.Unlock()
+	return resp, nil
+}
+
+func (s *etcdKVServer) Compact(ctx context.Context, req *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "compact is not supported")
+}
+
+//testDbdrvierCases run the store cases with zookeeper semantics under root
+func testDbdrvierCases(t *testing.T, db Dbdrvier, root string) {
+	appPath := root + "/application/ns1/app1"
+	_, err := db.Fetch(appPath)
+	assert.Equal(t, zk.ErrNoNode, err)
+
+	assert.Nil(t, db.Insert(appPath, "app1"))
+	assert.Nil(t, db.Update(appPath, "app1-v2"))
+	data, err := db.Fetch(appPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "app1-v2", string(data))
+
+	for i := 0; i < 600; i++ {
+		assert.Nil(t, db.Insert(fmt.Sprintf("%s/%03d.app1.ns1", appPath, i), "taskgroup"))
+	}
+	assert.Nil(t, db.Insert(root+"/application/ns1/app2", "app2"))
+	assert.Nil(t, db.Insert(root+"/application/ns2/app3", "app3"))
+	//"app1.bak" is ordered between "app1" and "app1/..."
+	assert.Nil(t, db.Insert(root+"/application/ns1/app1.bak", "bak"))
+
+	childs, err := db.List(root + "/application")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ns1", "ns2"}, childs)
+	childs, err = db.List(root + "/application/ns1/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1", "app1.bak", "app2"}, childs)
+	childs, err = db.List(appPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 600, len(childs))
+	assert.Equal(t, "000.app1.ns1", childs[0])
+	childs, err = db.List(root + "/application/ns3")
+	assert.Nil(t, err)
+	assert.Empty(t, childs)
+
+	//node with children can't be deleted
+	assert.Equal(t, zk.ErrNotEmpty, db.Delete(appPath))
+	assert.Equal(t, zk.ErrNotEmpty, db.Delete(root+"/application/ns1"))
+	for i := 0; i < 600; i++ {
+		assert.Nil(t, db.Delete(fmt.Sprintf("%s/%03d.app1.ns1", appPath, i)))
+	}
+	assert.Nil(t, db.Delete(appPath))
+	_, err = db.Fetch(appPath)
+	assert.Equal(t, zk.ErrNoNode, err)
+	assert.Equal(t, zk.ErrNoNode, db.Delete(appPath))
+	//parent nodes are created on insert like zookeeper
+	data, err = db.Fetch(root + "/application/ns2")
+	assert.Nil(t, err)
+	assert.Empty(t, data)
+	assert.Nil(t, db.Delete(root+"/application/ns2/app3"))
+	assert.Nil(t, db.Delete(root+"/application/ns2"))
+	assert.Equal(t, zk.ErrNoNode, db.Delete(root+"/application/ns2"))
+	childs, err = db.List(root + "/application/ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1.bak", "app2"}, childs)
+}
+
+func TestMemDbCases(t *testing.T) {
+	testDbdrvierCases(t, newMemDb(), "/blueking")
+}
+
+func TestDbEtcdCases(t *testing.T) {
+	kv, endpoint, stop := startEtcdKVServer(t)
+	defer stop()
+	db := NewDbEtcd([]string{endpoint}, nil)
+	assert.Nil(t, db.Connect())
+	defer db.(*dbEtcd).Close()
+
+	testDbdrvierCases(t, db, "/blueking")
+
+	//subtree of child is skipped when page ends in it
+	for i := 0; i < 2*etcdListPageSize; i++ {
+		assert.Nil(t, db.Insert(fmt.Sprintf("/blueking/version/ns1/app1/%04d", i), "version"))
+	}
+	assert.Nil(t, db.Insert("/blueking/version/ns1/app2/0001", "version"))
+	kv.Lock()
+	kv.returned = 0
+	kv.Unlock()
+	childs, err := db.List("/blueking/version/ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1", "app2"}, childs)
+	//the first page, then the keys of app2 and its child
+	assert.True(t, kv.returned <= etcdListPageSize+2, "keys returned %d", kv.returned)
+
+	//value of existing ancestor is kept when the missing parents are created
+	assert.Nil(t, db.Insert("/blueking/application/ns1/app2/0.app2.ns1.10001.123/123.task.0.app2.ns1.10001", "task"))
+	data, err := db.Fetch("/blueking/application/ns1/app2")
+	assert.Nil(t, err)
+	assert.Equal(t, "app2", string(data))
+}
+
+func TestManagerStoreOnEtcd(t *testing.T) {
+	_, endpoint, stop := startEtcdKVServer(t)
+	defer stop()
+	db := NewDbEtcd([]string{endpoint}, nil)
+	assert.Nil(t, db.Connect())
+	defer db.(*dbEtcd).Close()
+	store := NewManagerStore(db)
+	store.InitCacheMgr(false)
+
+	app := &types.Application{ID: "app1", RunAs: "ns1", Instances: 1}
+	assert.Nil(t, store.SaveApplication(app))
+	taskGroup := &types.TaskGroup{
+		ID:        "0.app1.ns1.10001.123",
+		AppID:     "app1",
+		RunAs:     "ns1",
+		Taskgroup: []*types.Task{{ID: "123.task1.0.app1.ns1.10001"}},
+	}
+	assert.Nil(t, store.SaveTaskGroup(taskGroup))
+
+	apps, err := store.ListApplications("ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(apps))
+	assert.Equal(t, "app1", apps[0].ID)
+	taskGroups, err := store.ListTaskGroups("ns1", "app1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(taskGroups))
+	assert.Equal(t, 1, len(taskGroups[0].Taskgroup))
+	assert.Equal(t, "123.task1.0.app1.ns1.10001", taskGroups[0].Taskgroup[0].ID)
+
+	service := &commtypes.BcsService{}
+	service.ObjectMeta.NameSpace = "ns1"
+	service.ObjectMeta.Name = "svc1"
+	assert.Nil(t, store.SaveService(service))
+	services, err := store.ListAllServices()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(services))
+	assert.Equal(t, "svc1", services[0].ObjectMeta.Name)
+
+	//application with taskgroups can't be deleted
+	assert.Equal(t, zk.ErrNotEmpty, store.DeleteApplication("ns1", "app1"))
+	assert.Nil(t, store.DeleteTaskGroup(taskGroup.ID))
+	assert.Nil(t, store.DeleteApplication("ns1", "app1"))
+	_, err = store.FetchApplication("ns1", "app1")
+	assert.Equal(t, zk.ErrNoNode, err)
+	assert.Equal(t, zk.ErrNoNode, store.DeleteApplication("ns1", "app1"))
+}
+
+//TestDbEtcdCasesWithServer run the store cases in real etcd cluster,
+//set BCS_TEST_ETCD_ENDPOINTS like 127.0.0.1:2379 to enable it
+func TestDbEtcdCasesWithServer(t *testing.T) {
+	endpoints := os.Getenv("BCS_TEST_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("BCS_TEST_ETCD_ENDPOINTS is not set")
+	}
+	db := NewDbEtcd(strings.Split(endpoints, ","), nil)
+	assert.Nil(t, db.Connect())
+	defer db.(*dbEtcd).Close()
+
+	root := fmt.Sprintf("/bcs-store-test-%d", time.Now().UnixNano())
+	testDbdrvierCases(t, db, root)
+	db.(*dbEtcd).EtcdCli.Delete(context.Background(), root, clientv3.WithPrefix())
+}
@@ -0,0 +1,126 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+func getDeploymentRevisionRootPath() string {
+	return "/" + bcsRootNode + "/" + deploymentRevisionNode
+}
+
+func (store *managerStore) SaveDeploymentRevision(revision *types.DeploymentRevision) error {
+
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	path := getDeploymentRevisionRootPath() + "/" + revision.ObjectMeta.NameSpace + "/" +
+		revision.ObjectMeta.Name + "/" + strconv.FormatInt(revision.Revision, 10)
+
+	return store.Db.Insert(path, string(data))
+}
+
+func (store *managerStore) FetchDeploymentRevision(ns, name string, revision int64) (*types.DeploymentRevision, error) {
+
+	path := getDeploymentRevisionRootPath() + "/" + ns + "/" + name + "/" + strconv.FormatInt(revision, 10)
+
+	data, err := store.Db.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &types.DeploymentRevision{}
+	if err := json.Unmarshal(data, obj); err != nil {
+		blog.Error("fail to unmarshal deployment revision(%s). err:%s", string(data), err.Error())
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+//ListDeploymentRevisions return the revisions of deployment in ascending order
+func (store *managerStore) ListDeploymentRevisions(ns, name string) ([]*types.DeploymentRevision, error) {
+
+	path := getDeploymentRevisionRootPath() + "/" + ns + "/" + name
+
+	nodes, err := store.Db.List(path)
+	if err != nil {
+		blog.Error("fail to list deployment(%s.%s) revisions, err:%s", ns, name, err.Error())
+		return nil, err
+	}
+
+	var revisions []int64
+	for _, node := range nodes {
+		revision, err := strconv.ParseInt(node, 10, 64)
+		if err != nil {
+			blog.Warn("deployment(%s.%s) revision node(%s) invalid, ignore it", ns, name, node)
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i] < revisions[j] })
+
+	var objs []*types.DeploymentRevision
+	for _, revision := range revisions {
+		obj, err := store.FetchDeploymentRevision(ns, name, revision)
+		if err != nil {
+			blog.Error("fail to fetch deployment(%s.%s) revision(%d)", ns, name, revision)
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+func (store *managerStore) DeleteDeploymentRevision(ns, name string, revision int64) error {
+
+	path := getDeploymentRevisionRootPath() + "/" + ns + "/" + name + "/" + strconv.FormatInt(revision, 10)
+	if err := store.Db.Delete(path); err != nil {
+		blog.Error("fail to delete deployment revision(%s) err:%s", path, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//DeleteDeploymentRevisions delete all the revisions of deployment
+func (store *managerStore) DeleteDeploymentRevisions(ns, name string) error {
+
+	revisions, err := store.ListDeploymentRevisions(ns, name)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if err := store.DeleteDeploymentRevision(ns, name, revision.Revision); err != nil {
+			return err
+		}
+	}
+
+	path := getDeploymentRevisionRootPath() + "/" + ns + "/" + name
+	if err := store.Db.Delete(path); err != nil {
+		blog.Error("fail to delete deployment revision node(%s) err:%s", path, err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"testing"
+
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentRevisions(t *testing.T) {
+	store := NewManagerStore(newMemDb())
+
+	for _, revision := range []int64{2, 10, 1} {
+		obj := &types.DeploymentRevision{Revision: revision}
+		obj.ObjectMeta.NameSpace = "ns"
+		obj.ObjectMeta.Name = "deploy"
+		assert.Nil(t, store.SaveDeploymentRevision(obj))
+	}
+
+	//listed in numeric order
+	revisions, err := store.ListDeploymentRevisions("ns", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, int64(1), revisions[0].Revision)
+	assert.Equal(t, int64(2), revisions[1].Revision)
+	assert.Equal(t, int64(10), revisions[2].Revision)
+
+	revision, err := store.FetchDeploymentRevision("ns", "deploy", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "deploy", revision.ObjectMeta.Name)
+
+	assert.Nil(t, store.DeleteDeploymentRevision("ns", "deploy", 10))
+	_, err = store.FetchDeploymentRevision("ns", "deploy", 10)
+	assert.Equal(t, zk.ErrNoNode, err)
+
+	assert.Nil(t, store.DeleteDeploymentRevisions("ns", "deploy"))
+	revisions, err = store.ListDeploymentRevisions("ns", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(revisions))
+}
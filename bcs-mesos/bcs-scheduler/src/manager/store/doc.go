@@ -12,7 +12,7 @@
  */
 
 /*
-Package store implements the interface for db operations and objects storage, currently using ZK
+Package store implements the interface for db operations and objects storage, using ZK or etcd v3
 
 Including Framework, applicaiton, version, task, taskgroup, configmap, secret, deployment,
 service, endpoint, agent.
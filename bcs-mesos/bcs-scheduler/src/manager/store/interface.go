@@ -225,6 +225,50 @@ type Store interface {
 	FetchAllAdmissionWebhooks() ([]*commtypes.AdmissionWebhookConfiguration, error)
 	/*=========AdmissionWebhook==========*/
 
+	// save resource quota
+	SaveResourceQuota(quota *commtypes.BcsResourceQuota) error
+	// fetch resource quota
+	FetchResourceQuota(ns, name string) (*commtypes.BcsResourceQuota, error)
+	// delete resource quota
+	DeleteResourceQuota(ns, name string) error
+	// list ns resource quota
+	ListResourceQuotas(runAs string) ([]*commtypes.BcsResourceQuota, error)
+	// list all resource quota
+	ListAllResourceQuotas() ([]*commtypes.BcsResourceQuota, error)
+
+	// save daemonset
+	SaveDaemonset(daemonset *types.Daemonset) error
+	// fetch daemonset
+	FetchDaemonset(ns, name string) (*types.Daemonset, error)
+	// delete daemonset
+	DeleteDaemonset(ns, name string) error
+	// list ns daemonsets
+	ListDaemonsets(runAs string) ([]*types.Daemonset, error)
+	// list all daemonsets
+	ListAllDaemonsets() ([]*types.Daemonset, error)
+
+	// save job
+	SaveJob(job *types.Job) error
+	// fetch job
+	FetchJob(ns, name string) (*types.Job, error)
+	// delete job
+	DeleteJob(ns, name string) error
+	// list ns jobs
+	ListJobs(runAs string) ([]*types.Job, error)
+	// list all jobs
+	ListAllJobs() ([]*types.Job, error)
+
+	// save deployment revision
+	SaveDeploymentRevision(revision *types.DeploymentRevision) error
+	// fetch deployment revision
+	FetchDeploymentRevision(ns, name string, revision int64) (*types.DeploymentRevision, error)
+	// list deployment revisions in ascending order
+	ListDeploymentRevisions(ns, name string) ([]*types.DeploymentRevision, error)
+	// delete deployment revision
+	DeleteDeploymentRevision(ns, name string, revision int64) error
+	// delete all revisions of deployment
+	DeleteDeploymentRevisions(ns, name string) error
+
 	//list object namespaces, object = applicationNode、versionNode...
 	ListObjectNamespaces(objectNode string) ([]string, error)
 
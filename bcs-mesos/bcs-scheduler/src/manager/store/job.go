@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+	"encoding/json"
+)
+
+func getJobRootPath() string {
+	return "/" + bcsRootNode + "/" + jobNode
+}
+
+func (store *managerStore) SaveJob(job *types.Job) error {
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	path := getJobRootPath() + "/" + job.ObjectMeta.NameSpace + "/" + job.ObjectMeta.Name
+
+	return store.Db.Insert(path, string(data))
+}
+
+func (store *managerStore) FetchJob(ns, name string) (*types.Job, error) {
+
+	path := getJobRootPath() + "/" + ns + "/" + name
+
+	data, err := store.Db.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &types.Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		blog.Error("fail to unmarshal job(%s). err:%s", string(data), err.Error())
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (store *managerStore) DeleteJob(ns, name string) error {
+
+	path := getJobRootPath() + "/" + ns + "/" + name
+	if err := store.Db.Delete(path); err != nil {
+		blog.Error("fail to delete job(%s) err:%s", path, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (store *managerStore) ListJobs(runAs string) ([]*types.Job, error) {
+
+	path := getJobRootPath() + "/" + runAs
+
+	names, err := store.Db.List(path)
+	if err != nil {
+		blog.Error("fail to list job names, err:%s", err.Error())
+		return nil, err
+	}
+
+	var objs []*types.Job
+	for _, name := range names {
+		obj, err := store.FetchJob(runAs, name)
+		if err != nil {
+			blog.Error("fail to fetch job(%s.%s)", runAs, name)
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+func (store *managerStore) ListAllJobs() ([]*types.Job, error) {
+	nss, err := store.ListObjectNamespaces(jobNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*types.Job
+	for _, ns := range nss {
+		obj, err := store.ListJobs(ns)
+		if err != nil {
+			blog.Error("fail to fetch job by ns(%s)", ns)
+			continue
+		}
+
+		objs = append(objs, obj...)
+	}
+
+	return objs, nil
+}
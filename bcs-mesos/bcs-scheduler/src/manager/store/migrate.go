@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"fmt"
+
+	"bk-bcs/bcs-common/common/blog"
+)
+
+//GetStoreRootPath return the root path of all the scheduler objects
+func GetStoreRootPath() string {
+	return "/" + bcsRootNode
+}
+
+//MigrateStore copy all the nodes under root from src db to dst db, return the number of copied nodes.
+//Nodes without data (the parent nodes created by zookeeper) are skipped.
+func MigrateStore(src, dst Dbdrvier, root string) (int, error) {
+	data, err := src.Fetch(root)
+	if err != nil {
+		return 0, fmt.Errorf("fetch %s error %s", root, err.Error())
+	}
+
+	count := 0
+	if len(data) > 0 {
+		if err = dst.Insert(root, string(data)); err != nil {
+			return count, fmt.Errorf("insert %s error %s", root, err.Error())
+		}
+		count++
+		blog.V(3).Infof("migrate node %s done", root)
+	}
+
+	childs, err := src.List(root)
+	if err != nil {
+		return count, fmt.Errorf("list %s error %s", root, err.Error())
+	}
+
+	for _, child := range childs {
+		n, err := MigrateStore(src, dst, root+"/"+child)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
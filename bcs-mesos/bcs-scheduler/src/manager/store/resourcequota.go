@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package store
+
+import (
+	"bk-bcs/bcs-common/common/blog"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"encoding/json"
+)
+
+func getResourceQuotaRootPath() string {
+	return "/" + bcsRootNode + "/" + resourceQuotaNode
+}
+
+func (store *managerStore) SaveResourceQuota(quota *commtypes.BcsResourceQuota) error {
+
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+
+	path := getResourceQuotaRootPath() + "/" + quota.ObjectMeta.NameSpace + "/" + quota.ObjectMeta.Name
+
+	return store.Db.Insert(path, string(data))
+}
+
+func (store *managerStore) FetchResourceQuota(ns, name string) (*commtypes.BcsResourceQuota, error) {
+
+	path := getResourceQuotaRootPath() + "/" + ns + "/" + name
+
+	data, err := store.Db.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &commtypes.BcsResourceQuota{}
+	if err := json.Unmarshal(data, quota); err != nil {
+		blog.Error("fail to unmarshal resourcequota(%s). err:%s", string(data), err.Error())
+		return nil, err
+	}
+
+	return quota, nil
+}
+
+func (store *managerStore) DeleteResourceQuota(ns, name string) error {
+
+	path := getResourceQuotaRootPath() + "/" + ns + "/" + name
+	if err := store.Db.Delete(path); err != nil {
+		blog.Error("fail to delete resourcequota(%s) err:%s", path, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (store *managerStore) ListResourceQuotas(runAs string) ([]*commtypes.BcsResourceQuota, error) {
+
+	path := getResourceQuotaRootPath() + "/" + runAs
+
+	names, err := store.Db.List(path)
+	if err != nil {
+		blog.Error("fail to list resourcequota names, err:%s", err.Error())
+		return nil, err
+	}
+
+	var objs []*commtypes.BcsResourceQuota
+	for _, name := range names {
+		obj, err := store.FetchResourceQuota(runAs, name)
+		if err != nil {
+			blog.Error("fail to fetch resourcequota(%s.%s)", runAs, name)
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+func (store *managerStore) ListAllResourceQuotas() ([]*commtypes.BcsResourceQuota, error) {
+	nss, err := store.ListObjectNamespaces(resourceQuotaNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*commtypes.BcsResourceQuota
+	for _, ns := range nss {
+		obj, err := store.ListResourceQuotas(ns)
+		if err != nil {
+			blog.Error("fail to fetch resourcequota by ns(%s)", ns)
+			continue
+		}
+
+		objs = append(objs, obj...)
+	}
+
+	return objs, nil
+}
@@ -66,7 +66,7 @@ func (store *managerStore) DeleteService(ns, name string) error {
 
 func (store *managerStore) ListServices(runAs string) ([]*commtypes.BcsService, error) {
 
-	path := getServiceRootPath() + runAs //defaultRunAs
+	path := getServiceRootPath() + "/" + runAs //defaultRunAs
 
 	IDs, err := store.Db.List(path)
 	if err != nil {
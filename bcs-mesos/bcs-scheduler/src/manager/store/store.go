@@ -191,4 +191,12 @@ const (
 	commandNode string = "command"
 	//admission webhook zk node
 	AdmissionWebhookNode string = "admissionwebhook"
+	//resource quota zk node
+	resourceQuotaNode string = "resourcequota"
+	//daemonset zk node
+	daemonsetNode string = "daemonset"
+	//job zk node
+	jobNode string = "job"
+	//deployment revision zk node
+	deploymentRevisionNode string = "deploymentrevision"
 )
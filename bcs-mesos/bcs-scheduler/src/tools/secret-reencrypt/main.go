@@ -0,0 +1,129 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+/*
+Package main is a one-shot tool to re-encrypt the secrets in scheduler store.
+
+It accepts the config file of bcs-scheduler. With gen_key, a new master key is added into
+secret_keyring_file and becomes primary. Then the data keys of all aesgcm secret items are
+rewrapped by the primary master key, and plaintext items are encrypted if
+secret_default_backend is aesgcm. Old master keys can be removed from keyring after that.
+Running schedulers reload the keyring file when it is modified.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-common/common/conf"
+	"bk-bcs/bcs-common/common/secretbackend"
+	commtypes "bk-bcs/bcs-common/common/types"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+)
+
+//ReencryptOptions options of the re-encryption, the keys are the same as bcs-scheduler config
+type ReencryptOptions struct {
+	conf.FileConfig
+	conf.LogConfig
+	ZkHost               string `json:"zkhost" value:"" usage:"zk address of scheduler store"`
+	StoreDriver          string `json:"store_driver" value:"zookeeper" usage:"the db driver of scheduler store, zookeeper or etcd"`
+	EtcdHost             string `json:"etcd_host" value:"" usage:"etcd address, split by comma"`
+	EtcdCAFile           string `json:"etcd_ca_file" value:"" usage:"the ca file of etcd"`
+	EtcdCertFile         string `json:"etcd_cert_file" value:"" usage:"the client cert file of etcd"`
+	EtcdKeyFile          string `json:"etcd_key_file" value:"" usage:"the client key file of etcd"`
+	SecretDefaultBackend string `json:"secret_default_backend" value:"" usage:"the backend of secret items without backend, aesgcm or empty for plaintext"`
+	SecretKeyringFile    string `json:"secret_keyring_file" value:"" usage:"the master keyring file of aesgcm secret backend"`
+	GenKey               string `json:"gen_key" value:"" usage:"generate a new primary master key with the id before re-encryption"`
+	DryRun               bool   `json:"dry_run" value:"false" usage:"only print the secrets to re-encrypt"`
+}
+
+func main() {
+	op := &ReencryptOptions{}
+	conf.Parse(op)
+
+	blog.InitLogs(op.LogConfig)
+	defer blog.CloseLogs()
+
+	if op.SecretKeyringFile == "" {
+		fmt.Fprintf(os.Stderr, "secret_keyring_file is required\n")
+		os.Exit(1)
+	}
+
+	if op.GenKey != "" && !op.DryRun {
+		if err := secretbackend.NewKeyring(op.SecretKeyringFile).GenerateKey(op.GenKey); err != nil {
+			fmt.Fprintf(os.Stderr, "generate master key %s error: %s\n", op.GenKey, err.Error())
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stdout, "master key %s is generated as primary\n", op.GenKey)
+	}
+
+	manager, err := secretbackend.NewManager(&secretbackend.Config{
+		KeyringFile:    op.SecretKeyringFile,
+		DefaultBackend: commtypes.SecretBackendType(op.SecretDefaultBackend),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "init secret backends error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	db, err := store.NewDbDriver(&store.DbDriverConfig{
+		Driver:       op.StoreDriver,
+		ZkHost:       op.ZkHost,
+		EtcdHost:     op.EtcdHost,
+		EtcdCAFile:   op.EtcdCAFile,
+		EtcdCertFile: op.EtcdCertFile,
+		EtcdKeyFile:  op.EtcdKeyFile,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+	managerStore := store.NewManagerStore(db)
+
+	secrets, err := managerStore.ListAllSecrets()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "list secrets error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	count, failed := 0, 0
+	for _, secret := range secrets {
+		changed, err := manager.Reencrypt(secret)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "re-encrypt secret %s.%s error: %s\n", secret.NameSpace, secret.Name, err.Error())
+			failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if op.DryRun {
+			fmt.Fprintf(os.Stdout, "secret %s.%s to re-encrypt\n", secret.NameSpace, secret.Name)
+			count++
+			continue
+		}
+		if err := managerStore.SaveSecret(secret); err != nil {
+			fmt.Fprintf(os.Stderr, "save secret %s.%s error: %s\n", secret.NameSpace, secret.Name, err.Error())
+			failed++
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "secret %s.%s re-encrypted\n", secret.NameSpace, secret.Name)
+		count++
+	}
+
+	fmt.Fprintf(os.Stdout, "re-encrypt %d of %d secrets done, %d failed\n", count, len(secrets), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
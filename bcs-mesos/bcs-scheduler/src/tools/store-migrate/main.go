@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+Package main is a one-shot tool to migrate the scheduler store from zookeeper to etcd.
+
+It accepts the config file of bcs-scheduler, copies every node under /blueking of zkhost
+into etcd_host, then bcs-scheduler can be restarted with "store_driver": "etcd".
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-common/common/conf"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/manager/store"
+	"bk-bcs/bcs-mesos/bcs-scheduler/src/util"
+)
+
+//MigrateOptions options of the migration, the keys are the same as bcs-scheduler config
+type MigrateOptions struct {
+	conf.FileConfig
+	conf.LogConfig
+	ZkHost       string `json:"zkhost" value:"" usage:"zk address of the source scheduler store"`
+	EtcdHost     string `json:"etcd_host" value:"" usage:"etcd address of the target scheduler store, split by comma"`
+	EtcdCAFile   string `json:"etcd_ca_file" value:"" usage:"the ca file of etcd"`
+	EtcdCertFile string `json:"etcd_cert_file" value:"" usage:"the client cert file of etcd"`
+	EtcdKeyFile  string `json:"etcd_key_file" value:"" usage:"the client key file of etcd"`
+}
+
+func main() {
+	op := &MigrateOptions{}
+	conf.Parse(op)
+
+	blog.InitLogs(op.LogConfig)
+	defer blog.CloseLogs()
+
+	if op.ZkHost == "" || op.EtcdHost == "" {
+		fmt.Fprintf(os.Stderr, "zkhost and etcd_host are required\n")
+		os.Exit(1)
+	}
+
+	dbzk, err := store.NewDbDriver(&store.DbDriverConfig{Driver: util.StoreDriverZk, ZkHost: op.ZkHost})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+	dbetcd, err := store.NewDbDriver(&store.DbDriverConfig{
+		Driver:       util.StoreDriverEtcd,
+		EtcdHost:     op.EtcdHost,
+		EtcdCAFile:   op.EtcdCAFile,
+		EtcdCertFile: op.EtcdCertFile,
+		EtcdKeyFile:  op.EtcdKeyFile,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	count, err := store.MigrateStore(dbzk, dbetcd, store.GetStoreRootPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate failed after %d nodes: %s\n", count, err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stdout, "migrate %d nodes from zookeeper %s to etcd %s done\n", count, op.ZkHost, op.EtcdHost)
+}
@@ -16,6 +16,7 @@ package types
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos/master"
@@ -43,6 +44,7 @@ const (
 	OPERATION_ROLLBACK   = "ROLLBACK"
 	OPERATION_RESCHEDULE = "RESCHEDULE"
 	OPERATION_UPDATE     = "UPDATE"
+	OPERATION_DAEMONSET  = "DAEMONSET"
 )
 
 //operation status
@@ -156,6 +158,14 @@ type Version struct {
 	Kind commtypes.BcsDataType
 	// add  20181122
 	RawJson *commtypes.ReplicaController `json:"raw_json,omitempty"`
+	// scheduling priority of taskgroups, the bigger the higher
+	Priority int32
+	// offer scoring policy, overrides the one of the cluster if set
+	ScorePolicy *commtypes.ScorePolicy
+	// init containers run to completion one by one before containers start
+	InitContainers []*InitContainer
+	// what to do with running taskgroups when referenced configmaps or secrets updated
+	ConfigReload *commtypes.ConfigReloadPolicy
 }
 
 //Resource discribe resources needed by a task
@@ -238,6 +248,24 @@ func (version *Version) CheckConstraints() bool {
 		}
 	}
 
+	return checkAppAffinity(version.Constraints.AppAffinity) && checkAppAffinity(version.Constraints.AppAntiAffinity)
+}
+
+//check app affinity terms: applications must be set, weight of preferred terms is from 1 to 100
+func checkAppAffinity(affinity *commtypes.AppAffinity) bool {
+	if affinity == nil {
+		return true
+	}
+	for _, term := range affinity.Required {
+		if term == nil || len(term.Applications) == 0 {
+			return false
+		}
+	}
+	for _, term := range affinity.Preferred {
+		if term == nil || len(term.Term.Applications) == 0 || term.Weight < 1 || term.Weight > 100 {
+			return false
+		}
+	}
 	return true
 }
 
@@ -310,6 +338,8 @@ func (version *Version) AllResource() *Resource {
 
 //Container for Version
 type Container struct {
+	// container name defined by user, used to select container in taskgroup
+	Name          string
 	Type          string
 	Docker        *Docker
 	Volumes       []*Volume
@@ -321,11 +351,26 @@ type Container struct {
 	Secrets    []commtypes.Secret
 
 	HealthChecks []*commtypes.HealthCheck
+	//probes checked by executor
+	LivenessProbe  *commtypes.HealthCheck
+	ReadinessProbe *commtypes.HealthCheck
+	StartupProbe   *commtypes.HealthCheck
+	//hooks run by executor
+	Lifecycle *commtypes.Lifecycle
 
 	//network flow limit
 	NetLimit *commtypes.NetLimit
 }
 
+//InitContainer for Version, runs to completion before containers start
+type InitContainer struct {
+	Name          string
+	Docker        *Docker
+	Volumes       []*Volume
+	Resources     *Resource
+	LimitResoures *Resource
+}
+
 //Docker for container
 type Docker struct {
 	Hostname        string
@@ -362,6 +407,15 @@ type Volume struct {
 	ContainerPath string
 	HostPath      string
 	Mode          string
+	// Name of volume, containers in taskgroup share emptyDir with the same name
+	Name     string                    `json:",omitempty"`
+	EmptyDir *commtypes.EmptyDirVolume `json:",omitempty"`
+	Local    *commtypes.LocalVolume    `json:",omitempty"`
+}
+
+// IsManaged volume is created and cleaned by executor, not by agent host path
+func (v *Volume) IsManaged() bool {
+	return v.EmptyDir != nil || v.Local != nil
 }
 
 //HealthCheck
@@ -394,6 +448,7 @@ type Task struct {
 	Kind            commtypes.BcsDataType
 	ID              string
 	Name            string
+	ContainerName   string
 	Hostame         string
 	Command         string
 	Arguments       []string
@@ -417,6 +472,17 @@ type Task struct {
 	IsChecked                   bool
 	ConsecutiveFailureTimes     uint32
 	LocalMaxConsecutiveFailures uint32
+	// readiness and startup probes checked by executor, liveness probe is merged into HealthChecks
+	ReadinessProbe *commtypes.HealthCheck
+	StartupProbe   *commtypes.HealthCheck
+	// reported by executor, Ready is only useful when ReadinessProbe is set,
+	// Started is only useful when StartupProbe is set
+	Ready   bool
+	Started bool
+	// hooks run by executor
+	Lifecycle *commtypes.Lifecycle
+	// init containers of taskgroup, only set in the first task
+	InitContainers []*InitContainer
 
 	OfferId        string
 	AgentId        string
@@ -434,6 +500,35 @@ type Task struct {
 	Message        string
 	//network flow limit
 	NetLimit *commtypes.NetLimit
+	// resource usage of container sampled by executor
+	Stats *ContainerStats `json:",omitempty"`
+}
+
+// ContainerStats resource usage of container sampled by executor,
+// counters are cumulative since container started
+type ContainerStats struct {
+	Timestamp        time.Time // sample time
+	CPUUsage         uint64    // cpu time in nanoseconds
+	CPUPercent       float64   // cpu usage between the last two samples, 100 means one core
+	MemoryUsage      uint64    // memory usage in bytes, including page cache
+	MemoryWorkingSet uint64    // memory usage without inactive page cache
+	MemoryLimit      uint64    // memory limit in bytes, 0 means no limit
+	NetRxBytes       uint64
+	NetRxPackets     uint64
+	NetTxBytes       uint64
+	NetTxPackets     uint64
+	BlkioReadBytes   uint64
+	BlkioWriteBytes  uint64
+}
+
+//IsReady task is ready for service when it has no readiness probe or the probe succeeds
+func (task *Task) IsReady() bool {
+	return task.ReadinessProbe == nil || task.Ready
+}
+
+//IsStarted task is started when it has no startup probe or the probe succeeds
+func (task *Task) IsStarted() bool {
+	return task.StartupProbe == nil || task.Started
 }
 
 // taskgroup describes the implements of multiple tasks
@@ -469,6 +564,18 @@ type TaskGroup struct {
 	CurrResource   *Resource
 	//BcsMessages map[int64]*BcsMessage
 	BcsEventMsg *BcsMessage
+	// scheduling priority, copied from version when built
+	Priority int32
+}
+
+//IsReady taskgroup is ready for service when all tasks are ready
+func (tg *TaskGroup) IsReady() bool {
+	for _, task := range tg.Taskgroup {
+		if !task.IsReady() {
+			return false
+		}
+	}
+	return true
 }
 
 //Application for container
@@ -676,6 +783,14 @@ type DataClass struct {
 	NetLimit       *commtypes.NetLimit
 	//add for proc 20180730
 	ProcInfo *ProcDef
+	//probes checked by executor, port is resolved by scheduler
+	ReadinessProbe *commtypes.HealthCheck
+	StartupProbe   *commtypes.HealthCheck
+	//hooks and init containers run by executor
+	Lifecycle      *commtypes.Lifecycle
+	InitContainers []*InitContainer
+	//emptyDir & local volumes created by executor
+	Volumes []*Volume
 }
 
 type DeploymentDef struct {
@@ -702,6 +817,11 @@ const (
 	DEPLOYMENT_OPERATION_START  = "START"
 )
 
+const (
+	DEPLOYMENT_EVENT_ROLLBACK                   = "Rollback"
+	DEPLOYMENT_EVENT_PROGRESS_DEADLINE_EXCEEDED = "ProgressDeadlineExceeded"
+)
+
 type Deployment struct {
 	ObjectMeta      commtypes.ObjectMeta        `json:"metadata"`
 	Selector        map[string]string           `json:"selector,omitempty"`
@@ -717,6 +837,54 @@ type Deployment struct {
 	// add  20181122
 	RawJson       *commtypes.BcsDeployment `json:"raw_json,omitempty"`
 	RawJsonBackup *commtypes.BcsDeployment `json:"raw_json_backup,omitempty"`
+	// index of the current canary step, and the time when the scaling of the step finished,
+	// the step is in analysis when it is not in rolling and the time is not 0
+	CurrCanaryStep     int   `json:"curr_canary_step"`
+	CanaryStepFinished int64 `json:"canary_step_finished"`
+	// the time when the services are switched to the new application in blue-green update
+	BlueGreenSwitched int64 `json:"bluegreen_switched"`
+	// revision of the current spec, and the revision before update, like RawJson and RawJsonBackup
+	Revision       int64 `json:"revision"`
+	RevisionBackup int64 `json:"revision_backup"`
+}
+
+// label added to the taskgroups of the new application in blue-green update,
+// the selector of active services is switched to this label
+const DEPLOYMENT_BLUEGREEN_APP_LABEL = "io.tencent.bcs.deployment.application"
+
+const (
+	// annotations of deployment recorded in the revision
+	DEPLOYMENT_ANNOTATION_OPERATOR     = "io.tencent.bcs.deployment.operator"
+	DEPLOYMENT_ANNOTATION_CHANGE_CAUSE = "io.tencent.bcs.deployment.change-cause"
+	// revisions kept besides the current one if revisionHistoryLimit not set
+	DEPLOYMENT_REVISION_HISTORY_LIMIT_DEFAULT = 10
+)
+
+// DeploymentRevision is a history record of the deployment spec,
+// it is created when the deployment is created or updated
+type DeploymentRevision struct {
+	ObjectMeta  commtypes.ObjectMeta      `json:"metadata"`
+	Revision    int64                     `json:"revision"`
+	Version     *Version                  `json:"version"`
+	Strategy    commtypes.UpgradeStrategy `json:"strategy"`
+	RawJson     *commtypes.BcsDeployment  `json:"raw_json,omitempty"`
+	CreateTime  int64                     `json:"create_time"`
+	Operator    string                    `json:"operator"`
+	ChangeCause string                    `json:"change_cause"`
+}
+
+// DeploymentRevisionDiff is a changed field between two revisions
+type DeploymentRevisionDiff struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// DeploymentRollbackDef is the request to rollback deployment to a revision,
+// revision 0 means the previous revision
+type DeploymentRollbackDef struct {
+	Revision int64  `json:"revision"`
+	Operator string `json:"operator"`
 }
 
 type DeploymentReferApplication struct {
@@ -725,6 +893,68 @@ type DeploymentReferApplication struct {
 	CurrentRollingInstances int    `josn:"curr_rolling_instances"`
 }
 
+type DaemonsetDef struct {
+	ObjectMeta commtypes.ObjectMeta `json:"metadata"`
+	Version    *Version             `json:"version"`
+	// raw json of daemonset from driver
+	RawJson *commtypes.BcsDaemonset `json:"raw_json,omitempty"`
+}
+
+const (
+	DAEMONSET_STATUS_RUNNING  = "Running"
+	DAEMONSET_STATUS_DELETING = "Deleting"
+)
+
+// Daemonset runs one taskgroup on every enabled agent,
+// the taskgroups belong to the application with the same namespace and name
+type Daemonset struct {
+	ObjectMeta commtypes.ObjectMeta `json:"metadata"`
+	Status     string               `json:"status"`
+	Message    string               `json:"message"`
+	// the hosts which the daemonset taskgroups are running on, hostname -> taskgroup ID
+	Pods      map[string]string       `json:"pods"`
+	CheckTime int64                   `json:"check_time"`
+	RawJson   *commtypes.BcsDaemonset `json:"raw_json,omitempty"`
+}
+
+type JobDef struct {
+	ObjectMeta            commtypes.ObjectMeta `json:"metadata"`
+	Version               *Version             `json:"version"`
+	Completions           int                  `json:"completions"`
+	Parallelism           int                  `json:"parallelism"`
+	BackoffLimit          int                  `json:"backoff_limit"`
+	ActiveDeadlineSeconds int64                `json:"active_deadline_seconds"`
+	// raw json of job from driver
+	RawJson *commtypes.BcsJob `json:"raw_json,omitempty"`
+}
+
+const (
+	JOB_STATUS_RUNNING  = "Running"
+	JOB_STATUS_COMPLETE = "Complete"
+	JOB_STATUS_FAILED   = "Failed"
+	JOB_STATUS_DELETING = "Deleting"
+)
+
+// Job runs taskgroups to completion,
+// the taskgroups belong to the application with the same namespace and name
+type Job struct {
+	ObjectMeta            commtypes.ObjectMeta `json:"metadata"`
+	Completions           int                  `json:"completions"`
+	Parallelism           int                  `json:"parallelism"`
+	BackoffLimit          int                  `json:"backoff_limit"`
+	ActiveDeadlineSeconds int64                `json:"active_deadline_seconds"`
+	Status                string               `json:"status"`
+	Message               string               `json:"message"`
+	// the count of taskgroups in running, succeeded and failed
+	Active         int               `json:"active"`
+	Succeeded      int               `json:"succeeded"`
+	Failed         int               `json:"failed"`
+	StartTime      int64             `json:"start_time"`
+	CompletionTime int64             `json:"completion_time"`
+	CheckTime      int64             `json:"check_time"`
+	RawJson        *commtypes.BcsJob `json:"raw_json,omitempty"`
+}
+
 type AgentSchedInfo struct {
 	HostName   string  `json:"host_name"`
 	DeltaCPU   float64 `json:"delta_cpu"`
@@ -733,6 +963,14 @@ type AgentSchedInfo struct {
 	Taskgroups map[string]*Resource
 }
 
+//ScoredOffer is the mesos offer with the scores of scheduler offer scoring
+type ScoredOffer struct {
+	*mesos.Offer
+	Score float64 `json:"score"`
+	//score of each scorer plugin
+	Scores map[string]float64 `json:"scores,omitempty"`
+}
+
 type TaskGroupOpResult struct {
 	ID     string
 	Status string
@@ -38,6 +38,9 @@ const (
 	Msg_Req_COMMAND_TASK   Msg_Type = 12
 	Msg_Res_COMMAND_TASK   Msg_Type = 13
 	Msg_TASK_STATUS_UPDATE Msg_Type = 14
+	Msg_Req_LOGS_TASK      Msg_Type = 15
+	Msg_Res_LOGS_TASK      Msg_Type = 16
+	Msg_RELOAD_CONFIG      Msg_Type = 17
 )
 
 const (
@@ -56,6 +59,9 @@ const (
 	Msg_Req_COMMAND_TASK_STR   string = "request_command_task"
 	Msg_Res_COMMAND_TASK_STR   string = "response_command_task"
 	Msg_TASK_STATUS_UPDATE_STR string = "task_status_update"
+	Msg_Req_LOGS_TASK_STR      string = "request_logs_task"
+	Msg_Res_LOGS_TASK_STR      string = "response_logs_task"
+	Msg_RELOAD_CONFIG_STR      string = "reload_config"
 )
 
 type Secret_Type int32
@@ -87,7 +93,11 @@ type BcsMessage struct {
 	RestartTask         *Msg_RestartTasks        `json:",omitempty"`
 	RequestCommandTask  *RequestCommandTask      `json:",omitempty"`
 	ResponseCommandTask *ResponseCommandTask     `json:",omitempty"`
+	RequestLogsTask     *RequestLogsTask         `json:",omitempty"`
+	ResponseLogsTask    *ResponseLogsTask        `json:",omitempty"`
+	ReloadConfig        *Msg_ReloadConfig        `json:",omitempty"`
 	TaskStatus          []byte                   `json:",omitempty"`
+	TaskStats           *ContainerStats          `json:",omitempty"`
 
 	Status MsgStatus_type
 	//if status=failed, then message is failed info
@@ -146,6 +156,11 @@ type Msg_Secret struct {
 	Name  *string
 	Value *string
 	Type  *Secret_Type
+	//Backend of secret item, Value is ciphertext or empty then,
+	//the content is resolved when taskinfo created and never stored
+	Backend *types.SecretBackend `json:",omitempty"`
+	//Resolved content cached when task created
+	Resolved *string `json:"-"`
 }
 
 type Msg_TaskStatusQuery struct {
@@ -172,6 +187,15 @@ type Msg_ReloadTasks struct {
 type Msg_RestartTasks struct {
 }
 
+//Msg_ReloadConfig carries the changed configmap/secret files of one task,
+//executor writes Files & Remotes into the container, then sends Signal or runs Command
+type Msg_ReloadConfig struct {
+	Files   []*Msg_LocalFile `json:",omitempty"`
+	Remotes []*Msg_Remote    `json:",omitempty"`
+	Signal  int              `json:",omitempty"`
+	Command []string         `json:",omitempty"`
+}
+
 type CommitTask struct {
 	TaskId *string
 	Image  *string
@@ -197,6 +221,34 @@ type ResponseCommandTask struct {
 	CommInspect *types.CommandInspectInfo
 }
 
+const (
+	//LogsDefaultLimit default max bytes of logs in one response
+	LogsDefaultLimit = 256 * 1024
+	//LogsMaxLimit max bytes of logs in one response, keep mesos message small
+	LogsMaxLimit = 1024 * 1024
+)
+
+//RequestLogsTask fetch logs of task container collected by executor.
+//logs is read from Offset, if Tail > 0, the last Tail lines is read and Offset is ignored
+type RequestLogsTask struct {
+	ID     string //request id
+	TaskId string //application taskid
+	Offset int64  //offset of logs stream
+	Tail   int    //lines from the end of logs
+	Limit  int    //max bytes of logs in response
+}
+
+type ResponseLogsTask struct {
+	ID          string //request id
+	TaskId      string //application taskid
+	ContainerId string //container id
+	Status      MsgStatus_type
+	Message     string
+	Offset      int64  //offset of the logs in response, bigger than requested one when logs are rotated away
+	NextOffset  int64  //offset for next request
+	Logs        []byte //logs content
+}
+
 func (x Msg_Type) Enum() *Msg_Type {
 	p := new(Msg_Type)
 	*p = x
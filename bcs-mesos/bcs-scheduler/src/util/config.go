@@ -19,7 +19,9 @@ import (
 	"strconv"
 	//"github.com/spf13/pflag"
 	"bk-bcs/bcs-common/common/conf"
+	"bk-bcs/bcs-common/common/secretbackend"
 	"bk-bcs/bcs-common/common/static"
+	commtypes "bk-bcs/bcs-common/common/types"
 )
 
 type SchedulerOptions struct {
@@ -36,22 +38,53 @@ type SchedulerOptions struct {
 	RegDiscvSvr       string `json:"regdiscv" value:"" usage:"the address to register and discove scheduler"`
 	UseCache          bool   `json:"use_cache" value:"false" usage:"whether use cache or not"`
 	DoRecover         bool   `json:"do_recover" value:"false" usage:"whether recover taskgroup LOST to RUNNING in master role"`
+	EnablePreemption  bool   `json:"enable_preemption" value:"false" usage:"whether taskgroups with higher priority can preempt lower ones when there is no fit offer"`
+	ScorePolicy       string `json:"score_policy" value:"" usage:"the offer scoring policy, name[:weight[:args]] split by comma, default LeastAllocated"`
 	Plugins           string `json:"plugins" value:"" usage:"whether use plugins"`
 	ZkHost            string `json:"zkhost" value:"" usage:"zk address"`
+	StoreDriver       string `json:"store_driver" value:"zookeeper" usage:"the db driver of scheduler store, zookeeper or etcd"`
+	EtcdHost          string `json:"etcd_host" value:"" usage:"etcd address, split by comma"`
+	EtcdCAFile        string `json:"etcd_ca_file" value:"" usage:"the ca file of etcd"`
+	EtcdCertFile      string `json:"etcd_cert_file" value:"" usage:"the client cert file of etcd"`
+	EtcdKeyFile       string `json:"etcd_key_file" value:"" usage:"the client key file of etcd"`
 	Cluster           string `json:"cluster" value:"" usage:"the cluster ID under bcs"`
 	PluginDir         string `json:"plugin_dir" value:"" usage:"the plugin dir"`
 	ContainerExecutor string `json:"container_executor" value:"" usage:"the container executor path"`
 	ProcessExecutor   string `json:"process_executor" value:"" usage:"the process executor path"`
 	CniDir            string `json:"cni_dir" value:"" usage:"the cni directory"`
 	NetImage          string `json:"net_image" value:"" usage:"the network image"`
+	ContainerRuntime  string `json:"container_runtime" value:"" usage:"the container runtime of executor, docker or cri"`
+	CRIEndpoint       string `json:"cri_endpoint" value:"" usage:"the cri runtime socket for executor"`
+	CRIImageTool      string `json:"cri_image_tool" value:"" usage:"the tool compatible with nerdctl for committing images in cri runtime"`
+
+	SecretDefaultBackend string `json:"secret_default_backend" value:"" usage:"the backend of secret items without backend, aesgcm or empty for plaintext"`
+	SecretKeyringFile    string `json:"secret_keyring_file" value:"" usage:"the master keyring file of aesgcm secret backend"`
+	SecretFileDir        string `json:"secret_file_dir" value:"" usage:"the directory of file secret backend"`
+	SecretVaultAddr      string `json:"secret_vault_addr" value:"" usage:"the vault address of vault secret backend"`
+	SecretVaultToken     string `json:"secret_vault_token" value:"" usage:"the vault token of vault secret backend"`
 }
 
 type SchedConfig struct {
 	Scheduler    Scheduler
 	HttpListener HttpListener
 	ZkHost       string
+	StoreDriver  string
+	EtcdHost     string
+	EtcdCAFile   string
+	EtcdCertFile string
+	EtcdKeyFile  string
+
+	// backends of secret items, see package secretbackend
+	SecretBackend secretbackend.Config
 }
 
+const (
+	// StoreDriverZk store scheduler objects in zookeeper
+	StoreDriverZk = "zookeeper"
+	// StoreDriverEtcd store scheduler objects in etcd v3
+	StoreDriverEtcd = "etcd"
+)
+
 type Scheduler struct {
 	Hostname      string
 	MesosMasterZK string
@@ -65,6 +98,12 @@ type Scheduler struct {
 	Plugins       string
 	PluginDir     string
 
+	// higher priority taskgroups can preempt lower ones
+	EnablePreemption bool
+
+	// offer scoring policy of the cluster, see package score
+	ScorePolicy string
+
 	ClientCAFile   string
 	ClientCertFile string
 	ClientKeyFile  string
@@ -79,6 +118,9 @@ type Scheduler struct {
 	ProcessExecutor   string
 	CniDir            string
 	NetImage          string
+	ContainerRuntime  string
+	CRIEndpoint       string
+	CRIImageTool      string
 }
 
 type HttpListener struct {
@@ -94,7 +136,8 @@ type HttpListener struct {
 
 func NewSchedulerCfg() *SchedConfig {
 	config := SchedConfig{
-		ZkHost: "",
+		ZkHost:      "",
+		StoreDriver: StoreDriverZk,
 		HttpListener: HttpListener{
 			TCPAddr:  "",
 			UnixAddr: "",
@@ -128,6 +171,17 @@ func NewSchedulerCfg() *SchedConfig {
 func SetSchedulerCfg(config *SchedConfig, op *SchedulerOptions) {
 
 	config.ZkHost = op.ZkHost
+	config.StoreDriver = op.StoreDriver
+	config.EtcdHost = op.EtcdHost
+	config.EtcdCAFile = op.EtcdCAFile
+	config.EtcdCertFile = op.EtcdCertFile
+	config.EtcdKeyFile = op.EtcdKeyFile
+
+	config.SecretBackend.DefaultBackend = commtypes.SecretBackendType(op.SecretDefaultBackend)
+	config.SecretBackend.KeyringFile = op.SecretKeyringFile
+	config.SecretBackend.FileDir = op.SecretFileDir
+	config.SecretBackend.VaultAddr = op.SecretVaultAddr
+	config.SecretBackend.VaultToken = op.SecretVaultToken
 
 	config.Scheduler.MesosMasterZK = op.MesosMasterZK
 	config.Scheduler.BcsZK = op.BCSZk
@@ -145,6 +199,8 @@ func SetSchedulerCfg(config *SchedConfig, op *SchedulerOptions) {
 	config.Scheduler.Address = op.Address + ":" + strconv.Itoa(int(op.Port))
 	config.Scheduler.UseCache = op.UseCache
 	config.Scheduler.DoRecover = op.DoRecover
+	config.Scheduler.EnablePreemption = op.EnablePreemption
+	config.Scheduler.ScorePolicy = op.ScorePolicy
 	config.Scheduler.Plugins = op.Plugins
 	config.Scheduler.Cluster = op.Cluster
 	config.Scheduler.PluginDir = op.PluginDir
@@ -152,6 +208,9 @@ func SetSchedulerCfg(config *SchedConfig, op *SchedulerOptions) {
 	config.Scheduler.ProcessExecutor = op.ProcessExecutor
 	config.Scheduler.CniDir = op.CniDir
 	config.Scheduler.NetImage = op.NetImage
+	config.Scheduler.ContainerRuntime = op.ContainerRuntime
+	config.Scheduler.CRIEndpoint = op.CRIEndpoint
+	config.Scheduler.CRIImageTool = op.CRIImageTool
 
 	config.HttpListener.TCPAddr = op.Address + ":" + strconv.Itoa(int(op.Port))
 	//config.HttpListener.CertDir = op.ServerCertDir
@@ -24,7 +24,7 @@ import (
 func NewCreateCommand() cli.Command {
 	return cli.Command{
 		Name:  "create",
-		Usage: "create new application/process/service/secret/configmap/deployment",
+		Usage: "create new application/process/service/secret/configmap/deployment/job",
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "from-file, f",
@@ -36,7 +36,7 @@ func NewCreateCommand() cli.Command {
 			},
 			cli.StringFlag{
 				Name:  "type, t",
-				Usage: "Create type, value can be app/service/secret/configmap/deployment",
+				Usage: "Create type, value can be app/service/secret/configmap/deployment/job",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -68,6 +68,8 @@ func create(c *utils.ClientContext) error {
 		return createService(c)
 	case "deploy", "deployment":
 		return createDeployment(c)
+	case "job":
+		return createJob(c)
 	default:
 		return fmt.Errorf("invalid type: %s", resourceType)
 	}
@@ -24,11 +24,11 @@ import (
 func NewDeleteCommand() cli.Command {
 	return cli.Command{
 		Name:  "delete",
-		Usage: "delete app/process/taskgroup/configmap/service/secret/deployment",
+		Usage: "delete app/process/taskgroup/configmap/service/secret/deployment/job",
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "type, t",
-				Usage: "Delete type, app/taskgroup/configmap/service/secret/deployment",
+				Usage: "Delete type, app/taskgroup/configmap/service/secret/deployment/job",
 			},
 			cli.StringFlag{
 				Name:  "name, n",
@@ -78,6 +78,8 @@ func deleteF(c *utils.ClientContext) error {
 		return deleteService(c)
 	case "deploy", "deployment":
 		return deleteDeployment(c)
+	case "job":
+		return deleteJob(c)
 	default:
 		return fmt.Errorf("invalid type: %s", resourceType)
 	}
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package deployment
+
+import (
+	"bk-bcs/bcs-services/bcs-client/cmd/utils"
+	v4 "bk-bcs/bcs-services/bcs-client/pkg/scheduler/v4"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+//NewHistoryCommand show revision history of deployment command
+func NewHistoryCommand() cli.Command {
+	return cli.Command{
+		Name:  "history",
+		Usage: "show revision history of deployment",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "type, t",
+				Usage: "History type, deployment",
+			},
+			cli.StringFlag{
+				Name:  "name, n",
+				Usage: "Deployment name",
+			},
+			cli.StringFlag{
+				Name:  "namespace, ns",
+				Usage: "Namespace",
+				Value: "defaultGroup",
+			},
+			cli.Int64Flag{
+				Name:  "revision",
+				Usage: "Show the details of the revision",
+			},
+			cli.StringFlag{
+				Name:  "diff",
+				Usage: "Show the changes between two revisions, `FROM,TO`",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return history(utils.NewClientContext(c))
+		},
+	}
+}
+
+func history(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionType); err != nil {
+		return err
+	}
+
+	resourceType := c.String(utils.OptionType)
+
+	switch resourceType {
+	case deploy, deployment:
+		return historyDeployment(c)
+	default:
+		return fmt.Errorf("invalid type: %s", resourceType)
+	}
+}
+
+func historyDeployment(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionClusterID, utils.OptionNamespace, utils.OptionName); err != nil {
+		return err
+	}
+
+	scheduler := v4.NewBcsScheduler(utils.GetClientOption())
+	name := c.String(utils.OptionName)
+
+	if c.IsSet(utils.OptionDiff) {
+		revisions := strings.Split(c.String(utils.OptionDiff), ",")
+		if len(revisions) != 2 {
+			return fmt.Errorf("invalid diff: %s, should be FROM,TO", c.String(utils.OptionDiff))
+		}
+		from, err := strconv.ParseInt(strings.TrimSpace(revisions[0]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid diff revision %s: %v", revisions[0], err)
+		}
+		to, err := strconv.ParseInt(strings.TrimSpace(revisions[1]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid diff revision %s: %v", revisions[1], err)
+		}
+
+		diffs, err := scheduler.DiffDeploymentRevisions(c.ClusterID(), c.Namespace(), name, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to diff deployment revisions: %v", err)
+		}
+		for _, diff := range diffs {
+			fmt.Printf("%s: %v -> %v\n", diff.Path, diff.From, diff.To)
+		}
+		return nil
+	}
+
+	if c.IsSet(utils.OptionRevision) {
+		revision, err := scheduler.GetDeploymentRevision(c.ClusterID(), c.Namespace(), name, c.Int64(utils.OptionRevision))
+		if err != nil {
+			return fmt.Errorf("failed to get deployment revision: %v", err)
+		}
+		fmt.Printf("%s\n", utils.TryIndent(revision))
+		return nil
+	}
+
+	revisions, err := scheduler.ListDeploymentRevisions(c.ClusterID(), c.Namespace(), name)
+	if err != nil {
+		return fmt.Errorf("failed to list deployment revisions: %v", err)
+	}
+
+	fmt.Printf("%-10s %-25s %-20s %-50s\n", "REVISION", "CREATED", "OPERATOR", "CHANGE-CAUSE")
+	for _, revision := range revisions {
+		fmt.Printf("%-10d %-25s %-20s %-50s\n",
+			revision.Revision,
+			time.Unix(revision.CreateTime, 0).Format("2006-01-02 15:04:05"),
+			revision.Operator,
+			revision.ChangeCause)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package deployment
+
+import (
+	"bk-bcs/bcs-services/bcs-client/cmd/utils"
+	v4 "bk-bcs/bcs-services/bcs-client/pkg/scheduler/v4"
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+//NewUndoCommand rollback deployment to a revision command
+func NewUndoCommand() cli.Command {
+	return cli.Command{
+		Name:  "undo",
+		Usage: "rollback deployment to a revision",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "type, t",
+				Usage: "Undo type, deployment",
+			},
+			cli.StringFlag{
+				Name:  "name, n",
+				Usage: "Deployment name",
+			},
+			cli.StringFlag{
+				Name:  "namespace, ns",
+				Usage: "Namespace",
+				Value: "defaultGroup",
+			},
+			cli.Int64Flag{
+				Name:  "to-revision",
+				Usage: "The revision to rollback to, default to the previous revision",
+			},
+			cli.StringFlag{
+				Name:   "operator",
+				Usage:  "Operator of the rollback recorded in revision history",
+				EnvVar: "USER",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return undo(utils.NewClientContext(c))
+		},
+	}
+}
+
+func undo(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionType); err != nil {
+		return err
+	}
+
+	resourceType := c.String(utils.OptionType)
+
+	switch resourceType {
+	case deploy, deployment:
+		return undoDeployment(c)
+	default:
+		return fmt.Errorf("invalid type: %s", resourceType)
+	}
+}
+
+func undoDeployment(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionClusterID, utils.OptionNamespace, utils.OptionName); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"revision": c.Int64(utils.OptionToRevision),
+		"operator": c.String(utils.OptionOperator),
+	})
+	if err != nil {
+		return err
+	}
+
+	scheduler := v4.NewBcsScheduler(utils.GetClientOption())
+	err = scheduler.RollbackDeployment(c.ClusterID(), c.Namespace(), c.String(utils.OptionName), data)
+	if err != nil {
+		return fmt.Errorf("failed to rollback deployment: %v", err)
+	}
+
+	fmt.Printf("success to rollback deployment\n")
+	return nil
+}
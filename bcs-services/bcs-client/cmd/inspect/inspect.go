@@ -24,11 +24,11 @@ import (
 func NewInspectCommand() cli.Command {
 	return cli.Command{
 		Name:  "inspect",
-		Usage: "show detailed information of application, taskgroup, service, configmap, deployment or secret",
+		Usage: "show detailed information of application, taskgroup, service, configmap, deployment, job or secret",
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "type, t",
-				Usage: "Inspect type, app/process/taskgroup/service/configmap/secret/deployment/endpoint",
+				Usage: "Inspect type, app/process/taskgroup/service/configmap/secret/deployment/job/endpoint",
 			},
 			cli.StringFlag{
 				Name:  "clusterid",
@@ -75,6 +75,8 @@ func inspect(c *utils.ClientContext) error {
 		return inspectService(c)
 	case "deploy", "deployment":
 		return inspectDeployment(c)
+	case "job":
+		return inspectJob(c)
 	case "endpoint":
 		return inspectEndpoint(c)
 	default:
@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package list
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"bk-bcs/bcs-services/bcs-client/cmd/utils"
+	"bk-bcs/bcs-services/bcs-client/pkg/storage/v1"
+)
+
+func listJob(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionClusterID, utils.OptionNamespace); err != nil {
+		return err
+	}
+
+	storage := v1.NewBcsStorage(utils.GetClientOption())
+
+	// get namespace
+	condition := url.Values{}
+	condition.Add(FilterNamespaceTag, c.Namespace())
+
+	if c.IsAllNamespace() {
+		var err error
+		if condition, err = getNamespaceFilter(storage, c.ClusterID()); err != nil {
+			return err
+		}
+	}
+
+	list, err := storage.ListJob(c.ClusterID(), condition)
+	if err != nil {
+		return fmt.Errorf("failed to list job: %v", err)
+	}
+
+	sort.Sort(list)
+	return printListJob(list)
+}
+
+func printListJob(list v1.JobList) error {
+	if len(list) == 0 {
+		fmt.Printf("Found no job\n")
+		return nil
+	}
+
+	fmt.Printf("%-50s  %-10s  %-30s  %-11s  %-8s  %-9s  %-8s\n",
+		"NAME",
+		"STATUS",
+		"NAMESPACE",
+		"COMPLETIONS",
+		"ACTIVE",
+		"SUCCEEDED",
+		"FAILED")
+	for _, job := range list {
+		fmt.Printf("%-50s  %-10s  %-30s  %-11d  %-8d  %-9d  %-8d\n",
+			job.Data.ObjectMeta.Name,
+			job.Data.Status,
+			job.Data.ObjectMeta.NameSpace,
+			job.Data.Completions,
+			job.Data.Active,
+			job.Data.Succeeded,
+			job.Data.Failed)
+	}
+	return nil
+}
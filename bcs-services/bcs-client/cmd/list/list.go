@@ -28,7 +28,7 @@ func NewListCommand() cli.Command {
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "type, t",
-				Usage: "List type, ns/app/process/taskgroup/service/configmap/secret/deployment/endpoint/agent",
+				Usage: "List type, ns/app/process/taskgroup/service/configmap/secret/deployment/job/endpoint/agent",
 			},
 			cli.StringFlag{
 				Name:  "clusterid",
@@ -81,6 +81,8 @@ func list(c *utils.ClientContext) error {
 		return listService(c)
 	case "deploy", "deployment":
 		return listDeployment(c)
+	case "job":
+		return listJob(c)
 	case "endpoint":
 		return listEndpoint(c)
 	case "agent":
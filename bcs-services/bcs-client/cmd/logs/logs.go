@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logs
+
+import (
+	"bk-bcs/bcs-services/bcs-client/cmd/utils"
+	v4 "bk-bcs/bcs-services/bcs-client/pkg/scheduler/v4"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	//followInterval interval for polling new logs when following
+	followInterval = time.Second
+)
+
+//NewLogsCommand print logs of container in taskgroup
+func NewLogsCommand() cli.Command {
+	return cli.Command{
+		Name:      "logs",
+		Usage:     "print the logs of container in taskgroup",
+		ArgsUsage: "<taskgroup>",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "container, c",
+				Usage: "Container name, can be empty if there is only one container in taskgroup",
+			},
+			cli.BoolFlag{
+				Name:  "follow, f",
+				Usage: "Specify if the logs should be streamed",
+			},
+			cli.IntFlag{
+				Name:  "tail",
+				Usage: "Lines of recent logs to display, all logs are displayed if not set",
+			},
+			cli.StringFlag{
+				Name:  "clusterid",
+				Usage: "Cluster ID",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return logs(utils.NewClientContext(c))
+		},
+	}
+}
+
+func logs(c *utils.ClientContext) error {
+	if err := c.MustSpecified(utils.OptionClusterID); err != nil {
+		return err
+	}
+
+	taskGroupID := c.Args().First()
+	if taskGroupID == "" {
+		return fmt.Errorf("taskgroup must be specified")
+	}
+
+	scheduler := v4.NewBcsScheduler(utils.GetClientOption())
+	container := c.String(utils.OptionContainer)
+	follow := c.Bool(utils.OptionFollow)
+	tail := c.Int(utils.OptionTail)
+
+	var offset int64
+	for {
+		result, err := scheduler.FetchTaskGroupLogs(c.ClusterID(), taskGroupID, container, offset, tail)
+		if err != nil {
+			return fmt.Errorf("failed to fetch logs: %v", err)
+		}
+		os.Stdout.Write(result.Logs)
+		offset = result.NextOffset
+
+		// tail is only used for the first request
+		if tail > 0 {
+			tail = 0
+			if !follow {
+				return nil
+			}
+			continue
+		}
+		if len(result.Logs) != 0 {
+			continue
+		}
+		if !follow {
+			return nil
+		}
+		time.Sleep(followInterval)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"bk-bcs/bcs-services/bcs-client/cmd/get"
 	"bk-bcs/bcs-services/bcs-client/cmd/inspect"
 	"bk-bcs/bcs-services/bcs-client/cmd/list"
+	"bk-bcs/bcs-services/bcs-client/cmd/logs"
 	"bk-bcs/bcs-services/bcs-client/cmd/offer"
 	"bk-bcs/bcs-services/bcs-client/cmd/template"
 	"bk-bcs/bcs-services/bcs-client/cmd/update"
@@ -56,7 +57,10 @@ func main() {
 		deployment.NewCancelCommand(),
 		deployment.NewPauseCommand(),
 		deployment.NewResumeCommand(),
+		deployment.NewHistoryCommand(),
+		deployment.NewUndoCommand(),
 		application.NewRescheduleCommand(),
+		logs.NewLogsCommand(),
 		env.NewExportCommand(),
 		env.NewEnvCommand(),
 		available.NewEnableCommand(),
@@ -35,4 +35,11 @@ const (
 	OptionString        = "string"
 	OptionScalar        = "scalar"
 	OptionAll           = "all"
+	OptionRevision      = "revision"
+	OptionToRevision    = "to-revision"
+	OptionDiff          = "diff"
+	OptionOperator      = "operator"
+	OptionContainer     = "container"
+	OptionFollow        = "follow"
+	OptionTail          = "tail"
 )
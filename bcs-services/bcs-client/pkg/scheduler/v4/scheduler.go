@@ -18,6 +18,7 @@ import (
 
 	commonTypes "bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto/mesos"
+	deploymentType "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
 	"bk-bcs/bcs-services/bcs-client/pkg/types"
 	"bk-bcs/bcs-services/bcs-client/pkg/utils"
 )
@@ -29,6 +30,7 @@ type Scheduler interface {
 	CreateSecret(clusterID, namespace string, data []byte) error
 	CreateService(clusterID, namespace string, data []byte) error
 	CreateDeployment(clusterID, namespace string, data []byte) error
+	CreateJob(clusterID, namespace string, data []byte) error
 
 	UpdateApplication(clusterID, namespace string, data []byte, extraValue url.Values) error
 	UpdateProcess(clusterID, namespace string, data []byte, extraValue url.Values) error
@@ -43,6 +45,7 @@ type Scheduler interface {
 	DeleteSecret(clusterID, namespace, name string, enforce bool) error
 	DeleteService(clusterID, namespace, name string, enforce bool) error
 	DeleteDeployment(clusterID, namespace, name string, enforce bool) error
+	DeleteJob(clusterID, namespace, name string, enforce bool) error
 
 	ScaleApplication(clusterID, namespace, name string, instance int) error
 	ScaleProcess(clusterID, namespace, name string, instance int) error
@@ -51,10 +54,15 @@ type Scheduler interface {
 	RollBackProcess(clusterID, namespace string, data []byte) error
 
 	RescheduleTaskGroup(clusterID, namespace, applicationName, taskGroupName string) error
+	FetchTaskGroupLogs(clusterID, taskGroupID, container string, offset int64, tail int) (*deploymentType.ResponseLogsTask, error)
 
 	ResumeDeployment(clusterID, namespace, name string) error
 	CancelDeployment(clusterID, namespace, name string) error
 	PauseDeployment(clusterID, namespace, name string) error
+	ListDeploymentRevisions(clusterID, namespace, name string) ([]*deploymentType.DeploymentRevision, error)
+	GetDeploymentRevision(clusterID, namespace, name string, revision int64) (*deploymentType.DeploymentRevision, error)
+	DiffDeploymentRevisions(clusterID, namespace, name string, from, to int64) ([]*deploymentType.DeploymentRevisionDiff, error)
+	RollbackDeployment(clusterID, namespace, name string, data []byte) error
 
 	ListAgentInfo(clusterID string, ipList []string) ([]*commonTypes.BcsClusterAgentInfo, error)
 	ListAgentSetting(clusterID string, ipList []string) ([]*commonTypes.BcsClusterAgentSetting, error)
@@ -81,6 +89,10 @@ const (
 	BcsSchedulerResumeDeploymentURI   = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/resumeupdate"
 	BcsSchedulerCancelDeploymentURI   = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/cancelupdate"
 	BcsSchedulerPauseDeploymentURI    = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/pauseupdate"
+	BcsSchedulerDeployRevisionsURI    = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/revisions"
+	BcsSchedulerDeployRevisionURI     = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/revisions/%d"
+	BcsSchedulerDeployRevisionDiffURI = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/revisiondiff/%d/%d"
+	BcsSchedulerRollbackDeploymentURI = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/deployments/%s/rollback"
 	BcsSchedulerClusterResourceURI    = "%s/bcsapi/v4/scheduler/mesos/cluster/resources"
 	BcsSchedulerAgentSettingURI       = "%s/bcsapi/v4/scheduler/mesos/agentsettings/?ips=%s"
 	BcsSchedulerUpdateAgentSettingURI = "%s/bcsapi/v4/scheduler/mesos/agentsettings/update"
@@ -88,6 +100,7 @@ const (
 	BcsSchedulerEnableAgentURI        = "%s/bcsapi/v4/scheduler/mesos/agentsettings/enable?ips=%s"
 	BcsSchedulerDisableAgentURI       = "%s/bcsapi/v4/scheduler/mesos/agentsettings/disable?ips=%s"
 	BcsSchedulerRescheduleURI         = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/applications/%s/taskgroups/%s/rescheduler"
+	BcsSchedulerTaskGroupLogsURI      = "%s/bcsapi/v4/scheduler/mesos/namespaces/%s/applications/%s/taskgroups/%s/logs?%s"
 	BcsSchedulerOfferURI              = "%s/bcsapi/v4/scheduler/mesos/cluster/current/offers"
 	BcsSchedulerAppDefinitionURI      = "%s/bcsapi/v4/scheduler/mesos/definition/application/%s/%s"
 	BcsSchedulerDeployDefinitionURI   = "%s/bcsapi/v4/scheduler/mesos/definition/deployment/%s/%s"
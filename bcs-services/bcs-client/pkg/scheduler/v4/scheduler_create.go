@@ -42,6 +42,10 @@ func (bs *bcsScheduler) CreateDeployment(clusterID, namespace string, data []byt
 	return bs.createResource(clusterID, namespace, BcsSchedulerResourceDeployment, data)
 }
 
+func (bs *bcsScheduler) CreateJob(clusterID, namespace string, data []byte) error {
+	return bs.createResource(clusterID, namespace, BcsSchedulerResourceJob, data)
+}
+
 func (bs *bcsScheduler) createResource(clusterID, namespace, resourceType string, data []byte) error {
 	resp, err := bs.requester.Do(
 		fmt.Sprintf(BcsSchedulerResourceURI, bs.bcsApiAddress, namespace, resourceType, ""),
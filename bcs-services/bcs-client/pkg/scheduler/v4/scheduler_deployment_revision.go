@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package v4
+
+import (
+	"fmt"
+	"net/http"
+
+	"bk-bcs/bcs-common/common/codec"
+	deploymentType "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+)
+
+func (bs *bcsScheduler) ListDeploymentRevisions(clusterID, namespace, name string) ([]*deploymentType.DeploymentRevision, error) {
+	return bs.listDeploymentRevisions(clusterID, namespace, name)
+}
+
+func (bs *bcsScheduler) GetDeploymentRevision(clusterID, namespace, name string, revision int64) (*deploymentType.DeploymentRevision, error) {
+	return bs.getDeploymentRevision(clusterID, namespace, name, revision)
+}
+
+func (bs *bcsScheduler) DiffDeploymentRevisions(clusterID, namespace, name string, from, to int64) ([]*deploymentType.DeploymentRevisionDiff, error) {
+	return bs.diffDeploymentRevisions(clusterID, namespace, name, from, to)
+}
+
+func (bs *bcsScheduler) RollbackDeployment(clusterID, namespace, name string, data []byte) error {
+	return bs.rollbackDeployment(clusterID, namespace, name, data)
+}
+
+func (bs *bcsScheduler) listDeploymentRevisions(clusterID, namespace, name string) ([]*deploymentType.DeploymentRevision, error) {
+	resp, err := bs.requester.Do(
+		fmt.Sprintf(BcsSchedulerDeployRevisionsURI, bs.bcsApiAddress, namespace, name),
+		http.MethodGet,
+		nil,
+		getClusterIDHeader(clusterID),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code, msg, data, err := parseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != 0 {
+		return nil, fmt.Errorf("list deployment revisions failed: %s", msg)
+	}
+
+	var result []*deploymentType.DeploymentRevision
+	err = codec.DecJson(data, &result)
+	return result, err
+}
+
+func (bs *bcsScheduler) getDeploymentRevision(clusterID, namespace, name string, revision int64) (*deploymentType.DeploymentRevision, error) {
+	resp, err := bs.requester.Do(
+		fmt.Sprintf(BcsSchedulerDeployRevisionURI, bs.bcsApiAddress, namespace, name, revision),
+		http.MethodGet,
+		nil,
+		getClusterIDHeader(clusterID),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code, msg, data, err := parseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != 0 {
+		return nil, fmt.Errorf("get deployment revision failed: %s", msg)
+	}
+
+	var result deploymentType.DeploymentRevision
+	err = codec.DecJson(data, &result)
+	return &result, err
+}
+
+func (bs *bcsScheduler) diffDeploymentRevisions(clusterID, namespace, name string, from, to int64) ([]*deploymentType.DeploymentRevisionDiff, error) {
+	resp, err := bs.requester.Do(
+		fmt.Sprintf(BcsSchedulerDeployRevisionDiffURI, bs.bcsApiAddress, namespace, name, from, to),
+		http.MethodGet,
+		nil,
+		getClusterIDHeader(clusterID),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code, msg, data, err := parseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != 0 {
+		return nil, fmt.Errorf("diff deployment revisions failed: %s", msg)
+	}
+
+	var result []*deploymentType.DeploymentRevisionDiff
+	err = codec.DecJson(data, &result)
+	return result, err
+}
+
+func (bs *bcsScheduler) rollbackDeployment(clusterID, namespace, name string, data []byte) error {
+	resp, err := bs.requester.Do(
+		fmt.Sprintf(BcsSchedulerRollbackDeploymentURI, bs.bcsApiAddress, namespace, name),
+		http.MethodPut,
+		data,
+		getClusterIDHeader(clusterID),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	code, msg, _, err := parseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("rollback deployment failed: %s", msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package v4
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"bk-bcs/bcs-common/common/codec"
+	deploymentType "bk-bcs/bcs-mesos/bcs-scheduler/src/types"
+)
+
+func (bs *bcsScheduler) FetchTaskGroupLogs(clusterID, taskGroupID, container string, offset int64, tail int) (*deploymentType.ResponseLogsTask, error) {
+	return bs.fetchTaskGroupLogs(clusterID, taskGroupID, container, offset, tail)
+}
+
+func (bs *bcsScheduler) fetchTaskGroupLogs(clusterID, taskGroupID, container string, offset int64, tail int) (*deploymentType.ResponseLogsTask, error) {
+	// taskgroup id is $instance.$application.$namespace.$cluster.$timestamp
+	fields := strings.Split(taskGroupID, ".")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid taskgroup id: %s", taskGroupID)
+	}
+
+	query := url.Values{}
+	query.Set("offset", strconv.FormatInt(offset, 10))
+	if container != "" {
+		query.Set("container", container)
+	}
+	if tail > 0 {
+		query.Set("tail", strconv.Itoa(tail))
+	}
+
+	resp, err := bs.requester.Do(
+		fmt.Sprintf(BcsSchedulerTaskGroupLogsURI, bs.bcsApiAddress, fields[2], fields[1], taskGroupID, query.Encode()),
+		http.MethodGet,
+		nil,
+		getClusterIDHeader(clusterID),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code, msg, data, err := parseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != 0 {
+		return nil, fmt.Errorf("fetch taskgroup logs failed: %s", msg)
+	}
+
+	var result deploymentType.ResponseLogsTask
+	err = codec.DecJson(data, &result)
+	return &result, err
+}
@@ -20,4 +20,5 @@ const (
 	BcsSchedulerResourceSecret      = "secrets"
 	BcsSchedulerResourceService     = "services"
 	BcsSchedulerResourceDeployment  = "deployments"
+	BcsSchedulerResourceJob         = "jobs"
 )
@@ -33,6 +33,7 @@ type Storage interface {
 	ListService(clusterID string, condition url.Values) (ServiceList, error)
 	ListEndpoint(clusterID string, condition url.Values) (EndpointList, error)
 	ListDeployment(clusterID string, condition url.Values) (DeploymentList, error)
+	ListJob(clusterID string, condition url.Values) (JobList, error)
 	ListNamespace(clusterID string, condition url.Values) ([]string, error)
 
 	InspectApplication(clusterID, namespace, name string) (*ApplicationSet, error)
@@ -43,6 +44,7 @@ type Storage interface {
 	InspectService(clusterID, namespace, name string) (*ServiceSet, error)
 	InspectEndpoint(clusterID, namespace, name string) (*EndpointSet, error)
 	InspectDeployment(clusterID, namespace, name string) (*DeploymentSet, error)
+	InspectJob(clusterID, namespace, name string) (*JobSet, error)
 }
 
 const (
@@ -150,6 +152,17 @@ func (bs *bcsStorage) ListDeployment(clusterID string, condition url.Values) (De
 	return result, err
 }
 
+func (bs *bcsStorage) ListJob(clusterID string, condition url.Values) (JobList, error) {
+	data, err := bs.listResource(clusterID, BcsStorageDynamicTypeJob, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	var result JobList
+	err = codec.DecJson(data, &result)
+	return result, err
+}
+
 func (bs *bcsStorage) ListNamespace(clusterID string, condition url.Values) ([]string, error) {
 	data, err := bs.listResource(clusterID, BcsStorageDynamicTypeNamespace, condition)
 	if err != nil {
@@ -249,6 +262,17 @@ func (bs *bcsStorage) InspectDeployment(clusterID, namespace, name string) (*Dep
 	return &result, err
 }
 
+func (bs *bcsStorage) InspectJob(clusterID, namespace, name string) (*JobSet, error) {
+	data, err := bs.inspectResource(clusterID, namespace, BcsStorageDynamicTypeJob, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result JobSet
+	err = codec.DecJson(data, &result)
+	return &result, err
+}
+
 func (bs *bcsStorage) listResource(clusterID, resourceType string, condition url.Values) ([]byte, error) {
 	if condition == nil {
 		condition = make(url.Values)
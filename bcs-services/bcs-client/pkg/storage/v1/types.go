@@ -27,6 +27,7 @@ const (
 	BcsStorageDynamicTypeService     = "service"
 	BcsStorageDynamicTypeEndpoint    = "endpoint"
 	BcsStorageDynamicTypeDeployment  = "deployment"
+	BcsStorageDynamicTypeJob         = "job"
 	BcsStorageDynamicTypeNamespace   = "namespace"
 )
 
@@ -62,6 +63,10 @@ type DeploymentSet struct {
 	Data deploymentType.Deployment `json:"data"`
 }
 
+type JobSet struct {
+	Data deploymentType.Job `json:"data"`
+}
+
 type ApplicationList []*ApplicationSet
 type ProcessList []*ProcessSet
 type TaskGroupList []*TaskGroupSet
@@ -70,6 +75,7 @@ type SecretList []*SecretSet
 type ServiceList []*ServiceSet
 type EndpointList []*EndpointSet
 type DeploymentList []*DeploymentSet
+type JobList []*JobSet
 
 // sort by namespace
 func (l ApplicationList) Len() int           { return len(l) }
@@ -98,3 +104,8 @@ func (l DeploymentList) Less(i, j int) bool {
 	return l[i].Data.ObjectMeta.NameSpace > l[j].Data.ObjectMeta.NameSpace
 }
 func (l DeploymentList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l JobList) Len() int             { return len(l) }
+func (l JobList) Less(i, j int) bool {
+	return l[i].Data.ObjectMeta.NameSpace > l[j].Data.ObjectMeta.NameSpace
+}
+func (l JobList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
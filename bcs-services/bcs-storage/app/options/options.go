@@ -50,6 +50,10 @@ type StorageOptions struct {
 	PrintBody    bool   `json:"print_body" value:"false" usage:"Print body every request."`
 	PrintManager bool   `json:"print_manager" value:"false" usage:"Print manager."`
 	DebugMode    bool   `json:"debug_mode" value:"false" usage:"Debug mode, use pprof."`
+
+	HistoryMaxTime    int64  `json:"history_max_hour" value:"1" usage:"Max hour for holding the change history of dynamic resources, watching from the versions before it needs a relist."`
+	SnapshotMaxTime   int64  `json:"snapshot_max_day" value:"0" usage:"Max day for holding snapshots of dynamic resources, 0 for no snapshot."`
+	SnapshotRetention string `json:"snapshot_retention" value:"" usage:"Max day for holding snapshots of each resource type, overrides snapshot_max_day, like Pod=1,Deployment=30."`
 }
 
 //NewStorageOptions create StorageOptions object
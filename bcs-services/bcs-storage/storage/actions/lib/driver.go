@@ -16,6 +16,7 @@ package lib
 import (
 	"bk-bcs/bcs-services/bcs-storage/storage/apiserver"
 	"bk-bcs/bcs-services/bcs-storage/storage/drivers/mongodb"
+	"bk-bcs/bcs-services/bcs-storage/storage/drivers/mysql"
 	"bk-bcs/bcs-services/bcs-storage/storage/drivers/zookeeper"
 	"bk-bcs/bcs-services/bcs-storage/storage/operator"
 )
@@ -26,8 +27,18 @@ func GetZookeeperTank(name string) func() operator.Tank {
 	}
 }
 
+// GetMongodbTank returns the mysql tank instead if mysql/name is configured
 func GetMongodbTank(name string) func() operator.Tank {
 	return func() operator.Tank {
+		if apiserver.GetAPIResource().HasMysqlConfig(name) {
+			return mysql.NewMysqlTank(apiserver.GetAPIResource().GetMysqlTankName(name))
+		}
 		return mongodb.NewMongodbTank(apiserver.GetAPIResource().GetMongodbTankName(name))
 	}
 }
+
+func GetMysqlTank(name string) func() operator.Tank {
+	return func() operator.Tank {
+		return mysql.NewMysqlTank(apiserver.GetAPIResource().GetMysqlTankName(name))
+	}
+}
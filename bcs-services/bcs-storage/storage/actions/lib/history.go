@@ -0,0 +1,504 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-services/bcs-storage/storage/apiserver"
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	// ResourceVersionTag is the key of resource version in documents, history entries and list responses
+	ResourceVersionTag = "resourceVersion"
+
+	historyTable         = "bcs_resource_history"
+	historyTableTag      = "table"
+	historyTypeTag       = "type"
+	historyDataTag       = "data"
+	historyCreateTimeTag = "createTime"
+
+	// the counter of resource versions
+	historyCounterTable   = "bcs_resource_version"
+	historyCounterNameTag = "name"
+
+	// how long the history is kept by default, watching from a compacted version gets ResourceVersionTooOld
+	historyDefaultMaxHours = 1
+	historyCompactGap      = time.Minute
+
+	// the entries of concurrent changes may become visible out of order, a missing version is waited for
+	// until the entry after it has been recorded for historySettleGap, then it is taken as a failed write
+	historySettleGap = 5 * time.Second
+
+	historyPollGap   = time.Second
+	historyBatchSize = 1000
+
+	// the written changes are retried to be recorded, so that watchers do not miss them
+	historyRecordRetry    = 3
+	historyRecordRetryGap = 100 * time.Millisecond
+)
+
+// History records the changes of documents with monotonically increasing resource versions
+// in table bcs_resource_history of the database, so that watchers can resume from a version.
+//
+// A version is allocated by increasing the counter in table bcs_resource_version atomically before the
+// document is written. The write is conditioned on the version of the document read before it, see
+// VersionCondition, and the change is recorded only after the write succeeds. So a stored document always
+// carries the version of its last change, and the changes of a document are recorded in the order of its
+// writes. Watchers only move forward over continuous versions, and skip the missing ones after
+// historySettleGap, which belong to the writes failed or given up.
+type History struct {
+	getNewTank operator.GetNewTank
+}
+
+var (
+	sharedHistories     = make(map[string]*History)
+	sharedHistoriesLock sync.Mutex
+)
+
+// NewHistory create a History keeping changes by the tanks from getNewTank
+func NewHistory(getNewTank operator.GetNewTank) *History {
+	return &History{getNewTank: getNewTank}
+}
+
+// SharedHistory returns the History of database config name, which is created by the first call.
+// The actions of the same database share it, so that only one compaction runs.
+func SharedHistory(name string, getNewTank operator.GetNewTank) *History {
+	sharedHistoriesLock.Lock()
+	defer sharedHistoriesLock.Unlock()
+	if h, ok := sharedHistories[name]; ok {
+		return h
+	}
+	h := NewHistory(getNewTank)
+	sharedHistories[name] = h
+	return h
+}
+
+// StartHistoriesCompaction start the compaction of all shared Histories, which stops when ctx is done
+func StartHistoriesCompaction(ctx context.Context) {
+	sharedHistoriesLock.Lock()
+	defer sharedHistoriesLock.Unlock()
+	for _, h := range sharedHistories {
+		go h.Compact(ctx)
+	}
+}
+
+// CurrentVersion returns the version that a list read after it contains all changes until, then the watch
+// from it gets the later changes. The changes in the last historySettleGap are not counted in, for their
+// documents may be still in writing, so they may be sent again by the watch.
+func (h *History) CurrentVersion() (uint64, error) {
+	tank := h.getNewTank()
+	defer tank.Close()
+
+	t := tank.From(historyTable).OrderBy("-"+ResourceVersionTag).
+		Select(ResourceVersionTag, historyCreateTimeTag).Limit(historyBatchSize).Query()
+	if err := t.GetError(); err != nil {
+		return 0, err
+	}
+	values := t.GetValue()
+	if len(values) == 0 {
+		return 0, nil
+	}
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	first, _ := values[0].(map[string]interface{})
+	return continuousVersion(toUint64(first[ResourceVersionTag])-1, values, time.Now().Add(-historySettleGap), true), nil
+}
+
+// Allocate returns the first of n new continuous versions, which should be set to the documents before writing.
+func (h *History) Allocate(n int) (uint64, error) {
+	tank := h.getNewTank()
+	defer tank.Close()
+
+	counter := tank.From(historyCounterTable).Index(historyCounterNameTag).
+		Filter(operator.NewCondition(operator.Eq, operator.M{historyCounterNameTag: ResourceVersionTag}))
+
+	last, err := increase(counter, int64(n))
+	if err != nil {
+		return 0, err
+	}
+
+	// the counter is just created, skip the versions recorded before it
+	if last == uint64(n) {
+		recorded, err := h.lastVersion(tank)
+		if err != nil {
+			return 0, err
+		}
+		if recorded > 0 {
+			blog.Infof("history | resource version counter starts from %d", recorded)
+			if last, err = increase(counter, int64(recorded)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return last - uint64(n) + 1, nil
+}
+
+func increase(counter operator.Tank, n int64) (uint64, error) {
+	t := counter.Increase(ResourceVersionTag, n)
+	if err := t.GetError(); err != nil {
+		return 0, err
+	}
+	for _, value := range t.GetValue() {
+		if entry, ok := value.(map[string]interface{}); ok {
+			return toUint64(entry[ResourceVersionTag]), nil
+		}
+	}
+	return 0, storageErr.AllocateVersionFailed
+}
+
+// Record the changes of docs in table, which have been written with the versions from Allocate in their
+// resourceVersion. They are inserted in one batch, and retried for historyRecordRetry times on failure.
+func (h *History) Record(table string, eventType operator.EventType, docs ...operator.M) (err error) {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	entries := make([]operator.M, 0, len(docs))
+	for _, doc := range docs {
+		data := CopyMap(doc)
+		delete(data, "_id")
+		entries = append(entries, operator.M{
+			ResourceVersionTag:   data[ResourceVersionTag],
+			historyTableTag:      table,
+			historyTypeTag:       eventType,
+			historyDataTag:       data,
+			historyCreateTimeTag: now,
+		})
+	}
+
+	tank := h.getNewTank()
+	defer tank.Close()
+	for i := 0; i < historyRecordRetry; i++ {
+		if err = tank.From(historyTable).Index(ResourceVersionTag).Insert(entries...).GetError(); err == nil {
+			return nil
+		}
+		blog.Warnf("history | record %d changes of %s failed: %v", len(entries), table, err)
+		time.Sleep(historyRecordRetryGap)
+	}
+	return err
+}
+
+// VersionCondition returns the condition that the documents are still at one of versions, version 0 is for
+// the documents written before resource versions. A write filtered by it fails to match the documents
+// changed after they were read.
+func VersionCondition(versions ...uint64) *operator.Condition {
+	known := make([]interface{}, 0, len(versions))
+	legacy := false
+	for _, version := range versions {
+		if version == 0 {
+			legacy = true
+			continue
+		}
+		known = append(known, version)
+	}
+
+	noVersion := operator.NewCondition(operator.Ext, operator.M{ResourceVersionTag: false})
+	if len(known) == 0 {
+		return noVersion
+	}
+	cond := operator.NewCondition(operator.In, operator.M{ResourceVersionTag: known})
+	if legacy {
+		return cond.Or(noVersion)
+	}
+	return cond
+}
+
+// Watch the changes of table after opts.ResourceVersion by polling the history
+func (h *History) Watch(table string, opts *operator.WatchOptions) (chan *operator.Event, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	event := make(chan *operator.Event, 1000)
+	go h.watching(ctx, table, opts, event)
+	return event, cancel
+}
+
+func (h *History) watching(ctx context.Context, table string, opts *operator.WatchOptions, event chan *operator.Event) {
+	tank := h.getNewTank()
+	defer tank.Close()
+	blog.Infof("history watching | begin to watch %s from %d", table, opts.ResourceVersion)
+	defer func() {
+		event <- operator.EventWatchBreak
+		blog.Infof("history watching | end watch: %s", table)
+	}()
+
+	var diffTree []string
+	if opts.MustDiff != "" {
+		diffTree = strings.Split(opts.MustDiff, ".")
+	}
+
+	var timeout <-chan time.Time
+	var timer *time.Timer
+	if opts.Timeout > 0 {
+		timer = time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	ticker := time.NewTicker(historyPollGap)
+	defer ticker.Stop()
+
+	last := opts.ResourceVersion
+	var eventsNumber uint
+	for {
+		entries, current, err := h.since(tank, table, last)
+		if err == storageErr.ResourceVersionTooOld {
+			blog.Warnf("history watching | %s from %d: %v", table, last, err)
+			event <- &operator.Event{Type: operator.Exp, Value: operator.M{"message": err.Error()}}
+			return
+		}
+		if err != nil {
+			blog.Errorf("history watching | get history of %s failed: %v", table, err)
+		}
+		last = current
+
+		for _, e := range entries {
+			if opts.SelfOnly && e.Type != operator.SChg {
+				continue
+			}
+			if e.Type != operator.Del && !hasPath(e.Value, diffTree) {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case event <- e:
+			}
+			eventsNumber++
+			if opts.MaxEvents > 0 && eventsNumber >= opts.MaxEvents {
+				return
+			}
+
+			// the waiting time is counted from the last event
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(opts.Timeout)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// since returns the events of table after version, and the version that the next query should start from.
+// If the versions after it have been compacted, ResourceVersionTooOld is returned.
+func (h *History) since(tank operator.Tank, table string, version uint64) ([]*operator.Event, uint64, error) {
+	t := tank.From(historyTable).Filter(operator.NewCondition(operator.Gt, operator.M{ResourceVersionTag: version})).
+		OrderBy(ResourceVersionTag).Select(ResourceVersionTag, historyCreateTimeTag).Limit(historyBatchSize).Query()
+	if err := t.GetError(); err != nil {
+		return nil, version, err
+	}
+	values := t.GetValue()
+	if len(values) == 0 {
+		return nil, version, nil
+	}
+
+	first, err := h.firstVersion(tank)
+	if err != nil {
+		return nil, version, err
+	}
+	if version+1 < first {
+		return nil, version, storageErr.ResourceVersionTooOld
+	}
+
+	// the entries until current are all visible
+	current := continuousVersion(version, values, time.Now().Add(-historySettleGap), false)
+	if current == version {
+		return nil, version, nil
+	}
+
+	cond := operator.NewCondition(operator.Eq, operator.M{historyTableTag: table}).
+		AddOp(operator.Gt, ResourceVersionTag, version).
+		AddOp(operator.Lte, ResourceVersionTag, current)
+	t = tank.From(historyTable).Filter(cond).OrderBy(ResourceVersionTag).Query()
+	if err = t.GetError(); err != nil {
+		return nil, version, err
+	}
+
+	events := make([]*operator.Event, 0, t.GetLen())
+	for _, value := range t.GetValue() {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		events = append(events, historyEvent(entry))
+	}
+	return events, current, nil
+}
+
+// Previous returns the last change before version of the document in table with the identity, nil if there is none
+func (h *History) Previous(table string, identity operator.M, version uint64) (*operator.Event, error) {
+	tank := h.getNewTank()
+	defer tank.Close()
+
+	feat := operator.M{historyTableTag: table}
+	for key, value := range identity {
+		feat[historyDataTag+"."+key] = value
+	}
+	cond := operator.NewCondition(operator.Eq, feat).AddOp(operator.Lt, ResourceVersionTag, version)
+	t := tank.From(historyTable).Filter(cond).OrderBy("-" + ResourceVersionTag).Limit(1).Query()
+	if err := t.GetError(); err != nil {
+		return nil, err
+	}
+	for _, value := range t.GetValue() {
+		if entry, ok := value.(map[string]interface{}); ok {
+			return historyEvent(entry), nil
+		}
+	}
+	return nil, nil
+}
+
+// continuousVersion returns the last version of entries in order after from, until a version is missing and
+// the entry after it is recorded after deadline. If mustSettle is set, it also stops at the entry recorded
+// after deadline.
+func continuousVersion(from uint64, entries []interface{}, deadline time.Time, mustSettle bool) uint64 {
+	current := from
+	for _, value := range entries {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version := toUint64(entry[ResourceVersionTag])
+		settled := snapshotTime(entry[historyCreateTimeTag]).Before(deadline)
+		if (mustSettle || version > current+1) && !settled {
+			break
+		}
+		current = version
+	}
+	return current
+}
+
+func (h *History) lastVersion(tank operator.Tank) (uint64, error) {
+	return h.edgeVersion(tank, "-"+ResourceVersionTag)
+}
+
+func (h *History) firstVersion(tank operator.Tank) (uint64, error) {
+	return h.edgeVersion(tank, ResourceVersionTag)
+}
+
+func (h *History) edgeVersion(tank operator.Tank, order string) (uint64, error) {
+	t := tank.From(historyTable).OrderBy(order).Select(ResourceVersionTag).Limit(1).Query()
+	if err := t.GetError(); err != nil {
+		return 0, err
+	}
+	for _, value := range t.GetValue() {
+		if entry, ok := value.(map[string]interface{}); ok {
+			return toUint64(entry[ResourceVersionTag]), nil
+		}
+	}
+	return 0, nil
+}
+
+// Compact remove the history out of retention periodically until ctx is done, the last one is always kept.
+func (h *History) Compact(ctx context.Context) {
+	ticker := time.NewTicker(historyCompactGap)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := h.compact(); err != nil {
+			blog.Errorf("history | compact failed: %v", err)
+		}
+	}
+}
+
+func (h *History) compact() error {
+	tank := h.getNewTank()
+	defer tank.Close()
+
+	last, err := h.lastVersion(tank)
+	if err != nil || last == 0 {
+		return err
+	}
+	cond := operator.BaseCondition.
+		AddOp(operator.Lt, ResourceVersionTag, last).
+		AddOp(operator.Lt, historyCreateTimeTag, time.Now().Add(-historyRetention()))
+	return tank.From(historyTable).Filter(cond).RemoveAll().GetError()
+}
+
+// historyRetention returns how long the history is kept in config
+func historyRetention() time.Duration {
+	hours := int64(historyDefaultMaxHours)
+	if conf := apiserver.GetAPIResource().Conf; conf != nil && conf.HistoryMaxTime > 0 {
+		hours = conf.HistoryMaxTime
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func historyEvent(entry map[string]interface{}) *operator.Event {
+	e := &operator.Event{
+		Type:            operator.EventType(toUint64(entry[historyTypeTag])),
+		ResourceVersion: toUint64(entry[ResourceVersionTag]),
+	}
+	if data, ok := entry[historyDataTag].(map[string]interface{}); ok {
+		e.Value = data
+	}
+	return e
+}
+
+// hasPath checks whether the keys of path exist in value
+func hasPath(value operator.M, path []string) bool {
+	var d interface{} = map[string]interface{}(value)
+	for _, key := range path {
+		md, ok := d.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if d = md[key]; d == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ResourceVersionOf returns the resource version of the document or event value, 0 if not set
+func ResourceVersionOf(value map[string]interface{}) uint64 {
+	return toUint64(value[ResourceVersionTag])
+}
+
+// toUint64 convert the numbers decoded by drivers into uint64
+func toUint64(v interface{}) uint64 {
+	switch n := v.(type) {
+	case int:
+		return uint64(n)
+	case int32:
+		return uint64(n)
+	case int64:
+		return uint64(n)
+	case uint64:
+		return n
+	case float64:
+		return uint64(n)
+	}
+	return 0
+}
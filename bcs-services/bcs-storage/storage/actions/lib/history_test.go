@@ -0,0 +1,175 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+func TestHistoryRecord(t *testing.T) {
+	mt := &operator.MockTank{Value: []interface{}{map[string]interface{}{ResourceVersionTag: float64(10)}}}
+	h := NewHistory(operator.GetMockTankNewFunc(mt))
+
+	version, err := h.CurrentVersion()
+	if err != nil || version != 10 {
+		t.Errorf("CurrentVersion() failed! \nresult:\n%d\nexpect:\n10\nerr:\n%v\n", version, err)
+	}
+
+	version, err = h.Allocate(1)
+	if err != nil || version != 11 {
+		t.Errorf("Allocate() failed! \nresult:\n%d\nexpect:\n11\nerr:\n%v\n", version, err)
+	}
+	version, err = h.Allocate(3)
+	if err != nil || version != 12 {
+		t.Errorf("Allocate() of 3 failed! \nresult:\n%d\nexpect:\n12\nerr:\n%v\n", version, err)
+	}
+
+	doc := operator.M{"_id": "id", "resourceName": "foo", ResourceVersionTag: version}
+	if err = h.Record("cluster_Pod", operator.Add, doc); err != nil {
+		t.Errorf("Record() failed! \nerr:\n%v\n", err)
+	}
+	if !reflect.DeepEqual(doc, operator.M{"_id": "id", "resourceName": "foo", ResourceVersionTag: version}) {
+		t.Errorf("Record() should not change the provided doc: %v", doc)
+	}
+
+	mt.Err = storageErr.UnknownOperationType
+	if err = h.Record("cluster_Pod", operator.Add, doc); err != storageErr.UnknownOperationType {
+		t.Errorf("Record() failed! \nerr:\n%v\nexpect:\n%v\n", err, storageErr.UnknownOperationType)
+	}
+	if _, err = h.Allocate(1); err != storageErr.UnknownOperationType {
+		t.Errorf("Allocate() failed! \nerr:\n%v\nexpect:\n%v\n", err, storageErr.UnknownOperationType)
+	}
+}
+
+func TestHistoryCompact(t *testing.T) {
+	h := NewHistory(operator.GetMockTankNewFunc(&operator.MockTank{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Compact(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Compact() should stop when the context is done")
+	}
+}
+
+func TestVersionCondition(t *testing.T) {
+	cases := []struct {
+		versions []uint64
+		expect   interface{}
+	}{
+		{[]uint64{1, 2}, operator.M{"in": operator.M{ResourceVersionTag: []interface{}{uint64(1), uint64(2)}}}},
+		{[]uint64{0}, operator.M{"exists": operator.M{ResourceVersionTag: false}}},
+		{[]uint64{0, 3}, operator.M{"or": []interface{}{
+			operator.M{"in": operator.M{ResourceVersionTag: []interface{}{uint64(3)}}},
+			operator.M{"exists": operator.M{ResourceVersionTag: false}},
+		}}},
+	}
+	for i, c := range cases {
+		if r := operator.MockCombineCondition(VersionCondition(c.versions...)); !reflect.DeepEqual(r, c.expect) {
+			t.Errorf("VersionCondition() case %d failed! \nresult:\n%v\nexpect:\n%v\n", i, r, c.expect)
+		}
+	}
+}
+
+func TestHistorySince(t *testing.T) {
+	entry := map[string]interface{}{
+		ResourceVersionTag: float64(10),
+		historyTableTag:    "cluster_Pod",
+		historyTypeTag:     float64(operator.Chg),
+		historyDataTag:     map[string]interface{}{"resourceName": "foo", ResourceVersionTag: float64(10)},
+	}
+	mt := &operator.MockTank{Value: []interface{}{entry}}
+	h := NewHistory(operator.GetMockTankNewFunc(mt))
+
+	if _, _, err := h.since(mt, "cluster_Pod", 5); err != storageErr.ResourceVersionTooOld {
+		t.Errorf("since() compacted version failed! \nerr:\n%v\nexpect:\n%v\n", err, storageErr.ResourceVersionTooOld)
+	}
+
+	events, current, err := h.since(mt, "cluster_Pod", 9)
+	expect := []*operator.Event{{
+		Type:            operator.Chg,
+		Value:           operator.M{"resourceName": "foo", ResourceVersionTag: float64(10)},
+		ResourceVersion: 10,
+	}}
+	if err != nil || current != 10 || !reflect.DeepEqual(events, expect) {
+		t.Errorf("since() failed! \nresult:\n%v %d\nexpect:\n%v 10\nerr:\n%v\n", events, current, expect, err)
+	}
+
+	if events, current, err = h.since(mt, "cluster_Pod", 10); err != nil || current != 10 || len(events) != 0 {
+		t.Errorf("since() latest version failed! \nresult:\n%v %d\nerr:\n%v\n", events, current, err)
+	}
+}
+
+func TestContinuousVersion(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(-historySettleGap)
+	old := deadline.Add(-time.Second)
+	entries := func(versions ...uint64) []interface{} {
+		r := make([]interface{}, 0, len(versions))
+		for _, v := range versions {
+			created := now
+			if v < 10 {
+				created = old
+			}
+			r = append(r, map[string]interface{}{ResourceVersionTag: float64(v), historyCreateTimeTag: created})
+		}
+		return r
+	}
+
+	cases := []struct {
+		from       uint64
+		entries    []interface{}
+		mustSettle bool
+		expect     uint64
+	}{
+		{from: 1, entries: entries(2, 3, 10, 11), expect: 3},
+		{from: 1, entries: entries(2, 3, 4, 10, 11), mustSettle: true, expect: 4},
+		{from: 10, entries: entries(11, 12), expect: 12},
+		// the missing version 10 is still in writing
+		{from: 9, entries: entries(11, 12), expect: 9},
+		// the missing version 3 is taken as failed since version 4 has been settled
+		{from: 1, entries: entries(2, 4, 5), expect: 5},
+		{from: 5, entries: nil, expect: 5},
+	}
+	for i, c := range cases {
+		if r := continuousVersion(c.from, c.entries, deadline, c.mustSettle); r != c.expect {
+			t.Errorf("continuousVersion() case %d failed! \nresult:\n%d\nexpect:\n%d\n", i, r, c.expect)
+		}
+	}
+}
+
+func TestHasPath(t *testing.T) {
+	value := operator.M{"data": map[string]interface{}{"status": map[string]interface{}{"phase": "Running"}}}
+	if !hasPath(value, []string{"data", "status", "phase"}) {
+		t.Errorf("hasPath() failed! data.status.phase should exist in %v", value)
+	}
+	if hasPath(value, []string{"data", "spec"}) {
+		t.Errorf("hasPath() failed! data.spec should not exist in %v", value)
+	}
+	if !hasPath(value, nil) {
+		t.Errorf("hasPath() failed! empty path should exist")
+	}
+}
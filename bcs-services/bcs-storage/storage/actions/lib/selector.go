@@ -0,0 +1,339 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	LabelSelectorTag = "labelSelector"
+	FieldSelectorTag = "fieldSelector"
+
+	// labels of both mesos and k8s dynamic resources are kept in data.metadata.labels
+	labelsKey = "data.metadata.labels"
+
+	// "." in keys of labels is stored as its unicode, the same as the keys in extra
+	keyDotReplacement = "\uff0e"
+)
+
+type SelectorOp string
+
+const (
+	SelectorEq        SelectorOp = "="
+	SelectorNe        SelectorOp = "!="
+	SelectorIn        SelectorOp = "in"
+	SelectorNotIn     SelectorOp = "notin"
+	SelectorExists    SelectorOp = "exists"
+	SelectorNotExists SelectorOp = "!"
+)
+
+// Requirement is one of the comma-separated requirements of selector
+type Requirement struct {
+	Key    string
+	Op     SelectorOp
+	Values []string
+}
+
+var (
+	selectorKeyRegexp = regexp.MustCompile(`^[^\s!=(),]+$`)
+	selectorSetRegexp = regexp.MustCompile(`^([^\s!=(),]+)\s+(in|notin)\s*\(([^()]*)\)$`)
+)
+
+// ParseSelector parse the kubernetes-style selector like "app=foo,tier!=cache,env in (prod,test),!canary",
+// the operators are "=", "==", "!=", "in", "notin", exists("key") and not exists("!key").
+func ParseSelector(raw string) ([]*Requirement, error) {
+	r := make([]*Requirement, 0)
+	for _, item := range splitSelector(raw) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		requirement, err := parseRequirement(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", raw, err)
+		}
+		r = append(r, requirement)
+	}
+	return r, nil
+}
+
+// splitSelector split the selector by the commas out of parentheses
+func splitSelector(raw string) []string {
+	r := make([]string, 0)
+	depth, begin := 0, 0
+	for i, c := range raw {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				r = append(r, raw[begin:i])
+				begin = i + 1
+			}
+		}
+	}
+	return append(r, raw[begin:])
+}
+
+func parseRequirement(item string) (*Requirement, error) {
+	if m := selectorSetRegexp.FindStringSubmatch(item); m != nil {
+		values := make([]string, 0)
+		for _, value := range strings.Split(m[3], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("empty values of %s", m[1])
+		}
+		return &Requirement{Key: m[1], Op: SelectorOp(m[2]), Values: values}, nil
+	}
+
+	requirement := &Requirement{Key: item, Op: SelectorExists}
+	if strings.HasPrefix(item, "!") {
+		requirement = &Requirement{Key: strings.TrimSpace(item[1:]), Op: SelectorNotExists}
+	} else {
+		for _, op := range []string{"!=", "==", "="} {
+			if i := strings.Index(item, op); i >= 0 {
+				requirement.Key = strings.TrimSpace(item[:i])
+				requirement.Op = SelectorEq
+				if op == "!=" {
+					requirement.Op = SelectorNe
+				}
+				requirement.Values = []string{strings.TrimSpace(item[i+len(op):])}
+				break
+			}
+		}
+	}
+	if !selectorKeyRegexp.MatchString(requirement.Key) {
+		return nil, fmt.Errorf("invalid key %q", requirement.Key)
+	}
+	for _, value := range requirement.Values {
+		if strings.ContainsAny(value, "!=(), \t") {
+			return nil, fmt.Errorf("invalid value %q", value)
+		}
+	}
+	return requirement, nil
+}
+
+// SelectorCondition compile the label selector and field selector into condition, nil if both of them are empty.
+// The keys of label selector are the labels of resources, and the keys of field selector are the keys
+// of documents like "namespace" or "data.status".
+func SelectorCondition(labelSelector, fieldSelector string) (*operator.Condition, error) {
+	labels, err := ParseSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := ParseSelector(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 && len(fields) == 0 {
+		return nil, nil
+	}
+
+	r := operator.BaseCondition
+	for _, requirement := range labels {
+		r = requirement.addTo(r, labelsKey+"."+strings.Replace(requirement.Key, ".", keyDotReplacement, -1))
+	}
+	for _, requirement := range fields {
+		r = requirement.addTo(r, requirement.Key)
+	}
+	return r, nil
+}
+
+// addTo add the requirement to the condition chain, != and notin match the documents without the key,
+// the same as kubernetes
+func (req *Requirement) addTo(cond *operator.Condition, key string) *operator.Condition {
+	switch req.Op {
+	case SelectorEq:
+		return cond.AddOp(operator.Eq, key, req.Values[0])
+	case SelectorNe:
+		return cond.AddOp(operator.Ne, key, req.Values[0])
+	case SelectorIn:
+		return cond.AddOp(operator.In, key, req.Values)
+	case SelectorNotIn:
+		return cond.AddOp(operator.Nin, key, req.Values)
+	case SelectorNotExists:
+		return cond.AddOp(operator.Ext, key, false)
+	}
+	return cond.AddOp(operator.Ext, key, true)
+}
+
+// MatchCondition check if the document matches the condition in memory, for the cases that
+// database can not filter, like watching. Contain and comparisons are done to strings and numbers only.
+func MatchCondition(cond *operator.Condition, doc map[string]interface{}) bool {
+	r := cond.Combine(
+		func(c *operator.Condition) interface{} {
+			return matchLeaf(c, doc)
+		},
+		func(t operator.ConditionType, condList []interface{}) interface{} {
+			switch t {
+			case operator.Not:
+				matched, _ := condList[0].(bool)
+				return !matched
+			case operator.Or:
+				for _, c := range condList {
+					if matched, _ := c.(bool); matched {
+						return true
+					}
+				}
+				return false
+			}
+			for _, c := range condList {
+				if matched, _ := c.(bool); !matched {
+					return false
+				}
+			}
+			return true
+		},
+	)
+	matched, _ := r.(bool)
+	return matched
+}
+
+func matchLeaf(c *operator.Condition, doc map[string]interface{}) bool {
+	if c.Type == operator.Tr {
+		return true
+	}
+	values, _ := c.Value.(operator.M)
+	for key, expect := range values {
+		value, exist := getDocPath(doc, key)
+		var matched bool
+		switch c.Type {
+		case operator.Eq:
+			matched = equalValue(value, expect)
+		case operator.Ne:
+			matched = !equalValue(value, expect)
+		case operator.In, operator.Nin:
+			for _, item := range toSlice(expect) {
+				if equalValue(value, item) {
+					matched = true
+					break
+				}
+			}
+			matched = matched == (c.Type == operator.In)
+		case operator.Ext:
+			should, _ := expect.(bool)
+			matched = exist == should
+		case operator.Con:
+			s, ok1 := value.(string)
+			sub, ok2 := expect.(string)
+			matched = ok1 && ok2 && strings.Contains(s, sub)
+		case operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+			matched = compareMatch(c.Type, value, expect)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// getDocPath returns the value of dot-separated key in document, the unicode of "." in key matches "."
+func getDocPath(doc map[string]interface{}, key string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, segment := range strings.Split(key, ".") {
+		var m map[string]interface{}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			m = v
+		case operator.M:
+			m = v
+		default:
+			return nil, false
+		}
+		var ok bool
+		if current, ok = m[strings.Replace(segment, keyDotReplacement, ".", -1)]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func equalValue(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compareMatch(t operator.ConditionType, value, expect interface{}) bool {
+	var cmp int
+	if fa, ok := toFloat(value); ok {
+		fb, ok := toFloat(expect)
+		if !ok {
+			return false
+		}
+		switch {
+		case fa < fb:
+			cmp = -1
+		case fa > fb:
+			cmp = 1
+		}
+	} else {
+		sa, ok1 := value.(string)
+		sb, ok2 := expect.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		cmp = strings.Compare(sa, sb)
+	}
+	switch t {
+	case operator.Lt:
+		return cmp < 0
+	case operator.Lte:
+		return cmp <= 0
+	case operator.Gt:
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// toSlice returns the elements of slice value for In and Nin conditions
+func toSlice(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	r := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		r = append(r, rv.Index(i).Interface())
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"reflect"
+	"testing"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+func TestParseSelector(t *testing.T) {
+	expect := []*Requirement{
+		{Key: "app", Op: SelectorEq, Values: []string{"foo"}},
+		{Key: "tier", Op: SelectorNe, Values: []string{"cache"}},
+		{Key: "env", Op: SelectorIn, Values: []string{"prod", "test"}},
+		{Key: "zone", Op: SelectorNotIn, Values: []string{"a"}},
+		{Key: "app.kubernetes.io/name", Op: SelectorExists},
+		{Key: "canary", Op: SelectorNotExists},
+		{Key: "version", Op: SelectorEq, Values: []string{""}},
+	}
+	r, err := ParseSelector(" app==foo, tier != cache,env in (prod, test),zone notin(a),app.kubernetes.io/name,!canary,version=")
+	if err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("ParseSelector() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+
+	if r, err := ParseSelector(""); err != nil || len(r) != 0 {
+		t.Errorf("ParseSelector() of empty failed! \nresult:\n%v\nerr:\n%v\n", r, err)
+	}
+	for _, raw := range []string{"app=a=b", "env in ()", "env in (a", "=foo", "a b", "!"} {
+		if _, err := ParseSelector(raw); err == nil {
+			t.Errorf("ParseSelector(%s) should fail", raw)
+		}
+	}
+}
+
+func TestSelectorCondition(t *testing.T) {
+	expect := operator.M{"and": []interface{}{
+		operator.M{"data.metadata.labels.app\uff0ekubernetes\uff0eio/name": "foo"},
+		operator.M{"nin": operator.M{"data.metadata.labels.tier": []string{"cache", "db"}}},
+		operator.M{"exists": operator.M{"data.metadata.labels.canary": false}},
+		operator.M{"ne": operator.M{"namespace": "kube-system"}},
+	}}
+	r, err := SelectorCondition("app.kubernetes.io/name=foo,tier notin (cache,db),!canary", "namespace!=kube-system")
+	if err != nil {
+		t.Fatalf("SelectorCondition() failed! err: %v", err)
+	}
+	if c := operator.MockCombineCondition(r); !reflect.DeepEqual(c, expect) {
+		t.Errorf("SelectorCondition() failed! \nresult:\n%v\nexpect:\n%v\n", c, expect)
+	}
+
+	if r, err := SelectorCondition("", " "); err != nil || r != nil {
+		t.Errorf("SelectorCondition() of empty should be nil, got %v %v", r, err)
+	}
+	if _, err := SelectorCondition("app in (", ""); err == nil {
+		t.Errorf("SelectorCondition() of invalid selector should fail")
+	}
+}
+
+func TestMatchCondition(t *testing.T) {
+	doc := map[string]interface{}{
+		"namespace": "default",
+		"data": map[string]interface{}{
+			"replicas": float64(3),
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"app.kubernetes.io/name": "foo", "tier": "web"},
+			},
+		},
+	}
+	cases := []struct {
+		label  string
+		field  string
+		expect bool
+	}{
+		{"app.kubernetes.io/name=foo", "", true},
+		{"app.kubernetes.io/name=bar", "", false},
+		{"tier!=cache,!canary", "namespace=default", true},
+		{"tier in (web,cache)", "namespace notin (kube-system)", true},
+		{"tier notin (web)", "", false},
+		{"canary", "", false},
+		{"", "data.replicas", true},
+		{"", "namespace!=default", false},
+	}
+	for _, c := range cases {
+		cond, err := SelectorCondition(c.label, c.field)
+		if err != nil {
+			t.Fatalf("SelectorCondition(%s, %s) failed! err: %v", c.label, c.field, err)
+		}
+		if r := MatchCondition(cond, doc); r != c.expect {
+			t.Errorf("MatchCondition(%s, %s) expect %v, got %v", c.label, c.field, c.expect, r)
+		}
+	}
+
+	cond := operator.BaseCondition.AddOp(operator.Gte, "data.replicas", 3).
+		Or(operator.BaseCondition.AddOp(operator.Con, "namespace", "kube")).Not()
+	if MatchCondition(cond, doc) {
+		t.Errorf("MatchCondition() of not/or condition failed")
+	}
+	if !MatchCondition(operator.BaseCondition.AddOp(operator.Lt, "data.replicas", 4), doc) {
+		t.Errorf("MatchCondition() of lt condition failed")
+	}
+}
+
+func TestWatchServerMatch(t *testing.T) {
+	cond, _ := SelectorCondition("app=foo", "")
+	ws := (&watchServer{}).WithCondition(cond)
+	labels := func(app string) operator.M {
+		return operator.M{"_id": "1", "data": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": app}},
+		}}
+	}
+	cases := []struct {
+		event  *operator.Event
+		expect bool
+	}{
+		{&operator.Event{Type: operator.Add, Value: labels("foo")}, true},
+		{&operator.Event{Type: operator.Chg, Value: labels("bar")}, false},
+		{&operator.Event{Type: operator.Del, Value: labels("bar")}, false},
+		{&operator.Event{Type: operator.Del, Value: operator.M{"_id": "1"}}, true},
+		{operator.EventWatchBreak, true},
+		{&operator.Event{Type: operator.Exp, Value: operator.M{"message": "too old"}}, true},
+	}
+	for i, c := range cases {
+		if r := ws.match(c.event); r != c.expect {
+			t.Errorf("match() of event %d expect %v, got %v", i, c.expect, r)
+		}
+	}
+}
@@ -0,0 +1,480 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-services/bcs-storage/storage/apiserver"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	snapshotTable       = "bcs_resource_snapshot"
+	snapshotTableTag    = "table"
+	snapshotTypeTag     = "type"
+	snapshotDataTag     = "data"
+	snapshotTimeTag     = "createTime"
+	snapshotResourceTag = "resourceType"
+
+	// the max changes returned by Timeline
+	snapshotTimelineMaxLimit = 1000
+
+	snapshotCompactGap = time.Hour
+)
+
+// Snapshots keeps every version of the dynamic resources in an append-only table, so that the resources
+// can be queried as they were at a point in time.
+//
+// Each snapshot is inserted once with the resource version of the change, and it is in effect from its
+// createTime until the snapshot of the same resource with a greater version. So the resource at a time
+// is the snapshot with the greatest version created before it, and concurrent changes never conflict.
+// A resource is identified by the keys in the table. Snapshots are only kept for the resource types with
+// a retention in config.
+type Snapshots struct {
+	getNewTank operator.GetNewTank
+	keys       []string
+}
+
+var (
+	sharedSnapshots     = make(map[string]*Snapshots)
+	sharedSnapshotsLock sync.Mutex
+)
+
+// NewSnapshots create Snapshots of the resources identified by keys
+func NewSnapshots(getNewTank operator.GetNewTank, keys ...string) *Snapshots {
+	return &Snapshots{getNewTank: getNewTank, keys: keys}
+}
+
+// SharedSnapshots returns the Snapshots of database config name, which is created by the first call.
+// The actions of the same database share it, so that only one compaction runs.
+func SharedSnapshots(name string, getNewTank operator.GetNewTank, keys ...string) *Snapshots {
+	sharedSnapshotsLock.Lock()
+	defer sharedSnapshotsLock.Unlock()
+	if s, ok := sharedSnapshots[name]; ok {
+		return s
+	}
+	s := NewSnapshots(getNewTank, keys...)
+	sharedSnapshots[name] = s
+	return s
+}
+
+// StartSnapshotsCompaction start the compaction of all shared Snapshots, which stops when ctx is done
+func StartSnapshotsCompaction(ctx context.Context) {
+	sharedSnapshotsLock.Lock()
+	defer sharedSnapshotsLock.Unlock()
+	for _, s := range sharedSnapshots {
+		go s.Compact(ctx)
+	}
+}
+
+// Record append the snapshots of docs in table at t in one batch, the resource versions of docs should be set.
+func (s *Snapshots) Record(table string, eventType operator.EventType, t time.Time, docs ...operator.M) error {
+	entries := make([]operator.M, 0, len(docs))
+	for _, doc := range docs {
+		if !snapshotEnabled(doc[snapshotResourceTag]) {
+			continue
+		}
+		data := CopyMap(doc)
+		delete(data, "_id")
+		entries = append(entries, operator.M{
+			snapshotTableTag:    table,
+			snapshotResourceTag: data[snapshotResourceTag],
+			snapshotTypeTag:     eventType,
+			ResourceVersionTag:  data[ResourceVersionTag],
+			snapshotTimeTag:     t,
+			snapshotDataTag:     data,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tank := s.getNewTank()
+	defer tank.Close()
+	return tank.From(snapshotTable).Index(ResourceVersionTag).Insert(entries...).GetError()
+}
+
+// At returns the resources of table matching condition as they were at t. Condition, offset, limit and
+// selector work the same as querying the table.
+func (s *Snapshots) At(table string, condition *operator.Condition, t time.Time, offset, limit int, selector []string) ([]interface{}, error) {
+	tank := s.getNewTank()
+	defer tank.Close()
+
+	versions, err := s.latest(tank, operator.NewCondition(operator.Eq, operator.M{snapshotTableTag: table}).
+		AddOp(operator.Lte, snapshotTimeTag, t))
+	if err != nil || len(versions) == 0 {
+		return []interface{}{}, err
+	}
+
+	cond := operator.NewCondition(operator.In, operator.M{ResourceVersionTag: versions}).
+		AddOp(operator.Ne, snapshotTypeTag, operator.Del).
+		And(dataCondition(condition))
+
+	fields := make([]string, 0, len(selector))
+	for _, key := range selector {
+		if key != "" {
+			fields = append(fields, snapshotDataTag+"."+key)
+		}
+	}
+	if len(fields) == 0 {
+		fields = append(fields, snapshotDataTag)
+	}
+
+	q := tank.From(snapshotTable).Filter(cond).OrderBy(snapshotTimeTag).
+		Offset(offset).Limit(limit).Select(fields...).Query()
+	if err = q.GetError(); err != nil {
+		return nil, err
+	}
+
+	r := make([]interface{}, 0, q.GetLen())
+	for _, value := range q.GetValue() {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if data, ok := entry[snapshotDataTag].(map[string]interface{}); ok {
+			r = append(r, data)
+		}
+	}
+	return r, nil
+}
+
+// latest returns the versions of the last snapshots of the resources in the snapshots matching cond
+func (s *Snapshots) latest(tank operator.Tank, cond *operator.Condition) ([]interface{}, error) {
+	groups := make([]string, 0, len(s.keys)+1)
+	groups = append(groups, snapshotTableTag)
+	for _, key := range s.keys {
+		groups = append(groups, snapshotDataTag+"."+key)
+	}
+
+	t := tank.From(snapshotTable).Filter(cond).GroupBy(groups...).
+		Aggregate(operator.NewAggregation(ResourceVersionTag, operator.AggMax, ResourceVersionTag))
+	if err := t.GetError(); err != nil {
+		return nil, err
+	}
+	versions := make([]interface{}, 0, t.GetLen())
+	for _, value := range t.GetValue() {
+		if entry, ok := value.(map[string]interface{}); ok {
+			versions = append(versions, toUint64(entry[ResourceVersionTag]))
+		}
+	}
+	return versions, nil
+}
+
+// SnapshotChange is a change in the timeline of a resource
+type SnapshotChange struct {
+	Time            interface{}            `json:"time"`
+	Type            string                 `json:"type"`
+	ResourceVersion uint64                 `json:"resourceVersion"`
+	Diff            []DiffItem             `json:"diff"`
+	Data            map[string]interface{} `json:"data,omitempty"`
+}
+
+// Timeline returns the changes of the resource identified by features in [from, to], with the diffs of
+// "data" between each change and the previous one. Zero from or to means no limit, and at most limit
+// changes are returned, which is no more than snapshotTimelineMaxLimit.
+func (s *Snapshots) Timeline(table string, features operator.M, from, to time.Time, limit int, withData bool) ([]*SnapshotChange, error) {
+	tank := s.getNewTank()
+	defer tank.Close()
+
+	identity := operator.NewCondition(operator.Eq, dataFeatures(table, features))
+
+	// the last change before from is only for the diff of the first one
+	var last map[string]interface{}
+	if !from.IsZero() {
+		q := tank.From(snapshotTable).Filter(identity.AddOp(operator.Lt, snapshotTimeTag, from)).
+			OrderBy("-" + ResourceVersionTag).Limit(1).Query()
+		if err := q.GetError(); err != nil {
+			return nil, err
+		}
+		for _, value := range q.GetValue() {
+			if entry, ok := value.(map[string]interface{}); ok {
+				_, last = snapshotEntry(entry)
+			}
+		}
+	}
+
+	cond := identity
+	if !from.IsZero() {
+		cond = cond.AddOp(operator.Gte, snapshotTimeTag, from)
+	}
+	if !to.IsZero() {
+		cond = cond.AddOp(operator.Lte, snapshotTimeTag, to)
+	}
+	if limit <= 0 || limit > snapshotTimelineMaxLimit {
+		limit = snapshotTimelineMaxLimit
+	}
+	q := tank.From(snapshotTable).Filter(cond).OrderBy(ResourceVersionTag).Limit(limit).Query()
+	if err := q.GetError(); err != nil {
+		return nil, err
+	}
+	return timeline(last, q.GetValue(), withData), nil
+}
+
+// timeline returns the changes of snapshot entries in order, last is the data before them
+func timeline(last map[string]interface{}, entries []interface{}, withData bool) []*SnapshotChange {
+	r := make([]*SnapshotChange, 0, len(entries))
+	for _, value := range entries {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		doc, current := snapshotEntry(entry)
+		eventType := operator.EventType(toUint64(entry[snapshotTypeTag]))
+		change := &SnapshotChange{
+			Time:            entry[snapshotTimeTag],
+			Type:            eventType.String(),
+			ResourceVersion: toUint64(entry[ResourceVersionTag]),
+			Diff:            Diff(last, current),
+		}
+		last = current
+
+		if withData {
+			change.Data = doc
+		}
+		r = append(r, change)
+	}
+	return r
+}
+
+// snapshotEntry returns the document in snapshot entry, and the data of resource which is nil for deletion
+func snapshotEntry(entry map[string]interface{}) (doc, data map[string]interface{}) {
+	doc, _ = entry[snapshotDataTag].(map[string]interface{})
+	if operator.EventType(toUint64(entry[snapshotTypeTag])) != operator.Del {
+		data, _ = doc[snapshotDataTag].(map[string]interface{})
+	}
+	return
+}
+
+// Compact remove the snapshots out of retention periodically until ctx is done, a snapshot is out of
+// retention when it has been superseded before the deadline, or it is a deletion created before the deadline.
+func (s *Snapshots) Compact(ctx context.Context) {
+	ticker := time.NewTicker(snapshotCompactGap)
+	defer ticker.Stop()
+	for {
+		s.compact()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Snapshots) compact() {
+	retention, defaultDays := snapshotRetention()
+	types := make([]string, 0, len(retention))
+	for resourceType, days := range retention {
+		types = append(types, resourceType)
+		s.compactBefore(operator.NewCondition(operator.Eq, operator.M{snapshotResourceTag: resourceType}), days)
+	}
+	if defaultDays > 0 {
+		s.compactBefore(operator.NewCondition(operator.Nin, operator.M{snapshotResourceTag: types}), defaultDays)
+	}
+}
+
+func (s *Snapshots) compactBefore(cond *operator.Condition, days int64) {
+	tank := s.getNewTank()
+	defer tank.Close()
+
+	deadline := time.Now().Add(time.Duration(-24*days) * time.Hour)
+	outDate := cond.AddOp(operator.Lt, snapshotTimeTag, deadline)
+
+	// the last snapshots before the deadline are still in effect after it, except the deletions
+	versions, err := s.latest(tank, outDate)
+	if err != nil {
+		blog.Errorf("snapshot | get the last snapshots before %s failed. err: %v", deadline.String(), err)
+		return
+	}
+	superseded := operator.NewCondition(operator.Nin, operator.M{ResourceVersionTag: versions}).
+		Or(operator.NewCondition(operator.Eq, operator.M{snapshotTypeTag: operator.Del}))
+
+	t := tank.From(snapshotTable).Filter(outDate.And(superseded)).RemoveAll()
+	if err = t.GetError(); err != nil {
+		blog.Errorf("snapshot | clean the snapshots failed. err: %v", err)
+		return
+	}
+	blog.Infof("snapshot | clean the snapshots before %s, total: %d", deadline.String(), t.GetChangeInfo().Removed)
+}
+
+func dataFeatures(table string, features operator.M) operator.M {
+	r := operator.M{snapshotTableTag: table}
+	for k, v := range features {
+		r[snapshotDataTag+"."+k] = v
+	}
+	return r
+}
+
+// dataCondition returns the condition with the keys under "data", for matching the documents in snapshots
+func dataCondition(condition *operator.Condition) *operator.Condition {
+	if condition == nil {
+		return operator.BaseCondition
+	}
+	r, _ := condition.Combine(func(c *operator.Condition) interface{} {
+		value, _ := c.Value.(operator.M)
+		if c.Type == operator.Tr || len(value) == 0 {
+			return operator.BaseCondition
+		}
+		m := make(operator.M, len(value))
+		for k, v := range value {
+			m[snapshotDataTag+"."+k] = v
+		}
+		return operator.NewCondition(c.Type, m)
+	}, func(t operator.ConditionType, cl []interface{}) interface{} {
+		r := operator.BaseCondition
+		for _, item := range cl {
+			c, ok := item.(*operator.Condition)
+			if !ok {
+				continue
+			}
+			switch t {
+			case operator.Not:
+				return c.Not()
+			case operator.Or:
+				r = r.Or(c)
+			default:
+				r = r.And(c)
+			}
+		}
+		return r
+	}).(*operator.Condition)
+	if r == nil {
+		return operator.BaseCondition
+	}
+	return r
+}
+
+// snapshotEnabled checks whether the snapshots of resourceType are kept, which needs a retention in config
+func snapshotEnabled(resourceType interface{}) bool {
+	retention, defaultDays := snapshotRetention()
+	if name, ok := resourceType.(string); ok {
+		if days, ok := retention[name]; ok {
+			return days > 0
+		}
+	}
+	return defaultDays > 0
+}
+
+// snapshotRetention returns the max days of each resource type in config, and the default max days.
+// No snapshot is kept without config.
+func snapshotRetention() (map[string]int64, int64) {
+	conf := apiserver.GetAPIResource().Conf
+	if conf == nil {
+		return nil, 0
+	}
+	return ParseRetention(conf.SnapshotRetention), conf.SnapshotMaxTime
+}
+
+// ParseRetention parse the retention like "Pod=1,Deployment=30" into the max days of each resource type
+func ParseRetention(raw string) map[string]int64 {
+	r := make(map[string]int64)
+	for _, item := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		days, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil || days <= 0 {
+			blog.Warnf("snapshot | invalid retention of %s: %s", kv[0], kv[1])
+			continue
+		}
+		r[strings.TrimSpace(kv[0])] = days
+	}
+	return r
+}
+
+// ParseTime parse the time in unix seconds, RFC3339 or "2006-01-02 15:04:05" in local zone
+func ParseTime(raw string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", raw, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %s, should be unix seconds, RFC3339 or 2006-01-02 15:04:05", raw)
+	}
+	return t, nil
+}
+
+// snapshotTime returns the time of value decoded by drivers, which may be time.Time or RFC3339 string
+func snapshotTime(value interface{}) time.Time {
+	switch t := value.(type) {
+	case time.Time:
+		return t
+	case string:
+		r, _ := time.Parse(time.RFC3339Nano, t)
+		return r
+	}
+	return time.Time{}
+}
+
+// DiffItem is a difference of the field in Path between two documents
+type DiffItem struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+const (
+	diffAdd     = "add"
+	diffRemove  = "remove"
+	diffReplace = "replace"
+)
+
+// Diff returns the differences from old to new, nested maps are compared by fields and the paths are
+// joined by ".", other values are compared as a whole. The items are sorted by path.
+func Diff(old, new map[string]interface{}) []DiffItem {
+	r := make([]DiffItem, 0)
+	diffMap("", old, new, &r)
+	sort.Slice(r, func(i, j int) bool { return r[i].Path < r[j].Path })
+	return r
+}
+
+func diffMap(prefix string, old, new map[string]interface{}, r *[]DiffItem) {
+	for k, ov := range old {
+		path := prefix + k
+		nv, ok := new[k]
+		if !ok {
+			*r = append(*r, DiffItem{Path: path, Op: diffRemove, Old: ov})
+			continue
+		}
+		om, oIsMap := ov.(map[string]interface{})
+		nm, nIsMap := nv.(map[string]interface{})
+		if oIsMap && nIsMap {
+			diffMap(path+".", om, nm, r)
+			continue
+		}
+		if !reflect.DeepEqual(ov, nv) {
+			*r = append(*r, DiffItem{Path: path, Op: diffReplace, Old: ov, New: nv})
+		}
+	}
+	for k, nv := range new {
+		if _, ok := old[k]; !ok {
+			*r = append(*r, DiffItem{Path: prefix + k, Op: diffAdd, New: nv})
+		}
+	}
+}
@@ -0,0 +1,242 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"bk-bcs/bcs-services/bcs-storage/app/options"
+	"bk-bcs/bcs-services/bcs-storage/storage/apiserver"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+func TestDiff(t *testing.T) {
+	old := map[string]interface{}{
+		"replicas": float64(1),
+		"image":    "nginx:1.0",
+		"labels":   map[string]interface{}{"app": "web", "tier": "front"},
+		"ports":    []interface{}{float64(80)},
+	}
+	new := map[string]interface{}{
+		"replicas": float64(3),
+		"image":    "nginx:1.0",
+		"labels":   map[string]interface{}{"app": "web", "version": "v2"},
+		"ports":    []interface{}{float64(80), float64(443)},
+	}
+	expect := []DiffItem{
+		{Path: "labels.tier", Op: diffRemove, Old: "front"},
+		{Path: "labels.version", Op: diffAdd, New: "v2"},
+		{Path: "ports", Op: diffReplace, Old: []interface{}{float64(80)}, New: []interface{}{float64(80), float64(443)}},
+		{Path: "replicas", Op: diffReplace, Old: float64(1), New: float64(3)},
+	}
+	if r := Diff(old, new); !reflect.DeepEqual(r, expect) {
+		t.Errorf("Diff() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+
+	if r := Diff(nil, map[string]interface{}{"a": "b"}); !reflect.DeepEqual(r, []DiffItem{{Path: "a", Op: diffAdd, New: "b"}}) {
+		t.Errorf("Diff() from nil failed! \nresult:\n%v\n", r)
+	}
+	if r := Diff(old, old); len(r) != 0 {
+		t.Errorf("Diff() of the same failed! \nresult:\n%v\n", r)
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	expect := map[string]int64{"Pod": 1, "Deployment": 30}
+	if r := ParseRetention(" Pod=1, Deployment = 30,Service=x,taskgroup=0,,"); !reflect.DeepEqual(r, expect) {
+		t.Errorf("ParseRetention() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+}
+
+func TestSnapshotEnabled(t *testing.T) {
+	a := apiserver.GetAPIResource()
+	defer func(conf *options.StorageOptions) { a.Conf = conf }(a.Conf)
+
+	a.Conf = nil
+	if snapshotEnabled("Pod") {
+		t.Errorf("snapshotEnabled() without config should be false")
+	}
+
+	a.Conf = &options.StorageOptions{SnapshotRetention: "Pod=1"}
+	if !snapshotEnabled("Pod") || snapshotEnabled("Deployment") {
+		t.Errorf("snapshotEnabled() should be true only for Pod with retention Pod=1")
+	}
+
+	a.Conf = &options.StorageOptions{SnapshotMaxTime: 7}
+	if !snapshotEnabled("Deployment") || !snapshotEnabled(nil) {
+		t.Errorf("snapshotEnabled() should be true for all types with snapshot_max_day 7")
+	}
+}
+
+func TestSnapshotsCompact(t *testing.T) {
+	s := NewSnapshots(operator.GetMockTankNewFunc(&operator.MockTank{}), "resourceName")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Compact(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Compact() should stop when the context is done")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expect := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	for _, raw := range []string{
+		"2020-01-02 03:04:05",
+		expect.Format(time.RFC3339),
+		expect.Format("2006-01-02T15:04:05Z07:00"),
+	} {
+		if r, err := ParseTime(raw); err != nil || !r.Equal(expect) {
+			t.Errorf("ParseTime(%s) failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", raw, r, expect, err)
+		}
+	}
+	if r, err := ParseTime("1577934245"); err != nil || r.Unix() != 1577934245 {
+		t.Errorf("ParseTime() of unix seconds failed! \nresult:\n%v\nerr:\n%v\n", r, err)
+	}
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Errorf("ParseTime() of invalid time should fail")
+	}
+}
+
+func snapshotEntryOf(eventType operator.EventType, version uint64, tm string, data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		snapshotTypeTag:    float64(eventType),
+		ResourceVersionTag: float64(version),
+		snapshotTimeTag:    tm,
+		snapshotDataTag:    map[string]interface{}{"resourceName": "web", snapshotDataTag: data},
+	}
+}
+
+func TestSnapshotTimeline(t *testing.T) {
+	mt := &operator.MockTank{Value: []interface{}{
+		snapshotEntryOf(operator.Add, 1, "2020-01-02T03:00:00Z", map[string]interface{}{"replicas": float64(1)}),
+		snapshotEntryOf(operator.Chg, 2, "2020-01-02T03:10:00Z", map[string]interface{}{"replicas": float64(3)}),
+		snapshotEntryOf(operator.Del, 3, "2020-01-02T03:20:00Z", map[string]interface{}{"replicas": float64(3)}),
+	}}
+	s := NewSnapshots(operator.GetMockTankNewFunc(mt), "resourceName")
+
+	r, err := s.Timeline("cluster_Deployment", operator.M{"resourceName": "web"}, time.Time{}, time.Time{}, 0, false)
+	expect := []*SnapshotChange{
+		{
+			Time:            "2020-01-02T03:00:00Z",
+			Type:            operator.Add.String(),
+			ResourceVersion: 1,
+			Diff:            []DiffItem{{Path: "replicas", Op: diffAdd, New: float64(1)}},
+		},
+		{
+			Time:            "2020-01-02T03:10:00Z",
+			Type:            operator.Chg.String(),
+			ResourceVersion: 2,
+			Diff:            []DiffItem{{Path: "replicas", Op: diffReplace, Old: float64(1), New: float64(3)}},
+		},
+		{
+			Time:            "2020-01-02T03:20:00Z",
+			Type:            operator.Del.String(),
+			ResourceVersion: 3,
+			Diff:            []DiffItem{{Path: "replicas", Op: diffRemove, Old: float64(3)}},
+		},
+	}
+	if err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("Timeline() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+}
+
+func TestTimelineFromLast(t *testing.T) {
+	last := map[string]interface{}{"replicas": float64(1)}
+	entries := []interface{}{
+		snapshotEntryOf(operator.Chg, 2, "2020-01-02T03:10:00Z", map[string]interface{}{"replicas": float64(3)}),
+	}
+	expect := []*SnapshotChange{{
+		Time:            "2020-01-02T03:10:00Z",
+		Type:            operator.Chg.String(),
+		ResourceVersion: 2,
+		Diff:            []DiffItem{{Path: "replicas", Op: diffReplace, Old: float64(1), New: float64(3)}},
+		Data:            map[string]interface{}{"resourceName": "web", snapshotDataTag: map[string]interface{}{"replicas": float64(3)}},
+	}}
+	if r := timeline(last, entries, true); !reflect.DeepEqual(r, expect) {
+		t.Errorf("timeline() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+}
+
+func TestSnapshotAt(t *testing.T) {
+	mt := &operator.MockTank{Value: []interface{}{
+		snapshotEntryOf(operator.Chg, 2, "2020-01-02T03:10:00Z", map[string]interface{}{"replicas": float64(3)}),
+	}}
+	s := NewSnapshots(operator.GetMockTankNewFunc(mt), "resourceName")
+
+	cond := operator.NewCondition(operator.Eq, operator.M{"resourceName": "web"})
+	r, err := s.At("cluster_Deployment", cond, time.Now(), 0, -1, nil)
+	expect := []interface{}{map[string]interface{}{"resourceName": "web", snapshotDataTag: map[string]interface{}{"replicas": float64(3)}}}
+	if err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("At() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+
+	mt.Value = nil
+	if r, err = s.At("cluster_Deployment", cond, time.Now(), 0, -1, nil); err != nil || len(r) != 0 {
+		t.Errorf("At() without snapshots failed! \nresult:\n%v\nerr:\n%v\n", r, err)
+	}
+}
+
+func TestSharedSnapshots(t *testing.T) {
+	getNewTank := operator.GetMockTankNewFunc(&operator.MockTank{})
+	if SharedSnapshots("test", getNewTank) != SharedSnapshots("test", getNewTank) {
+		t.Errorf("SharedSnapshots() of the same name should be the same one")
+	}
+	if SharedHistory("test", getNewTank) != SharedHistory("test", getNewTank) {
+		t.Errorf("SharedHistory() of the same name should be the same one")
+	}
+}
+
+func TestDataFeatures(t *testing.T) {
+	expect := operator.M{
+		snapshotTableTag:    "cluster_Pod",
+		"data.resourceName": "web",
+		"data.namespace":    nil,
+	}
+	if r := dataFeatures("cluster_Pod", operator.M{"resourceName": "web", "namespace": nil}); !reflect.DeepEqual(r, expect) {
+		t.Errorf("dataFeatures() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+}
+
+func TestDataCondition(t *testing.T) {
+	cond := operator.NewCondition(operator.Eq, operator.M{"clusterId": "c1"}).
+		AddOp(operator.In, "metadata.labels.app", []string{"foo"}).
+		And(operator.NewCondition(operator.Ext, operator.M{"spec": true}).
+			Or(operator.NewCondition(operator.Ne, operator.M{"status": "Failed"}).Not()))
+	expect := operator.M{"and": []interface{}{
+		operator.M{"and": []interface{}{
+			operator.M{"data.clusterId": "c1"},
+			operator.M{"in": operator.M{"data.metadata.labels.app": []string{"foo"}}},
+		}},
+		operator.M{"or": []interface{}{
+			operator.M{"exists": operator.M{"data.spec": true}},
+			operator.M{"not": operator.M{"ne": operator.M{"data.status": "Failed"}}},
+		}},
+	}}
+	if r := operator.MockCombineCondition(dataCondition(cond)); !reflect.DeepEqual(r, expect) {
+		t.Errorf("dataCondition() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+	if r := dataCondition(nil); r != operator.BaseCondition {
+		t.Errorf("dataCondition() of nil failed! \nresult:\n%v\nexpect:\n%v\n", r, operator.BaseCondition)
+	}
+}
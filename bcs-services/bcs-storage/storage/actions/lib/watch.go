@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"bk-bcs/bcs-common/common/blog"
 	"bk-bcs/bcs-common/common/codec"
@@ -32,6 +33,17 @@ type watchServer struct {
 	req  *restful.Request
 	resp *restful.Response
 
+	// history of the table, watch from it when opts.ResourceVersion is set
+	history *History
+	table   string
+
+	// events of the documents not matching the condition are ignored, nil for no filtering
+	condition *operator.Condition
+	// keys identifying a document, "_id" is used if empty
+	identity []string
+	// identities of the documents sent as matching, a change of them no longer matching is sent as deletion
+	sent map[string]bool
+
 	Writer func(resp *restful.Response, event *operator.Event) bool
 }
 
@@ -52,6 +64,13 @@ func NewWatchServer(req *restful.Request, resp *restful.Response, tank operator.
 	if err := codec.DecJsonReader(req.Request.Body, opts); err != nil {
 		return nil, err
 	}
+	if raw := req.QueryParameter(ResourceVersionTag); raw != "" {
+		version, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts.ResourceVersion = version
+	}
 	return &watchServer{
 		req:    req,
 		resp:   resp,
@@ -61,6 +80,25 @@ func NewWatchServer(req *restful.Request, resp *restful.Response, tank operator.
 	}, nil
 }
 
+//WithHistory make the watch resumable from the resource version with the history of table
+func (ws *watchServer) WithHistory(history *History, table string) *watchServer {
+	ws.history = history
+	ws.table = table
+	return ws
+}
+
+//WithCondition only send the events of documents matching the condition, like the selectors
+func (ws *watchServer) WithCondition(condition *operator.Condition) *watchServer {
+	ws.condition = condition
+	return ws
+}
+
+//WithIdentity set the keys identifying a document in events, for the documents without "_id" like the history
+func (ws *watchServer) WithIdentity(keys ...string) *watchServer {
+	ws.identity = keys
+	return ws
+}
+
 //Go running watchServer
 func (ws *watchServer) Go(ctx context.Context) {
 	if ws.tank == nil || ws.req == nil || ws.resp == nil {
@@ -75,8 +113,15 @@ func (ws *watchServer) Go(ctx context.Context) {
 	ws.resp.WriteHeader(http.StatusOK)
 	ws.resp.ResponseWriter.(http.Flusher).Flush()
 
-	blog.Infof(ws.sprint("begin to watch"))
-	event, cancel := ws.tank.Watch(ws.opts)
+	var event chan *operator.Event
+	var cancel context.CancelFunc
+	if ws.history != nil && ws.opts.ResourceVersion > 0 {
+		blog.Infof(ws.sprint(fmt.Sprintf("begin to watch from resource version %d", ws.opts.ResourceVersion)))
+		event, cancel = ws.history.Watch(ws.table, ws.opts)
+	} else {
+		blog.Infof(ws.sprint("begin to watch"))
+		event, cancel = ws.tank.Watch(ws.opts)
+	}
 	defer func() {
 		cancel()
 		blog.Infof(ws.sprint("watch end"))
@@ -92,6 +137,12 @@ func (ws *watchServer) Go(ctx context.Context) {
 			blog.Infof(ws.sprint("stop watch by server"))
 			return
 		case e := <-event:
+			if e = ws.filter(e); e == nil {
+				continue
+			}
+			if e.ResourceVersion == 0 && e.Value != nil {
+				e.ResourceVersion = ResourceVersionOf(e.Value)
+			}
 			if ws.Writer(ws.resp, e) {
 				blog.Infof(ws.sprint(fmt.Sprintf("flush: %v", e)))
 			}
@@ -100,6 +151,91 @@ func (ws *watchServer) Go(ctx context.Context) {
 	}
 }
 
+// filter returns the event to send, nil if it should be ignored. A change of the document which matched the
+// condition before but no longer matches is sent as a deletion, since the document leaves the selected set.
+func (ws *watchServer) filter(e *operator.Event) *operator.Event {
+	if ws.condition == nil || e == nil {
+		return e
+	}
+	switch e.Type {
+	case operator.Add, operator.Chg, operator.SChg, operator.Del:
+	default:
+		return e
+	}
+	if ws.sent == nil {
+		ws.sent = make(map[string]bool)
+	}
+	key := ws.identityOf(e.Value)
+
+	if ws.match(e) {
+		if e.Type == operator.Del {
+			delete(ws.sent, key)
+		} else {
+			ws.sent[key] = true
+		}
+		return e
+	}
+	if !ws.matchedBefore(key, e) {
+		return nil
+	}
+	delete(ws.sent, key)
+	if e.Type == operator.Del {
+		return e
+	}
+	return &operator.Event{Type: operator.Del, Value: e.Value, ResourceVersion: e.ResourceVersion}
+}
+
+// matchedBefore check whether the document was sent as matching the condition. The documents got by the client
+// from a list before watching are not sent, so the previous change in history is checked for them.
+func (ws *watchServer) matchedBefore(key string, e *operator.Event) bool {
+	if ws.sent[key] {
+		return true
+	}
+	if ws.history == nil || len(ws.identity) == 0 || e.ResourceVersion == 0 {
+		return false
+	}
+	identity := make(operator.M, len(ws.identity))
+	for _, k := range ws.identity {
+		identity[k] = e.Value[k]
+	}
+	prev, err := ws.history.Previous(ws.table, identity, e.ResourceVersion)
+	if err != nil {
+		blog.Errorf(ws.sprint(fmt.Sprintf("get the previous change of %s failed: %v", key, err)))
+		return false
+	}
+	return prev != nil && prev.Type != operator.Del && MatchCondition(ws.condition, prev.Value)
+}
+
+// identityOf returns the identity of document in events
+func (ws *watchServer) identityOf(value operator.M) string {
+	if len(ws.identity) == 0 {
+		return fmt.Sprint(value["_id"])
+	}
+	key := ""
+	for _, k := range ws.identity {
+		key += fmt.Sprintf("%v/", value[k])
+	}
+	return key
+}
+
+// match check the document of event with the condition, the events without document like break are always sent.
+// Deletions from mongodb oplog only contain "_id", they can not be checked and are always sent too.
+func (ws *watchServer) match(e *operator.Event) bool {
+	if ws.condition == nil || e == nil {
+		return true
+	}
+	switch e.Type {
+	case operator.Add, operator.Chg, operator.SChg:
+	case operator.Del:
+		if len(e.Value) <= 1 {
+			return true
+		}
+	default:
+		return true
+	}
+	return MatchCondition(ws.condition, e.Value)
+}
+
 func (ws *watchServer) sprint(s string) string {
 	return fmt.Sprintf("watch server %s | %s", ws.req.Request.URL, s)
 }
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package lib
+
+import (
+	"reflect"
+	"testing"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+func watchTestDoc(name, app string) operator.M {
+	return operator.M{"resourceName": name, "namespace": "default", "data": map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": app}},
+	}}
+}
+
+func TestWatchServerFilter(t *testing.T) {
+	cond, _ := SelectorCondition("app=foo", "")
+	ws := (&watchServer{}).WithCondition(cond).WithIdentity("resourceName", "namespace")
+
+	cases := []struct {
+		event  *operator.Event
+		expect *operator.Event
+	}{
+		// a never matched document is ignored
+		{&operator.Event{Type: operator.Add, Value: watchTestDoc("a", "bar")}, nil},
+		{&operator.Event{Type: operator.Chg, Value: watchTestDoc("a", "bar")}, nil},
+		// the document leaving the selector is sent as deletion once
+		{&operator.Event{Type: operator.Add, Value: watchTestDoc("b", "foo")}, &operator.Event{Type: operator.Add, Value: watchTestDoc("b", "foo")}},
+		{&operator.Event{Type: operator.Chg, Value: watchTestDoc("b", "bar")}, &operator.Event{Type: operator.Del, Value: watchTestDoc("b", "bar")}},
+		{&operator.Event{Type: operator.Chg, Value: watchTestDoc("b", "baz")}, nil},
+		{&operator.Event{Type: operator.Del, Value: watchTestDoc("b", "baz")}, nil},
+		// the document coming back is sent again, and the deletion of it too
+		{&operator.Event{Type: operator.SChg, Value: watchTestDoc("b", "foo")}, &operator.Event{Type: operator.SChg, Value: watchTestDoc("b", "foo")}},
+		{&operator.Event{Type: operator.Del, Value: watchTestDoc("b", "bar")}, &operator.Event{Type: operator.Del, Value: watchTestDoc("b", "bar")}},
+		{operator.EventWatchBreak, operator.EventWatchBreak},
+	}
+	for i, c := range cases {
+		if r := ws.filter(c.event); !reflect.DeepEqual(r, c.expect) {
+			t.Errorf("filter() of event %d failed! \nresult:\n%v\nexpect:\n%v\n", i, r, c.expect)
+		}
+	}
+}
+
+func TestWatchServerFilterWithHistory(t *testing.T) {
+	cond, _ := SelectorCondition("app=foo", "")
+
+	// the document matched in the previous change of history, which the client got from list
+	mt := &operator.MockTank{Value: []interface{}{map[string]interface{}{
+		ResourceVersionTag: uint64(3),
+		historyTypeTag:     int(operator.Chg),
+		historyDataTag:     map[string]interface{}(watchTestDoc("a", "foo")),
+	}}}
+	ws := (&watchServer{}).WithHistory(NewHistory(operator.GetMockTankNewFunc(mt)), "cluster_Pod").
+		WithCondition(cond).WithIdentity("resourceName", "namespace")
+	event := &operator.Event{Type: operator.Chg, Value: watchTestDoc("a", "bar"), ResourceVersion: 5}
+	expect := &operator.Event{Type: operator.Del, Value: watchTestDoc("a", "bar"), ResourceVersion: 5}
+	if r := ws.filter(event); !reflect.DeepEqual(r, expect) {
+		t.Errorf("filter() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+
+	// the document did not match in the previous change
+	mt.Value = []interface{}{map[string]interface{}{
+		ResourceVersionTag: uint64(6),
+		historyTypeTag:     int(operator.Chg),
+		historyDataTag:     map[string]interface{}(watchTestDoc("c", "bar")),
+	}}
+	event = &operator.Event{Type: operator.Chg, Value: watchTestDoc("c", "baz"), ResourceVersion: 7}
+	if r := ws.filter(event); r != nil {
+		t.Errorf("filter() failed! \nresult:\n%v\nexpect:\n%v\n", r, nil)
+	}
+}
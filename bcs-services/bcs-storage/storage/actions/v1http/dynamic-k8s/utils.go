@@ -14,6 +14,7 @@
 package dynamic
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -41,6 +42,9 @@ type reqDynamic struct {
 	condition *operator.Condition
 	features  operator.M
 	data      operator.M
+
+	// the current resource version before list
+	version uint64
 }
 
 // get a new instance of reqDynamic, getNewTank() will be called and
@@ -100,6 +104,15 @@ func (rd *reqDynamic) getLimit() int {
 	return rd.limit
 }
 
+// getAt returns the time of ?at=, zero if not set
+func (rd *reqDynamic) getAt() (time.Time, error) {
+	raw := rd.req.QueryParameter(atTag)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return lib.ParseTime(raw)
+}
+
 func (rd *reqDynamic) getExtra() (extra operator.M) {
 	raw := rd.req.QueryParameter(extraTag)
 	if raw == "" {
@@ -186,6 +199,11 @@ func (rd *reqDynamic) getFeat(resourceFeatList []string) *operator.Condition {
 	return rd.condition
 }
 
+// getSelectorFeat returns the condition of label selector and field selector, nil if no selector
+func (rd *reqDynamic) getSelectorFeat() (*operator.Condition, error) {
+	return lib.SelectorCondition(rd.req.QueryParameter(lib.LabelSelectorTag), rd.req.QueryParameter(lib.FieldSelectorTag))
+}
+
 func (rd *reqDynamic) getReqData() (operator.M, error) {
 	if rd.data == nil {
 		var tmp types.BcsStorageDynamicIf
@@ -208,14 +226,44 @@ func (rd *reqDynamic) csGet() ([]interface{}, error) {
 }
 
 func (rd *reqDynamic) nsList() ([]interface{}, error) {
-	return rd.get(rd.getNsListFeat())
+	return rd.list(rd.getNsListFeat())
 }
 
 func (rd *reqDynamic) csList() ([]interface{}, error) {
-	return rd.get(rd.getCsListFeat())
+	return rd.list(rd.getCsListFeat())
+}
+
+// list get the current resource version before query, so that the watch from it
+// will not miss any change after the list.
+func (rd *reqDynamic) list(condition *operator.Condition) (r []interface{}, err error) {
+	selector, err := rd.getSelectorFeat()
+	if err != nil {
+		return
+	}
+	if selector != nil {
+		condition = condition.And(selector)
+	}
+
+	if rd.req.QueryParameter(atTag) != "" {
+		return rd.get(condition)
+	}
+	if rd.version, err = history.CurrentVersion(); err != nil {
+		blog.Errorf("Failed to get resource version. err: %v", err)
+		return
+	}
+	return rd.get(condition)
 }
 
 func (rd *reqDynamic) get(condition *operator.Condition) (r []interface{}, err error) {
+	at, err := rd.getAt()
+	if err != nil {
+		return
+	}
+	// query the snapshots at the time
+	if !at.IsZero() {
+		return snapshots.At(rd.getTable(), condition, at, rd.getOffset(), rd.getLimit(), rd.getSelector())
+	}
+
 	tank := rd.tank.From(rd.getTable()).Filter(condition).
 		Offset(rd.getOffset()).Limit(rd.getLimit()).Select(rd.getSelector()...).Query()
 
@@ -247,16 +295,29 @@ func (rd *reqDynamic) csPut() error {
 	return rd.put(rd.getCsFeat())
 }
 
-// put try update first, if target is no found the try insert.
+// put try update first, if target is no found the try insert. The write is based on the document read
+// before it, and retried with a new resource version if the document has been changed in the meantime.
 func (rd *reqDynamic) put(condition *operator.Condition) (err error) {
-	tank := rd.tank.From(rd.getTable()).Filter(condition)
-
 	data, err := rd.getReqData()
 	if err != nil {
 		return
 	}
 
-	// Update or insert
+	for i := 0; i < putMaxRetry; i++ {
+		var written bool
+		if written, err = rd.tryPut(condition, data); err != nil || written {
+			return
+		}
+	}
+	blog.Errorf("Failed to put after %d retries. err: %v", putMaxRetry, storageErr.ResourceVersionConflict)
+	return storageErr.ResourceVersionConflict
+}
+
+// tryPut writes data with a new resource version, unless the document is inserted or changed by others after
+// it is read. The change is recorded in history after it is written.
+func (rd *reqDynamic) tryPut(condition *operator.Condition, data operator.M) (written bool, err error) {
+	tank := rd.tank.From(rd.getTable()).Filter(condition)
+
 	timeNow := time.Now()
 
 	queryTank := tank.Query()
@@ -264,16 +325,76 @@ func (rd *reqDynamic) put(condition *operator.Condition) (err error) {
 		blog.Errorf("Failed to check if resource exist. err: %v", err)
 		return
 	}
+
+	version, err := history.Allocate(1)
+	if err != nil {
+		blog.Errorf("Failed to allocate resource version. err: %v", err)
+		return
+	}
+	data.Update(updateTimeTag, timeNow).Update(lib.ResourceVersionTag, version)
+
+	// Update or insert
+	eventType := operator.Chg
 	if queryTank.GetLen() == 0 {
+		eventType = operator.Add
 		data.Update(createTimeTag, timeNow)
+
+		if written, err = rd.insert(tank, data); err != nil || !written {
+			return
+		}
+	} else {
+		delete(data, createTimeTag)
+		stored, _ := queryTank.GetValue()[0].(map[string]interface{})
+		updateTank := tank.Filter(lib.VersionCondition(lib.ResourceVersionOf(stored))).
+			Index(indexKeys...).Update(data)
+		if err = updateTank.GetError(); err != nil {
+			blog.Errorf("Failed to update. err: %v", err)
+			return
+		}
+		if updateTank.GetChangeInfo().Matched == 0 {
+			return false, nil
+		}
 	}
 
-	tank = tank.Index(indexKeys...).Upsert(data.Update(updateTimeTag, timeNow))
-	if err = tank.GetError(); err != nil {
-		blog.Errorf("Failed to update. err: %v", err)
+	if err = history.Record(rd.getTable(), eventType, data); err != nil {
+		blog.Errorf("Failed to record history. err: %v", err)
 		return
 	}
-	return
+
+	// snapshots are only for looking back, failure does not fail the put
+	if snapshotErr := snapshots.Record(rd.getTable(), eventType, timeNow, data); snapshotErr != nil {
+		blog.Errorf("Failed to record snapshot. err: %v", snapshotErr)
+	}
+	return true, nil
+}
+
+// insert data unless the resource is inserted by others in the meantime, which fails the insert by the
+// unique index, or is found by counting after insert for the drivers whose unique index ignores missing keys.
+func (rd *reqDynamic) insert(tank operator.Tank, data operator.M) (bool, error) {
+	if err := tank.Index(indexKeys...).Insert(data).GetError(); err != nil {
+		if countTank := tank.Count(); countTank.GetError() == nil && countTank.GetLen() > 0 {
+			return false, nil
+		}
+		blog.Errorf("Failed to insert. err: %v", err)
+		return false, err
+	}
+
+	countTank := tank.Count()
+	if err := countTank.GetError(); err != nil {
+		blog.Errorf("Failed to check if resource is inserted by others. err: %v", err)
+		return false, err
+	}
+	if countTank.GetLen() <= 1 {
+		return true, nil
+	}
+
+	// take back the insert and try again
+	version := lib.ResourceVersionOf(data)
+	if err := tank.Filter(lib.VersionCondition(version)).Remove().GetError(); err != nil {
+		blog.Errorf("Failed to remove the duplicated resource. err: %v", err)
+		return false, err
+	}
+	return false, nil
 }
 
 func (rd *reqDynamic) nsRemove() error {
@@ -292,15 +413,63 @@ func (rd *reqDynamic) csBatchRemove() error {
 	return rd.remove(rd.getCsRemoveFeat(), false)
 }
 
+// remove the matches in one RemoveAll, which is limited to the versions read before it, and record the
+// deletions of them in one batch.
 func (rd *reqDynamic) remove(condition *operator.Condition, mustMatch bool) (err error) {
-	tank := rd.tank.From(rd.getTable()).Filter(condition).RemoveAll()
+	tank := rd.tank.From(rd.getTable()).Filter(condition)
 
-	if err = tank.GetError(); err != nil {
+	// get the documents to be removed for history
+	queryTank := tank.Query()
+	if err = queryTank.GetError(); err != nil {
+		blog.Errorf("Failed to query resources to be removed. err: %v", err)
+		return
+	}
+	docs := make([]operator.M, 0, queryTank.GetLen())
+	versions := make([]uint64, 0, queryTank.GetLen())
+	for _, value := range queryTank.GetValue() {
+		if doc, ok := value.(map[string]interface{}); ok {
+			docs = append(docs, doc)
+			versions = append(versions, lib.ResourceVersionOf(doc))
+		}
+	}
+	if len(docs) == 0 {
+		if mustMatch {
+			return storageErr.ResourceDoesNotExist
+		}
+		return
+	}
+
+	first, err := history.Allocate(len(docs))
+	if err != nil {
+		blog.Errorf("Failed to allocate resource version. err: %v", err)
+		return
+	}
+
+	removeTank := tank.Filter(lib.VersionCondition(versions...)).RemoveAll()
+	if err = removeTank.GetError(); err != nil {
 		blog.Errorf("Failed to remove. err: %v", err)
 		return
 	}
+	changeInfo := removeTank.GetChangeInfo()
+
+	// the documents changed after read are not removed
+	if changeInfo.Removed < len(docs) {
+		if docs, err = rd.removedDocs(tank, docs, first); err != nil {
+			blog.Errorf("Failed to check removed resources. err: %v", err)
+			return
+		}
+	}
+	for i, doc := range docs {
+		doc[lib.ResourceVersionTag] = first + uint64(i)
+	}
+	if err = history.Record(rd.getTable(), operator.Del, docs...); err != nil {
+		blog.Errorf("Failed to record history. err: %v", err)
+		return
+	}
+	if snapshotErr := snapshots.Record(rd.getTable(), operator.Del, time.Now(), docs...); snapshotErr != nil {
+		blog.Errorf("Failed to record snapshot. err: %v", snapshotErr)
+	}
 
-	changeInfo := tank.GetChangeInfo()
 	if changeInfo.Removed != changeInfo.Matched {
 		return storageErr.RemoveLessThanMatch
 	}
@@ -311,6 +480,68 @@ func (rd *reqDynamic) remove(condition *operator.Condition, mustMatch bool) (err
 	return
 }
 
+// removedDocs returns the docs which do not exist any more, except the ones put again after the removal
+// with the versions after first.
+func (rd *reqDynamic) removedDocs(tank operator.Tank, docs []operator.M, first uint64) ([]operator.M, error) {
+	keys := append([]string{lib.ResourceVersionTag}, indexKeys...)
+	queryTank := tank.Select(keys...).Query()
+	if err := queryTank.GetError(); err != nil {
+		return nil, err
+	}
+	remained := make(map[string]bool, queryTank.GetLen())
+	for _, value := range queryTank.GetValue() {
+		if doc, ok := value.(map[string]interface{}); ok && lib.ResourceVersionOf(doc) < first {
+			remained[identityOf(doc)] = true
+		}
+	}
+
+	r := make([]operator.M, 0, len(docs))
+	for _, doc := range docs {
+		if !remained[identityOf(doc)] {
+			r = append(r, doc)
+		}
+	}
+	return r, nil
+}
+
+// identityOf returns the values of indexKeys in doc as a string
+func identityOf(doc map[string]interface{}) string {
+	values := make([]string, 0, len(indexKeys))
+	for _, key := range indexKeys {
+		values = append(values, fmt.Sprint(doc[key]))
+	}
+	return strings.Join(values, "/")
+}
+
+func (rd *reqDynamic) nsTimeline() ([]*lib.SnapshotChange, error) {
+	return rd.timeline(rd.getNsFeat())
+}
+
+func (rd *reqDynamic) csTimeline() ([]*lib.SnapshotChange, error) {
+	return rd.timeline(rd.getCsFeat())
+}
+
+// timeline returns the changes of the resource in [from, to] with diffs, at most limit changes
+func (rd *reqDynamic) timeline(condition *operator.Condition) (r []*lib.SnapshotChange, err error) {
+	var from, to time.Time
+	if raw := rd.req.QueryParameter(fromTag); raw != "" {
+		if from, err = lib.ParseTime(raw); err != nil {
+			return
+		}
+	}
+	if raw := rd.req.QueryParameter(toTag); raw != "" {
+		if to, err = lib.ParseTime(raw); err != nil {
+			return
+		}
+	}
+	withData := rd.req.QueryParameter(withDataTag) == "true"
+
+	if r, err = snapshots.Timeline(rd.getTable(), rd.features, from, to, rd.getLimit(), withData); err != nil {
+		blog.Errorf("Failed to get the timeline. err: %v", err)
+	}
+	return
+}
+
 // exit() should be called after all ops in reqDynamic to close the connection
 // to database.
 func (rd *reqDynamic) exit() {
@@ -39,6 +39,14 @@ const (
 	updateTimeTag = "updateTime"
 	createTimeTag = "createTime"
 	timeLayout    = "2006-01-02 15:04:05"
+
+	atTag       = "at"
+	fromTag     = "from"
+	toTag       = "to"
+	withDataTag = "withData"
+
+	// the max times of trying put when the resource is changed by others while writing
+	putMaxRetry = 3
 )
 
 var needTimeFormatList = [...]string{updateTimeTag, createTimeTag}
@@ -53,6 +61,12 @@ const dbConfig = "dynamic"
 
 var getNewTank operator.GetNewTank = lib.GetMongodbTank(dbConfig)
 
+// history records the changes with resource versions for resumable watches
+var history = lib.SharedHistory(dbConfig, func() operator.Tank { return getNewTank() })
+
+// snapshots keeps every version of resources for querying them at a point in time
+var snapshots = lib.SharedSnapshots(dbConfig, func() operator.Tank { return getNewTank() }, indexKeys...)
+
 func GetNamespaceResources(req *restful.Request, resp *restful.Response) {
 	request := newReqDynamic(req)
 	defer request.exit()
@@ -141,7 +155,7 @@ func ListNamespaceResources(req *restful.Request, resp *restful.Response) {
 		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageListResourceFail, Message: common.BcsErrStorageListResourceFailStr})
 		return
 	}
-	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r})
+	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r, Extra: map[string]interface{}{lib.ResourceVersionTag: request.version}})
 }
 
 func ListClusterResources(req *restful.Request, resp *restful.Response) {
@@ -154,7 +168,7 @@ func ListClusterResources(req *restful.Request, resp *restful.Response) {
 		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageListResourceFail, Message: common.BcsErrStorageListResourceFailStr})
 		return
 	}
-	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r})
+	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r, Extra: map[string]interface{}{lib.ResourceVersionTag: request.version}})
 }
 
 func DeleteBatchNamespaceResource(req *restful.Request, resp *restful.Response) {
@@ -179,12 +193,37 @@ func DeleteBatchClusterResource(req *restful.Request, resp *restful.Response) {
 	lib.ReturnRest(&lib.RestResponse{Resp: resp})
 }
 
+func GetNamespaceResourceHistory(req *restful.Request, resp *restful.Response) {
+	request := newReqDynamic(req)
+	defer request.exit()
+	r, err := request.nsTimeline()
+	if err != nil {
+		blog.Errorf("%s | err: %v", common.BcsErrStorageGetResourceFailStr, err)
+		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageGetResourceFail, Message: common.BcsErrStorageGetResourceFailStr})
+		return
+	}
+	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r})
+}
+
+func GetClusterResourceHistory(req *restful.Request, resp *restful.Response) {
+	request := newReqDynamic(req)
+	defer request.exit()
+	r, err := request.csTimeline()
+	if err != nil {
+		blog.Errorf("%s | err: %v", common.BcsErrStorageGetResourceFailStr, err)
+		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageGetResourceFail, Message: common.BcsErrStorageGetResourceFailStr})
+		return
+	}
+	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r})
+}
+
 func init() {
 	// Namespace resources.
 	namespaceResourcesPath := urlPath("/dynamic/namespace_resources/clusters/{clusterId}/namespaces/{namespace}/{resourceType}/{resourceName}")
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: namespaceResourcesPath, Params: nil, Handler: lib.MarkProcess(GetNamespaceResources)})
 	actions.RegisterV1Action(actions.Action{Verb: "PUT", Path: namespaceResourcesPath, Params: nil, Handler: lib.MarkProcess(PutNamespaceResources)})
 	actions.RegisterV1Action(actions.Action{Verb: "DELETE", Path: namespaceResourcesPath, Params: nil, Handler: lib.MarkProcess(DeleteNamespaceResources)})
+	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: namespaceResourcesPath + "/history", Params: nil, Handler: lib.MarkProcess(GetNamespaceResourceHistory)})
 
 	listNamespaceResourcesPath := urlPath("/dynamic/namespace_resources/clusters/{clusterId}/namespaces/{namespace}/{resourceType}")
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: listNamespaceResourcesPath, Params: nil, Handler: lib.MarkProcess(ListNamespaceResources)})
@@ -195,6 +234,7 @@ func init() {
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: clusterResourcesPath, Params: nil, Handler: lib.MarkProcess(GetClusterResources)})
 	actions.RegisterV1Action(actions.Action{Verb: "PUT", Path: clusterResourcesPath, Params: nil, Handler: lib.MarkProcess(PutClusterResources)})
 	actions.RegisterV1Action(actions.Action{Verb: "DELETE", Path: clusterResourcesPath, Params: nil, Handler: lib.MarkProcess(DeleteClusterResources)})
+	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: clusterResourcesPath + "/history", Params: nil, Handler: lib.MarkProcess(GetClusterResourceHistory)})
 
 	listClusterResourcesPath := urlPath("/dynamic/cluster_resources/clusters/{clusterId}/{resourceType}")
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: listClusterResourcesPath, Params: nil, Handler: lib.MarkProcess(ListClusterResources)})
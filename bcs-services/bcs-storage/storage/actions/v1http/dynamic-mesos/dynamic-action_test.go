@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
 	"bk-bcs/bcs-services/bcs-storage/storage/operator"
 
 	"github.com/emicklei/go-restful"
@@ -49,9 +50,10 @@ func TestPutResources(t *testing.T) {
 		"data": map[string]interface{}{
 			"foo": "bar",
 		},
-		"resourceType": "",
-		"clusterId":    "",
-		"resourceName": "",
+		"resourceType":    "",
+		"clusterId":       "",
+		"resourceName":    "",
+		"resourceVersion": uint64(1),
 	}
 	r, _ := http.NewRequest("PUT", "/", ioutil.NopCloser(strings.NewReader("{\"data\":{\"foo\":\"bar\"}}")))
 	req := restful.NewRequest(r)
@@ -68,6 +70,7 @@ func TestPutResources(t *testing.T) {
 
 	nsExpect := csExpect
 	nsExpect["namespace"] = ""
+	nsExpect["resourceVersion"] = uint64(2)
 	nsExpect["data"] = map[string]interface{}{"hello": "world"}
 	r, _ = http.NewRequest("PUT", "/", ioutil.NopCloser(strings.NewReader("{\"data\":{\"hello\":\"world\"}}")))
 	req = restful.NewRequest(r)
@@ -79,11 +82,33 @@ func TestPutResources(t *testing.T) {
 	}
 }
 
+func TestPutResourcesConflict(t *testing.T) {
+	r, _ := http.NewRequest("PUT", "/", ioutil.NopCloser(strings.NewReader("{\"data\":{\"foo\":\"bar\"}}")))
+	req := restful.NewRequest(r)
+
+	// the resource is always changed by others after read
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{
+		Value:      []interface{}{map[string]interface{}{"resourceName": "foo", "resourceVersion": int64(1)}},
+		Length:     1,
+		ChangeInfo: &operator.ChangeInfo{},
+	})
+
+	request := newReqDynamic(req)
+	defer request.exit()
+
+	if err := request.csPut(); err != storageErr.ResourceVersionConflict {
+		t.Errorf("csPut() failed! \nerr:\n%v\nexpect:\n%v\n", err, storageErr.ResourceVersionConflict)
+	}
+}
+
 func TestDeleteResources(t *testing.T) {
 	r, _ := http.NewRequest("DELETE", "/", nil)
 	req := restful.NewRequest(r)
 
-	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1}})
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{
+		Value:      []interface{}{map[string]interface{}{"resourceName": "foo"}},
+		ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1},
+	})
 
 	request := newReqDynamic(req)
 	defer request.exit()
@@ -113,6 +138,25 @@ func TestListResources(t *testing.T) {
 	}
 }
 
+func TestListResourcesWithSelector(t *testing.T) {
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{})
+	cases := []struct {
+		url    string
+		expect bool
+	}{
+		{"/?labelSelector=app%3Dfoo,tier+notin+(cache)&fieldSelector=data.status%3DRunning", true},
+		{"/?labelSelector=app+in+(foo", false},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest("GET", c.url, nil)
+		request := newReqDynamic(restful.NewRequest(r))
+		if _, err := request.csList(); (err == nil) != c.expect {
+			t.Errorf("csList() with %s expect success %v, got err: %v", c.url, c.expect, err)
+		}
+		request.exit()
+	}
+}
+
 func TestDeleteBatchResource(t *testing.T) {
 	expect := operator.M{
 		"and": []interface{}{
@@ -129,7 +173,10 @@ func TestDeleteBatchResource(t *testing.T) {
 	r, _ := http.NewRequest("DELETE", "/", ioutil.NopCloser(strings.NewReader("{\"updateTimeBegin\":1516849200,\"updateTimeEnd\":1516849201}")))
 	req := restful.NewRequest(r)
 
-	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1}})
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{
+		Value:      []interface{}{map[string]interface{}{"resourceName": "foo"}},
+		ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1},
+	})
 
 	request := newReqDynamic(req)
 	defer request.exit()
@@ -145,7 +192,10 @@ func TestDeleteBatchResource(t *testing.T) {
 	r, _ = http.NewRequest("DELETE", "/", ioutil.NopCloser(strings.NewReader("{\"updateTimeBegin\":1516849200,\"updateTimeEnd\":1516849201}")))
 	req = restful.NewRequest(r)
 
-	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1}})
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{
+		Value:      []interface{}{map[string]interface{}{"resourceName": "foo"}},
+		ChangeInfo: &operator.ChangeInfo{Matched: 1, Removed: 1},
+	})
 
 	request = newReqDynamic(req)
 	defer request.exit()
@@ -0,0 +1,209 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package dynamicQuery
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"bk-bcs/bcs-common/common"
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-services/bcs-storage/storage/actions/lib"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	"github.com/emicklei/go-restful"
+)
+
+const (
+	resourceTypeTag = "resourceType"
+	groupByTag      = "groupBy"
+	aggregationTag  = "aggregation"
+	orderByTag      = "orderBy"
+)
+
+type aggregationResource struct {
+	newFilter func() qFilter
+	name      string
+}
+
+// resources can be aggregated, the same filters are used as query
+var (
+	mesosAggregationResources = map[string]aggregationResource{
+		"taskgroup":     {func() qFilter { return &TaskGroupFilter{} }, "taskgroup"},
+		"application":   {func() qFilter { return &ApplicationFilter{Kind: ",application"} }, "application"},
+		"process":       {func() qFilter { return &ProcessFilter{Kind: "process"} }, "application"},
+		"deployment":    {func() qFilter { return &DeploymentFilter{} }, "deployment"},
+		"job":           {func() qFilter { return &JobMesosFilter{} }, "job"},
+		"service":       {func() qFilter { return &ServiceFilter{} }, "service"},
+		"configmap":     {func() qFilter { return &ConfigMapFilter{} }, "configmap"},
+		"secret":        {func() qFilter { return &SecretFilter{} }, "secret"},
+		"endpoints":     {func() qFilter { return &EndpointsFilter{} }, "endpoint"},
+		"exportservice": {func() qFilter { return &ExportServiceFilter{} }, "exportservice"},
+	}
+	k8sAggregationResources = map[string]aggregationResource{
+		"pod":         {func() qFilter { return &PodFilter{} }, "Pod"},
+		"replicaset":  {func() qFilter { return &ReplicaSetFilter{} }, "ReplicaSet"},
+		"deployment":  {func() qFilter { return &DeploymentK8sFilter{} }, "Deployment"},
+		"service":     {func() qFilter { return &ServiceK8sFilter{} }, "Service"},
+		"configmap":   {func() qFilter { return &ConfigMapK8sFilter{} }, "ConfigMap"},
+		"secret":      {func() qFilter { return &SecretK8sFilter{} }, "Secret"},
+		"endpoints":   {func() qFilter { return &EndpointsK8sFilter{} }, "EndPoints"},
+		"ingress":     {func() qFilter { return &IngressFilter{} }, "Ingress"},
+		"namespace":   {func() qFilter { return &NameSpaceFilter{} }, "Namespace"},
+		"node":        {func() qFilter { return &NodeFilter{} }, "Node"},
+		"daemonset":   {func() qFilter { return &DaemonSetFilter{} }, "DaemonSet"},
+		"job":         {func() qFilter { return &JobFilter{} }, "Job"},
+		"statefulset": {func() qFilter { return &StatefulSetFilter{} }, "StatefulSet"},
+	}
+)
+
+func doAggregate(req *restful.Request, resp *restful.Response, resources map[string]aggregationResource) {
+	resource, ok := resources[req.PathParameter(resourceTypeTag)]
+	if !ok {
+		blog.Errorf("%s | err: unknown resource type %s", common.BcsErrStorageListResourceFailStr, req.PathParameter(resourceTypeTag))
+		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageListResourceFail, Message: common.BcsErrStorageListResourceFailStr})
+		return
+	}
+
+	request := newReqDynamic(req, resource.newFilter(), resource.name)
+	defer request.exit()
+	r, err := request.aggregateDynamic()
+	if err != nil {
+		blog.Errorf("%s | err: %v", common.BcsErrStorageListResourceFailStr, err)
+		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageListResourceFail, Message: common.BcsErrStorageListResourceFailStr})
+		return
+	}
+	lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: r})
+}
+
+func AggregateMesos(req *restful.Request, resp *restful.Response) {
+	doAggregate(req, resp, mesosAggregationResources)
+}
+
+func AggregateK8s(req *restful.Request, resp *restful.Response) {
+	doAggregate(req, resp, k8sAggregationResources)
+}
+
+// aggregateDynamic group the resources matched by filter, and return the group values with aggregations.
+// The fields can be the json names of filter or the keys of documents like "data.status".
+func (rd *reqDynamic) aggregateDynamic() ([]interface{}, error) {
+	if err := rd.generateFilter(); err != nil {
+		return nil, err
+	}
+	fields := filterFields(rd.filter)
+	field := func(name string) string {
+		if key, ok := fields[name]; ok {
+			return key
+		}
+		return name
+	}
+
+	groups := splitParam(rd.getParam(groupByTag))
+	keys := make([]string, 0, len(groups))
+	for _, name := range groups {
+		keys = append(keys, field(name))
+	}
+	aggregations, err := parseAggregations(rd.getParam(aggregationTag), field)
+	if err != nil {
+		return nil, err
+	}
+
+	// orders are the names of groups and aggregations, the names of groups are replaced by the keys
+	orders := splitParam(rd.getParam(orderByTag))
+	for i, order := range orders {
+		name := strings.TrimPrefix(order, "-")
+		for j, group := range groups {
+			if group == name {
+				orders[i] = strings.TrimSuffix(order, name) + keys[j]
+				break
+			}
+		}
+	}
+
+	tank := rd.tank.From(rd.getTable()).Filter(rd.getFeat()).GroupBy(keys...).OrderBy(orders...).
+		Offset(rd.getOffset()).Limit(rd.getLimit()).Aggregate(aggregations...)
+	if err = tank.GetError(); err != nil {
+		blog.Errorf("Failed to aggregate. err: %v", err)
+		return nil, err
+	}
+
+	r := tank.GetValue()
+	for _, item := range r {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for i, name := range groups {
+			if name == keys[i] {
+				continue
+			}
+			m[name] = m[keys[i]]
+			delete(m, keys[i])
+		}
+	}
+	return r, nil
+}
+
+// parseAggregations parse the aggregations like "count,sum:replicas,max:restartCount", count is the default.
+// The aggregation is named by itself in the results.
+func parseAggregations(raw string, field func(string) string) ([]*operator.Aggregation, error) {
+	items := splitParam(raw)
+	if len(items) == 0 {
+		items = []string{string(operator.AggCount)}
+	}
+	r := make([]*operator.Aggregation, 0, len(items))
+	for _, item := range items {
+		f, name := item, ""
+		if i := strings.Index(item, ":"); i >= 0 {
+			f, name = item[:i], item[i+1:]
+		}
+		aggregation := operator.NewAggregation(item, operator.AggregateFunc(f), field(name))
+		if !aggregation.IsValid() {
+			return nil, fmt.Errorf("invalid aggregation: %s", item)
+		}
+		r = append(r, aggregation)
+	}
+	return r, nil
+}
+
+// filterFields returns the json names of filter fields with the keys they filter, except the time ranges
+func filterFields(filter qFilter) map[string]string {
+	r := make(map[string]string)
+	typeOf := reflect.Indirect(reflect.ValueOf(filter)).Type()
+	if typeOf.Kind() != reflect.Struct {
+		return r
+	}
+	for i := 0; i < typeOf.NumField(); i++ {
+		f := typeOf.Field(i)
+		tagList := strings.Split(f.Tag.Get("filter"), ",")
+		if tagList[0] == "" || (len(tagList) > 1 && strings.HasPrefix(tagList[1], "time")) {
+			continue
+		}
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" {
+			r[name] = tagList[0]
+		}
+	}
+	return r
+}
+
+func splitParam(raw string) []string {
+	r := make([]string, 0)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			r = append(r, item)
+		}
+	}
+	return r
+}
@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package dynamicQuery
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestFilterFields(t *testing.T) {
+	expect := map[string]string{
+		"baseInField": "base.in.field",
+		"intField":    "int.field",
+		"int64Field":  "int64.field",
+		"boolField":   "bool.field",
+	}
+	if r := filterFields(&mockFilter{}); !reflect.DeepEqual(r, expect) {
+		t.Errorf("filterFields() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+}
+
+func TestParseAggregations(t *testing.T) {
+	field := func(name string) string {
+		if name == "intField" {
+			return "int.field"
+		}
+		return name
+	}
+	expect := []*operator.Aggregation{
+		{Name: "count", Func: operator.AggCount},
+		{Name: "sum:intField", Func: operator.AggSum, Field: "int.field"},
+		{Name: "max:data.restartCount", Func: operator.AggMax, Field: "data.restartCount"},
+	}
+	if r, err := parseAggregations("count, sum:intField,,max:data.restartCount", field); err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("parseAggregations() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+	if r, err := parseAggregations("", field); err != nil || !reflect.DeepEqual(r, expect[:1]) {
+		t.Errorf("parseAggregations() default failed! \nresult:\n%v\nerr:\n%v\n", r, err)
+	}
+	for _, raw := range []string{"sum", "median:intField"} {
+		if _, err := parseAggregations(raw, field); err == nil {
+			t.Errorf("parseAggregations(%s) should fail", raw)
+		}
+	}
+}
+
+func TestAggregateDynamic(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/?boolField=true&groupBy=baseInField,data.status&aggregation=count&orderBy=-count,baseInField", nil)
+	req := restful.NewRequest(r)
+
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{Value: []interface{}{
+		map[string]interface{}{"base.in.field": "a", "data.status": "Running", "count": float64(2)},
+	}})
+
+	request := newReqDynamic(req, &mockFilter{}, "hi")
+	defer request.exit()
+
+	result, err := request.aggregateDynamic()
+	expect := []interface{}{
+		map[string]interface{}{"baseInField": "a", "data.status": "Running", "count": float64(2)},
+	}
+	if err != nil || !reflect.DeepEqual(result, expect) {
+		t.Errorf("aggregateDynamic() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", result, expect, err)
+	}
+
+	condition := operator.MockCombineCondition(request.condition)
+	if c := (operator.M{"bool.field": true}); !reflect.DeepEqual(condition, c) {
+		t.Errorf("aggregateDynamic() failed! \ncondition:\n%v\nexpect:\n%v\n", condition, c)
+	}
+}
@@ -96,6 +96,13 @@ func GetNameSpace(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	// grep job
+	if result, err = grepNamespace(req, &JobMesosFilter{}, "job", result); err != nil {
+		blog.Errorf("%s | err: %v", common.BcsErrStorageListResourceFailStr, err)
+		lib.ReturnRest(&lib.RestResponse{Resp: resp, Data: []string{}, ErrCode: common.BcsErrStorageListResourceFail, Message: common.BcsErrStorageListResourceFailStr})
+		return
+	}
+
 	// grep service
 	if result, err = grepNamespace(req, &ServiceFilter{}, "service", result); err != nil {
 		blog.Errorf("%s | err: %v", common.BcsErrStorageListResourceFailStr, err)
@@ -136,6 +143,10 @@ func GetDeployment(req *restful.Request, resp *restful.Response) {
 	doQuery(req, resp, &DeploymentFilter{}, "deployment")
 }
 
+func GetJobMesos(req *restful.Request, resp *restful.Response) {
+	doQuery(req, resp, &JobMesosFilter{}, "job")
+}
+
 func GetService(req *restful.Request, resp *restful.Response) {
 	doQuery(req, resp, &ServiceFilter{}, "service")
 }
@@ -291,6 +302,7 @@ func init() {
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/application"), Params: nil, Handler: lib.MarkProcess(GetApplication)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/process"), Params: nil, Handler: lib.MarkProcess(GetProcess)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/deployment"), Params: nil, Handler: lib.MarkProcess(GetDeployment)})
+	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/job"), Params: nil, Handler: lib.MarkProcess(GetJobMesos)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/service"), Params: nil, Handler: lib.MarkProcess(GetService)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/configmap"), Params: nil, Handler: lib.MarkProcess(GetConfigMap)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/secret"), Params: nil, Handler: lib.MarkProcess(GetSecret)})
@@ -312,11 +324,15 @@ func init() {
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/job"), Params: nil, Handler: lib.MarkProcess(GetJob)})
 	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/statefulset"), Params: nil, Handler: lib.MarkProcess(GetStatefulSet)})
 
+	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/{resourceType}/aggregation"), Params: nil, Handler: lib.MarkProcess(AggregateMesos)})
+	actions.RegisterV1Action(actions.Action{Verb: "GET", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/{resourceType}/aggregation"), Params: nil, Handler: lib.MarkProcess(AggregateK8s)})
+
 	// POST
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/taskgroup"), Params: nil, Handler: lib.MarkProcess(GetTaskGroup)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/application"), Params: nil, Handler: lib.MarkProcess(GetApplication)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/process"), Params: nil, Handler: lib.MarkProcess(GetProcess)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/deployment"), Params: nil, Handler: lib.MarkProcess(GetDeployment)})
+	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/job"), Params: nil, Handler: lib.MarkProcess(GetJobMesos)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/service"), Params: nil, Handler: lib.MarkProcess(GetService)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/configmap"), Params: nil, Handler: lib.MarkProcess(GetConfigMap)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/secret"), Params: nil, Handler: lib.MarkProcess(GetSecret)})
@@ -336,4 +352,7 @@ func init() {
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/daemonset"), Params: nil, Handler: lib.MarkProcess(GetDaemonSet)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/job"), Params: nil, Handler: lib.MarkProcess(GetJob)})
 	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/statefulset"), Params: nil, Handler: lib.MarkProcess(GetStatefulSet)})
+
+	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/mesos/dynamic/clusters/{clusterId}/{resourceType}/aggregation"), Params: nil, Handler: lib.MarkProcess(AggregateMesos)})
+	actions.RegisterV1Action(actions.Action{Verb: "POST", Path: urlPath("/k8s/dynamic/clusters/{clusterId}/{resourceType}/aggregation"), Params: nil, Handler: lib.MarkProcess(AggregateK8s)})
 }
@@ -72,3 +72,36 @@ func TestDoQuery(t *testing.T) {
 		t.Errorf("queryDynamic() failed! \nquery_condition:\n%v\nexpect:\n%v\n", condition, expect)
 	}
 }
+
+func TestDoQueryWithSelector(t *testing.T) {
+	expect := operator.M{"and": []interface{}{
+		operator.M{"bool.field": true},
+		operator.M{"and": []interface{}{
+			operator.M{"data.metadata.labels.app": "foo"},
+			operator.M{"in": operator.M{"data.status": []string{"Running", "Staging"}}},
+		}},
+	}}
+	r, _ := http.NewRequest("GET", "/?boolField=true&labelSelector=app%3Dfoo&fieldSelector=data.status+in+(Running,Staging)", nil)
+	req := restful.NewRequest(r)
+
+	getNewTank = operator.GetMockTankNewFunc(&operator.MockTank{})
+
+	request := newReqDynamic(req, &mockFilter{}, "hi")
+	defer request.exit()
+
+	if _, err := request.queryDynamic(); err != nil {
+		t.Errorf("queryDynamic() failed! err: %v", err)
+	}
+
+	condition := operator.MockCombineCondition(request.condition)
+	if !reflect.DeepEqual(condition, expect) {
+		t.Errorf("queryDynamic() failed! \nquery_condition:\n%v\nexpect:\n%v\n", condition, expect)
+	}
+
+	r, _ = http.NewRequest("GET", "/?labelSelector=app+in+()", nil)
+	request = newReqDynamic(restful.NewRequest(r), &mockFilter{}, "hi")
+	defer request.exit()
+	if _, err := request.queryDynamic(); err == nil {
+		t.Errorf("queryDynamic() with invalid selector should fail")
+	}
+}
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package dynamicQuery
+
+import (
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+type JobMesosFilter struct {
+	ClusterId           string `json:"clusterId" filter:"clusterId"`
+	Name                string `json:"name,omitempty" filter:"resourceName"`
+	Namespace           string `json:"namespace,omitempty" filter:"namespace"`
+	Status              string `json:"status,omitempty" filter:"data.status"`
+	CheckTime           string `json:"checkTime,omitempty" filter:"data.check_time,int64"`
+	StartTimeBegin      string `json:"startTimeBegin,omitempty" filter:"data.start_time,timeL"`
+	StartTimeEnd        string `json:"startTimeEnd,omitempty" filter:"data.start_time,timeR"`
+	CompletionTimeBegin string `json:"completionTimeBegin,omitempty" filter:"data.completion_time,timeL"`
+	CompletionTimeEnd   string `json:"completionTimeEnd,omitempty" filter:"data.completion_time,timeR"`
+}
+
+// the start and completion time of mesos job are unix seconds
+const jobMesosNestedTimeLayout = timestampsLayout
+
+func (t JobMesosFilter) getCondition() *operator.Condition {
+	return qGenerate(t, jobMesosNestedTimeLayout)
+}
@@ -43,6 +43,9 @@ type reqDynamic struct {
 	features  operator.M
 	data      operator.M
 	body      operator.M
+
+	// condition of label selector and field selector, nil if not provided
+	selectorCondition *operator.Condition
 }
 
 // get a new instance of reqDynamic, getNewTank() will be called and
@@ -166,6 +169,9 @@ func (rd *reqDynamic) getFeat() *operator.Condition {
 		if len(features) > 0 {
 			r = r.And(operator.NewCondition(operator.In, features))
 		}
+		if rd.selectorCondition != nil {
+			r = r.And(rd.selectorCondition)
+		}
 		rd.condition = r
 	}
 
@@ -177,6 +183,11 @@ func (rd *reqDynamic) generateFilter() error {
 		if err := codec.DecJson(rd.getQueryParamJson(), &(rd.filter)); err != nil {
 			return err
 		}
+		selector, err := lib.SelectorCondition(rd.getParam(lib.LabelSelectorTag), rd.getParam(lib.FieldSelectorTag))
+		if err != nil {
+			return err
+		}
+		rd.selectorCondition = selector
 		rd.isGen = true
 	}
 	return nil
@@ -23,7 +23,9 @@ import (
 
 const (
 	clusterIdTag    = "clusterId"
+	namespaceTag    = "namespace"
 	resourceTypeTag = "resourceType"
+	resourceNameTag = "resourceName"
 	tableTag        = resourceTypeTag
 
 	mesosType = "taskgroup"
@@ -32,6 +34,9 @@ const (
 
 var (
 	containerTypeList = [...]string{mesosType, k8sType}
+
+	// keys identifying a resource in the tables of dynamic actions
+	indexKeys = []string{resourceNameTag, namespaceTag}
 )
 
 // Use Mongodb for storage.
@@ -39,6 +44,9 @@ const dbConfig = "dynamic"
 
 var getNewTank operator.GetNewTank = lib.GetMongodbTank(dbConfig)
 
+// history of the changes recorded by dynamic actions, for watching from a resource version
+var history = lib.SharedHistory(dbConfig, func() operator.Tank { return getNewTank() })
+
 func WatchDynamic(req *restful.Request, resp *restful.Response) {
 	request := newReqDynamic(req, resp)
 	request.watch()
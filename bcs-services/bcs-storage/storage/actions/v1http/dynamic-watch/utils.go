@@ -45,6 +45,11 @@ func (rd *reqDynamic) getFeat() *operator.Condition {
 	return operator.BaseCondition
 }
 
+// getSelectorFeat returns the condition of selectors in url parameters, nil if no selector
+func (rd *reqDynamic) getSelectorFeat() (*operator.Condition, error) {
+	return lib.SelectorCondition(rd.req.QueryParameter(lib.LabelSelectorTag), rd.req.QueryParameter(lib.FieldSelectorTag))
+}
+
 func (rd *reqDynamic) getTable() string {
 	if rd.table == "" {
 		rd.table = rd.req.PathParameter(clusterIdTag) + "_" + rd.req.PathParameter(tableTag)
@@ -53,6 +58,13 @@ func (rd *reqDynamic) getTable() string {
 }
 
 func (rd *reqDynamic) watch() {
+	selector, err := rd.getSelectorFeat()
+	if err != nil {
+		blog.Errorf("dynamic watch failed: %v", err)
+		rd.resp.Write(operator.EventWatchBreakBytes)
+		return
+	}
+
 	tank := rd.tank.From(rd.getTable()).Filter(rd.getFeat())
 	ws, err := lib.NewWatchServer(rd.req, rd.resp, tank)
 	if err != nil {
@@ -61,10 +73,17 @@ func (rd *reqDynamic) watch() {
 		return
 	}
 
-	ws.Go(context.Background())
+	ws.WithHistory(history, rd.getTable()).WithCondition(selector).WithIdentity(indexKeys...).Go(context.Background())
 }
 
 func (rd *reqDynamic) watchContainer() {
+	selector, err := rd.getSelectorFeat()
+	if err != nil {
+		blog.Errorf("dynamic container watch failed: %v", err)
+		rd.resp.Write(operator.EventWatchBreakBytes)
+		return
+	}
+
 	tableTank := rd.tank.Tables()
 	if err := tableTank.GetError(); err != nil {
 		blog.Errorf("dynamic container watch failed: %v", err)
@@ -100,7 +119,7 @@ func (rd *reqDynamic) watchContainer() {
 		return
 	}
 
-	ws.Go(context.Background())
+	ws.WithHistory(history, table).WithCondition(selector).WithIdentity(indexKeys...).Go(context.Background())
 }
 
 func inList(s string, l []interface{}) bool {
@@ -28,6 +28,7 @@ import (
 	"bk-bcs/bcs-services/bcs-storage/app/options"
 	"bk-bcs/bcs-services/bcs-storage/storage/actions"
 	"bk-bcs/bcs-services/bcs-storage/storage/drivers/mongodb"
+	"bk-bcs/bcs-services/bcs-storage/storage/drivers/mysql"
 	"bk-bcs/bcs-services/bcs-storage/storage/drivers/zookeeper"
 	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
 	"bk-bcs/bcs-services/bcs-storage/storage/operator"
@@ -38,6 +39,7 @@ import (
 const (
 	configKeySep     = "/"
 	mongodbConfigKey = "mongodb"
+	mysqlConfigKey   = "mysql"
 	zkConfigKey      = "zk"
 )
 
@@ -80,6 +82,10 @@ func (a *APIResource) SetConfig(op *options.StorageOptions) {
 			if err = a.parseMongodb(key, dbConfig); err != nil {
 				SetUnhealthy(mongodbConfigKey, err.Error())
 			}
+		case mysqlConfigKey:
+			if err = a.parseMysql(key, dbConfig); err != nil {
+				SetUnhealthy(mysqlConfigKey, err.Error())
+			}
 		case zkConfigKey:
 			if err = a.parseZk(key, dbConfig); err != nil {
 				SetUnhealthy(zkConfigKey, err.Error())
@@ -116,6 +122,16 @@ func (a *APIResource) GetMongodbTankName(name string) string {
 	return getDriverName(mongodbConfigKey, name)
 }
 
+func (a *APIResource) GetMysqlTankName(name string) string {
+	return getDriverName(mysqlConfigKey, name)
+}
+
+// HasMysqlConfig returns whether the database is configured as mysql/name
+func (a *APIResource) HasMysqlConfig(name string) bool {
+	_, ok := a.dbInfoMap[a.GetMysqlTankName(name)]
+	return ok
+}
+
 func (a *APIResource) GetZkTankName(name string) string {
 	return getDriverName(zkConfigKey, name)
 }
@@ -194,6 +210,58 @@ func (a *APIResource) parseMongodb(key string, dbConf *conf.Config) (err error)
 	return err
 }
 
+func (a *APIResource) parseMysql(key string, dbConf *conf.Config) (err error) {
+	address := dbConf.Read(key, "Addr")
+	timeoutRaw := dbConf.Read(key, "ConnectTimeout")
+	timeout, _ := strconv.Atoi(timeoutRaw)
+	database := dbConf.Read(key, "Database")
+	schema := dbConf.Read(key, "Schema")
+	username := dbConf.Read(key, "Username")
+	password := dbConf.Read(key, "Password")
+	maxOpenConn, _ := strconv.Atoi(dbConf.Read(key, "MaxOpenConn"))
+	maxIdleConn, _ := strconv.Atoi(dbConf.Read(key, "MaxIdleConn"))
+
+	if password != "" {
+		realPwd, _ := encrypt.DesDecryptFromBase([]byte(password))
+		password = string(realPwd)
+	}
+
+	a.dbInfoMap[key] = &operator.DBInfo{
+		Addr:           strings.Split(address, ","),
+		ConnectTimeout: time.Second * time.Duration(timeout),
+		Database:       database,
+		Schema:         schema,
+		Username:       username,
+		Password:       password,
+		MaxOpenConn:    maxOpenConn,
+		MaxIdleConn:    maxIdleConn,
+	}
+
+	if !runWithTimeout(
+		func() {
+			if err = mysql.RegisterMysqlTank(key, a.dbInfoMap[key]); err != nil {
+				blog.Errorf("register db config failed: %s | %v", key, err)
+			}
+		},
+		2*time.Second,
+	) {
+		blog.Errorf("register db config timeout: %s", key)
+		return fmt.Errorf("db connect timeout: %s", key)
+	}
+
+	if err != nil {
+		return
+	}
+
+	// check db connect and ping.
+	if err = mysql.NewMysqlTank(key).GetError(); err == nil {
+		blog.Infof("Complete parse mysql config: %s", key)
+	} else {
+		blog.Errorf("Check db config failed: %s | %v", key, err)
+	}
+	return err
+}
+
 func (a *APIResource) parseZk(key string, dbConf *conf.Config) (err error) {
 	address := dbConf.Read(key, "Addr")
 	timeoutRaw := dbConf.Read(key, "ConnectTimeout")
@@ -0,0 +1,126 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongodb
+
+import (
+	"strconv"
+	"strings"
+
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// keys of group values in _id and aggregation values in the results of $group,
+	// the group keys and aggregation names may contain "." or "$" which mongodb does not accept
+	groupKeyPrefix       = "g"
+	aggregationKeyPrefix = "a"
+)
+
+var aggregateOperators = map[operator.AggregateFunc]string{
+	operator.AggCount: "$sum",
+	operator.AggSum:   "$sum",
+	operator.AggAvg:   "$avg",
+	operator.AggMin:   "$min",
+	operator.AggMax:   "$max",
+}
+
+// getPipeline returns the aggregation pipeline: $match -> $group -> $sort -> $skip -> $limit
+func (s *search) getPipeline(aggregations []*operator.Aggregation) ([]bson.M, error) {
+	id := bson.D{}
+	for i, key := range s.groups {
+		id = append(id, bson.DocElem{Name: groupKeyPrefix + strconv.Itoa(i), Value: "$" + key})
+	}
+	group := bson.M{"_id": id}
+	if len(id) == 0 {
+		group["_id"] = nil
+	}
+	for i, aggregation := range aggregations {
+		if !aggregation.IsValid() {
+			return nil, storageErr.AggregationInvalid
+		}
+		var value interface{} = "$" + aggregation.Field
+		if aggregation.Func == operator.AggCount {
+			value = 1
+		}
+		group[aggregationKeyPrefix+strconv.Itoa(i)] = bson.M{aggregateOperators[aggregation.Func]: value}
+	}
+
+	pipeline := []bson.M{
+		{"$match": s.getRawCond()},
+		{"$group": group},
+		{"$sort": s.getGroupOrder(aggregations)},
+	}
+	if s.offset > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": s.offset})
+	}
+	if s.limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": s.limit})
+	}
+	return pipeline, nil
+}
+
+// getGroupOrder returns the $sort of groups, the orders are the group keys or aggregation names,
+// and the groups are always sorted by group keys at last
+func (s *search) getGroupOrder(aggregations []*operator.Aggregation) bson.D {
+	keys := make(map[string]string, len(s.groups)+len(aggregations))
+	for i, key := range s.groups {
+		keys[key] = "_id." + groupKeyPrefix + strconv.Itoa(i)
+	}
+	for i, aggregation := range aggregations {
+		keys[aggregation.Name] = aggregationKeyPrefix + strconv.Itoa(i)
+	}
+
+	order := bson.D{}
+	used := make(map[string]bool)
+	for _, key := range s.orders {
+		direction := 1
+		if strings.HasPrefix(key, "-") {
+			direction = -1
+			key = key[1:]
+		}
+		if field, ok := keys[key]; ok && !used[field] {
+			used[field] = true
+			order = append(order, bson.DocElem{Name: field, Value: direction})
+		}
+	}
+	for i := range s.groups {
+		if field := "_id." + groupKeyPrefix + strconv.Itoa(i); !used[field] {
+			order = append(order, bson.DocElem{Name: field, Value: 1})
+		}
+	}
+	if len(order) == 0 {
+		order = append(order, bson.DocElem{Name: "_id", Value: 1})
+	}
+	return order
+}
+
+// aggregateResult convert the results of $group to the group keys and aggregation names
+func aggregateResult(groups []string, aggregations []*operator.Aggregation, docs []bson.M) []interface{} {
+	r := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		id, _ := doc["_id"].(bson.M)
+		item := make(map[string]interface{}, len(groups)+len(aggregations))
+		for i, key := range groups {
+			item[key] = id[groupKeyPrefix+strconv.Itoa(i)]
+		}
+		for i, aggregation := range aggregations {
+			item[aggregation.Name] = doc[aggregationKeyPrefix+strconv.Itoa(i)]
+		}
+		r = append(r, item)
+	}
+	return r
+}
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongodb
+
+import (
+	"reflect"
+	"testing"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestGetPipeline(t *testing.T) {
+	s := (&search{}).clone().setGroups("namespace", "data.status").setOrder("-count", "data.status").setOffset(5).setLimit(10)
+	s.combineCondition(operator.BaseCondition.AddOp(operator.Eq, "clusterId", "c1"))
+	aggregations := []*operator.Aggregation{
+		operator.NewAggregation("", operator.AggCount, ""),
+		operator.NewAggregation("", operator.AggAvg, "data.restartCount"),
+	}
+	expect := []bson.M{
+		{"$match": bson.M{"clusterId": "c1"}},
+		{"$group": bson.M{
+			"_id": bson.D{{Name: "g0", Value: "$namespace"}, {Name: "g1", Value: "$data.status"}},
+			"a0":  bson.M{"$sum": 1},
+			"a1":  bson.M{"$avg": "$data.restartCount"},
+		}},
+		{"$sort": bson.D{{Name: "a0", Value: -1}, {Name: "_id.g1", Value: 1}, {Name: "_id.g0", Value: 1}}},
+		{"$skip": 5},
+		{"$limit": 10},
+	}
+	if r, err := s.getPipeline(aggregations); err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("getPipeline() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+
+	if _, err := s.getPipeline([]*operator.Aggregation{{Name: "sum", Func: operator.AggSum}}); err == nil {
+		t.Errorf("getPipeline() with invalid aggregation should fail")
+	}
+}
+
+func TestGetPipelineWithoutGroups(t *testing.T) {
+	s := (&search{limit: -1}).clone()
+	expect := []bson.M{
+		{"$match": bson.M{}},
+		{"$group": bson.M{"_id": nil, "a0": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Name: "_id", Value: 1}}},
+	}
+	if r, err := s.getPipeline([]*operator.Aggregation{operator.NewAggregation("", operator.AggCount, "")}); err != nil || !reflect.DeepEqual(r, expect) {
+		t.Errorf("getPipeline() failed! \nresult:\n%v\nexpect:\n%v\nerr:\n%v\n", r, expect, err)
+	}
+}
+
+func TestAggregateResult(t *testing.T) {
+	groups := []string{"namespace", "data.status"}
+	aggregations := []*operator.Aggregation{operator.NewAggregation("", operator.AggCount, "")}
+	docs := []bson.M{
+		{"_id": bson.M{"g0": "default", "g1": "Running"}, "a0": 3},
+		{"_id": bson.M{"g0": "default"}, "a0": 1},
+	}
+	expect := []interface{}{
+		map[string]interface{}{"namespace": "default", "data.status": "Running", "count": 3},
+		map[string]interface{}{"namespace": "default", "data.status": nil, "count": 1},
+	}
+	if r := aggregateResult(groups, aggregations, docs); !reflect.DeepEqual(r, expect) {
+		t.Errorf("aggregateResult() failed! \nresult:\n%v\nexpect:\n%v\n", r, expect)
+	}
+}
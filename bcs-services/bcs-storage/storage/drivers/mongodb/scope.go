@@ -82,6 +82,10 @@ func (s *scope) do() *scope {
 		s.doDatabases()
 	case operator.Tail:
 		s.doTail()
+	case operator.Aggregate:
+		s.doAggregate()
+	case operator.Increase:
+		s.doIncrease()
 	case operator.GetTableV:
 		s.err = storageErr.GetTableVNotSupported
 	case operator.SetTableV:
@@ -113,6 +117,25 @@ func (s *scope) doQuery() {
 	s.length = len(s.value)
 }
 
+// Do the aggregate action with aggregation pipeline, save result to scope.value and scope.err
+func (s *scope) doAggregate() {
+	if s.tank.collection == nil {
+		s.err = storageErr.MongodbCollectionNoFound
+		return
+	}
+	pipeline, err := s.tank.search.getPipeline(s.tank.aggregations)
+	if err != nil {
+		s.err = err
+		return
+	}
+	var docs []bson.M
+	if s.err = s.tank.collection.Pipe(pipeline).AllowDiskUse().All(&docs); s.err != nil {
+		return
+	}
+	s.value = aggregateResult(s.tank.search.groups, s.tank.aggregations, docs)
+	s.length = len(s.value)
+}
+
 // Do the tail action, save mgo.Iter to scope.iter for return
 func (s *scope) doTail() {
 	if s.doFilter(); s.err != nil {
@@ -198,6 +221,25 @@ func (s *scope) doUpdate(all bool) {
 	}
 }
 
+// Do the increase action with findAndModify, save the document after increasing to scope.value
+func (s *scope) doIncrease() {
+	if err := s.ensureIndex(); err != nil {
+		s.err = err
+		return
+	}
+
+	rawCond := s.tank.search.getRawCond()
+	change := mgo.Change{Update: bson.M{"$inc": s.tank.data[0]}, Upsert: true, ReturnNew: true}
+	var doc bson.M
+	var info *mgo.ChangeInfo
+	if info, s.err = s.tank.collection.Find(rawCond).Apply(change, &doc); s.err != nil {
+		return
+	}
+	s.changeInfo = &operator.ChangeInfo{Updated: info.Updated, Matched: info.Matched}
+	s.value = []interface{}{doc}
+	s.length = 1
+}
+
 // Do the remove action
 func (s *scope) doRemove(all bool) {
 	if s.tank.collection == nil {
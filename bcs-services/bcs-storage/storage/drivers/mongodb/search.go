@@ -34,6 +34,7 @@ type search struct {
 	offset   int
 	limit    int
 	selector bson.M
+	groups   []string
 }
 
 func (s *search) clone() *search {
@@ -45,6 +46,7 @@ func (s *search) clone() *search {
 		offset:     s.offset,
 		limit:      s.limit,
 		selector:   s.selector,
+		groups:     s.groups,
 	}
 	if s.condition == nil {
 		ns.condition = operator.BaseCondition
@@ -87,6 +89,11 @@ func (s *search) setSelector(key ...string) *search {
 	return s
 }
 
+func (s *search) setGroups(key ...string) *search {
+	s.groups = key
+	return s
+}
+
 func (s *search) setOffset(offset int) *search {
 	s.offset = offset
 	return s
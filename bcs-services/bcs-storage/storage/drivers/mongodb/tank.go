@@ -95,8 +95,9 @@ type mongoTank struct {
 	scope      *scope
 	index      []string
 
-	data []interface{}
-	err  error
+	data         []interface{}
+	aggregations []*operator.Aggregation
+	err          error
 }
 
 func (mt *mongoTank) init(name string) error {
@@ -193,6 +194,11 @@ func (mt *mongoTank) setData(data ...operator.M) *mongoTank {
 	return mt
 }
 
+func (mt *mongoTank) setAggregations(aggregations ...*operator.Aggregation) *mongoTank {
+	mt.aggregations = aggregations
+	return mt
+}
+
 func (mt *mongoTank) Tail() *mgo.Iter {
 	return mt.clone().newScope(operator.Tail).iter
 }
@@ -308,6 +314,11 @@ func (mt *mongoTank) Filter(cond *operator.Condition, args ...interface{}) opera
 	return mt.clone().search.combineCondition(cond).tank
 }
 
+// GroupBy set group keys, will no reach db until Aggregate() called
+func (mt *mongoTank) GroupBy(key ...string) operator.Tank {
+	return mt.clone().search.setGroups(key...).tank
+}
+
 // Count the data length according to filters before
 func (mt *mongoTank) Count() operator.Tank {
 	return mt.clone().newScope(operator.Count).tank
@@ -348,6 +359,16 @@ func (mt *mongoTank) RemoveAll(args ...interface{}) operator.Tank {
 	return mt.clone().newScope(operator.RemoveAll).tank
 }
 
+// Aggregate the groups according to filters before
+func (mt *mongoTank) Aggregate(aggregations ...*operator.Aggregation) operator.Tank {
+	return mt.clone().setAggregations(aggregations...).newScope(operator.Aggregate).tank
+}
+
+// Increase the number in key of first match according to filters before, or insert it if no match
+func (mt *mongoTank) Increase(key string, n int64) operator.Tank {
+	return mt.clone().setData(operator.M{key: n}).newScope(operator.Increase).tank
+}
+
 // Watch make a watch to collections and its documents, then return a chan Event.
 func (mt *mongoTank) Watch(opts *operator.WatchOptions) (chan *operator.Event, context.CancelFunc) {
 	return newWatchHandler(opts, mt).watch()
@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	// aliases of group values and aggregation values in the select list
+	groupKeyPrefix       = "g"
+	aggregationKeyPrefix = "a"
+)
+
+// getAggregateQuery returns the GROUP BY query of aggregations, the group values are selected in json text.
+// Except count, aggregations are done to the numeric values, the others are taken as 0 by mysql.
+func (s *search) getAggregateQuery(table string, aggregations []*operator.Aggregation) (string, []interface{}, error) {
+	d := s.tank.driver.dialect
+	columns := make([]string, 0, len(s.groups)+len(aggregations))
+	groups := make([]string, 0, len(s.groups))
+	keys := make(map[string]string, len(s.groups)+len(aggregations))
+	for i, key := range s.groups {
+		alias := groupKeyPrefix + strconv.Itoa(i)
+		columns = append(columns, d.extractJSON(jsonPath(key))+" AS "+quoteIdent(alias))
+		groups = append(groups, d.extract(jsonPath(key)))
+		keys[key] = groups[i]
+	}
+	for i, aggregation := range aggregations {
+		if !aggregation.IsValid() {
+			return "", nil, storageErr.AggregationInvalid
+		}
+		alias := aggregationKeyPrefix + strconv.Itoa(i)
+		var column string
+		if aggregation.Func == operator.AggCount {
+			column = "COUNT(*)"
+		} else {
+			column = fmt.Sprintf("%s(%s + 0)", strings.ToUpper(string(aggregation.Func)), d.extract(jsonPath(aggregation.Field)))
+		}
+		columns = append(columns, column+" AS "+quoteIdent(alias))
+		keys[aggregation.Name] = quoteIdent(alias)
+	}
+
+	cond := s.getRawCond()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), quoteIdent(table), cond.sql)
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ")
+	}
+	// aggregation without groups returns a row even nothing matches, make it the same as mongodb
+	query += " HAVING COUNT(*) > 0" + s.getGroupOrderBy(keys, groups) + s.getLimit()
+	return query, cond.args, nil
+}
+
+// getGroupOrderBy returns the ORDER BY clause of groups, the orders are the group keys or aggregation names,
+// and the groups are always sorted by group keys at last
+func (s *search) getGroupOrderBy(keys map[string]string, groups []string) string {
+	orders := make([]string, 0, len(s.orders)+len(groups))
+	used := make(map[string]bool)
+	for _, key := range s.orders {
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+			key = key[1:]
+		}
+		if expr, ok := keys[key]; ok && !used[expr] {
+			used[expr] = true
+			orders = append(orders, expr+" "+direction)
+		}
+	}
+	for _, expr := range groups {
+		if !used[expr] {
+			orders = append(orders, expr+" ASC")
+		}
+	}
+	if len(orders) == 0 {
+		return ""
+	}
+	return " ORDER BY " + strings.Join(orders, ", ")
+}
+
+// decodeJSONValue decode the json text of group value, sql NULL means the key does not exist
+func decodeJSONValue(raw interface{}) (interface{}, error) {
+	var data []byte
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return v, nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return decodeValue(value), nil
+}
+
+// decodeNumber convert the aggregation value to int64 for count and float64 for others,
+// drivers may return numbers in text
+func decodeNumber(raw interface{}, integer bool) interface{} {
+	var f float64
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	case []byte:
+		f, _ = strconv.ParseFloat(string(v), 64)
+	case string:
+		f, _ = strconv.ParseFloat(v, 64)
+	default:
+		return v
+	}
+	if integer {
+		return int64(f)
+	}
+	return f
+}
@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	// document tables have two columns: the auto increment id and the json document
+	idColumn   = "_id"
+	dataColumn = "data"
+
+	// mysql error number of creating an index already existed
+	mysqlErrDupKeyName = 1061
+
+	// the unicode of "." in keys
+	keyDotReplacement = "\uff0e"
+)
+
+// dialect hides the differences of json functions and DDL between mysql and sqlite,
+// sqlite is used to run the driver tests without a mysql server
+type dialect interface {
+	// statements creating the document table
+	createTable(table string) []string
+
+	// statements creating the change log table
+	createChangeTable(table string) []string
+
+	// statement listing all tables of current database
+	listTables() string
+
+	// expression of the json path value, can be compared with the value returned by bind()
+	extract(path string) string
+
+	// expression of the json path value in text, for LIKE
+	extractText(path string) string
+
+	// expression of the json path value in json text, for returning the group values of aggregation
+	extractJSON(path string) string
+
+	// condition that the json path exists
+	exists(path string) string
+
+	// condition that the json path does not exist or is null
+	isNull(path string) string
+
+	// placeholder and argument of the value to be compared with extract()
+	bind(v interface{}) (string, interface{})
+
+	// suffix locking the selected rows in transaction
+	forUpdate() string
+
+	// statement creating the unique index of json paths
+	createIndex(table, index string, paths []string) string
+
+	// whether the error is caused by an existing index
+	isIndexExist(err error) bool
+}
+
+// quoteIdent quote the table or column name with backticks, which both mysql and sqlite accept
+func quoteIdent(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// jsonPath convert the dot-separated key to json path, every segment is quoted like $."a"."b".
+// The unicode of "." in key is the "." of segment, which is how the keys with "." are passed for mongodb.
+func jsonPath(key string) string {
+	segments := strings.Split(key, ".")
+	for i, segment := range segments {
+		segment = strings.Replace(segment, keyDotReplacement, ".", -1)
+		segment = strings.Replace(segment, `\`, `\\`, -1)
+		segments[i] = `"` + strings.Replace(segment, `"`, `\"`, -1) + `"`
+	}
+	return "$." + strings.Join(segments, ".")
+}
+
+type mysqlDialect struct{}
+
+func (d mysqlDialect) quote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\x00", `\0`, -1)
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (d mysqlDialect) createTable(table string) []string {
+	return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+		"%s BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, %s JSON NOT NULL"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		quoteIdent(table), quoteIdent(idColumn), quoteIdent(dataColumn))}
+}
+
+func (d mysqlDialect) createChangeTable(table string) []string {
+	return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+		"`id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, `tbl` VARCHAR(255) NOT NULL, "+
+		"`op` VARCHAR(16) NOT NULL, `doc_id` BIGINT NOT NULL, `data` JSON NOT NULL, `created` BIGINT NOT NULL, "+
+		"KEY `idx_tbl_id` (`tbl`, `id`), KEY `idx_created` (`created`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", quoteIdent(table))}
+}
+
+func (d mysqlDialect) listTables() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()"
+}
+
+func (d mysqlDialect) extract(path string) string {
+	return fmt.Sprintf("JSON_EXTRACT(%s, %s)", quoteIdent(dataColumn), d.quote(path))
+}
+
+func (d mysqlDialect) extractText(path string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(%s)", d.extract(path))
+}
+
+func (d mysqlDialect) extractJSON(path string) string {
+	return d.extract(path)
+}
+
+func (d mysqlDialect) exists(path string) string {
+	return fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', %s)", quoteIdent(dataColumn), d.quote(path))
+}
+
+// JSON_EXTRACT returns json null rather than sql NULL for null value
+func (d mysqlDialect) isNull(path string) string {
+	return fmt.Sprintf("COALESCE(JSON_TYPE(%s), 'NULL') = 'NULL'", d.extract(path))
+}
+
+// booleans and objects are compared as json, the others are converted to json scalar by mysql
+func (d mysqlDialect) bind(v interface{}) (string, interface{}) {
+	switch v.(type) {
+	case bool, map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return "CAST(? AS JSON)", string(b)
+	}
+	return "?", v
+}
+
+func (d mysqlDialect) forUpdate() string {
+	return " FOR UPDATE"
+}
+
+// functional key parts need mysql 8.0.13 or later
+func (d mysqlDialect) createIndex(table, index string, paths []string) string {
+	parts := make([]string, 0, len(paths))
+	for _, path := range paths {
+		parts = append(parts, fmt.Sprintf("(CAST(%s AS CHAR(255)))", d.extractText(path)))
+	}
+	return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)",
+		quoteIdent(index), quoteIdent(table), strings.Join(parts, ", "))
+}
+
+func (d mysqlDialect) isIndexExist(err error) bool {
+	mysqlErr, ok := err.(*mysqlDriver.MySQLError)
+	return ok && mysqlErr.Number == mysqlErrDupKeyName
+}
+
+type sqliteDialect struct{}
+
+func (d sqliteDialect) quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (d sqliteDialect) createTable(table string) []string {
+	return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s INTEGER PRIMARY KEY AUTOINCREMENT, %s TEXT NOT NULL)",
+		quoteIdent(table), quoteIdent(idColumn), quoteIdent(dataColumn))}
+}
+
+func (d sqliteDialect) createChangeTable(table string) []string {
+	return []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT, `tbl` TEXT NOT NULL, `op` TEXT NOT NULL, "+
+			"`doc_id` INTEGER NOT NULL, `data` TEXT NOT NULL, `created` INTEGER NOT NULL)", quoteIdent(table)),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (`tbl`, `id`)", quoteIdent(table+"_tbl_id"), quoteIdent(table)),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (`created`)", quoteIdent(table+"_created"), quoteIdent(table)),
+	}
+}
+
+func (d sqliteDialect) listTables() string {
+	return "SELECT name FROM sqlite_master WHERE type = 'table'"
+}
+
+func (d sqliteDialect) extract(path string) string {
+	return fmt.Sprintf("json_extract(%s, %s)", quoteIdent(dataColumn), d.quote(path))
+}
+
+func (d sqliteDialect) extractText(path string) string {
+	return d.extract(path)
+}
+
+// json_extract returns sql values for json scalars
+func (d sqliteDialect) extractJSON(path string) string {
+	return fmt.Sprintf("json_quote(%s)", d.extract(path))
+}
+
+func (d sqliteDialect) exists(path string) string {
+	return fmt.Sprintf("json_type(%s, %s) IS NOT NULL", quoteIdent(dataColumn), d.quote(path))
+}
+
+func (d sqliteDialect) isNull(path string) string {
+	return fmt.Sprintf("%s IS NULL", d.extract(path))
+}
+
+// json_extract returns objects in minified json text, and booleans in 1 or 0
+func (d sqliteDialect) bind(v interface{}) (string, interface{}) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return "json(?)", string(b)
+	}
+	return "?", v
+}
+
+func (d sqliteDialect) forUpdate() string {
+	return ""
+}
+
+func (d sqliteDialect) createIndex(table, index string, paths []string) string {
+	parts := make([]string, 0, len(paths))
+	for _, path := range paths {
+		parts = append(parts, d.extract(path))
+	}
+	return fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)",
+		quoteIdent(index), quoteIdent(table), strings.Join(parts, ", "))
+}
+
+func (d sqliteDialect) isIndexExist(err error) bool {
+	return false
+}
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	// time values are stored in UTC with fixed width, so that they can be compared as strings
+	timeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+var timeRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{9}Z$`)
+
+// encodeValue convert the time values in v to timeLayout strings recursively
+func encodeValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().Format(timeLayout)
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return value.UTC().Format(timeLayout)
+	case operator.M:
+		return encodeValue(map[string]interface{}(value))
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			r[k] = encodeValue(item)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			r = append(r, encodeValue(item))
+		}
+		return r
+	}
+	return v
+}
+
+// decodeValue recover the values decoded with json.Number, integers are int64 and
+// the strings in timeLayout are time.Time in local zone, the same as mongodb driver returns
+func decodeValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case string:
+		if timeRegexp.MatchString(value) {
+			if t, err := time.Parse(timeLayout, value); err == nil {
+				return t.Local()
+			}
+		}
+		return value
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = decodeValue(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = decodeValue(item)
+		}
+		return value
+	}
+	return v
+}
+
+// encodeDocument marshal the document into json for data column
+func encodeDocument(doc map[string]interface{}) (string, error) {
+	tmp := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		if k == idColumn {
+			continue
+		}
+		tmp[k] = v
+	}
+	b, err := json.Marshal(encodeValue(tmp))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeDocument unmarshal the data column with the row id as "_id"
+func decodeDocument(id int64, data []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	decodeValue(doc)
+	doc[idColumn] = id
+	return doc, nil
+}
+
+// getPath returns the value of dot-separated key in document
+func getPath(doc map[string]interface{}, key string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, segment := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// setPath set the value of dot-separated key in document, the parents are created if not exist
+func setPath(doc map[string]interface{}, key string, value interface{}) {
+	segments := strings.Split(key, ".")
+	current := doc
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := current[segment].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[segment] = next
+		}
+		current = next
+	}
+	current[segments[len(segments)-1]] = value
+}
+
+// project keep the selected keys and "_id" of document, like the projection of mongodb
+func project(doc map[string]interface{}, keys []string) map[string]interface{} {
+	if len(keys) == 0 {
+		return doc
+	}
+	r := map[string]interface{}{idColumn: doc[idColumn]}
+	for _, key := range keys {
+		if v, ok := getPath(doc, key); ok {
+			setPath(r, key, v)
+		}
+	}
+	return r
+}
+
+// increaseValue returns the number v increased by n, v is taken as 0 if it is not a number
+func increaseValue(v, n interface{}) interface{} {
+	delta, _ := n.(int64)
+	switch value := v.(type) {
+	case int64:
+		return value + delta
+	case float64:
+		return value + float64(delta)
+	}
+	return delta
+}
+
+// toSlice returns the elements of slice or array value for In and Nin conditions
+func toSlice(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	r := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		r = append(r, rv.Index(i).Interface())
+	}
+	return r
+}
@@ -11,4 +11,485 @@
  *
  */
 
+// Package mysql implements operator.Tank on mysql.
+//
+// All databases of one config share a sql schema, collection c of database d is the table "d__c",
+// which has an auto increment "_id" column and a json "data" column for the dynamic document.
+// Conditions are translated to json path expressions of "data". Every change is logged into
+// table bcs_storage_changes in the same transaction, and Watch polls the change log.
 package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	// tables of database are named as database + tableSep + collection in one sql schema
+	tableSep = "__"
+
+	// changeTable keeps the change log of all tables for Watch
+	changeTable = "bcs_storage_changes"
+
+	// how long the change log is kept
+	changeRetention = time.Hour
+	changeCleanGap  = time.Minute
+)
+
+type originDriver struct {
+	pool    *sql.DB
+	dialect dialect
+
+	// default settings
+	database string
+
+	tableLock sync.RWMutex
+	tables    map[string]bool
+	indexes   map[string]bool
+}
+
+// hasTable checks whether table exists, the cached tables are refreshed from db if refresh is set
+func (od *originDriver) hasTable(table string, refresh bool) (bool, error) {
+	od.tableLock.RLock()
+	ok := od.tables[table]
+	od.tableLock.RUnlock()
+	if ok || !refresh {
+		return ok, nil
+	}
+	tables, err := od.listTables()
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tables {
+		if t == table {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (od *originDriver) listTables() ([]string, error) {
+	rows, err := od.pool.Query(od.dialect.listTables())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	od.tableLock.Lock()
+	for _, table := range tables {
+		od.tables[table] = true
+	}
+	od.tableLock.Unlock()
+	return tables, nil
+}
+
+// ensureTable create the document table if not exists
+func (od *originDriver) ensureTable(table string) error {
+	if ok, _ := od.hasTable(table, false); ok {
+		return nil
+	}
+	for _, stmt := range od.dialect.createTable(table) {
+		if _, err := od.pool.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	od.tableLock.Lock()
+	od.tables[table] = true
+	od.tableLock.Unlock()
+	return nil
+}
+
+// ensureIndex create the unique index of keys, failure is ignored for the upsert checks the filter itself
+func (od *originDriver) ensureIndex(table string, keys []string) {
+	h := fnv.New64a()
+	h.Write([]byte(table + "|" + strings.Join(keys, "|")))
+	index := fmt.Sprintf("uk_%x", h.Sum64())
+
+	od.tableLock.RLock()
+	ok := od.indexes[index]
+	od.tableLock.RUnlock()
+	if ok {
+		return
+	}
+
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		paths = append(paths, jsonPath(key))
+	}
+	if _, err := od.pool.Exec(od.dialect.createIndex(table, index, paths)); err != nil && !od.dialect.isIndexExist(err) {
+		blog.Errorf("mysql create index %s on %s(%s) failed: %v", index, table, strings.Join(keys, ","), err)
+	}
+	od.tableLock.Lock()
+	od.indexes[index] = true
+	od.tableLock.Unlock()
+}
+
+// cleanChanges remove the change log out of retention periodically
+func (od *originDriver) cleanChanges() {
+	for range time.Tick(changeCleanGap) {
+		deadline := time.Now().Add(-changeRetention).Unix()
+		if _, err := od.pool.Exec(fmt.Sprintf("DELETE FROM %s WHERE `created` < ?", quoteIdent(changeTable)), deadline); err != nil {
+			blog.Errorf("mysql clean change log failed: %v", err)
+		}
+	}
+}
+
+var (
+	driverPool     map[string]*originDriver
+	driverPoolLock sync.RWMutex
+)
+
+// RegisterMysqlTank register mysql operation unit
+func RegisterMysqlTank(name string, info *operator.DBInfo) (err error) {
+	if len(info.Addr) == 0 {
+		return fmt.Errorf("mysql address of %s is empty", name)
+	}
+	config := mysqlDriver.NewConfig()
+	config.User = info.Username
+	config.Passwd = info.Password
+	config.Net = "tcp"
+	config.Addr = info.Addr[0]
+	config.DBName = info.Schema
+	config.Timeout = info.ConnectTimeout
+	config.Params = map[string]string{"charset": "utf8mb4"}
+	return registerDriver(name, "mysql", config.FormatDSN(), mysqlDialect{}, info)
+}
+
+func registerDriver(name, driverName, dsn string, d dialect, info *operator.DBInfo) error {
+	driverPoolLock.Lock()
+	defer driverPoolLock.Unlock()
+	if driverPool == nil {
+		driverPool = make(map[string]*originDriver, 10)
+	}
+	if _, ok := driverPool[name]; ok {
+		err := storageErr.MysqlDriverAlreadyInPool
+		blog.Errorf("%v: %s", err, name)
+		return err
+	}
+
+	pool, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return err
+	}
+	if info.MaxOpenConn > 0 {
+		pool.SetMaxOpenConns(info.MaxOpenConn)
+	}
+	if info.MaxIdleConn > 0 {
+		pool.SetMaxIdleConns(info.MaxIdleConn)
+	}
+	if err = pool.Ping(); err != nil {
+		pool.Close()
+		return err
+	}
+
+	driver := &originDriver{
+		pool:     pool,
+		dialect:  d,
+		database: info.Database,
+		tables:   make(map[string]bool),
+		indexes:  make(map[string]bool),
+	}
+	for _, stmt := range d.createChangeTable(changeTable) {
+		if _, err = pool.Exec(stmt); err != nil {
+			pool.Close()
+			return err
+		}
+	}
+	go driver.cleanChanges()
+	driverPool[name] = driver
+	return nil
+}
+
+// NewMysqlTank create mysql operation unit
+func NewMysqlTank(name string) operator.Tank {
+	tank := &mysqlTank{}
+	if tank.err = tank.init(name); tank.err != nil {
+		blog.Errorf("Init mysql tank failed. %v", tank.err)
+	}
+	return tank
+}
+
+type mysqlTank struct {
+	isInit bool
+	name   string
+
+	driver *originDriver
+	dbName string
+	cName  string
+	search *search
+	scope  *scope
+	index  []string
+
+	data         []operator.M
+	aggregations []*operator.Aggregation
+	err          error
+}
+
+func (mt *mysqlTank) init(name string) error {
+	mt.isInit = false
+	mt.name = name
+	var ok bool
+	driverPoolLock.RLock()
+	mt.driver, ok = driverPool[name]
+	driverPoolLock.RUnlock()
+	if !ok {
+		err := storageErr.MysqlDriverNotExist
+		blog.Errorf("%v: %s", err, name)
+		return err
+	}
+	mt.search = (&search{tank: mt}).clone()
+	mt.scope = (&scope{tank: mt}).clone()
+	mt.isInit = true
+	mt.dbName = mt.driver.database
+	return nil
+}
+
+func (mt *mysqlTank) clone() *mysqlTank {
+	tank := &mysqlTank{
+		isInit: mt.isInit,
+		name:   mt.name,
+		index:  mt.index,
+		driver: mt.driver,
+		dbName: mt.dbName,
+		cName:  mt.cName,
+		err:    mt.err,
+	}
+	tank.scope = (&scope{tank: tank}).clone()
+	if mt.search == nil {
+		tank.search = &search{limit: -1, offset: 0}
+	} else {
+		tank.search = mt.search.clone()
+	}
+	tank.search.tank = tank
+	return tank
+}
+
+func (mt *mysqlTank) switchDB(name string) *mysqlTank {
+	if mt.isInit {
+		mt.dbName = name
+		mt.cName = ""
+		return mt
+	}
+	mt.err = storageErr.MysqlTankNotInit
+	return mt
+}
+
+func (mt *mysqlTank) switchCollection(name string) *mysqlTank {
+	if mt.isInit {
+		mt.cName = name
+		return mt
+	}
+	mt.err = storageErr.MysqlTankNotInit
+	return mt
+}
+
+// table returns the sql table of current collection
+func (mt *mysqlTank) table() string {
+	return mt.dbName + tableSep + mt.cName
+}
+
+func (mt *mysqlTank) newScope(op operator.OperationType) *scope {
+	s := &scope{
+		operation: op,
+		tank:      mt,
+	}
+	mt.scope = s
+	if mt.err == nil {
+		mt.scope.do()
+	}
+	return s
+}
+
+func (mt *mysqlTank) setIndex(key ...string) *mysqlTank {
+	mt.index = key
+	return mt
+}
+
+func (mt *mysqlTank) setData(data ...operator.M) *mysqlTank {
+	mt.data = data
+	return mt
+}
+
+func (mt *mysqlTank) setAggregations(aggregations ...*operator.Aggregation) *mysqlTank {
+	mt.aggregations = aggregations
+	return mt
+}
+
+// connections are kept in pool, nothing to close
+func (mt *mysqlTank) Close() {
+}
+
+// get value from scope, so it must be called after options,
+// or will return []interface{}{}
+func (mt *mysqlTank) GetValue() []interface{} {
+	if mt.scope.value == nil {
+		return []interface{}{}
+	}
+	return mt.scope.value
+}
+
+// get the value length, or the Count() value
+func (mt *mysqlTank) GetLen() int {
+	return mt.scope.length
+}
+
+// get the changeInfo after update or remove
+func (mt *mysqlTank) GetChangeInfo() *operator.ChangeInfo {
+	return mt.scope.changeInfo
+}
+
+// get the last error during the operations
+func (mt *mysqlTank) GetError() error {
+	if mt.err != nil {
+		return mt.err
+	}
+	return mt.scope.err
+}
+
+// list databases, which are the prefixes of tables
+func (mt *mysqlTank) Databases() operator.Tank {
+	return mt.clone().newScope(operator.Databases).tank
+}
+
+// switch database
+func (mt *mysqlTank) Using(name string) operator.Tank {
+	return mt.clone().switchDB(name)
+}
+
+// list collections, should be called after Using()
+func (mt *mysqlTank) Tables() operator.Tank {
+	return mt.clone().newScope(operator.Tables).tank
+}
+
+// NOT INVOLVED
+func (mt *mysqlTank) SetTableV(data interface{}) operator.Tank {
+	return mt.clone().newScope(operator.SetTableV).tank
+}
+
+// NOT INVOLVED
+func (mt *mysqlTank) GetTableV() operator.Tank {
+	return mt.clone().newScope(operator.GetTableV).tank
+}
+
+// switch collection
+func (mt *mysqlTank) From(name string) operator.Tank {
+	return mt.clone().switchCollection(name)
+}
+
+// set distinct key, will no reach db until Query() called
+func (mt *mysqlTank) Distinct(key string) operator.Tank {
+	return mt.clone().search.setDistinct(key).tank
+}
+
+// OrderBy set order key, will no reach db until Query() called
+func (mt *mysqlTank) OrderBy(key ...string) operator.Tank {
+	return mt.clone().search.setOrder(key...).tank
+}
+
+// Select set select key, will no reach db until Query() called
+func (mt *mysqlTank) Select(key ...string) operator.Tank {
+	return mt.clone().search.setSelector(key...).tank
+}
+
+// Offset set offset value, will no reach db until Query() called
+func (mt *mysqlTank) Offset(n int) operator.Tank {
+	return mt.clone().search.setOffset(n).tank
+}
+
+// Limit set limit value, will no reach db until Query() called
+func (mt *mysqlTank) Limit(n int) operator.Tank {
+	return mt.clone().search.setLimit(n).tank
+}
+
+// Index set unique index
+func (mt *mysqlTank) Index(key ...string) operator.Tank {
+	return mt.clone().setIndex(key...)
+}
+
+// Filter add condition for filter, multi filter will be combine with AND
+func (mt *mysqlTank) Filter(cond *operator.Condition, args ...interface{}) operator.Tank {
+	return mt.clone().search.combineCondition(cond).tank
+}
+
+// GroupBy set group keys, will no reach db until Aggregate() called
+func (mt *mysqlTank) GroupBy(key ...string) operator.Tank {
+	return mt.clone().search.setGroups(key...).tank
+}
+
+// Count the data length according to filters before
+func (mt *mysqlTank) Count() operator.Tank {
+	return mt.clone().newScope(operator.Count).tank
+}
+
+// Query the value according to filters before
+func (mt *mysqlTank) Query(args ...interface{}) operator.Tank {
+	return mt.clone().newScope(operator.Query).tank
+}
+
+// Insert multi value
+func (mt *mysqlTank) Insert(data ...operator.M) operator.Tank {
+	return mt.clone().setData(data...).newScope(operator.Insert).tank
+}
+
+// Upsert value according to filters before
+func (mt *mysqlTank) Upsert(data operator.M, args ...interface{}) operator.Tank {
+	return mt.clone().setData(data).newScope(operator.Upsert).tank
+}
+
+// Update value to first match according to filters before
+func (mt *mysqlTank) Update(data operator.M, args ...interface{}) operator.Tank {
+	return mt.clone().setData(data).newScope(operator.Update).tank
+}
+
+// UpdateAll value to all matches according to filters before
+func (mt *mysqlTank) UpdateAll(data operator.M, args ...interface{}) operator.Tank {
+	return mt.clone().setData(data).newScope(operator.UpdateAll).tank
+}
+
+// Remove first match according to filters before
+func (mt *mysqlTank) Remove(args ...interface{}) operator.Tank {
+	return mt.clone().newScope(operator.Remove).tank
+}
+
+// RemoveAll matches according to filters before
+func (mt *mysqlTank) RemoveAll(args ...interface{}) operator.Tank {
+	return mt.clone().newScope(operator.RemoveAll).tank
+}
+
+// Aggregate the groups according to filters before
+func (mt *mysqlTank) Aggregate(aggregations ...*operator.Aggregation) operator.Tank {
+	return mt.clone().setAggregations(aggregations...).newScope(operator.Aggregate).tank
+}
+
+// Increase the number in key of first match according to filters before, or insert it if no match
+func (mt *mysqlTank) Increase(key string, n int64) operator.Tank {
+	return mt.clone().setData(operator.M{key: n}).newScope(operator.Increase).tank
+}
+
+// Watch make a watch to table and its documents by polling the change log, then return a chan Event.
+func (mt *mysqlTank) Watch(opts *operator.WatchOptions) (chan *operator.Event, context.CancelFunc) {
+	return newWatchHandler(opts, mt).watch()
+}
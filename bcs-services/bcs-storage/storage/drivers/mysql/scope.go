@@ -0,0 +1,507 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	storageErr "bk-bcs/bcs-services/bcs-storage/storage/errors"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	changeOpAdd = "add"
+	changeOpChg = "chg"
+	changeOpDel = "del"
+)
+
+type scope struct {
+	err       error
+	tank      *mysqlTank
+	operation operator.OperationType
+
+	changeInfo *operator.ChangeInfo
+	value      []interface{}
+	length     int
+}
+
+func (s *scope) clone() *scope {
+	ns := &scope{
+		tank:       s.tank,
+		operation:  s.operation,
+		changeInfo: s.changeInfo,
+		value:      s.value,
+	}
+	if ns.operation == "" {
+		ns.operation = operator.None
+	}
+	return ns
+}
+
+// Do the actual operation to mysql
+func (s *scope) do() *scope {
+	started := time.Now()
+	defer func() {
+		if s.err != nil {
+			reportMysqlMetrics(string(s.operation), "FAILURE", started)
+		} else {
+			reportMysqlMetrics(string(s.operation), "SUCCESS", started)
+		}
+	}()
+	switch s.operation {
+	case operator.None:
+	case operator.Query:
+		s.doQuery()
+	case operator.Insert:
+		s.doInsert()
+	case operator.Upsert:
+		s.doUpdate(false, true)
+	case operator.Update:
+		s.doUpdate(false, false)
+	case operator.UpdateAll:
+		s.doUpdate(true, false)
+	case operator.Remove:
+		s.doRemove(false)
+	case operator.RemoveAll:
+		s.doRemove(true)
+	case operator.Count:
+		s.doCount()
+	case operator.Tables:
+		s.doTables()
+	case operator.Databases:
+		s.doDatabases()
+	case operator.Aggregate:
+		s.doAggregate()
+	case operator.Increase:
+		s.doIncrease()
+	case operator.GetTableV:
+		s.err = storageErr.GetTableVNotSupported
+	case operator.SetTableV:
+		s.err = storageErr.SetTableVNotSupported
+	default:
+		s.err = storageErr.UnknownOperationType
+	}
+	return s
+}
+
+// checkTable returns whether the table of collection exists, a query to a non-existent table gets nothing
+func (s *scope) checkTable() bool {
+	if s.tank.cName == "" {
+		s.err = storageErr.MysqlTableNoFound
+		return false
+	}
+	ok, err := s.tank.driver.hasTable(s.tank.table(), true)
+	if err != nil {
+		s.err = err
+		return false
+	}
+	return ok
+}
+
+// Do the count action, save result to scope.length and scope.err
+func (s *scope) doCount() {
+	if !s.checkTable() {
+		return
+	}
+	cond := s.tank.search.getRawCond()
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quoteIdent(s.tank.table()), cond.sql)
+	if s.err = s.tank.driver.pool.QueryRow(query, cond.args...).Scan(&count); s.err != nil {
+		return
+	}
+
+	// the same as mongodb, offset and limit are applied to count
+	count -= s.tank.search.offset
+	if count < 0 {
+		count = 0
+	}
+	if limit := s.tank.search.limit; limit > 0 && count > limit {
+		count = limit
+	}
+	s.length = count
+}
+
+// Do the query action, save result to scope.value and scope.err
+func (s *scope) doQuery() {
+	if !s.checkTable() {
+		return
+	}
+	search := s.tank.search
+	cond := search.getRawCond()
+
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s",
+		quoteIdent(idColumn), quoteIdent(dataColumn), quoteIdent(s.tank.table()), cond.sql)
+	// distinct returns the values of all matches
+	if search.distinct == "" {
+		query += search.getOrderBy() + search.getLimit()
+	}
+
+	var docs []map[string]interface{}
+	if docs, s.err = queryDocuments(s.tank.driver.pool, query, cond.args...); s.err != nil {
+		return
+	}
+
+	if search.distinct != "" {
+		s.value = distinctValues(docs, search.distinct)
+	} else {
+		s.value = make([]interface{}, 0, len(docs))
+		for _, doc := range docs {
+			s.value = append(s.value, project(doc, search.selector))
+		}
+	}
+	s.length = len(s.value)
+}
+
+// Do the aggregate action with GROUP BY, save result to scope.value and scope.err
+func (s *scope) doAggregate() {
+	if !s.checkTable() {
+		return
+	}
+	aggregations := s.tank.aggregations
+	query, args, err := s.tank.search.getAggregateQuery(s.tank.table(), aggregations)
+	if err != nil {
+		s.err = err
+		return
+	}
+	rows, err := s.tank.driver.pool.Query(query, args...)
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer rows.Close()
+
+	groups := s.tank.search.groups
+	value := make([]interface{}, 0)
+	for rows.Next() {
+		columns := make([]interface{}, len(groups)+len(aggregations))
+		dest := make([]interface{}, len(columns))
+		for i := range columns {
+			dest[i] = &columns[i]
+		}
+		if s.err = rows.Scan(dest...); s.err != nil {
+			return
+		}
+		item := make(map[string]interface{}, len(columns))
+		for i, key := range groups {
+			if item[key], s.err = decodeJSONValue(columns[i]); s.err != nil {
+				return
+			}
+		}
+		for i, aggregation := range aggregations {
+			item[aggregation.Name] = decodeNumber(columns[len(groups)+i], aggregation.Func == operator.AggCount)
+		}
+		value = append(value, item)
+	}
+	if s.err = rows.Err(); s.err != nil {
+		return
+	}
+	s.value = value
+	s.length = len(s.value)
+}
+
+// Do the insert action
+func (s *scope) doInsert() {
+	if !s.ensureTable() {
+		return
+	}
+	s.err = s.transaction(func(tx *sql.Tx) error {
+		for _, data := range s.tank.data {
+			if _, err := s.insert(tx, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Do the update action, the (dot-separated) keys of data are set to the matches like "$set" of mongodb,
+// if upsert is set and nothing matches, the data is inserted with the fields of Eq conditions
+func (s *scope) doUpdate(all, upsert bool) {
+	if !s.ensureTable() {
+		return
+	}
+	data := s.tank.data[0]
+	changeInfo := &operator.ChangeInfo{}
+	s.err = s.transaction(func(tx *sql.Tx) error {
+		docs, err := s.selectForUpdate(tx, all)
+		if err != nil {
+			return err
+		}
+		changeInfo.Matched = len(docs)
+		if len(docs) == 0 && upsert {
+			doc := getEqualFields(s.tank.search.condition)
+			for k, v := range data {
+				setPath(doc, k, v)
+			}
+			_, err = s.insert(tx, doc)
+			return err
+		}
+
+		for _, doc := range docs {
+			for k, v := range data {
+				setPath(doc, k, v)
+			}
+			if err = s.update(tx, doc); err != nil {
+				return err
+			}
+			changeInfo.Updated++
+		}
+		return nil
+	})
+	s.changeInfo = changeInfo
+}
+
+// Do the increase action, the first match is locked until the increase is done, so that concurrent
+// increases get different values. The document after increasing is saved to scope.value
+func (s *scope) doIncrease() {
+	if !s.ensureTable() {
+		return
+	}
+	var doc map[string]interface{}
+	changeInfo := &operator.ChangeInfo{}
+	s.err = s.transaction(func(tx *sql.Tx) error {
+		docs, err := s.selectForUpdate(tx, false)
+		if err != nil {
+			return err
+		}
+		changeInfo.Matched = len(docs)
+		if len(docs) == 0 {
+			doc = getEqualFields(s.tank.search.condition)
+			for k, v := range s.tank.data[0] {
+				setPath(doc, k, v)
+			}
+			var id int64
+			if id, err = s.insert(tx, doc); err != nil {
+				return err
+			}
+			doc[idColumn] = id
+			return nil
+		}
+
+		doc = docs[0]
+		for k, v := range s.tank.data[0] {
+			current, _ := getPath(doc, k)
+			setPath(doc, k, increaseValue(current, v))
+		}
+		changeInfo.Updated = 1
+		return s.update(tx, doc)
+	})
+	if s.err != nil {
+		return
+	}
+	s.changeInfo = changeInfo
+	s.value = []interface{}{doc}
+	s.length = 1
+}
+
+// Do the remove action
+func (s *scope) doRemove(all bool) {
+	s.changeInfo = &operator.ChangeInfo{}
+	if !s.checkTable() {
+		return
+	}
+	changeInfo := &operator.ChangeInfo{}
+	s.err = s.transaction(func(tx *sql.Tx) error {
+		docs, err := s.selectForUpdate(tx, all)
+		if err != nil {
+			return err
+		}
+		changeInfo.Matched = len(docs)
+
+		remove := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", quoteIdent(s.tank.table()), quoteIdent(idColumn))
+		for _, doc := range docs {
+			id := doc[idColumn].(int64)
+			if _, err = tx.Exec(remove, id); err != nil {
+				return err
+			}
+			raw, err := encodeDocument(doc)
+			if err != nil {
+				return err
+			}
+			if err = s.logChange(tx, changeOpDel, id, raw); err != nil {
+				return err
+			}
+			changeInfo.Removed++
+		}
+		return nil
+	})
+	s.changeInfo = changeInfo
+}
+
+// Do the tables action, list all collections of database
+func (s *scope) doTables() {
+	tables, err := s.tank.driver.listTables()
+	if err != nil {
+		s.err = err
+		return
+	}
+	prefix := s.tank.dbName + tableSep
+	value := make([]interface{}, 0, len(tables))
+	for _, table := range tables {
+		if strings.HasPrefix(table, prefix) {
+			value = append(value, strings.TrimPrefix(table, prefix))
+		}
+	}
+	s.value = value
+	s.length = len(s.value)
+}
+
+// Do the databases action, list all databases
+func (s *scope) doDatabases() {
+	tables, err := s.tank.driver.listTables()
+	if err != nil {
+		s.err = err
+		return
+	}
+	exist := make(map[string]bool)
+	value := make([]interface{}, 0)
+	for _, table := range tables {
+		i := strings.Index(table, tableSep)
+		if i <= 0 || exist[table[:i]] {
+			continue
+		}
+		exist[table[:i]] = true
+		value = append(value, table[:i])
+	}
+	s.value = value
+	s.length = len(s.value)
+}
+
+func (s *scope) ensureTable() bool {
+	if s.tank.cName == "" {
+		s.err = storageErr.MysqlTableNoFound
+		return false
+	}
+	if s.err = s.tank.driver.ensureTable(s.tank.table()); s.err != nil {
+		return false
+	}
+	if len(s.tank.index) != 0 {
+		s.tank.driver.ensureIndex(s.tank.table(), s.tank.index)
+	}
+	return true
+}
+
+func (s *scope) transaction(f func(tx *sql.Tx) error) error {
+	tx, err := s.tank.driver.pool.Begin()
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// selectForUpdate returns the matches locked in transaction, only the first one if all is not set
+func (s *scope) selectForUpdate(tx *sql.Tx, all bool) ([]map[string]interface{}, error) {
+	cond := s.tank.search.getRawCond()
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s ORDER BY %s",
+		quoteIdent(idColumn), quoteIdent(dataColumn), quoteIdent(s.tank.table()), cond.sql, quoteIdent(idColumn))
+	if !all {
+		query += " LIMIT 1"
+	}
+	return queryDocuments(tx, query+s.tank.driver.dialect.forUpdate(), cond.args...)
+}
+
+// update write the document back to its row
+func (s *scope) update(tx *sql.Tx, doc map[string]interface{}) error {
+	id := doc[idColumn].(int64)
+	raw, err := encodeDocument(doc)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?",
+		quoteIdent(s.tank.table()), quoteIdent(dataColumn), quoteIdent(idColumn)), raw, id); err != nil {
+		return err
+	}
+	return s.logChange(tx, changeOpChg, id, raw)
+}
+
+func (s *scope) insert(tx *sql.Tx, data map[string]interface{}) (int64, error) {
+	raw, err := encodeDocument(data)
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (?)",
+		quoteIdent(s.tank.table()), quoteIdent(dataColumn)), raw)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, s.logChange(tx, changeOpAdd, id, raw)
+}
+
+// logChange add the change of document into change log for Watch
+func (s *scope) logChange(tx *sql.Tx, op string, id int64, raw string) error {
+	_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (`tbl`, `op`, `doc_id`, `data`, `created`) VALUES (?, ?, ?, ?, ?)",
+		quoteIdent(changeTable)), s.tank.table(), op, id, raw, time.Now().Unix())
+	return err
+}
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryDocuments(q querier, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err = rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		doc, err := decodeDocument(id, data)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// distinctValues returns the distinct values of key in docs, in the order of appearance
+func distinctValues(docs []map[string]interface{}, key string) []interface{} {
+	values := make([]interface{}, 0)
+	for _, doc := range docs {
+		v, ok := getPath(doc, key)
+		if !ok {
+			continue
+		}
+		exist := false
+		for _, value := range values {
+			if reflect.DeepEqual(value, v) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			values = append(values, v)
+		}
+	}
+	return values
+}
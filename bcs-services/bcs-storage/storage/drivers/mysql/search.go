@@ -0,0 +1,337 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+// sqlCond is the where clause combined from operator.Condition
+type sqlCond struct {
+	sql  string
+	args []interface{}
+}
+
+type search struct {
+	tank      *mysqlTank
+	condition *operator.Condition
+	rawCond   *sqlCond
+
+	orders   []string
+	distinct string
+	offset   int
+	limit    int
+	selector []string
+	groups   []string
+}
+
+func (s *search) clone() *search {
+	ns := &search{
+		tank:     s.tank,
+		orders:   s.orders,
+		distinct: s.distinct,
+		offset:   s.offset,
+		limit:    s.limit,
+		selector: s.selector,
+		groups:   s.groups,
+	}
+	if s.condition == nil {
+		ns.condition = operator.BaseCondition
+	} else {
+		ns.condition = s.condition
+	}
+	return ns
+}
+
+func (s *search) combineCondition(cond *operator.Condition) *search {
+	if s.condition == operator.BaseCondition {
+		s.condition = cond
+	} else {
+		s.condition = s.condition.And(cond)
+	}
+	return s
+}
+
+func (s *search) setDistinct(key string) *search {
+	s.distinct = key
+	return s
+}
+
+func (s *search) setOrder(key ...string) *search {
+	s.orders = key
+	return s
+}
+
+func (s *search) setSelector(key ...string) *search {
+	tmp := make([]string, 0, len(key))
+	for _, v := range key {
+		if v == "" {
+			continue
+		}
+		tmp = append(tmp, v)
+	}
+	if len(tmp) > 0 {
+		s.selector = tmp
+	}
+	return s
+}
+
+func (s *search) setGroups(key ...string) *search {
+	s.groups = key
+	return s
+}
+
+func (s *search) setOffset(offset int) *search {
+	s.offset = offset
+	return s
+}
+
+func (s *search) setLimit(limit int) *search {
+	s.limit = limit
+	return s
+}
+
+func (s *search) getRawCond() *sqlCond {
+	if s.rawCond != nil {
+		return s.rawCond
+	}
+	d := s.tank.driver.dialect
+	raw := s.condition.Combine(
+		func(cond *operator.Condition) interface{} {
+			return leafNodeProcessor(d, cond)
+		},
+		branchNodeProcessor,
+	)
+
+	if rawCond, ok := raw.(*sqlCond); ok && rawCond != nil {
+		s.rawCond = rawCond
+	} else {
+		s.rawCond = &sqlCond{sql: "1 = 1"}
+	}
+	return s.rawCond
+}
+
+// getOrderBy returns the ORDER BY clause, "-key" means descending, "_id" is always the last key
+func (s *search) getOrderBy() string {
+	d := s.tank.driver.dialect
+	orders := make([]string, 0, len(s.orders)+1)
+	for _, key := range s.orders {
+		if key == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+			key = key[1:]
+		}
+		if key == idColumn {
+			orders = append(orders, quoteIdent(idColumn)+" "+direction)
+			continue
+		}
+		orders = append(orders, d.extract(jsonPath(key))+" "+direction)
+	}
+	orders = append(orders, quoteIdent(idColumn)+" ASC")
+	return " ORDER BY " + strings.Join(orders, ", ")
+}
+
+// getLimit returns the LIMIT clause, both mysql and sqlite need LIMIT if OFFSET is set
+func (s *search) getLimit() string {
+	if s.limit <= 0 && s.offset <= 0 {
+		return ""
+	}
+	limit := int64(1) << 62
+	if s.limit > 0 {
+		limit = int64(s.limit)
+	}
+	return " LIMIT " + strconv.FormatInt(limit, 10) + " OFFSET " + strconv.Itoa(s.offset)
+}
+
+// Handle leaf node of Condition while combining, keys of the same node are combined with AND
+func leafNodeProcessor(d dialect, cond *operator.Condition) interface{} {
+	if cond.Type == operator.Tr {
+		return &sqlCond{sql: "1 = 1"}
+	}
+	originValue, _ := cond.Value.(operator.M)
+
+	// keep the order of keys, so that the same condition makes the same sql
+	keys := make([]string, 0, len(originValue))
+	for k := range originValue {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	conds := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if c := leafCondition(d, cond.Type, jsonPath(key), originValue[key]); c != nil {
+			conds = append(conds, c)
+		}
+	}
+	if len(conds) == 0 {
+		return &sqlCond{sql: "1 = 1"}
+	}
+	return branchNodeProcessor(operator.And, conds)
+}
+
+func leafCondition(d dialect, t operator.ConditionType, path string, value interface{}) *sqlCond {
+	value = encodeValue(value)
+	switch t {
+	case operator.Eq:
+		if value == nil {
+			return &sqlCond{sql: d.isNull(path)}
+		}
+		return compareCondition(d, path, "=", value)
+	case operator.Ne:
+		if value == nil {
+			return &sqlCond{sql: "NOT (" + d.isNull(path) + ")"}
+		}
+		// the same as mongodb, documents without the key match Ne
+		c := compareCondition(d, path, "<>", value)
+		c.sql = "(" + d.isNull(path) + " OR " + c.sql + ")"
+		return c
+	case operator.Lt:
+		return compareCondition(d, path, "<", value)
+	case operator.Lte:
+		return compareCondition(d, path, "<=", value)
+	case operator.Gt:
+		return compareCondition(d, path, ">", value)
+	case operator.Gte:
+		return compareCondition(d, path, ">=", value)
+	case operator.In:
+		return inCondition(d, path, toSlice(value))
+	case operator.Nin:
+		c := inCondition(d, path, toSlice(value))
+		c.sql = "(" + d.isNull(path) + " OR NOT " + c.sql + ")"
+		return c
+	case operator.Con:
+		str, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		return &sqlCond{
+			sql:  d.extractText(path) + " LIKE ? ESCAPE '!'",
+			args: []interface{}{"%" + escapeLike(str) + "%"},
+		}
+	case operator.Ext:
+		if exist, ok := value.(bool); ok && !exist {
+			return &sqlCond{sql: "NOT (" + d.exists(path) + ")"}
+		}
+		return &sqlCond{sql: d.exists(path)}
+	}
+	return nil
+}
+
+func compareCondition(d dialect, path, op string, value interface{}) *sqlCond {
+	placeholder, arg := d.bind(value)
+	return &sqlCond{sql: d.extract(path) + " " + op + " " + placeholder, args: []interface{}{arg}}
+}
+
+// IN with json values is not supported by mysql, so it is combined with OR
+func inCondition(d dialect, path string, values []interface{}) *sqlCond {
+	if len(values) == 0 {
+		return &sqlCond{sql: "(1 = 0)"}
+	}
+	items := make([]string, 0, len(values))
+	var args []interface{}
+	for _, value := range values {
+		value = encodeValue(value)
+		if value == nil {
+			items = append(items, d.isNull(path))
+			continue
+		}
+		c := compareCondition(d, path, "=", value)
+		items = append(items, c.sql)
+		args = append(args, c.args...)
+	}
+	return &sqlCond{sql: "(" + strings.Join(items, " OR ") + ")", args: args}
+}
+
+func escapeLike(s string) string {
+	s = strings.Replace(s, "!", "!!", -1)
+	s = strings.Replace(s, "%", "!%", -1)
+	return strings.Replace(s, "_", "!_", -1)
+}
+
+// Handle branch node of Condition while combining
+func branchNodeProcessor(t operator.ConditionType, condList []interface{}) interface{} {
+	conds := make([]*sqlCond, 0, len(condList))
+	for _, c := range condList {
+		if sc, ok := c.(*sqlCond); ok && sc != nil {
+			conds = append(conds, sc)
+		}
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+	switch t {
+	case operator.And, operator.Or:
+		if len(conds) == 1 {
+			return conds[0]
+		}
+		sep := " AND "
+		if t == operator.Or {
+			sep = " OR "
+		}
+		items := make([]string, 0, len(conds))
+		var args []interface{}
+		for _, c := range conds {
+			items = append(items, "("+c.sql+")")
+			args = append(args, c.args...)
+		}
+		return &sqlCond{sql: strings.Join(items, sep), args: args}
+	case operator.Not:
+		return &sqlCond{sql: "NOT (" + conds[0].sql + ")", args: conds[0].args}
+	}
+	return nil
+}
+
+// getEqualFields returns the fields of Eq conditions combined with AND,
+// which are inserted with the data when upsert does not match any document
+func getEqualFields(cond *operator.Condition) map[string]interface{} {
+	raw := cond.Combine(
+		func(c *operator.Condition) interface{} {
+			r := make(map[string]interface{})
+			if c.Type != operator.Eq {
+				return r
+			}
+			for k, v := range c.Value.(operator.M) {
+				r[k] = v
+			}
+			return r
+		},
+		func(t operator.ConditionType, condList []interface{}) interface{} {
+			r := make(map[string]interface{})
+			if t != operator.And {
+				return r
+			}
+			for _, c := range condList {
+				if m, ok := c.(map[string]interface{}); ok {
+					for k, v := range m {
+						r[k] = v
+					}
+				}
+			}
+			return r
+		},
+	)
+	fields, _ := raw.(map[string]interface{})
+	r := make(map[string]interface{})
+	for k, v := range fields {
+		setPath(r, k, v)
+	}
+	return r
+}
@@ -0,0 +1,213 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+func newTestSearch(d dialect) *search {
+	tank := &mysqlTank{driver: &originDriver{dialect: d}}
+	tank.search = (&search{tank: tank, limit: -1}).clone()
+	return tank.search
+}
+
+func TestJsonPath(t *testing.T) {
+	cases := map[string]string{
+		"a":          `$."a"`,
+		"a.b":        `$."a"."b"`,
+		`a"b`:        `$."a\"b"`,
+		`a\b.c-d`:    `$."a\\b"."c-d"`,
+		"l.a\uff0eb": `$."l"."a.b"`,
+	}
+	for key, expect := range cases {
+		if path := jsonPath(key); path != expect {
+			t.Errorf("jsonPath(%s) expect %s, got %s", key, expect, path)
+		}
+	}
+}
+
+func TestGetRawCond(t *testing.T) {
+	cond := operator.NewCondition(operator.Eq, operator.M{"clusterId": "c1", "data.spec.replicas": int64(2)}).
+		And(operator.NewCondition(operator.In, operator.M{"namespace": []string{"default", "kube-system"}})).
+		And(operator.NewCondition(operator.Ne, operator.M{"resourceName": nil}))
+	s := newTestSearch(mysqlDialect{}).combineCondition(cond)
+
+	raw := s.getRawCond()
+	expectSQL := "(((JSON_EXTRACT(`data`, '$.\"clusterId\"') = ?) AND " +
+		"(JSON_EXTRACT(`data`, '$.\"data\".\"spec\".\"replicas\"') = ?)) AND " +
+		"((JSON_EXTRACT(`data`, '$.\"namespace\"') = ? OR JSON_EXTRACT(`data`, '$.\"namespace\"') = ?))) AND " +
+		"(NOT (COALESCE(JSON_TYPE(JSON_EXTRACT(`data`, '$.\"resourceName\"')), 'NULL') = 'NULL'))"
+	if raw.sql != expectSQL {
+		t.Errorf("getRawCond sql expect:\n%s\ngot:\n%s", expectSQL, raw.sql)
+	}
+	expectArgs := []interface{}{"c1", int64(2), "default", "kube-system"}
+	if !reflect.DeepEqual(raw.args, expectArgs) {
+		t.Errorf("getRawCond args expect %v, got %v", expectArgs, raw.args)
+	}
+}
+
+func TestGetRawCondSqlite(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	cond := operator.NewCondition(operator.Gte, operator.M{"updateTime": tm}).
+		Or(operator.NewCondition(operator.Con, operator.M{"name": "a_b%"})).
+		And(operator.NewCondition(operator.Nin, operator.M{"labels": []interface{}{map[string]interface{}{"a": "b"}}}))
+	s := newTestSearch(sqliteDialect{}).combineCondition(cond)
+
+	raw := s.getRawCond()
+	expectSQL := "((json_extract(`data`, '$.\"updateTime\"') >= ?) OR " +
+		"(json_extract(`data`, '$.\"name\"') LIKE ? ESCAPE '!')) AND " +
+		"((json_extract(`data`, '$.\"labels\"') IS NULL OR NOT (json_extract(`data`, '$.\"labels\"') = json(?))))"
+	if raw.sql != expectSQL {
+		t.Errorf("getRawCond sql expect:\n%s\ngot:\n%s", expectSQL, raw.sql)
+	}
+	expectArgs := []interface{}{"2020-01-02T03:04:05.000000006Z", "%a!_b!%%", `{"a":"b"}`}
+	if !reflect.DeepEqual(raw.args, expectArgs) {
+		t.Errorf("getRawCond args expect %v, got %v", expectArgs, raw.args)
+	}
+}
+
+func TestGetRawCondEmpty(t *testing.T) {
+	s := newTestSearch(mysqlDialect{})
+	if raw := s.getRawCond(); raw.sql != "1 = 1" || len(raw.args) != 0 {
+		t.Errorf("getRawCond of base condition expect 1 = 1, got %s %v", raw.sql, raw.args)
+	}
+
+	s = newTestSearch(mysqlDialect{}).combineCondition(operator.NewCondition(operator.In, operator.M{"a": []string{}}))
+	if raw := s.getRawCond(); raw.sql != "(1 = 0)" {
+		t.Errorf("getRawCond of empty In expect (1 = 0), got %s", raw.sql)
+	}
+}
+
+func TestGetOrderByAndLimit(t *testing.T) {
+	s := newTestSearch(sqliteDialect{}).setOrder("-createTime", "name")
+	expect := " ORDER BY json_extract(`data`, '$.\"createTime\"') DESC, json_extract(`data`, '$.\"name\"') ASC, `_id` ASC"
+	if orderBy := s.getOrderBy(); orderBy != expect {
+		t.Errorf("getOrderBy expect %s, got %s", expect, orderBy)
+	}
+
+	if limit := s.getLimit(); limit != "" {
+		t.Errorf("getLimit without limit and offset expect empty, got %s", limit)
+	}
+	if limit := s.setOffset(10).getLimit(); limit != " LIMIT 4611686018427387904 OFFSET 10" {
+		t.Errorf("getLimit with offset only got %s", limit)
+	}
+	if limit := s.setLimit(5).getLimit(); limit != " LIMIT 5 OFFSET 10" {
+		t.Errorf("getLimit with limit and offset got %s", limit)
+	}
+}
+
+func TestGetEqualFields(t *testing.T) {
+	cond := operator.NewCondition(operator.Eq, operator.M{"clusterId": "c1", "data.metadata.name": "n1"}).
+		And(operator.NewCondition(operator.Gt, operator.M{"count": 1})).
+		And(operator.NewCondition(operator.Eq, operator.M{"a": "x"}).Or(operator.NewCondition(operator.Eq, operator.M{"a": "y"})))
+	expect := map[string]interface{}{
+		"clusterId": "c1",
+		"data": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "n1"},
+		},
+	}
+	if fields := getEqualFields(cond); !reflect.DeepEqual(fields, expect) {
+		t.Errorf("getEqualFields expect %v, got %v", expect, fields)
+	}
+}
+
+func TestDocumentCodec(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	doc := map[string]interface{}{
+		"_id":   int64(3),
+		"int":   10,
+		"float": 1.5,
+		"time":  tm,
+		"nest":  operator.M{"list": []interface{}{"a", int64(1), &tm}},
+	}
+	raw, err := encodeDocument(doc)
+	if err != nil {
+		t.Fatalf("encodeDocument failed: %v", err)
+	}
+	decoded, err := decodeDocument(7, []byte(raw))
+	if err != nil {
+		t.Fatalf("decodeDocument failed: %v", err)
+	}
+	expect := map[string]interface{}{
+		"_id":   int64(7),
+		"int":   int64(10),
+		"float": 1.5,
+		"time":  tm.Local(),
+		"nest":  map[string]interface{}{"list": []interface{}{"a", int64(1), tm.Local()}},
+	}
+	if !reflect.DeepEqual(decoded, expect) {
+		t.Errorf("document codec expect %v, got %v", expect, decoded)
+	}
+}
+
+func TestProject(t *testing.T) {
+	doc := map[string]interface{}{
+		"_id":  int64(1),
+		"a":    "x",
+		"data": map[string]interface{}{"b": "y", "c": "z"},
+	}
+	expect := map[string]interface{}{
+		"_id":  int64(1),
+		"data": map[string]interface{}{"b": "y"},
+	}
+	if r := project(doc, []string{"data.b", "none"}); !reflect.DeepEqual(r, expect) {
+		t.Errorf("project expect %v, got %v", expect, r)
+	}
+	if r := project(doc, nil); !reflect.DeepEqual(r, doc) {
+		t.Errorf("project without keys expect %v, got %v", doc, r)
+	}
+}
+
+func TestGetAggregateQuery(t *testing.T) {
+	s := newTestSearch(mysqlDialect{}).setGroups("namespace", "data.status").setOrder("-count", "namespace").setLimit(10)
+	s.combineCondition(operator.BaseCondition.AddOp(operator.Eq, "clusterId", "c1"))
+	query, args, err := s.getAggregateQuery("dynamic__pod", []*operator.Aggregation{
+		operator.NewAggregation("", operator.AggCount, ""),
+		operator.NewAggregation("restarts", operator.AggSum, "data.restartCount"),
+	})
+	expect := "SELECT JSON_EXTRACT(`data`, '$.\"namespace\"') AS `g0`, JSON_EXTRACT(`data`, '$.\"data\".\"status\"') AS `g1`, " +
+		"COUNT(*) AS `a0`, SUM(JSON_EXTRACT(`data`, '$.\"data\".\"restartCount\"') + 0) AS `a1` " +
+		"FROM `dynamic__pod` WHERE JSON_EXTRACT(`data`, '$.\"clusterId\"') = ? " +
+		"GROUP BY JSON_EXTRACT(`data`, '$.\"namespace\"'), JSON_EXTRACT(`data`, '$.\"data\".\"status\"') HAVING COUNT(*) > 0 " +
+		"ORDER BY `a0` DESC, JSON_EXTRACT(`data`, '$.\"namespace\"') ASC, JSON_EXTRACT(`data`, '$.\"data\".\"status\"') ASC LIMIT 10 OFFSET 0"
+	if err != nil || query != expect || !reflect.DeepEqual(args, []interface{}{"c1"}) {
+		t.Errorf("getAggregateQuery failed! \nresult:\n%s %v %v\nexpect:\n%s\n", query, args, err, expect)
+	}
+
+	if _, _, err = s.getAggregateQuery("dynamic__pod", []*operator.Aggregation{{Name: "max", Func: operator.AggMax}}); err == nil {
+		t.Errorf("getAggregateQuery with invalid aggregation expect error")
+	}
+}
+
+func TestDecodeAggregation(t *testing.T) {
+	for raw, expect := range map[string]interface{}{`"default"`: "default", `3`: int64(3), `{"a":true}`: map[string]interface{}{"a": true}} {
+		if v, err := decodeJSONValue([]byte(raw)); err != nil || !reflect.DeepEqual(v, expect) {
+			t.Errorf("decodeJSONValue(%s) expect %v, got %v %v", raw, expect, v, err)
+		}
+	}
+	if v, err := decodeJSONValue(nil); err != nil || v != nil {
+		t.Errorf("decodeJSONValue(nil) expect nil, got %v %v", v, err)
+	}
+	if v := decodeNumber([]byte("4"), true); v != int64(4) {
+		t.Errorf("decodeNumber expect 4, got %v", v)
+	}
+	if v := decodeNumber(int64(3), false); v != float64(3) {
+		t.Errorf("decodeNumber expect 3.0, got %v", v)
+	}
+}
@@ -0,0 +1,295 @@
+// +build libsqlite3
+
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestTank register a driver on a temporary sqlite database, which shares the sql generation with mysql
+func newTestTank(t *testing.T, name string) (operator.Tank, func()) {
+	dir, err := ioutil.TempDir("", "bcs-storage-mysql")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	info := &operator.DBInfo{Database: "dynamic", MaxOpenConn: 1}
+	if err = registerDriver(name, "sqlite3", filepath.Join(dir, "storage.db"), sqliteDialect{}, info); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("register driver failed: %v", err)
+	}
+	return NewMysqlTank(name), func() {
+		driverPoolLock.Lock()
+		driverPool[name].pool.Close()
+		delete(driverPool, name)
+		driverPoolLock.Unlock()
+		os.RemoveAll(dir)
+	}
+}
+
+func names(t *testing.T, tank operator.Tank) []string {
+	if err := tank.GetError(); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	r := make([]string, 0, tank.GetLen())
+	for _, v := range tank.GetValue() {
+		r = append(r, v.(map[string]interface{})["name"].(string))
+	}
+	return r
+}
+
+func TestTankQuery(t *testing.T) {
+	tank, clean := newTestTank(t, "query")
+	defer clean()
+
+	pods := tank.From("pod")
+	if r := pods.Query(); r.GetError() != nil || r.GetLen() != 0 {
+		t.Fatalf("query non-existent table expect nothing, got %v %v", r.GetValue(), r.GetError())
+	}
+
+	if err := pods.Insert(
+		operator.M{"name": "p1", "namespace": "default", "replicas": 3, "labels": map[string]interface{}{"app": "a"}},
+		operator.M{"name": "p2", "namespace": "kube-system", "replicas": 1, "ready": true},
+		operator.M{"name": "p3", "namespace": "default", "replicas": 2, "labels": map[string]interface{}{"app": "b"}},
+	).GetError(); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	cases := []struct {
+		cond   *operator.Condition
+		expect []string
+	}{
+		{operator.BaseCondition, []string{"p1", "p2", "p3"}},
+		{operator.NewCondition(operator.Eq, operator.M{"namespace": "default"}), []string{"p1", "p3"}},
+		{operator.NewCondition(operator.Eq, operator.M{"labels.app": "b"}), []string{"p3"}},
+		{operator.NewCondition(operator.Eq, operator.M{"ready": true}), []string{"p2"}},
+		{operator.NewCondition(operator.Eq, operator.M{"labels": nil}), []string{"p2"}},
+		{operator.NewCondition(operator.Ne, operator.M{"labels.app": "a"}), []string{"p2", "p3"}},
+		{operator.NewCondition(operator.Gte, operator.M{"replicas": 2}), []string{"p1", "p3"}},
+		{operator.NewCondition(operator.In, operator.M{"name": []string{"p1", "p2"}}), []string{"p1", "p2"}},
+		{operator.NewCondition(operator.Nin, operator.M{"name": []string{"p1", "p2"}}), []string{"p3"}},
+		{operator.NewCondition(operator.Con, operator.M{"namespace": "system"}), []string{"p2"}},
+		{operator.NewCondition(operator.Ext, operator.M{"ready": true}), []string{"p2"}},
+		{operator.NewCondition(operator.Eq, operator.M{"name": "p1"}).Or(operator.NewCondition(operator.Lt, operator.M{"replicas": 2})), []string{"p1", "p2"}},
+		{operator.NewCondition(operator.Eq, operator.M{"namespace": "default"}).Not(), []string{"p2"}},
+	}
+	for i, c := range cases {
+		if r := names(t, pods.Filter(c.cond).Query()); !reflect.DeepEqual(r, c.expect) {
+			t.Errorf("case %d: expect %v, got %v", i, c.expect, r)
+		}
+	}
+
+	if r := names(t, pods.OrderBy("-replicas").Offset(1).Limit(1).Query()); !reflect.DeepEqual(r, []string{"p3"}) {
+		t.Errorf("order, offset and limit expect [p3], got %v", r)
+	}
+
+	r := pods.Filter(operator.NewCondition(operator.Eq, operator.M{"name": "p1"})).Select("labels.app").Query()
+	if r.GetLen() != 1 || !reflect.DeepEqual(r.GetValue()[0], map[string]interface{}{
+		"_id": int64(1), "labels": map[string]interface{}{"app": "a"}}) {
+		t.Errorf("select expect labels.app of p1, got %v", r.GetValue())
+	}
+
+	if r := pods.Distinct("namespace").Query(); !reflect.DeepEqual(r.GetValue(), []interface{}{"default", "kube-system"}) {
+		t.Errorf("distinct expect [default kube-system], got %v", r.GetValue())
+	}
+
+	if r := pods.Filter(operator.NewCondition(operator.Eq, operator.M{"namespace": "default"})).Count(); r.GetLen() != 2 {
+		t.Errorf("count expect 2, got %d %v", r.GetLen(), r.GetError())
+	}
+	if r := pods.Offset(1).Limit(1).Count(); r.GetLen() != 1 {
+		t.Errorf("count with offset and limit expect 1, got %d", r.GetLen())
+	}
+
+	if r := tank.Databases(); !reflect.DeepEqual(r.GetValue(), []interface{}{"dynamic"}) {
+		t.Errorf("databases expect [dynamic], got %v %v", r.GetValue(), r.GetError())
+	}
+	if r := tank.Tables(); !reflect.DeepEqual(r.GetValue(), []interface{}{"pod"}) {
+		t.Errorf("tables expect [pod], got %v %v", r.GetValue(), r.GetError())
+	}
+}
+
+func TestTankUpdate(t *testing.T) {
+	tank, clean := newTestTank(t, "update")
+	defer clean()
+
+	now := time.Now().Round(time.Second)
+	pods := tank.From("pod").Index("clusterId", "name")
+	cond := operator.NewCondition(operator.Eq, operator.M{"clusterId": "c1", "name": "p1"})
+
+	r := pods.Filter(cond).Upsert(operator.M{"status": "Pending", "createTime": now})
+	if r.GetError() != nil || r.GetChangeInfo().Matched != 0 {
+		t.Fatalf("upsert insert failed: %v %v", r.GetError(), r.GetChangeInfo())
+	}
+	r = pods.Filter(cond).Upsert(operator.M{"status": "Running", "spec.node": "n1"})
+	if r.GetError() != nil || r.GetChangeInfo().Matched != 1 || r.GetChangeInfo().Updated != 1 {
+		t.Fatalf("upsert update failed: %v %v", r.GetError(), r.GetChangeInfo())
+	}
+
+	r = pods.Filter(cond).Query()
+	expect := map[string]interface{}{
+		"_id":        int64(1),
+		"clusterId":  "c1",
+		"name":       "p1",
+		"status":     "Running",
+		"createTime": now,
+		"spec":       map[string]interface{}{"node": "n1"},
+	}
+	if r.GetLen() != 1 || !reflect.DeepEqual(r.GetValue()[0], expect) {
+		t.Errorf("upsert expect %v, got %v", expect, r.GetValue())
+	}
+
+	pods.Insert(operator.M{"clusterId": "c1", "name": "p2"}, operator.M{"clusterId": "c2", "name": "p3"})
+	c1 := operator.NewCondition(operator.Eq, operator.M{"clusterId": "c1"})
+	if r = pods.Filter(c1).Update(operator.M{"status": "Failed"}); r.GetChangeInfo().Updated != 1 {
+		t.Errorf("update expect 1 updated, got %v %v", r.GetChangeInfo(), r.GetError())
+	}
+	if r = pods.Filter(c1).UpdateAll(operator.M{"status": "Failed"}); r.GetChangeInfo().Matched != 2 {
+		t.Errorf("update all expect 2 matched, got %v %v", r.GetChangeInfo(), r.GetError())
+	}
+	failed := operator.NewCondition(operator.Eq, operator.M{"status": "Failed"})
+	if r = pods.Filter(failed).Count(); r.GetLen() != 2 {
+		t.Errorf("count after update all expect 2, got %d", r.GetLen())
+	}
+
+	if r = pods.Filter(c1).Remove(); r.GetChangeInfo().Removed != 1 {
+		t.Errorf("remove expect 1 removed, got %v %v", r.GetChangeInfo(), r.GetError())
+	}
+	if r = pods.RemoveAll(); r.GetChangeInfo().Removed != 2 {
+		t.Errorf("remove all expect 2 removed, got %v %v", r.GetChangeInfo(), r.GetError())
+	}
+	if r = pods.Filter(c1).Remove(); r.GetError() != nil || r.GetChangeInfo().Removed != 0 {
+		t.Errorf("remove nothing expect no error, got %v %v", r.GetChangeInfo(), r.GetError())
+	}
+}
+
+func TestTankIncrease(t *testing.T) {
+	tank, clean := newTestTank(t, "increase")
+	defer clean()
+
+	counter := tank.From("counter").Index("name").
+		Filter(operator.NewCondition(operator.Eq, operator.M{"name": "version"}))
+	for i := int64(1); i <= 3; i++ {
+		r := counter.Increase("value", 1)
+		if err := r.GetError(); err != nil {
+			t.Fatalf("increase failed: %v", err)
+		}
+		if r.GetLen() != 1 || r.GetValue()[0].(map[string]interface{})["value"] != i {
+			t.Errorf("increase expect %d, got %v", i, r.GetValue())
+		}
+	}
+	if r := counter.Increase("value", 10); r.GetValue()[0].(map[string]interface{})["value"] != int64(13) {
+		t.Errorf("increase by 10 expect 13, got %v %v", r.GetValue(), r.GetError())
+	}
+	if r := tank.From("counter").Count(); r.GetLen() != 1 {
+		t.Errorf("increase expect 1 counter, got %d", r.GetLen())
+	}
+}
+
+func TestTankAggregate(t *testing.T) {
+	tank, clean := newTestTank(t, "aggregate")
+	defer clean()
+
+	pods := tank.From("pod")
+	count := operator.NewAggregation("", operator.AggCount, "")
+	replicas := operator.NewAggregation("replicas", operator.AggSum, "replicas")
+	if r := pods.GroupBy("namespace").Aggregate(count); r.GetError() != nil || r.GetLen() != 0 {
+		t.Fatalf("aggregate non-existent table expect nothing, got %v %v", r.GetValue(), r.GetError())
+	}
+
+	if err := pods.Insert(
+		operator.M{"name": "p1", "namespace": "default", "replicas": 3},
+		operator.M{"name": "p2", "namespace": "kube-system", "replicas": 1},
+		operator.M{"name": "p3", "namespace": "default", "replicas": 2},
+		operator.M{"name": "p4", "replicas": 4},
+	).GetError(); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	r := pods.GroupBy("namespace").OrderBy("-count").Aggregate(count, replicas)
+	expect := []interface{}{
+		map[string]interface{}{"namespace": "default", "count": int64(2), "replicas": float64(5)},
+		map[string]interface{}{"namespace": nil, "count": int64(1), "replicas": float64(4)},
+		map[string]interface{}{"namespace": "kube-system", "count": int64(1), "replicas": float64(1)},
+	}
+	if r.GetError() != nil || !reflect.DeepEqual(r.GetValue(), expect) {
+		t.Errorf("aggregate expect %v, got %v %v", expect, r.GetValue(), r.GetError())
+	}
+
+	max := operator.NewAggregation("", operator.AggMax, "replicas")
+	r = pods.Filter(operator.NewCondition(operator.Eq, operator.M{"namespace": "nothing"})).Aggregate(count, max)
+	if r.GetError() != nil || r.GetLen() != 0 {
+		t.Errorf("aggregate without groups and matches expect nothing, got %v %v", r.GetValue(), r.GetError())
+	}
+	r = pods.Aggregate(count, max)
+	expect = []interface{}{map[string]interface{}{"count": int64(4), "max:replicas": float64(4)}}
+	if r.GetError() != nil || !reflect.DeepEqual(r.GetValue(), expect) {
+		t.Errorf("aggregate without groups expect %v, got %v %v", expect, r.GetValue(), r.GetError())
+	}
+}
+
+func TestTankWatch(t *testing.T) {
+	tank, clean := newTestTank(t, "watch")
+	defer clean()
+
+	pods := tank.From("pod")
+	pods.Insert(operator.M{"name": "p0"})
+
+	event, cancel := pods.Watch(&operator.WatchOptions{MaxEvents: 3, MustDiff: "status"})
+	defer cancel()
+	// wait for the watch beginning
+	time.Sleep(100 * time.Millisecond)
+
+	pods.Insert(operator.M{"name": "p1"}, operator.M{"name": "p2", "status": "Pending"})
+	tank.From("node").Insert(operator.M{"name": "n1", "status": "Ready"})
+	p2 := operator.NewCondition(operator.Eq, operator.M{"name": "p2"})
+	pods.Filter(p2).Update(operator.M{"status": "Running"})
+	pods.Filter(p2).Remove()
+
+	expect := []struct {
+		t      operator.EventType
+		status interface{}
+	}{
+		{operator.Add, "Pending"},
+		{operator.Chg, "Running"},
+		{operator.Del, "Running"},
+	}
+	for i, e := range expect {
+		select {
+		case ev := <-event:
+			if ev.Type != e.t || ev.Value["name"] != "p2" || ev.Value["status"] != e.status {
+				t.Errorf("event %d expect %s %v, got %s %v", i, e.t, e.status, ev.Type, ev.Value)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d timeout", i)
+		}
+	}
+	select {
+	case ev := <-event:
+		if ev != operator.EventWatchBreak {
+			t.Errorf("expect watch break after max events, got %v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch break timeout")
+	}
+}
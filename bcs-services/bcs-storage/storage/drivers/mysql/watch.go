@@ -0,0 +1,285 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bk-bcs/bcs-common/common/blog"
+	"bk-bcs/bcs-services/bcs-storage/storage/operator"
+)
+
+const (
+	// the interval of polling the change log
+	watchPollGap = time.Second
+	// the max number of changes fetched in one poll
+	watchBatchSize = 1000
+	// how long a missing id of the change log is read again, the id is allocated before the
+	// transaction commits, so a change may be visible later than the ones after it
+	watchSettleGap = 5 * time.Second
+)
+
+type watchHandler struct {
+	opts  *operator.WatchOptions
+	event chan *operator.Event
+
+	driver   *originDriver
+	table    string
+	diffTree []string
+}
+
+type change struct {
+	id    int64
+	tbl   string
+	op    string
+	docID int64
+	data  []byte
+}
+
+// changeCursor is the position of the change log read by a watcher, the ids skipped by
+// the changes read are kept as gaps with the time they are found, until they show up or settle
+type changeCursor struct {
+	last int64
+	gaps map[int64]time.Time
+}
+
+func newChangeCursor(last int64) *changeCursor {
+	return &changeCursor{last: last, gaps: make(map[int64]time.Time)}
+}
+
+// pending returns the gaps in order which are not settled at now
+func (cc *changeCursor) pending(now time.Time) []int64 {
+	var ids []int64
+	for id, found := range cc.gaps {
+		if now.Sub(found) >= watchSettleGap {
+			delete(cc.gaps, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// accept moves the cursor to the change, and returns false if the change has been read
+func (cc *changeCursor) accept(id int64, now time.Time) bool {
+	if id > cc.last {
+		for missing := cc.last + 1; missing < id; missing++ {
+			cc.gaps[missing] = now
+		}
+		cc.last = id
+		return true
+	}
+	if _, ok := cc.gaps[id]; ok {
+		delete(cc.gaps, id)
+		return true
+	}
+	return false
+}
+
+func newWatchHandler(opts *operator.WatchOptions, tank *mysqlTank) *watchHandler {
+	wh := &watchHandler{
+		opts:   opts,
+		driver: tank.driver,
+	}
+	if tank.dbName != "" && tank.cName != "" {
+		wh.table = tank.table()
+	}
+	if opts.MustDiff != "" {
+		wh.diffTree = strings.Split(opts.MustDiff, ".")
+	}
+	return wh
+}
+
+// isDiff checks whether the document contains the keys of diffTree,
+// the change log keeps the whole document instead of the changed part
+func (wh *watchHandler) isDiff(eventType operator.EventType, doc map[string]interface{}) bool {
+	if eventType == operator.Del {
+		return true
+	}
+	var d interface{} = doc
+	for _, t := range wh.diffTree {
+		md, ok := d.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if d = md[t]; d == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (wh *watchHandler) watch() (event chan *operator.Event, cancel context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	event = make(chan *operator.Event, 1000)
+	wh.event = event
+	go wh.watching(ctx)
+	return
+}
+
+func (wh *watchHandler) watching(ctx context.Context) {
+	if wh.driver == nil || wh.table == "" {
+		blog.Errorf("mysql watching | driver is not init or dbName or cName is empty")
+		wh.event <- operator.EventWatchBreak
+		return
+	}
+
+	last, err := wh.lastChangeID()
+	if err != nil {
+		blog.Errorf("mysql watching | get last change of %s failed: %v", wh.table, err)
+		wh.event <- operator.EventWatchBreak
+		return
+	}
+	blog.Infof("mysql watching | begin to watch: %s", wh.table)
+	defer func() {
+		wh.event <- operator.EventWatchBreak
+		blog.Infof("mysql watching | end watch: %s", wh.table)
+	}()
+
+	var timeout <-chan time.Time
+	var timer *time.Timer
+	if wh.opts.Timeout > 0 {
+		timer = time.NewTimer(wh.opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	ticker := time.NewTicker(watchPollGap)
+	defer ticker.Stop()
+
+	cursor := newChangeCursor(last)
+	var eventsNumber uint
+	for {
+		if wh.opts.MaxEvents > 0 && eventsNumber >= wh.opts.MaxEvents {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		changes, err := wh.fetchChanges(cursor.last, cursor.pending(now))
+		if err != nil {
+			blog.Errorf("mysql watching | fetch changes of %s failed: %v", wh.table, err)
+			continue
+		}
+		for _, c := range changes {
+			// the ids are continuous across all tables, only the changes of watching table are sent
+			if !cursor.accept(c.id, now) || c.tbl != wh.table {
+				continue
+			}
+			e := wh.toEvent(c)
+			if e == nil {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case wh.event <- e:
+			}
+			eventsNumber++
+			if wh.opts.MaxEvents > 0 && eventsNumber >= wh.opts.MaxEvents {
+				return
+			}
+
+			// the waiting time is counted from the last event
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(wh.opts.Timeout)
+			}
+		}
+	}
+}
+
+func (wh *watchHandler) toEvent(c *change) *operator.Event {
+	var eventType operator.EventType
+	switch c.op {
+	case changeOpAdd:
+		eventType = operator.Add
+	case changeOpDel:
+		eventType = operator.Del
+	case changeOpChg:
+		eventType = operator.Chg
+	default:
+		return nil
+	}
+
+	// If SelfOnly is true means the watcher only concern the change of node itself,
+	// and its eventType should be EventSelfChange.
+	// Others such as children change, children add, children delete will be ignored.
+	if wh.opts.SelfOnly && eventType != operator.SChg {
+		return nil
+	}
+
+	doc, err := decodeDocument(c.docID, c.data)
+	if err != nil {
+		blog.Errorf("mysql watching | decode change %d of %s failed: %v", c.id, wh.table, err)
+		return nil
+	}
+
+	// If MustDiff is set and the document does not contain the keys of diffTree, then continue
+	if !wh.isDiff(eventType, doc) {
+		return nil
+	}
+	return &operator.Event{Type: eventType, Value: doc}
+}
+
+// lastChangeID returns the id of the latest change, only the changes after it will be watched
+func (wh *watchHandler) lastChangeID() (int64, error) {
+	var id int64
+	err := wh.driver.pool.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(`id`), 0) FROM %s", quoteIdent(changeTable))).Scan(&id)
+	return id, err
+}
+
+// fetchChanges returns the changes of all tables after last and the ones of gaps in order,
+// the document is only read for the watching table
+func (wh *watchHandler) fetchChanges(last int64, gaps []int64) ([]*change, error) {
+	where := "`id` > ?"
+	args := []interface{}{wh.table, last}
+	if len(gaps) > 0 {
+		where += " OR `id` IN (?" + strings.Repeat(", ?", len(gaps)-1) + ")"
+		for _, id := range gaps {
+			args = append(args, id)
+		}
+	}
+	rows, err := wh.driver.pool.Query(fmt.Sprintf(
+		"SELECT `id`, `tbl`, `op`, `doc_id`, CASE WHEN `tbl` = ? THEN `data` ELSE '' END FROM %s "+
+			"WHERE %s ORDER BY `id` LIMIT %d", quoteIdent(changeTable), where, watchBatchSize+len(gaps)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*change
+	for rows.Next() {
+		c := &change{}
+		if err = rows.Scan(&c.id, &c.tbl, &c.op, &c.docID, &c.data); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
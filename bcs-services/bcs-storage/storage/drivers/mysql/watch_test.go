@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChangeCursor(t *testing.T) {
+	now := time.Now()
+	cc := newChangeCursor(10)
+	if !cc.accept(11, now) || !cc.accept(14, now) || cc.last != 14 {
+		t.Errorf("accept() of new changes failed! \nresult:\n%d\nexpect:\n%d\n", cc.last, 14)
+	}
+	if r := cc.pending(now); !reflect.DeepEqual(r, []int64{12, 13}) {
+		t.Errorf("pending() failed! \nresult:\n%v\nexpect:\n%v\n", r, []int64{12, 13})
+	}
+
+	// the change committed late is read once, and the ones read are skipped
+	if !cc.accept(13, now) || cc.accept(13, now) || cc.accept(11, now) || cc.last != 14 {
+		t.Errorf("accept() of gaps failed")
+	}
+	if r := cc.pending(now.Add(time.Second)); !reflect.DeepEqual(r, []int64{12}) {
+		t.Errorf("pending() after gap filled failed! \nresult:\n%v\nexpect:\n%v\n", r, []int64{12})
+	}
+
+	// the gap is given up after settled
+	if r := cc.pending(now.Add(watchSettleGap)); len(r) != 0 || cc.accept(12, now) {
+		t.Errorf("pending() after settled failed! \nresult:\n%v\n", r)
+	}
+}
@@ -95,6 +95,10 @@ func (s *scope) do() *scope {
 		s.doGetTableV()
 	case operator.SetTableV:
 		s.doSetTableV()
+	case operator.Aggregate:
+		s.err = storageErr.AggregateNotSupported
+	case operator.Increase:
+		s.err = storageErr.IncreaseNotSupported
 	default:
 		s.err = storageErr.UnknownOperationType
 	}
@@ -301,6 +301,11 @@ func (zt *zkTank) Filter(cond *operator.Condition, args ...interface{}) operator
 	return zt.clone().search.combineCondition(cond).tank
 }
 
+// GroupBy Tank implementation, NOT INVOLVED
+func (zt *zkTank) GroupBy(key ...string) operator.Tank {
+	return zt.clone()
+}
+
 // Count Tank implementation
 func (zt *zkTank) Count() operator.Tank {
 	return zt.clone().newScope(operator.Count).tank
@@ -341,6 +346,16 @@ func (zt *zkTank) RemoveAll(args ...interface{}) operator.Tank {
 	return zt.clone().newScope(operator.RemoveAll).tank
 }
 
+// Aggregate Tank implementation, NOT INVOLVED
+func (zt *zkTank) Aggregate(aggregations ...*operator.Aggregation) operator.Tank {
+	return zt.clone().newScope(operator.Aggregate).tank
+}
+
+// Increase Tank implementation, NOT INVOLVED
+func (zt *zkTank) Increase(key string, n int64) operator.Tank {
+	return zt.clone().newScope(operator.Increase).tank
+}
+
 // Watch Tank implementation
 func (zt *zkTank) Watch(opts *operator.WatchOptions) (chan *operator.Event, context.CancelFunc) {
 	return nil, nil
@@ -51,4 +51,14 @@ var (
 	ResourceDoesNotExist         = &StorageError{Code: common.AdditionErrorCode + 6319, Message: "resource does not exist"}
 	RemoveLessThanMatch          = &StorageError{Code: common.AdditionErrorCode + 6320, Message: "remove less than match"}
 	UpdateLessThanMatch          = &StorageError{Code: common.AdditionErrorCode + 6321, Message: "update less than match"}
+	MysqlDriverNotExist          = &StorageError{Code: common.AdditionErrorCode + 6322, Message: "Mysql driver does not exist"}
+	MysqlTankNotInit             = &StorageError{Code: common.AdditionErrorCode + 6323, Message: "Mysql tank does not init"}
+	MysqlDriverAlreadyInPool     = &StorageError{Code: common.AdditionErrorCode + 6324, Message: "mysql driver already in pool"}
+	MysqlTableNoFound            = &StorageError{Code: common.AdditionErrorCode + 6325, Message: "mysql table no found"}
+	ResourceVersionTooOld        = &StorageError{Code: common.AdditionErrorCode + 6326, Message: "resource version is too old, relist and watch again"}
+	AggregateNotSupported        = &StorageError{Code: common.AdditionErrorCode + 6327, Message: "Aggregate is not supported by this driver"}
+	AggregationInvalid           = &StorageError{Code: common.AdditionErrorCode + 6328, Message: "aggregation is invalid"}
+	IncreaseNotSupported         = &StorageError{Code: common.AdditionErrorCode + 6330, Message: "Increase is not supported by this driver"}
+	AllocateVersionFailed        = &StorageError{Code: common.AdditionErrorCode + 6331, Message: "failed to allocate resource version"}
+	ResourceVersionConflict      = &StorageError{Code: common.AdditionErrorCode + 6332, Message: "resource is changed by others while writing"}
 )
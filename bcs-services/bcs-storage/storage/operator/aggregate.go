@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making Blueking Container Service available.
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package operator
+
+type AggregateFunc string
+
+const (
+	AggCount AggregateFunc = "count"
+	AggSum   AggregateFunc = "sum"
+	AggAvg   AggregateFunc = "avg"
+	AggMin   AggregateFunc = "min"
+	AggMax   AggregateFunc = "max"
+)
+
+// Aggregation defined a value calculated from each group of Aggregate().
+// The value is set to the key Name of the group result.
+type Aggregation struct {
+	Name string
+	Func AggregateFunc
+
+	// The field to be calculated, which is not needed by AggCount
+	Field string
+}
+
+// NewAggregation return a new Aggregation, the name will be "func" for count and "func:field" for others if not provided
+func NewAggregation(name string, f AggregateFunc, field string) *Aggregation {
+	if name == "" {
+		name = string(f)
+		if f != AggCount {
+			name += ":" + field
+		}
+	}
+	return &Aggregation{Name: name, Func: f, Field: field}
+}
+
+// IsValid check if the aggregation can be done by drivers
+func (a *Aggregation) IsValid() bool {
+	if a == nil || a.Name == "" {
+		return false
+	}
+	switch a.Func {
+	case AggCount:
+		return true
+	case AggSum, AggAvg, AggMin, AggMax:
+		return a.Field != ""
+	}
+	return false
+}
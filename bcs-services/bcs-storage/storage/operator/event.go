@@ -32,6 +32,10 @@ type WatchOptions struct {
 	// The value-change event will be checked if it's different from last status. If not then this event
 	// will be ignored. And it will not trigger timeout reset.
 	MustDiff string `json:"mustDiff"`
+
+	// Resume the watch from the resource version returned by list or the last received event.
+	// The events after it are replayed from history, 0 for watching from now.
+	ResourceVersion uint64 `json:"resourceVersion"`
 }
 
 type EventType int32
@@ -43,6 +47,7 @@ const (
 	Chg
 	SChg
 	Brk EventType = -1
+	Exp EventType = -2
 )
 
 func (et EventType) String() string {
@@ -57,12 +62,16 @@ var (
 		Chg:  "EventChange",
 		SChg: "EventSelfChange",
 		Brk:  "EventWatchBreak",
+		Exp:  "EventWatchExpired",
 	}
 )
 
 type Event struct {
 	Type  EventType `json:"type"`
 	Value M         `json:"value"`
+
+	// the resource version of this change, only set when the change is recorded in history
+	ResourceVersion uint64 `json:"resourceVersion,omitempty"`
 }
 
 var (
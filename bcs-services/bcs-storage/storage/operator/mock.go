@@ -22,6 +22,9 @@ type MockTank struct {
 	Length     int
 	ChangeInfo *ChangeInfo
 	Err        error
+
+	// Counter is increased by Increase(), which returns a tank with the value of it
+	Counter int64
 }
 
 // implements type GetNewTank, return a mock function which will return the provided mock tank
@@ -102,6 +105,10 @@ func (mt *MockTank) Filter(cond *Condition, args ...interface{}) Tank {
 	return mt
 }
 
+func (mt *MockTank) GroupBy(key ...string) Tank {
+	return mt
+}
+
 func (mt *MockTank) Count() Tank {
 	return mt
 }
@@ -134,6 +141,15 @@ func (mt *MockTank) RemoveAll(args ...interface{}) Tank {
 	return mt
 }
 
+func (mt *MockTank) Aggregate(aggregations ...*Aggregation) Tank {
+	return mt
+}
+
+func (mt *MockTank) Increase(key string, n int64) Tank {
+	mt.Counter += n
+	return &MockTank{Value: []interface{}{map[string]interface{}{key: mt.Counter}}, Length: 1, Err: mt.Err}
+}
+
 func (mt *MockTank) Watch(opts *WatchOptions) (chan *Event, context.CancelFunc) {
 	return nil, nil
 }
@@ -29,6 +29,9 @@ type DBInfo struct {
 	ListenerName string
 	MaxOpenConn  int
 	MaxIdleConn  int
+
+	// Schema is the sql database which keeps the tables of all databases above, sql drivers only
+	Schema string
 }
 
 type ChangeInfo struct {
@@ -54,6 +57,8 @@ const (
 	SetTableV OperationType = "setTableV"
 	GetTableV OperationType = "getTableV"
 	Tail      OperationType = "tail"
+	Aggregate OperationType = "aggregate"
+	Increase  OperationType = "increase"
 )
 
 type M map[string]interface{}
@@ -128,6 +133,9 @@ type Tank interface {
 	// Add filter by *Condition, multi-liner-filter will be combine with "AND"
 	Filter(cond *Condition, args ...interface{}) Tank
 
+	// Set group keys for Aggregate(), the documents with the same values of keys are in one group
+	GroupBy(key ...string) Tank
+
 	// Do the count query
 	Count() Tank
 
@@ -152,6 +160,15 @@ type Tank interface {
 	// Do the remove and remove all matched thing
 	RemoveAll(args ...interface{}) Tank
 
+	// Do the aggregation to each group according to the filter chain before.
+	// Every group returns a value contains the group keys and the names of aggregations,
+	// OrderBy(), Offset() and Limit() are applied to the groups
+	Aggregate(aggregations ...*Aggregation) Tank
+
+	// Do the atomic increase of the number in key by n to the first match according to the filter chain before,
+	// and insert the fields of filter with key = n if nothing matches. The value is the document after increasing
+	Increase(key string, n int64) Tank
+
 	// Watch table then return a chan Event.
 	Watch(opts *WatchOptions) (chan *Event, context.CancelFunc)
 }
@@ -14,6 +14,7 @@
 package bcsstorage
 
 import (
+	"context"
 	"net/http"
 	"net/http/pprof"
 	"strconv"
@@ -24,6 +25,7 @@ import (
 	"bk-bcs/bcs-common/common/types"
 	"bk-bcs/bcs-services/bcs-storage/app/options"
 	"bk-bcs/bcs-services/bcs-storage/storage/actions"
+	"bk-bcs/bcs-services/bcs-storage/storage/actions/lib"
 	"bk-bcs/bcs-services/bcs-storage/storage/apiserver"
 	"bk-bcs/bcs-services/bcs-storage/storage/rdiscover"
 
@@ -110,6 +112,12 @@ func (s *StorageServer) Start() error {
 	// startDaemon
 	actions.StartActionDaemon()
 
+	// compact the history and snapshots of dynamic resources until the server exits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lib.StartHistoriesCompaction(ctx)
+	lib.StartSnapshotsCompaction(ctx)
+
 	select {
 	case err := <-chErr:
 		blog.Errorf("exit! err:%s", err.Error())
@@ -4,8 +4,11 @@ set -e
 SKIP_DIR="\
 bk-bcs/bcs-mesos/bcs-scheduler/src/mesosproto \
 bk-bcs/bcs-mesos/bcs-container-executor/mesos \
-bk-bcs/bcs-mesos/bcs-process-executor/process-executor/protobuf \
-bk-bcs/bcs-mesos/bcs-process-daemon"
+bk-bcs/bcs-mesos/bcs-process-executor/process-executor/protobuf"
+
+# the vendored go-sqlite3 ships without the amalgamation, so link against
+# the system libsqlite3 to run the bcs-storage mysql driver contract tests.
+TAGS="libsqlite3"
 
 PACKAGES=$(go list ../...)
 for dir in $SKIP_DIR;do
@@ -14,14 +17,14 @@ done
 
 #test:
 	echo "go test"
-	go test -cover=true $PACKAGES
+	go test -tags "${TAGS}" -cover=true $PACKAGES
 
 #collect-cover-data:
 	echo "collect-cover-data"
 	echo "mode: count" > coverage-all.out
 	for pkg in $PACKAGES;do
 		echo "collect package:"${pkg}
-		go test -v -coverprofile=coverage.out -covermode=count ${pkg};\
+		go test -tags "${TAGS}" -v -coverprofile=coverage.out -covermode=count ${pkg};\
 		if [ -f coverage.out ]; then\
 			tail -n +2 coverage.out >> coverage-all.out;\
 		fi\
@@ -0,0 +1,48 @@
+#!/bin/sh
+# regenerate api.pb.go of CRI runtime v1alpha2 used by bcs-container-executor from api.proto.
+# protoc and protoc-gen-gogo of github.com/gogo/protobuf v1.3.2 are required in PATH,
+# and github.com/gogo/protobuf must be in GOPATH for gogo.proto.
+set -e
+
+ROOT=$(cd $(dirname $0)/..; pwd)
+CRI_API=${ROOT}/bcs-mesos/bcs-container-executor/container/cri/runtime/v1alpha2
+
+GOGO_SRC=""
+for dir in $(go env GOPATH | tr ':' ' '); do
+	if [ -f "${dir}/src/github.com/gogo/protobuf/gogoproto/gogo.proto" ]; then
+		GOGO_SRC=${dir}/src
+		break
+	fi
+done
+if [ -z "${GOGO_SRC}" ]; then
+	echo "github.com/gogo/protobuf not found in GOPATH"
+	exit 1
+fi
+
+protoc -I "${CRI_API}" -I "${GOGO_SRC}" -I "${GOGO_SRC}/github.com/gogo/protobuf/protobuf" \
+	--gogo_out=plugins=grpc:"${CRI_API}" "${CRI_API}/api.proto"
+
+# api.proto is from kubernetes, keep the license of it
+TMP=$(mktemp)
+cat > ${TMP} <<BOILERPLATE
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+BOILERPLATE
+cat "${CRI_API}/api.pb.go" >> ${TMP}
+mv ${TMP} "${CRI_API}/api.pb.go"
+chmod 644 "${CRI_API}/api.pb.go"
+gofmt -w "${CRI_API}/api.pb.go"